@@ -37,6 +37,7 @@ import (
 	"istio.io/istio/cni/pkg/util"
 	"istio.io/istio/pkg/collateral"
 	"istio.io/istio/pkg/ctrlz"
+	"istio.io/istio/pkg/ctrlz/fw"
 	"istio.io/istio/pkg/env"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/version"
@@ -67,7 +68,7 @@ var rootCmd = &cobra.Command{
 		ctx := c.Context()
 
 		// Start controlz server
-		_, _ = ctrlz.Run(ctrlzOptions, nil)
+		_, _ = ctrlz.Run(ctrlzOptions, []fw.Topic{nodeagent.ZtunnelRolloutTopic(), nodeagent.CaptureExplainTopic()})
 
 		var cfg *config.Config
 		if cfg, err = constructConfig(); err != nil {
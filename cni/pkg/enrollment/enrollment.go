@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package enrollment defines the per-node ambient enrollment status that the istio-cni
+// node agent publishes, and that istioctl reads back.
+package enrollment
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+)
+
+const (
+	// StatusLabel marks the Leases holding the enrollment status of a node, so they can be listed
+	// regardless of which namespace istio-cni is installed in.
+	StatusLabel = "istio.io/cni-enrollment-status"
+	// StatusAnnotation is the Lease annotation holding the JSON encoded NodeStatus of a node.
+	StatusAnnotation = "ambient.istio.io/enrollment-status"
+
+	// MaxStatusSize bounds the encoded status, well within the annotation size limit of the Lease.
+	MaxStatusSize = 64 * 1024
+
+	leasePrefix = "istio-cni-enrollment-"
+
+	// PodConditionType is the pod condition the node agent maintains for every pod it tries to enroll.
+	PodConditionType corev1.PodConditionType = "ambient.istio.io/Enrolled"
+)
+
+// Status is the definition of StatusAnnotation, registered along with the istio.io/api annotations.
+var Status = annotation.Instance{
+	Name: StatusAnnotation,
+	Description: "The ambient enrollment status of the pods of a node, as JSON. Written by the istio-cni node " +
+		"agent on the Lease of its node, and read by istioctl ztunnel-config enrollment.",
+	FeatureStatus: annotation.Alpha,
+	Hidden:        true,
+	Resources:     []annotation.ResourceTypes{annotation.Any},
+}
+
+// Phase is the outcome of the most recent enrollment attempt for a pod.
+type Phase string
+
+const (
+	// PhaseEnrolled means redirection is in place, ztunnel acked the pod, and the pod is annotated.
+	PhaseEnrolled Phase = "Enrolled"
+	// PhasePending means redirection may be in place, but the enrollment has not completed yet and will be retried.
+	PhasePending Phase = "Pending"
+	// PhaseFailed means the enrollment failed before any redirection was installed and will not be retried.
+	PhaseFailed Phase = "Failed"
+	// PhaseRemoveFailed means removing the pod from the mesh failed and will be retried.
+	PhaseRemoveFailed Phase = "RemoveFailed"
+)
+
+// AckState is the state of the ztunnel acknowledgement for a pod.
+type AckState string
+
+const (
+	// AckAcked means ztunnel accepted the pod.
+	AckAcked AckState = "Acked"
+	// AckRejected means ztunnel responded with an error.
+	AckRejected AckState = "Rejected"
+	// AckNoResponse means the pod was sent, but no valid response was received.
+	AckNoResponse AckState = "NoResponse"
+	// AckNotConnected means no ztunnel was connected to the node agent.
+	AckNotConnected AckState = "NotConnected"
+	// AckNotSent means the enrollment failed before the pod was sent to ztunnel.
+	AckNotSent AckState = "NotSent"
+)
+
+// PodStatus is the enrollment status of a single pod.
+type PodStatus struct {
+	Namespace  string   `json:"namespace"`
+	Name       string   `json:"name"`
+	UID        string   `json:"uid"`
+	Phase      Phase    `json:"phase"`
+	ZtunnelAck AckState `json:"ztunnelAck"`
+	LastError  string   `json:"lastError,omitempty"`
+	// Attempts counts the enrollment attempts since the last phase transition.
+	Attempts           int       `json:"attempts"`
+	LastAttemptTime    time.Time `json:"lastAttemptTime"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// NodeStatus aggregates the enrollment status of every pod the node agent is tracking.
+type NodeStatus struct {
+	Node       string        `json:"node"`
+	UpdateTime time.Time     `json:"updateTime"`
+	Summary    map[Phase]int `json:"summary"`
+	Pods       []PodStatus   `json:"pods"`
+	// Truncated is set when enrolled pods were left out of Pods to fit within MaxStatusSize.
+	// Summary always counts every tracked pod.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Encode serializes the status for storage under StatusAnnotation.
+// If the status is larger than MaxStatusSize, enrolled pods are dropped from the pod list first,
+// since those are the least interesting when debugging.
+func (s *NodeStatus) Encode() (string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	if len(b) <= MaxStatusSize {
+		return string(b), nil
+	}
+	trimmed := *s
+	trimmed.Truncated = true
+	trimmed.Pods = nil
+	for _, p := range s.Pods {
+		if p.Phase != PhaseEnrolled {
+			trimmed.Pods = append(trimmed.Pods, p)
+		}
+	}
+	b, err = json.Marshal(trimmed)
+	if err != nil {
+		return "", err
+	}
+	if len(b) > MaxStatusSize {
+		return "", fmt.Errorf("enrollment status for node %s is %d bytes, over the %d byte limit", s.Node, len(b), MaxStatusSize)
+	}
+	return string(b), nil
+}
+
+// LeaseName returns the name of the Lease holding the status of the given node.
+func LeaseName(node string) string {
+	return leasePrefix + node
+}
+
+// FromLease decodes the status the node agent stored on a Lease. It returns nil if the Lease has no status.
+func FromLease(lease *coordinationv1.Lease) (*NodeStatus, error) {
+	raw, ok := lease.Annotations[StatusAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	s := &NodeStatus{}
+	if err := json.Unmarshal([]byte(raw), s); err != nil {
+		return nil, fmt.Errorf("failed to decode enrollment status of lease %s/%s: %v", lease.Namespace, lease.Name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package explain defines the dry-run rendering of a pod's traffic redirection rules, which the
+// istio-cni node agent serves for ambient pods, and istioctl renders itself for sidecar pods.
+package explain
+
+import (
+	"sigs.k8s.io/knftables"
+
+	"istio.io/istio/pkg/slices"
+	iptablesbuilder "istio.io/istio/tools/istio-iptables/pkg/builder"
+	nftablesbuilder "istio.io/istio/tools/istio-nftables/pkg/builder"
+)
+
+const (
+	ModeAmbient = "ambient"
+	ModeSidecar = "sidecar"
+
+	BackendIptables = "iptables"
+	BackendNftables = "nftables"
+)
+
+// Explanation is the set of redirection rules for a pod, in the order they are applied, without having
+// applied them.
+type Explanation struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Mode is either ModeAmbient or ModeSidecar.
+	Mode string `json:"mode,omitempty"`
+	// Backend is either BackendIptables or BackendNftables.
+	Backend string `json:"backend"`
+	Rules   []Rule `json:"rules"`
+	// Notes describe what the rendering could not know, and so may differ from the rules actually applied.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// Rule is a single rule, in the form the backend would apply it, and why it exists.
+type Rule struct {
+	// Family is "ipv4" or "ipv6" for iptables, and "inet" for nftables, whose tables handle both.
+	Family string `json:"family"`
+	Table  string `json:"table"`
+	Chain  string `json:"chain"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// FromIptables converts the rules explained by an iptables rule builder for the given family, "ipv4" or "ipv6".
+func FromIptables(family string, rules []iptablesbuilder.ExplainedRule) []Rule {
+	return slices.Map(rules, func(r iptablesbuilder.ExplainedRule) Rule {
+		return Rule{
+			Family: family,
+			Table:  r.Table,
+			Chain:  r.Chain,
+			Rule:   r.Rule,
+			Reason: r.Reason,
+		}
+	})
+}
+
+// FromNftables converts the rules explained by an nftables rule builder.
+func FromNftables(rules []nftablesbuilder.ExplainedRule) []Rule {
+	return slices.Map(rules, func(r nftablesbuilder.ExplainedRule) Rule {
+		return Rule{
+			Family: string(knftables.InetFamily),
+			Table:  r.Table,
+			Chain:  r.Chain,
+			Rule:   r.Rule,
+			Reason: r.Reason,
+		}
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/cni/pkg/explain"
 	"istio.io/istio/cni/pkg/ipset"
 	"istio.io/istio/cni/pkg/scopes"
 	"istio.io/istio/cni/pkg/util"
@@ -39,6 +40,26 @@ const (
 	ChainHostPostrouting = "ISTIO_POSTRT"
 )
 
+// Reasons attached to the in-pod rules when they are explained. They are shared with the nftables
+// implementation, which installs equivalent rules.
+var (
+	ReasonJumpToIstioChains = "Jump from the built-in chains to the Istio chains, which hold the rest of the rules"
+	ReasonVirtualInterface  = fmt.Sprintf("Treat inbound TCP traffic on a rerouted virtual interface as outbound, "+
+		"and send it to ztunnel outbound port %d", config.ZtunnelOutboundPort)
+	ReasonVirtualInterfaceRT = "Stop processing virtual interface traffic once it has been redirected"
+	ReasonSetConnmark        = "Remember on the connection that ztunnel marked its packets, so replies can be recognized"
+	ReasonProbeInbound       = "Do not capture kubelet health probes, which arrive from the SNAT-ed host probe address"
+	ReasonProbeReply         = "Do not capture replies to kubelet health probes"
+	ReasonInboundRedirect    = fmt.Sprintf("Redirect inbound TCP traffic not sent by ztunnel to ztunnel inbound plaintext port %d; "+
+		"port %d (HBONE) reaches ztunnel directly", config.ZtunnelInboundPlaintextPort, config.ZtunnelInboundPort)
+	ReasonRestoreConnmark  = "Restore the ztunnel mark on outbound packets of connections ztunnel marked"
+	ReasonDNSRedirect      = fmt.Sprintf("DNS capture is enabled, redirect DNS queries to the ztunnel DNS proxy on port %d", config.DNSCapturePort)
+	ReasonDNSConntrack     = "Keep ztunnel DNS queries to upstream resolvers in their own conntrack zone, to avoid UDP port collisions"
+	ReasonOutboundMarked   = "Do not capture outbound traffic sent by ztunnel itself"
+	ReasonOutboundLoopback = "Do not capture the application calling itself over loopback"
+	ReasonOutboundRedirect = fmt.Sprintf("Redirect outbound TCP traffic not sent by ztunnel to ztunnel outbound port %d", config.ZtunnelOutboundPort)
+)
+
 type IptablesConfigurator struct {
 	ext    dep.Dependencies
 	nlDeps NetlinkDependencies
@@ -178,7 +199,7 @@ func (cfg *IptablesConfigurator) CreateInpodRules(log *istiolog.Scope, podOverri
 	}
 
 	log.Debug("Adding iptables rules")
-	if err := cfg.executeCommands(log, builder); err != nil {
+	if err := cfg.executeCommands(log, builder, cfg.cfg.Reconcile); err != nil {
 		log.Errorf("failed to restore iptables rules: %v", err)
 		return err
 	}
@@ -186,6 +207,41 @@ func (cfg *IptablesConfigurator) CreateInpodRules(log *istiolog.Scope, podOverri
 	return nil
 }
 
+// CheckInpodRules reports whether the iptables rules in the pod have drifted from the rules
+// CreateInpodRules would install with the given overrides.
+// NOTE that this expects to be run from within the pod network namespace!
+func (cfg *IptablesConfigurator) CheckInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) (bool, error) {
+	builder := cfg.AppendInpodRules(podOverrides)
+	_, deltaExists := iptablescapture.VerifyIptablesState(log, cfg.ext, builder, &cfg.iptV, &cfg.ipt6V)
+	return deltaExists, nil
+}
+
+// RepairInpodRules reinstalls the in-pod rules, removing whatever Istio rules are currently present first.
+// Unlike CreateInpodRules, this always reconciles, since the existing rules are known to be wrong.
+// NOTE that this expects to be run from within the pod network namespace!
+func (cfg *IptablesConfigurator) RepairInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error {
+	builder := cfg.AppendInpodRules(podOverrides)
+
+	if err := cfg.addLoopbackRoute(); err != nil {
+		return err
+	}
+
+	if err := cfg.addInpodMarkIPRule(); err != nil {
+		return err
+	}
+
+	log.Debug("Reinstalling iptables rules")
+	return cfg.executeCommands(log, builder, true)
+}
+
+// ExplainInpodRules returns the rules CreateInpodRules would install with the given overrides, without
+// installing them, along with the reason each rule exists.
+func (cfg *IptablesConfigurator) ExplainInpodRules(podOverrides config.PodLevelOverrides) (*explain.Explanation, error) {
+	iptablesBuilder := cfg.AppendInpodRules(podOverrides)
+	rules := append(explain.FromIptables("ipv4", iptablesBuilder.ExplainV4()), explain.FromIptables("ipv6", iptablesBuilder.ExplainV6())...)
+	return &explain.Explanation{Backend: explain.BackendIptables, Rules: rules}, nil
+}
+
 func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOverrides) *builder.IptablesRuleBuilder {
 	var redirectDNS bool
 
@@ -208,6 +264,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// without polluting the main table too much.
 
 	// -t mangle -A PREROUTING -j ISTIO_PRERT
+	iptablesBuilder.WithReason(ReasonJumpToIstioChains)
 	iptablesBuilder.AppendRule(
 		"PREROUTING", "mangle",
 		"-j", ChainInpodPrerouting,
@@ -254,6 +311,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 			// and just shunt it directly to the outbound port of the proxy.
 			// Note that for now this explicitly excludes UDP traffic, as we can't proxy arbitrary UDP stuff,
 			// and this is a difference from the old sidecar `traffic.sidecar.istio.io/kubevirtInterfaces` annotation.
+			iptablesBuilder.WithReason(ReasonVirtualInterface)
 			iptablesBuilder.AppendRule(ChainInpodPrerouting, "nat",
 				"-i", fmt.Sprint(virtInterface),
 				"-p", "tcp",
@@ -267,6 +325,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 			// and not the top-level PREROUTING table like the kubevirt rule does.
 			// Returning from the top-level PREROUTING table would skip other people's PRERT rules unconditionally,
 			// which is unsafe (and should not be needed anyway) - if we really find ourselves needing to do that, we should ACCEPT inside our chain instead.
+			iptablesBuilder.WithReason(ReasonVirtualInterfaceRT)
 			iptablesBuilder.AppendRule(ChainInpodPrerouting, "nat",
 				"-i", fmt.Sprint(virtInterface),
 				"-p", "tcp",
@@ -278,6 +337,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: -A ISTIO_PRERT -m mark --mark 0x539/0xfff -j CONNMARK --set-xmark 0x111/0xfff
 	//
 	// DESC: If we have a packet mark, set a connmark.
+	iptablesBuilder.WithReason(ReasonSetConnmark)
 	iptablesBuilder.AppendRule(ChainInpodPrerouting, "mangle", "-m", "mark",
 		"--mark", inpodMark,
 		"-j", "CONNMARK",
@@ -294,6 +354,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// CLI: -t mangle -A ISTIO_PRERT -s fd16:9254:7127:1337:ffff:ffff:ffff:ffff -p tcp -m tcp --dport <PROBEPORT> -j ACCEPT
 		//
 		// DESC: If this is one of our node-probe ports and is from our SNAT-ed/"special" hostside IP, short-circuit out here
+		iptablesBuilder.WithReason(ReasonProbeInbound)
 		iptablesBuilder.AppendVersionedRule(cfg.cfg.HostProbeSNATAddress.String(), cfg.cfg.HostProbeV6SNATAddress.String(),
 			ChainInpodPrerouting, "nat",
 			"-s", iptablesconstants.IPVersionSpecific,
@@ -308,6 +369,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	//
 	// DESC: Anything coming BACK from the pod healthcheck port with a dest of our SNAT-ed hostside IP
 	// we also short-circuit.
+	iptablesBuilder.WithReason(ReasonProbeReply)
 	iptablesBuilder.AppendVersionedRule(cfg.cfg.HostProbeSNATAddress.String(), cfg.cfg.HostProbeV6SNATAddress.String(),
 		ChainInpodOutput, "nat",
 		"-d", iptablesconstants.IPVersionSpecific,
@@ -321,6 +383,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		//
 		// DESC: Anything that is not bound for localhost and does not have the mark, REDIRECT to ztunnel inbound plaintext port <INPLAINPORT>
 		// Skip 15008, which will go direct without redirect needed.
+		iptablesBuilder.WithReason(ReasonInboundRedirect)
 		iptablesBuilder.AppendVersionedRule("127.0.0.1/32", "::1/128",
 			ChainInpodPrerouting, "nat",
 			"!", "-d", iptablesconstants.IPVersionSpecific,
@@ -336,6 +399,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: -A ISTIO_OUTPUT -m connmark --mark 0x111/0xfff -j CONNMARK --restore-mark --nfmask 0xffffffff --ctmask 0xffffffff
 	//
 	// DESC: Propagate/restore connmark (if we had one) for outbound
+	iptablesBuilder.WithReason(ReasonRestoreConnmark)
 	iptablesBuilder.AppendRule(
 		ChainInpodOutput, "mangle",
 		"-m", "connmark",
@@ -350,6 +414,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// CLI: -A ISTIO_OUTPUT ! -o lo -p udp -m udp --dport 53 -j REDIRECT --to-port 15053
 		//
 		// DESC: If this is a UDP DNS request to a non-localhost resolver, send it to ztunnel DNS proxy port
+		iptablesBuilder.WithReason(ReasonDNSRedirect)
 		iptablesBuilder.AppendRule(
 			ChainInpodOutput, "nat",
 			"!", "-o", "lo",
@@ -376,6 +441,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// Assign packets between the proxy and upstream DNS servers to their own conntrack zones to avoid issues in port collision
 		// See https://github.com/istio/istio/issues/33469
 		// Proxy --> Upstream
+		iptablesBuilder.WithReason(ReasonDNSConntrack)
 		iptablesBuilder.AppendRule(
 			ChainInpodOutput, "raw",
 			"-p", "udp",
@@ -403,6 +469,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: -A ISTIO_OUTPUT -p tcp -m mark --mark 0x111/0xfff -j ACCEPT
 	//
 	// DESC: If this is outbound and has our mark, let it go.
+	iptablesBuilder.WithReason(ReasonOutboundMarked)
 	iptablesBuilder.AppendRule(
 		ChainInpodOutput, "nat",
 		"-p", "tcp",
@@ -413,6 +480,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 
 	// Do not redirect app calls to back itself via Ztunnel when using the endpoint address
 	// e.g. appN => appN by lo
+	iptablesBuilder.WithReason(ReasonOutboundLoopback)
 	iptablesBuilder.AppendVersionedRule("127.0.0.1/32", "::1/128",
 		ChainInpodOutput, "nat",
 		"!", "-d", iptablesconstants.IPVersionSpecific,
@@ -422,6 +490,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: -A ISTIO_OUTPUT ! -d 127.0.0.1/32 -p tcp -m mark ! --mark 0x539/0xfff -j REDIRECT --to-ports <OUTPORT>
 	//
 	// DESC: If this is outbound, not bound for localhost, and does not have our packet mark, redirect to ztunnel proxy <OUTPORT>
+	iptablesBuilder.WithReason(ReasonOutboundRedirect)
 	iptablesBuilder.AppendVersionedRule("127.0.0.1/32", "::1/128",
 		ChainInpodOutput, "nat",
 		"!", "-d", iptablesconstants.IPVersionSpecific,
@@ -434,7 +503,7 @@ func (cfg *IptablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	return iptablesBuilder
 }
 
-func (cfg *IptablesConfigurator) executeCommands(log *istiolog.Scope, iptablesBuilder *builder.IptablesRuleBuilder) error {
+func (cfg *IptablesConfigurator) executeCommands(log *istiolog.Scope, iptablesBuilder *builder.IptablesRuleBuilder, reconcile bool) error {
 	var execErrs []error
 	guardrails := false
 	defer func() {
@@ -448,11 +517,11 @@ func (cfg *IptablesConfigurator) executeCommands(log *istiolog.Scope, iptablesBu
 		}
 	}()
 	residueExists, deltaExists := iptablescapture.VerifyIptablesState(log, cfg.ext, iptablesBuilder, &cfg.iptV, &cfg.ipt6V)
-	if residueExists && deltaExists && !cfg.cfg.Reconcile {
+	if residueExists && deltaExists && !reconcile {
 		log.Warn("reconcile is needed but no-reconcile flag is set. Unexpected behavior may occur due to preexisting iptables rules")
 	}
 	// Cleanup Step
-	if (residueExists && deltaExists && cfg.cfg.Reconcile) || cfg.cfg.CleanupOnly {
+	if (residueExists && deltaExists && reconcile) || cfg.cfg.CleanupOnly {
 		// Apply safety guardrails
 		if !cfg.cfg.CleanupOnly {
 			log.Info("Setting up guardrails")
@@ -478,7 +547,7 @@ func (cfg *IptablesConfigurator) executeCommands(log *istiolog.Scope, iptablesBu
 		}
 
 		// Remove leftovers from non-matching istio iptables cfg
-		if cfg.cfg.Reconcile {
+		if reconcile {
 			log.Info("Performing cleanup of any unexpected leftovers from previous iptables executions")
 			cfg.cleanupIstioLeftovers(log, cfg.ext, iptablesBuilder, &cfg.iptV, &cfg.ipt6V)
 		}
@@ -577,7 +646,7 @@ func (cfg *IptablesConfigurator) CreateHostRulesForHealthChecks() error {
 	log.Info("Adding host netnamespace iptables rules")
 
 	return util.RunAsHost(func() error {
-		if err := cfg.executeCommands(log.WithLabels("component", "host"), builder); err != nil {
+		if err := cfg.executeCommands(log.WithLabels("component", "host"), builder, cfg.cfg.Reconcile); err != nil {
 			log.Errorf("failed to add host netnamespace iptables rules: %v", err)
 			return err
 		}
@@ -15,6 +15,8 @@
 package iptables
 
 import (
+	"bytes"
+	"io"
 	"net/netip"
 	"path/filepath"
 	"strings"
@@ -23,7 +25,10 @@ import (
 	"istio.io/istio/cni/pkg/config"
 	"istio.io/istio/cni/pkg/scopes"
 	testutil "istio.io/istio/pilot/test/util"
+	istiolog "istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/test/util/assert"
+	iptablesconstants "istio.io/istio/tools/istio-iptables/pkg/constants"
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
 )
 
@@ -116,6 +121,55 @@ func TestInvokedTwiceIsIdempotent(t *testing.T) {
 	}
 }
 
+// saveStub returns a fixed iptables-save output for IPv4, simulating the rules currently present in a pod.
+type saveStub struct {
+	dep.DependenciesStub
+	save string
+}
+
+func (s *saveStub) Run(logger *istiolog.Scope, quietLogging bool, cmd iptablesconstants.IptablesCmd,
+	iptVer *dep.IptablesVersion, stdin io.ReadSeeker, args ...string,
+) (*bytes.Buffer, error) {
+	out, err := s.DependenciesStub.Run(logger, quietLogging, cmd, iptVer, stdin, args...)
+	if cmd == iptablesconstants.IPTablesSave && iptVer.DetectedBinary == "iptables" {
+		return bytes.NewBufferString(s.save), nil
+	}
+	return out, err
+}
+
+func TestCheckAndRepairInpodRules(t *testing.T) {
+	cfg := constructTestConfig()
+	ext := &saveStub{}
+	iptConfigurator, _, _ := NewIptablesConfigurator(cfg, cfg, ext, ext, EmptyNlDeps())
+	expected := iptConfigurator.AppendInpodRules(config.PodLevelOverrides{}).BuildV4Restore()
+
+	ext.save = expected
+	drifted, err := iptConfigurator.CheckInpodRules(scopes.CNIAgent, config.PodLevelOverrides{})
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, false)
+
+	// Rules installed for different overrides are drift too.
+	drifted, err = iptConfigurator.CheckInpodRules(scopes.CNIAgent, config.PodLevelOverrides{IngressMode: true})
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, true)
+
+	// Something flushed one of our chains.
+	ext.save = strings.Join(slices.Filter(strings.Split(expected, "\n"), func(l string) bool {
+		return !strings.HasPrefix(l, "-A "+ChainInpodOutput)
+	}), "\n")
+	drifted, err = iptConfigurator.CheckInpodRules(scopes.CNIAgent, config.PodLevelOverrides{})
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, true)
+
+	// Repairing removes what is left before reinstalling, even though reconcile mode is off.
+	ext.ExecutedAll = nil
+	assert.NoError(t, iptConfigurator.RepairInpodRules(scopes.CNIAgent, config.PodLevelOverrides{}))
+	assert.Equal(t, slices.ContainsFunc(ext.ExecutedAll, func(c string) bool {
+		return strings.Contains(c, "-F "+ChainInpodOutput)
+	}), true)
+	assert.Equal(t, slices.Contains(ext.ExecutedAll, "COMMIT"), true)
+}
+
 func ipstr(ipv6 bool) string {
 	if ipv6 {
 		return "ipv6"
@@ -133,6 +187,33 @@ func compareToGolden(t *testing.T, ipv6 bool, name string, actual []string) {
 	testutil.CompareContent(t, gotBytes, goldenFile)
 }
 
+func TestExplainInpodRules(t *testing.T) {
+	for _, tt := range GetCommonInPodTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := constructTestConfig()
+			tt.config(cfg)
+			ext := &dep.DependenciesStub{}
+			iptConfigurator, _, _ := NewIptablesConfigurator(cfg, cfg, ext, ext, EmptyNlDeps())
+			ex, err := iptConfigurator.ExplainInpodRules(tt.podOverrides)
+			assert.NoError(t, err)
+			assert.Equal(t, len(ext.ExecutedAll), 0)
+
+			expected := iptConfigurator.AppendInpodRules(tt.podOverrides).BuildV4Restore()
+			for _, r := range ex.Rules {
+				if r.Family != "ipv4" {
+					continue
+				}
+				if r.Reason == "" {
+					t.Errorf("rule %q has no reason", r.Rule)
+				}
+				if !strings.Contains(expected, r.Rule) {
+					t.Errorf("explained rule %q is not installed", r.Rule)
+				}
+			}
+		})
+	}
+}
+
 func constructTestConfig() *config.AmbientConfig {
 	probeSNATipv4 := netip.MustParseAddr("169.254.7.127")
 	probeSNATipv6 := netip.MustParseAddr("e9ac:1e77:90ca:399f:4d6d:ece2:2f9b:3164")
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
 	"strings"
 
@@ -25,6 +26,7 @@ import (
 
 	"istio.io/istio/cni/pkg/config"
 	"istio.io/istio/cni/pkg/constants"
+	"istio.io/istio/cni/pkg/explain"
 	"istio.io/istio/cni/pkg/iptables"
 	"istio.io/istio/cni/pkg/scopes"
 	"istio.io/istio/cni/pkg/util"
@@ -132,6 +134,67 @@ func (cfg *NftablesConfigurator) CreateInpodRules(log *istiolog.Scope, podOverri
 }
 
 func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOverrides) (*knftables.Transaction, error) {
+	return cfg.executeCommands(cfg.buildInpodRules(podOverrides))
+}
+
+// CheckInpodRules reports whether the nftables rules in the pod have drifted from the rules
+// CreateInpodRules would install with the given overrides.
+//
+// nft normalizes rules when listing them, so the listed rule text never matches what we programmed.
+// Instead, this checks that each of our tables holds the expected number of rules in each chain,
+// which catches tables or chains that were flushed or deleted, and rules that were added or removed.
+// Tables we program no rules into are not checked, as CreateInpodRules leaves them alone too.
+// NOTE that this expects to be run from within the pod network namespace!
+func (cfg *NftablesConfigurator) CheckInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) (bool, error) {
+	rb := cfg.buildInpodRules(podOverrides)
+	for _, table := range []string{AmbientNatTable, AmbientMangleTable, AmbientRawTable} {
+		if len(rb.Rules[table]) == 0 {
+			continue
+		}
+		expected := map[string]int{}
+		for _, rule := range rb.Rules[table] {
+			expected[rule.Chain]++
+		}
+		nft, err := cfg.nftProvider(knftables.InetFamily, table)
+		if err != nil {
+			return false, err
+		}
+		rules, err := nft.ListRules(context.TODO(), "")
+		if knftables.IsNotFound(err) {
+			log.Debugf("table %s is missing", table)
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		actual := map[string]int{}
+		for _, rule := range rules {
+			actual[rule.Chain]++
+		}
+		if !maps.Equal(expected, actual) {
+			log.Debugf("rules in table %s do not match, expected rules per chain %v, found %v", table, expected, actual)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RepairInpodRules reinstalls the in-pod rules. Programming the rules always flushes our tables
+// first, in the same transaction, so this is the same as creating them.
+// NOTE that this expects to be run from within the pod network namespace!
+func (cfg *NftablesConfigurator) RepairInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error {
+	return cfg.CreateInpodRules(log, podOverrides)
+}
+
+// ExplainInpodRules returns the rules CreateInpodRules would install with the given overrides, without
+// installing them, along with the reason each rule exists.
+func (cfg *NftablesConfigurator) ExplainInpodRules(podOverrides config.PodLevelOverrides) (*explain.Explanation, error) {
+	rules := explain.FromNftables(cfg.buildInpodRules(podOverrides).Explain())
+	return &explain.Explanation{Backend: explain.BackendNftables, Rules: rules}, nil
+}
+
+// buildInpodRules returns the in-pod rules for the given overrides, without programming them.
+func (cfg *NftablesConfigurator) buildInpodRules(podOverrides config.PodLevelOverrides) *builder.NftablesRuleBuilder {
 	rb := builder.NewNftablesRuleBuilder(config.GetConfig(cfg.cfg))
 
 	var redirectDNS bool
@@ -145,6 +208,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		redirectDNS = false
 	}
 
+	rb.WithReason(iptables.ReasonJumpToIstioChains)
 	rb.AppendRule(
 		PreroutingChain, AmbientMangleTable,
 		"jump", IstioPreroutingChain,
@@ -185,6 +249,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 			// and just shunt it directly to the outbound port of the proxy.
 			// Note that for now this explicitly excludes UDP traffic, as we can't proxy arbitrary UDP stuff,
 			// and this is a difference from the old sidecar `traffic.sidecar.istio.io/kubevirtInterfaces` annotation.
+			rb.WithReason(iptables.ReasonVirtualInterface)
 			rb.AppendRule(IstioPreroutingChain, AmbientNatTable,
 				"iifname", fmt.Sprint(virtInterface),
 				"meta l4proto tcp", Counter,
@@ -192,6 +257,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 			)
 
 			// CLI: nft add rule inet istio-ambient-nat istio-prerouting iifname <iface> meta l4proto tcp counter return
+			rb.WithReason(iptables.ReasonVirtualInterfaceRT)
 			rb.AppendRule(IstioPreroutingChain, AmbientNatTable,
 				"iifname", fmt.Sprint(virtInterface),
 				"meta l4proto tcp", Counter,
@@ -202,6 +268,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 
 	// CLI: nft add rule inet istio-ambient-mangle istio-prerouting meta mark & 0xfff == 0x539 counter ct mark set ct mark & 0xfffff000 | 0x111
 	// DESC: If we have a packet mark, set a connmark.
+	rb.WithReason(iptables.ReasonSetConnmark)
 	rb.AppendRule(IstioPreroutingChain, AmbientMangleTable,
 		"meta mark & 0xfff ==",
 		fmt.Sprintf("0x%x", config.InpodMark), Counter, "ct mark set ct mark & 0xfffff000 | ",
@@ -218,6 +285,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// CLI: nft add rule inet istio-ambient-nat istio-prerouting meta l4proto tcp ip saddr 169.254.7.127 counter accept
 		//
 		// DESC: If this is one of our node-probe ports and is from our SNAT-ed/"special" hostside IP, short-circuit out here
+		rb.WithReason(iptables.ReasonProbeInbound)
 		rb.AppendRule(IstioPreroutingChain, AmbientNatTable,
 			"meta l4proto tcp",
 			"ip saddr", cfg.cfg.HostProbeSNATAddress.String(), Counter,
@@ -235,6 +303,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 
 	// CLI: nft add rule inet istio-ambient-nat istio-output meta l4proto tcp ip daddr 169.254.7.127 counter accept
 	// DESC: Anything coming BACK from the pod healthcheck port with a dest of our SNAT-ed hostside IP we also short-circuit.
+	rb.WithReason(iptables.ReasonProbeReply)
 	rb.AppendRule(IstioOutputChain, AmbientNatTable,
 		"meta l4proto tcp",
 		"ip daddr", cfg.cfg.HostProbeSNATAddress.String(), Counter,
@@ -254,6 +323,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		//
 		// DESC: Anything that is not bound for localhost and does not have the mark, REDIRECT to ztunnel inbound plaintext port <INPLAINPORT>
 		// Skip 15008, which will go direct without redirect needed.
+		rb.WithReason(iptables.ReasonInboundRedirect)
 		rb.AppendRule(IstioPreroutingChain, AmbientNatTable,
 			"ip daddr", "!=", "127.0.0.1/32",
 			"tcp dport", "!=", fmt.Sprint(config.ZtunnelInboundPort),
@@ -272,6 +342,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: nft add rule inet istio-ambient-mangle istio-output ct mark and 0xfff == 0x111 counter meta mark set ct mark
 	//
 	// DESC: Propagate/restore connmark (if we had one) for outbound
+	rb.WithReason(iptables.ReasonRestoreConnmark)
 	rb.AppendRule(
 		IstioOutputChain, AmbientMangleTable,
 		"ct mark and", fmt.Sprintf("0x%x", config.InpodTProxyMask),
@@ -283,6 +354,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// CLI: nft add rule inet istio-ambient-nat istio-output oifname != "lo" mark and 0xfff != 0x539 udp dport 53 counter redirect to :15053
 		//
 		// DESC: If this is a UDP DNS request to a non-localhost resolver, send it to ztunnel DNS proxy port
+		rb.WithReason(iptables.ReasonDNSRedirect)
 		rb.AppendRule(
 			IstioOutputChain, AmbientNatTable,
 			"oifname", "!=", "lo",
@@ -316,6 +388,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		// See https://github.com/istio/istio/issues/33469
 		// CLI: nft add rule inet istio-ambient-raw istio-output udp dport 53 meta mark and 0xfff == 0x539 counter ct zone set 1
 		// Proxy --> Upstream
+		rb.WithReason(iptables.ReasonDNSConntrack)
 		rb.AppendRule(
 			IstioOutputChain, AmbientRawTable,
 			"udp dport", "53",
@@ -338,6 +411,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: nft add rule inet istio-ambient-nat istio-output meta l4proto tcp mark and 0xfff == 0x111 counter accept
 	//
 	// DESC: If this is outbound and has our mark, let it go.
+	rb.WithReason(iptables.ReasonOutboundMarked)
 	rb.AppendRule(
 		IstioOutputChain, AmbientNatTable,
 		"meta l4proto tcp",
@@ -349,6 +423,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// Do not redirect app calls to back itself via Ztunnel when using the endpoint address
 	// e.g. appN => appN by lo
 	// CLI: nft add rule inet istio-ambient-nat istio-output oifname "lo" ip daddr != 127.0.0.1 counter accept
+	rb.WithReason(iptables.ReasonOutboundLoopback)
 	rb.AppendRule(
 		IstioOutputChain, AmbientNatTable,
 		"oifname", "lo",
@@ -369,6 +444,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 	// CLI: nft add rule inet istio-ambient-nat istio-output meta l4proto tcp ip daddr != 127.0.0.1 mark and 0xfff != 0x539 counter redirect to :15001
 	//
 	// DESC: If this is outbound, not bound for localhost, and does not have our packet mark, redirect to ztunnel proxy <OUTPORT>
+	rb.WithReason(iptables.ReasonOutboundRedirect)
 	rb.AppendRule(
 		IstioOutputChain, AmbientNatTable,
 		"meta l4proto tcp",
@@ -389,7 +465,7 @@ func (cfg *NftablesConfigurator) AppendInpodRules(podOverrides config.PodLevelOv
 		"redirect to", ":"+fmt.Sprintf("%d", config.ZtunnelOutboundPort),
 	)
 
-	return cfg.executeCommands(rb)
+	return rb
 }
 
 // DeleteInpodRules removes nftables rules from a pod's network namespace
@@ -228,6 +228,77 @@ func TestCreateInpodRulesConcurrent(t *testing.T) {
 	wg.Wait()
 }
 
+// tableView returns a mock for a single table of the shared mock, like the per-table knftables
+// instance CheckInpodRules lists rules with.
+func tableView(shared *MockNftablesCapture, table string) builder.NftablesAPI {
+	shared.RLock()
+	defer shared.RUnlock()
+	view := builder.NewMockNftables(knftables.InetFamily, table)
+	view.Table = shared.Tables[knftables.InetFamily][table]
+	return view
+}
+
+func TestCheckAndRepairInpodRules(t *testing.T) {
+	cfg := constructTestConfig()
+	cfg.RedirectDNS = true
+	ext := &dep.DependenciesStub{}
+
+	mock := NewMockNftablesCapture()
+	originalProvider := nftProviderVar
+	nftProviderVar = func(_ knftables.Family, table string) (builder.NftablesAPI, error) {
+		if table == "" {
+			return mock, nil
+		}
+		return tableView(mock, table), nil
+	}
+	t.Cleanup(func() { nftProviderVar = originalProvider })
+
+	nftConfigurator, _, _ := NewNftablesConfigurator(cfg, cfg, ext, ext, iptables.EmptyNlDeps())
+	assertDrift := func(overrides config.PodLevelOverrides, want bool) {
+		t.Helper()
+		drifted, err := nftConfigurator.CheckInpodRules(scopes.CNIAgent, overrides)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if drifted != want {
+			t.Fatalf("expected drift %v, got %v", want, drifted)
+		}
+	}
+	run := func(tx *knftables.Transaction) {
+		t.Helper()
+		if err := mock.Run(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing installed yet.
+	assertDrift(config.PodLevelOverrides{}, true)
+
+	if err := nftConfigurator.CreateInpodRules(scopes.CNIAgent, config.PodLevelOverrides{}); err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(config.PodLevelOverrides{}, false)
+	// Rules installed for different overrides are drift too.
+	assertDrift(config.PodLevelOverrides{IngressMode: true}, true)
+
+	// Something flushed one of our chains.
+	tx := mock.NewTransaction()
+	tx.Flush(&knftables.Chain{Name: IstioOutputChain, Table: AmbientNatTable, Family: knftables.InetFamily})
+	run(tx)
+	assertDrift(config.PodLevelOverrides{}, true)
+
+	if err := nftConfigurator.RepairInpodRules(scopes.CNIAgent, config.PodLevelOverrides{}); err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(config.PodLevelOverrides{}, false)
+
+	// Something deleted a whole table.
+	tx = mock.NewTransaction()
+	tx.Delete(&knftables.Table{Name: AmbientRawTable, Family: knftables.InetFamily})
+	run(tx)
+	assertDrift(config.PodLevelOverrides{}, true)
+}
+
 func ipstr(ipv6 bool) string {
 	if ipv6 {
 		return "ipv6"
@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync/atomic"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/cni/pkg/explain"
+	"istio.io/istio/cni/pkg/trafficmanager"
+	"istio.io/istio/pkg/ctrlz/fw"
+)
+
+// captureExplainer renders the in-pod rules the node agent would install for a pod, without installing them.
+type captureExplainer struct {
+	kubeClient     kubernetes.Interface
+	trafficManager trafficmanager.TrafficRuleManager
+}
+
+// activeCaptureExplainer is the explainer the ControlZ topic uses. Like activeZtunnelServer, the topic
+// is registered before the node agent creates its traffic managers.
+var activeCaptureExplainer atomic.Pointer[captureExplainer]
+
+func (e *captureExplainer) explain(ctx context.Context, namespace, name string) (*explain.Explanation, error) {
+	pod, err := e.kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// The rules only depend on the pod annotations and the node agent configuration, so this does not need
+	// the pod netns, and works for pods that are not (yet) enrolled.
+	ex, err := e.trafficManager.ExplainInpodRules(getPodLevelTrafficOverrides(pod))
+	if err != nil {
+		return nil, err
+	}
+	ex.Namespace = pod.Namespace
+	ex.Name = pod.Name
+	ex.Mode = explain.ModeAmbient
+	return ex, nil
+}
+
+type captureExplainTopic struct{}
+
+// CaptureExplainTopic returns a ControlZ topic that renders the in-pod redirection rules for a pod, along with
+// the reason each rule exists, without applying them.
+func CaptureExplainTopic() fw.Topic {
+	return captureExplainTopic{}
+}
+
+func (captureExplainTopic) Title() string {
+	return "Capture Explain"
+}
+
+func (captureExplainTopic) Prefix() string {
+	return "capture"
+}
+
+const captureExplainTemplate = `{{ define "content" }}
+<form method="get">
+    <input type="text" name="namespace" placeholder="namespace" value="{{ .Namespace }}">
+    <input type="text" name="name" placeholder="pod" value="{{ .Name }}">
+    <input type="submit" value="Explain">
+</form>
+{{ if .Error }}
+<p>{{ .Error }}</p>
+{{ else if .Explanation }}
+<p>Backend: {{ .Explanation.Backend }}</p>
+<table>
+    <thead>
+        <tr>
+            <th>Family</th>
+            <th>Table</th>
+            <th>Chain</th>
+            <th>Rule</th>
+            <th>Reason</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{ range .Explanation.Rules }}
+        <tr>
+            <td>{{ .Family }}</td>
+            <td>{{ .Table }}</td>
+            <td>{{ .Chain }}</td>
+            <td>{{ .Rule }}</td>
+            <td>{{ .Reason }}</td>
+        </tr>
+        {{ end }}
+    </tbody>
+</table>
+{{ end }}
+{{ template "last-refresh" .}}
+{{ end }}
+`
+
+type captureExplainPage struct {
+	Namespace   string
+	Name        string
+	Explanation *explain.Explanation
+	Error       string
+}
+
+func (captureExplainTopic) Activate(context fw.TopicContext) {
+	tmpl := template.Must(context.Layout().Parse(captureExplainTemplate))
+
+	_ = context.HTMLRouter().StrictSlash(true).NewRoute().Path("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		page := &captureExplainPage{
+			Namespace: req.URL.Query().Get("namespace"),
+			Name:      req.URL.Query().Get("name"),
+		}
+		if page.Namespace != "" && page.Name != "" {
+			e := activeCaptureExplainer.Load()
+			if e == nil {
+				page.Error = "The ambient node agent is not running."
+			} else if ex, err := e.explain(req.Context(), page.Namespace, page.Name); err != nil {
+				page.Error = err.Error()
+			} else {
+				page.Explanation = ex
+			}
+		}
+		fw.RenderHTML(w, tmpl, page)
+	})
+
+	_ = context.JSONRouter().NewRoute().Methods("GET").Path("/explain").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := activeCaptureExplainer.Load()
+		if e == nil {
+			fw.RenderError(w, http.StatusServiceUnavailable, fmt.Errorf("ambient node agent is not running"))
+			return
+		}
+		namespace, name := req.URL.Query().Get("namespace"), req.URL.Query().Get("name")
+		if namespace == "" || name == "" {
+			fw.RenderError(w, http.StatusBadRequest, fmt.Errorf("namespace and name must be set"))
+			return
+		}
+		ex, err := e.explain(req.Context(), namespace, name)
+		if kerrors.IsNotFound(err) {
+			fw.RenderError(w, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			fw.RenderError(w, http.StatusInternalServerError, err)
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, ex)
+	})
+}
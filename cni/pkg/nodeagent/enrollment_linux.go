@@ -0,0 +1,323 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package nodeagent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/cni/pkg/enrollment"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/ptr"
+)
+
+const (
+	// enrollmentStatusFlushInterval bounds how often the node status Lease is rewritten.
+	// Pod add retries can fire several times a second while ztunnel is unavailable, so
+	// per-pod changes are batched rather than written through.
+	enrollmentStatusFlushInterval = 10 * time.Second
+
+	// maxEnrollmentErrorLen caps the error stored per pod, so a handful of verbose
+	// iptables failures cannot push the node status over the size limit.
+	maxEnrollmentErrorLen = 512
+)
+
+// enrollmentReporter records the outcome of every enrollment attempt made by the meshDataplane.
+//
+// Transitions (a change in phase, ztunnel ack state or error) are surfaced on the pod itself as an
+// Event and a pod condition. The full set of tracked pods is periodically written to a Lease per node in
+// the namespace of the agent, which `istioctl ztunnel-config enrollment` reads. The Lease is owned by the
+// Node, so it is garbage collected along with it.
+//
+// Reporting is best effort: failing to write status never fails or retries an enrollment.
+// All methods are safe to call on a nil reporter.
+type enrollmentReporter struct {
+	kubeClient kubernetes.Interface
+	events     *kclient.EventRecorder
+	node       string
+	namespace  string
+	owner      []metav1.OwnerReference
+
+	mu    sync.Mutex
+	pods  map[types.UID]*enrollment.PodStatus
+	dirty bool
+
+	// allow overriding for tests
+	now func() time.Time
+}
+
+func newEnrollmentReporter(kubeClient kubernetes.Interface, events *kclient.EventRecorder, node, namespace string) *enrollmentReporter {
+	return &enrollmentReporter{
+		kubeClient: kubeClient,
+		events:     events,
+		node:       node,
+		namespace:  namespace,
+		pods:       map[types.UID]*enrollment.PodStatus{},
+		now:        time.Now,
+	}
+}
+
+// Run periodically flushes the node status until the context is cancelled.
+func (r *enrollmentReporter) Run(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(enrollmentStatusFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.flush(ctx); err != nil {
+				log.Warnf("failed to write node enrollment status: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// observeAdd records the outcome of an add attempt. addErr is the error returned by the attempt, if any.
+func (r *enrollmentReporter) observeAdd(pod *corev1.Pod, phase enrollment.Phase, ack enrollment.AckState, addErr error) {
+	if r == nil {
+		return
+	}
+	errMsg := ""
+	if addErr != nil {
+		errMsg = addErr.Error()
+		if len(errMsg) > maxEnrollmentErrorLen {
+			errMsg = errMsg[:maxEnrollmentErrorLen]
+		}
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	st, ok := r.pods[pod.UID]
+	if !ok {
+		st = &enrollment.PodStatus{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			UID:       string(pod.UID),
+		}
+		r.pods[pod.UID] = st
+	}
+	transition := !ok || st.Phase != phase || st.ZtunnelAck != ack || st.LastError != errMsg
+	if transition {
+		st.Phase = phase
+		st.ZtunnelAck = ack
+		st.LastError = errMsg
+		st.Attempts = 0
+		st.LastTransitionTime = now
+	}
+	st.Attempts++
+	st.LastAttemptTime = now
+	r.dirty = true
+	snapshot := *st
+	r.mu.Unlock()
+
+	if transition {
+		r.reportTransition(pod, snapshot)
+	}
+}
+
+// observeRemove records the outcome of a remove attempt. On success the pod is no longer tracked.
+func (r *enrollmentReporter) observeRemove(pod *corev1.Pod, isDelete bool, removeErr error) {
+	if r == nil {
+		return
+	}
+	if removeErr != nil {
+		r.observeAdd(pod, enrollment.PhaseRemoveFailed, enrollment.AckNotSent, removeErr)
+		return
+	}
+
+	r.mu.Lock()
+	_, tracked := r.pods[pod.UID]
+	delete(r.pods, pod.UID)
+	r.dirty = r.dirty || tracked
+	r.mu.Unlock()
+
+	// A deleted pod has nothing left to report on.
+	if isDelete {
+		return
+	}
+	r.writeCondition(pod, corev1.PodCondition{
+		Type:               enrollment.PodConditionType,
+		Status:             corev1.ConditionFalse,
+		Reason:             "Removed",
+		Message:            "pod was removed from the ambient mesh",
+		LastTransitionTime: metav1.NewTime(r.now()),
+	})
+}
+
+func (r *enrollmentReporter) reportTransition(pod *corev1.Pod, st enrollment.PodStatus) {
+	cond := corev1.PodCondition{
+		Type:               enrollment.PodConditionType,
+		Status:             corev1.ConditionFalse,
+		Reason:             string(st.Phase),
+		Message:            st.LastError,
+		LastTransitionTime: metav1.NewTime(st.LastTransitionTime),
+	}
+	eventType := corev1.EventTypeWarning
+	if st.Phase == enrollment.PhaseEnrolled {
+		cond.Status = corev1.ConditionTrue
+		cond.Message = "pod traffic is redirected to ztunnel"
+		eventType = corev1.EventTypeNormal
+	}
+	r.writeCondition(pod, cond)
+
+	if r.events != nil {
+		if st.LastError != "" {
+			r.events.Write(pod, eventType, "Ambient"+string(st.Phase), "ambient enrollment %s (ztunnel %s): %s", st.Phase, st.ZtunnelAck, st.LastError)
+		} else {
+			r.events.Write(pod, eventType, "Ambient"+string(st.Phase), "ambient enrollment %s (ztunnel %s)", st.Phase, st.ZtunnelAck)
+		}
+	}
+}
+
+func (r *enrollmentReporter) writeCondition(pod *corev1.Pod, cond corev1.PodCondition) {
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": []corev1.PodCondition{cond},
+		},
+	})
+	if err != nil {
+		log.Warnf("failed to build enrollment condition patch for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	// Conditions are merged by type, so this only ever touches our own condition.
+	_, err = r.kubeClient.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name,
+		types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil && !kerrors.IsNotFound(err) {
+		log.Warnf("failed to update enrollment condition for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
+// status builds the current node status.
+func (r *enrollmentReporter) status() *enrollment.NodeStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := &enrollment.NodeStatus{
+		Node:       r.node,
+		UpdateTime: r.now(),
+		Summary:    map[enrollment.Phase]int{},
+		Pods:       make([]enrollment.PodStatus, 0, len(r.pods)),
+	}
+	for _, p := range r.pods {
+		st.Summary[p.Phase]++
+		st.Pods = append(st.Pods, *p)
+	}
+	sort.Slice(st.Pods, func(i, j int) bool {
+		if st.Pods[i].Namespace != st.Pods[j].Namespace {
+			return st.Pods[i].Namespace < st.Pods[j].Namespace
+		}
+		return st.Pods[i].Name < st.Pods[j].Name
+	})
+	return st
+}
+
+// flush writes the node status Lease, if anything changed since the last successful write.
+func (r *enrollmentReporter) flush(ctx context.Context) error {
+	r.mu.Lock()
+	dirty := r.dirty
+	r.dirty = false
+	r.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+
+	err := r.writeStatus(ctx, r.status())
+	if err != nil {
+		// Try again on the next tick.
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+	}
+	return err
+}
+
+func (r *enrollmentReporter) writeStatus(ctx context.Context, st *enrollment.NodeStatus) error {
+	data, err := st.Encode()
+	if err != nil {
+		return err
+	}
+	if r.owner == nil {
+		node, err := r.kubeClient.CoreV1().Nodes().Get(ctx, r.node, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		r.owner = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Node", Name: node.Name, UID: node.UID}}
+	}
+	now := metav1.NewMicroTime(r.now())
+	leases := r.kubeClient.CoordinationV1().Leases(r.namespace)
+	name := enrollment.LeaseName(r.node)
+	existing, err := leases.Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       r.namespace,
+				Labels:          map[string]string{enrollment.StatusLabel: "true"},
+				Annotations:     map[string]string{enrollment.StatusAnnotation: data},
+				OwnerReferences: r.owner,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity: ptr.Of(r.node),
+				RenewTime:      &now,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	updated := existing.DeepCopy()
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	updated.Labels[enrollment.StatusLabel] = "true"
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[enrollment.StatusAnnotation] = data
+	updated.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// classifyAddError maps an error returned from NetServer.AddPodToMesh to the resulting
+// enrollment phase and ztunnel ack state.
+func classifyAddError(err error) (enrollment.Phase, enrollment.AckState) {
+	switch {
+	case err == nil:
+		return enrollment.PhaseEnrolled, enrollment.AckAcked
+	case errors.Is(err, ErrNonRetryableAdd):
+		return enrollment.PhaseFailed, enrollment.AckNotSent
+	case errors.Is(err, errNoZtunnelConnection):
+		return enrollment.PhasePending, enrollment.AckNotConnected
+	case errors.Is(err, errZtunnelAck):
+		return enrollment.PhasePending, enrollment.AckRejected
+	default:
+		return enrollment.PhasePending, enrollment.AckNoResponse
+	}
+}
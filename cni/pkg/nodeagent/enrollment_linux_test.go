@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/cni/pkg/enrollment"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestClassifyAddError(t *testing.T) {
+	cases := []struct {
+		name  string
+		err   error
+		phase enrollment.Phase
+		ack   enrollment.AckState
+	}{
+		{"success", nil, enrollment.PhaseEnrolled, enrollment.AckAcked},
+		{"netns gone", NewErrNonRetryableAdd(ErrPodNotFound), enrollment.PhaseFailed, enrollment.AckNotSent},
+		{"no ztunnel", errNoZtunnelConnection, enrollment.PhasePending, enrollment.AckNotConnected},
+		{"ack error", fmt.Errorf("%w: boom", errZtunnelAck), enrollment.PhasePending, enrollment.AckRejected},
+		{"timeout", errors.New("context expired before response received"), enrollment.PhasePending, enrollment.AckNoResponse},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			phase, ack := classifyAddError(tt.err)
+			assert.Equal(t, phase, tt.phase)
+			assert.Equal(t, ack, tt.ack)
+		})
+	}
+}
+
+func TestMeshDataplaneRecordsEnrollmentStatus(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+			UID:       types.UID("test"),
+		},
+	}
+	fakeCtx := context.Background()
+	fakeClientSet := fake.NewClientset(pod, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", UID: "node1"}})
+	podIPs := []netip.Addr{netip.MustParseAddr("99.9.9.1")}
+
+	server := &fakeServer{}
+	server.On("AddPodToMesh", fakeCtx, pod, podIPs, "").Return(errNoZtunnelConnection).Once()
+	server.On("AddPodToMesh", fakeCtx, pod, podIPs, "").Return(nil).Once()
+
+	m := getFakeDP(server, fakeClientSet)
+	m.enrollment = newEnrollmentReporter(fakeClientSet, nil, "node1", "istio-system")
+
+	assert.Error(t, m.AddPodToMesh(fakeCtx, pod, podIPs, ""))
+	cond := getEnrollmentCondition(t, fakeClientSet, pod)
+	assert.Equal(t, cond.Status, corev1.ConditionFalse)
+	assert.Equal(t, cond.Reason, string(enrollment.PhasePending))
+	assert.Equal(t, cond.Message, errNoZtunnelConnection.Error())
+
+	assert.NoError(t, m.enrollment.flush(fakeCtx))
+	st := getNodeEnrollmentStatus(t, fakeClientSet)
+	assert.Equal(t, st.Node, "node1")
+	assert.Equal(t, len(st.Pods), 1)
+	assert.Equal(t, st.Pods[0].ZtunnelAck, enrollment.AckNotConnected)
+	assert.Equal(t, st.Summary[enrollment.PhasePending], 1)
+
+	assert.NoError(t, m.AddPodToMesh(fakeCtx, pod, podIPs, ""))
+	cond = getEnrollmentCondition(t, fakeClientSet, pod)
+	assert.Equal(t, cond.Status, corev1.ConditionTrue)
+	assert.Equal(t, cond.Reason, string(enrollment.PhaseEnrolled))
+
+	assert.NoError(t, m.enrollment.flush(fakeCtx))
+	st = getNodeEnrollmentStatus(t, fakeClientSet)
+	assert.Equal(t, st.Pods[0].Phase, enrollment.PhaseEnrolled)
+	assert.Equal(t, st.Pods[0].ZtunnelAck, enrollment.AckAcked)
+	assert.Equal(t, st.Pods[0].LastError, "")
+
+	server.On("RemovePodFromMesh", fakeCtx, pod, true).Return(nil)
+	assert.NoError(t, m.RemovePodFromMesh(fakeCtx, pod, true))
+	assert.NoError(t, m.enrollment.flush(fakeCtx))
+	st = getNodeEnrollmentStatus(t, fakeClientSet)
+	assert.Equal(t, len(st.Pods), 0)
+}
+
+func TestEnrollmentReporterOnlyReportsTransitions(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+			UID:       types.UID("test"),
+		},
+	}
+	fakeClientSet := fake.NewClientset(pod, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", UID: "node1"}})
+	r := newEnrollmentReporter(fakeClientSet, nil, "node1", "istio-system")
+
+	for range 3 {
+		r.observeAdd(pod, enrollment.PhasePending, enrollment.AckNotConnected, errNoZtunnelConnection)
+	}
+	patches := 0
+	for _, a := range fakeClientSet.Actions() {
+		if a.GetVerb() == "patch" {
+			patches++
+		}
+	}
+	assert.Equal(t, patches, 1)
+	assert.Equal(t, r.status().Pods[0].Attempts, 3)
+
+	// Nothing changed since the last write, so flushing twice only writes once.
+	assert.NoError(t, r.flush(context.Background()))
+	fakeClientSet.ClearActions()
+	assert.NoError(t, r.flush(context.Background()))
+	assert.Equal(t, len(fakeClientSet.Actions()), 0)
+}
+
+func getEnrollmentCondition(t *testing.T, client kubernetes.Interface, pod *corev1.Pod) corev1.PodCondition {
+	t.Helper()
+	p, err := client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	for _, c := range p.Status.Conditions {
+		if c.Type == enrollment.PodConditionType {
+			return c
+		}
+	}
+	t.Fatalf("pod has no %s condition", enrollment.PodConditionType)
+	return corev1.PodCondition{}
+}
+
+func getNodeEnrollmentStatus(t *testing.T, client kubernetes.Interface) *enrollment.NodeStatus {
+	t.Helper()
+	lease, err := client.CoordinationV1().Leases("istio-system").Get(context.Background(), enrollment.LeaseName("node1"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, lease.Labels[enrollment.StatusLabel], "true")
+	assert.Equal(t, lease.OwnerReferences[0].Kind, "Node")
+	st, err := enrollment.FromLease(lease)
+	assert.NoError(t, err)
+	return st
+}
@@ -24,11 +24,22 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	set "istio.io/istio/cni/pkg/addressset"
+	"istio.io/istio/cni/pkg/enrollment"
 	"istio.io/istio/cni/pkg/trafficmanager"
 	"istio.io/istio/cni/pkg/util"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/monitoring"
 	"istio.io/istio/pkg/util/sets"
 )
 
+var (
+	driftResultTag = monitoring.CreateLabel("result")
+	podRulesDrift  = monitoring.NewSum(
+		"nodeagent_pod_rules_drift_total",
+		"The total number of times an enrolled pod's in-pod redirection rules were found to have drifted, by repair result.",
+	)
+)
+
 type meshDataplane struct {
 	kubeClient         kubernetes.Interface
 	netServer          MeshDataplane
@@ -40,6 +51,11 @@ type meshDataplane struct {
 	// even if aws-vpc-cni has already removed its iif rule (making re-detection fail).
 	branchENIMu    sync.Mutex
 	branchENIRules map[netip.Addr]*branchENIRoute
+
+	// enrollment records the per-pod outcome of add and remove attempts. May be nil.
+	enrollment *enrollmentReporter
+	// events reports redirection repairs on the pod. May be nil.
+	events *kclient.EventRecorder
 }
 
 // ConstructInitialSnapshot is always called first, before Start.
@@ -58,6 +74,7 @@ func (s *meshDataplane) ConstructInitialSnapshot(existingAmbientPods []*corev1.P
 // ConstructInitialSnapshot should always be invoked before this function.
 func (s *meshDataplane) Start(ctx context.Context) {
 	s.netServer.Start(ctx)
+	go s.enrollment.Run(ctx)
 }
 
 // Stop terminates the netserver, flushes host ipsets, and removes host iptables healthprobe rules.
@@ -87,6 +104,29 @@ func (s *meshDataplane) Stop(skipCleanup bool) {
 	s.netServer.Stop(skipCleanup)
 }
 
+// CheckPodRedirection reinstalls the pod's in-pod redirection rules if they drifted, and reports every
+// repair (or failure to repair) as a metric and an Event on the pod.
+func (s *meshDataplane) CheckPodRedirection(pod *corev1.Pod) (bool, error) {
+	drifted, err := s.netServer.CheckPodRedirection(pod)
+	if !drifted {
+		return false, err
+	}
+	if err != nil {
+		podRulesDrift.With(driftResultTag.Value("failed")).Increment()
+		if s.events != nil {
+			s.events.Write(pod, corev1.EventTypeWarning, "AmbientRedirectionRepairFailed",
+				"in-pod traffic redirection rules drifted and could not be reinstalled: %v", err)
+		}
+		return true, err
+	}
+	podRulesDrift.With(driftResultTag.Value("repaired")).Increment()
+	if s.events != nil {
+		s.events.Write(pod, corev1.EventTypeWarning, "AmbientRedirectionRepaired",
+			"in-pod traffic redirection rules drifted from the expected rules and were reinstalled")
+	}
+	return true, nil
+}
+
 // rememberBranchENIRoute caches the branch ENI info for a pod IP so we can
 // clean up its rules later without re-scanning.
 func (s *meshDataplane) rememberBranchENIRoute(podIP netip.Addr, info *branchENIRoute) {
@@ -142,6 +182,8 @@ func (s *meshDataplane) AddPodToMesh(ctx context.Context, pod *corev1.Pod, podIP
 	// - Add pod IP to ipset IF none of the above has failed, as a last step
 	log := log.WithLabels("ns", pod.Namespace, "name", pod.Name)
 	if err := s.netServer.AddPodToMesh(ctx, pod, podIPs, netNs); err != nil {
+		phase, ack := classifyAddError(err)
+		s.enrollment.observeAdd(pod, phase, ack, err)
 		// iptables injection failed, this is not a "retryable partial add"
 		// this is a nonrecoverable/nonretryable error and we won't even bother to
 		// annotate the pod or retry the event.
@@ -172,6 +214,7 @@ func (s *meshDataplane) AddPodToMesh(ctx context.Context, pod *corev1.Pod, podIP
 		// never fail, or isn't usefully retryable.
 		// For now tho, err on the side of being loud in the logs,
 		// since retrying in that case isn't _harmful_ and means all pods will fail anyway.
+		s.enrollment.observeAdd(pod, enrollment.PhasePending, enrollment.AckAcked, err)
 		return err
 	}
 
@@ -183,9 +226,11 @@ func (s *meshDataplane) AddPodToMesh(ctx context.Context, pod *corev1.Pod, podIP
 	if err := util.AnnotateEnrolledPod(s.kubeClient, &pod.ObjectMeta); err != nil {
 		// If we have an error annotating the full status - that is retryable.
 		// (maybe K8S is busy, etc - but we need a k8s controlplane ACK).
+		s.enrollment.observeAdd(pod, enrollment.PhasePending, enrollment.AckAcked, err)
 		return err
 	}
 
+	s.enrollment.observeAdd(pod, enrollment.PhaseEnrolled, enrollment.AckAcked, nil)
 	return nil
 }
 
@@ -208,6 +253,7 @@ func (s *meshDataplane) RemovePodFromMesh(ctx context.Context, pod *corev1.Pod,
 		// (unless we have a kernel incompatibility).
 		// - so while retrying on ipser remove error is safe from an eventing perspective,
 		// it may not be useful
+		s.enrollment.observeRemove(pod, isDelete, err)
 		return err
 	}
 
@@ -216,6 +262,7 @@ func (s *meshDataplane) RemovePodFromMesh(ctx context.Context, pod *corev1.Pod,
 	// So we will return if this fails (for a potential retry).
 	if err := s.netServer.RemovePodFromMesh(ctx, pod, isDelete); err != nil {
 		log.Errorf("failed to remove pod from mesh: %v", err)
+		s.enrollment.observeRemove(pod, isDelete, err)
 		return err
 	}
 
@@ -230,10 +277,12 @@ func (s *meshDataplane) RemovePodFromMesh(ctx context.Context, pod *corev1.Pod,
 		if !isDelete {
 			// If we have an error annotating the partial status and the pod is not terminating
 			// - that is retryable.
+			s.enrollment.observeRemove(pod, isDelete, err)
 			return err
 		}
 	}
 
+	s.enrollment.observeRemove(pod, isDelete, nil)
 	return nil
 }
 
@@ -19,11 +19,13 @@ import (
 	"errors"
 	"fmt"
 	"net/netip"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"istio.io/istio/cni/pkg/trafficmanager"
+	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/slices"
 )
 
@@ -33,6 +35,10 @@ type NetServer struct {
 	currentPodSnapshot *podNetnsCache
 	trafficManager     trafficmanager.TrafficRuleManager
 	podNs              PodNetnsFinder
+	// rulesMu keeps CheckPodRedirection from racing with a pod being added or removed, and so
+	// reinstalling rules the pod is about to lose. Adds and removes only read-lock it, so they
+	// still run concurrently with each other.
+	rulesMu sync.RWMutex
 	// allow overriding for tests
 	netnsRunner func(fdable NetnsFd, toRun func() error) error
 }
@@ -113,26 +119,9 @@ func (s *NetServer) SyncHostProbeIPSet(_ *corev1.Pod, _ []netip.Addr) error {
 func (s *NetServer) AddPodToMesh(ctx context.Context, pod *corev1.Pod, podIPs []netip.Addr, netNs string) error {
 	log := log.WithLabels("ns", pod.Namespace, "name", pod.Name)
 	log.Info("adding pod to the mesh")
-	// make sure the cache is aware of the pod, even if we don't have the netns yet.
-	s.currentPodSnapshot.Ensure(string(pod.UID))
-	openNetns, err := s.getOrOpenNetns(pod, netNs)
+	openNetns, err := s.createInpodRules(log, pod, netNs)
 	if err != nil {
-		// if we fail, we should not leave a dangling UID in the snapshot.
-		s.currentPodSnapshot.Take(string(pod.UID))
-		return NewErrNonRetryableAdd(err)
-	}
-
-	podCfg := getPodLevelTrafficOverrides(pod)
-
-	log.Debug("calling CreateInpodRules")
-	if err := s.netnsRunner(openNetns, func() error {
-		return s.trafficManager.CreateInpodRules(log, podCfg)
-	}); err != nil {
-		// We currently treat any failure to create inpod rules as non-retryable/catastrophic,
-		// and return a NonRetryableError in this case.
-		log.Errorf("failed to update POD inpod: %s/%s %v", pod.Namespace, pod.Name, err)
-		s.currentPodSnapshot.Take(string(pod.UID))
-		return NewErrNonRetryableAdd(err)
+		return err
 	}
 
 	// For *any* other failures after a successful `CreateInpodRules` call, we must return
@@ -162,6 +151,63 @@ func (s *NetServer) RemovePodFromMesh(ctx context.Context, pod *corev1.Pod, isDe
 	log := log.WithLabels("ns", pod.Namespace, "name", pod.Name)
 	log.WithLabels("delete", isDelete).Debugf("removing pod from the mesh")
 
+	if err := s.deleteInpodRules(log, pod, isDelete); err != nil {
+		return err
+	}
+
+	log.Debug("removing pod from ztunnel")
+	if err := s.ztunnelServer.PodDeleted(ctx, string(pod.UID)); err != nil {
+		log.Errorf("failed to delete pod from ztunnel: %v", err)
+		return err
+	}
+	return nil
+}
+
+// createInpodRules caches the pod's netns, and creates the inpod rules in it.
+// Any error returned is a NonRetryableError.
+func (s *NetServer) createInpodRules(log *istiolog.Scope, pod *corev1.Pod, netNs string) (Netns, error) {
+	s.rulesMu.RLock()
+	defer s.rulesMu.RUnlock()
+
+	// make sure the cache is aware of the pod, even if we don't have the netns yet.
+	s.currentPodSnapshot.Ensure(string(pod.UID))
+	openNetns, err := s.getOrOpenNetns(pod, netNs)
+	if err != nil {
+		// if we fail, we should not leave a dangling UID in the snapshot.
+		s.currentPodSnapshot.Take(string(pod.UID))
+		return nil, NewErrNonRetryableAdd(err)
+	}
+
+	podCfg := getPodLevelTrafficOverrides(pod)
+
+	log.Debug("calling CreateInpodRules")
+	if err := s.netnsRunner(openNetns, func() error {
+		return s.trafficManager.CreateInpodRules(log, podCfg)
+	}); err != nil {
+		// We currently treat any failure to create inpod rules as non-retryable/catastrophic,
+		// and return a NonRetryableError in this case.
+		log.Errorf("failed to update POD inpod: %s/%s %v", pod.Namespace, pod.Name, err)
+		s.currentPodSnapshot.Take(string(pod.UID))
+		return nil, NewErrNonRetryableAdd(err)
+	}
+	return openNetns, nil
+}
+
+func newNetServer(ztunnelServer ZtunnelServer, podNsMap *podNetnsCache, trafficManager trafficmanager.TrafficRuleManager, podNs PodNetnsFinder) *NetServer {
+	return &NetServer{
+		ztunnelServer:      ztunnelServer,
+		currentPodSnapshot: podNsMap,
+		podNs:              podNs,
+		trafficManager:     trafficManager,
+		netnsRunner:        NetnsDo,
+	}
+}
+
+// deleteInpodRules drops the pod's netns from the cache, and removes the inpod rules if the pod is still running.
+func (s *NetServer) deleteInpodRules(log *istiolog.Scope, pod *corev1.Pod, isDelete bool) error {
+	s.rulesMu.RLock()
+	defer s.rulesMu.RUnlock()
+
 	// Whether pod is already deleted or not, we need to let go of our netns ref.
 	openNetns := s.currentPodSnapshot.Take(string(pod.UID))
 	if openNetns == nil {
@@ -180,23 +226,36 @@ func (s *NetServer) RemovePodFromMesh(ctx context.Context, pod *corev1.Pod, isDe
 			log.Warn("pod netns already gone, not deleting inpod rules")
 		}
 	}
-
-	log.Debug("removing pod from ztunnel")
-	if err := s.ztunnelServer.PodDeleted(ctx, string(pod.UID)); err != nil {
-		log.Errorf("failed to delete pod from ztunnel: %v", err)
-		return err
-	}
 	return nil
 }
 
-func newNetServer(ztunnelServer ZtunnelServer, podNsMap *podNetnsCache, trafficManager trafficmanager.TrafficRuleManager, podNs PodNetnsFinder) *NetServer {
-	return &NetServer{
-		ztunnelServer:      ztunnelServer,
-		currentPodSnapshot: podNsMap,
-		podNs:              podNs,
-		trafficManager:     trafficManager,
-		netnsRunner:        NetnsDo,
+// CheckPodRedirection compares the inpod rules of an enrolled pod against the rules it should have,
+// and reinstalls them if they drifted. Pods we hold no netns for (e.g. ones being removed) are skipped.
+func (s *NetServer) CheckPodRedirection(pod *corev1.Pod) (bool, error) {
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+
+	openNetns := s.currentPodSnapshot.Get(string(pod.UID))
+	if openNetns == nil {
+		return false, nil
 	}
+
+	log := log.WithLabels("ns", pod.Namespace, "name", pod.Name)
+	podCfg := getPodLevelTrafficOverrides(pod)
+	drifted := false
+	err := s.netnsRunner(openNetns, func() error {
+		var err error
+		drifted, err = s.trafficManager.CheckInpodRules(log, podCfg)
+		if err != nil || !drifted {
+			return err
+		}
+		log.Warn("inpod rules drifted from the expected rules, reinstalling them")
+		if err := s.trafficManager.RepairInpodRules(log, podCfg); err != nil {
+			return fmt.Errorf("failed to repair inpod rules: %w", err)
+		}
+		return nil
+	})
+	return drifted, err
 }
 
 // reconcileExistingPod is intended to run on node agent startup, for each pod that was already enrolled prior to startup.
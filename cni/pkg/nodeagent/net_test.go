@@ -188,6 +188,38 @@ func TestServerRemovePod(t *testing.T) {
 	assertNSClosed(t, closed)
 }
 
+func TestServerCheckPodRedirection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	setupLogging()
+	fixture := getTestFixure(ctx)
+	netServer := fixture.netServer
+	nlDeps := fixture.nlDeps
+	pod := buildConvincingPod(false)
+
+	// Pods we hold no netns for are not checked.
+	drifted, err := netServer.CheckPodRedirection(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, false)
+	assert.Equal(t, nlDeps.AddLoopbackRoutesCnt.Load(), 0)
+
+	fixture.podNsMap.UpsertPodCacheWithNetns(string(pod.UID), WorkloadInfo{
+		Workload: podToWorkload(pod),
+		Netns:    newFakeNs(123),
+	})
+	// The fake iptables-save returns nothing, so the rules look flushed and are reinstalled.
+	drifted, err = netServer.CheckPodRedirection(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, true)
+	assert.Equal(t, nlDeps.AddLoopbackRoutesCnt.Load(), 1)
+	assert.Equal(t, nlDeps.AddInpodMarkIPRuleCnt.Load(), 1)
+
+	nlDeps.AddRouteErr = errors.New("fake error")
+	drifted, err = netServer.CheckPodRedirection(pod)
+	assert.Error(t, err)
+	assert.Equal(t, drifted, true)
+}
+
 func TestServerRemovePodAlwaysRemovesIPSetEntryEvenOnFail(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -33,6 +33,15 @@ var (
 	UseScopedIptablesLegacyLocking = env.RegisterBoolVar("AMBIENT_USE_SCOPED_XTABLES_LOCKING", true, "").Get()
 	EnableAWSBranchENIProbe        = env.RegisterBoolVar("AMBIENT_ENABLE_AWS_BRANCH_ENI_PROBE", true,
 		"If true, detect AWS VPC CNI branch ENI pods and add ip rules to route probe traffic via veth").Get()
+	ZtunnelPodPinning = env.RegisterBoolVar("AMBIENT_ZTUNNEL_POD_PINNING", false,
+		"If true, pods stay with the ztunnel that accepted them when a newer ztunnel connects, instead of all "+
+			"moving to the newer ztunnel at once. Pods then move when drained, or when their ztunnel disconnects.").Get()
+	ZtunnelDrainInterval = env.RegisterDurationVar("AMBIENT_ZTUNNEL_DRAIN_INTERVAL", 0,
+		"If pod pinning is enabled, move one pod from an older ztunnel to the newest one at this interval. "+
+			"If zero, pods are only moved on command or when their ztunnel disconnects.").Get()
+	ReconcilePodRulesInterval = env.RegisterDurationVar("AMBIENT_RECONCILE_POD_RULES_INTERVAL", 0,
+		"If set, check the in-pod redirection rules of every enrolled pod at this interval, and reinstall them "+
+			"if they no longer match the expected rules. If zero, rules are only reconciled on startup.").Get()
 )
 
 const (
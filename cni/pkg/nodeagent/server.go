@@ -53,6 +53,10 @@ type MeshDataplane interface {
 	// IP was observable (e.g. right after a node/kubelet restart).
 	SyncHostProbeIPSet(pod *corev1.Pod, podIPs []netip.Addr) error
 
+	// CheckPodRedirection verifies an enrolled pod's in-pod redirection rules against the rules it should
+	// have, and reinstalls them if they drifted. It returns true if the rules had drifted.
+	CheckPodRedirection(pod *corev1.Pod) (bool, error)
+
 	Stop(skipCleanup bool)
 }
 
@@ -121,11 +125,41 @@ func (s *Server) Start() {
 	// Start accepting ztunnel connections
 	// (and send current snapshot when we get one)
 	s.dataplane.Start(s.ctx)
+	if ReconcilePodRulesInterval > 0 {
+		go s.reconcilePodRulesPeriodically(ReconcilePodRulesInterval)
+	}
 	// Everything (informer handlers, snapshot, zt server) ready to go
 	log.Info("CNI ambient server marking ready")
 	s.Ready()
 }
 
+// reconcilePodRulesPeriodically checks the in-pod redirection rules of every enrolled pod at the given
+// interval, and repairs any that drifted (for instance, because some other tool flushed them).
+func (s *Server) reconcilePodRulesPeriodically(interval time.Duration) {
+	log.Infof("checking in-pod redirection rules of enrolled pods every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.reconcilePodRules()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Server) reconcilePodRules() {
+	for _, pod := range s.handlers.GetActiveAmbientPodSnapshot() {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if _, err := s.dataplane.CheckPodRedirection(pod); err != nil {
+			log.WithLabels("ns", pod.Namespace, "name", pod.Name).Warnf("failed to reconcile in-pod redirection rules: %v", err)
+		}
+	}
+}
+
 func (s *Server) Stop(skipCleanup bool) {
 	s.cniServerStopFunc()
 	s.dataplane.Stop(skipCleanup)
@@ -29,6 +29,7 @@ import (
 	"istio.io/istio/cni/pkg/trafficmanager"
 	"istio.io/istio/cni/pkg/util"
 	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient"
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
 )
 
@@ -100,6 +101,9 @@ func initMeshDataplane(client kube.Client, args AmbientArgs) (*meshDataplane, er
 	if err != nil {
 		return nil, fmt.Errorf("error initializing the ztunnel server: %w", err)
 	}
+	ztunnelServer.podPinning = ZtunnelPodPinning
+	ztunnelServer.drainInterval = ZtunnelDrainInterval
+	activeZtunnelServer.Store(ztunnelServer)
 
 	hostTrafficManager, podTrafficManager, err := trafficmanager.NewTrafficRuleManager(&trafficmanager.TrafficRuleManagerConfig{
 		NativeNftables: useNftables,
@@ -112,6 +116,7 @@ func initMeshDataplane(client kube.Client, args AmbientArgs) (*meshDataplane, er
 	if err != nil {
 		return nil, fmt.Errorf("error creating traffic managers: %w", err)
 	}
+	activeCaptureExplainer.Store(&captureExplainer{kubeClient: client.Kube(), trafficManager: podTrafficManager})
 
 	err = backoff.Retry(func() error {
 		if !useNftables {
@@ -138,15 +143,27 @@ func initMeshDataplane(client kube.Client, args AmbientArgs) (*meshDataplane, er
 		return nil, err
 	}
 	netServer := newNetServer(ztunnelServer, podNsMap, podTrafficManager, podNetns)
+	events := kclient.NewEventRecorder(client, "istio-cni")
 
 	return &meshDataplane{
 		kubeClient:         client.Kube(),
 		netServer:          netServer,
 		hostTrafficManager: hostTrafficManager,
 		hostAddrSet:        setManager,
+		enrollment:         newEnrollmentReporter(client.Kube(), &events, NodeName, enrollmentStatusNamespace(args)),
+		events:             &events,
 	}, nil
 }
 
+// enrollmentStatusNamespace returns the namespace the node enrollment status is written to.
+// This is the namespace the agent runs in, which is where the chart grants access to Leases.
+func enrollmentStatusNamespace(args AmbientArgs) string {
+	if PodNamespace != "" {
+		return PodNamespace
+	}
+	return args.SystemNamespace
+}
+
 // createHostNetworkAddrSetManager creates a host network addressSet manager. This is designed to be called from the host netns.
 // Note that if the set already exists by name, Create will not return an error.
 //
@@ -32,6 +32,7 @@ import (
 	set "istio.io/istio/cni/pkg/addressset"
 	"istio.io/istio/cni/pkg/ipset"
 	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/monitoring/monitortest"
 	"istio.io/istio/pkg/test/util/assert"
 )
 
@@ -162,6 +163,33 @@ func TestMeshDataplaneDoesntAnnotateOnAddWithNonretryableError(t *testing.T) {
 	assert.Equal(t, len(pod.Annotations), 0)
 }
 
+func TestMeshDataplaneCheckPodRedirectionReportsRepairs(t *testing.T) {
+	mt := monitortest.New(t)
+	pod := podWithAnnotation()
+	server := &fakeServer{}
+	server.On("CheckPodRedirection", pod).Return(false, nil).Once()
+	server.On("CheckPodRedirection", pod).Return(true, nil).Once()
+	server.On("CheckPodRedirection", pod).Return(true, errors.New("fake error")).Once()
+
+	m := getFakeDP(server, fake.NewClientset(pod))
+
+	drifted, err := m.CheckPodRedirection(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, false)
+	mt.Assert(podRulesDrift.Name(), nil, monitortest.DoesNotExist)
+
+	drifted, err = m.CheckPodRedirection(pod)
+	assert.NoError(t, err)
+	assert.Equal(t, drifted, true)
+	mt.Assert(podRulesDrift.Name(), map[string]string{"result": "repaired"}, monitortest.Exactly(1))
+
+	drifted, err = m.CheckPodRedirection(pod)
+	assert.Error(t, err)
+	assert.Equal(t, drifted, true)
+	mt.Assert(podRulesDrift.Name(), map[string]string{"result": "failed"}, monitortest.Exactly(1))
+	server.AssertExpectations(t)
+}
+
 func TestMeshDataplaneRemovePodRemovesAnnotation(t *testing.T) {
 	pod := podWithAnnotation()
 	fakeCtx := context.Background()
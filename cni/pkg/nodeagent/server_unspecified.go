@@ -51,6 +51,10 @@ func (*meshDataplane) SyncHostProbeIPSet(pod *corev1.Pod, podIPs []netip.Addr) e
 	return errNotImplemented
 }
 
+func (*meshDataplane) CheckPodRedirection(pod *corev1.Pod) (bool, error) {
+	return false, errNotImplemented
+}
+
 func (*meshDataplane) Stop(skipCleanup bool) {
 	// not supported
 	return
@@ -0,0 +1,319 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeagent
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"istio.io/istio/pkg/ctrlz/fw"
+	"istio.io/istio/pkg/zdsapi"
+)
+
+// With pod pinning enabled, a ztunnel rollout on a node goes like this:
+//
+//   - The new ztunnel connects. Its snapshot only contains pods that no connected ztunnel is serving,
+//     so every existing pod stays with the old ztunnel.
+//   - New pods are sent to the newest ztunnel, as they are today.
+//   - Existing pods are moved to the newest ztunnel one at a time, either every drainInterval, or on
+//     command through the ControlZ "ztunnel" topic (which `istioctl ztunnel-config rollout` drives).
+//     A move sends the pod to the new ztunnel, and only once that is acked removes it from the old one.
+//   - When the old ztunnel disconnects, whatever it was still serving is handed to the newest ztunnel.
+
+// ZtunnelConnectionStatus describes a single connected ztunnel.
+type ZtunnelConnectionStatus struct {
+	UUID        string    `json:"uuid"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	Pods        int       `json:"pods"`
+	// Latest is set for the connection new pods are sent to.
+	Latest bool `json:"latest"`
+}
+
+// ZtunnelRolloutStatus describes how the pods on this node are spread across connected ztunnels.
+type ZtunnelRolloutStatus struct {
+	PodPinning    bool                      `json:"podPinning"`
+	DrainInterval string                    `json:"drainInterval,omitempty"`
+	Connections   []ZtunnelConnectionStatus `json:"connections"`
+	// Unassigned counts pods known to the node agent that no connected ztunnel is serving.
+	Unassigned int `json:"unassigned"`
+}
+
+// ZtunnelDrainResult is the result of a drain request.
+type ZtunnelDrainResult struct {
+	Moved  int                  `json:"moved"`
+	Status ZtunnelRolloutStatus `json:"status"`
+}
+
+func (z *ztunnelServer) rolloutStatus() ZtunnelRolloutStatus {
+	st := ZtunnelRolloutStatus{
+		PodPinning:  z.podPinning,
+		Connections: []ZtunnelConnectionStatus{},
+	}
+	if z.podPinning && z.drainInterval > 0 {
+		st.DrainInterval = z.drainInterval.String()
+	}
+	snap := z.pods.ReadCurrentPodSnapshot()
+
+	z.conns.mu.Lock()
+	defer z.conns.mu.Unlock()
+	counts := map[ZtunnelConnection]int{}
+	for uid := range snap {
+		owner := z.conns.owners[uid]
+		if _, connected := z.conns.connectedAt[owner]; owner == nil || !connected {
+			st.Unassigned++
+			continue
+		}
+		counts[owner]++
+	}
+	for i, conn := range z.conns.connectionSet {
+		st.Connections = append(st.Connections, ZtunnelConnectionStatus{
+			UUID:        conn.UUID().String(),
+			ConnectedAt: z.conns.connectedAt[conn],
+			Pods:        counts[conn],
+			Latest:      i == len(z.conns.connectionSet)-1,
+		})
+	}
+	return st
+}
+
+// drainPods moves up to limit pods (all of them if limit <= 0) from older ztunnels to the newest one,
+// and returns how many were moved.
+func (z *ztunnelServer) drainPods(ctx context.Context, limit int) (int, error) {
+	latest, err := z.conns.LatestConn()
+	if err != nil {
+		return 0, errNoZtunnelConnection
+	}
+	snap := z.pods.ReadCurrentPodSnapshot()
+	moved := 0
+	z.conns.mu.Lock()
+	older := make([]ZtunnelConnection, 0, len(z.conns.connectionSet))
+	for _, conn := range z.conns.connectionSet {
+		if conn != latest {
+			older = append(older, conn)
+		}
+	}
+	z.conns.mu.Unlock()
+
+	for _, from := range older {
+		for _, uid := range z.conns.podsPinnedTo(from) {
+			if limit > 0 && moved >= limit {
+				return moved, nil
+			}
+			wl, ok := snap[uid]
+			if !ok || wl.Netns == nil {
+				// The pod is gone, or we never had its netns. Either way there is nothing to hand over.
+				continue
+			}
+			if err := z.movePod(ctx, uid, wl, from, latest); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// movePod hands a pod from one ztunnel to another. The pod is only removed from the old ztunnel
+// once the new one has acked it, so it is never left without a proxy.
+func (z *ztunnelServer) movePod(ctx context.Context, uid string, wl WorkloadInfo, from, to ZtunnelConnection) error {
+	log := log.WithLabels("uid", uid, "to_conn_uuid", to.UUID())
+	if from != nil {
+		log = log.WithLabels("from_conn_uuid", from.UUID())
+	}
+	if err := z.sendAdd(ctx, uid, wl, to); err != nil {
+		return fmt.Errorf("failed to move pod %s to ztunnel %s: %w", uid, to.UUID(), err)
+	}
+	z.conns.pin(uid, to)
+	log.Info("moved pod to newest ztunnel")
+
+	if from == nil {
+		return nil
+	}
+	if _, err := from.Send(ctx, &zdsapi.WorkloadRequest{
+		Payload: &zdsapi.WorkloadRequest_Del{
+			Del: &zdsapi.DelWorkload{Uid: uid},
+		},
+	}, nil); err != nil {
+		// The new ztunnel already serves the pod; a stale entry in the old one goes away when it exits.
+		log.Warnf("failed to remove moved pod from previous ztunnel: %v", err)
+	}
+	return nil
+}
+
+func (z *ztunnelServer) sendAdd(ctx context.Context, uid string, wl WorkloadInfo, to ZtunnelConnection) error {
+	fd := int(wl.Netns.Fd())
+	resp, err := to.Send(ctx, &zdsapi.WorkloadRequest{
+		Payload: &zdsapi.WorkloadRequest_Add{
+			Add: &zdsapi.AddWorkload{
+				Uid:          uid,
+				WorkloadInfo: wl.Workload,
+			},
+		},
+	}, &fd)
+	if err != nil {
+		return err
+	}
+	if resp.GetAck().GetError() != "" {
+		return fmt.Errorf("%w: %s", errZtunnelAck, resp.GetAck().GetError())
+	}
+	return nil
+}
+
+// rehomePods hands the pods that were pinned to a now disconnected ztunnel to the newest one.
+// If no ztunnel is connected, the pods are left unassigned and are sent in the next snapshot.
+func (z *ztunnelServer) rehomePods(ctx context.Context, gone ZtunnelConnection) {
+	orphans := z.conns.podsPinnedTo(gone)
+	if len(orphans) == 0 {
+		return
+	}
+	latest, err := z.conns.LatestConn()
+	if err != nil {
+		log.Infof("no ztunnel connected, %d pods will be sent with the next snapshot", len(orphans))
+		return
+	}
+	log.Infof("handing %d pods from disconnected ztunnel %s to %s", len(orphans), gone.UUID(), latest.UUID())
+	snap := z.pods.ReadCurrentPodSnapshot()
+	for _, uid := range orphans {
+		wl, ok := snap[uid]
+		if !ok || wl.Netns == nil {
+			continue
+		}
+		if err := z.movePod(ctx, uid, wl, nil, latest); err != nil {
+			log.Errorf("failed to hand over pod: %v", err)
+		}
+	}
+}
+
+func (z *ztunnelServer) drainPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(z.drainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if z.conns.len() < 2 {
+				continue
+			}
+			if _, err := z.drainPods(ctx, 1); err != nil {
+				log.Warnf("failed to drain pod to newest ztunnel: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// activeZtunnelServer is the ztunnel server the ControlZ topic reports on. The topic is registered
+// when ControlZ starts, which is before the node agent creates the server.
+var activeZtunnelServer atomic.Pointer[ztunnelServer]
+
+type ztunnelRolloutTopic struct{}
+
+// ZtunnelRolloutTopic returns a ControlZ topic that shows and controls how pods are spread across connected ztunnels.
+func ZtunnelRolloutTopic() fw.Topic {
+	return ztunnelRolloutTopic{}
+}
+
+func (ztunnelRolloutTopic) Title() string {
+	return "Ztunnel Rollout"
+}
+
+func (ztunnelRolloutTopic) Prefix() string {
+	return "ztunnel"
+}
+
+const ztunnelRolloutTemplate = `{{ define "content" }}
+{{ if . }}
+<p>Pod pinning: {{ .PodPinning }}. Pods not served by any ztunnel: {{ .Unassigned }}.</p>
+<table>
+    <thead>
+        <tr>
+            <th>Connection</th>
+            <th>Connected At</th>
+            <th>Pods</th>
+            <th>Latest</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{ range .Connections }}
+        <tr>
+            <td>{{ .UUID }}</td>
+            <td>{{ .ConnectedAt }}</td>
+            <td>{{ .Pods }}</td>
+            <td>{{ .Latest }}</td>
+        </tr>
+        {{ end }}
+    </tbody>
+</table>
+{{ else }}
+<p>The ambient node agent is not running.</p>
+{{ end }}
+{{ template "last-refresh" .}}
+{{ end }}
+`
+
+func (ztunnelRolloutTopic) Activate(context fw.TopicContext) {
+	tmpl := template.Must(context.Layout().Parse(ztunnelRolloutTemplate))
+
+	_ = context.HTMLRouter().StrictSlash(true).NewRoute().Path("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		z := activeZtunnelServer.Load()
+		if z == nil {
+			fw.RenderHTML(w, tmpl, nil)
+			return
+		}
+		st := z.rolloutStatus()
+		fw.RenderHTML(w, tmpl, &st)
+	})
+
+	_ = context.JSONRouter().StrictSlash(true).NewRoute().Methods("GET").Path("/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		z := activeZtunnelServer.Load()
+		if z == nil {
+			fw.RenderError(w, http.StatusServiceUnavailable, fmt.Errorf("ambient node agent is not running"))
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, z.rolloutStatus())
+	})
+
+	_ = context.JSONRouter().NewRoute().Methods("POST").Path("/drain").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		z := activeZtunnelServer.Load()
+		if z == nil {
+			fw.RenderError(w, http.StatusServiceUnavailable, fmt.Errorf("ambient node agent is not running"))
+			return
+		}
+		if !z.podPinning {
+			fw.RenderError(w, http.StatusBadRequest, fmt.Errorf("pod pinning is not enabled, pods already follow the newest ztunnel"))
+			return
+		}
+		limit := 0
+		if v := req.URL.Query().Get("max"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				fw.RenderError(w, http.StatusBadRequest, fmt.Errorf("invalid max %q", v))
+				return
+			}
+			limit = n
+		}
+		moved, err := z.drainPods(req.Context(), limit)
+		if err != nil {
+			fw.RenderError(w, http.StatusInternalServerError, fmt.Errorf("drained %d pods before failing: %v", moved, err))
+			return
+		}
+		fw.RenderJSON(w, http.StatusOK, ZtunnelDrainResult{Moved: moved, Status: z.rolloutStatus()})
+	})
+}
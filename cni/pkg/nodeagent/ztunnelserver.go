@@ -21,6 +21,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +36,13 @@ import (
 
 var readWriteDeadline = 5 * time.Second
 
+var (
+	// errNoZtunnelConnection is returned when a pod add is attempted while no ztunnel is connected.
+	errNoZtunnelConnection = errors.New("no ztunnel connection")
+	// errZtunnelAck is returned when ztunnel responds to a pod add with an ack error.
+	errZtunnelAck = errors.New("got ack error")
+)
+
 var ztunnelConnected = monitoring.NewGauge("ztunnel_connected",
 	"number of connections to ztunnel")
 
@@ -60,6 +68,11 @@ To clean up stale ztunnels
 type connMgr struct {
 	connectionSet []ZtunnelConnection
 	mu            sync.Mutex
+
+	// owners tracks which connection each pod (by UID) was last successfully sent to.
+	owners map[string]ZtunnelConnection
+	// connectedAt tracks when each connection in connectionSet was accepted.
+	connectedAt map[ZtunnelConnection]time.Time
 }
 
 func (c *connMgr) addConn(conn ZtunnelConnection) {
@@ -67,6 +80,10 @@ func (c *connMgr) addConn(conn ZtunnelConnection) {
 	defer c.mu.Unlock()
 	log := log.WithLabels("conn_uuid", conn.UUID())
 	c.connectionSet = append(c.connectionSet, conn)
+	if c.connectedAt == nil {
+		c.connectedAt = map[ZtunnelConnection]time.Time{}
+	}
+	c.connectedAt[conn] = time.Now()
 	log.Infof("new ztunnel connected, total connected: %v", len(c.connectionSet))
 	ztunnelConnected.RecordInt(int64(len(c.connectionSet)))
 }
@@ -98,12 +115,56 @@ func (c *connMgr) deleteConn(conn ZtunnelConnection) {
 		}
 	}
 	c.connectionSet = retainedConns
+	delete(c.connectedAt, conn)
 	log.Infof("ztunnel disconnected, total connected %s", len(c.connectionSet))
 	ztunnelConnected.RecordInt(int64(len(c.connectionSet)))
 }
 
-// this is used in tests
-// nolint: unused
+// pin records that the pod with the given UID is served by conn.
+func (c *connMgr) pin(uid string, conn ZtunnelConnection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.owners == nil {
+		c.owners = map[string]ZtunnelConnection{}
+	}
+	c.owners[uid] = conn
+}
+
+// unpin forgets which connection serves the pod with the given UID.
+func (c *connMgr) unpin(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.owners, uid)
+}
+
+// liveOwner returns the connection serving the pod with the given UID, if that connection is still connected.
+func (c *connMgr) liveOwner(uid string) ZtunnelConnection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	owner := c.owners[uid]
+	if owner == nil {
+		return nil
+	}
+	if _, connected := c.connectedAt[owner]; !connected {
+		return nil
+	}
+	return owner
+}
+
+// podsPinnedTo returns the UIDs of the pods served by conn.
+func (c *connMgr) podsPinnedTo(conn ZtunnelConnection) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var uids []string
+	for uid, owner := range c.owners {
+		if owner == conn {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	return uids
+}
+
 func (c *connMgr) len() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -119,6 +180,13 @@ type ztunnelServer struct {
 	conns             *connMgr
 	pods              PodNetnsCache
 	keepaliveInterval time.Duration
+
+	// podPinning keeps pods on the ztunnel that first accepted them when a newer ztunnel connects,
+	// instead of handing every pod to the newest ztunnel in its snapshot. See ztunnelrollout.go.
+	podPinning bool
+	// drainInterval, if non-zero and podPinning is enabled, is how often a single pod is moved
+	// from an older ztunnel to the newest one.
+	drainInterval time.Duration
 }
 
 var _ ZtunnelServer = &ztunnelServer{}
@@ -129,6 +197,9 @@ func (z *ztunnelServer) Close() error {
 
 func (z *ztunnelServer) Run(ctx context.Context) {
 	context.AfterFunc(ctx, func() { _ = z.Close() })
+	if z.podPinning && z.drainInterval > 0 {
+		go z.drainPeriodically(ctx)
+	}
 
 	// Allow at most 5 requests per second. This is still a ridiculous amount; at most we should have 2 ztunnels on our node,
 	// and they will only connect once and persist.
@@ -171,7 +242,13 @@ func (z *ztunnelServer) handleConn(ctx context.Context, conn ZtunnelConnection)
 
 	// before doing anything, add the connection to the list of active connections
 	z.conns.addConn(conn)
-	defer z.conns.deleteConn(conn)
+	defer func() {
+		z.conns.deleteConn(conn)
+		if z.podPinning {
+			// Pods pinned to this ztunnel have nowhere else to go, hand them to the newest one.
+			go z.rehomePods(ctx, conn)
+		}
+	}()
 
 	log := log.WithLabels("conn_uuid", conn.UUID())
 
@@ -267,6 +344,12 @@ func (z *ztunnelServer) sendSnapshot(_ context.Context, conn ZtunnelConnection)
 		var resp *zdsapi.WorkloadResponse
 		var err error
 		log := log.WithLabels("uid", uid)
+		if z.podPinning {
+			if owner := z.conns.liveOwner(uid); owner != nil && owner != conn {
+				log.Debugf("pod is pinned to ztunnel %s, leaving it out of the snapshot", owner.UUID())
+				continue
+			}
+		}
 		if wl.Workload != nil {
 			log = log.WithLabels(
 				"name", wl.Workload.Name,
@@ -299,6 +382,8 @@ func (z *ztunnelServer) sendSnapshot(_ context.Context, conn ZtunnelConnection)
 		}
 		if resp.GetAck().GetError() != "" {
 			log.Errorf("add-workload: got ack error: %s", resp.GetAck().GetError())
+		} else if wl.Netns != nil {
+			z.conns.pin(uid, conn)
 		}
 	}
 	resp, err := conn.SendMsgAndWaitForAck(&zdsapi.WorkloadRequest{
@@ -355,6 +440,7 @@ func (z *ztunnelServer) PodDeleted(ctx context.Context, uid string) error {
 	}
 
 	log.Debugf("sending delete pod to all ztunnels: %s %v", uid, r)
+	z.conns.unpin(uid)
 
 	var delErr []error
 
@@ -243,7 +243,7 @@ func newZtunnelServer(addr string, pods PodNetnsCache, keepaliveInterval time.Du
 func (z *ztunnelServer) PodAdded(ctx context.Context, pod *v1.Pod, netns Netns) error {
 	latestConn, err := z.conns.LatestConn()
 	if err != nil {
-		return fmt.Errorf("no ztunnel connection")
+		return errNoZtunnelConnection
 	}
 
 	uid := string(pod.ObjectMeta.UID)
@@ -276,7 +276,8 @@ func (z *ztunnelServer) PodAdded(ctx context.Context, pod *v1.Pod, netns Netns)
 
 	if resp.GetAck().GetError() != "" {
 		log.Errorf("failed to add workload: %s", resp.GetAck().GetError())
-		return fmt.Errorf("got ack error: %s", resp.GetAck().GetError())
+		return fmt.Errorf("%w: %s", errZtunnelAck, resp.GetAck().GetError())
 	}
+	z.conns.pin(uid, latestConn)
 	return nil
 }
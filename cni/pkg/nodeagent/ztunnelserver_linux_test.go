@@ -35,6 +35,7 @@ import (
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/monitoring/monitortest"
 	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
 	"istio.io/istio/pkg/zdsapi"
 )
 
@@ -526,6 +527,86 @@ func TestZtunnelLatestConnFallsBackToPreviousIfNewestDisconnects(t *testing.T) {
 	mt.Assert(ztunnelConnected.Name(), nil, monitortest.Exactly(0))
 }
 
+func TestZtunnelPodPinningDrainsToNewestZtunnel(t *testing.T) {
+	mt := monitortest.New(t)
+	setupLogging()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cache := &fakePodCache{}
+	cacheCloser := fillCacheWithFakePods(cache, 2)
+	defer cacheCloser()
+
+	srv := createStoppedServer(cache, uuid.New(), time.Second/10)
+	srv.ztunServer.podPinning = true
+	go srv.ztunServer.Run(ctx)
+	defer srv.ztunServer.Close()
+
+	// The first ztunnel gets every pod in its snapshot.
+	client1 := connectZtClientToServer(srv.addr)
+	sendHello(client1)
+	for i := 0; i < 2; i++ {
+		m, fds := readRequest(t, client1)
+		assert.Equal(t, m.GetAdd() != nil, true)
+		assert.Equal(t, len(fds), 1)
+		sendAck(client1)
+	}
+	m, _ := readRequest(t, client1)
+	assert.Equal(t, m.GetSnapshotSent() != nil, true)
+	sendAck(client1)
+
+	// The second ztunnel gets an empty snapshot, since every pod is pinned to the first.
+	client2 := connectZtClientToServer(srv.addr)
+	sendHello(client2)
+	m, _ = readRequest(t, client2)
+	assert.Equal(t, m.GetSnapshotSent() != nil, true)
+	sendAck(client2)
+	mt.Assert(ztunnelConnected.Name(), nil, monitortest.Exactly(2))
+
+	st := srv.ztunServer.rolloutStatus()
+	assert.Equal(t, len(st.Connections), 2)
+	assert.Equal(t, st.Connections[0].Pods, 2)
+	assert.Equal(t, st.Connections[1].Pods, 0)
+	assert.Equal(t, st.Connections[1].Latest, true)
+
+	// Draining a single pod adds it to the newest ztunnel before removing it from the old one.
+	drained := make(chan int)
+	go func() {
+		moved, err := srv.ztunServer.drainPods(ctx, 1)
+		assert.NoError(t, err)
+		drained <- moved
+	}()
+	m, fds := readRequest(t, client2)
+	movedUID := m.GetAdd().GetUid()
+	assert.Equal(t, len(fds), 1)
+	sendAck(client2)
+	m, _ = readRequest(t, client1)
+	assert.Equal(t, m.GetDel().GetUid(), movedUID)
+	sendAck(client1)
+	assert.Equal(t, <-drained, 1)
+
+	st = srv.ztunServer.rolloutStatus()
+	assert.Equal(t, st.Connections[0].Pods, 1)
+	assert.Equal(t, st.Connections[1].Pods, 1)
+
+	// When the old ztunnel goes away, the pod it still had is handed to the newest one.
+	client1.Close()
+	m, fds = readRequest(t, client2)
+	assert.Equal(t, m.GetAdd() != nil, true)
+	assert.Equal(t, m.GetAdd().GetUid() != movedUID, true)
+	assert.Equal(t, len(fds), 1)
+	sendAck(client2)
+
+	retry.UntilSuccessOrFail(t, func() error {
+		st := srv.ztunServer.rolloutStatus()
+		if len(st.Connections) != 1 || st.Connections[0].Pods != 2 || st.Unassigned != 0 {
+			return fmt.Errorf("unexpected status %+v", st)
+		}
+		return nil
+	}, retry.Timeout(time.Second*5))
+	client2.Close()
+}
+
 func TestZtunnelRemovePod(t *testing.T) {
 	mt := monitortest.New(t)
 	setupLogging()
@@ -22,7 +22,6 @@ import (
 	"github.com/containernetworking/plugins/pkg/ns"
 
 	"istio.io/istio/pkg/log"
-	"istio.io/istio/tools/common/config"
 	"istio.io/istio/tools/istio-iptables/pkg/cmd"
 	"istio.io/istio/tools/istio-iptables/pkg/dependencies"
 )
@@ -33,26 +32,10 @@ var getNs = ns.GetNS
 // Program defines a method which programs iptables based on the parameters
 // provided in Redirect.
 func (ipt *iptables) Program(podName, netns string, rdrct *Redirect) error {
-	cfg := config.DefaultConfig()
+	cfg := rdrct.Config()
 	cfg.HostFilesystemPodNetwork = true
 	cfg.NetworkNamespace = netns
-	cfg.ProxyPort = rdrct.targetPort
-	cfg.ProxyUID = rdrct.noRedirectUID
-	cfg.ProxyGID = rdrct.noRedirectGID
-	cfg.InboundInterceptionMode = rdrct.redirectMode
-	cfg.OutboundIPRangesInclude = rdrct.includeIPCidrs
-	cfg.InboundPortsExclude = rdrct.excludeInboundPorts
-	cfg.InboundPortsInclude = rdrct.includeInboundPorts
-	cfg.ExcludeInterfaces = rdrct.excludeInterfaces
-	cfg.OutboundPortsExclude = rdrct.excludeOutboundPorts
-	cfg.OutboundPortsInclude = rdrct.includeOutboundPorts
-	cfg.OutboundIPRangesExclude = rdrct.excludeIPCidrs
-	cfg.RerouteVirtualInterfaces = rdrct.rerouteVirtualInterfaces
 	cfg.DryRun = dependencies.DryRunFilePath.Get() != ""
-	cfg.RedirectDNS = rdrct.dnsRedirect
-	cfg.CaptureAllDNS = rdrct.dnsRedirect
-	cfg.DropInvalid = rdrct.invalidDrop
-	cfg.DualStack = rdrct.dualStack
 
 	netNs, err := getNs(netns)
 	if err != nil {
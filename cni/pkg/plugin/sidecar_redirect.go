@@ -24,6 +24,7 @@ import (
 	"istio.io/api/annotation"
 	"istio.io/istio/pkg/log"
 	netutil "istio.io/istio/pkg/util/net"
+	"istio.io/istio/tools/common/config"
 	"istio.io/istio/tools/istio-iptables/pkg/cmd"
 )
 
@@ -313,3 +314,26 @@ func NewRedirect(pi *PodInfo) (*Redirect, error) {
 	}
 	return redir, nil
 }
+
+// Config returns the capture configuration for the redirect. Settings that depend on the pod network
+// namespace, such as the pod IP, are filled in later by config.FillConfigFromEnvironment.
+func (rd *Redirect) Config() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.ProxyPort = rd.targetPort
+	cfg.ProxyUID = rd.noRedirectUID
+	cfg.ProxyGID = rd.noRedirectGID
+	cfg.InboundInterceptionMode = rd.redirectMode
+	cfg.OutboundIPRangesInclude = rd.includeIPCidrs
+	cfg.InboundPortsExclude = rd.excludeInboundPorts
+	cfg.InboundPortsInclude = rd.includeInboundPorts
+	cfg.ExcludeInterfaces = rd.excludeInterfaces
+	cfg.OutboundPortsExclude = rd.excludeOutboundPorts
+	cfg.OutboundPortsInclude = rd.includeOutboundPorts
+	cfg.OutboundIPRangesExclude = rd.excludeIPCidrs
+	cfg.RerouteVirtualInterfaces = rd.rerouteVirtualInterfaces
+	cfg.RedirectDNS = rd.dnsRedirect
+	cfg.CaptureAllDNS = rd.dnsRedirect
+	cfg.DropInvalid = rd.invalidDrop
+	cfg.DualStack = rd.dualStack
+	return cfg
+}
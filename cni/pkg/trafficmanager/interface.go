@@ -16,6 +16,7 @@ package trafficmanager
 
 import (
 	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/cni/pkg/explain"
 	"istio.io/istio/cni/pkg/iptables"
 	istiolog "istio.io/istio/pkg/log"
 )
@@ -26,6 +27,14 @@ import (
 type TrafficRuleManager interface {
 	CreateInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error
 	DeleteInpodRules(log *istiolog.Scope) error
+	// CheckInpodRules reports whether the rules in the current pod network namespace have drifted
+	// from the rules CreateInpodRules would install with the given overrides.
+	CheckInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) (bool, error)
+	// RepairInpodRules replaces whatever rules are in the current pod network namespace with the expected ones.
+	RepairInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error
+	// ExplainInpodRules renders the rules CreateInpodRules would install with the given overrides, without
+	// installing them, along with the reason each rule exists.
+	ExplainInpodRules(podOverrides config.PodLevelOverrides) (*explain.Explanation, error)
 	CreateHostRulesForHealthChecks() error
 	DeleteHostRules()
 	ReconcileModeEnabled() bool
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/cni/pkg/explain"
 	"istio.io/istio/cni/pkg/iptables"
 	istiolog "istio.io/istio/pkg/log"
 	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
@@ -89,6 +90,30 @@ func (m *IptablesTrafficManager) DeleteInpodRules(log *istiolog.Scope) error {
 	return m.podIptables.DeleteInpodRules(log)
 }
 
+// CheckInpodRules reports whether the iptables rules in a pod's network namespace have drifted
+func (m *IptablesTrafficManager) CheckInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) (bool, error) {
+	if m.podIptables == nil {
+		return false, fmt.Errorf("pod iptables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podIptables.CheckInpodRules(log, podOverrides)
+}
+
+// RepairInpodRules reinstalls the iptables rules in a pod's network namespace
+func (m *IptablesTrafficManager) RepairInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error {
+	if m.podIptables == nil {
+		return fmt.Errorf("pod iptables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podIptables.RepairInpodRules(log, podOverrides)
+}
+
+// ExplainInpodRules renders the in-pod iptables rules for the given overrides without applying them
+func (m *IptablesTrafficManager) ExplainInpodRules(podOverrides config.PodLevelOverrides) (*explain.Explanation, error) {
+	if m.podIptables == nil {
+		return nil, fmt.Errorf("pod iptables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podIptables.ExplainInpodRules(podOverrides)
+}
+
 // CreateHostRulesForHealthChecks creates host-level iptables rules for health check handling
 func (m *IptablesTrafficManager) CreateHostRulesForHealthChecks() error {
 	if m.hostIptables == nil {
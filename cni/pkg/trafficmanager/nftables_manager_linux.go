@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"istio.io/istio/cni/pkg/config"
+	"istio.io/istio/cni/pkg/explain"
 	"istio.io/istio/cni/pkg/nftables"
 	istiolog "istio.io/istio/pkg/log"
 )
@@ -72,6 +73,30 @@ func (m *NftablesTrafficManager) DeleteInpodRules(log *istiolog.Scope) error {
 	return m.podNftables.DeleteInpodRules(log)
 }
 
+// CheckInpodRules reports whether the nftables rules in a pod's network namespace have drifted
+func (m *NftablesTrafficManager) CheckInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) (bool, error) {
+	if m.podNftables == nil {
+		return false, fmt.Errorf("pod nftables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podNftables.CheckInpodRules(log, podOverrides)
+}
+
+// RepairInpodRules reinstalls the nftables rules in a pod's network namespace
+func (m *NftablesTrafficManager) RepairInpodRules(log *istiolog.Scope, podOverrides config.PodLevelOverrides) error {
+	if m.podNftables == nil {
+		return fmt.Errorf("pod nftables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podNftables.RepairInpodRules(log, podOverrides)
+}
+
+// ExplainInpodRules renders the in-pod nftables rules for the given overrides without applying them
+func (m *NftablesTrafficManager) ExplainInpodRules(podOverrides config.PodLevelOverrides) (*explain.Explanation, error) {
+	if m.podNftables == nil {
+		return nil, fmt.Errorf("pod nftables configurator not available (this is likely a host-only traffic manager)")
+	}
+	return m.podNftables.ExplainInpodRules(podOverrides)
+}
+
 // CreateHostRulesForHealthChecks creates host-level nftables rules for health check handling
 func (m *NftablesTrafficManager) CreateHostRulesForHealthChecks() error {
 	if m.hostNftables == nil {
@@ -0,0 +1,178 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+// Cmd returns the command grouping the Istio CA subcommands.
+func Cmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Interact with the Istio CA",
+	}
+	cmd.AddCommand(issuedCmd(ctx))
+	cmd.AddCommand(rootRotationCmd(ctx))
+	cmd.AddCommand(revokeCmd(ctx))
+	return cmd
+}
+
+// IssuedRecord is an issuance record, along with the Istiod instance that issued the certificate.
+type IssuedRecord struct {
+	audit.Record
+	Istiod string `json:"istiod"`
+}
+
+func issuedCmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var identity, serial, outputFormat string
+	var since time.Duration
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "issued",
+		Short: "List the certificates recently issued by the Istio CA",
+		Long: `
+Lists the workload certificates recently issued by each Istiod instance, most recent first, with the identity, serial
+and validity of each certificate and the authenticated caller that requested it.
+
+Each Istiod instance keeps the last CA_AUDIT_LOG_HISTORY_SIZE issuances in memory. If CA_AUDIT_LOG_FILE is set, the
+history is restored from that file when Istiod restarts.
+`,
+		Example: `  # List the certificates issued in the last hour
+  istioctl x ca issued --since 1h
+
+  # List the certificates issued to an identity
+  istioctl x ca issued --identity spiffe://cluster.local/ns/default/sa/reviews
+
+  # Find the issuance of a certificate by its hex encoded serial number, in JSON format
+  istioctl x ca issued --serial 5b1ef0c2a9d4 -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if outputFormat != tableOutput && outputFormat != jsonOutput {
+				return util.CommandParseError{Err: fmt.Errorf("unknown output format %q", outputFormat)}
+			}
+			if limit < 0 {
+				return util.CommandParseError{Err: fmt.Errorf("--limit must not be negative")}
+			}
+			query := url.Values{}
+			if identity != "" {
+				query.Set("identity", identity)
+			}
+			if serial != "" {
+				query.Set("serial", strings.ToLower(serial))
+			}
+			if since > 0 {
+				query.Set("since", time.Now().Add(-since).UTC().Format(time.RFC3339))
+			}
+			if limit > 0 {
+				query.Set("limit", strconv.Itoa(limit))
+			}
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), ctx.IstioNamespace(), "debug/certz?"+query.Encode())
+			if err != nil {
+				return err
+			}
+			records, err := mergeRecords(res, limit)
+			if err != nil {
+				return err
+			}
+			if outputFormat == jsonOutput {
+				out, err := json.MarshalIndent(records, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(c.OutOrStdout(), string(out))
+				return nil
+			}
+			printRecords(c.OutOrStdout(), records)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringVar(&identity, "identity", "", "Only list certificates issued for this SAN, such as a SPIFFE identity")
+	cmd.Flags().StringVar(&serial, "serial", "", "Only list the certificate with this hex encoded serial number")
+	cmd.Flags().DurationVar(&since, "since", 0, "Only list certificates issued within this duration, such as 1h")
+	cmd.Flags().IntVar(&limit, "limit", 0, "The maximum number of certificates to list. 0 lists all of them")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutput, "Output format: one of json|table")
+	return cmd
+}
+
+// mergeRecords combines the records returned by each Istiod instance, most recent first, and keeps the first limit
+// of them if limit is positive.
+func mergeRecords(res map[string][]byte, limit int) ([]IssuedRecord, error) {
+	out := []IssuedRecord{}
+	for istiod, b := range res {
+		var records []audit.Record
+		if err := json.Unmarshal(b, &records); err != nil {
+			return nil, fmt.Errorf("%s: %v", istiod, err)
+		}
+		for _, r := range records {
+			out = append(out, IssuedRecord{Record: r, Istiod: istiod})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].IssuedAt.Equal(out[j].IssuedAt) {
+			return out[i].IssuedAt.After(out[j].IssuedAt)
+		}
+		return out[i].Serial < out[j].Serial
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func printRecords(writer io.Writer, records []IssuedRecord) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISSUED\tSERIAL\tIDENTITY\tNOT AFTER\tAUTHENTICATOR\tCALLER\tISTIOD")
+	for _, r := range records {
+		caller := r.CallerPod
+		if caller == "" {
+			caller = r.ClientAddress
+		}
+		if r.Impersonated {
+			caller += " (impersonated)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.IssuedAt.UTC().Format(time.RFC3339), r.Serial,
+			strings.Join(r.SANs, ","), r.NotAfter.UTC().Format(time.RFC3339), r.Authenticator, caller, r.Istiod)
+	}
+	_ = w.Flush()
+}
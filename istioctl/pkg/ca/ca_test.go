@@ -0,0 +1,152 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+func TestIssued(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	marshal := func(records ...audit.Record) []byte {
+		b, err := json.Marshal(records)
+		assert.NoError(t, err)
+		return b
+	}
+	res := map[string][]byte{
+		"istiod-a": marshal(
+			audit.Record{
+				Serial: "3", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, IssuedAt: ts.Add(2 * time.Minute),
+				NotAfter: ts.Add(24 * time.Hour), Authenticator: "KubeJWTAuthenticator", CallerPod: "a/app",
+			},
+			audit.Record{
+				Serial: "1", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, IssuedAt: ts,
+				NotAfter: ts.Add(24 * time.Hour), Authenticator: "ClientCertAuthenticator", ClientAddress: "10.0.0.1",
+			},
+		),
+		"istiod-b": marshal(audit.Record{
+			Serial: "2", SANs: []string{"spiffe://cluster.local/ns/b/sa/b"}, IssuedAt: ts.Add(time.Minute),
+			NotAfter: ts.Add(24 * time.Hour), Authenticator: "KubeJWTAuthenticator", CallerPod: "istio-system/ztunnel",
+			Impersonated: true,
+		}),
+	}
+
+	records, err := mergeRecords(res, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[1].Istiod, "istiod-b")
+
+	records, err = mergeRecords(res, 0)
+	assert.NoError(t, err)
+	out := &bytes.Buffer{}
+	printRecords(out, records)
+	assert.Equal(t, out.String(),
+		`ISSUED                   SERIAL     IDENTITY                             NOT AFTER                AUTHENTICATOR               CALLER                                  ISTIOD
+2024-01-02T03:06:05Z     3          spiffe://cluster.local/ns/a/sa/a     2024-01-03T03:04:05Z     KubeJWTAuthenticator        a/app                                   istiod-a
+2024-01-02T03:05:05Z     2          spiffe://cluster.local/ns/b/sa/b     2024-01-03T03:04:05Z     KubeJWTAuthenticator        istio-system/ztunnel (impersonated)     istiod-b
+2024-01-02T03:04:05Z     1          spiffe://cluster.local/ns/a/sa/a     2024-01-03T03:04:05Z     ClientCertAuthenticator     10.0.0.1                                istiod-a
+`)
+
+	_, err = mergeRecords(map[string][]byte{"istiod-a": []byte("not json")}, 0)
+	assert.Error(t, err)
+}
+
+func TestRootRotation(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	marshal := func(s ca.RootRotationStatus) []byte {
+		b, err := json.Marshal(s)
+		assert.NoError(t, err)
+		return b
+	}
+	advance := func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error) {
+		return ca.NextRootRotationPhase(phase), nil
+	}
+	res := map[string][]byte{
+		"istiod-b": marshal(ca.RootRotationStatus{
+			Secret: "istio-ca-secret", Phase: ca.RootRotationDistributing, Since: ts,
+			Proxies: ca.ProxySync{Synced: 2, Pending: []string{"b/app"}}, Blocked: "1 proxies have not acknowledged the trust bundle",
+		}),
+		"istiod-a": marshal(ca.RootRotationStatus{
+			Secret: "istio-ca-secret", Phase: ca.RootRotationDistributing, Since: ts,
+			Proxies: ca.ProxySync{Synced: 3, AwaitingCertificate: []string{"a/ztunnel"}},
+		}),
+	}
+
+	statuses, err := mergeRootRotationStatuses(res)
+	assert.NoError(t, err)
+	assert.Equal(t, statuses[0].Istiod, "istiod-a")
+	out := &bytes.Buffer{}
+	printRootRotationStatuses(out, statuses)
+	assert.Equal(t, out.String(),
+		`ISTIOD       PHASE            SINCE                    SYNCED     PENDING     AWAITING CERT     BLOCKED
+istiod-a     Distributing     2024-01-02T03:04:05Z     3          0           1                 -
+istiod-b     Distributing     2024-01-02T03:04:05Z     2          1           0                 1 proxies have not acknowledged the trust bundle
+`)
+
+	// Advancing is gated on all instances, aborting is not.
+	_, _, err = checkRootRotation(statuses, advance, false)
+	assert.Error(t, err)
+	_, target, err := checkRootRotation(statuses, advance, true)
+	assert.NoError(t, err)
+	assert.Equal(t, target, ca.RootRotationSigning)
+	_, target, err = checkRootRotation(statuses, func(ca.RootRotationPhase) (ca.RootRotationPhase, error) {
+		return ca.RootRotationIdle, nil
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, target, ca.RootRotationIdle)
+
+	statuses[1].Phase = ca.RootRotationSigning
+	_, _, err = checkRootRotation(statuses, advance, true)
+	assert.Error(t, err)
+
+	_, err = mergeRootRotationStatuses(map[string][]byte{})
+	assert.Error(t, err)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := cli.NewFakeContext(&cli.NewFakeContextOption{IstioNamespace: "istio-system"})
+	cmd := revokeCmd(ctx)
+	cmd.SetArgs([]string{"--serial", "0x1F2E", "--identity", "spiffe://cluster.local/ns/a/sa/a", "--reason", "keyCompromise"})
+	cmd.SetOut(&bytes.Buffer{})
+	assert.NoError(t, cmd.Execute())
+
+	client, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(""))
+	assert.NoError(t, err)
+	s, err := client.Kube().CoreV1().Secrets("istio-system").Get(context.Background(), ca.CARevocationsSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	var revocations []ca.Revocation
+	assert.NoError(t, json.Unmarshal(s.Data[ca.RevocationsFile], &revocations))
+	assert.Equal(t, len(revocations), 2)
+	assert.Equal(t, revocations[0].Serial, "1f2e")
+	assert.Equal(t, revocations[1].Identity, "spiffe://cluster.local/ns/a/sa/a")
+	assert.Equal(t, revocations[1].Reason, "keyCompromise")
+
+	cmd = revokeCmd(ctx)
+	cmd.SetArgs([]string{"--serial", "1", "--reason", "bored"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	assert.Error(t, cmd.Execute())
+}
@@ -0,0 +1,82 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+func revokeCmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var serials, identities []string
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke certificates issued by the Istio CA",
+		Long: `
+Revokes certificates issued by the Istio CA, by serial number or by identity. The revocations are added to the
+istio-ca-revocations secret, and each Istiod instance publishes a CRL listing them on its next check.
+
+Revoking an identity revokes all the certificates issued to it until now. Each Istiod instance resolves it into the
+serials of the certificates it issued since it started and, if CA_AUDIT_LOG_FILE is set, of those in its audit log.
+Certificates issued by instances that are gone, along with their audit log, must be revoked by serial.
+
+Requires PILOT_ENABLE_CA_REVOCATION and PILOT_ENABLE_CA_CRL to be set on Istiod.
+`,
+		Example: `  # Revoke a certificate by its hex encoded serial number, found with istioctl x ca issued
+  istioctl x ca revoke --serial 5b1ef0c2a9d4
+
+  # Revoke all the certificates of an identity whose key was compromised
+  istioctl x ca revoke --identity spiffe://cluster.local/ns/default/sa/reviews --reason keyCompromise`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			revocations := []ca.Revocation{}
+			for _, serial := range serials {
+				revocations = append(revocations, ca.Revocation{Serial: serial, Reason: reason})
+			}
+			for _, identity := range identities {
+				revocations = append(revocations, ca.Revocation{Identity: identity, Reason: reason})
+			}
+			if len(revocations) == 0 {
+				return util.CommandParseError{Err: fmt.Errorf("at least one --serial or --identity is required")}
+			}
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			if err := ca.AddRevocations(context.Background(), kubeClient.Kube().CoreV1(), ctx.IstioNamespace(),
+				revocations...); err != nil {
+				return fmt.Errorf("failed to revoke certificates: %v", err)
+			}
+			_, _ = fmt.Fprintf(c.OutOrStdout(), "Added %d revocations to secret %s/%s.\n",
+				len(revocations), ctx.IstioNamespace(), ca.CARevocationsSecret)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringSliceVar(&serials, "serial", nil, "The hex encoded serial number of a certificate to revoke")
+	cmd.Flags().StringSliceVar(&identities, "identity", nil, "An identity, such as a SPIFFE identity, whose certificates to revoke")
+	cmd.Flags().StringVar(&reason, "reason", "", "The RFC 5280 revocation reason, such as keyCompromise or superseded")
+	return cmd
+}
@@ -0,0 +1,238 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+// RootRotationStatus is the state of the staged root rotation, as observed by an Istiod instance.
+type RootRotationStatus struct {
+	ca.RootRotationStatus
+	Istiod string `json:"istiod"`
+}
+
+func rootRotationCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "root-rotation",
+		Short: "Replace the self-signed root of the Istio CA in stages",
+		Long: `
+Replaces the self-signed root of the Istio CA with a root of a new key, in stages gated on the trust bundle of the
+proxies, so that mTLS keeps working between workloads holding certificates signed by either root:
+
+  1. start: the new root is added to the trust bundle, while the old root keeps signing (Distributing).
+  2. advance: once every proxy has acknowledged the new trust bundle, the new root signs (Signing).
+  3. advance: once the certificates signed by the old root have expired, the old root is retired (Idle).
+
+Each Istiod instance reports the trust bundle state of the proxies connected to it, and a phase only advances once
+all of them are ready. Requires PILOT_ENABLE_CA_STAGED_ROOT_ROTATION and ISTIO_MULTIROOT_MESH to be set on Istiod.
+`,
+	}
+	cmd.AddCommand(rootRotationStatusCmd(ctx))
+	cmd.AddCommand(rootRotationRequestCmd(ctx, "start", "Add a new root to the trust bundle",
+		func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error) {
+			if phase != ca.RootRotationIdle {
+				return "", fmt.Errorf("a rotation is already in phase %s", phase)
+			}
+			return ca.RootRotationDistributing, nil
+		}))
+	cmd.AddCommand(rootRotationRequestCmd(ctx, "advance", "Advance the rotation to its next phase, once all proxies are ready",
+		func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error) {
+			if phase == ca.RootRotationIdle {
+				return "", fmt.Errorf("no rotation is in progress, use start")
+			}
+			return ca.NextRootRotationPhase(phase), nil
+		}))
+	cmd.AddCommand(rootRotationRequestCmd(ctx, "abort", "Remove the new root from the trust bundle, before it signs",
+		func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error) {
+			if phase != ca.RootRotationDistributing {
+				return "", fmt.Errorf("only a rotation in phase %s can be aborted, not %s", ca.RootRotationDistributing, phase)
+			}
+			return ca.RootRotationIdle, nil
+		}))
+	return cmd
+}
+
+func rootRotationStatusCmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the root rotation, and of the trust bundle of the proxies",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if outputFormat != tableOutput && outputFormat != jsonOutput {
+				return util.CommandParseError{Err: fmt.Errorf("unknown output format %q", outputFormat)}
+			}
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			statuses, err := fetchRootRotationStatuses(kubeClient, ctx.IstioNamespace())
+			if err != nil {
+				return err
+			}
+			if outputFormat == jsonOutput {
+				out, err := json.MarshalIndent(statuses, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(c.OutOrStdout(), string(out))
+				return nil
+			}
+			printRootRotationStatuses(c.OutOrStdout(), statuses)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutput, "Output format: one of json|table")
+	return cmd
+}
+
+func rootRotationRequestCmd(ctx cli.Context, use, short string,
+	next func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error),
+) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var force bool
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			statuses, err := fetchRootRotationStatuses(kubeClient, ctx.IstioNamespace())
+			if err != nil {
+				return err
+			}
+			phase, target, err := checkRootRotation(statuses, next, force)
+			if err != nil {
+				return err
+			}
+			annotations := map[string]string{ca.RootRotationRequestAnnotation: string(target)}
+			if force {
+				annotations[ca.RootRotationForceAnnotation] = "true"
+			}
+			patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"annotations": annotations}})
+			if err != nil {
+				return err
+			}
+			secret := statuses[0].Secret
+			if _, err := kubeClient.Kube().CoreV1().Secrets(ctx.IstioNamespace()).Patch(context.Background(), secret,
+				types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+				return fmt.Errorf("failed to request the root rotation on secret %s: %v", secret, err)
+			}
+			_, _ = fmt.Fprintf(c.OutOrStdout(), "Requested the root rotation from phase %s to %s. "+
+				"Check its progress with istioctl x ca root-rotation status.\n", phase, target)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().BoolVar(&force, "force", false,
+		"Request the transition even if proxies are not ready. Proxies without the new trust bundle may fail mTLS")
+	return cmd
+}
+
+func fetchRootRotationStatuses(kubeClient kube.CLIClient, istioNamespace string) ([]RootRotationStatus, error) {
+	res, err := kubeClient.AllDiscoveryDo(context.Background(), istioNamespace, "debug/rootrotation")
+	if err != nil {
+		return nil, err
+	}
+	return mergeRootRotationStatuses(res)
+}
+
+// mergeRootRotationStatuses decodes the status returned by each Istiod instance, sorted by instance.
+func mergeRootRotationStatuses(res map[string][]byte) ([]RootRotationStatus, error) {
+	out := make([]RootRotationStatus, 0, len(res))
+	for istiod, b := range res {
+		s := RootRotationStatus{Istiod: istiod}
+		if err := json.Unmarshal(b, &s.RootRotationStatus); err != nil {
+			return nil, fmt.Errorf("%s: %v: %s", istiod, err, strings.TrimSpace(string(b)))
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no Istiod instance found")
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Istiod < out[j].Istiod
+	})
+	return out, nil
+}
+
+// checkRootRotation returns the phase of the rotation, if all Istiod instances agree on it, and the phase it moves
+// to. Unless force is set, a rotation only advances if all Istiod instances report it can; aborting it is not gated.
+func checkRootRotation(statuses []RootRotationStatus, next func(phase ca.RootRotationPhase) (ca.RootRotationPhase, error),
+	force bool,
+) (ca.RootRotationPhase, ca.RootRotationPhase, error) {
+	phase := statuses[0].Phase
+	var blocked []string
+	for _, s := range statuses {
+		if s.Phase != phase {
+			return "", "", fmt.Errorf("Istiod instances are in phases %s and %s, retry once they converge", phase, s.Phase)
+		}
+		if s.Requested != "" {
+			return "", "", fmt.Errorf("a transition to phase %s is already requested", s.Requested)
+		}
+		if s.Blocked != "" {
+			blocked = append(blocked, fmt.Sprintf("%s: %s", s.Istiod, s.Blocked))
+		}
+	}
+	target, err := next(phase)
+	if err != nil {
+		return "", "", err
+	}
+	if target == ca.NextRootRotationPhase(phase) && len(blocked) > 0 && !force {
+		return "", "", fmt.Errorf("the rotation can not advance yet:\n%s", strings.Join(blocked, "\n"))
+	}
+	return phase, target, nil
+}
+
+func printRootRotationStatuses(writer io.Writer, statuses []RootRotationStatus) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISTIOD\tPHASE\tSINCE\tSYNCED\tPENDING\tAWAITING CERT\tBLOCKED")
+	for _, s := range statuses {
+		since := "-"
+		if !s.Since.IsZero() {
+			since = s.Since.UTC().Format(time.RFC3339)
+		}
+		blocked := s.Blocked
+		if blocked == "" {
+			blocked = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", s.Istiod, s.Phase, since, s.Proxies.Synced,
+			len(s.Proxies.Pending), len(s.Proxies.AwaitingCertificate), blocked)
+	}
+	_ = w.Flush()
+}
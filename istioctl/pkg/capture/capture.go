@@ -0,0 +1,260 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"net/url"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/cni/pkg/explain"
+	"istio.io/istio/cni/pkg/plugin"
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/completion"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/istioctl/pkg/util/ambient"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/istioctl/pkg/ztunnelconfig"
+	"istio.io/istio/pkg/ctrlz"
+	"istio.io/istio/tools/common/config"
+	iptablescapture "istio.io/istio/tools/istio-iptables/pkg/capture"
+	"istio.io/istio/tools/istio-iptables/pkg/cmd"
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	dep "istio.io/istio/tools/istio-iptables/pkg/dependencies"
+	nftablescapture "istio.io/istio/tools/istio-nftables/pkg/capture"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	yamlOutput  = "yaml"
+
+	// istioCNIDaemonSet is the name of the istio-cni node agent DaemonSet.
+	istioCNIDaemonSet = "istio-cni-node"
+	// istioValidation is the init container that checks the rules istio-cni installed for a sidecar pod.
+	istioValidation = "istio-validation"
+)
+
+// Cmd returns the command grouping the traffic capture debugging commands.
+func Cmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "capture",
+		Short: "Inspect the traffic capture rules of pods",
+	}
+	cmd.AddCommand(explainCmd(ctx))
+	return cmd
+}
+
+func explainCmd(ctx cli.Context) *cobra.Command {
+	var outputFormat string
+	var ctrlzPort int
+
+	cmd := &cobra.Command{
+		Use:   "explain <pod-name>[.<namespace>]",
+		Short: "Shows the traffic redirection rules of a pod, and why each rule exists",
+		Long: `Shows the traffic redirection rules of a pod, in the order they are applied, along with the reason each rule exists.
+Nothing is applied or read from the pod network namespace.
+
+For ambient pods, the rules are rendered by the istio-cni node agent on the pod's node, as it would install them.
+For sidecar pods, the rules are rendered locally from the pod's istio-init container, or, when istio-cni installs
+them, from the pod annotations. Settings only known inside the pod, such as its DNS servers, are noted in the output.`,
+		Example: `  # Explain the rules of a pod
+  istioctl x capture explain productpage-v1-7f4b4f44b-6zd95.default
+
+  # Explain the rules of a pod, as JSON
+  istioctl x capture explain productpage-v1-7f4b4f44b-6zd95 -n default -o json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return util.CommandParseError{Err: fmt.Errorf("explain requires a pod name")}
+			}
+			if outputFormat != tableOutput && outputFormat != jsonOutput && outputFormat != yamlOutput {
+				return util.CommandParseError{Err: fmt.Errorf("unknown output format %q", outputFormat)}
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			podName, ns := handlers.InferPodInfo(args[0], ctx.NamespaceOrDefault(ctx.Namespace()))
+			pod, err := kubeClient.Kube().CoreV1().Pods(ns).Get(context.Background(), podName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			var ex *explain.Explanation
+			if ambient.InAmbient(pod) {
+				nsn, err := ztunnelconfig.PodOnNodeFromDaemonset(pod.Spec.NodeName, istioCNIDaemonSet, ctx.IstioNamespace(), kubeClient)
+				if err != nil {
+					return fmt.Errorf("failed to find the istio-cni pod on node %s: %v", pod.Spec.NodeName, err)
+				}
+				query := url.Values{}
+				query.Set("namespace", pod.Namespace)
+				query.Set("name", pod.Name)
+				out, err := kubeClient.EnvoyDoWithPort(context.Background(), nsn.Name, nsn.Namespace, "GET", "capturej/explain?"+query.Encode(), ctrlzPort)
+				if err != nil {
+					return fmt.Errorf("failed to explain the rules of %s/%s via %s: %v", pod.Namespace, pod.Name, nsn, err)
+				}
+				ex = &explain.Explanation{}
+				if err := json.Unmarshal(out, ex); err != nil {
+					return fmt.Errorf("failed to decode explanation: %v", err)
+				}
+			} else {
+				if ex, err = explainSidecar(pod); err != nil {
+					return err
+				}
+			}
+			return printExplanation(c.OutOrStdout(), ex, outputFormat)
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutput, "Output format: one of table|json|yaml")
+	cmd.Flags().IntVar(&ctrlzPort, "ctrlz_port", ctrlz.DefaultControlZPort, "ControlZ port of the istio-cni node agent, for ambient pods")
+	return cmd
+}
+
+// explainSidecar renders the rules of a sidecar pod from the container that installs them.
+func explainSidecar(pod *corev1.Pod) (*explain.Explanation, error) {
+	if _, injected := pod.Annotations[annotation.SidecarStatus.Name]; !injected {
+		return nil, fmt.Errorf("pod %s/%s is neither in ambient mode nor injected with a sidecar", pod.Namespace, pod.Name)
+	}
+
+	var initContainer *corev1.Container
+	for i, c := range pod.Spec.InitContainers {
+		if c.Name == plugin.ISTIOINIT || c.Name == istioValidation {
+			initContainer = &pod.Spec.InitContainers[i]
+			break
+		}
+	}
+	if initContainer == nil {
+		return nil, fmt.Errorf("pod %s/%s has no %s or %s container", pod.Namespace, pod.Name, plugin.ISTIOINIT, istioValidation)
+	}
+	environ := map[string]string{}
+	for _, e := range initContainer.Env {
+		environ[e.Name] = e.Value
+	}
+	cfg, err := cmd.ConfigFromContainer(initContainer.Args, environ)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the %s container arguments: %v", initContainer.Name, err)
+	}
+
+	ex := &explain.Explanation{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Mode:      explain.ModeSidecar,
+	}
+	if cfg.SkipRuleApply {
+		// The container only validates the rules, istio-cni installs them from the pod annotations.
+		redirect, err := plugin.NewRedirect(plugin.ExtractPodInfo(pod))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the pod redirection annotations: %v", err)
+		}
+		nativeNftables := cfg.NativeNftables
+		cfg = redirect.Config()
+		cfg.NativeNftables = nativeNftables
+		cfg.OwnerGroupsInclude = constants.OwnerGroupsInclude.DefaultValue
+		cfg.OwnerGroupsExclude = constants.OwnerGroupsExclude.DefaultValue
+		cfg.HostIPv4LoopbackCidr = constants.HostIPv4LoopbackCidr.DefaultValue
+		ex.Notes = append(ex.Notes, "The rules are installed by istio-cni, and rendered from the pod annotations.")
+	}
+	fillFromPodStatus(cfg, pod)
+	if cfg.RedirectDNS && !cfg.CaptureAllDNS {
+		ex.Notes = append(ex.Notes, "DNS capture is enabled, but the DNS servers in the pod /etc/resolv.conf are not known, so its rules are not shown.")
+	}
+
+	if cfg.NativeNftables {
+		ex.Backend = explain.BackendNftables
+		nftConfigurator, err := nftablescapture.NewNftablesConfigurator(cfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		rules, err := nftConfigurator.Explain()
+		if err != nil {
+			return nil, err
+		}
+		ex.Rules = explain.FromNftables(rules)
+		return ex, nil
+	}
+
+	ex.Backend = explain.BackendIptables
+	iptConfigurator, err := iptablescapture.NewIptablesConfigurator(cfg, &dep.DependenciesStub{})
+	if err != nil {
+		return nil, err
+	}
+	v4, v6, err := iptConfigurator.Explain()
+	if err != nil {
+		return nil, err
+	}
+	ex.Rules = append(explain.FromIptables("ipv4", v4), explain.FromIptables("ipv6", v6)...)
+	return ex, nil
+}
+
+// fillFromPodStatus fills in the settings the container detects from the pod network namespace, the same way
+// config.FillConfigFromEnvironment does.
+func fillFromPodStatus(cfg *config.Config, pod *corev1.Pod) {
+	for _, podIP := range pod.Status.PodIPs {
+		ip, err := netip.ParseAddr(podIP.IP)
+		if err != nil {
+			continue
+		}
+		if !cfg.HostIP.IsValid() {
+			cfg.HostIP = ip
+		}
+		cfg.EnableIPv6 = ip.Is6()
+		if !cfg.DualStack || cfg.EnableIPv6 {
+			return
+		}
+	}
+}
+
+func printExplanation(out io.Writer, ex *explain.Explanation, outputFormat string) error {
+	switch outputFormat {
+	case jsonOutput, yamlOutput:
+		b, err := json.MarshalIndent(ex, "", "  ")
+		if err != nil {
+			return err
+		}
+		if outputFormat == yamlOutput {
+			if b, err = yaml.JSONToYAML(b); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(out, "Pod %s/%s (%s, %s)\n", ex.Namespace, ex.Name, ex.Mode, ex.Backend)
+	for _, note := range ex.Notes {
+		_, _ = fmt.Fprintf(out, "Note: %s\n", note)
+	}
+	w := new(tabwriter.Writer).Init(out, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "FAMILY\tTABLE\tRULE\tREASON")
+	for _, r := range ex.Rules {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Family, r.Table, r.Rule, r.Reason)
+	}
+	return w.Flush()
+}
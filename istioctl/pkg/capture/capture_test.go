@@ -0,0 +1,93 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/cni/pkg/explain"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func sidecarPod(initContainer corev1.Container, annotations map[string]string) *corev1.Pod {
+	annotations[annotation.SidecarStatus.Name] = "{}"
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Annotations: annotations},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{initContainer},
+			Containers:     []corev1.Container{{Name: "app"}, {Name: "istio-proxy"}},
+		},
+		Status: corev1.PodStatus{PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}}},
+	}
+}
+
+func hasRule(ex *explain.Explanation, substr string) bool {
+	return slices.ContainsFunc(ex.Rules, func(r explain.Rule) bool {
+		return strings.Contains(r.Rule, substr)
+	})
+}
+
+func TestExplainSidecar(t *testing.T) {
+	t.Run("istio-init", func(t *testing.T) {
+		pod := sidecarPod(corev1.Container{
+			Name: "istio-init",
+			Args: []string{
+				"istio-iptables", "-p", "15001", "-z", "15006", "-u", "1337", "-m", "REDIRECT", "-i", "*", "-x", "",
+				"-b", "*", "-d", "15090,15021,15020", "-o", "3306", "--log_output_level=default:info",
+			},
+			Env: []corev1.EnvVar{{Name: "ISTIO_META_DNS_CAPTURE", Value: "true"}},
+		}, map[string]string{})
+
+		ex, err := explainSidecar(pod)
+		assert.NoError(t, err)
+		assert.Equal(t, ex.Mode, explain.ModeSidecar)
+		assert.Equal(t, ex.Backend, explain.BackendIptables)
+		assert.Equal(t, hasRule(ex, "--dport 3306 -j RETURN"), true)
+		// The DNS servers of the pod are not known, so DNS capture is left out, and noted.
+		assert.Equal(t, hasRule(ex, "--to-ports 15053"), false)
+		assert.Equal(t, len(ex.Notes), 1)
+		for _, r := range ex.Rules {
+			if r.Reason == "" {
+				t.Errorf("rule %q has no reason", r.Rule)
+			}
+		}
+	})
+
+	t.Run("istio-cni", func(t *testing.T) {
+		pod := sidecarPod(corev1.Container{
+			Name: "istio-validation",
+			Args: []string{"istio-iptables", "-p", "15001", "--run-validation", "--skip-rule-apply", "--native-nftables"},
+		}, map[string]string{annotation.SidecarTrafficExcludeOutboundPorts.Name: "5432"})
+
+		ex, err := explainSidecar(pod)
+		assert.NoError(t, err)
+		assert.Equal(t, ex.Backend, explain.BackendNftables)
+		assert.Equal(t, hasRule(ex, "tcp dport 5432"), true)
+		assert.Equal(t, len(ex.Notes), 1)
+	})
+
+	t.Run("not injected", func(t *testing.T) {
+		pod := sidecarPod(corev1.Container{Name: "istio-init"}, map[string]string{})
+		delete(pod.Annotations, annotation.SidecarStatus.Name)
+		_, err := explainSidecar(pod)
+		assert.Error(t, err)
+	})
+}
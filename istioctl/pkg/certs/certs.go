@@ -0,0 +1,211 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+// ProxyCertificates is the certificates of a proxy, along with the Istiod instance it is connected to.
+type ProxyCertificates struct {
+	xds.ProxyCertificates
+	Istiod string `json:"istiod"`
+}
+
+// Cmd returns the command listing the certificates of the proxies of the mesh.
+func Cmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var outputFormat string
+	var within time.Duration
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "List the certificates of the proxies with issues, such as near expiry or mismatched roots",
+		Long: `
+Lists the certificates served to every proxy connected to Istiod, and the proxies with certificate issues:
+
+  Expired:       the certificate expired.
+  NearExpiry:    the certificate expires within the --within duration.
+  StuckRotation: the workload certificate was not renewed after most of its lifetime.
+  RootMismatch:  the workload certificate chains to a root missing from the mesh trust bundle, or from the trust
+                 bundle acknowledged by the proxy, or the proxy did not acknowledge the current trust bundle.
+
+Workload certificates are found in the recent issuances of the Istio CA of all Istiod instances, and are not listed
+when they are issued by another CA. Gateway credentials are the ones served by Istiod with SDS.
+`,
+		Example: `  # List the proxies with certificate issues
+  istioctl x certs
+
+  # List the certificates of all proxies, reporting the ones expiring within a day
+  istioctl x certs --all --within 24h -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if outputFormat != tableOutput && outputFormat != jsonOutput {
+				return util.CommandParseError{Err: fmt.Errorf("unknown output format %q", outputFormat)}
+			}
+			if within < 0 {
+				return util.CommandParseError{Err: fmt.Errorf("--within must not be negative")}
+			}
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			query.Set("within", within.String())
+			res, err := kubeClient.AllDiscoveryDo(context.Background(), ctx.IstioNamespace(), "debug/certinventoryz?"+query.Encode())
+			if err != nil {
+				return err
+			}
+			// Proxies may get their certificates from another Istiod instance than the one they are connected to.
+			issued, err := kubeClient.AllDiscoveryDo(context.Background(), ctx.IstioNamespace(), "debug/certz")
+			if err != nil {
+				log.Warnf("failed to list the certificates issued by the Istio CA: %v", err)
+			}
+			proxies, err := mergeInventories(res, issued, time.Now(), within)
+			if err != nil {
+				return err
+			}
+			if !all {
+				proxies = withIssues(proxies)
+			}
+			if outputFormat == jsonOutput {
+				out, err := json.MarshalIndent(proxies, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(c.OutOrStdout(), string(out))
+				return nil
+			}
+			printProxies(c.OutOrStdout(), proxies, all)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().DurationVar(&within, "within", xds.DefaultCertificateExpiryWindow,
+		"Report the certificates expiring within this duration as near expiry")
+	cmd.Flags().BoolVar(&all, "all", false, "List the certificates of all proxies, not only the ones with issues")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", tableOutput, "Output format: one of json|table")
+	return cmd
+}
+
+// mergeInventories combines the inventories returned by each Istiod instance, sorted by proxy. The workload
+// certificates of each proxy are completed with the issuances of all Istiod instances, and their issues evaluated again.
+func mergeInventories(res, issued map[string][]byte, now time.Time, within time.Duration) ([]ProxyCertificates, error) {
+	var records []audit.Record
+	for istiod, b := range issued {
+		var r []audit.Record
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, fmt.Errorf("%s: %v", istiod, err)
+		}
+		records = append(records, r...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].IssuedAt.After(records[j].IssuedAt)
+	})
+	byPod := xds.IssuedCertificatesByPod(records)
+
+	out := []ProxyCertificates{}
+	for istiod, b := range res {
+		inv := xds.CertificateInventory{}
+		if err := json.Unmarshal(b, &inv); err != nil {
+			return nil, fmt.Errorf("%s: %v: %s", istiod, err, strings.TrimSpace(string(b)))
+		}
+		for _, p := range inv.Proxies {
+			if certs := byPod[p.Pod]; len(certs) > 0 {
+				served := []xds.ServedCertificate{}
+				for _, c := range p.Certificates {
+					if c.Source != xds.CertificateSourceCA {
+						served = append(served, c)
+					}
+				}
+				p.Certificates = append(served, certs...)
+			}
+			xds.EvaluateCertificates(&p, inv.MeshRoots, now, within)
+			out = append(out, ProxyCertificates{ProxyCertificates: p, Istiod: istiod})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Proxy < out[j].Proxy
+	})
+	return out, nil
+}
+
+func withIssues(proxies []ProxyCertificates) []ProxyCertificates {
+	out := []ProxyCertificates{}
+	for _, p := range proxies {
+		if len(p.Issues) > 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// printProxies prints a row per certificate with issues, or per certificate if all is set, and a row per issue of
+// the proxy not related to a certificate.
+func printProxies(writer io.Writer, proxies []ProxyCertificates, all bool) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PROXY\tSOURCE\tSERIAL\tIDENTITY\tNOT AFTER\tISSUES\tISTIOD")
+	for _, p := range proxies {
+		issues := map[string][]string{}
+		for _, i := range p.Issues {
+			issues[i.Serial] = append(issues[i.Serial], i.Kind+": "+i.Detail)
+		}
+		if len(issues[""]) > 0 {
+			_, _ = fmt.Fprintf(w, "%s\t-\t-\t%s\t-\t%s\t%s\n", p.Proxy, orDash(p.Identity), strings.Join(issues[""], "; "), p.Istiod)
+		}
+		for _, c := range p.Certificates {
+			if !all && len(issues[c.Serial]) == 0 {
+				continue
+			}
+			identity := strings.Join(c.Identities, ",")
+			if c.Name != "" {
+				identity = c.Name
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.Proxy, c.Source, c.Serial, orDash(identity),
+				c.NotAfter.UTC().Format(time.RFC3339), orDash(strings.Join(issues[c.Serial], "; ")), p.Istiod)
+		}
+	}
+	_ = w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
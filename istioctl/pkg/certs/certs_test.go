@@ -0,0 +1,84 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certs
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+func TestMergeInventories(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	marshal := func(v any) []byte {
+		b, err := json.Marshal(v)
+		assert.NoError(t, err)
+		return b
+	}
+	res := map[string][]byte{
+		"istiod-a": marshal(xds.CertificateInventory{
+			MeshRoots: []string{"root"},
+			Proxies: []xds.ProxyCertificates{{
+				Proxy: "gateway.istio-system", Pod: "istio-system/gateway", Type: "router",
+				Certificates: []xds.ServedCertificate{{
+					Source: xds.CertificateSourceSDS, Name: "kubernetes://default/tls", Serial: "a1",
+					NotBefore: now.Add(-90 * 24 * time.Hour), NotAfter: now.Add(time.Hour),
+				}},
+			}},
+		}),
+		"istiod-b": marshal(xds.CertificateInventory{
+			MeshRoots: []string{"root"},
+			Proxies: []xds.ProxyCertificates{{
+				Proxy: "app.default", Pod: "default/app", Type: "sidecar", Identity: "spiffe://cluster.local/ns/default/sa/app",
+				Certificates: []xds.ServedCertificate{},
+			}},
+		}),
+	}
+	// The sidecar connected to istiod-b got its certificate from istiod-a.
+	issued := map[string][]byte{
+		"istiod-a": marshal([]audit.Record{{
+			Serial: "b2", SANs: []string{"spiffe://cluster.local/ns/default/sa/app"}, CallerPod: "default/app", IssuedAt: now,
+			NotBefore: now, NotAfter: now.Add(24 * time.Hour), Root: "retired",
+		}}),
+	}
+
+	proxies, err := mergeInventories(res, issued, now, 6*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, len(proxies), 2)
+	assert.Equal(t, proxies[0].Proxy, "app.default")
+	assert.Equal(t, proxies[0].Certificates[0].Serial, "b2")
+
+	out := &bytes.Buffer{}
+	printProxies(out, proxies, false)
+	assert.Equal(t, out.String(),
+		`PROXY                    SOURCE     SERIAL     IDENTITY                                     NOT AFTER                ISSUES                                                         ISTIOD
+app.default              CA         b2         spiffe://cluster.local/ns/default/sa/app     2024-01-03T03:04:05Z     RootMismatch: root retired is not in the mesh trust bundle     istiod-b
+gateway.istio-system     SDS        a1         kubernetes://default/tls                     2024-01-02T04:04:05Z     NearExpiry: expires at 2024-01-02T04:04:05Z                    istiod-a
+`)
+
+	// Proxies without issues are only listed with --all.
+	assert.Equal(t, len(withIssues(proxies)), 2)
+	proxies, err = mergeInventories(res, nil, now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, len(withIssues(proxies)), 0)
+
+	_, err = mergeInventories(map[string][]byte{"istiod-a": []byte("not json")}, nil, now, time.Hour)
+	assert.Error(t, err)
+}
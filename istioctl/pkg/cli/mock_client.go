@@ -59,6 +59,10 @@ func (c MockClient) AllDiscoveryDo(_ context.Context, _, _ string) (map[string][
 	return c.Results, nil
 }
 
+func (c MockClient) AllDiscoveryDoWithBody(_ context.Context, _, _, _ string, _ []byte) (map[string][]byte, error) {
+	return c.Results, nil
+}
+
 func (c MockClient) EnvoyDoWithPort(ctx context.Context, podName, podNamespace, method, path string, port int) ([]byte, error) {
 	results, ok := c.Results[podName]
 	if !ok {
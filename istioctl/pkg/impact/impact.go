@@ -0,0 +1,179 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/pilot/pkg/xds"
+)
+
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+)
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var filenames []string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "impact",
+		Short: "Preview which proxies and resources a config change would alter",
+		Long: `
+Sends the given Istio configuration to each Istiod instance, which computes the configuration its connected proxies
+would receive if it was applied, without applying it. Lists the listeners, clusters and routes that would be added,
+removed or changed for each proxy.
+
+Configs without a namespace are placed in the namespace given by --namespace.
+`,
+		Example: `  # Preview the impact of a VirtualService change
+  istioctl x impact -f reviews-v2.yaml
+
+  # Preview the impact of configs read from stdin, in the bookinfo namespace
+  cat reviews-v2.yaml | istioctl x impact -f - -n bookinfo
+
+  # Show the impact in JSON format
+  istioctl x impact -f reviews-v2.yaml -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if len(filenames) == 0 {
+				return util.CommandParseError{Err: fmt.Errorf("at least one file must be provided with --filename")}
+			}
+			if outputFormat != tableOutput && outputFormat != jsonOutput {
+				return util.CommandParseError{Err: fmt.Errorf("unknown output format %q", outputFormat)}
+			}
+			configs, err := readFiles(filenames, c.InOrStdin())
+			if err != nil {
+				return err
+			}
+			kubeClient, err := ctx.CLIClientWithRevision(ctx.RevisionOrDefault(opts.Revision))
+			if err != nil {
+				return err
+			}
+			path := "debug/config_impact?namespace=" + url.QueryEscape(ctx.NamespaceOrDefault(ctx.Namespace()))
+			// Each instance only knows about the proxies connected to it, so all of them are asked.
+			res, err := kubeClient.AllDiscoveryDoWithBody(context.Background(), ctx.IstioNamespace(), http.MethodPost, path, configs)
+			if err != nil {
+				return err
+			}
+			impact, err := mergeImpacts(res)
+			if err != nil {
+				return err
+			}
+			if outputFormat == jsonOutput {
+				out, err := json.MarshalIndent(impact, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(c.OutOrStdout(), string(out))
+				return nil
+			}
+			printImpact(c.OutOrStdout(), impact)
+			return nil
+		},
+	}
+	opts.AttachControlPlaneFlags(cmd)
+	cmd.PersistentFlags().StringSliceVarP(&filenames, "filename", "f", nil,
+		"Files containing the Istio configuration to preview, or - to read from stdin")
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", tableOutput, "Output format: one of json|table")
+	return cmd
+}
+
+// readFiles reads and concatenates the given files into a single multi-document YAML stream.
+func readFiles(filenames []string, stdin io.Reader) ([]byte, error) {
+	var docs [][]byte
+	for _, f := range filenames {
+		var b []byte
+		var err error
+		if f == "-" {
+			b, err = io.ReadAll(stdin)
+		} else {
+			b, err = os.ReadFile(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, b)
+	}
+	return bytes.Join(docs, []byte("\n---\n")), nil
+}
+
+// mergeImpacts merges the impact reported by each Istiod instance.
+func mergeImpacts(res map[string][]byte) (*xds.ConfigImpact, error) {
+	out := &xds.ConfigImpact{Proxies: []xds.ProxyImpact{}}
+	for istiod, b := range res {
+		impact := xds.ConfigImpact{}
+		if err := json.Unmarshal(b, &impact); err != nil {
+			return nil, fmt.Errorf("invalid response from %s: %v", istiod, err)
+		}
+		out.Configs = impact.Configs
+		out.Proxies = append(out.Proxies, impact.Proxies...)
+		out.Unaffected += impact.Unaffected
+	}
+	sort.Slice(out.Proxies, func(i, j int) bool {
+		return out.Proxies[i].ID < out.Proxies[j].ID
+	})
+	return out, nil
+}
+
+func printImpact(writer io.Writer, impact *xds.ConfigImpact) {
+	if len(impact.Proxies) == 0 {
+		_, _ = fmt.Fprintf(writer, "No proxies would be affected (%d unaffected)\n", impact.Unaffected)
+		return
+	}
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PROXY\tTYPE\tCHANGE\tNAME")
+	for _, p := range impact.Proxies {
+		for _, r := range []struct {
+			typ    string
+			impact *xds.ResourceImpact
+		}{
+			{"listener", p.Listeners},
+			{"cluster", p.Clusters},
+			{"route", p.Routes},
+		} {
+			if r.impact == nil {
+				continue
+			}
+			for _, n := range r.impact.Added {
+				_, _ = fmt.Fprintf(w, "%s\t%s\tadded\t%s\n", p.ID, r.typ, n)
+			}
+			for _, n := range r.impact.Removed {
+				_, _ = fmt.Fprintf(w, "%s\t%s\tremoved\t%s\n", p.ID, r.typ, n)
+			}
+			for _, n := range r.impact.Changed {
+				_, _ = fmt.Fprintf(w, "%s\t%s\tchanged\t%s\n", p.ID, r.typ, n)
+			}
+		}
+	}
+	_ = w.Flush()
+	_, _ = fmt.Fprintf(writer, "\n%d proxies affected, %d unaffected\n", len(impact.Proxies), impact.Unaffected)
+}
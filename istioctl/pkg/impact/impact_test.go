@@ -0,0 +1,48 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impact
+
+import (
+	"bytes"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestMergeAndPrintImpacts(t *testing.T) {
+	impact, err := mergeImpacts(map[string][]byte{
+		"istiod-1": []byte(`{"configs":["VirtualService/default/reviews"],"unaffected":2,
+"proxies":[{"id":"reviews-v1.default","routes":{"changed":["9080"]}}]}`),
+		"istiod-2": []byte(`{"configs":["VirtualService/default/reviews"],"unaffected":1,
+"proxies":[{"id":"productpage.default","clusters":{"added":["outbound|9080|v2|reviews.default.svc.cluster.local"]},
+"routes":{"changed":["9080"]}}]}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, impact.Unaffected, 3)
+	assert.Equal(t, len(impact.Proxies), 2)
+
+	out := &bytes.Buffer{}
+	printImpact(out, impact)
+	assert.Equal(t, out.String(), `PROXY                   TYPE        CHANGE      NAME
+productpage.default     cluster     added       outbound|9080|v2|reviews.default.svc.cluster.local
+productpage.default     route       changed     9080
+reviews-v1.default      route       changed     9080
+
+2 proxies affected, 3 unaffected
+`)
+
+	_, err = mergeImpacts(map[string][]byte{"istiod-1": []byte("not found")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/completion"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/kube"
+)
+
+func historyConfigCmd(ctx cli.Context) *cobra.Command {
+	var from, to int
+	var diff, showDiff bool
+
+	historyCmd := &cobra.Command{
+		Use:   "history <pod-name[.namespace]>",
+		Short: "Retrieves the recently pushed configuration versions of the Envoy in the specified pod from Istiod",
+		Long: `Retrieve the versions of the listeners, routes, clusters and secrets recently pushed by Istiod to the Envoy
+instance in the specified pod, or the difference between two of them.
+
+Istiod only keeps a history if PILOT_CONFIG_HISTORY_SIZE is set.`,
+		Example: `  # List the recently pushed configuration versions of a pod.
+  istioctl proxy-config history <pod-name[.namespace]>
+
+  # Show what changed between versions 3 and 5.
+  istioctl proxy-config history <pod-name[.namespace]> --from 3 --to 5
+
+  # Show what changed in the most recent version, including the changed resources.
+  istioctl proxy-config history <pod-name[.namespace]> --diff --show-diff`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("history requires pod name")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			podName, podNamespace, err := getPodName(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			query := url.Values{"proxyID": []string{podName + "." + podNamespace}}
+			diff = diff || from > 0 || to > 0
+			if diff {
+				// An empty version is filled in by Istiod: the latest version for to, the one before to for from.
+				query.Set("from", versionParam(from))
+				query.Set("to", versionParam(to))
+			}
+			res, err := istiodProxyDebug(context.Background(), kubeClient, ctx.IstioNamespace(), "debug/config_history?"+query.Encode())
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case jsonOutput, yamlOutput:
+				return printRaw(c.OutOrStdout(), res, outputFormat)
+			case summaryOutput:
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+			if !diff {
+				var versions []xds.ConfigVersion
+				if err := json.Unmarshal(res, &versions); err != nil {
+					return err
+				}
+				printConfigVersions(c.OutOrStdout(), versions)
+				return nil
+			}
+			d := &xds.ConfigHistoryDiff{}
+			if err := json.Unmarshal(res, d); err != nil {
+				return err
+			}
+			printConfigHistoryDiff(c.OutOrStdout(), d, showDiff)
+			return nil
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+
+	historyCmd.Flags().IntVar(&from, "from", 0, "The version to compare from. Defaults to the version before --to")
+	historyCmd.Flags().IntVar(&to, "to", 0, "The version to compare to. Defaults to the latest version")
+	historyCmd.Flags().BoolVar(&diff, "diff", false, "Compare the latest version against the previous one")
+	historyCmd.Flags().BoolVar(&showDiff, "show-diff", false, "Include a diff of each changed resource")
+	return historyCmd
+}
+
+// istiodProxyDebug requests a proxy specific debug path from each Istiod instance, and returns the response of the
+// one the proxy is connected to. The other instances return an empty response.
+func istiodProxyDebug(ctx context.Context, kubeClient kube.CLIClient, istioNamespace, path string) ([]byte, error) {
+	res, err := kubeClient.AllDiscoveryDo(ctx, istioNamespace, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range res {
+		return b, nil
+	}
+	return nil, fmt.Errorf("the proxy is not connected to any Istiod instance")
+}
+
+func printRaw(w io.Writer, res []byte, format string) error {
+	if format == yamlOutput {
+		out, err := yaml.JSONToYAML(res)
+		if err != nil {
+			return err
+		}
+		_, _ = w.Write(out)
+		return nil
+	}
+	_, _ = fmt.Fprintln(w, strings.TrimSpace(string(res)))
+	return nil
+}
+
+func printConfigVersions(writer io.Writer, versions []xds.ConfigVersion) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tTIME\tPUSH VERSION\tTYPE\tRESOURCES")
+	for _, v := range versions {
+		types := make([]string, 0, len(v.Resources))
+		for t := range v.Resources {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		counts := make([]string, 0, len(types))
+		for _, t := range types {
+			counts = append(counts, fmt.Sprintf("%s:%d", t, v.Resources[t]))
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", v.Version, v.Time.Format(time.RFC3339), v.PushVersion,
+			v3.GetShortType(v.TypeURL), strings.Join(counts, ","))
+	}
+	_ = w.Flush()
+}
+
+func printConfigHistoryDiff(writer io.Writer, d *xds.ConfigHistoryDiff, showDiff bool) {
+	_, _ = fmt.Fprintf(writer, "Changes from version %d (%s) to version %d (%s)\n",
+		d.From.Version, d.From.Time.Format(time.RFC3339), d.To.Version, d.To.Time.Format(time.RFC3339))
+	var all []struct {
+		typ string
+		xds.ResourceChange
+	}
+	for _, t := range []struct {
+		typ     string
+		changes []xds.ResourceChange
+	}{
+		{"listener", d.Listeners},
+		{"route", d.Routes},
+		{"cluster", d.Clusters},
+		{"secret", d.Secrets},
+	} {
+		for _, c := range t.changes {
+			all = append(all, struct {
+				typ string
+				xds.ResourceChange
+			}{t.typ, c})
+		}
+	}
+	if len(all) == 0 {
+		_, _ = fmt.Fprintln(writer, "No changes")
+		return
+	}
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TYPE\tCHANGE\tNAME")
+	for _, c := range all {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", c.typ, c.Change, c.Name)
+	}
+	_ = w.Flush()
+	if !showDiff {
+		return
+	}
+	for _, c := range all {
+		if c.Diff != "" {
+			_, _ = fmt.Fprintf(writer, "\n%s", c.Diff)
+		}
+	}
+}
+
+func versionParam(v int) string {
+	if v <= 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
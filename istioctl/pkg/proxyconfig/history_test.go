@@ -0,0 +1,53 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyconfig
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestPrintConfigHistory(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	v1 := xds.ConfigVersion{Version: 1, PushVersion: "p1", Time: ts, TypeURL: v3.ClusterType, Resources: map[string]int{"CDS": 2}}
+	v2 := xds.ConfigVersion{Version: 2, PushVersion: "p2", Time: ts, TypeURL: v3.SecretType, Resources: map[string]int{"SDS": 1, "CDS": 2}}
+
+	out := &bytes.Buffer{}
+	printConfigVersions(out, []xds.ConfigVersion{v1, v2})
+	assert.Equal(t, out.String(), `VERSION     TIME                     PUSH VERSION     TYPE     RESOURCES
+1           2024-01-02T03:04:05Z     p1               CDS      CDS:2
+2           2024-01-02T03:04:05Z     p2               SDS      CDS:2,SDS:1
+`)
+
+	out.Reset()
+	printConfigHistoryDiff(out, &xds.ConfigHistoryDiff{
+		From:     v1,
+		To:       v2,
+		Clusters: []xds.ResourceChange{{Name: "a", Change: "added", Diff: "+a\n"}},
+		Secrets:  []xds.ResourceChange{{Name: "default", Change: "changed"}},
+	}, true)
+	assert.Equal(t, out.String(), `Changes from version 1 (2024-01-02T03:04:05Z) to version 2 (2024-01-02T03:04:05Z)
+TYPE        CHANGE      NAME
+cluster     added       a
+secret      changed     default
+
++a
+`)
+}
@@ -1356,7 +1356,7 @@ func ProxyConfig(ctx cli.Context) *cobra.Command {
 		Short: "Retrieve information about proxy configuration from Envoy [kube only]",
 		Long:  `A group of commands used to retrieve information about proxy configuration from the Envoy config dump`,
 		Example: `  # Retrieve information about proxy configuration from an Envoy instance.
-  istioctl proxy-config <clusters|listeners|routes|endpoints|ecds|bootstrap|log|secret> <pod-name[.namespace]>`,
+  istioctl proxy-config <clusters|listeners|routes|endpoints|ecds|bootstrap|log|secret|history> <pod-name[.namespace]>`,
 		Aliases: []string{"pc"},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			return istioctlutil.ValidatePort(proxyAdminPort)
@@ -1377,6 +1377,7 @@ func ProxyConfig(ctx cli.Context) *cobra.Command {
 	configCmd.AddCommand(secretConfigCmd(ctx))
 	configCmd.AddCommand(rootCACompareConfigCmd(ctx))
 	configCmd.AddCommand(ecdsConfigCmd(ctx))
+	configCmd.AddCommand(historyConfigCmd(ctx))
 
 	return configCmd
 }
@@ -31,6 +31,7 @@ import (
 
 	"istio.io/istio/istioctl/pkg/multixds"
 	"istio.io/istio/pilot/pkg/model"
+	pilotxds "istio.io/istio/pilot/pkg/xds"
 	xdsresource "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/util/protomarshal"
 )
@@ -51,6 +52,8 @@ type xdsWriterStatus struct {
 	istiodID      string
 	istiodVersion string
 	typeStatus    map[string]string // typeURL -> status
+	// flow summarizes why Istiod considers the proxy slow, or is empty if it keeps up.
+	flow string
 }
 
 const ignoredStatus = "IGNORED"
@@ -76,13 +79,17 @@ func (s *XdsStatusWriter) PrintAll(statuses map[string]*discovery.DiscoveryRespo
 
 	// Print new header
 	headers := []string{"NAME", "CLUSTER", "ISTIOD", "VERSION", "SUBSCRIBED TYPES"}
+	showSlow := anySlow(fullStatus)
+	if showSlow {
+		headers = append(headers, "SLOW")
+	}
 	if _, err := fmt.Fprintln(w, strings.Join(headers, "\t")); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
 	// Print each proxy's status in the new format
 	for _, status := range fullStatus {
-		if err := xdsStatusPrintlnSubscribedTypes(w, status, allTypes); err != nil {
+		if err := xdsStatusPrintlnSubscribedTypes(w, status, allTypes, showSlow); err != nil {
 			return fmt.Errorf("failed to print status for proxy %s: %w", status.proxyID, err)
 		}
 	}
@@ -170,13 +177,17 @@ func (s *XdsStatusWriter) printAllVerbose(statuses map[string]*discovery.Discove
 		headers = append(headers, xdsresource.GetShortType(t))
 	}
 	headers = append(headers, "ISTIOD", "VERSION")
+	showSlow := anySlow(fullStatus)
+	if showSlow {
+		headers = append(headers, "SLOW")
+	}
 	if _, err := fmt.Fprintln(w, strings.Join(headers, "\t")); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
 	// Print each proxy's status
 	for _, status := range fullStatus {
-		if err := xdsStatusPrintlnDynamic(w, status, allTypesVerbose); err != nil {
+		if err := xdsStatusPrintlnDynamic(w, status, allTypesVerbose, showSlow); err != nil {
 			return fmt.Errorf("failed to print status for proxy %s: %w", status.proxyID, err)
 		}
 	}
@@ -243,6 +254,7 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*discovery.DiscoveryRe
 				istiodID:      multixds.CpInfo(dr).ID,
 				istiodVersion: meta.IstioVersion,
 				typeStatus:    typeStatus,
+				flow:          formatFlowControl(meta.Raw[pilotxds.FlowControlMetadataKey]),
 			})
 		}
 	}
@@ -281,7 +293,7 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*discovery.DiscoveryRe
 }
 
 // xdsStatusPrintlnDynamic prints a single row of proxy status with dynamic type columns
-func xdsStatusPrintlnDynamic(w io.Writer, status *xdsWriterStatus, allTypes []string) error {
+func xdsStatusPrintlnDynamic(w io.Writer, status *xdsWriterStatus, allTypes []string, showSlow bool) error {
 	fields := []string{status.proxyID, status.clusterID}
 
 	// Add status for each type, using IGNORED if not present
@@ -294,10 +306,50 @@ func xdsStatusPrintlnDynamic(w io.Writer, status *xdsWriterStatus, allTypes []st
 	}
 
 	fields = append(fields, status.istiodID, status.istiodVersion)
+	if showSlow {
+		fields = append(fields, slowStatus(status))
+	}
 	_, err := fmt.Fprintln(w, strings.Join(fields, "\t"))
 	return err
 }
 
+// anySlow returns true if Istiod reported any of the proxies as slow.
+func anySlow(statuses []*xdsWriterStatus) bool {
+	for _, s := range statuses {
+		if s.flow != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func slowStatus(status *xdsWriterStatus) string {
+	if status.flow == "" {
+		return "-"
+	}
+	return status.flow
+}
+
+// formatFlowControl converts the flow control state reported for a slow proxy to a human-readable string.
+func formatFlowControl(v any) string {
+	flow, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+	number := func(key string) float64 {
+		n, _ := flow[key].(float64)
+		return n
+	}
+	parts := []string{
+		fmt.Sprintf("%d unacked", int(number("unacked"))),
+		"ack " + (time.Duration(number("ackLatencySeconds") * float64(time.Second))).Round(time.Millisecond).String(),
+	}
+	if coalesced := int(number("coalescedPushes")); coalesced > 0 {
+		parts = append(parts, fmt.Sprintf("%d pushes held", coalesced))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // formatStatus converts a GenericXdsConfig status to a human-readable string
 func formatStatus(s *xdsstatus.ClientConfig_GenericXdsConfig) string {
 	switch s.GetConfigStatus() {
@@ -348,7 +400,7 @@ func handleAndGetXdsConfigs(clientConfig *xdsstatus.ClientConfig) []*xdsstatus.C
 }
 
 // xdsStatusPrintlnSubscribedTypes prints a single row with the new format
-func xdsStatusPrintlnSubscribedTypes(w io.Writer, status *xdsWriterStatus, allTypes []string) error {
+func xdsStatusPrintlnSubscribedTypes(w io.Writer, status *xdsWriterStatus, allTypes []string, showSlow bool) error {
 	// Collect types with a non-IGNORED status
 	subscribed := []string{}
 	for _, t := range allTypes {
@@ -363,6 +415,9 @@ func xdsStatusPrintlnSubscribedTypes(w io.Writer, status *xdsWriterStatus, allTy
 		subscribedStr = fmt.Sprintf("%d (%s)", count, strings.Join(subscribed, ","))
 	}
 	fields := []string{status.proxyID, status.clusterID, status.istiodID, status.istiodVersion, subscribedStr}
+	if showSlow {
+		fields = append(fields, slowStatus(status))
+	}
 	_, err := fmt.Fprintln(w, strings.Join(fields, "\t"))
 	return err
 }
@@ -23,6 +23,7 @@ import (
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	status "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/util/protoconv"
@@ -130,6 +131,41 @@ func TestXdsStatusWriter_PrintAll(t *testing.T) {
 			wantFile:  "testdata/multiXdsStatusSinglePilot.txt",
 			verbosity: 0,
 		},
+		{
+			name:   "prints slow proxies",
+			format: "table",
+			input: map[string]*discovery.DiscoveryResponse{
+				"istiod1": xdsResponseInput("istiod1", []clientConfigInput{
+					{
+						proxyID:        "proxy1",
+						clusterID:      "cluster1",
+						version:        "1.20",
+						cdsSyncStatus:  status.ConfigStatus_STALE,
+						ldsSyncStatus:  status.ConfigStatus_SYNCED,
+						rdsSyncStatus:  status.ConfigStatus_SYNCED,
+						edsSyncStatus:  status.ConfigStatus_STALE,
+						ecdsSyncStatus: status.ConfigStatus_NOT_SENT,
+						flow: map[string]any{
+							"unacked":           3,
+							"ackLatencySeconds": 6.25,
+							"coalescedPushes":   2,
+						},
+					},
+					{
+						proxyID:        "proxy2",
+						clusterID:      "cluster1",
+						version:        "1.20",
+						cdsSyncStatus:  status.ConfigStatus_SYNCED,
+						ldsSyncStatus:  status.ConfigStatus_SYNCED,
+						rdsSyncStatus:  status.ConfigStatus_SYNCED,
+						edsSyncStatus:  status.ConfigStatus_SYNCED,
+						ecdsSyncStatus: status.ConfigStatus_NOT_SENT,
+					},
+				}),
+			},
+			wantFile:  "testdata/multiXdsStatusSlowProxy.txt",
+			verbosity: 0,
+		},
 		{
 			name:   "prints all known xds types at max verbosity",
 			format: "table",
@@ -253,6 +289,8 @@ type clientConfigInput struct {
 	clusterID string
 	version   string
 	namespace string
+	// flow is the flow control state Istiod reports for slow proxies.
+	flow map[string]any
 
 	cdsSyncStatus  status.ConfigStatus
 	ldsSyncStatus  status.ConfigStatus
@@ -267,10 +305,15 @@ func newXdsClientConfig(config clientConfigInput) *status.ClientConfig {
 		IstioVersion: config.version,
 		Namespace:    config.namespace,
 	}
+	md := meta.ToStruct()
+	if config.flow != nil {
+		flow, _ := structpb.NewStruct(config.flow)
+		md.Fields[xds.FlowControlMetadataKey] = structpb.NewStructValue(flow)
+	}
 	return &status.ClientConfig{
 		Node: &core.Node{
 			Id:       config.proxyID,
-			Metadata: meta.ToStruct(),
+			Metadata: md,
 		},
 		GenericXdsConfigs: []*status.ClientConfig_GenericXdsConfig{
 			{
@@ -0,0 +1,155 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ztunnelconfig
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/cni/pkg/enrollment"
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/slices"
+)
+
+func enrollmentCmd(ctx cli.Context) *cobra.Command {
+	var node, workloadsNamespace, outputFormat string
+	var failedOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "enrollment",
+		Short: "Retrieves the ambient enrollment status reported by istio-cni.",
+		Long: `Retrieve the per-pod ambient enrollment status reported by the istio-cni node agent on each node.
+
+This shows the outcome of the most recent attempt to enroll each pod, the ztunnel acknowledgement
+state, and the last error, without needing to read the istio-cni logs.`,
+		Example: `  # Retrieve the enrollment status of pods on all nodes.
+  istioctl ztunnel-config enrollment
+
+  # Retrieve the enrollment status of pods on a specific node.
+  istioctl ztunnel-config enrollment --node ambient-worker
+
+  # Only show pods that are not enrolled.
+  istioctl ztunnel-config enrollment --failed-only
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			// Each node agent publishes its status on a Lease in its own namespace, labeled for discovery.
+			leases, err := kubeClient.Kube().CoordinationV1().Leases(metav1.NamespaceAll).List(context.Background(),
+				metav1.ListOptions{LabelSelector: enrollment.StatusLabel + "=true"})
+			if err != nil {
+				return fmt.Errorf("failed to list enrollment status: %v", err)
+			}
+			var statuses []*enrollment.NodeStatus
+			for i := range leases.Items {
+				st, err := enrollment.FromLease(&leases.Items[i])
+				if err != nil {
+					return err
+				}
+				if st == nil || (node != "" && st.Node != node) {
+					continue
+				}
+				statuses = append(statuses, st)
+			}
+			if node != "" && len(statuses) == 0 {
+				return fmt.Errorf("no enrollment status reported for node %q", node)
+			}
+			filter := enrollmentFilter{namespace: workloadsNamespace, failedOnly: failedOnly}
+			switch outputFormat {
+			case summaryOutput:
+				return printEnrollmentSummary(cmd.OutOrStdout(), statuses, filter, time.Now())
+			case jsonOutput, yamlOutput:
+				return printEnrollmentDump(cmd.OutOrStdout(), statuses, filter, outputFormat)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	cmd.PersistentFlags().StringVar(&node, "node", "", "Only show pods on the given node")
+	cmd.PersistentFlags().StringVar(&workloadsNamespace, "workload-namespace", "", "Filter pods by namespace")
+	cmd.PersistentFlags().BoolVar(&failedOnly, "failed-only", false, "Only show pods that are not enrolled")
+
+	return cmd
+}
+
+type enrollmentFilter struct {
+	namespace  string
+	failedOnly bool
+}
+
+func (f enrollmentFilter) Verify(p enrollment.PodStatus) bool {
+	if f.namespace != "" && p.Namespace != f.namespace {
+		return false
+	}
+	if f.failedOnly && p.Phase == enrollment.PhaseEnrolled {
+		return false
+	}
+	return true
+}
+
+func filterEnrollment(statuses []*enrollment.NodeStatus, filter enrollmentFilter) []*enrollment.NodeStatus {
+	out := make([]*enrollment.NodeStatus, 0, len(statuses))
+	for _, st := range statuses {
+		filtered := *st
+		filtered.Pods = slices.Filter(st.Pods, filter.Verify)
+		out = append(out, &filtered)
+	}
+	slices.SortFunc(out, func(a, b *enrollment.NodeStatus) int {
+		return cmp.Compare(a.Node, b.Node)
+	})
+	return out
+}
+
+func printEnrollmentSummary(out io.Writer, statuses []*enrollment.NodeStatus, filter enrollmentFilter, now time.Time) error {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tPOD NAME\tNODE\tPHASE\tZTUNNEL\tATTEMPTS\tAGE\tLAST ERROR")
+	for _, st := range filterEnrollment(statuses, filter) {
+		for _, p := range st.Pods {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				p.Namespace, p.Name, st.Node, p.Phase, p.ZtunnelAck, p.Attempts,
+				duration.HumanDuration(now.Sub(p.LastTransitionTime)), p.LastError)
+		}
+	}
+	return w.Flush()
+}
+
+func printEnrollmentDump(out io.Writer, statuses []*enrollment.NodeStatus, filter enrollmentFilter, outputFormat string) error {
+	b, err := json.MarshalIndent(filterEnrollment(statuses, filter), "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal enrollment status: %v", err)
+	}
+	if outputFormat == yamlOutput {
+		if b, err = yaml.JSONToYAML(b); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(out, string(b))
+	return nil
+}
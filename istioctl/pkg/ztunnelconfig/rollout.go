@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ztunnelconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/ctrlz"
+)
+
+// istioCNIDaemonSet is the name of the istio-cni node agent DaemonSet.
+const istioCNIDaemonSet = "istio-cni-node"
+
+// ztunnelRolloutStatus mirrors the status served by the istio-cni node agent on its ControlZ "ztunnel" topic.
+type ztunnelRolloutStatus struct {
+	PodPinning    bool   `json:"podPinning"`
+	DrainInterval string `json:"drainInterval,omitempty"`
+	Connections   []struct {
+		UUID        string    `json:"uuid"`
+		ConnectedAt time.Time `json:"connectedAt"`
+		Pods        int       `json:"pods"`
+		Latest      bool      `json:"latest"`
+	} `json:"connections"`
+	Unassigned int `json:"unassigned"`
+}
+
+type ztunnelDrainResult struct {
+	Moved  int                  `json:"moved"`
+	Status ztunnelRolloutStatus `json:"status"`
+}
+
+func rolloutCmd(ctx cli.Context) *cobra.Command {
+	var node, outputFormat string
+	var drain int
+	var drainAll bool
+	var ctrlzPort int
+
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "Shows and controls how pods on a node are spread across ztunnel instances during an upgrade.",
+		Long: `Shows and controls how pods on a node are spread across ztunnel instances during an upgrade.
+
+When the istio-cni node agent runs with AMBIENT_ZTUNNEL_POD_PINNING enabled, a newly connected ztunnel only
+receives new pods, and existing pods stay with the ztunnel that already serves them. This command shows which
+ztunnel serves how many pods, and can move pods to the newest ztunnel.`,
+		Example: `  # Show how pods are spread across the ztunnels on a node.
+  istioctl ztunnel-config rollout --node ambient-worker
+
+  # Move 10 pods to the newest ztunnel on a node.
+  istioctl ztunnel-config rollout --node ambient-worker --drain 10
+
+  # Move every remaining pod to the newest ztunnel on a node.
+  istioctl ztunnel-config rollout --node ambient-worker --drain-all
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("rollout does not take arguments, use --node")
+			}
+			if node == "" {
+				return fmt.Errorf("--node must be set")
+			}
+			if drain < 0 {
+				return fmt.Errorf("--drain must not be negative")
+			}
+			if drain > 0 && drainAll {
+				return fmt.Errorf("at most one of --drain or --drain-all must be passed")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			nsn, err := PodOnNodeFromDaemonset(node, istioCNIDaemonSet, ctx.IstioNamespace(), kubeClient)
+			if err != nil {
+				return fmt.Errorf("failed to find the istio-cni pod on node %s: %v", node, err)
+			}
+
+			if drain == 0 && !drainAll {
+				out, err := kubeClient.EnvoyDoWithPort(context.TODO(), nsn.Name, nsn.Namespace, "GET", "ztunnelj/", ctrlzPort)
+				if err != nil {
+					return fmt.Errorf("failed to get ztunnel rollout status from %s: %v", nsn, err)
+				}
+				st := &ztunnelRolloutStatus{}
+				if err := json.Unmarshal(out, st); err != nil {
+					return fmt.Errorf("failed to decode ztunnel rollout status: %v", err)
+				}
+				return printRollout(cmd.OutOrStdout(), st, st, outputFormat, time.Now())
+			}
+
+			// --drain-all sends no limit, which the node agent treats as all pods.
+			path := "ztunnelj/drain"
+			if drain > 0 {
+				path = fmt.Sprintf("%s?max=%d", path, drain)
+			}
+			out, err := kubeClient.EnvoyDoWithPort(context.TODO(), nsn.Name, nsn.Namespace, "POST", path, ctrlzPort)
+			if err != nil {
+				return fmt.Errorf("failed to drain pods to the newest ztunnel via %s: %v", nsn, err)
+			}
+			res := &ztunnelDrainResult{}
+			if err := json.Unmarshal(out, res); err != nil {
+				return fmt.Errorf("failed to decode drain result: %v", err)
+			}
+			if outputFormat == summaryOutput {
+				fmt.Fprintf(cmd.OutOrStdout(), "Moved %d pods to the newest ztunnel.\n", res.Moved)
+			}
+			return printRollout(cmd.OutOrStdout(), res, &res.Status, outputFormat, time.Now())
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
+	cmd.PersistentFlags().StringVar(&node, "node", "", "The node whose ztunnels to show")
+	cmd.PersistentFlags().IntVar(&drain, "drain", 0, "Move up to this many pods to the newest ztunnel")
+	cmd.PersistentFlags().BoolVar(&drainAll, "drain-all", false, "Move every pod to the newest ztunnel")
+	cmd.PersistentFlags().IntVar(&ctrlzPort, "ctrlz_port", ctrlz.DefaultControlZPort, "ControlZ port of the istio-cni node agent")
+
+	return cmd
+}
+
+// printRollout prints the status as a table, or dump as json/yaml.
+func printRollout(out io.Writer, dump any, st *ztunnelRolloutStatus, outputFormat string, now time.Time) error {
+	switch outputFormat {
+	case summaryOutput:
+	case jsonOutput, yamlOutput:
+		b, err := json.MarshalIndent(dump, "", "    ")
+		if err != nil {
+			return err
+		}
+		if outputFormat == yamlOutput {
+			if b, err = yaml.JSONToYAML(b); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	default:
+		return fmt.Errorf("output format %q not supported", outputFormat)
+	}
+
+	pinning := "disabled"
+	if st.PodPinning {
+		pinning = "enabled"
+		if st.DrainInterval != "" {
+			pinning += fmt.Sprintf(", draining one pod every %s", st.DrainInterval)
+		}
+	}
+	fmt.Fprintf(out, "Pod pinning: %s\n", pinning)
+	w := new(tabwriter.Writer).Init(out, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(w, "CONNECTION\tAGE\tPODS\tLATEST")
+	for _, c := range st.Connections {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", c.UUID, duration.HumanDuration(now.Sub(c.ConnectedAt)), c.Pods, c.Latest)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if st.Unassigned > 0 {
+		fmt.Fprintf(out, "%d pods are not served by any connected ztunnel.\n", st.Unassigned)
+	}
+	return nil
+}
@@ -68,6 +68,8 @@ func ZtunnelConfig(ctx cli.Context) *cobra.Command {
 	configCmd.AddCommand(policiesCmd(ctx))
 	configCmd.AddCommand(allCmd(ctx))
 	configCmd.AddCommand(connectionsCmd(ctx))
+	configCmd.AddCommand(enrollmentCmd(ctx))
+	configCmd.AddCommand(rolloutCmd(ctx))
 
 	return configCmd
 }
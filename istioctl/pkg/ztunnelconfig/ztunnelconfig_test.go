@@ -22,11 +22,17 @@ import (
 	"testing"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/rest/fake"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
+	"istio.io/istio/cni/pkg/enrollment"
 	"istio.io/istio/istioctl/pkg/cli"
 	"istio.io/istio/pkg/kube"
 )
@@ -81,6 +87,109 @@ func TestProxyConfig(t *testing.T) {
 	}
 }
 
+func TestEnrollment(t *testing.T) {
+	status := func(namespace, node string, pods ...enrollment.PodStatus) runtime.Object {
+		data, err := (&enrollment.NodeStatus{Node: node, Pods: pods}).Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        enrollment.LeaseName(node),
+				Namespace:   namespace,
+				Labels:      map[string]string{enrollment.StatusLabel: "true"},
+				Annotations: map[string]string{enrollment.StatusAnnotation: data},
+			},
+		}
+	}
+	objects := []runtime.Object{
+		status("istio-system", "node1", enrollment.PodStatus{
+			Namespace: "default", Name: "enrolled", Phase: enrollment.PhaseEnrolled, ZtunnelAck: enrollment.AckAcked,
+		}),
+		status("kube-system", "node2", enrollment.PodStatus{
+			Namespace: "default", Name: "stuck", Phase: enrollment.PhasePending, ZtunnelAck: enrollment.AckNotConnected,
+			LastError: "no ztunnel connection",
+		}),
+		&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "node3", Namespace: "istio-system"}},
+	}
+	cases := []execTestCase{
+		{
+			args:           strings.Split("enrollment", " "),
+			expectedString: "enrolled",
+		},
+		{
+			args:           strings.Split("enrollment --failed-only", " "),
+			expectedString: "default   stuck    node2 Pending NotConnected 0",
+		},
+		{
+			args:           strings.Split("enrollment --node node3", " "),
+			expectedString: `no enrollment status reported for node "node3"`,
+			wantException:  true,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecTestOutput(t, ZtunnelConfig(cli.NewFakeContext(&cli.NewFakeContextOption{
+				Namespace: "default",
+				Objects:   objects,
+			})), c)
+		})
+	}
+}
+
+func TestRollout(t *testing.T) {
+	objects := []runtime.Object{
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio-cni-node", Namespace: "istio-system"},
+			Spec: appsv1.DaemonSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "istio-cni-node"}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "istio-cni-node-abcde",
+				Namespace: "istio-system",
+				Labels:    map[string]string{"k8s-app": "istio-cni-node"},
+			},
+			Spec: corev1.PodSpec{NodeName: "node1"},
+		},
+	}
+	status := []byte(`{"podPinning":true,"connections":[` +
+		`{"uuid":"old","connectedAt":"2024-01-01T00:00:00Z","pods":3,"latest":false},` +
+		`{"uuid":"new","connectedAt":"2024-01-01T00:00:00Z","pods":1,"latest":true}],"unassigned":0}`)
+	cases := []execTestCase{
+		{
+			execClientConfig: map[string][]byte{"istio-cni-node-abcde": status},
+			args:             strings.Split("rollout --node node1", " "),
+			expectedString:   "Pod pinning: enabled",
+		},
+		{
+			execClientConfig: map[string][]byte{"istio-cni-node-abcde": []byte(`{"moved":3,"status":{"podPinning":true}}`)},
+			args:             strings.Split("rollout --node node1 --drain-all", " "),
+			expectedString:   "Moved 3 pods to the newest ztunnel.",
+		},
+		{
+			args:           strings.Split("rollout", " "),
+			expectedString: "--node must be set",
+			wantException:  true,
+		},
+		{
+			args:           strings.Split("rollout --node node1 --drain 1 --drain-all", " "),
+			expectedString: "at most one of --drain or --drain-all must be passed",
+			wantException:  true,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecTestOutput(t, ZtunnelConfig(cli.NewFakeContext(&cli.NewFakeContextOption{
+				Results:        c.execClientConfig,
+				IstioNamespace: "istio-system",
+				Objects:        objects,
+			})), c)
+		})
+	}
+}
+
 func verifyExecTestOutput(t *testing.T, cmd *cobra.Command, c execTestCase) {
 	t.Helper()
 
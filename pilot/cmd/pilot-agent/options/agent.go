@@ -71,6 +71,7 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 		IstiodSAN:                    istiodSAN.Get(),
 		SDSFactory:                   sds,
 		WorkloadIdentitySocketFile:   workloadIdentitySocketFile,
+		SPIFFEWorkloadAPISocketPath:  spiffeWorkloadAPISocket,
 		EnvoySkipDeprecatedLogs:      envoySkipDeprecatedLogsEnv,
 	}
 	if enableWDSEnvWasSet {
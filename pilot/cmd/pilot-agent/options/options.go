@@ -59,6 +59,10 @@ var (
 	workloadIdentitySocketFile = env.Register("WORKLOAD_IDENTITY_SOCKET_FILE", security.DefaultWorkloadIdentitySocketFile,
 		fmt.Sprintf("SPIRE workload identity SDS socket filename. If set, an SDS socket with this name must exist at %s", security.WorkloadIdentityPath)).Get()
 
+	spiffeWorkloadAPISocket = env.Register("SPIFFE_WORKLOAD_API_SOCKET", "",
+		"If set, the agent serves the SPIFFE Workload API on a UDS at this path, with the workload certificate "+
+			"and trust bundle of the agent. Disabled if empty.").Get()
+
 	// set to "SYSTEM" for ACME/public signed CA servers.
 	caRootCA = env.Register("CA_ROOT_CA", "",
 		"Explicitly set the root CA to expect for the CA connection.").Get()
@@ -29,6 +29,7 @@ import (
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/ctrlz"
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/tracing"
 	"istio.io/istio/pkg/version"
 )
 
@@ -92,6 +93,14 @@ func newDiscoveryCommand() *cobra.Command {
 		RunE: func(c *cobra.Command, args []string) error {
 			cmd.PrintFlags(c.Flags())
 
+			if features.EnablePushTracing {
+				shutdown, err := tracing.Initialize()
+				if err != nil {
+					return fmt.Errorf("failed to initialize tracing: %v", err)
+				}
+				defer shutdown()
+			}
+
 			// Create the stop channel for all the servers.
 			stop := make(chan struct{})
 
@@ -200,4 +209,7 @@ func addFlags(c *cobra.Command) {
 
 	// Attach the Istio Keepalive options to the command.
 	serverArgs.KeepaliveOptions.AttachCobraFlags(c)
+
+	// Attach the Istio CA external signer options to the command.
+	serverArgs.CASignerOptions.AttachCobraFlags(c)
 }
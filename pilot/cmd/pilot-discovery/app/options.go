@@ -68,6 +68,12 @@ func validateFlags(serverArgs *bootstrap.PilotArgs) error {
 		return err
 	}
 
+	if serverArgs.CASignerOptions != nil {
+		if err := serverArgs.CASignerOptions.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if _, err := bootstrap.TLSMinVersion(serverArgs.ServerOptions.TLSOptions.TLSMinVersion); err != nil {
 		return err
 	}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -35,14 +36,18 @@ import (
 	securityModel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/env"
+	"istio.io/istio/pkg/kube/watcher/configmapwatcher"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	"istio.io/istio/security/pkg/pki/signer"
 	caserver "istio.io/istio/security/pkg/server/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
+	"istio.io/istio/security/pkg/server/ca/policy"
 	"istio.io/istio/security/pkg/util"
 )
 
@@ -54,6 +59,10 @@ type caOptions struct {
 	Namespace        string
 	Authenticators   []security.Authenticator
 	CertSignerDomain string
+	// PodName is the pod of this istiod, if known.
+	PodName string
+	// Signer configures the external signer holding the CA private key, if any.
+	Signer *signer.Options
 }
 
 // Based on istio_ca main - removing creation of Secrets with private keys in all namespaces and install complexity.
@@ -147,6 +156,42 @@ func (s *Server) initCAServer(ca caserver.CertificateAuthority, opts *caOptions)
 		log.Fatalf("failed to create istio ca server: %v", startErr)
 	}
 	s.caServer = caServer
+	caServer.Policy = policy.NewEngine(workloadCertTTL.Get())
+	if s.kubeClient != nil {
+		policyWatcher := configmapwatcher.NewController(s.kubeClient, opts.Namespace, policy.ConfigMapName,
+			caServer.Policy.UpdateFromConfigMap)
+		// Istiod is not ready until the policy is loaded, so that no certificate bypasses it.
+		s.addReadinessProbe("ca policy", policyWatcher.HasSynced)
+		s.addStartFunc("ca policy", func(stop <-chan struct{}) error {
+			go policyWatcher.Run(stop)
+			return nil
+		})
+	}
+	if features.EnableCAJWTSVID {
+		keyConfig := caserver.JWTSVIDKeyConfig{
+			Namespace:      opts.Namespace,
+			RotationPeriod: features.CAJWTSVIDKeyRotationPeriod,
+			TTL:            features.CAJWTSVIDTTL,
+		}
+		if s.kubeClient != nil {
+			// The keys are shared by the replicas through a secret.
+			keyConfig.Client = s.kubeClient.Kube().CoreV1()
+		}
+		caServer.JWTSVIDKeys = caserver.NewJWTSVIDKeys(keyConfig)
+		s.addReadinessProbe("jwt-svid keys", caServer.JWTSVIDKeys.HasSynced)
+		s.addStartFunc("jwt-svid keys", func(stop <-chan struct{}) error {
+			go caServer.JWTSVIDKeys.Run(stop)
+			return nil
+		})
+	}
+	s.XDSServer.ListIssuedCertificates = caServer.IssuanceLog().Query
+	s.addTerminatingStartFunc("ca audit log", func(stop <-chan struct{}) error {
+		<-stop
+		if err := caServer.IssuanceLog().Close(); err != nil {
+			log.Warnf("failed to close CA audit log: %v", err)
+		}
+		return nil
+	})
 }
 
 // RunCA will start the cert signing GRPC service on an existing server.
@@ -190,10 +235,35 @@ func (s *Server) RunCA(grpc *grpc.Server) {
 	}
 
 	s.caServer.Register(grpc)
+	if features.EnableCAJWTSVID {
+		s.httpMux.HandleFunc(caserver.JWKSPath, s.caServer.ServeJWKS)
+		if s.httpsMux != nil {
+			s.httpsMux.HandleFunc(caserver.JWKSPath, s.caServer.ServeJWKS)
+		}
+	}
 
 	log.Info("Istiod CA has started")
 }
 
+// lookupIssuedInAuditLog returns a function looking up the certificates issued to an identity in the audit log file,
+// including its rotated files.
+func lookupIssuedInAuditLog(file string) func(identity string, since time.Time) ([]ca.IssuedCertificate, error) {
+	return func(identity string, since time.Time) ([]ca.IssuedCertificate, error) {
+		records, err := audit.ReadFilesSince(file, since)
+		if err != nil {
+			return nil, err
+		}
+		f := audit.Filter{Identity: identity}
+		var out []ca.IssuedCertificate
+		for _, r := range records {
+			if f.Matches(r) {
+				out = append(out, ca.IssuedCertificate{Serial: r.Serial, IssuedAt: r.IssuedAt, NotAfter: r.NotAfter})
+			}
+		}
+		return out, nil
+	}
+}
+
 // detectAuthEnv will use the JWT token that is mounted in istiod to set the default audience
 // and trust domain for Istiod, if not explicitly defined.
 // K8S will use the same kind of tokens for the pods, and the value in istiod's own token is
@@ -387,13 +457,24 @@ func handleEvent(s *Server) {
 	}
 
 	// process updated root cert or crl file
-	err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleFromFile(
-		fileBundle.SigningCertFile,
-		fileBundle.SigningKeyFile,
-		fileBundle.CertChainFiles,
-		fileBundle.RootCertFile,
-		fileBundle.CRLFile,
-	)
+	if s.caSigner != nil {
+		// A rotated CA certificate is signed with the key of the external signer matching it.
+		err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleWithSignerFromFile(
+			fileBundle.SigningCertFile,
+			s.caSigner.Signer,
+			fileBundle.CertChainFiles,
+			fileBundle.RootCertFile,
+			fileBundle.CRLFile,
+		)
+	} else {
+		err = s.CA.GetCAKeyCertBundle().UpdateVerifiedKeyCertBundleFromFile(
+			fileBundle.SigningCertFile,
+			fileBundle.SigningKeyFile,
+			fileBundle.CertChainFiles,
+			fileBundle.RootCertFile,
+			fileBundle.CRLFile,
+		)
+	}
 	if err != nil {
 		log.Errorf("Failed to update new Plug-in CA certs: %v", err)
 		return
@@ -402,9 +483,12 @@ func handleEvent(s *Server) {
 		caserver.RecordCertsExpiry(s.CA.GetCAKeyCertBundle())
 	}
 
-	// notify watcher to replicate new or updated crl data
+	// notify watcher to replicate new or updated crl data. If the CA revokes certificates itself, the plugged-in CRL
+	// is merged into a new CRL signed by the CA, which is replicated instead.
 	if updateCRL {
-		s.istiodCertBundleWatcher.SetAndNotifyCACRL(s.CA.GetCAKeyCertBundle().GetCRLPem())
+		if !s.CA.RefreshCRL() {
+			s.istiodCertBundleWatcher.SetAndNotifyCACRL(s.CA.GetCAKeyCertBundle().GetCRLPem())
+		}
 		log.Infof("Istiod has detected the newly added CRL file and updated its CRL accordingly")
 	}
 
@@ -498,6 +582,16 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 		return nil, fmt.Errorf("unable to determine signing file format %v", err)
 	}
 
+	if opts.Signer != nil {
+		if s.caSigner, err = signer.New(opts.Signer); err != nil {
+			return nil, fmt.Errorf("failed to create the CA signer: %v", err)
+		}
+	}
+	if s.caSigner != nil {
+		// The private key is held by the external signer, cacerts only holds the certificates.
+		fileBundle.SigningKeyFile = ""
+	}
+
 	signingCABundleComplete, bundleExists, err := checkCABundleCompleteness(
 		fileBundle.SigningKeyFile,
 		fileBundle.SigningCertFile,
@@ -518,6 +612,10 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 	}
 
 	useSelfSignedCA := !signingCABundleComplete || (features.UseCacertsForSelfSignedCA && istioGenerated)
+	if useSelfSignedCA && s.caSigner != nil {
+		return nil, fmt.Errorf("failed to create an istiod CA: the %s signer requires the CA certificates to be plugged in "+
+			"with the %s secret", opts.Signer.Type, ca.CACertsSecret)
+	}
 	if useSelfSignedCA {
 		if features.UseCacertsForSelfSignedCA && istioGenerated {
 			log.Infof("IstioGenerated %s secret found, use it as the CA certificate", ca.CACertsSecret)
@@ -540,7 +638,13 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 		// The secret is mounted and the "istio-generated" key is not used.
 		log.Info("Use local CA certificate")
 
-		caOpts, err = ca.NewPluggedCertIstioCAOptions(fileBundle, workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), caRSAKeySize.Get())
+		if s.caSigner != nil {
+			log.Infof("Use the %s signer for the CA private key", opts.Signer.Type)
+			caOpts, err = ca.NewPluggedCertWithSignerIstioCAOptions(fileBundle, s.caSigner.Signer, workloadCertTTL.Get(),
+				maxWorkloadCertTTL.Get(), caRSAKeySize.Get())
+		} else {
+			caOpts, err = ca.NewPluggedCertIstioCAOptions(fileBundle, workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), caRSAKeySize.Get())
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to create an istiod CA: %v", err)
 		}
@@ -562,13 +666,66 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 
 		s.initCACertsAndCRLWatcher()
 	}
+	if features.EnableCARevocation && features.EnableCACRL && s.kubeClient != nil {
+		log.Info("Certificate revocation is enabled")
+		caOpts.RevocationConfig = &ca.RevocationConfig{
+			Client:        s.kubeClient.Kube().CoreV1(),
+			Namespace:     opts.Namespace,
+			CheckInterval: features.CACRLRefreshInterval,
+			CRLValidity:   features.CACRLValidity,
+			OnCRLUpdate:   s.istiodCertBundleWatcher.SetAndNotifyCACRL,
+		}
+		if auditFile := features.CAAuditLogFile; auditFile != "" {
+			// Identity revocations also resolve the certificates issued before a restart from the audit log.
+			caOpts.RevocationConfig.LookupIssued = lookupIssuedInAuditLog(auditFile)
+		}
+	}
 	istioCA, err := ca.NewIstioCA(caOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create an istiod CA: %v", err)
 	}
 
+	if features.EnableCAStagedRootRotation {
+		// The trust bundle is only pushed to the proxies, and its state observed, with multiple roots.
+		if !features.MultiRootMesh {
+			return nil, fmt.Errorf("PILOT_ENABLE_CA_STAGED_ROOT_ROTATION requires ISTIO_MULTIROOT_MESH")
+		}
+		rotationOpts := ca.StagedRootRotationOptions{
+			Replica:       opts.PodName,
+			CheckInterval: features.CAStagedRootRotationCheckInterval,
+			ProxySync:     s.XDSServer.TrustBundleSync,
+			IssuedSince:   s.XDSServer.IssuedSince,
+		}
+		if s.kubeClient != nil && opts.PodName != "" {
+			// Transitions are gated on the proxies of every replica, which report their state with a Lease each.
+			rotationOpts.Replicas = ca.NewLeaseReplicaSyncStore(s.kubeClient.Kube(), opts.Namespace, opts.PodName,
+				3*features.CAStagedRootRotationCheckInterval)
+		} else {
+			log.Warnf("POD_NAME is not set, the staged root rotation is only gated on the proxies of this istiod")
+		}
+		if err := istioCA.EnableStagedRootRotation(rotationOpts); err != nil {
+			return nil, err
+		}
+		s.XDSServer.RootRotationStatus = istioCA.RootRotationStatus
+	}
+
 	// Start root cert rotator in a separate goroutine.
 	istioCA.Run(s.internalStop)
+	if s.caSigner != nil {
+		caSigner := s.caSigner
+		// Istiod is not ready while the external signer cannot sign, as it cannot issue certificates.
+		signerHealthy := &atomic.Bool{}
+		signerHealthy.Store(true)
+		s.addReadinessProbe("ca signer", signerHealthy.Load)
+		s.addTerminatingStartFunc("ca signer", func(stop <-chan struct{}) error {
+			go signer.Monitor(stop, opts.Signer.HealthCheckInterval, istioCA.GetCAKeyCertBundle(), signerHealthy)
+			<-stop
+			if err := caSigner.Close(); err != nil {
+				log.Warnf("failed to close the CA signer: %v", err)
+			}
+			return nil
+		})
+	}
 	return istioCA, nil
 }
 
@@ -589,14 +746,14 @@ func (s *Server) createSelfSignedCACertificateOptions(fileBundle *ca.SigningCAFi
 			selfSignedRootCertCheckInterval.Get(), workloadCertTTL.Get(),
 			maxWorkloadCertTTL.Get(), opts.TrustDomain, features.UseCacertsForSelfSignedCA, true,
 			opts.Namespace, s.kubeClient.Kube().CoreV1(), fileBundle.RootCertFile,
-			enableJitterForRootCertRotator.Get(), caRSAKeySize.Get())
+			enableJitterForRootCertRotator.Get(), caRSAKeySize.Get(), features.EnableCARevocation && features.EnableCACRL)
 	} else {
 		log.Warnf(
 			"Use local self-signed CA certificate for testing. Will use in-memory root CA, no K8S access and no ca key file %s",
 			fileBundle.SigningKeyFile)
 
 		caOpts, err = ca.NewSelfSignedDebugIstioCAOptions(fileBundle.RootCertFile, SelfSignedCACertTTL.Get(),
-			workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), opts.TrustDomain, caRSAKeySize.Get())
+			workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), opts.TrustDomain, caRSAKeySize.Get(), false)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a self-signed istiod CA: %v", err)
@@ -662,6 +819,7 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 
 // checkCABundleCompleteness checks if all required CA certificate files exist
 // this function may return bundleExists as false even when some files exist in case of an error
+// an empty signingKeyFile is not required, the signing key being held by an external signer
 func checkCABundleCompleteness(
 	signingKeyFile, signingCertFile, rootCertFile string,
 	chainFiles []string,
@@ -691,7 +849,7 @@ func checkCABundleCompleteness(
 	}
 
 	bundleExists = signingKeyExists || signingCertExists || rootCertExists || chainFilesExist
-	signingCABundleComplete = signingKeyExists && signingCertExists && rootCertExists && chainFilesExist
+	signingCABundleComplete = (signingKeyExists || signingKeyFile == "") && signingCertExists && rootCertExists && chainFilesExist
 
 	return signingCABundleComplete, bundleExists, nil
 }
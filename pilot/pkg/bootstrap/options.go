@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/pkg/env"
 	"istio.io/istio/pkg/keepalive"
 	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/security/pkg/pki/signer"
 )
 
 // RegistryOptions provide configuration options for the configuration controller. If FileDir is set, that directory will
@@ -57,6 +58,7 @@ type PilotArgs struct {
 	CtrlZOptions       *ctrlz.Options
 	KrtDebugger        *krt.DebugHandler `json:"-"`
 	KeepaliveOptions   *keepalive.Options
+	CASignerOptions    *signer.Options
 	ShutdownDuration   time.Duration
 	JwtRule            string
 }
@@ -149,6 +151,7 @@ func (p *PilotArgs) applyDefaults() {
 	p.RegistryOptions.KubeOptions.Revision = Revision
 	p.JwtRule = JwtRule
 	p.KeepaliveOptions = keepalive.DefaultOption()
+	p.CASignerOptions = signer.DefaultOptions()
 	p.RegistryOptions.ClusterRegistriesNamespace = p.Namespace
 	p.KrtDebugger = new(krt.DebugHandler)
 }
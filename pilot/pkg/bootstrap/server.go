@@ -40,6 +40,7 @@ import (
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/api/security/v1beta1"
 	"istio.io/istio/pilot/pkg/config/kube/agentgateway"
+	"istio.io/istio/pilot/pkg/controllers/autowaypoint"
 	"istio.io/istio/pilot/pkg/controllers/ipallocate"
 	"istio.io/istio/pilot/pkg/controllers/untaint"
 	kubecredentials "istio.io/istio/pilot/pkg/credentials/kube"
@@ -58,6 +59,7 @@ import (
 	"istio.io/istio/pilot/pkg/status"
 	tb "istio.io/istio/pilot/pkg/trustbundle"
 	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pilot/pkg/xds/sharding"
 	"istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
@@ -83,6 +85,7 @@ import (
 	xdspkg "istio.io/istio/pkg/xds"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
+	"istio.io/istio/security/pkg/pki/signer"
 	caserver "istio.io/istio/security/pkg/server/ca"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
 	"istio.io/istio/security/pkg/server/ca/authenticate/kubeauth"
@@ -157,6 +160,8 @@ type Server struct {
 	CA       *ca.IstioCA
 	RA       ra.RegistrationAuthority
 	caServer *caserver.Server
+	// caSigner holds the CA private key outside of Istiod, if configured.
+	caSigner signer.Provider
 
 	// TrustAnchors for workload to workload mTLS and proxy to istiod TLS
 	// Only initiated when `ISTIO_MULTIROOT_MESH` = true
@@ -296,6 +301,11 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 		namespaces := kclient.New[*corev1.Namespace](s.kubeClient)
 		filter := namespace.NewDiscoveryNamespacesFilter(namespaces, s.environment.Watcher, s.internalStop)
 		s.kubeClient = kubelib.SetObjectFilter(s.kubeClient, filter)
+		if features.EnablePushPriority {
+			s.XDSServer.NamespaceLabels = func(ns string) map[string]string {
+				return namespaces.Get(ns, "").GetLabels()
+			}
+		}
 	}
 
 	s.initMeshNetworks(args, s.fileWatcher)
@@ -317,6 +327,8 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 		Namespace:        args.Namespace,
 		ExternalCAType:   ra.CaExternalType(externalCaType),
 		CertSignerDomain: features.CertSignerDomain,
+		PodName:          args.PodName,
+		Signer:           args.CASignerOptions,
 	}
 
 	if caOpts.ExternalCAType == ra.ExtCAK8s {
@@ -380,6 +392,7 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 	s.initRegistryEventHandlers()
 
 	s.initDiscoveryService()
+	s.initXDSSharding(args)
 
 	// Notice that the order of authenticators matters, since at runtime
 	// authenticators are activated sequentially and the first successful attempt
@@ -838,6 +851,29 @@ func (s *Server) initSecureDiscoveryService(args *PilotArgs, trustDomain string)
 	return nil
 }
 
+// initXDSSharding sets up the membership new xDS connections are sharded across, if enabled.
+func (s *Server) initXDSSharding(args *PilotArgs) {
+	if !features.EnableXDSSharding || s.kubeClient == nil {
+		return
+	}
+	if args.PodName == "" {
+		log.Warnf("xDS sharding is disabled, POD_NAME is not set")
+		return
+	}
+	_, port, err := net.SplitHostPort(args.ServerOptions.SecureGRPCAddr)
+	if err != nil && features.XDSShardingAddress == "" {
+		log.Warnf("xDS sharding is disabled, the secure gRPC address %q has no port: %v", args.ServerOptions.SecureGRPCAddr, err)
+		return
+	}
+	membership := sharding.NewMembership(s.kubeClient, args.Namespace, args.PodName, args.Revision,
+		features.XDSShardingAddress, port, features.XDSShardingLeaseDuration)
+	s.XDSServer.ShardMembership = membership
+	s.addStartFunc("xds sharding", func(stop <-chan struct{}) error {
+		go membership.Run(stop)
+		return nil
+	})
+}
+
 // addStartFunc appends a function to be run. These are run synchronously in order,
 // so the function should start a go routine if it needs to do anything blocking
 func (s *Server) addStartFunc(name string, fn server.Component) {
@@ -1181,6 +1217,10 @@ func (s *Server) initControllers(args *PilotArgs) error {
 		s.initIPAutoallocateController(args)
 	}
 
+	if features.EnableAmbientWaypointAutoProvisioning {
+		s.initWaypointProvisionController(args)
+	}
+
 	s.initKubeOptions(args)
 
 	if err := s.initConfigController(args); err != nil {
@@ -1222,6 +1262,24 @@ func (s *Server) initIPAutoallocateController(args *PilotArgs) {
 	})
 }
 
+func (s *Server) initWaypointProvisionController(args *PilotArgs) {
+	if s.kubeClient == nil {
+		return
+	}
+	s.addStartFunc("waypoint provision controller", func(stop <-chan struct{}) error {
+		go leaderelection.
+			NewLeaderElection(args.Namespace, args.PodName, leaderelection.WaypointProvisionController, args.Revision, s.kubeClient).
+			AddRunFunction(func(leaderStop <-chan struct{}) {
+				provisioner := autowaypoint.NewProvisioner(leaderStop, s.kubeClient, args.Revision, args.KrtDebugger)
+				// Start informers again, as the controller creates them only after acquiring the leader lock.
+				// Note: stop here should be the overall pilot stop, NOT the leader election stop.
+				s.kubeClient.RunAndWait(stop)
+				provisioner.Run(leaderStop)
+			}).Run(stop)
+		return nil
+	})
+}
+
 func (s *Server) initMulticluster(args *PilotArgs) {
 	if s.kubeClient == nil {
 		return
@@ -0,0 +1,469 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autowaypoint provisions waypoints for ambient Services that L7 configuration applies to.
+//
+// In ambient mode, an AuthorizationPolicy with HTTP rules or an HTTPRoute that targets a Service is only enforced by
+// the waypoint the Service is bound to. Without one, the configuration is silently not enforced. When enabled, this
+// controller creates the namespace waypoint for such Services and binds them to it with the istio.io/use-waypoint
+// label. The gateway deployment controller then deploys the waypoint as for any other waypoint Gateway.
+//
+// Once no L7 configuration targets a Service anymore, the binding is removed again, and the waypoint is deleted when
+// nothing else uses it.
+package autowaypoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"istio.io/api/label"
+	securityclient "istio.io/client-go/pkg/apis/security/v1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/ambient"
+	"istio.io/istio/pilot/pkg/serviceregistry/ambient/statusqueue"
+	"istio.io/istio/pkg/activenotifier"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/gvr"
+	"istio.io/istio/pkg/config/schema/kind"
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/kube/kubetypes"
+	istiolog "istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+)
+
+var log = istiolog.RegisterScope("autowaypoint", "ambient waypoint auto-provisioning controller")
+
+const (
+	controllerName = "waypoint auto-provisioner"
+
+	// ProvisionedWaypointAnnotation is set on a Service the controller bound to a waypoint, and on a waypoint the
+	// controller created, to the name of the waypoint. It distinguishes the bindings and waypoints made by the
+	// controller, which it removes again, from those made by the user. Removing it hands the object over to the user.
+	ProvisionedWaypointAnnotation = "ambient.istio.io/provisioned-waypoint"
+
+	// statusManager is the fieldManager used for the WaypointProvisioned condition.
+	statusManager = "istio-ambient-waypoint-provisioner"
+)
+
+// Reasons of the WaypointProvisioned condition.
+const (
+	ReasonProvisioned  = "Provisioned"
+	ReasonPending      = "Pending"
+	ReasonOptedOut     = "OptedOut"
+	ReasonNameConflict = "NameConflict"
+)
+
+// l7Target is a Service that an AuthorizationPolicy or HTTPRoute needs a waypoint for.
+type l7Target struct {
+	Service types.NamespacedName
+	// Source is the configuration needing the waypoint, such as "AuthorizationPolicy default/allow-get".
+	Source string
+}
+
+func (t l7Target) ResourceName() string {
+	return t.Service.String() + "/" + t.Source
+}
+
+// Provision describes what the controller does, or did, for a Service.
+type Provision struct {
+	Service    model.TypedObject
+	Generation int64
+	// Waypoint the Service is, or will be, bound to, in the Service namespace.
+	Waypoint string
+	// Sources are the configurations needing the waypoint, sorted.
+	Sources []string
+	// Reason is one of the Reason constants, or empty if the controller has nothing to do or report for the Service.
+	Reason string
+	// Deprovision is set when the controller bound the Service to Waypoint, but it no longer needs one.
+	Deprovision bool
+}
+
+func (p Provision) ResourceName() string {
+	return p.Service.NamespacedName.String()
+}
+
+func (p Provision) Equals(other Provision) bool {
+	return p.Service == other.Service &&
+		p.Generation == other.Generation &&
+		p.Waypoint == other.Waypoint &&
+		slices.Equal(p.Sources, other.Sources) &&
+		p.Reason == other.Reason &&
+		p.Deprovision == other.Deprovision
+}
+
+// impl pilot/pkg/serviceregistry/ambient/statusqueue/StatusWriter
+func (p Provision) GetStatusTarget() model.TypedObject {
+	return p.Service
+}
+
+func (p Provision) GetConditions(map[string]model.Condition) model.ConditionSet {
+	set := model.ConditionSet{
+		// Always set the condition type, so it is pruned once there is nothing to report.
+		model.WaypointProvisioned: nil,
+	}
+	if p.Reason == "" {
+		return set
+	}
+	sources := strings.Join(p.Sources, ", ")
+	waypoint := p.Service.Namespace + "/" + p.Waypoint
+	cond := &model.Condition{
+		ObservedGeneration: p.Generation,
+		Reason:             p.Reason,
+	}
+	switch p.Reason {
+	case ReasonProvisioned:
+		cond.Status = true
+		cond.Message = fmt.Sprintf("bound to waypoint %s, which was provisioned for %s", waypoint, sources)
+	case ReasonPending:
+		cond.Message = fmt.Sprintf("provisioning waypoint %s for %s", waypoint, sources)
+	case ReasonOptedOut:
+		cond.Message = fmt.Sprintf("a waypoint is needed for %s, but the Service opts out of using one with %s=none",
+			sources, label.IoIstioUseWaypoint.Name)
+	case ReasonNameConflict:
+		cond.Message = fmt.Sprintf("a waypoint is needed for %s, but the Gateway %s exists and is not a waypoint", sources, waypoint)
+	}
+	set[model.WaypointProvisioned] = cond
+	return set
+}
+
+// end impl StatusWriter
+
+type Provisioner struct {
+	services   kclient.Client[*corev1.Service]
+	gateways   kclient.Client[*gatewayv1.Gateway]
+	namespaces kclient.Client[*corev1.Namespace]
+	authz      kclient.Informer[*securityclient.AuthorizationPolicy]
+	httpRoutes kclient.Informer[*gatewayv1.HTTPRoute]
+
+	provisions  krt.Collection[Provision]
+	queue       controllers.Queue
+	statusQueue *statusqueue.StatusQueue
+	revision    string
+}
+
+func NewProvisioner(stop <-chan struct{}, c kubelib.Client, revision string, debugger *krt.DebugHandler) *Provisioner {
+	filter := kclient.Filter{ObjectFilter: c.ObjectFilter()}
+	p := &Provisioner{
+		services:   kclient.NewFiltered[*corev1.Service](c, filter),
+		gateways:   kclient.NewFilteredDelayed[*gatewayv1.Gateway](c, gvr.KubernetesGateway, filter),
+		namespaces: kclient.New[*corev1.Namespace](c),
+		authz:      kclient.NewDelayedInformer[*securityclient.AuthorizationPolicy](c, gvr.AuthorizationPolicy, kubetypes.StandardInformer, filter),
+		httpRoutes: kclient.NewDelayedInformer[*gatewayv1.HTTPRoute](c, gvr.HTTPRoute, kubetypes.StandardInformer, filter),
+		revision:   revision,
+	}
+	p.setup(stop, debugger)
+	return p
+}
+
+func (p *Provisioner) setup(stop <-chan struct{}, debugger *krt.DebugHandler) {
+	opts := krt.NewOptionsBuilder(stop, "auto-waypoint", debugger)
+	Services := krt.WrapClient[*corev1.Service](p.services, opts.WithName("informer/Services")...)
+	Gateways := krt.WrapClient[*gatewayv1.Gateway](p.gateways, opts.WithName("informer/Gateways")...)
+	Namespaces := krt.WrapClient[*corev1.Namespace](p.namespaces, opts.WithName("informer/Namespaces")...)
+	AuthzPolicies := krt.WrapClient[*securityclient.AuthorizationPolicy](p.authz, opts.WithName("informer/AuthorizationPolicies")...)
+	HTTPRoutes := krt.WrapClient[*gatewayv1.HTTPRoute](p.httpRoutes, opts.WithName("informer/HTTPRoutes")...)
+
+	AuthzTargets := krt.NewManyCollection(AuthzPolicies, authorizationPolicyTargets, opts.WithName("AuthorizationPolicyTargets")...)
+	RouteTargets := krt.NewManyCollection(HTTPRoutes, httpRouteTargets, opts.WithName("HTTPRouteTargets")...)
+	Targets := krt.JoinCollection([]krt.Collection[l7Target]{AuthzTargets, RouteTargets}, opts.WithName("L7Targets")...)
+	TargetsByService := krt.NewIndex(Targets, "service", func(t l7Target) []types.NamespacedName {
+		return []types.NamespacedName{t.Service}
+	})
+
+	p.provisions = krt.NewCollection(Services, func(ctx krt.HandlerContext, svc *corev1.Service) *Provision {
+		targets := TargetsByService.Fetch(ctx, config.NamespacedName(svc))
+		namespace := ptr.Flatten(krt.FetchOne(ctx, Namespaces, krt.FilterKey(svc.Namespace)))
+		gw := ptr.Flatten(krt.FetchOne(ctx, Gateways, krt.FilterKey(svc.Namespace+"/"+constants.DefaultNamespaceWaypoint)))
+		return buildProvision(svc, namespace, gw, targets)
+	}, opts.WithName("WaypointProvisions")...)
+
+	p.queue = controllers.NewQueue(controllerName,
+		controllers.WithReconciler(p.reconcile),
+		controllers.WithMaxAttempts(5))
+	p.provisions.Register(func(o krt.Event[Provision]) {
+		switch {
+		case o.Event == controllers.EventDelete:
+			// A deleted Service may have been the last one using its waypoint
+			if o.Old.Reason == ReasonProvisioned || o.Old.Deprovision {
+				p.queue.Add(o.Old.Service.NamespacedName)
+			}
+		case o.New.Reason == ReasonPending || o.New.Deprovision:
+			p.queue.Add(o.New.Service.NamespacedName)
+		case o.Old != nil && o.Old.Deprovision:
+			// The Service was unbound, so its waypoint may no longer be used
+			p.queue.Add(o.New.Service.NamespacedName)
+		}
+	})
+
+	if features.EnableAmbientStatus {
+		// The controller only runs while it holds the leader lock, so status is always written.
+		p.statusQueue = statusqueue.NewQueue(activenotifier.New(true))
+		statusqueue.Register(p.statusQueue, statusManager, p.provisions,
+			func(pr Provision) (kclient.Patcher, map[string]model.Condition) {
+				svc := p.services.Get(pr.Service.Name, pr.Service.Namespace)
+				if svc == nil {
+					return kclient.ToPatcher[*corev1.Service](p.services), nil
+				}
+				return kclient.ToPatcher[*corev1.Service](p.services), ambient.TranslateKubernetesConditions(svc.Status.Conditions)
+			})
+	}
+}
+
+// authorizationPolicyTargets returns the Services an AuthorizationPolicy with HTTP rules targets.
+// Only Services in the policy namespace are considered, so a policy cannot provision waypoints in other namespaces.
+func authorizationPolicyTargets(_ krt.HandlerContext, pol *securityclient.AuthorizationPolicy) []l7Target {
+	if len(ambient.HTTPAttributes(&pol.Spec)) == 0 {
+		return nil
+	}
+	var targets []l7Target
+	for _, ref := range model.GetTargetRefs(&pol.Spec) {
+		if ref.GetKind() != gvk.Service.Kind || !isCoreGroup(ref.GetGroup()) {
+			continue
+		}
+		if ref.GetNamespace() != "" && ref.GetNamespace() != pol.Namespace {
+			continue
+		}
+		targets = append(targets, l7Target{
+			Service: types.NamespacedName{Namespace: pol.Namespace, Name: ref.GetName()},
+			Source:  gvk.AuthorizationPolicy.Kind + " " + pol.Namespace + "/" + pol.Name,
+		})
+	}
+	return targets
+}
+
+// httpRouteTargets returns the Services an HTTPRoute is attached to.
+// Only Services in the route namespace are considered: a route in another namespace (a consumer route) only
+// applies to its own namespace's clients, and must not provision waypoints for Services it does not own.
+func httpRouteTargets(_ krt.HandlerContext, route *gatewayv1.HTTPRoute) []l7Target {
+	var targets []l7Target
+	for _, ref := range route.Spec.ParentRefs {
+		if string(ptr.OrEmpty(ref.Kind)) != gvk.Service.Kind || !isCoreGroup(string(ptr.OrEmpty(ref.Group))) {
+			continue
+		}
+		if ref.Namespace != nil && string(*ref.Namespace) != route.Namespace {
+			continue
+		}
+		targets = append(targets, l7Target{
+			Service: types.NamespacedName{Namespace: route.Namespace, Name: string(ref.Name)},
+			Source:  gvk.HTTPRoute.Kind + " " + route.Namespace + "/" + route.Name,
+		})
+	}
+	return targets
+}
+
+func isCoreGroup(group string) bool {
+	return group == "" || group == "core"
+}
+
+// buildProvision determines what to do for a Service, given the configuration targeting it, its namespace, and the
+// Gateway of its namespace waypoint, if it exists.
+func buildProvision(svc *corev1.Service, namespace *corev1.Namespace, gw *gatewayv1.Gateway, targets []l7Target) *Provision {
+	pr := &Provision{
+		Service:    model.TypedObject{NamespacedName: config.NamespacedName(svc), Kind: kind.Service},
+		Generation: svc.Generation,
+		Waypoint:   constants.DefaultNamespaceWaypoint,
+		Sources:    slices.Sort(slices.Map(targets, func(t l7Target) string { return t.Source })),
+	}
+	if len(targets) == 0 {
+		if provisioned, f := svc.Annotations[ProvisionedWaypointAnnotation]; f {
+			pr.Waypoint = provisioned
+			pr.Deprovision = true
+		}
+		return pr
+	}
+	if namespace == nil {
+		return pr
+	}
+	// Waypoints and east/west gateways cannot have a waypoint
+	if managed := svc.Labels[label.GatewayManaged.Name]; managed == constants.ManagedGatewayMeshControllerLabel ||
+		managed == constants.ManagedGatewayEastWestControllerLabel {
+		return pr
+	}
+	// Outside of ambient, the configuration is enforced by sidecars
+	if namespace.Labels[label.IoIstioDataplaneMode.Name] != constants.DataplaneModeAmbient {
+		return pr
+	}
+
+	wp, isNone := ambient.UseWaypointForService(svc.ObjectMeta, namespace)
+	switch {
+	case isNone:
+		pr.Reason = ReasonOptedOut
+	case wp != nil:
+		// Only report on bindings the controller made; any other is the user's
+		if provisioned, f := svc.Annotations[ProvisionedWaypointAnnotation]; f && provisioned == wp.Name && wp.Namespace == svc.Namespace {
+			pr.Waypoint = wp.Name
+			pr.Reason = ReasonProvisioned
+		}
+	case gw != nil && gw.Spec.GatewayClassName != constants.WaypointGatewayClassName:
+		pr.Reason = ReasonNameConflict
+	default:
+		pr.Reason = ReasonPending
+	}
+	return pr
+}
+
+func (p *Provisioner) HasSynced() bool {
+	return p.queue.HasSynced()
+}
+
+func (p *Provisioner) Run(stop <-chan struct{}) {
+	kubelib.WaitForCacheSync(controllerName, stop,
+		p.services.HasSynced, p.gateways.HasSynced, p.namespaces.HasSynced, p.authz.HasSynced, p.httpRoutes.HasSynced)
+	if p.statusQueue != nil {
+		go p.statusQueue.Run(stop)
+	}
+	p.queue.Run(stop)
+	controllers.ShutdownAll(p.services, p.gateways, p.namespaces, p.authz, p.httpRoutes)
+}
+
+func (p *Provisioner) reconcile(key types.NamespacedName) error {
+	log := log.WithLabels("service", key)
+	pr := p.provisions.GetKey(key.String())
+	switch {
+	case pr == nil || (pr.Reason == "" && !pr.Deprovision):
+		// The Service was deleted or unbound
+		return p.cleanupWaypoint(key.Namespace, constants.DefaultNamespaceWaypoint)
+	case pr.Deprovision:
+		return p.unbind(key, pr.Waypoint)
+	case pr.Reason != ReasonPending:
+		log.Debugf("no provisioning needed")
+		return nil
+	}
+
+	if gw := p.gateways.Get(pr.Waypoint, key.Namespace); gw == nil {
+		if _, err := p.gateways.Create(p.makeWaypoint(key.Namespace, pr.Waypoint)); err != nil {
+			return fmt.Errorf("failed to create waypoint %s/%s: %v", key.Namespace, pr.Waypoint, err)
+		}
+		log.Infof("created waypoint %s/%s for %s", key.Namespace, pr.Waypoint, strings.Join(pr.Sources, ", "))
+	} else if gw.Spec.GatewayClassName != constants.WaypointGatewayClassName {
+		// Reported in status by the collection
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels":      map[string]string{label.IoIstioUseWaypoint.Name: pr.Waypoint},
+			"annotations": map[string]string{ProvisionedWaypointAnnotation: pr.Waypoint},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := p.services.Patch(key.Name, key.Namespace, types.MergePatchType, patch); err != nil {
+		return fmt.Errorf("failed to bind service %s to waypoint %s: %v", key, pr.Waypoint, err)
+	}
+	log.Infof("bound to waypoint %s/%s", key.Namespace, pr.Waypoint)
+	return nil
+}
+
+// unbind removes the binding the controller made from a Service. The binding label is left alone if the user
+// changed it since.
+func (p *Provisioner) unbind(key types.NamespacedName, waypoint string) error {
+	svc := p.services.Get(key.Name, key.Namespace)
+	if svc == nil {
+		return nil
+	}
+	labels := map[string]any{}
+	if svc.Labels[label.IoIstioUseWaypoint.Name] == waypoint {
+		labels[label.IoIstioUseWaypoint.Name] = nil
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels":      labels,
+			"annotations": map[string]any{ProvisionedWaypointAnnotation: nil},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := p.services.Patch(key.Name, key.Namespace, types.MergePatchType, patch); err != nil {
+		return fmt.Errorf("failed to unbind service %s from waypoint %s: %v", key, waypoint, err)
+	}
+	log.WithLabels("service", key).Infof("unbound from waypoint %s/%s, no L7 configuration needs it anymore", key.Namespace, waypoint)
+	return nil
+}
+
+// cleanupWaypoint deletes a waypoint the controller created, once no Service or namespace uses it anymore.
+func (p *Provisioner) cleanupWaypoint(namespace, name string) error {
+	gw := p.gateways.Get(name, namespace)
+	if gw == nil || gw.Annotations[ProvisionedWaypointAnnotation] != name {
+		return nil
+	}
+	for _, ns := range p.namespaces.List(metav1.NamespaceAll, klabels.Everything()) {
+		if usesWaypoint(ns.ObjectMeta, ns.Name, namespace, name) {
+			return nil
+		}
+	}
+	for _, svc := range p.services.List(metav1.NamespaceAll, klabels.Everything()) {
+		if usesWaypoint(svc.ObjectMeta, svc.Namespace, namespace, name) {
+			return nil
+		}
+	}
+	if err := p.gateways.Delete(name, namespace); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete waypoint %s/%s: %v", namespace, name, err)
+	}
+	log.Infof("deleted waypoint %s/%s, which is no longer used", namespace, name)
+	return nil
+}
+
+// usesWaypoint returns true if the object, in objNamespace, is bound to the waypoint namespace/name.
+func usesWaypoint(obj metav1.ObjectMeta, objNamespace, namespace, name string) bool {
+	if obj.Labels[label.IoIstioUseWaypoint.Name] != name {
+		return false
+	}
+	wpNamespace := obj.Labels[label.IoIstioUseWaypointNamespace.Name]
+	if wpNamespace == "" {
+		wpNamespace = objNamespace
+	}
+	return wpNamespace == namespace
+}
+
+// makeWaypoint builds a namespace waypoint, the same as `istioctl waypoint apply` does.
+func (p *Provisioner) makeWaypoint(namespace, name string) *gatewayv1.Gateway {
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{ProvisionedWaypointAnnotation: name},
+		},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: constants.WaypointGatewayClassName,
+			Listeners: []gatewayv1.Listener{{
+				Name:     "mesh",
+				Port:     15008,
+				Protocol: gatewayv1.ProtocolType(protocol.HBONE),
+			}},
+		},
+	}
+	if p.revision != "" && p.revision != "default" {
+		gw.Labels = map[string]string{label.IoIstioRev.Name: p.revision}
+	}
+	return gw
+}
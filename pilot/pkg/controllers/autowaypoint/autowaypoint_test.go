@@ -0,0 +1,281 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autowaypoint
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"istio.io/api/label"
+	"istio.io/api/security/v1beta1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityclient "istio.io/client-go/pkg/apis/security/v1"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/gvr"
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient/clienttest"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func ambientNamespace(name string, labels map[string]string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{label.IoIstioDataplaneMode.Name: constants.DataplaneModeAmbient},
+	}}
+	for k, v := range labels {
+		ns.Labels[k] = v
+	}
+	return ns
+}
+
+func service(labels, annotations map[string]string) *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "reviews",
+		Namespace:   "default",
+		Labels:      labels,
+		Annotations: annotations,
+	}}
+}
+
+func httpAuthorizationPolicy(name string) *securityclient.AuthorizationPolicy {
+	return &securityclient.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1beta1.AuthorizationPolicy{
+			TargetRefs: []*typev1beta1.PolicyTargetReference{{Kind: "Service", Name: "reviews"}},
+			Rules: []*v1beta1.Rule{{
+				To: []*v1beta1.Rule_To{{Operation: &v1beta1.Operation{Methods: []string{"GET"}}}},
+			}},
+		},
+	}
+}
+
+func TestTargets(t *testing.T) {
+	reviews := types.NamespacedName{Namespace: "default", Name: "reviews"}
+
+	t.Run("authorization policy with HTTP rules", func(t *testing.T) {
+		assert.Equal(t, authorizationPolicyTargets(nil, httpAuthorizationPolicy("allow-get")), []l7Target{
+			{Service: reviews, Source: "AuthorizationPolicy default/allow-get"},
+		})
+	})
+
+	t.Run("authorization policy without HTTP rules", func(t *testing.T) {
+		pol := httpAuthorizationPolicy("allow-port")
+		pol.Spec.Rules[0].To[0].Operation = &v1beta1.Operation{Ports: []string{"9080"}}
+		assert.Equal(t, len(authorizationPolicyTargets(nil, pol)), 0)
+	})
+
+	t.Run("http route", func(t *testing.T) {
+		route := &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "reviews-split", Namespace: "default"},
+			Spec: gatewayv1.HTTPRouteSpec{CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{
+				{Group: ptr.Of(gatewayv1.Group("")), Kind: ptr.Of(gatewayv1.Kind("Service")), Name: "reviews"},
+				{Name: "ingress"},
+				{
+					Group: ptr.Of(gatewayv1.Group("")), Kind: ptr.Of(gatewayv1.Kind("Service")), Name: "ratings",
+					Namespace: ptr.Of(gatewayv1.Namespace("other")),
+				},
+			}}},
+		}
+		assert.Equal(t, httpRouteTargets(nil, route), []l7Target{
+			{Service: reviews, Source: "HTTPRoute default/reviews-split"},
+		})
+	})
+
+	t.Run("authorization policy in another namespace", func(t *testing.T) {
+		pol := httpAuthorizationPolicy("allow-get")
+		pol.Spec.TargetRefs[0].Namespace = "other"
+		assert.Equal(t, len(authorizationPolicyTargets(nil, pol)), 0)
+	})
+}
+
+func TestBuildProvision(t *testing.T) {
+	targets := []l7Target{
+		{Service: types.NamespacedName{Namespace: "default", Name: "reviews"}, Source: "HTTPRoute default/reviews-split"},
+		{Service: types.NamespacedName{Namespace: "default", Name: "reviews"}, Source: "AuthorizationPolicy default/allow-get"},
+	}
+	cases := []struct {
+		name      string
+		svc       *corev1.Service
+		namespace *corev1.Namespace
+		gateway   *gatewayv1.Gateway
+		targets   []l7Target
+		reason    string
+		unbind    bool
+	}{
+		{
+			name:      "no l7 configuration",
+			svc:       service(nil, nil),
+			namespace: ambientNamespace("default", nil),
+		},
+		{
+			name: "no longer needed",
+			svc: service(map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"},
+				map[string]string{ProvisionedWaypointAnnotation: "waypoint"}),
+			namespace: ambientNamespace("default", nil),
+			unbind:    true,
+		},
+		{
+			name:      "not ambient",
+			svc:       service(nil, nil),
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			targets:   targets,
+		},
+		{
+			name:      "no waypoint",
+			svc:       service(nil, nil),
+			namespace: ambientNamespace("default", nil),
+			targets:   targets,
+			reason:    ReasonPending,
+		},
+		{
+			name:      "opted out",
+			svc:       service(map[string]string{label.IoIstioUseWaypoint.Name: "none"}, nil),
+			namespace: ambientNamespace("default", map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"}),
+			targets:   targets,
+			reason:    ReasonOptedOut,
+		},
+		{
+			name:      "namespace waypoint",
+			svc:       service(nil, nil),
+			namespace: ambientNamespace("default", map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"}),
+			targets:   targets,
+		},
+		{
+			name: "provisioned",
+			svc: service(map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"},
+				map[string]string{ProvisionedWaypointAnnotation: "waypoint"}),
+			namespace: ambientNamespace("default", nil),
+			targets:   targets,
+			reason:    ReasonProvisioned,
+		},
+		{
+			name: "name conflict",
+			svc:  service(nil, nil),
+			gateway: &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "waypoint", Namespace: "default"},
+				Spec:       gatewayv1.GatewaySpec{GatewayClassName: "istio"},
+			},
+			namespace: ambientNamespace("default", nil),
+			targets:   targets,
+			reason:    ReasonNameConflict,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := buildProvision(tt.svc, tt.namespace, tt.gateway, tt.targets)
+			assert.Equal(t, pr.Reason, tt.reason)
+			assert.Equal(t, pr.Deprovision, tt.unbind)
+			if len(tt.targets) > 0 {
+				assert.Equal(t, pr.Sources, []string{"AuthorizationPolicy default/allow-get", "HTTPRoute default/reviews-split"})
+			}
+		})
+	}
+}
+
+func TestProvisioner(t *testing.T) {
+	stop := test.NewStop(t)
+	c := kubelib.NewFakeClient()
+	clienttest.MakeCRD(t, c, gvr.KubernetesGateway)
+	clienttest.MakeCRD(t, c, gvr.HTTPRoute)
+	clienttest.MakeCRD(t, c, gvr.AuthorizationPolicy)
+
+	p := NewProvisioner(stop, c, "", krt.GlobalDebugHandler)
+	go p.Run(stop)
+	c.RunAndWait(stop)
+	kubelib.WaitForCacheSync("test", stop, p.HasSynced)
+
+	namespaces := clienttest.NewWriter[*corev1.Namespace](t, c)
+	services := clienttest.Wrap(t, p.services)
+	gateways := clienttest.Wrap(t, p.gateways)
+	policies := clienttest.NewWriter[*securityclient.AuthorizationPolicy](t, c)
+
+	namespaces.Create(ambientNamespace("default", nil))
+	services.Create(service(nil, nil))
+	policies.Create(httpAuthorizationPolicy("allow-get"))
+
+	var gw *gatewayv1.Gateway
+	assert.EventuallyEqual(t, func() string {
+		gw = gateways.Get("waypoint", "default")
+		if gw == nil {
+			return ""
+		}
+		return string(gw.Spec.GatewayClassName)
+	}, constants.WaypointGatewayClassName)
+	assert.Equal(t, gw.Spec.Listeners[0].Port, gatewayv1.PortNumber(15008))
+
+	assert.EventuallyEqual(t, func() string {
+		return services.Get("reviews", "default").Labels[label.IoIstioUseWaypoint.Name]
+	}, "waypoint")
+	assert.EventuallyEqual(t, func() string {
+		return p.provisions.GetKey("default/reviews").Reason
+	}, ReasonProvisioned)
+
+	// Once the policy is gone, the Service is unbound and the waypoint deleted
+	policies.Delete("allow-get", "default")
+	assert.EventuallyEqual(t, func() string {
+		return services.Get("reviews", "default").Labels[label.IoIstioUseWaypoint.Name]
+	}, "")
+	assert.EventuallyEqual(t, func() *gatewayv1.Gateway {
+		return gateways.Get("waypoint", "default")
+	}, nil)
+}
+
+func TestProvisionerKeepsUserWaypoint(t *testing.T) {
+	stop := test.NewStop(t)
+	c := kubelib.NewFakeClient()
+	clienttest.MakeCRD(t, c, gvr.KubernetesGateway)
+	clienttest.MakeCRD(t, c, gvr.HTTPRoute)
+	clienttest.MakeCRD(t, c, gvr.AuthorizationPolicy)
+
+	p := NewProvisioner(stop, c, "", krt.GlobalDebugHandler)
+	go p.Run(stop)
+	c.RunAndWait(stop)
+	kubelib.WaitForCacheSync("test", stop, p.HasSynced)
+
+	namespaces := clienttest.NewWriter[*corev1.Namespace](t, c)
+	services := clienttest.Wrap(t, p.services)
+	gateways := clienttest.Wrap(t, p.gateways)
+	policies := clienttest.NewWriter[*securityclient.AuthorizationPolicy](t, c)
+	reviews := func() *corev1.Service {
+		return ptr.NonEmptyOrDefault(services.Get("reviews", "default"), &corev1.Service{})
+	}
+
+	namespaces.Create(ambientNamespace("default", nil))
+	services.Create(service(nil, nil))
+	policies.Create(httpAuthorizationPolicy("allow-get"))
+	assert.EventuallyEqual(t, func() string {
+		return reviews().Annotations[ProvisionedWaypointAnnotation]
+	}, "waypoint")
+
+	// The user starts using the waypoint for another Service, so it must be kept
+	ratings := service(map[string]string{label.IoIstioUseWaypoint.Name: "waypoint"}, nil)
+	ratings.Name = "ratings"
+	services.Create(ratings)
+	policies.Delete("allow-get", "default")
+	assert.EventuallyEqual(t, func() string {
+		return reviews().Annotations[ProvisionedWaypointAnnotation]
+	}, "")
+	assert.EventuallyEqual(t, func() string {
+		return reviews().Labels[label.IoIstioUseWaypoint.Name]
+	}, "")
+	assert.Equal(t, gateways.Get("waypoint", "default") != nil, true)
+}
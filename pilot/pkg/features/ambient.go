@@ -86,6 +86,10 @@ var (
 		false,
 		"If enabled, selector based authorization policies will be enforced as L4 policies in front of the waypoint.").Get()
 
+	EnableAmbientWaypointAutoProvisioning = registerAmbient("AMBIENT_ENABLE_WAYPOINT_AUTO_PROVISIONING", false, false,
+		"If enabled, istiod will create a namespace waypoint, and bind a Service to it, when an AuthorizationPolicy with HTTP rules "+
+			"or an HTTPRoute targets an ambient Service that is not bound to a waypoint.")
+
 	EnableWdsDryRunAuthzPol = registerAmbient("AMBIENT_ENABLE_DRY_RUN_AUTHORIZATION_POLICY", false, false,
 		"If enabled, ztunnel will be configured with dry-run authorizationPolicies. "+
 			"Ensure ztunnel is 1.29 or above before enabling this feature. "+
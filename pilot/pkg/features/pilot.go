@@ -325,6 +325,11 @@ var (
 	EnableLazySidecarEvaluation = env.Register("ENABLE_LAZY_SIDECAR_EVALUATION", true,
 		"If enabled, pilot will only compute sidecar resources when actually used").Get()
 
+	EnableLazyNamespaceIndexes = env.Register("PILOT_ENABLE_LAZY_NAMESPACE_INDEXES", false,
+		"If enabled, the virtual service, destination rule, sidecar, authorization policy and telemetry indexes of a "+
+			"namespace are only built when first used, and are shared with the previous push context if the configs "+
+			"they are built from did not change.").Get()
+
 	// EnableCACRL ToDo (nilekh): remove this feature flag once it's stable
 	EnableCACRL = env.Register(
 		"PILOT_ENABLE_CA_CRL",
@@ -415,6 +420,14 @@ var (
 		true,
 		"If enabled, pilot will start the credentials controller for remote clusters. Default is true.",
 	).Get()
+
+	EnablePushTracing = env.Register(
+		"PILOT_ENABLE_PUSH_TRACING",
+		false,
+		"If enabled, pilot will trace each config event and the pushes it causes. Traces are exported with "+
+			"OpenTelemetry, configured by the standard OTEL_EXPORTER_OTLP_* environment variables, and the most "+
+			"recent pushes are available at /debug/push_trace.",
+	).Get()
 )
 
 // UnsafeFeaturesEnabled returns true if any unsafe features are enabled.
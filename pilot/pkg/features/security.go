@@ -16,6 +16,7 @@ package features
 
 import (
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -90,6 +91,57 @@ var (
 
 	CertSignerDomain = env.Register("CERT_SIGNER_DOMAIN", "", "The cert signer domain info").Get()
 
+	CAAuditLogFile = env.Register("CA_AUDIT_LOG_FILE", "",
+		"If set, every certificate issued by the Istio CA is appended to this file as a JSON line, "+
+			"recording its serial, SANs, validity, the authenticator used and the caller. "+
+			"A certificate is not returned until its issuance is recorded.").Get()
+
+	CAAuditLogFileMaxSize = env.Register("CA_AUDIT_LOG_FILE_MAX_SIZE", 100*1024*1024,
+		"The maximum size in bytes of CA_AUDIT_LOG_FILE. Once reached, the file is renamed with the next free "+
+			"numbered suffix (.1, .2, ...), and a new file is started. Rotated files are never overwritten or removed. "+
+			"If not positive, the file is never rotated.").Get()
+
+	CAAuditLogGRPCAddress = env.Register("CA_AUDIT_LOG_GRPC_ADDRESS", "",
+		"If set, every certificate issued by the Istio CA is sent to the istio.security.audit.v1.CertificateIssuanceSink "+
+			"service at this address. A certificate is not returned until the service acknowledges its issuance.").Get()
+
+	CAAuditLogGRPCInsecure = env.Register("CA_AUDIT_LOG_GRPC_INSECURE", false,
+		"If enabled, the connection to CA_AUDIT_LOG_GRPC_ADDRESS is not encrypted. "+
+			"Otherwise TLS is used, verified with the system roots.").Get()
+
+	CAAuditLogHistorySize = env.Register("CA_AUDIT_LOG_HISTORY_SIZE", 1000,
+		"The number of most recent certificate issuances kept in memory, to be queried with /debug/certz.").Get()
+
+	EnableCARevocation = env.Register("PILOT_ENABLE_CA_REVOCATION", false,
+		"If enabled, the Istio CA revokes the certificates listed in the istio-ca-revocations secret, by serial "+
+			"or by identity, and publishes a CRL signed by its signing certificate to the proxies. "+
+			"Requires PILOT_ENABLE_CA_CRL.").Get()
+
+	CACRLRefreshInterval = env.Register("CA_CRL_REFRESH_INTERVAL", time.Minute,
+		"How often the Istio CA reloads the istio-ca-revocations secret and, if it changed, publishes a new CRL.").Get()
+
+	CACRLValidity = env.Register("CA_CRL_VALIDITY", 7*24*time.Hour,
+		"The validity of the CRLs generated by the Istio CA. A CRL is re-signed once half of its validity has "+
+			"elapsed; proxies reject peers once the CRL they have expires.").Get()
+
+	EnableCAJWTSVID = env.Register("PILOT_ENABLE_CA_JWT_SVID", false,
+		"If enabled, the Istio CA issues JWT-SVIDs to authenticated workloads, signed with dedicated keys stored in "+
+			"the istio-jwt-svid-keys secret, and publishes the keys verifying them at /jwt-svid/jwks.json.").Get()
+
+	CAJWTSVIDTTL = env.Register("CA_JWT_SVID_TTL", 5*time.Minute,
+		"The default and maximum validity of the JWT-SVIDs issued by the Istio CA.").Get()
+
+	CAJWTSVIDKeyRotationPeriod = env.Register("CA_JWT_SVID_KEY_ROTATION_PERIOD", 24*time.Hour,
+		"How long a key signs the JWT-SVIDs issued by the Istio CA before it is replaced. Replaced keys are still "+
+			"published until the JWT-SVIDs they signed expire.").Get()
+
+	EnableCAStagedRootRotation = env.Register("PILOT_ENABLE_CA_STAGED_ROOT_ROTATION", false,
+		"If enabled, the self-signed root of the Istio CA can be replaced by a root of a new key in stages, requested "+
+			"with istioctl x ca root-rotation and gated on the trust bundle of the proxies. Requires ISTIO_MULTIROOT_MESH.").Get()
+
+	CAStagedRootRotationCheckInterval = env.Register("CA_STAGED_ROOT_ROTATION_CHECK_INTERVAL", 30*time.Second,
+		"The interval at which requested transitions of the staged root rotation are checked.").Get()
+
 	UseCacertsForSelfSignedCA = env.Register("USE_CACERTS_FOR_SELF_SIGNED_CA", false,
 		"If enabled, istiod will use a secret named cacerts to store its self-signed istio-"+
 			"generated root certificate.").Get()
@@ -89,6 +89,88 @@ var (
 			"for this time, we'll trigger a push.",
 	).Get()
 
+	EnableAdaptiveDebounce = env.Register(
+		"PILOT_ENABLE_ADAPTIVE_DEBOUNCE",
+		false,
+		"If enabled, the quiet period used for debouncing is tuned from the recent push context init time, push queue "+
+			"depth and config event rate, between PILOT_DEBOUNCE_AFTER_MIN and PILOT_DEBOUNCE_AFTER_MAX. PILOT_DEBOUNCE_AFTER "+
+			"is used until enough events have been observed, and PILOT_DEBOUNCE_MAX still bounds the total delay.",
+	).Get()
+
+	DebounceAfterMin = env.Register(
+		"PILOT_DEBOUNCE_AFTER_MIN",
+		10*time.Millisecond,
+		"The minimum quiet period used for debouncing when PILOT_ENABLE_ADAPTIVE_DEBOUNCE is enabled.",
+	).Get()
+
+	DebounceAfterMax = env.Register(
+		"PILOT_DEBOUNCE_AFTER_MAX",
+		time.Second,
+		"The maximum quiet period used for debouncing when PILOT_ENABLE_ADAPTIVE_DEBOUNCE is enabled.",
+	).Get()
+
+	EnablePushFlowControl = env.Register(
+		"PILOT_ENABLE_PUSH_FLOW_CONTROL",
+		false,
+		"If enabled, pushes to a proxy that has PILOT_PUSH_FLOW_CONTROL_MAX_UNACKED un-ACKed responses of a single type "+
+			"are coalesced into a single push, which is sent once the proxy catches up.",
+	).Get()
+
+	PushFlowControlMaxUnacked = env.Register(
+		"PILOT_PUSH_FLOW_CONTROL_MAX_UNACKED",
+		3,
+		"The number of un-ACKed responses of a single type after which a proxy is considered to be lagging.",
+	).Get()
+
+	PushFlowControlSlowAck = env.Register(
+		"PILOT_PUSH_FLOW_CONTROL_SLOW_ACK",
+		5*time.Second,
+		"The ACK latency after which a proxy is reported as slow in /debug/syncz and istioctl proxy-status.",
+	).Get()
+
+	ConfigHistorySize = env.Register(
+		"PILOT_CONFIG_HISTORY_SIZE",
+		0,
+		"The number of pushed versions of the listeners, routes, clusters and secrets of each proxy to keep for "+
+			"/debug/config_history. If 0, no history is kept.",
+	).Get()
+
+	EnableXDSSharding = env.Register(
+		"PILOT_ENABLE_XDS_SHARDING",
+		false,
+		"If enabled, Istiod replicas of the same revision discover each other through Leases, and new xDS connections "+
+			"are sharded across them by Sidecar scope. Connections that belong to another replica are closed with a "+
+			"redirect to it, which the Istio agent follows. Proxies that do not follow redirects are always served.",
+	).Get()
+
+	XDSShardingLeaseDuration = env.Register(
+		"PILOT_XDS_SHARDING_LEASE_DURATION",
+		15*time.Second,
+		"The duration of the Leases Istiod replicas hold when PILOT_ENABLE_XDS_SHARDING is enabled. A replica that "+
+			"does not renew its Lease within this time no longer receives redirected connections.",
+	).Get()
+
+	XDSShardingAddress = env.Register(
+		"PILOT_XDS_SHARDING_ADDRESS",
+		"",
+		"The address other Istiod replicas redirect xDS connections to when PILOT_ENABLE_XDS_SHARDING is enabled. "+
+			"Defaults to the pod IP and the secure gRPC port.",
+	).Get()
+
+	EnablePushPriority = env.Register(
+		"PILOT_ENABLE_PUSH_PRIORITY",
+		false,
+		"If enabled, proxies are pushed in priority order. Gateways and waypoints are pushed first, and the priority "+
+			"of other proxies can be set with the istio.io/push-priority pod annotation or namespace label.",
+	).Get()
+
+	PushPriorityStarvationLimit = env.Register(
+		"PILOT_PUSH_PRIORITY_STARVATION_LIMIT",
+		10,
+		"The maximum number of times a pending lower priority proxy is passed over in favor of higher priority "+
+			"proxies before it is pushed. Only applies when PILOT_ENABLE_PUSH_PRIORITY is enabled.",
+	).Get()
+
 	EnableEDSDebounce = env.Register(
 		"PILOT_ENABLE_EDS_DEBOUNCE",
 		true,
@@ -118,4 +200,11 @@ var (
 
 	XDSCacheIndexClearInterval = env.Register("PILOT_XDS_CACHE_INDEX_CLEAR_INTERVAL", 5*time.Second,
 		"The interval for xds cache index clearing.").Get()
+
+	XDSCacheSnapshotPath = env.Register("PILOT_XDS_CACHE_SNAPSHOT_PATH", "",
+		"If set, Pilot will periodically write a snapshot of the XDS cache to this file, and restore it on startup. "+
+			"Restored entries are only served after the configs they depend on are verified to be unchanged.").Get()
+
+	XDSCacheSnapshotInterval = env.Register("PILOT_XDS_CACHE_SNAPSHOT_INTERVAL", 5*time.Minute,
+		"The interval at which the XDS cache snapshot is written to PILOT_XDS_CACHE_SNAPSHOT_PATH.").Get()
 )
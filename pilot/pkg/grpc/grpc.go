@@ -133,6 +133,8 @@ var expectedGrpcFailureMessages = sets.New(
 	"client disconnected",
 	"error reading from server: EOF",
 	"transport is closing",
+	// Sent by Istiod when it redirects a connection to another replica, see xds.RedirectError.
+	"connection redirected",
 )
 
 func containsExpectedMessage(msg string) bool {
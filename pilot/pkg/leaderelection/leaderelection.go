@@ -59,6 +59,7 @@ const (
 	InferencePoolController     = "istio-gateway-inferencepool"
 	NodeUntaintController       = "istio-node-untaint"
 	IPAutoallocateController    = "istio-ip-autoallocate"
+	WaypointProvisionController = "istio-waypoint-provision"
 )
 
 // Leader election key prefix for remote istiod managed clusters
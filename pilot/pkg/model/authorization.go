@@ -15,9 +15,13 @@
 package model
 
 import (
+	"encoding/json"
+
 	"k8s.io/apimachinery/pkg/types"
 
 	authpb "istio.io/api/security/v1beta1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/slices"
 )
@@ -40,6 +44,11 @@ type AuthorizationPolicies struct {
 
 	// The name of the root namespace. Policy in the root namespace applies to workloads in all namespaces.
 	RootNamespace string `json:"root_namespace"`
+
+	// byNamespace holds the policies of each namespace, converted from configsByNamespace on first use, if
+	// PILOT_ENABLE_LAZY_NAMESPACE_INDEXES is enabled. NamespaceToPolicies is not used then.
+	byNamespace        *namespaceIndex[[]AuthorizationPolicy]
+	configsByNamespace map[string][]config.Config
 }
 
 // GetAuthorizationPolicies returns the AuthorizationPolicies for the given environment.
@@ -52,18 +61,19 @@ func GetAuthorizationPolicies(env *Environment) *AuthorizationPolicies {
 	policies := env.List(gvk.AuthorizationPolicy, NamespaceAll)
 	sortConfigByCreationTime(policies)
 
+	if features.EnableLazyNamespaceIndexes {
+		policy.byNamespace = newNamespaceIndex[[]AuthorizationPolicy]("authorizationpolicy")
+		policy.configsByNamespace = groupByNamespace(policies)
+		return policy
+	}
+
 	policyCount := make(map[string]int)
 	for _, config := range policies {
 		policyCount[config.Namespace]++
 	}
 
 	for _, config := range policies {
-		authzConfig := AuthorizationPolicy{
-			Name:        config.Name,
-			Namespace:   config.Namespace,
-			Annotations: config.Annotations,
-			Spec:        config.Spec.(*authpb.AuthorizationPolicy),
-		}
+		authzConfig := toAuthorizationPolicy(config)
 		if _, ok := policy.NamespaceToPolicies[config.Namespace]; !ok {
 			policy.NamespaceToPolicies[config.Namespace] = make([]AuthorizationPolicy, 0, policyCount[config.Namespace])
 		}
@@ -73,6 +83,39 @@ func GetAuthorizationPolicies(env *Environment) *AuthorizationPolicies {
 	return policy
 }
 
+func toAuthorizationPolicy(c config.Config) AuthorizationPolicy {
+	return AuthorizationPolicy{
+		Name:        c.Name,
+		Namespace:   c.Namespace,
+		Annotations: c.Annotations,
+		Spec:        c.Spec.(*authpb.AuthorizationPolicy),
+	}
+}
+
+// namespacePolicies returns the policies of namespace, converting them if needed.
+func (policy *AuthorizationPolicies) namespacePolicies(namespace string) []AuthorizationPolicy {
+	if policy.byNamespace == nil {
+		return policy.NamespaceToPolicies[namespace]
+	}
+	return policy.byNamespace.get(namespace, func(namespace string) ([]AuthorizationPolicy, int) {
+		policies := slices.Map(policy.configsByNamespace[namespace], toAuthorizationPolicy)
+		return policies, len(policies)
+	})
+}
+
+// MarshalJSON includes the policies of all namespaces, also of those not used yet with lazy namespace indexes.
+func (policy *AuthorizationPolicies) MarshalJSON() ([]byte, error) {
+	type plain AuthorizationPolicies
+	out := plain(*policy)
+	if policy.byNamespace != nil {
+		out.NamespaceToPolicies = make(map[string][]AuthorizationPolicy, len(policy.configsByNamespace))
+		for ns := range policy.configsByNamespace {
+			out.NamespaceToPolicies[ns] = policy.namespacePolicies(ns)
+		}
+	}
+	return json.Marshal(out)
+}
+
 type AuthorizationPoliciesResult struct {
 	Custom []AuthorizationPolicy
 	Deny   []AuthorizationPolicy
@@ -100,7 +143,7 @@ func (policy *AuthorizationPolicies) ListAuthorizationPolicies(selectionOpts Wor
 	}
 
 	for _, ns := range slices.FilterDuplicates(lookupInNamespaces) {
-		for _, config := range policy.NamespaceToPolicies[ns] {
+		for _, config := range policy.namespacePolicies(ns) {
 			spec := config.Spec
 
 			if selectionOpts.ShouldAttachPolicy(gvk.AuthorizationPolicy, config.NamespacedName(), spec) {
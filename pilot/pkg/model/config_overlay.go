@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+var errOverlayReadOnly = errors.New("unsupported operation: the overlay config store is read-only")
+
+// overlayConfigStore serves a set of proposed configs on top of another store. Proposed configs replace
+// the configs with the same type, name and namespace, and are added otherwise. It is read-only.
+type overlayConfigStore struct {
+	ConfigStore
+	overlay map[config.GroupVersionKind]map[types.NamespacedName]config.Config
+}
+
+// NewOverlayConfigStore returns a read-only store that serves the given configs on top of store.
+// Proposed configs without a creation timestamp inherit the one of the config they replace, so they keep their
+// precedence, or are treated as just created otherwise.
+func NewOverlayConfigStore(store ConfigStore, configs []config.Config) (ConfigStore, error) {
+	o := &overlayConfigStore{
+		ConfigStore: store,
+		overlay:     map[config.GroupVersionKind]map[types.NamespacedName]config.Config{},
+	}
+	now := time.Now()
+	for _, cfg := range configs {
+		if _, f := store.Schemas().FindByGroupVersionKind(cfg.GroupVersionKind); !f {
+			return nil, fmt.Errorf("%v %s/%s: type is not served by the config store", cfg.GroupVersionKind, cfg.Namespace, cfg.Name)
+		}
+		if cfg.CreationTimestamp.IsZero() {
+			if cur := store.Get(cfg.GroupVersionKind, cfg.Name, cfg.Namespace); cur != nil {
+				cfg.CreationTimestamp = cur.CreationTimestamp
+			} else {
+				cfg.CreationTimestamp = now
+			}
+		}
+		if o.overlay[cfg.GroupVersionKind] == nil {
+			o.overlay[cfg.GroupVersionKind] = map[types.NamespacedName]config.Config{}
+		}
+		o.overlay[cfg.GroupVersionKind][cfg.NamespacedName()] = cfg
+	}
+	return o, nil
+}
+
+func (o *overlayConfigStore) Get(typ config.GroupVersionKind, name, namespace string) *config.Config {
+	if cfg, f := o.overlay[typ][types.NamespacedName{Name: name, Namespace: namespace}]; f {
+		return &cfg
+	}
+	return o.ConfigStore.Get(typ, name, namespace)
+}
+
+func (o *overlayConfigStore) List(typ config.GroupVersionKind, namespace string) []config.Config {
+	configs := o.ConfigStore.List(typ, namespace)
+	overlay := o.overlay[typ]
+	if len(overlay) == 0 {
+		return configs
+	}
+	out := make([]config.Config, 0, len(configs)+len(overlay))
+	for _, cfg := range configs {
+		if _, f := overlay[cfg.NamespacedName()]; !f {
+			out = append(out, cfg)
+		}
+	}
+	for _, cfg := range overlay {
+		if namespace == "" || cfg.Namespace == namespace {
+			out = append(out, cfg)
+		}
+	}
+	return out
+}
+
+func (o *overlayConfigStore) Create(config.Config) (string, error) {
+	return "", errOverlayReadOnly
+}
+
+func (o *overlayConfigStore) Update(config.Config) (string, error) {
+	return "", errOverlayReadOnly
+}
+
+func (o *overlayConfigStore) UpdateStatus(config.Config) (string, error) {
+	return "", errOverlayReadOnly
+}
+
+func (o *overlayConfigStore) Delete(config.GroupVersionKind, string, string, *string) error {
+	return errOverlayReadOnly
+}
+
+// NewShadowEnvironment returns a copy of env that reads configs from store rather than from the environment's
+// config store. It is used to compute a PushContext for configs that have not been applied yet.
+// The shadow environment does not share the XDS cache of env, and its VirtualServices are merged from store
+// by a controller that stops with stop.
+func NewShadowEnvironment(env *Environment, store ConfigStore, stop <-chan struct{}) *Environment {
+	shadow := &Environment{
+		ServiceDiscovery:       env.ServiceDiscovery,
+		ConfigStore:            store,
+		Watcher:                env.Watcher,
+		AmbientIndexes:         env.AmbientIndexes,
+		NetworksWatcher:        env.NetworksWatcher,
+		NetworkManager:         env.NetworkManager,
+		DomainSuffix:           env.DomainSuffix,
+		TrustBundle:            env.TrustBundle,
+		clusterLocalServices:   env.clusterLocalServices,
+		CredentialsController:  env.CredentialsController,
+		GatewayAPIController:   env.GatewayAPIController,
+		AgentgatewayController: env.AgentgatewayController,
+		EndpointIndex:          env.EndpointIndex,
+		Cache:                  DisabledCache{},
+	}
+	shadow.VirtualServiceController = newStaticVirtualServiceController(store.List(gvk.VirtualService, ""), env.Mesh(), stop)
+	return shadow
+}
@@ -0,0 +1,76 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestOverlayConfigStore(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	vs := func(name, namespace string, hosts ...string) config.Config {
+		return config.Config{
+			Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Name: name, Namespace: namespace},
+			Spec: &networking.VirtualService{Hosts: hosts},
+		}
+	}
+	store := NewFakeStore()
+	existing := vs("a", "default", "a.example.com")
+	existing.CreationTimestamp = created
+	_, _ = store.Create(existing)
+	_, _ = store.Create(vs("b", "other", "b.example.com"))
+
+	overlay, err := NewOverlayConfigStore(store, []config.Config{
+		vs("a", "default", "a.example.org"),
+		vs("c", "default", "c.example.com"),
+	})
+	assert.NoError(t, err)
+
+	// Replaced configs keep the creation time of the config they replace
+	a := overlay.Get(gvk.VirtualService, "a", "default")
+	assert.Equal(t, a.Spec.(*networking.VirtualService).Hosts, []string{"a.example.org"})
+	assert.Equal(t, a.CreationTimestamp, created)
+	assert.Equal(t, overlay.Get(gvk.VirtualService, "b", "other") != nil, true)
+
+	names := func(cfgs []config.Config) []string {
+		return slices.Sort(slices.Map(cfgs, func(c config.Config) string { return c.Namespace + "/" + c.Name }))
+	}
+	assert.Equal(t, names(overlay.List(gvk.VirtualService, "")), []string{"default/a", "default/c", "other/b"})
+	assert.Equal(t, names(overlay.List(gvk.VirtualService, "default")), []string{"default/a", "default/c"})
+	// The underlying store is not modified
+	assert.Equal(t, names(store.List(gvk.VirtualService, "")), []string{"default/a", "other/b"})
+	_, err = overlay.Create(vs("d", "default"))
+	assert.Error(t, err)
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := NewOverlayConfigStore(store, []config.Config{{Meta: config.Meta{GroupVersionKind: gvk.Pod, Name: "p"}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("virtual services", func(t *testing.T) {
+		env := &Environment{ConfigStore: store}
+		shadow := NewShadowEnvironment(env, overlay, test.NewStop(t))
+		merged := shadow.VirtualServiceController.MergedVirtualServices()
+		assert.Equal(t, names(merged), []string{"default/a", "default/c", "other/b"})
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/monitoring"
+	"istio.io/istio/pkg/util/sets"
+)
+
+var (
+	namespaceIndexBuilds = monitoring.NewSum(
+		"pilot_namespace_index_builds",
+		"Total number of per namespace push context indexes built on first use, by index type.",
+	)
+
+	namespaceIndexReuses = monitoring.NewSum(
+		"pilot_namespace_index_reuses",
+		"Total number of per namespace push context indexes shared with the previous push context, by index type.",
+	)
+
+	namespaceIndexSize = monitoring.NewDistribution(
+		"pilot_namespace_index_size",
+		"Number of entries of the per namespace push context indexes built, by index type.",
+		[]float64{0, 1, 5, 10, 50, 100, 500, 1000},
+	)
+
+	namespaceIndexCount = monitoring.NewGauge(
+		"pilot_namespace_indexes",
+		"Number of per namespace indexes built or shared by the current push context, by index type.",
+	)
+)
+
+// namespaceIndex is a push context index that is built separately for each namespace, on first use. A push context
+// derived from a previous one shares the indexes of the namespaces whose inputs did not change.
+// The index does not keep the function building it, so that it does not keep the push context it was created by
+// reachable once shared. Each caller passes the build function of its own push context.
+// All methods are thread safe.
+type namespaceIndex[T any] struct {
+	typ string
+
+	mu    sync.Mutex
+	built map[string]*namespaceIndexEntry[T]
+}
+
+// namespaceIndexEntry is the index of a single namespace. It is built once, concurrent users wait for the build.
+type namespaceIndexEntry[T any] struct {
+	once  sync.Once
+	done  atomic.Bool
+	value T
+}
+
+func newNamespaceIndex[T any](typ string) *namespaceIndex[T] {
+	return &namespaceIndex[T]{typ: typ, built: map[string]*namespaceIndexEntry[T]{}}
+}
+
+// get returns the index of namespace, building it with build if needed. build returns the index along with its number
+// of entries. It may get the indexes of other namespaces, but not the one being built.
+func (i *namespaceIndex[T]) get(namespace string, build func(namespace string) (T, int)) T {
+	i.mu.Lock()
+	e, f := i.built[namespace]
+	if !f {
+		e = &namespaceIndexEntry[T]{}
+		i.built[namespace] = e
+	}
+	i.mu.Unlock()
+	// Build without holding the index lock, so that other namespaces can be built and read meanwhile.
+	e.once.Do(func() {
+		v, size := build(namespace)
+		e.value = v
+		e.done.Store(true)
+		namespaceIndexBuilds.With(typeTag.Value(i.typ)).Increment()
+		namespaceIndexSize.With(typeTag.Value(i.typ)).Record(float64(size))
+	})
+	return e.value
+}
+
+// inherit shares the indexes previous built for namespaces not in changed. Indexes still being built are not shared,
+// as they would be built from the inputs of the previous push context.
+func (i *namespaceIndex[T]) inherit(previous *namespaceIndex[T], changed sets.String) {
+	if previous == nil {
+		return
+	}
+	previous.mu.Lock()
+	defer previous.mu.Unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	reused := 0
+	for ns, e := range previous.built {
+		if changed.Contains(ns) || !e.done.Load() {
+			continue
+		}
+		if _, f := i.built[ns]; !f {
+			i.built[ns] = e
+			reused++
+		}
+	}
+	namespaceIndexReuses.With(typeTag.Value(i.typ)).RecordInt(int64(reused))
+}
+
+// len returns the number of namespaces whose index is built.
+func (i *namespaceIndex[T]) len() int {
+	if i == nil {
+		return 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	n := 0
+	for _, e := range i.built {
+		if e.done.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// groupByNamespace groups configs by namespace, keeping their order.
+func groupByNamespace(configs []config.Config) map[string][]config.Config {
+	out := map[string][]config.Config{}
+	for _, c := range configs {
+		out[c.Namespace] = append(out[c.Namespace], c)
+	}
+	return out
+}
@@ -0,0 +1,303 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	networking "istio.io/api/networking/v1alpha3"
+	authpb "istio.io/api/security/v1beta1"
+	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/mesh/meshwatcher"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/config/visibility"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/krt"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestNamespaceIndex(t *testing.T) {
+	builds := map[string]int{}
+	build := func(ns string) (string, int) {
+		builds[ns]++
+		return ns + "-index", 1
+	}
+	idx := newNamespaceIndex[string]("test")
+	assert.Equal(t, idx.len(), 0)
+	assert.Equal(t, idx.get("a", build), "a-index")
+	assert.Equal(t, idx.get("a", build), "a-index")
+	assert.Equal(t, idx.get("b", build), "b-index")
+	assert.Equal(t, builds, map[string]int{"a": 1, "b": 1})
+	assert.Equal(t, idx.len(), 2)
+
+	// Only the unchanged namespaces are shared
+	next := newNamespaceIndex[string]("test")
+	next.inherit(idx, sets.New("b"))
+	assert.Equal(t, next.len(), 1)
+	assert.Equal(t, next.get("a", build), "a-index")
+	assert.Equal(t, next.get("b", build), "b-index")
+	assert.Equal(t, builds, map[string]int{"a": 1, "b": 2})
+
+	var nilIndex *namespaceIndex[string]
+	assert.Equal(t, nilIndex.len(), 0)
+	next.inherit(nil, nil)
+
+	t.Run("concurrent", func(t *testing.T) {
+		builds := atomic.NewInt32(0)
+		idx := newNamespaceIndex[string]("test")
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, idx.get("a", func(ns string) (string, int) {
+					builds.Inc()
+					time.Sleep(10 * time.Millisecond)
+					return ns + "-index", 1
+				}), "a-index")
+			}()
+		}
+		wg.Wait()
+		// Concurrent users of a namespace wait for a single build
+		assert.Equal(t, builds.Load(), int32(1))
+	})
+}
+
+func TestLazyDestinationRulesResolveHostsInCopy(t *testing.T) {
+	test.SetForTest(t, &features.EnableLazyNamespaceIndexes, true)
+	ps := NewPushContext()
+	ps.Mesh = mesh.DefaultMeshConfig()
+	ps.exportToDefaults.destinationRule = sets.New(visibility.Public)
+	spec := &networking.DestinationRule{Host: "svc"}
+	ps.SetDestinationRulesForTesting([]config.Config{{
+		Meta: config.Meta{Name: "rule", Namespace: "a", Domain: "cluster.local", GroupVersionKind: gvk.DestinationRule},
+		Spec: spec,
+	}})
+	rules := ps.exportedDestRules("a").specificDestRules[host.Name("svc.a.svc.cluster.local")]
+	assert.Equal(t, len(rules), 1)
+	assert.Equal(t, rules[0].rule.Spec.(*networking.DestinationRule).Host, "svc.a.svc.cluster.local")
+	// The spec shared with the config store is not modified
+	assert.Equal(t, spec.Host, "svc")
+}
+
+// newLazyIndexEnvironment returns an environment with the given configs, for testing the lazy namespace indexes.
+func newLazyIndexEnvironment(t *testing.T, configs ...config.Config) (*Environment, ConfigStoreController) {
+	test.SetForTest(t, &features.EnableLazyNamespaceIndexes, true)
+	env := NewEnvironment()
+	env.Watcher = meshwatcher.NewTestWatcher(mesh.DefaultMeshConfig())
+	store := NewFakeStore()
+	for _, c := range configs {
+		if _, err := store.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.VirtualServiceController = NewVirtualServiceController(store, VSControllerOptions{KrtDebugger: krt.GlobalDebugHandler}, env.Watcher)
+	stop := test.NewStop(t)
+	go store.Run(stop)
+	go env.VirtualServiceController.Run(stop)
+	kube.WaitForCacheSync("test", stop, store.HasSynced, env.VirtualServiceController.HasSynced)
+	env.ConfigStore = store
+	env.ServiceDiscovery = &localServiceDiscovery{}
+	env.Init()
+	return env, store
+}
+
+func TestLazyNamespaceIndexes(t *testing.T) {
+	destinationRule := func(ns, subset string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: "rule", Namespace: ns, GroupVersionKind: gvk.DestinationRule},
+			Spec: &networking.DestinationRule{
+				Host:    "svc." + ns + ".svc.cluster.local",
+				Subsets: []*networking.Subset{{Name: subset}},
+			},
+		}
+	}
+	sidecar := func(ns string, hosts ...string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: "default", Namespace: ns, GroupVersionKind: gvk.Sidecar},
+			Spec: &networking.Sidecar{Egress: []*networking.IstioEgressListener{{Hosts: hosts}}},
+		}
+	}
+	env, store := newLazyIndexEnvironment(t,
+		destinationRule("a", "v1"),
+		destinationRule("b", "v1"),
+		sidecar("a", "a/*"),
+		sidecar("b", "b/*"),
+	)
+
+	subsets := func(ps *PushContext, ns string) []string {
+		var out []string
+		for _, rule := range ps.exportedDestRules(ns).specificDestRules[host.Name("svc."+ns+".svc.cluster.local")] {
+			for _, ss := range rule.rule.Spec.(*networking.DestinationRule).Subsets {
+				out = append(out, ss.Name)
+			}
+		}
+		return out
+	}
+	sidecarHosts := func(ps *PushContext, ns string) []string {
+		scopes, _ := ps.namespaceSidecars(ns)
+		var out []string
+		for _, sc := range scopes {
+			out = append(out, sc.Sidecar.Egress[0].Hosts...)
+		}
+		return out
+	}
+
+	old := NewPushContext()
+	old.InitContext(env, nil, nil)
+	// Nothing is built until it is used
+	assert.Equal(t, old.destinationRuleIndex.byNamespace.len(), 0)
+	assert.Equal(t, old.sidecarIndex.byNamespace.len(), 0)
+	assert.Equal(t, subsets(old, "a"), []string{"v1"})
+	assert.Equal(t, subsets(old, "b"), []string{"v1"})
+	assert.Equal(t, sidecarHosts(old, "a"), []string{"a/*"})
+	assert.Equal(t, sidecarHosts(old, "b"), []string{"b/*"})
+	_, hasSidecar := old.namespaceSidecars("c")
+	assert.Equal(t, hasSidecar, false)
+
+	// A destination rule change only rebuilds its namespace
+	if _, err := store.Update(destinationRule("a", "v2")); err != nil {
+		t.Fatal(err)
+	}
+	drPush := NewPushContext()
+	drPush.InitContext(env, old, &PushRequest{
+		ConfigsUpdated: sets.New(ConfigKey{Kind: kind.DestinationRule, Name: "rule", Namespace: "a"}),
+	})
+	assert.Equal(t, drPush.destinationRuleIndex.byNamespace.built["b"] == old.destinationRuleIndex.byNamespace.built["b"], true)
+	_, reused := drPush.destinationRuleIndex.byNamespace.built["a"]
+	assert.Equal(t, reused, false)
+	assert.Equal(t, subsets(drPush, "a"), []string{"v2"})
+	assert.Equal(t, subsets(drPush, "b"), []string{"v1"})
+	// Sidecars depend on destination rules, so none are shared
+	assert.Equal(t, drPush.sidecarIndex.byNamespace.len(), 0)
+
+	// A sidecar change only rebuilds its namespace
+	assert.Equal(t, sidecarHosts(drPush, "b"), []string{"b/*"})
+	if _, err := store.Update(sidecar("a", "a/*", "b/*")); err != nil {
+		t.Fatal(err)
+	}
+	sidecarPush := NewPushContext()
+	sidecarPush.InitContext(env, drPush, &PushRequest{
+		ConfigsUpdated: sets.New(ConfigKey{Kind: kind.Sidecar, Name: "default", Namespace: "a"}),
+	})
+	assert.Equal(t, sidecarPush.sidecarIndex.byNamespace.len(), 1)
+	assert.Equal(t, sidecarPush.sidecarIndex.byNamespace.built["b"].value[0] == drPush.sidecarIndex.byNamespace.built["b"].value[0], true)
+	assert.Equal(t, sidecarHosts(sidecarPush, "a"), []string{"a/*", "b/*"})
+	assert.Equal(t, sidecarPush.destinationRuleIndex.byNamespace == drPush.destinationRuleIndex.byNamespace, true)
+}
+
+func TestLazyVirtualServiceAuthorizationAndTelemetryIndexes(t *testing.T) {
+	virtualService := func(ns, host string, exportTo ...string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: "vs", Namespace: ns, GroupVersionKind: gvk.VirtualService, Domain: "cluster.local"},
+			Spec: &networking.VirtualService{Hosts: []string{host}, ExportTo: exportTo},
+		}
+	}
+	authorizationPolicy := func(ns string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: "policy", Namespace: ns, GroupVersionKind: gvk.AuthorizationPolicy},
+			Spec: &authpb.AuthorizationPolicy{},
+		}
+	}
+	telemetry := func(ns string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: "telemetry", Namespace: ns, GroupVersionKind: gvk.Telemetry},
+			Spec: &tpb.Telemetry{},
+		}
+	}
+	env, store := newLazyIndexEnvironment(t,
+		virtualService("a", "a.example.com", "b"),
+		virtualService("c", "c.example.com", "."),
+		authorizationPolicy("a"),
+		authorizationPolicy("b"),
+		telemetry("a"),
+		telemetry("b"),
+	)
+
+	hosts := func(ps *PushContext, ns string) []string {
+		var out []string
+		for _, vs := range ps.VirtualServicesForGateway(ns, constants.IstioMeshGateway) {
+			out = append(out, vs.Spec.(*networking.VirtualService).Hosts...)
+		}
+		return out
+	}
+
+	old := NewPushContext()
+	old.InitContext(env, nil, nil)
+	// Nothing is built until it is used
+	assert.Equal(t, old.virtualServiceIndex.byNamespace.len(), 0)
+	assert.Equal(t, old.AuthzPolicies.byNamespace.len(), 0)
+	assert.Equal(t, old.Telemetry.byNamespace.len(), 0)
+	assert.Equal(t, hosts(old, "a"), nil)
+	assert.Equal(t, hosts(old, "b"), []string{"a.example.com"})
+	assert.Equal(t, hosts(old, "c"), []string{"c.example.com"})
+	assert.Equal(t, len(old.AuthzPolicies.namespacePolicies("a")), 1)
+	assert.Equal(t, len(old.AuthzPolicies.namespacePolicies("b")), 1)
+	assert.Equal(t, len(old.Telemetry.namespaceTelemetries("a")), 1)
+	assert.Equal(t, len(old.Telemetry.namespaceTelemetries("b")), 1)
+
+	// A virtual service change rebuilds its namespace and the namespaces it is exported to
+	if _, err := store.Update(virtualService("a", "a2.example.com", "b")); err != nil {
+		t.Fatal(err)
+	}
+	assert.EventuallyEqual(t, func() string {
+		for _, vs := range env.VirtualServiceController.MergedVirtualServices() {
+			if vs.Namespace == "a" {
+				return vs.Spec.(*networking.VirtualService).Hosts[0]
+			}
+		}
+		return ""
+	}, "a2.example.com")
+	vsPush := NewPushContext()
+	vsPush.InitContext(env, old, &PushRequest{
+		ConfigsUpdated: sets.New(ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "a"}),
+	})
+	assert.Equal(t, vsPush.virtualServiceIndex.byNamespace.len(), 1)
+	assert.Equal(t, vsPush.virtualServiceIndex.byNamespace.built["c"] == old.virtualServiceIndex.byNamespace.built["c"], true)
+	assert.Equal(t, hosts(vsPush, "b"), []string{"a2.example.com"})
+
+	// Authorization policy and telemetry changes only rebuild their namespace
+	policyPush := NewPushContext()
+	policyPush.InitContext(env, old, &PushRequest{
+		ConfigsUpdated: sets.New(
+			ConfigKey{Kind: kind.AuthorizationPolicy, Name: "policy", Namespace: "a"},
+			ConfigKey{Kind: kind.Telemetry, Name: "telemetry", Namespace: "a"},
+		),
+	})
+	assert.Equal(t, policyPush.AuthzPolicies.byNamespace.len(), 1)
+	assert.Equal(t, policyPush.AuthzPolicies.byNamespace.built["b"] == old.AuthzPolicies.byNamespace.built["b"], true)
+	assert.Equal(t, policyPush.Telemetry.byNamespace.len(), 1)
+	assert.Equal(t, policyPush.Telemetry.byNamespace.built["b"] == old.Telemetry.byNamespace.built["b"], true)
+
+	// Debug output includes the policies of the namespaces not used yet
+	out, err := json.Marshal(policyPush.AuthzPolicies)
+	assert.NoError(t, err)
+	got := AuthorizationPolicies{}
+	assert.NoError(t, json.Unmarshal(out, &got))
+	assert.Equal(t, len(got.NamespaceToPolicies), 2)
+}
@@ -24,6 +24,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -49,6 +50,7 @@ import (
 	"istio.io/istio/pkg/ptr"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/workloadapi"
 	"istio.io/istio/pkg/xds"
@@ -112,6 +114,71 @@ type virtualServiceIndex struct {
 
 	// Map of VS hostname -> referenced hostnames
 	referencedDestinations map[string]sets.String
+
+	// byNamespace holds the private and exported virtual services of each namespace by gateway, grouped from
+	// privateByNamespace and exportedToNamespace on first use, if PILOT_ENABLE_LAZY_NAMESPACE_INDEXES is enabled.
+	// privateByNamespaceAndGateway and exportedToNamespaceByGateway are not used then.
+	byNamespace         *namespaceIndex[namespaceVirtualServices]
+	privateByNamespace  map[string][]config.Config
+	exportedToNamespace map[string][]config.Config
+}
+
+// namespaceVirtualServices are the virtual services visible in a namespace other than the public ones, keyed by gateway.
+type namespaceVirtualServices struct {
+	private  map[string][]config.Config
+	exported map[string][]config.Config
+}
+
+// namespaceVirtualServices returns the private and exported virtual services of namespace, grouping them if needed.
+func (i *virtualServiceIndex) namespaceVirtualServices(namespace string) namespaceVirtualServices {
+	return i.byNamespace.get(namespace, func(namespace string) (namespaceVirtualServices, int) {
+		private, exported := i.privateByNamespace[namespace], i.exportedToNamespace[namespace]
+		return namespaceVirtualServices{private: groupByGateway(private), exported: groupByGateway(exported)}, len(private) + len(exported)
+	})
+}
+
+// private returns the virtual services of namespace bound to gateway that are only visible in namespace.
+func (i *virtualServiceIndex) private(namespace, gateway string) []config.Config {
+	if i.byNamespace != nil {
+		return i.namespaceVirtualServices(namespace).private[gateway]
+	}
+	return i.privateByNamespaceAndGateway[types.NamespacedName{Namespace: namespace, Name: gateway}]
+}
+
+// exported returns the virtual services of other namespaces bound to gateway that are exported to namespace.
+func (i *virtualServiceIndex) exported(namespace, gateway string) []config.Config {
+	if i.byNamespace != nil {
+		return i.namespaceVirtualServices(namespace).exported[gateway]
+	}
+	return i.exportedToNamespaceByGateway[types.NamespacedName{Namespace: namespace, Name: gateway}]
+}
+
+// inherit shares the namespace indexes of previous that the changed virtual services do not affect. These are the
+// indexes of the namespaces in changed, and of the namespaces a virtual service of those is or was exported to.
+func (i *virtualServiceIndex) inherit(previous *virtualServiceIndex, changed sets.String) {
+	affected := changed.Copy()
+	for _, exported := range []map[string][]config.Config{previous.exportedToNamespace, i.exportedToNamespace} {
+		for ns, configs := range exported {
+			if slices.ContainsFunc(configs, func(c config.Config) bool { return changed.Contains(c.Namespace) }) {
+				affected.Insert(ns)
+			}
+		}
+	}
+	i.byNamespace.inherit(previous.byNamespace, affected)
+}
+
+// groupByGateway groups virtual services by the gateways they are bound to, keeping their order.
+func groupByGateway(configs []config.Config) map[string][]config.Config {
+	if len(configs) == 0 {
+		return nil
+	}
+	out := map[string][]config.Config{}
+	for _, c := range configs {
+		for _, gw := range getGatewayNames(c.Spec.(*networking.VirtualService)) {
+			out[gw] = append(out[gw], c)
+		}
+	}
+	return out
 }
 
 func newVirtualServiceIndex() virtualServiceIndex {
@@ -134,6 +201,54 @@ type destinationRuleIndex struct {
 	//  exportedByNamespace contains all dest rules pertaining to a service exported by a namespace.
 	exportedByNamespace map[string]*consolidatedDestRules
 	rootNamespaceLocal  *consolidatedDestRules
+
+	// byNamespace holds the destination rules of each namespace, built from configsByNamespace on first use, if
+	// PILOT_ENABLE_LAZY_NAMESPACE_INDEXES is enabled. The maps above are not used then.
+	byNamespace        *namespaceIndex[namespaceDestRules]
+	configsByNamespace map[string][]config.Config
+}
+
+// namespaceDestRules are the destination rules defined in a namespace.
+type namespaceDestRules struct {
+	local    *consolidatedDestRules
+	exported *consolidatedDestRules
+	// rootLocal is only set for the root namespace.
+	rootLocal *consolidatedDestRules
+}
+
+// namespaceDestRules returns the destination rules defined in namespace, building them if needed.
+func (ps *PushContext) namespaceDestRules(namespace string) namespaceDestRules {
+	i := &ps.destinationRuleIndex
+	return i.byNamespace.get(namespace, func(namespace string) (namespaceDestRules, int) {
+		return ps.buildNamespaceDestRules(namespace, i.configsByNamespace[namespace])
+	})
+}
+
+// localDestRules returns the public and private destination rules defined in namespace.
+func (ps *PushContext) localDestRules(namespace string) *consolidatedDestRules {
+	if ps.destinationRuleIndex.byNamespace != nil {
+		return ps.namespaceDestRules(namespace).local
+	}
+	return ps.destinationRuleIndex.namespaceLocal[namespace]
+}
+
+// exportedDestRules returns the destination rules exported by namespace.
+func (ps *PushContext) exportedDestRules(namespace string) *consolidatedDestRules {
+	if ps.destinationRuleIndex.byNamespace != nil {
+		return ps.namespaceDestRules(namespace).exported
+	}
+	return ps.destinationRuleIndex.exportedByNamespace[namespace]
+}
+
+// rootLocalDestRules returns the private destination rules of the root namespace.
+func (ps *PushContext) rootLocalDestRules() *consolidatedDestRules {
+	if ps.destinationRuleIndex.byNamespace != nil {
+		if rl := ps.namespaceDestRules(ps.Mesh.RootNamespace).rootLocal; rl != nil {
+			return rl
+		}
+		return newConsolidatedDestRules()
+	}
+	return ps.destinationRuleIndex.rootNamespaceLocal
 }
 
 func newDestinationRuleIndex() destinationRuleIndex {
@@ -147,6 +262,10 @@ func newDestinationRuleIndex() destinationRuleIndex {
 type sidecarIndex struct {
 	// user configured sidecars for each namespace if available.
 	sidecarsByNamespace map[string][]*SidecarScope
+	// byNamespace holds the user configured sidecars of each namespace, converted from configsByNamespace on first
+	// use, if PILOT_ENABLE_LAZY_NAMESPACE_INDEXES is enabled. sidecarsByNamespace is not used then.
+	byNamespace        *namespaceIndex[[]*SidecarScope]
+	configsByNamespace map[string][]config.Config
 	// the Sidecar for the root namespace (if present). This applies to any namespace without its own Sidecar.
 	meshRootSidecarConfig *config.Config
 	// meshRootSidecarsByNamespace contains the default sidecar for namespaces that do not have a sidecar.
@@ -167,6 +286,20 @@ type sidecarIndex struct {
 	derivedSidecarMutex *sync.RWMutex
 }
 
+// namespaceSidecars returns the user configured sidecars of namespace.
+func (ps *PushContext) namespaceSidecars(namespace string) ([]*SidecarScope, bool) {
+	i := &ps.sidecarIndex
+	if i.byNamespace != nil {
+		sidecars := i.byNamespace.get(namespace, func(namespace string) ([]*SidecarScope, int) {
+			scopes := ps.convertToSidecarScopes(i.configsByNamespace[namespace])
+			return scopes, len(scopes)
+		})
+		return sidecars, len(sidecars) > 0
+	}
+	sidecars, f := i.sidecarsByNamespace[namespace]
+	return sidecars, f
+}
+
 func newSidecarIndex() sidecarIndex {
 	return sidecarIndex{
 		sidecarsByNamespace:           map[string][]*SidecarScope{},
@@ -386,6 +519,32 @@ type PushRequest struct {
 
 	// Forced defines that configs should be generated and pushed regardless if they have changed or not.
 	Forced bool
+
+	// Causes holds the span contexts of the config events that triggered this push, used for push causality
+	// tracing. When requests are merged the causes of both are kept, up to MaxPushCauses.
+	Causes []trace.SpanContext
+
+	// Span is the span context of the push this request is part of. It is set once the push context is
+	// initialized, and per-proxy pushes are traced as its children.
+	Span trace.SpanContext
+}
+
+// MaxPushCauses is the maximum number of causes tracked by a single PushRequest.
+const MaxPushCauses = 100
+
+// mergeCauses returns the causes of both requests, keeping the older causes if there are more than MaxPushCauses.
+// Neither input is mutated.
+func mergeCauses(a, b []trace.SpanContext) []trace.SpanContext {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	n := min(len(a)+len(b), MaxPushCauses)
+	out := make([]trace.SpanContext, 0, n)
+	out = append(out, a...)
+	return append(out, b[:max(0, n-len(a))]...)
 }
 
 type ResourceDelta = xds.ResourceDelta
@@ -519,6 +678,11 @@ func (pr *PushRequest) Merge(other *PushRequest) *PushRequest {
 		pr.AddressesUpdated.Merge(other.AddressesUpdated)
 	}
 
+	pr.Causes = mergeCauses(pr.Causes, other.Causes)
+	if other.Span.IsValid() {
+		pr.Span = other.Span
+	}
+
 	return pr
 }
 
@@ -551,6 +715,12 @@ func (pr *PushRequest) CopyMerge(other *PushRequest) *PushRequest {
 
 		// Merge the two reasons. Note that we shouldn't deduplicate here, or we would under count
 		Reason: reason,
+
+		Causes: mergeCauses(pr.Causes, other.Causes),
+		Span:   pr.Span,
+	}
+	if other.Span.IsValid() {
+		merged.Span = other.Span
 	}
 
 	if pr.ConfigsUpdated == nil && other.ConfigsUpdated == nil {
@@ -775,6 +945,17 @@ func (ps *PushContext) UpdateMetrics() {
 		mmap := ps.ProxyStatus[pm.Name()]
 		pm.Record(float64(len(mmap)))
 	}
+	if features.EnableLazyNamespaceIndexes {
+		namespaceIndexCount.With(typeTag.Value("destinationrule")).Record(float64(ps.destinationRuleIndex.byNamespace.len()))
+		namespaceIndexCount.With(typeTag.Value("sidecar")).Record(float64(ps.sidecarIndex.byNamespace.len()))
+		namespaceIndexCount.With(typeTag.Value("virtualservice")).Record(float64(ps.virtualServiceIndex.byNamespace.len()))
+		if ps.AuthzPolicies != nil {
+			namespaceIndexCount.With(typeTag.Value("authorizationpolicy")).Record(float64(ps.AuthzPolicies.byNamespace.len()))
+		}
+		if ps.Telemetry != nil {
+			namespaceIndexCount.With(typeTag.Value("telemetry")).Record(float64(ps.Telemetry.byNamespace.len()))
+		}
+	}
 }
 
 // It is called after virtual service short host name is resolved to FQDN
@@ -1088,19 +1269,17 @@ func (ps *PushContext) IsServiceVisible(service *Service, namespace string) bool
 // Instead, we pass the virtualServiceIndex directly into SelectVirtualServices
 // function.
 func (ps *PushContext) VirtualServicesForGateway(proxyNamespace, gateway string) []*config.Config {
-	name := types.NamespacedName{
-		Namespace: proxyNamespace,
-		Name:      gateway,
-	}
-	res := make([]*config.Config, 0, len(ps.virtualServiceIndex.privateByNamespaceAndGateway[name])+
-		len(ps.virtualServiceIndex.exportedToNamespaceByGateway[name])+
+	private := ps.virtualServiceIndex.private(proxyNamespace, gateway)
+	exported := ps.virtualServiceIndex.exported(proxyNamespace, gateway)
+	res := make([]*config.Config, 0, len(private)+
+		len(exported)+
 		len(ps.virtualServiceIndex.publicByGateway[gateway]))
 	// Use index-based iteration to get stable pointers to slice elements
-	for i := range ps.virtualServiceIndex.privateByNamespaceAndGateway[name] {
-		res = append(res, &ps.virtualServiceIndex.privateByNamespaceAndGateway[name][i])
+	for i := range private {
+		res = append(res, &private[i])
 	}
-	for i := range ps.virtualServiceIndex.exportedToNamespaceByGateway[name] {
-		res = append(res, &ps.virtualServiceIndex.exportedToNamespaceByGateway[name][i])
+	for i := range exported {
+		res = append(res, &exported[i])
 	}
 	// Favor same-namespace Gateway routes, to give the "consumer override" preference.
 	// We do 2 iterations here to avoid extra allocations.
@@ -1141,10 +1320,23 @@ func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Insta
 	return sidecar
 }
 
+// SidecarScopeName returns the name of the SidecarScope the proxy is assigned, without initializing it. Scopes are
+// per config namespace, so the name is only unique within the namespace of the proxy.
+func (ps *PushContext) SidecarScopeName(proxy *Proxy) string {
+	workloadLabels := proxy.Labels
+	if proxy.Type != SidecarProxy {
+		workloadLabels = nil
+	}
+	if sc := ps.doGetSidecarScope(proxy, workloadLabels); sc != nil {
+		return sc.Name
+	}
+	return ""
+}
+
 func (ps *PushContext) doGetSidecarScope(proxy *Proxy, workloadLabels labels.Instance) *SidecarScope {
 	// TODO: logic to merge multiple sidecar resources
 	// Currently we assume that there will be only one sidecar config for a namespace.
-	sidecars, hasSidecar := ps.sidecarIndex.sidecarsByNamespace[proxy.ConfigNamespace]
+	sidecars, hasSidecar := ps.namespaceSidecars(proxy.ConfigNamespace)
 	switch proxy.Type {
 	case Router, Waypoint:
 		ps.sidecarIndex.derivedSidecarMutex.Lock()
@@ -1230,10 +1422,10 @@ func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service)
 	// 1. select destination rule from proxy config namespace
 	if proxyNameSpace != ps.Mesh.RootNamespace {
 		// search through the DestinationRules in proxy's namespace first
-		if ps.destinationRuleIndex.namespaceLocal[proxyNameSpace] != nil {
+		if local := ps.localDestRules(proxyNameSpace); local != nil {
 			if _, drs, ok := MostSpecificHostMatch(service.Hostname,
-				ps.destinationRuleIndex.namespaceLocal[proxyNameSpace].specificDestRules,
-				ps.destinationRuleIndex.namespaceLocal[proxyNameSpace].wildcardDestRules,
+				local.specificDestRules,
+				local.wildcardDestRules,
 			); ok {
 				return drs
 			}
@@ -1242,9 +1434,10 @@ func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service)
 		// If this is a namespace local DR in the same namespace, this must be meant for this proxy, so we do not
 		// need to worry about overriding other DRs with *.local type rules here. If we ignore this, then exportTo=. in
 		// root namespace would always be ignored
+		rootLocal := ps.rootLocalDestRules()
 		if _, drs, ok := MostSpecificHostMatch(service.Hostname,
-			ps.destinationRuleIndex.rootNamespaceLocal.specificDestRules,
-			ps.destinationRuleIndex.rootNamespaceLocal.wildcardDestRules,
+			rootLocal.specificDestRules,
+			rootLocal.wildcardDestRules,
 		); ok {
 			return drs
 		}
@@ -1283,10 +1476,10 @@ func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service)
 }
 
 func (ps *PushContext) getExportedDestinationRuleFromNamespace(owningNamespace string, hostname host.Name, clientNamespace string) []*ConsolidatedDestRule {
-	if ps.destinationRuleIndex.exportedByNamespace[owningNamespace] != nil {
+	if exported := ps.exportedDestRules(owningNamespace); exported != nil {
 		if _, drs, ok := MostSpecificHostMatch(hostname,
-			ps.destinationRuleIndex.exportedByNamespace[owningNamespace].specificDestRules,
-			ps.destinationRuleIndex.exportedByNamespace[owningNamespace].wildcardDestRules,
+			exported.specificDestRules,
+			exported.wildcardDestRules,
 		); ok {
 			out := make([]*ConsolidatedDestRule, 0, len(drs))
 			for _, mdr := range drs {
@@ -1376,6 +1569,11 @@ func (ps *PushContext) updateContext(
 		trafficExtensionsChanged, proxyConfigsChanged bool
 
 	changedEnvoyFilters := sets.New[types.NamespacedName]()
+	changedVirtualServiceNamespaces := sets.New[string]()
+	changedDestinationRuleNamespaces := sets.New[string]()
+	changedSidecarNamespaces := sets.New[string]()
+	changedAuthzNamespaces := sets.New[string]()
+	changedTelemetryNamespaces := sets.New[string]()
 
 	// We do not need to watch Ingress or Gateway API changes. Both of these have their own controllers which will send
 	// events for Istio types (Gateway and VirtualService).
@@ -1385,12 +1583,15 @@ func (ps *PushContext) updateContext(
 			servicesChanged = true
 		case kind.DestinationRule:
 			destinationRulesChanged = true
+			changedDestinationRuleNamespaces.Insert(conf.Namespace)
 		case kind.VirtualService:
 			virtualServicesChanged = true
+			changedVirtualServiceNamespaces.Insert(conf.Namespace)
 		case kind.Gateway:
 			gatewayChanged = true
 		case kind.Sidecar:
 			sidecarsChanged = true
+			changedSidecarNamespaces.Insert(conf.Namespace)
 		case kind.TrafficExtension:
 			trafficExtensionsChanged = true
 		case kind.EnvoyFilter:
@@ -1398,11 +1599,13 @@ func (ps *PushContext) updateContext(
 			changedEnvoyFilters.Insert(types.NamespacedName{Namespace: conf.Namespace, Name: conf.Name})
 		case kind.AuthorizationPolicy:
 			authzChanged = true
+			changedAuthzNamespaces.Insert(conf.Namespace)
 		case kind.RequestAuthentication,
 			kind.PeerAuthentication:
 			authnChanged = true
 		case kind.Telemetry:
 			telemetryChanged = true
+			changedTelemetryNamespaces.Insert(conf.Namespace)
 		case kind.ProxyConfig:
 			proxyConfigsChanged = true
 		}
@@ -1429,12 +1632,18 @@ func (ps *PushContext) updateContext(
 
 	if virtualServicesChanged {
 		ps.initVirtualServices(env)
+		if ps.virtualServiceIndex.byNamespace != nil {
+			ps.virtualServiceIndex.inherit(&oldPushContext.virtualServiceIndex, changedVirtualServiceNamespaces)
+		}
 	} else {
 		ps.virtualServiceIndex = oldPushContext.virtualServiceIndex
 	}
 
 	if destinationRulesChanged {
 		ps.initDestinationRules(env)
+		if ps.destinationRuleIndex.byNamespace != nil {
+			ps.destinationRuleIndex.byNamespace.inherit(oldPushContext.destinationRuleIndex.byNamespace, changedDestinationRuleNamespaces)
+		}
 	} else {
 		ps.destinationRuleIndex = oldPushContext.destinationRuleIndex
 	}
@@ -1447,6 +1656,9 @@ func (ps *PushContext) updateContext(
 
 	if authzChanged {
 		ps.initAuthorizationPolicies(env)
+		if ps.AuthzPolicies.byNamespace != nil && oldPushContext.AuthzPolicies != nil {
+			ps.AuthzPolicies.byNamespace.inherit(oldPushContext.AuthzPolicies.byNamespace, changedAuthzNamespaces)
+		}
 	} else {
 		ps.AuthzPolicies = oldPushContext.AuthzPolicies
 	}
@@ -1458,6 +1670,10 @@ func (ps *PushContext) updateContext(
 		// TODO: find a way to avoid reinitializing telemetry
 		// if the services are not related to telemetry provider.
 		ps.initTelemetry(env)
+		// The telemetry configs of the namespaces in which none changed are the same, even if services changed.
+		if ps.Telemetry.byNamespace != nil && oldPushContext.Telemetry != nil {
+			ps.Telemetry.byNamespace.inherit(oldPushContext.Telemetry.byNamespace, changedTelemetryNamespaces)
+		}
 	} else {
 		ps.Telemetry = oldPushContext.Telemetry
 	}
@@ -1492,6 +1708,11 @@ func (ps *PushContext) updateContext(
 	// Sidecars need to be updated if services, virtual services, destination rules, or the sidecar configs change
 	if servicesChanged || virtualServicesChanged || destinationRulesChanged || authnChanged || sidecarsChanged {
 		ps.initSidecarScopes(env)
+		// If only sidecars changed, the sidecars of the other namespaces are unchanged and can be shared.
+		onlySidecarsChanged := !servicesChanged && !virtualServicesChanged && !destinationRulesChanged && !authnChanged
+		if onlySidecarsChanged && ps.sidecarIndex.byNamespace != nil {
+			ps.sidecarIndex.byNamespace.inherit(oldPushContext.sidecarIndex.byNamespace, changedSidecarNamespaces)
+		}
 	} else {
 		// new ADS connection may insert new entry to computedSidecarsByNamespace/gatewayDefaultSidecarsByNamespace.
 		oldPushContext.sidecarIndex.derivedSidecarMutex.RLock()
@@ -1751,6 +1972,38 @@ func (ps *PushContext) initVirtualServices(env *Environment) {
 		ps.virtualServiceIndex.destinationsByGateway = make(map[string]sets.String)
 	}
 
+	// With lazy namespace indexes, the private and exported virtual services are only grouped by gateway when a
+	// namespace is first used.
+	lazy := features.EnableLazyNamespaceIndexes
+	if lazy {
+		ps.virtualServiceIndex.byNamespace = newNamespaceIndex[namespaceVirtualServices]("virtualservice")
+		ps.virtualServiceIndex.privateByNamespace = map[string][]config.Config{}
+		ps.virtualServiceIndex.exportedToNamespace = map[string][]config.Config{}
+	}
+	addPrivate := func(virtualService config.Config, gwNames []string) {
+		ns := virtualService.Namespace
+		if lazy {
+			ps.virtualServiceIndex.privateByNamespace[ns] = append(ps.virtualServiceIndex.privateByNamespace[ns], virtualService)
+			return
+		}
+		private := ps.virtualServiceIndex.privateByNamespaceAndGateway
+		for _, gw := range gwNames {
+			n := types.NamespacedName{Namespace: ns, Name: gw}
+			private[n] = append(private[n], virtualService)
+		}
+	}
+	addExported := func(virtualService config.Config, gwNames []string, ns string) {
+		if lazy {
+			ps.virtualServiceIndex.exportedToNamespace[ns] = append(ps.virtualServiceIndex.exportedToNamespace[ns], virtualService)
+			return
+		}
+		exported := ps.virtualServiceIndex.exportedToNamespaceByGateway
+		for _, gw := range gwNames {
+			n := types.NamespacedName{Namespace: ns, Name: gw}
+			exported[n] = append(exported[n], virtualService)
+		}
+	}
+
 	vservices := env.VirtualServiceController.MergedVirtualServices()
 
 	totalVirtualServices.Record(float64(len(vservices)))
@@ -1764,11 +2017,7 @@ func (ps *PushContext) initVirtualServices(env *Environment) {
 			// We only honor ., *
 			if ps.exportToDefaults.virtualService.Contains(visibility.Private) {
 				// add to local namespace only
-				private := ps.virtualServiceIndex.privateByNamespaceAndGateway
-				for _, gw := range gwNames {
-					n := types.NamespacedName{Namespace: ns, Name: gw}
-					private[n] = append(private[n], virtualService)
-				}
+				addPrivate(virtualService, gwNames)
 			} else if ps.exportToDefaults.virtualService.Contains(visibility.Public) {
 				for _, gw := range gwNames {
 					ps.virtualServiceIndex.publicByGateway[gw] = append(ps.virtualServiceIndex.publicByGateway[gw], virtualService)
@@ -1791,17 +2040,9 @@ func (ps *PushContext) initVirtualServices(env *Environment) {
 				for exportTo := range exportToSet {
 					if exportTo == visibility.Private || string(exportTo) == ns {
 						// add to local namespace only
-						for _, gw := range gwNames {
-							n := types.NamespacedName{Namespace: ns, Name: gw}
-							ps.virtualServiceIndex.privateByNamespaceAndGateway[n] = append(ps.virtualServiceIndex.privateByNamespaceAndGateway[n], virtualService)
-						}
+						addPrivate(virtualService, gwNames)
 					} else {
-						exported := ps.virtualServiceIndex.exportedToNamespaceByGateway
-						// add to local namespace only
-						for _, gw := range gwNames {
-							n := types.NamespacedName{Namespace: string(exportTo), Name: gw}
-							exported[n] = append(exported[n], virtualService)
-						}
+						addExported(virtualService, gwNames, string(exportTo))
 					}
 				}
 			}
@@ -1930,25 +2171,39 @@ func (ps *PushContext) initSidecarScopes(env *Environment) {
 	}
 	ps.sidecarIndex.meshRootSidecarConfig = rootNSConfig
 
+	if features.EnableLazyNamespaceIndexes {
+		ps.sidecarIndex.byNamespace = newNamespaceIndex[[]*SidecarScope]("sidecar")
+		ps.sidecarIndex.configsByNamespace = groupByNamespace(sidecarConfigs)
+		return
+	}
+
 	ps.sidecarIndex.sidecarsByNamespace = make(map[string][]*SidecarScope)
 	ps.convertSidecarScopes(sidecarConfigs)
 }
 
 func (ps *PushContext) convertSidecarScopes(sidecarConfigs []config.Config) {
+	for _, sc := range ps.convertToSidecarScopes(sidecarConfigs) {
+		ps.sidecarIndex.sidecarsByNamespace[sc.Namespace] = append(ps.sidecarIndex.sidecarsByNamespace[sc.Namespace], sc)
+	}
+}
+
+// convertToSidecarScopes converts the sidecar configs, keeping their order, with up to ConvertSidecarScopeConcurrency
+// workers.
+func (ps *PushContext) convertToSidecarScopes(sidecarConfigs []config.Config) []*SidecarScope {
 	if len(sidecarConfigs) == 0 {
-		return
+		return nil
 	}
-	if features.ConvertSidecarScopeConcurrency > 1 {
-		ps.concurrentConvertToSidecarScope(sidecarConfigs)
-	} else {
-		for _, sidecarConfig := range sidecarConfigs {
-			ps.sidecarIndex.sidecarsByNamespace[sidecarConfig.Namespace] = append(ps.sidecarIndex.sidecarsByNamespace[sidecarConfig.Namespace],
-				convertToSidecarScope(ps, &sidecarConfig, sidecarConfig.Namespace))
-		}
+	if features.ConvertSidecarScopeConcurrency > 1 && len(sidecarConfigs) > 1 {
+		return ps.concurrentConvertToSidecarScope(sidecarConfigs)
+	}
+	sidecarScopes := make([]*SidecarScope, 0, len(sidecarConfigs))
+	for _, sidecarConfig := range sidecarConfigs {
+		sidecarScopes = append(sidecarScopes, convertToSidecarScope(ps, &sidecarConfig, sidecarConfig.Namespace))
 	}
+	return sidecarScopes
 }
 
-func (ps *PushContext) concurrentConvertToSidecarScope(sidecarConfigs []config.Config) {
+func (ps *PushContext) concurrentConvertToSidecarScope(sidecarConfigs []config.Config) []*SidecarScope {
 	type taskItem struct {
 		idx int
 		cfg config.Config
@@ -1980,10 +2235,7 @@ func (ps *PushContext) concurrentConvertToSidecarScope(sidecarConfigs []config.C
 
 	close(taskItems)
 	wg.Wait()
-
-	for _, sc := range sidecarScopes {
-		ps.sidecarIndex.sidecarsByNamespace[sc.Namespace] = append(ps.sidecarIndex.sidecarsByNamespace[sc.Namespace], sc)
-	}
+	return sidecarScopes
 }
 
 // Split out of DestinationRule expensive conversions - once per push.
@@ -2007,6 +2259,14 @@ func newConsolidatedDestRules() *consolidatedDestRules {
 	}
 }
 
+// size returns the number of hosts with destination rules. It is safe to call on a nil receiver.
+func (c *consolidatedDestRules) size() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.specificDestRules) + len(c.wildcardDestRules)
+}
+
 // Testing Only. This allows tests to inject a config without having the mock.
 func (ps *PushContext) SetDestinationRulesForTesting(configs []config.Config) {
 	ps.setDestinationRules(configs)
@@ -2044,14 +2304,50 @@ func (ps *PushContext) setDestinationRules(configs []config.Config) {
 	// Sort by time first. So if two destination rule have top level traffic policies
 	// we take the first one.
 	sortConfigBySelectorAndCreationTime(configs)
+	if features.EnableLazyNamespaceIndexes {
+		ps.destinationRuleIndex.byNamespace = newNamespaceIndex[namespaceDestRules]("destinationrule")
+		ps.destinationRuleIndex.configsByNamespace = groupByNamespace(configs)
+		return
+	}
 	namespaceLocalDestRules := make(map[string]*consolidatedDestRules)
 	exportedDestRulesByNamespace := make(map[string]*consolidatedDestRules)
 	rootNamespaceLocalDestRules := newConsolidatedDestRules()
 
+	ps.indexDestinationRules(configs, namespaceLocalDestRules, exportedDestRulesByNamespace, rootNamespaceLocalDestRules)
+
+	ps.destinationRuleIndex.namespaceLocal = namespaceLocalDestRules
+	ps.destinationRuleIndex.exportedByNamespace = exportedDestRulesByNamespace
+	ps.destinationRuleIndex.rootNamespaceLocal = rootNamespaceLocalDestRules
+}
+
+// buildNamespaceDestRules indexes the destination rules defined in namespace, and returns the index along with its
+// number of hosts.
+func (ps *PushContext) buildNamespaceDestRules(namespace string, configs []config.Config) (namespaceDestRules, int) {
+	local := make(map[string]*consolidatedDestRules)
+	exported := make(map[string]*consolidatedDestRules)
+	var rootLocal *consolidatedDestRules
+	if namespace == ps.Mesh.RootNamespace {
+		rootLocal = newConsolidatedDestRules()
+	}
+	ps.indexDestinationRules(configs, local, exported, rootLocal)
+	out := namespaceDestRules{local: local[namespace], exported: exported[namespace], rootLocal: rootLocal}
+	return out, out.local.size() + out.exported.size() + out.rootLocal.size()
+}
+
+// indexDestinationRules adds the sorted destination rules to the namespace local, exported and root namespace local
+// indexes.
+func (ps *PushContext) indexDestinationRules(configs []config.Config, namespaceLocalDestRules, exportedDestRulesByNamespace map[string]*consolidatedDestRules,
+	rootNamespaceLocalDestRules *consolidatedDestRules,
+) {
 	for i := range configs {
 		rule := configs[i].Spec.(*networking.DestinationRule)
 
-		rule.Host = string(ResolveShortnameToFQDN(rule.Host, configs[i].Meta))
+		if resolved := string(ResolveShortnameToFQDN(rule.Host, configs[i].Meta)); resolved != rule.Host {
+			// The spec is shared with the config store and other push contexts, resolve the host in a copy.
+			rule = protomarshal.Clone(rule)
+			rule.Host = resolved
+			configs[i].Spec = rule
+		}
 		var exportToSet sets.Set[visibility.Instance]
 
 		// destination rules with workloadSelector should not be exported to other namespaces
@@ -2100,10 +2396,6 @@ func (ps *PushContext) setDestinationRules(configs []config.Config) {
 			ps.mergeDestinationRule(rootNamespaceLocalDestRules, configs[i], exportToSet)
 		}
 	}
-
-	ps.destinationRuleIndex.namespaceLocal = namespaceLocalDestRules
-	ps.destinationRuleIndex.exportedByNamespace = exportedDestRulesByNamespace
-	ps.destinationRuleIndex.rootNamespaceLocal = rootNamespaceLocalDestRules
 }
 
 // pre computes all AuthorizationPolicies per namespace
@@ -25,6 +25,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -159,6 +160,28 @@ func TestConcurrentMerge(t *testing.T) {
 	}
 }
 
+func TestMergeCauses(t *testing.T) {
+	cause := func(i int) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{byte(i)}, SpanID: trace.SpanID{1}})
+	}
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{0xff}, SpanID: trace.SpanID{1}})
+
+	reqA := &PushRequest{Causes: []trace.SpanContext{cause(1)}}
+	reqB := &PushRequest{Causes: []trace.SpanContext{cause(2)}, Span: span}
+	merged := reqA.CopyMerge(reqB)
+	assert.Equal(t, merged.Causes, []trace.SpanContext{cause(1), cause(2)})
+	assert.Equal(t, merged.Span, span)
+	assert.Equal(t, len(reqA.Causes), 1)
+
+	many := &PushRequest{}
+	for i := 0; i < MaxPushCauses+10; i++ {
+		many = many.Merge(&PushRequest{Causes: []trace.SpanContext{cause(i)}})
+	}
+	assert.Equal(t, len(many.Causes), MaxPushCauses)
+	// The oldest causes are kept
+	assert.Equal(t, many.Causes[0], cause(0))
+}
+
 func TestEnvoyFilters(t *testing.T) {
 	envoyFilters := []*EnvoyFilterWrapper{
 		convertToEnvoyFilterWrapper(&config.Config{
@@ -1842,7 +1865,7 @@ func TestInitPushContext(t *testing.T) {
 		// Allow looking into exported fields for parts of push context
 		cmp.AllowUnexported(PushContext{}, exportToDefaults{}, serviceIndex{}, virtualServiceIndex{},
 			destinationRuleIndex{}, gatewayIndex{}, consolidatedDestRules{}, IstioEgressListenerWrapper{}, SidecarScope{},
-			AuthenticationPolicies{}, NetworkManager{}, sidecarIndex{}, Telemetries{}, ProxyConfigs{}, ConsolidatedDestRule{},
+			AuthenticationPolicies{}, AuthorizationPolicies{}, NetworkManager{}, sidecarIndex{}, Telemetries{}, ProxyConfigs{}, ConsolidatedDestRule{},
 			ClusterLocalHosts{}),
 		// These are not feasible/worth comparing
 		cmpopts.IgnoreTypes(sync.RWMutex{}, localServiceDiscovery{}, FakeStore{}, atomic.Bool{}, sync.Mutex{}, func() {}),
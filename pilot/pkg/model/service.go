@@ -1225,6 +1225,9 @@ const (
 	// WaypointMissing is set on a ServiceEntry with a wildcard hostname and not bound to a waypoint.
 	// It is used to inform the user that the ServiceEntry will not be active until it is bound to a waypoint.
 	WaypointMissing ConditionType = "istio.io/WaypointMissing"
+	// WaypointProvisioned is set on a Service that L7 configuration needs a waypoint for, when waypoint auto-provisioning
+	// is enabled. It reports whether a waypoint was provisioned and bound for the Service.
+	WaypointProvisioned ConditionType = "istio.io/WaypointProvisioned"
 
 	NoWaypointForWildcardService          string = "NoWaypointForWildcardService"
 	NoWaypointForConnectStrategyCondition string = "NoWaypointForRacingConnectStrategy"
@@ -32,11 +32,14 @@ import (
 	"istio.io/api/envoy/extensions/stats"
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	tpb "istio.io/api/telemetry/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/xds"
 	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/util/sets"
 )
 
@@ -59,6 +62,11 @@ type Telemetries struct {
 	// The name of the root namespace.
 	RootNamespace string `json:"root_namespace"`
 
+	// byNamespace holds the Telemetry configs of each namespace, converted from configsByNamespace on first use, if
+	// PILOT_ENABLE_LAZY_NAMESPACE_INDEXES is enabled. NamespaceToTelemetries is not used then.
+	byNamespace        *namespaceIndex[[]Telemetry]
+	configsByNamespace map[string][]config.Config
+
 	// Computed meshConfig
 	meshConfig *meshconfig.MeshConfig
 
@@ -116,18 +124,37 @@ func getTelemetries(env *Environment) *Telemetries {
 
 	fromEnv := env.List(gvk.Telemetry, NamespaceAll)
 	sortConfigByCreationTime(fromEnv)
+	if features.EnableLazyNamespaceIndexes {
+		telemetries.byNamespace = newNamespaceIndex[[]Telemetry]("telemetry")
+		telemetries.configsByNamespace = groupByNamespace(fromEnv)
+		return telemetries
+	}
 	for _, config := range fromEnv {
-		telemetry := Telemetry{
-			Name:      config.Name,
-			Namespace: config.Namespace,
-			Spec:      config.Spec.(*tpb.Telemetry),
-		}
-		telemetries.NamespaceToTelemetries[config.Namespace] = append(telemetries.NamespaceToTelemetries[config.Namespace], telemetry)
+		telemetries.NamespaceToTelemetries[config.Namespace] = append(telemetries.NamespaceToTelemetries[config.Namespace], toTelemetry(config))
 	}
 
 	return telemetries
 }
 
+func toTelemetry(c config.Config) Telemetry {
+	return Telemetry{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+		Spec:      c.Spec.(*tpb.Telemetry),
+	}
+}
+
+// namespaceTelemetries returns the Telemetry configs of namespace, converting them if needed.
+func (t *Telemetries) namespaceTelemetries(namespace string) []Telemetry {
+	if t.byNamespace == nil {
+		return t.NamespaceToTelemetries[namespace]
+	}
+	return t.byNamespace.get(namespace, func(namespace string) ([]Telemetry, int) {
+		telemetries := slices.Map(t.configsByNamespace[namespace], toTelemetry)
+		return telemetries, len(telemetries)
+	})
+}
+
 type metricsConfig struct {
 	ClientMetrics     metricConfig
 	ServerMetrics     metricConfig
@@ -457,7 +484,7 @@ func (t *Telemetries) applicableTelemetries(proxy *Proxy, svc *Service) computed
 	}
 
 	matcher := PolicyMatcherForProxy(proxy).WithService(svc).WithRootNamespace(t.RootNamespace)
-	for _, telemetry := range t.NamespaceToTelemetries[namespace] {
+	for _, telemetry := range t.namespaceTelemetries(namespace) {
 		spec := telemetry.Spec
 		// Namespace wide policy; already handled above
 		if len(spec.GetSelector().GetMatchLabels()) == 0 && len(GetTargetRefs(spec)) == 0 {
@@ -660,7 +687,7 @@ func matchWorkloadMode(selector *tpb.AccessLogging_LogSelector, mode tpb.Workloa
 }
 
 func (t *Telemetries) namespaceWideTelemetryConfig(namespace string) Telemetry {
-	for _, tel := range t.NamespaceToTelemetries[namespace] {
+	for _, tel := range t.namespaceTelemetries(namespace) {
 		if len(tel.Spec.GetSelector().GetMatchLabels()) == 0 && len(GetTargetRefs(tel.Spec)) == 0 {
 			return tel
 		}
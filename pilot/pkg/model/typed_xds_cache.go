@@ -103,6 +103,15 @@ type typedXdsCache[K comparable] interface {
 	Keys() []K
 	// Snapshot returns a snapshot of all keys and values. This is for testing/debug only
 	Snapshot() []*discovery.Resource
+	// Entries returns a snapshot of all keys, values and their dependent configs.
+	Entries() []typedCacheEntry[K]
+}
+
+// typedCacheEntry is a single cached value along with its key and dependents.
+type typedCacheEntry[K comparable] struct {
+	key              K
+	value            *discovery.Resource
+	dependentConfigs []ConfigHash
 }
 
 // newTypedXdsCache returns an instance of a cache.
@@ -359,6 +368,22 @@ func (l *lruCache[K]) Snapshot() []*discovery.Resource {
 	return res
 }
 
+func (l *lruCache[K]) Entries() []typedCacheEntry[K] {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	iKeys := l.store.Keys()
+	res := make([]typedCacheEntry[K], 0, len(iKeys))
+	for _, ik := range iKeys {
+		// Peek rather than Get, so that exporting the cache does not change the LRU ordering.
+		v, ok := l.store.Peek(ik)
+		if !ok || v.value == nil {
+			continue
+		}
+		res = append(res, typedCacheEntry[K]{key: ik, value: v.value, dependentConfigs: v.dependentConfigs})
+	}
+	return res
+}
+
 func (l *lruCache[K]) indexLength() int {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -397,3 +422,5 @@ func (d disabledCache[K]) ClearAll() {}
 func (d disabledCache[K]) Keys() []K { return nil }
 
 func (d disabledCache[K]) Snapshot() []*discovery.Resource { return nil }
+
+func (d disabledCache[K]) Entries() []typedCacheEntry[K] { return nil }
@@ -32,9 +32,10 @@ import (
 // This function is used by sidecar converter.
 // Returns pointers to configs in the index to avoid copying config.Config structs.
 func SelectVirtualServices(vsidx virtualServiceIndex, configNamespace string, hostsByNamespace map[string]hostClassification) []*config.Config {
-	n := types.NamespacedName{Namespace: configNamespace, Name: constants.IstioMeshGateway}
-	estimatedCap := len(vsidx.privateByNamespaceAndGateway[n]) +
-		len(vsidx.exportedToNamespaceByGateway[n]) +
+	private := vsidx.private(configNamespace, constants.IstioMeshGateway)
+	exported := vsidx.exported(configNamespace, constants.IstioMeshGateway)
+	estimatedCap := len(private) +
+		len(exported) +
 		len(vsidx.publicByGateway[constants.IstioMeshGateway])
 	importedVirtualServices := make([]*config.Config, 0, estimatedCap)
 	vsset := sets.New[types.NamespacedName]()
@@ -118,8 +119,8 @@ func SelectVirtualServices(vsidx virtualServiceIndex, configNamespace string, ho
 		}
 	}
 
-	loopAndAdd(vsidx.privateByNamespaceAndGateway[n])
-	loopAndAdd(vsidx.exportedToNamespaceByGateway[n])
+	loopAndAdd(private)
+	loopAndAdd(exported)
 	loopAndAdd(vsidx.publicByGateway[constants.IstioMeshGateway])
 
 	return importedVirtualServices
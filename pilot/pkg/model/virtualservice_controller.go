@@ -17,9 +17,11 @@ package model
 import (
 	"k8s.io/apimachinery/pkg/types"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/mesh/meshwatcher"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
@@ -55,21 +57,47 @@ func NewVirtualServiceController(
 	options VSControllerOptions,
 	meshConfig meshwatcher.WatcherCollection,
 ) *VirtualServiceController {
+	VirtualServices := store.KrtCollection(gvk.VirtualService)
+	if VirtualServices == nil {
+		panic("VirtualServices is nil")
+	}
 	stop := make(chan struct{})
 	opts := krt.NewOptionsBuilder(stop, "virtualservice", options.KrtDebugger)
+	c := newVirtualServiceController(VirtualServices, meshConfig.AsCollection(), options.XDSUpdater, opts)
+	c.stop = stop
+	return c
+}
 
-	c := &VirtualServiceController{
-		xdsUpdater: options.XDSUpdater,
-		stop:       stop,
+// newStaticVirtualServiceController returns a controller that merges a fixed set of VirtualServices, for the given
+// mesh config. The VirtualServices are merged by the time it returns, unless stop is closed first.
+func newStaticVirtualServiceController(
+	virtualServices []config.Config,
+	meshConfig *meshconfig.MeshConfig,
+	stop <-chan struct{},
+) *VirtualServiceController {
+	if meshConfig == nil {
+		meshConfig = mesh.DefaultMeshConfig()
 	}
+	opts := krt.NewOptionsBuilder(stop, "virtualservice-static", nil)
+	vs := krt.NewStaticCollection[config.Config](nil, virtualServices, opts.WithName("VirtualServices")...)
+	mc := krt.NewStatic[MeshConfig](&MeshConfig{MeshConfig: meshConfig}, true, opts.WithName("MeshConfig")...)
+	c := newVirtualServiceController(vs, mc.AsCollection(), nil, opts)
+	c.outputs.MergedVirtualServices.WaitUntilSynced(stop)
+	return c
+}
 
-	VirtualServices := store.KrtCollection(gvk.VirtualService)
-	if VirtualServices == nil {
-		panic("VirtualServices is nil")
+func newVirtualServiceController(
+	VirtualServices krt.Collection[config.Config],
+	meshConfig krt.Collection[MeshConfig],
+	xdsUpdater XDSUpdater,
+	opts krt.OptionsBuilder,
+) *VirtualServiceController {
+	c := &VirtualServiceController{
+		xdsUpdater: xdsUpdater,
 	}
 
 	DefaultExportTo := defaultExportTo(
-		meshConfig.AsCollection(),
+		meshConfig,
 		opts,
 	)
 
@@ -0,0 +1,262 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/util/hash"
+	"istio.io/istio/pkg/version"
+)
+
+// XdsCacheSnapshot is a serializable copy of the XDS cache. It allows a restarted istiod to start with
+// a warm cache, rather than regenerating configuration for every proxy on the first push.
+type XdsCacheSnapshot struct {
+	// Version is the istiod build that wrote the snapshot. Cache keys are only stable within a single
+	// build, so snapshots written by other versions are discarded.
+	Version string `json:"version"`
+	// PushVersion is the version of the push context when the snapshot was taken.
+	PushVersion string `json:"pushVersion"`
+	// MeshFingerprint is the fingerprint of the mesh config and mesh networks. These are not tracked
+	// as dependent configs, as any change to them clears the whole cache.
+	MeshFingerprint uint64 `json:"meshFingerprint"`
+	// Fingerprints holds the fingerprint of every config referenced by an entry, at the time the
+	// snapshot was taken.
+	Fingerprints map[ConfigHash]uint64 `json:"fingerprints"`
+	// Entries holds the cached resources.
+	Entries []XdsCacheSnapshotEntry `json:"entries"`
+}
+
+// XdsCacheSnapshotEntry is a single entry of an XdsCacheSnapshot.
+type XdsCacheSnapshotEntry struct {
+	Type       string       `json:"type"`
+	Key        uint64       `json:"key"`
+	Dependents []ConfigHash `json:"dependents"`
+	// Resource is the serialized discovery.Resource.
+	Resource []byte `json:"resource"`
+}
+
+func (e XdsCacheSnapshotEntry) DependentConfigs() []ConfigHash {
+	return e.Dependents
+}
+
+// PersistentXdsCache is implemented by XdsCache implementations that can be exported and restored.
+type PersistentXdsCache interface {
+	XdsCache
+	// Export returns all cache entries that can be persisted. SDS entries hold private keys and are never exported.
+	Export() []XdsCacheSnapshotEntry
+	// Restore adds the given entries to the cache, as if they were generated for the given push request.
+	// It returns the number of entries restored.
+	Restore(entries []XdsCacheSnapshotEntry, pushReq *PushRequest) int
+}
+
+var _ PersistentXdsCache = XdsCacheImpl{}
+
+func (x XdsCacheImpl) Export() []XdsCacheSnapshotEntry {
+	var out []XdsCacheSnapshotEntry
+	out = appendSnapshotEntries(out, CDSType, x.cds.Entries())
+	out = appendSnapshotEntries(out, EDSType, x.eds.Entries())
+	out = appendSnapshotEntries(out, RDSType, x.rds.Entries())
+	return out
+}
+
+func appendSnapshotEntries(out []XdsCacheSnapshotEntry, typ string, entries []typedCacheEntry[uint64]) []XdsCacheSnapshotEntry {
+	for _, e := range entries {
+		b, err := proto.Marshal(e.value)
+		if err != nil {
+			log.Warnf("failed to marshal %s cache entry %d: %v", typ, e.key, err)
+			continue
+		}
+		out = append(out, XdsCacheSnapshotEntry{
+			Type:       typ,
+			Key:        e.key,
+			Dependents: e.dependentConfigs,
+			Resource:   b,
+		})
+	}
+	return out
+}
+
+func (x XdsCacheImpl) Restore(entries []XdsCacheSnapshotEntry, pushReq *PushRequest) int {
+	restored := 0
+	for _, e := range entries {
+		res := &discovery.Resource{}
+		if err := proto.Unmarshal(e.Resource, res); err != nil {
+			log.Warnf("failed to unmarshal %s cache entry %d: %v", e.Type, e.Key, err)
+			continue
+		}
+		switch e.Type {
+		case CDSType:
+			x.cds.Add(e.Key, e, pushReq, res)
+		case EDSType:
+			x.eds.Add(e.Key, e, pushReq, res)
+		case RDSType:
+			x.rds.Add(e.Key, e, pushReq, res)
+		default:
+			log.Warnf("unknown cache entry type %s in snapshot", e.Type)
+			continue
+		}
+		restored++
+	}
+	return restored
+}
+
+// NewXdsCacheSnapshot builds a snapshot of the cache. Only entries for which every dependent config has a known
+// fingerprint are included, as the others could not be validated when the snapshot is restored.
+func NewXdsCacheSnapshot(cache PersistentXdsCache, pushVersion string, meshFingerprint uint64,
+	fingerprints map[ConfigHash]uint64,
+) *XdsCacheSnapshot {
+	snap := &XdsCacheSnapshot{
+		Version:         version.Info.String(),
+		PushVersion:     pushVersion,
+		MeshFingerprint: meshFingerprint,
+		Fingerprints:    map[ConfigHash]uint64{},
+	}
+outer:
+	for _, e := range cache.Export() {
+		for _, dep := range e.Dependents {
+			if _, f := fingerprints[dep]; !f {
+				continue outer
+			}
+		}
+		for _, dep := range e.Dependents {
+			snap.Fingerprints[dep] = fingerprints[dep]
+		}
+		snap.Entries = append(snap.Entries, e)
+	}
+	return snap
+}
+
+// ValidEntries returns the entries of the snapshot that are still valid for the given mesh and config fingerprints.
+// An entry is valid only if none of the configs it depends on have changed since the snapshot was taken.
+func (snap *XdsCacheSnapshot) ValidEntries(meshFingerprint uint64, fingerprints map[ConfigHash]uint64) []XdsCacheSnapshotEntry {
+	if snap.Version != version.Info.String() {
+		log.Infof("discarding xds cache snapshot from version %s", snap.Version)
+		return nil
+	}
+	if snap.MeshFingerprint != meshFingerprint {
+		log.Infof("discarding xds cache snapshot %s, mesh config has changed", snap.PushVersion)
+		return nil
+	}
+	var out []XdsCacheSnapshotEntry
+outer:
+	for _, e := range snap.Entries {
+		for _, dep := range e.Dependents {
+			want, f := snap.Fingerprints[dep]
+			if !f {
+				continue outer
+			}
+			got, f := fingerprints[dep]
+			if !f || got != want {
+				continue outer
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// MeshFingerprint computes a fingerprint of the mesh config and mesh networks of the environment.
+func MeshFingerprint(env *Environment) uint64 {
+	h := hash.New()
+	opts := proto.MarshalOptions{Deterministic: true}
+	if m := env.Mesh(); m != nil {
+		b, _ := opts.Marshal(m)
+		h.Write(b)
+	}
+	h.WriteString("/")
+	if n := env.MeshNetworks(); n != nil {
+		b, _ := opts.Marshal(n)
+		h.Write(b)
+	}
+	return h.Sum64()
+}
+
+// ConfigFingerprints computes a fingerprint of every config and service known to the environment, keyed by the hash of
+// its ConfigKey. A config fingerprint changes whenever its resource version does; a service fingerprint changes
+// whenever the service or any of its endpoints do. Configs without a resource version are left out.
+func ConfigFingerprints(env *Environment, push *PushContext) map[ConfigHash]uint64 {
+	out := map[ConfigHash]uint64{}
+	if env.ConfigStore != nil {
+		for _, s := range env.Schemas().All() {
+			k, ok := gvk.ToKind(s.GroupVersionKind())
+			if !ok {
+				continue
+			}
+			for _, cfg := range env.List(s.GroupVersionKind(), "") {
+				if cfg.ResourceVersion == "" {
+					continue
+				}
+				h := hash.New()
+				h.WriteString(cfg.ResourceVersion)
+				h.WriteString("/")
+				h.WriteString(strconv.FormatInt(cfg.Generation, 10))
+				out[ConfigKey{Kind: k, Name: cfg.Name, Namespace: cfg.Namespace}.HashCode()] = h.Sum64()
+			}
+		}
+	}
+	if push == nil {
+		return out
+	}
+	for _, svc := range push.GetAllServices() {
+		key := ConfigKey{Kind: kind.ServiceEntry, Name: string(svc.Hostname), Namespace: svc.Attributes.Namespace}.HashCode()
+		h := hash.New()
+		if b, err := json.Marshal(svc); err == nil {
+			h.Write(b)
+		} else {
+			continue
+		}
+		if env.EndpointIndex != nil {
+			if shards, f := env.EndpointIndex.ShardsForService(string(svc.Hostname), svc.Attributes.Namespace); f {
+				h.WriteString(strconv.FormatUint(endpointShardsFingerprint(shards), 10))
+			}
+		}
+		// Services with the same hostname may exist in multiple clusters, fold them into a single fingerprint.
+		out[key] ^= h.Sum64()
+	}
+	return out
+}
+
+// endpointShardsFingerprint computes an order independent fingerprint of all endpoints in the shards.
+func endpointShardsFingerprint(shards *EndpointShards) uint64 {
+	shards.RLock()
+	defer shards.RUnlock()
+	var sums []uint64
+	for _, k := range shards.Keys() {
+		for _, ep := range shards.Shards[k] {
+			b, err := json.Marshal(ep)
+			if err != nil {
+				continue
+			}
+			h := hash.New()
+			h.WriteString(k.String())
+			h.Write(b)
+			sums = append(sums, h.Sum64())
+		}
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i] < sums[j] })
+	h := hash.New()
+	for _, s := range sums {
+		h.WriteString(strconv.FormatUint(s, 10))
+	}
+	return h.Sum64()
+}
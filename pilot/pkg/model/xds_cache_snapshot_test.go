@@ -0,0 +1,84 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+type snapshotTestEntry struct {
+	entry
+	typ string
+}
+
+func (e snapshotTestEntry) Type() string    { return e.typ }
+func (e snapshotTestEntry) Key() any        { return e.entry.Key() }
+func (e snapshotTestEntry) Cacheable() bool { return true }
+
+func TestXdsCacheSnapshot(t *testing.T) {
+	dr := ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}.HashCode()
+	vs := ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "default"}.HashCode()
+	secret := ConfigKey{Kind: kind.Secret, Name: "cert", Namespace: "default"}.HashCode()
+
+	cluster := snapshotTestEntry{entry: entry{key: "cluster", dependentConfigs: []ConfigHash{dr}}, typ: CDSType}
+	route := snapshotTestEntry{entry: entry{key: "route", dependentConfigs: []ConfigHash{vs}}, typ: RDSType}
+	unknown := snapshotTestEntry{entry: entry{key: "unknown", dependentConfigs: []ConfigHash{secret}}, typ: CDSType}
+
+	req := &PushRequest{Start: time.Now()}
+	c := NewXdsCache().(XdsCacheImpl)
+	c.Add(cluster, req, &discovery.Resource{Name: "cluster"})
+	c.Add(route, req, &discovery.Resource{Name: "route"})
+	c.Add(unknown, req, &discovery.Resource{Name: "unknown"})
+
+	fingerprints := map[ConfigHash]uint64{dr: 1, vs: 2}
+	snap := NewXdsCacheSnapshot(c, "v1", 10, fingerprints)
+	// Entries with unknown dependents cannot be validated, so are not exported
+	assert.Equal(t, len(snap.Entries), 2)
+	assert.Equal(t, snap.Fingerprints, fingerprints)
+
+	t.Run("unchanged", func(t *testing.T) {
+		valid := snap.ValidEntries(10, map[ConfigHash]uint64{dr: 1, vs: 2})
+		assert.Equal(t, len(valid), 2)
+
+		restored := NewXdsCache().(XdsCacheImpl)
+		assert.Equal(t, restored.Restore(valid, &PushRequest{Start: time.Now()}), 2)
+		assert.Equal(t, restored.Get(cluster).GetName(), "cluster")
+		assert.Equal(t, restored.Get(route).GetName(), "route")
+	})
+	t.Run("config changed", func(t *testing.T) {
+		valid := snap.ValidEntries(10, map[ConfigHash]uint64{dr: 1, vs: 3})
+		assert.Equal(t, len(valid), 1)
+		assert.Equal(t, valid[0].Type, CDSType)
+	})
+	t.Run("config removed", func(t *testing.T) {
+		valid := snap.ValidEntries(10, map[ConfigHash]uint64{vs: 2})
+		assert.Equal(t, len(valid), 1)
+		assert.Equal(t, valid[0].Type, RDSType)
+	})
+	t.Run("mesh changed", func(t *testing.T) {
+		assert.Equal(t, len(snap.ValidEntries(11, fingerprints)), 0)
+	})
+	t.Run("version changed", func(t *testing.T) {
+		old := *snap
+		old.Version = "old"
+		assert.Equal(t, len(old.ValidEntries(10, fingerprints)), 0)
+	})
+}
@@ -48,6 +48,7 @@ import (
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
+	secconfig "istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/util/sets"
@@ -377,6 +378,7 @@ func (cb *ClusterBuilder) applyDestinationRule(mc *clusterWrapper, clusterMode C
 
 	if destRule != nil {
 		opts.isDrWithSelector = destinationRule.GetWorkloadSelector() != nil
+		opts.mtlsParameters = secconfig.MTLSParametersFromAnnotations(destRule.Annotations)
 	}
 	// Apply traffic policy for the main default cluster.
 	cb.applyTrafficPolicy(service, opts)
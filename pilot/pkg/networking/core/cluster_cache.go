@@ -46,6 +46,7 @@ type clusterCache struct {
 	hbone                   bool
 	proxyView               model.ProxyView
 	metadataCerts           *metadataCerts // metadata certificates of proxy
+	mtlsSignatureAlgorithms []string       // mesh mTLS signature algorithms set in the proxy metadata
 	endpointBuilder         *endpoints.EndpointBuilder
 
 	// service attributes
@@ -130,6 +131,12 @@ func (t *clusterCache) Key() any {
 	h.WriteString(strconv.FormatBool(t.preserveHTTP1HeaderCase))
 	h.Write(Separator)
 
+	for _, alg := range t.mtlsSignatureAlgorithms {
+		h.WriteString(alg)
+		h.Write(Separator)
+	}
+	h.Write(Separator)
+
 	if t.endpointBuilder != nil {
 		t.endpointBuilder.WriteHash(h)
 	}
@@ -207,6 +214,7 @@ func buildClusterKey(service *model.Service, port *model.Port, cb *ClusterBuilde
 		destinationRule:         dr,
 		envoyFilterKeys:         efKeys,
 		metadataCerts:           cb.metadataCerts,
+		mtlsSignatureAlgorithms: cb.proxyMetadata.MTLSSignatureAlgorithms,
 		peerAuthVersion:         cb.sidecarScope.AuthnPolicies.GetVersion(),
 		serviceAccounts:         cb.req.Push.ServiceAccounts(service.Hostname, service.Attributes.Namespace),
 		endpointBuilder:         eb,
@@ -148,6 +148,7 @@ func (cb *ClusterBuilder) buildUpstreamClusterTLSContext(opts *buildClusterOpts,
 				ValidationContextSdsSecretConfig: sec_model.ConstructSdsSecretConfig(sec_model.SDSRootResourceName),
 			},
 		}
+		sec_model.ApplyMTLSParameters(tlsContext.CommonTlsContext, sec_model.MeshMTLSParameters(opts.mesh, cb.proxyMetadata).Override(opts.mtlsParameters))
 		// Set default SNI of cluster name for istio_mutual if sni is not set and if not a DFP cluster.
 		if len(tlsContext.Sni) == 0 && !c.isDFPCluster {
 			tlsContext.Sni = c.cluster.Name
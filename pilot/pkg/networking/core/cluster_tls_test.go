@@ -37,6 +37,7 @@ import (
 	authn_model "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/serviceregistry/provider"
 	"istio.io/istio/pilot/test/xdstest"
+	secconfig "istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/util/assert"
@@ -1765,6 +1766,66 @@ func TestBuildUpstreamClusterTLSContext(t *testing.T) {
 	}
 }
 
+func TestBuildUpstreamClusterTLSContextMTLSParameters(t *testing.T) {
+	testCases := []struct {
+		name               string
+		mesh               *meshconfig.MeshConfig
+		mtlsParameters     secconfig.MTLSParameters
+		algorithms         []string
+		tls                *networking.ClientTLSSettings
+		expectedCurves     []string
+		expectedAlgorithms []string
+	}{
+		{
+			name: "mesh curves",
+			mesh: &meshconfig.MeshConfig{
+				MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519MLKEM768", "X25519"}},
+			},
+			tls:            &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_ISTIO_MUTUAL},
+			expectedCurves: []string{"X25519MLKEM768", "X25519"},
+		},
+		{
+			name:               "proxy metadata algorithms",
+			mesh:               &meshconfig.MeshConfig{},
+			algorithms:         []string{"ecdsa_secp256r1_sha256"},
+			tls:                &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_ISTIO_MUTUAL},
+			expectedAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+		},
+		{
+			name: "destination rule overrides mesh",
+			mesh: &meshconfig.MeshConfig{
+				MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519"}},
+			},
+			mtlsParameters: secconfig.MTLSParameters{
+				ECDHCurves:          []string{"X25519MLKEM768"},
+				SignatureAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+			},
+			tls:                &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_ISTIO_MUTUAL},
+			expectedCurves:     []string{"X25519MLKEM768"},
+			expectedAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+		},
+		{
+			name: "not applied to SIMPLE",
+			mesh: &meshconfig.MeshConfig{
+				MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519MLKEM768"}},
+			},
+			tls: &networking.ClientTLSSettings{Mode: networking.ClientTLSSettings_SIMPLE, InsecureSkipVerify: &wrappers.BoolValue{Value: true}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxy := newSidecarProxy()
+			proxy.Metadata.MTLSSignatureAlgorithms = tc.algorithms
+			cb := NewClusterBuilder(proxy, nil, model.DisabledCache{})
+			opts := &buildClusterOpts{mesh: tc.mesh, mutable: newTestCluster(), mtlsParameters: tc.mtlsParameters}
+			ret, err := cb.buildUpstreamClusterTLSContext(opts, tc.tls)
+			assert.NoError(t, err)
+			assert.Equal(t, ret.CommonTlsContext.TlsParams.EcdhCurves, tc.expectedCurves)
+			assert.Equal(t, ret.CommonTlsContext.TlsParams.SignatureAlgorithms, tc.expectedAlgorithms)
+		})
+	}
+}
+
 func TestBuildAutoMtlsSettings(t *testing.T) {
 	tlsSettings := &networking.ClientTLSSettings{
 		Mode:            networking.ClientTLSSettings_ISTIO_MUTUAL,
@@ -39,9 +39,19 @@ type ConfigGenerator interface {
 	BuildDeltaClusters(proxy *model.Proxy, updates *model.PushRequest,
 		watched *model.WatchedResource) ([]*discovery.Resource, []string, model.XdsLogDetails, bool)
 
+	// BuildDeltaListeners returns both a list of listeners that need to be pushed for a given proxy and a list of listeners
+	// that have been deleted and should be removed from a given proxy. This is Delta LDS output.
+	BuildDeltaListeners(node *model.Proxy, updates *model.PushRequest,
+		watched *model.WatchedResource) ([]*listener.Listener, []string, bool)
+
 	// BuildHTTPRoutes returns the list of HTTP routes for the given proxy. This is the RDS output
 	BuildHTTPRoutes(node *model.Proxy, req *model.PushRequest, routeNames []string) ([]*discovery.Resource, model.XdsLogDetails)
 
+	// BuildDeltaHTTPRoutes returns both a list of HTTP routes that need to be pushed for a given proxy and a list of routes
+	// that have been deleted and should be removed from a given proxy. This is Delta RDS output.
+	BuildDeltaHTTPRoutes(node *model.Proxy, updates *model.PushRequest,
+		watched *model.WatchedResource) ([]*discovery.Resource, []string, model.XdsLogDetails, bool)
+
 	// BuildNameTable returns list of hostnames and the associated IPs
 	BuildNameTable(node *model.Proxy, push *model.PushContext) *dnsProto.NameTable
 
@@ -0,0 +1,248 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// deltaListenerConfigTypes are the config types for which listeners and routes can be generated incrementally.
+var deltaListenerConfigTypes = sets.New(
+	kind.ServiceEntry,
+	kind.VirtualService,
+	kind.DestinationRule,
+)
+
+// BuildDeltaListeners returns the listeners that changed for the updated configs, and the listeners that have
+// been removed. If the changes cannot be scoped, all listeners are built and returned, and delta is not used.
+// Outbound listeners and routes of a sidecar are built per port, and their conflict resolution is scoped to a port,
+// so only those of the ports of the services impacted by the updated configs are built. Listeners that are not
+// built per port, such as the virtual inbound listener, are always built and returned.
+func (configgen *ConfigGeneratorImpl) BuildDeltaListeners(proxy *model.Proxy, req *model.PushRequest,
+	watched *model.WatchedResource,
+) ([]*listener.Listener, []string, bool) {
+	ports, ok := deltaPorts(proxy, req)
+	if !ok {
+		return configgen.BuildListeners(proxy, req.Push), nil, false
+	}
+	builder := NewListenerBuilder(proxy, req.Push)
+	builder.outboundPorts = ports
+	listeners := configgen.buildListeners(builder)
+	built := sets.NewWithLength[string](len(listeners))
+	for _, l := range listeners {
+		built.Insert(l.Name)
+	}
+	// Only the outbound listeners of the affected ports were built, so only those can have been removed.
+	var removed []string
+	for name := range watched.ResourceNames {
+		if port, ok := listenerPort(name); ok && ports.Contains(port) && !built.Contains(name) {
+			removed = append(removed, name)
+		}
+	}
+	slices.Sort(removed)
+	return listeners, removed, true
+}
+
+// BuildDeltaHTTPRoutes returns the watched routes that changed for the updated configs. Routes are never removed
+// here, as they are unsubscribed by the proxy once no listener references them. If the changes cannot be scoped,
+// all watched routes are returned and delta is not used.
+func (configgen *ConfigGeneratorImpl) BuildDeltaHTTPRoutes(proxy *model.Proxy, req *model.PushRequest,
+	watched *model.WatchedResource,
+) ([]*discovery.Resource, []string, model.XdsLogDetails, bool) {
+	ports, ok := deltaPorts(proxy, req)
+	if !ok {
+		routes, logs := configgen.BuildHTTPRoutes(proxy, req, watched.ResourceNames.UnsortedList())
+		return routes, nil, logs, false
+	}
+	routeNames := make([]string, 0)
+	for name := range watched.ResourceNames {
+		if portAffected(name, ports) {
+			routeNames = append(routeNames, name)
+		}
+	}
+	if len(routeNames) == 0 {
+		return nil, nil, model.DefaultXdsLogDetails, true
+	}
+	routes, logs := configgen.BuildHTTPRoutes(proxy, req, routeNames)
+	return routes, nil, logs, true
+}
+
+// deltaPorts returns the ports whose sidecar listeners and routes may be changed by the updated configs, and
+// whether the changes could be scoped to ports at all.
+func deltaPorts(proxy *model.Proxy, req *model.PushRequest) (sets.Set[int], bool) {
+	if req == nil || req.Forced || len(req.ConfigsUpdated) == 0 || !req.Delta.IsEmpty() ||
+		proxy.Type != model.SidecarProxy || proxy.SidecarScope == nil || proxy.PrevSidecarScope == nil {
+		return nil, false
+	}
+	scopes := []*model.SidecarScope{proxy.SidecarScope, proxy.PrevSidecarScope}
+
+	// Hosts whose configuration changed.
+	changed := sets.New[host.Name]()
+	for key := range req.ConfigsUpdated {
+		if !deltaListenerConfigTypes.Contains(key.Kind) {
+			return nil, false
+		}
+		switch key.Kind {
+		case kind.ServiceEntry:
+			changed.Insert(host.Name(key.Name))
+		case kind.DestinationRule:
+			for _, sc := range scopes {
+				if cfg := sc.DestinationRuleByName(key.Name, key.Namespace); cfg != nil {
+					changed.Insert(host.Name(cfg.Spec.(*networking.DestinationRule).Host))
+				}
+			}
+		case kind.VirtualService:
+			found := false
+			for _, sc := range scopes {
+				for _, cfg := range scopeVirtualServices(sc) {
+					if cfg.Name != key.Name || cfg.Namespace != key.Namespace {
+						continue
+					}
+					found = true
+					changed.InsertAll(host.NewNames(cfg.Spec.(*networking.VirtualService).Hosts)...)
+				}
+			}
+			// The virtual service may be a delegate, merged into another one.
+			if !found {
+				return nil, false
+			}
+		}
+	}
+
+	// Routes of a virtual service embed settings of their destinations, so the hosts routing to a changed
+	// host are changed as well.
+	affected := changed.Copy()
+	for _, sc := range scopes {
+		for _, cfg := range scopeVirtualServices(sc) {
+			vs := cfg.Spec.(*networking.VirtualService)
+			if matchesAnyHost(virtualServiceDestinationHosts(vs), changed) {
+				affected.InsertAll(host.NewNames(vs.Hosts)...)
+			}
+		}
+	}
+
+	virtualServiceHosts := sets.New[host.Name]()
+	for _, sc := range scopes {
+		for _, cfg := range scopeVirtualServices(sc) {
+			virtualServiceHosts.InsertAll(host.NewNames(cfg.Spec.(*networking.VirtualService).Hosts)...)
+		}
+	}
+	ports := sets.New[int]()
+	// Sidecar egress listeners with a port hold the affected hosts on that port, rather than on their service ports.
+	for _, sc := range scopes {
+		for _, ilw := range sc.EgressListeners {
+			if ilw.IstioListener == nil || ilw.IstioListener.Port == nil {
+				continue
+			}
+			hosts := slices.Map(ilw.Services(), func(svc *model.Service) host.Name { return svc.Hostname })
+			for _, cfg := range ilw.VirtualServices() {
+				hosts = append(hosts, host.NewNames(cfg.Spec.(*networking.VirtualService).Hosts)...)
+			}
+			if matchesAnyHost(hosts, affected) {
+				ports.Insert(int(ilw.IstioListener.Port.Number))
+			}
+		}
+	}
+	for h := range affected {
+		matched := false
+		for _, sc := range scopes {
+			for _, svc := range sc.ServicesForHostname(h) {
+				matched = true
+				for _, port := range svc.Ports {
+					ports.Insert(port.Port)
+				}
+			}
+		}
+		// Routes of virtual service hosts without a service are not scoped to a port.
+		if !matched && virtualServiceHosts.Contains(h) {
+			return nil, false
+		}
+	}
+	return ports, true
+}
+
+// portAffected returns true if the route may be changed when the listeners and routes of ports change. Names of
+// the form <host>:<port> or <port> are only affected if their port is in ports; any other name is always considered
+// affected.
+func portAffected(name string, ports sets.Set[int]) bool {
+	port, ok := listenerPort(name)
+	return !ok || ports.Contains(port)
+}
+
+// listenerPort returns the port of a listener or route name of the form <address>_<port>, <host>:<port> or <port>.
+func listenerPort(name string) (int, bool) {
+	if i := strings.LastIndexAny(name, "_:"); i >= 0 {
+		name = name[i+1:]
+	}
+	port, err := strconv.Atoi(name)
+	return port, err == nil
+}
+
+func scopeVirtualServices(sc *model.SidecarScope) []*config.Config {
+	var out []*config.Config
+	for _, ilw := range sc.EgressListeners {
+		out = append(out, ilw.VirtualServices()...)
+	}
+	return out
+}
+
+func virtualServiceDestinationHosts(vs *networking.VirtualService) []host.Name {
+	var out []host.Name
+	for _, r := range vs.Http {
+		for _, d := range r.Route {
+			out = append(out, host.Name(d.GetDestination().GetHost()))
+		}
+		if r.Mirror != nil {
+			out = append(out, host.Name(r.Mirror.Host))
+		}
+		for _, m := range r.Mirrors {
+			out = append(out, host.Name(m.GetDestination().GetHost()))
+		}
+	}
+	for _, r := range vs.Tcp {
+		for _, d := range r.Route {
+			out = append(out, host.Name(d.GetDestination().GetHost()))
+		}
+	}
+	for _, r := range vs.Tls {
+		for _, d := range r.Route {
+			out = append(out, host.Name(d.GetDestination().GetHost()))
+		}
+	}
+	return out
+}
+
+func matchesAnyHost(hosts []host.Name, set sets.Set[host.Name]) bool {
+	for _, h := range hosts {
+		for s := range set {
+			if h.Matches(s) || s.Matches(h) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"strings"
+	"testing"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const deltaServiceA = `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: a
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  addresses: [240.0.0.1]
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  endpoints:
+  - address: 1.0.0.1
+---
+`
+
+const deltaServiceB = `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: b
+  namespace: default
+spec:
+  hosts: [b.example.com]
+  addresses: [240.0.0.2]
+  ports:
+  - number: 8080
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  endpoints:
+  - address: 1.0.0.2
+---
+`
+
+const deltaServiceC = `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: c
+  namespace: default
+spec:
+  hosts: [c.example.com]
+  addresses: [240.0.0.3]
+  ports:
+  - number: 9000
+    name: tcp
+    protocol: TCP
+  resolution: STATIC
+  endpoints:
+  - address: 1.0.0.3
+---
+`
+
+const deltaServices = deltaServiceA + deltaServiceB + deltaServiceC
+
+const deltaVirtualService = `
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: a
+  namespace: default
+spec:
+  hosts: [a.example.com]
+  http:
+  - route:
+    - destination:
+        host: b.example.com
+---
+`
+
+const deltaSidecar = `
+apiVersion: networking.istio.io/v1
+kind: Sidecar
+metadata:
+  name: default
+  namespace: default
+spec:
+  egress:
+  - port:
+      number: 9000
+      protocol: HTTP
+      name: http
+    hosts:
+    - "*/b.example.com"
+  - hosts:
+    - "*/*"
+---
+`
+
+// TestDeltaListenersAndRoutes checks that applying the delta listeners and routes generated for a config change
+// to the previous state results in the same state as generating them from scratch, and that only the resources
+// of the impacted ports are sent.
+func TestDeltaListenersAndRoutes(t *testing.T) {
+	cases := []struct {
+		name    string
+		before  string
+		after   string
+		updated sets.Set[model.ConfigKey]
+		// Expected changed listeners and routes, if delta is used.
+		listeners []string
+		routes    []string
+		delta     bool
+	}{
+		{
+			name:      "service port changed",
+			before:    deltaServices,
+			after:     strings.Replace(deltaServices, "number: 8080", "number: 8081", 1),
+			updated:   sets.New(model.ConfigKey{Kind: kind.ServiceEntry, Name: "b.example.com", Namespace: "default"}),
+			listeners: []string{"0.0.0.0_8081", "virtualInbound", "virtualOutbound"},
+			delta:     true,
+		},
+		{
+			name:      "service removed",
+			before:    deltaServices,
+			after:     deltaServiceA + deltaServiceB,
+			updated:   sets.New(model.ConfigKey{Kind: kind.ServiceEntry, Name: "c.example.com", Namespace: "default"}),
+			listeners: []string{"virtualInbound", "virtualOutbound"},
+			delta:     true,
+		},
+		{
+			name:   "destination rule added",
+			before: deltaServices,
+			after: deltaServices + `
+apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: b
+  namespace: default
+spec:
+  host: b.example.com
+  trafficPolicy:
+    loadBalancer:
+      consistentHash:
+        httpHeaderName: x-user
+`,
+			updated:   sets.New(model.ConfigKey{Kind: kind.DestinationRule, Name: "b", Namespace: "default"}),
+			listeners: []string{"0.0.0.0_8080", "virtualInbound", "virtualOutbound"},
+			routes:    []string{"8080"},
+			delta:     true,
+		},
+		{
+			name:      "destination of a virtual service changed",
+			before:    deltaServices + deltaVirtualService,
+			after:     strings.Replace(deltaServices, "number: 8080", "number: 8081", 1) + deltaVirtualService,
+			updated:   sets.New(model.ConfigKey{Kind: kind.ServiceEntry, Name: "b.example.com", Namespace: "default"}),
+			listeners: []string{"0.0.0.0_80", "0.0.0.0_8081", "virtualInbound", "virtualOutbound"},
+			routes:    []string{"80"},
+			delta:     true,
+		},
+		{
+			name:      "virtual service added",
+			before:    deltaServices,
+			after:     deltaServices + deltaVirtualService,
+			updated:   sets.New(model.ConfigKey{Kind: kind.VirtualService, Name: "a", Namespace: "default"}),
+			listeners: []string{"0.0.0.0_80", "virtualInbound", "virtualOutbound"},
+			routes:    []string{"80"},
+			delta:     true,
+		},
+		{
+			name:      "sidecar egress listener with a port",
+			before:    deltaServices + deltaSidecar,
+			after:     strings.Replace(deltaServices, "number: 8080", "number: 9000", 1) + deltaSidecar,
+			updated:   sets.New(model.ConfigKey{Kind: kind.ServiceEntry, Name: "b.example.com", Namespace: "default"}),
+			listeners: []string{"0.0.0.0_9000", "240.0.0.3_9000", "virtualInbound", "virtualOutbound"},
+			delta:     true,
+		},
+		{
+			name:    "unsupported config type",
+			before:  deltaServices,
+			after:   deltaServices,
+			updated: sets.New(model.ConfigKey{Kind: kind.Sidecar, Name: "default", Namespace: "default"}),
+			delta:   false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			before := NewConfigGenTest(t, TestOptions{ConfigString: tt.before})
+			beforeProxy := before.SetupProxy(nil)
+			after := NewConfigGenTest(t, TestOptions{ConfigString: tt.after})
+			afterProxy := after.SetupProxy(nil)
+			afterProxy.PrevSidecarScope = beforeProxy.SidecarScope
+
+			// Listeners
+			current := before.Listeners(beforeProxy)
+			want := after.Listeners(afterProxy)
+			watched := &model.WatchedResource{ResourceNames: sets.New(slices.Map(current, (*listener.Listener).GetName)...)}
+			changed, removed, delta := after.DeltaListeners(afterProxy, tt.updated, watched)
+			assert.Equal(t, delta, tt.delta)
+			if !delta {
+				assert.Equal(t, byName(changed, (*listener.Listener).GetName), byName(want, (*listener.Listener).GetName))
+				return
+			}
+			assert.Equal(t, sets.SortedList(sets.New(slices.Map(changed, (*listener.Listener).GetName)...)), tt.listeners)
+			assert.Equal(t, applyDelta(current, changed, removed, (*listener.Listener).GetName), byName(want, (*listener.Listener).GetName))
+
+			// Routes. Routes added or removed by listener changes are subscribed or unsubscribed by the proxy, so
+			// only the routes referenced before and after the change are compared.
+			routeNames := sets.New(ExtractRoutesFromListeners(current)...).Intersection(sets.New(ExtractRoutesFromListeners(want)...))
+			currentRoutes := buildRoutes(before, beforeProxy, sets.SortedList(routeNames))
+			wantRoutes := buildRoutes(after, afterProxy, sets.SortedList(routeNames))
+			changedRoutes, removedRoutes, delta := after.DeltaRoutes(afterProxy, tt.updated, &model.WatchedResource{ResourceNames: routeNames})
+			assert.Equal(t, delta, true)
+			assert.Equal(t, sets.SortedList(sets.New(slices.Map(changedRoutes, (*route.RouteConfiguration).GetName)...)), tt.routes)
+			assert.Equal(t, applyDelta(currentRoutes, changedRoutes, removedRoutes, (*route.RouteConfiguration).GetName),
+				byName(wantRoutes, (*route.RouteConfiguration).GetName))
+		})
+	}
+}
+
+func buildRoutes(f *ConfigGenTest, p *model.Proxy, names []string) []*route.RouteConfiguration {
+	resources, _ := f.ConfigGen.BuildHTTPRoutes(p, &model.PushRequest{Push: f.PushContext()}, names)
+	out := make([]*route.RouteConfiguration, 0, len(resources))
+	for _, r := range resources {
+		rc := &route.RouteConfiguration{}
+		if err := r.Resource.UnmarshalTo(rc); err != nil {
+			f.t.Fatal(err)
+		}
+		out = append(out, rc)
+	}
+	return out
+}
+
+// applyDelta applies a delta response to the resources previously sent.
+func applyDelta[T any](current, changed []T, removed []string, name func(T) string) map[string]T {
+	out := byName(current, name)
+	for _, r := range removed {
+		delete(out, r)
+	}
+	for _, c := range changed {
+		out[name(c)] = c
+	}
+	return out
+}
+
+func byName[T any](resources []T, name func(T) string) map[string]T {
+	out := make(map[string]T, len(resources))
+	for _, r := range resources {
+		out[name(r)] = r
+	}
+	return out
+}
@@ -332,6 +332,37 @@ func (f *ConfigGenTest) DeltaClusters(
 	return res, removed, delta
 }
 
+func (f *ConfigGenTest) DeltaListeners(
+	p *model.Proxy,
+	configUpdated sets.Set[model.ConfigKey],
+	watched *model.WatchedResource,
+) ([]*listener.Listener, []string, bool) {
+	return f.ConfigGen.BuildDeltaListeners(p,
+		&model.PushRequest{
+			Push: f.PushContext(), ConfigsUpdated: configUpdated,
+		}, watched)
+}
+
+func (f *ConfigGenTest) DeltaRoutes(
+	p *model.Proxy,
+	configUpdated sets.Set[model.ConfigKey],
+	watched *model.WatchedResource,
+) ([]*route.RouteConfiguration, []string, bool) {
+	raw, removed, _, delta := f.ConfigGen.BuildDeltaHTTPRoutes(p,
+		&model.PushRequest{
+			Push: f.PushContext(), ConfigsUpdated: configUpdated,
+		}, watched)
+	res := make([]*route.RouteConfiguration, 0, len(raw))
+	for _, r := range raw {
+		rc := &route.RouteConfiguration{}
+		if err := r.Resource.UnmarshalTo(rc); err != nil {
+			f.t.Fatal(err)
+		}
+		res = append(res, rc)
+	}
+	return res, removed, delta
+}
+
 func (f *ConfigGenTest) RoutesFromListeners(p *model.Proxy, l []*listener.Listener) []*route.RouteConfiguration {
 	resources, _ := f.ConfigGen.BuildHTTPRoutes(p, &model.PushRequest{Push: f.PushContext()}, ExtractRoutesFromListeners(l))
 	out := make([]*route.RouteConfiguration, 0, len(resources))
@@ -115,7 +115,11 @@ func gatewayTransportSocketConnectTimeout() *durationpb.Duration {
 func (configgen *ConfigGeneratorImpl) BuildListeners(node *model.Proxy,
 	push *model.PushContext,
 ) []*listener.Listener {
-	builder := NewListenerBuilder(node, push)
+	return configgen.buildListeners(NewListenerBuilder(node, push))
+}
+
+func (configgen *ConfigGeneratorImpl) buildListeners(builder *ListenerBuilder) []*listener.Listener {
+	node, push := builder.node, builder.push
 	switch node.Type {
 	case model.SidecarProxy:
 		builder = configgen.buildSidecarListeners(builder)
@@ -416,6 +420,9 @@ func (lb *ListenerBuilder) buildSidecarOutboundListeners(node *model.Proxy,
 			// multiple ports, we expect the user to provide a virtualService
 			// that will route to a proper Service.
 
+			if lb.outboundPorts != nil && !lb.outboundPorts.Contains(int(egressListener.IstioListener.Port.Number)) {
+				continue
+			}
 			// Skip ports we cannot bind to
 			wildcard := wildCards[node.GetIPMode()][0]
 			listenPort := &model.Port{
@@ -475,6 +482,9 @@ func (lb *ListenerBuilder) buildSidecarOutboundListeners(node *model.Proxy,
 			for _, service := range services {
 				saddress := service.GetAddressForProxy(node)
 				for _, servicePort := range service.Ports {
+					if lb.outboundPorts != nil && !lb.outboundPorts.Contains(servicePort.Port) {
+						continue
+					}
 					// Skip ports we cannot bind to
 					wildcard := wildCards[node.GetIPMode()][0]
 					if canbind, knownlistener := lb.node.CanBindToPort(bind.bindToPort, node, push, bind.Primary(),
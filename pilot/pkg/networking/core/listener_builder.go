@@ -46,6 +46,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/proto"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/wellknown"
 )
 
@@ -63,6 +64,8 @@ type ListenerBuilder struct {
 	httpProxyListener       *listener.Listener
 	virtualOutboundListener *listener.Listener
 	virtualInboundListener  *listener.Listener
+	// outboundPorts restricts the sidecar outbound listeners built to these ports, if set.
+	outboundPorts sets.Set[int]
 
 	envoyFilterWrapper *model.MergedEnvoyFilterWrapper
 
@@ -59,6 +59,7 @@ import (
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
+	secconfig "istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/proto"
 	"istio.io/istio/pkg/slices"
@@ -1339,11 +1340,21 @@ func buildCommonConnectTLSContext(proxy *model.Proxy, push *model.PushContext) *
 		TlsMaximumProtocolVersion: tls.TlsParameters_TLSv1_3,
 		TlsMinimumProtocolVersion: tls.TlsParameters_TLSv1_3,
 	}
+	// The workload annotations, set on waypoints through the Gateway infrastructure annotations, take precedence.
+	security.ApplyMTLSParameters(ctx, security.MeshMTLSParameters(push.Mesh, proxy.Metadata).Override(proxyMTLSParameters(proxy)))
 	// Compliance for Envoy tunnel TLS contexts.
 	security.EnforceCompliance(ctx)
 	return ctx
 }
 
+// proxyMTLSParameters returns the mTLS parameters set with annotations on the workload of the proxy.
+func proxyMTLSParameters(proxy *model.Proxy) secconfig.MTLSParameters {
+	if proxy.Metadata == nil {
+		return secconfig.MTLSParameters{}
+	}
+	return secconfig.MTLSParametersFromAnnotations(proxy.Metadata.Annotations)
+}
+
 // globalServiceVIPs returns network-filtered and IP-family-filtered VIP addresses
 // from a globally-scoped service.
 func (lb *ListenerBuilder) globalServiceVIPs(svc *model.Service) []string {
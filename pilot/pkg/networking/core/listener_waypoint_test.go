@@ -17,8 +17,11 @@ package core
 import (
 	"testing"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pilot/pkg/model"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/config/security"
+	"istio.io/istio/pkg/test/util/assert"
 )
 
 func TestXFCCIncludeClientIdentityEnabled(t *testing.T) {
@@ -112,3 +115,37 @@ func TestWaypointXFCCClientIdentityFilterConfig(t *testing.T) {
 		t.Fatal("filter missing typed config")
 	}
 }
+
+func TestCommonConnectTLSContextMTLSParameters(t *testing.T) {
+	push := &model.PushContext{Mesh: &meshconfig.MeshConfig{
+		MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519MLKEM768", "X25519"}},
+	}}
+	cases := []struct {
+		name               string
+		annotations        map[string]string
+		expectedCurves     []string
+		expectedAlgorithms []string
+	}{
+		{
+			name:           "mesh curves",
+			expectedCurves: []string{"X25519MLKEM768", "X25519"},
+		},
+		{
+			name: "workload annotations",
+			annotations: map[string]string{
+				security.MTLSECDHCurvesAnnotation:          "X25519MLKEM768",
+				security.MTLSSignatureAlgorithmsAnnotation: "ecdsa_secp256r1_sha256",
+			},
+			expectedCurves:     []string{"X25519MLKEM768"},
+			expectedAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxy := &model.Proxy{Metadata: &model.NodeMetadata{Annotations: tc.annotations}}
+			ctx := buildCommonConnectTLSContext(proxy, push)
+			assert.Equal(t, ctx.TlsParams.EcdhCurves, tc.expectedCurves)
+			assert.Equal(t, ctx.TlsParams.SignatureAlgorithms, tc.expectedAlgorithms)
+		})
+	}
+}
@@ -134,9 +134,9 @@ func (a policyApplier) InboundMTLSSettings(
 		Port: endpointPort,
 		Mode: effectiveMTLSMode,
 		TCP: authn_utils.BuildInboundTLS(effectiveMTLSMode, node, networking.ListenerProtocolTCP,
-			trustDomainAliases, minTLSVersion, mc),
+			trustDomainAliases, minTLSVersion, mc, a.consolidatedPeerPolicy.MTLSParameters),
 		HTTP: authn_utils.BuildInboundTLS(effectiveMTLSMode, node, networking.ListenerProtocolHTTP,
-			trustDomainAliases, minTLSVersion, mc),
+			trustDomainAliases, minTLSVersion, mc, a.consolidatedPeerPolicy.MTLSParameters),
 	}
 }
 
@@ -380,6 +380,9 @@ type MergedPeerAuthentication struct {
 	Mode model.MutualTLSMode
 	// PerPort is the per-port policy
 	PerPort map[uint32]model.MutualTLSMode
+	// MTLSParameters are the ECDH curves and signature algorithms set with annotations, each from the most narrow
+	// scope setting it.
+	MTLSParameters security.MTLSParameters
 }
 
 // ComposePeerAuthentication returns the effective PeerAuthentication given the list of applicable
@@ -445,6 +448,12 @@ func ComposePeerAuthentication(rootNamespace string, configs []*config.Config) M
 		workloadPolicy = workloadCfg.Spec.(*v1beta1.PeerAuthentication)
 	}
 
+	for _, cfg := range []*config.Config{meshCfg, namespaceCfg, workloadCfg} {
+		if cfg != nil {
+			outputPolicy.MTLSParameters = outputPolicy.MTLSParameters.Override(security.MTLSParametersFromAnnotations(cfg.Annotations))
+		}
+	}
+
 	if workloadPolicy != nil && !isMtlsModeUnset(workloadPolicy.Mtls) {
 		// If workload policy is defined, update parent policy to workload policy.
 		outputPolicy.Mode = model.ConvertToMutualTLSMode(workloadPolicy.Mtls.Mode)
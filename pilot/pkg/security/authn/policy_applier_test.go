@@ -38,6 +38,7 @@ import (
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/jwt"
 	protovalue "istio.io/istio/pkg/proto"
 	istiotest "istio.io/istio/pkg/test"
@@ -2103,6 +2104,45 @@ func TestComposePeerAuthentication(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "mtls parameters",
+			configs: []*config.Config{
+				{
+					Meta: config.Meta{
+						Name:      "default",
+						Namespace: "root-namespace",
+						Annotations: map[string]string{
+							security.MTLSECDHCurvesAnnotation:          "X25519MLKEM768,X25519",
+							security.MTLSSignatureAlgorithmsAnnotation: "ecdsa_secp256r1_sha256",
+						},
+					},
+					Spec: &v1beta1.PeerAuthentication{},
+				},
+				{
+					Meta: config.Meta{
+						Name:      "foo",
+						Namespace: "my-ns",
+						Annotations: map[string]string{
+							security.MTLSECDHCurvesAnnotation: "X25519MLKEM768",
+						},
+					},
+					Spec: &v1beta1.PeerAuthentication{
+						Selector: &type_beta.WorkloadSelector{
+							MatchLabels: map[string]string{
+								"app": "foo",
+							},
+						},
+					},
+				},
+			},
+			want: MergedPeerAuthentication{
+				Mode: model.MTLSPermissive,
+				MTLSParameters: security.MTLSParameters{
+					ECDHCurves:          []string{"X25519MLKEM768"},
+					SignatureAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
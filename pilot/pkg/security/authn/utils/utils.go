@@ -23,6 +23,7 @@ import (
 	"istio.io/istio/pilot/pkg/networking"
 	"istio.io/istio/pilot/pkg/networking/util"
 	authn_model "istio.io/istio/pilot/pkg/security/model"
+	"istio.io/istio/pkg/config/security"
 	protovalue "istio.io/istio/pkg/proto"
 )
 
@@ -36,10 +37,11 @@ var SupportedCiphers = []string{
 	"AES128-GCM-SHA256",
 }
 
-// BuildInboundTLS returns the TLS context corresponding to the mTLS mode.
+// BuildInboundTLS returns the TLS context corresponding to the mTLS mode, with the mTLS parameters set with
+// PeerAuthentication annotations.
 func BuildInboundTLS(mTLSMode model.MutualTLSMode, node *model.Proxy,
 	protocol networking.ListenerProtocol, trustDomainAliases []string, minTLSVersion tls.TlsParameters_TlsProtocol,
-	mc *meshconfig.MeshConfig,
+	mc *meshconfig.MeshConfig, mtlsParameters security.MTLSParameters,
 ) *tls.DownstreamTlsContext {
 	if mTLSMode == model.MTLSDisable || mTLSMode == model.MTLSUnknown {
 		return nil
@@ -79,6 +81,8 @@ func BuildInboundTLS(mTLSMode model.MutualTLSMode, node *model.Proxy,
 		TlsMinimumProtocolVersion: minTLSVersion,
 		TlsMaximumProtocolVersion: tls.TlsParameters_TLSv1_3,
 	}
+	// The PeerAuthentication annotations take precedence over the mesh-wide mTLS parameters.
+	authn_model.ApplyMTLSParameters(ctx.CommonTlsContext, authn_model.MeshMTLSParameters(mc, node.Metadata).Override(mtlsParameters))
 	authn_model.ApplyToCommonTLSContext(ctx.CommonTlsContext, node, []string{}, /*subjectAltNames*/
 		"", /*crl*/
 		trustDomainAliases, ctx.RequireClientCertificate.Value, nil)
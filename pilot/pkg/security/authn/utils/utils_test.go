@@ -24,6 +24,8 @@ import (
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	model "istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pkg/config/security"
+	"istio.io/istio/pkg/test/util/assert"
 )
 
 func TestGetMinTLSVersion(t *testing.T) {
@@ -93,10 +95,65 @@ func TestGetMTLSCipherSuites(t *testing.T) {
 				Metadata: &model.NodeMetadata{},
 			}
 
-			got := BuildInboundTLS(model.MTLSStrict, testNode, networking.ListenerProtocolTCP, []string{}, tls.TlsParameters_TLSv1_2, &tt.mesh)
+			got := BuildInboundTLS(model.MTLSStrict, testNode, networking.ListenerProtocolTCP, []string{}, tls.TlsParameters_TLSv1_2, &tt.mesh,
+				security.MTLSParameters{})
 			if diff := cmp.Diff(tt.expectedMTLSCipherSuites, got.CommonTlsContext.TlsParams.CipherSuites, protocmp.Transform()); diff != "" {
 				t.Errorf("unexpected cipher suites: %v", diff)
 			}
 		})
 	}
 }
+
+func TestBuildInboundTLSMTLSParameters(t *testing.T) {
+	tests := []struct {
+		name               string
+		mesh               *meshconfig.MeshConfig
+		metadata           *model.NodeMetadata
+		mtlsParameters     security.MTLSParameters
+		expectedCurves     []string
+		expectedAlgorithms []string
+	}{
+		{
+			name: "Envoy defaults",
+			mesh: &meshconfig.MeshConfig{},
+		},
+		{
+			name: "mesh curves",
+			mesh: &meshconfig.MeshConfig{
+				MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519MLKEM768", "X25519"}},
+			},
+			expectedCurves: []string{"X25519MLKEM768", "X25519"},
+		},
+		{
+			name:               "proxy metadata algorithms",
+			mesh:               &meshconfig.MeshConfig{},
+			metadata:           &model.NodeMetadata{MTLSSignatureAlgorithms: []string{"ecdsa_secp256r1_sha256", "rsa_pss_rsae_sha256"}},
+			expectedAlgorithms: []string{"ecdsa_secp256r1_sha256", "rsa_pss_rsae_sha256"},
+		},
+		{
+			name: "peer authentication overrides mesh",
+			mesh: &meshconfig.MeshConfig{
+				MeshMTLS: &meshconfig.MeshConfig_TLSConfig{EcdhCurves: []string{"X25519"}},
+			},
+			metadata: &model.NodeMetadata{MTLSSignatureAlgorithms: []string{"rsa_pss_rsae_sha256"}},
+			mtlsParameters: security.MTLSParameters{
+				ECDHCurves:          []string{"X25519MLKEM768"},
+				SignatureAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+			},
+			expectedCurves:     []string{"X25519MLKEM768"},
+			expectedAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testNode := &model.Proxy{Metadata: &model.NodeMetadata{}}
+			if tt.metadata != nil {
+				testNode.Metadata = tt.metadata
+			}
+			got := BuildInboundTLS(model.MTLSStrict, testNode, networking.ListenerProtocolTCP, []string{}, tls.TlsParameters_TLSv1_2, tt.mesh,
+				tt.mtlsParameters)
+			assert.Equal(t, got.CommonTlsContext.TlsParams.EcdhCurves, tt.expectedCurves)
+			assert.Equal(t, got.CommonTlsContext.TlsParams.SignatureAlgorithms, tt.expectedAlgorithms)
+		})
+	}
+}
@@ -21,10 +21,12 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/model/credentials"
 	"istio.io/istio/pilot/pkg/networking/util"
+	sec "istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/log"
 	pm "istio.io/istio/pkg/model"
 	"istio.io/istio/pkg/security"
@@ -305,6 +307,33 @@ func ApplyCredentialSDSToServerCommonTLSContext(tlsContext *tls.CommonTlsContext
 	}
 }
 
+// MeshMTLSParameters returns the ECDH curves and signature algorithms of mesh mTLS set mesh-wide, and in the proxy
+// metadata.
+func MeshMTLSParameters(mesh *meshconfig.MeshConfig, metadata *model.NodeMetadata) sec.MTLSParameters {
+	var algorithms []string
+	if metadata != nil {
+		algorithms = metadata.MTLSSignatureAlgorithms
+	}
+	return sec.NewMTLSParameters(mesh.GetMeshMTLS().GetEcdhCurves(), algorithms)
+}
+
+// ApplyMTLSParameters sets the ECDH curves and signature algorithms of a mesh mTLS context. It must be called before
+// EnforceCompliance, so that the compliance policy takes precedence.
+func ApplyMTLSParameters(ctx *tls.CommonTlsContext, p sec.MTLSParameters) {
+	if p.IsEmpty() {
+		return
+	}
+	if ctx.TlsParams == nil {
+		ctx.TlsParams = &tls.TlsParameters{}
+	}
+	if len(p.ECDHCurves) > 0 {
+		ctx.TlsParams.EcdhCurves = p.ECDHCurves
+	}
+	if len(p.SignatureAlgorithms) > 0 {
+		ctx.TlsParams.SignatureAlgorithms = p.SignatureAlgorithms
+	}
+}
+
 func EnforceGoCompliance(ctx *gotls.Config) {
 	pm.EnforceGoCompliance(ctx)
 }
@@ -506,7 +506,7 @@ func getConditions[T controllers.ComparableObject](name types.NamespacedName, i
 	}
 	switch t := any(o).(type) {
 	case *corev1.Service:
-		return translateKubernetesCondition(t.Status.Conditions)
+		return TranslateKubernetesConditions(t.Status.Conditions)
 	case *networkingclient.ServiceEntry:
 		return translateIstioCondition(t.Status.Conditions)
 	case *securityclient.AuthorizationPolicy:
@@ -533,7 +533,8 @@ func translateIstioCondition(conds []*v1alpha1.IstioCondition) map[string]model.
 	return res
 }
 
-func translateKubernetesCondition(conds []metav1.Condition) map[string]model.Condition {
+// TranslateKubernetesConditions converts the conditions of a Kubernetes object for use with the status queue.
+func TranslateKubernetesConditions(conds []metav1.Condition) map[string]model.Condition {
 	res := make(map[string]model.Condition, len(conds))
 	for _, cond := range conds {
 		c := model.Condition{
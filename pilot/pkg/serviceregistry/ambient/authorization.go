@@ -488,6 +488,30 @@ const (
 		"This will be more restrictive than requested."
 )
 
+// HTTPAttributes returns the HTTP attributes an AuthorizationPolicy matches on, sorted.
+// ztunnel cannot enforce these; a policy using any of them needs a waypoint.
+func HTTPAttributes(pol *v1beta1.AuthorizationPolicy) []string {
+	found := sets.New[string]()
+	for _, rule := range pol.GetRules() {
+		for _, to := range rule.GetTo() {
+			if op := to.GetOperation(); op != nil {
+				found.InsertAll(httpOperations(op)...)
+			}
+		}
+		for _, from := range rule.GetFrom() {
+			if src := from.GetSource(); src != nil {
+				found.InsertAll(httpSources(src)...)
+			}
+		}
+		for _, when := range rule.GetWhen() {
+			if !l4WhenAttributes.Contains(when.GetKey()) {
+				found.Insert(when.GetKey())
+			}
+		}
+	}
+	return sets.SortedList(found)
+}
+
 func httpOperations(op *v1beta1.Operation) []string {
 	foundUnsupportedOperations := []string{}
 	if len(op.Hosts) > 0 {
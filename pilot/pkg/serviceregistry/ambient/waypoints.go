@@ -193,6 +193,20 @@ func fetchWaypointForWorkload(ctx krt.HandlerContext, Waypoints krt.Collection[W
 	return nil, ReportWaypointUnsupportedTrafficType(w.ResourceName(), constants.WorkloadTraffic)
 }
 
+// UseWaypointForService returns the waypoint a Service is bound to by the istio.io/use-waypoint label, on the
+// Service or else on its namespace. Unlike fetchWaypointForService, the waypoint is not required to exist or be ready.
+// isNone is set if the Service opts out of using a waypoint.
+func UseWaypointForService(svc metav1.ObjectMeta, namespace *v1.Namespace) (named *krt.Named, isNone bool) {
+	if wp, isNone := getUseWaypoint(svc, svc.Namespace); wp != nil || isNone {
+		return wp, isNone
+	}
+	if namespace == nil {
+		return nil, false
+	}
+	wp, _ := getUseWaypoint(namespace.ObjectMeta, svc.Namespace)
+	return wp, false
+}
+
 // getUseWaypoint takes objectMeta and a defaultNamespace
 // it looks for the istio.io/use-waypoint label and parses it
 // if there is no namespace provided in the label the default namespace will be used
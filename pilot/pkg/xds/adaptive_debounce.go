@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+	"time"
+)
+
+// adaptiveDebounceWeight is the weight of a new sample in the moving averages of the observed event interval and
+// push context init time.
+const adaptiveDebounceWeight = 0.2
+
+// Reasons for a quiet period chosen by adaptive debouncing.
+const (
+	// debounceReasonInitial is used until both an event interval and a push context init time have been observed.
+	debounceReasonInitial = "initial"
+	// debounceReasonEventRate is used when waiting for the next event of a burst.
+	debounceReasonEventRate = "event_rate"
+	// debounceReasonPushCost is used when waiting as long as it takes to build a push context.
+	debounceReasonPushCost = "push_cost"
+	// debounceReasonMin and debounceReasonMax are used when the quiet period is clamped to its bounds.
+	debounceReasonMin = "min"
+	debounceReasonMax = "max"
+)
+
+// AdaptiveDebounceStatus is the most recent quiet period chosen by adaptive debouncing, and the inputs it was chosen from.
+type AdaptiveDebounceStatus struct {
+	QuietPeriod float64 `json:"quietPeriodSeconds"`
+	// Reason is the input that determined the quiet period.
+	Reason string `json:"reason"`
+	// EventInterval is the moving average of the time between config events.
+	EventInterval float64 `json:"eventIntervalSeconds"`
+	// InitContext is the moving average of the time taken to init a push context.
+	InitContext float64 `json:"initContextSeconds"`
+	// QueueDepth is the number of proxies that were waiting in the push queue.
+	QueueDepth int `json:"queueDepth"`
+	// Connections is the number of connected proxies.
+	Connections int       `json:"connections"`
+	Min         float64   `json:"minSeconds"`
+	Max         float64   `json:"maxSeconds"`
+	Time        time.Time `json:"time"`
+}
+
+// adaptiveDebounce tunes the debounce quiet period from the observed cost of pushes and rate of config events.
+//
+// When events arrive in bursts, it waits for about two event intervals, so that the rest of the burst is merged into a
+// single push. When events are isolated, waiting would not merge anything, so it pushes after the minimum quiet period.
+// It never waits less than the time it takes to init a push context, as pushing more often than that only queues up
+// work. Finally, the quiet period is stretched by the fraction of proxies still waiting on the previous push.
+// All methods are thread safe.
+type adaptiveDebounce struct {
+	initial time.Duration
+	min     time.Duration
+	max     time.Duration
+	// queueDepth returns the number of proxies waiting in the push queue.
+	queueDepth func() int
+	// connections returns the number of connected proxies.
+	connections func() int
+
+	mu            sync.Mutex
+	lastEvent     time.Time
+	eventInterval time.Duration
+	initContext   time.Duration
+	status        AdaptiveDebounceStatus
+}
+
+func newAdaptiveDebounce(initial, minQuiet, maxQuiet time.Duration, queueDepth, connections func() int) *adaptiveDebounce {
+	maxQuiet = max(maxQuiet, minQuiet)
+	return &adaptiveDebounce{
+		initial:     min(max(initial, minQuiet), maxQuiet),
+		min:         minQuiet,
+		max:         maxQuiet,
+		queueDepth:  queueDepth,
+		connections: connections,
+	}
+}
+
+func movingAverage(avg, sample time.Duration) time.Duration {
+	if avg == 0 {
+		return sample
+	}
+	return time.Duration(float64(avg)*(1-adaptiveDebounceWeight) + float64(sample)*adaptiveDebounceWeight)
+}
+
+// observeEvent records the arrival of a config event.
+func (a *adaptiveDebounce) observeEvent(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.lastEvent.IsZero() {
+		// Intervals longer than the maximum quiet period are all equally isolated, cap them so that the average
+		// recovers quickly once events arrive in bursts again.
+		a.eventInterval = movingAverage(a.eventInterval, min(now.Sub(a.lastEvent), a.max))
+	}
+	a.lastEvent = now
+}
+
+// observeInitContext records the time taken to init a push context.
+func (a *adaptiveDebounce) observeInitContext(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.initContext = movingAverage(a.initContext, d)
+}
+
+// quietPeriod returns the quiet period to wait for after the last event before pushing. It is called once per
+// debounce window, and records the decision.
+func (a *adaptiveDebounce) quietPeriod() time.Duration {
+	depth, conns := a.queueDepth(), a.connections()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	quiet, reason := a.initial, debounceReasonInitial
+	if a.eventInterval > 0 && a.initContext > 0 {
+		burst := a.min
+		if 2*a.eventInterval <= a.max {
+			burst = 2 * a.eventInterval
+		}
+		quiet, reason = burst, debounceReasonEventRate
+		if a.initContext > burst {
+			quiet, reason = a.initContext, debounceReasonPushCost
+		}
+		if conns > 0 && depth > 0 {
+			quiet += time.Duration(float64(quiet) * float64(min(depth, conns)) / float64(conns))
+		}
+		if quiet <= a.min {
+			quiet, reason = a.min, debounceReasonMin
+		} else if quiet >= a.max {
+			quiet, reason = a.max, debounceReasonMax
+		}
+	}
+	a.status = AdaptiveDebounceStatus{
+		QuietPeriod:   quiet.Seconds(),
+		Reason:        reason,
+		EventInterval: a.eventInterval.Seconds(),
+		InitContext:   a.initContext.Seconds(),
+		QueueDepth:    depth,
+		Connections:   conns,
+		Min:           a.min.Seconds(),
+		Max:           a.max.Seconds(),
+		Time:          time.Now(),
+	}
+	recordAdaptiveDebounce(quiet, reason)
+	return quiet
+}
+
+// lastStatus returns the most recent decision.
+func (a *adaptiveDebounce) lastStatus() AdaptiveDebounceStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.status
+}
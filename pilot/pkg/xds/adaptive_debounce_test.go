@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	uatomic "go.uber.org/atomic"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestAdaptiveDebounce(t *testing.T) {
+	const (
+		minQuiet = 10 * time.Millisecond
+		maxQuiet = time.Second
+		initial  = 100 * time.Millisecond
+	)
+	depth, conns := 0, 100
+	newDebounce := func() *adaptiveDebounce {
+		return newAdaptiveDebounce(initial, minQuiet, maxQuiet, func() int { return depth }, func() int { return conns })
+	}
+	// events sends n events, interval apart, starting at start. It returns the time of the last event.
+	events := func(a *adaptiveDebounce, start time.Time, n int, interval time.Duration) time.Time {
+		for i := 0; i < n; i++ {
+			a.observeEvent(start.Add(time.Duration(i) * interval))
+		}
+		return start.Add(time.Duration(n-1) * interval)
+	}
+	assertQuiet := func(t *testing.T, a *adaptiveDebounce, want time.Duration, reason string) {
+		t.Helper()
+		assert.Equal(t, a.quietPeriod(), want)
+		assert.Equal(t, a.lastStatus().Reason, reason)
+	}
+
+	t.Run("initial", func(t *testing.T) {
+		a := newDebounce()
+		assertQuiet(t, a, initial, debounceReasonInitial)
+		a.observeEvent(time.Now())
+		a.observeEvent(time.Now())
+		// No push context init time observed yet
+		assertQuiet(t, a, initial, debounceReasonInitial)
+	})
+	t.Run("bursts wait for the next event", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(5 * time.Millisecond)
+		events(a, time.Now(), 10, 40*time.Millisecond)
+		assertQuiet(t, a, 80*time.Millisecond, debounceReasonEventRate)
+	})
+	t.Run("isolated events push quickly", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(5 * time.Millisecond)
+		events(a, time.Now(), 10, time.Minute)
+		assertQuiet(t, a, minQuiet, debounceReasonMin)
+	})
+	t.Run("expensive pushes wait longer", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(300 * time.Millisecond)
+		events(a, time.Now(), 10, 40*time.Millisecond)
+		assertQuiet(t, a, 300*time.Millisecond, debounceReasonPushCost)
+	})
+	t.Run("queue depth stretches the quiet period", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(5 * time.Millisecond)
+		events(a, time.Now(), 10, 40*time.Millisecond)
+		depth = 50
+		defer func() { depth = 0 }()
+		assertQuiet(t, a, 120*time.Millisecond, debounceReasonEventRate)
+		assert.Equal(t, a.lastStatus().QueueDepth, 50)
+	})
+	t.Run("bounded by max", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(5 * time.Second)
+		events(a, time.Now(), 10, 40*time.Millisecond)
+		assertQuiet(t, a, maxQuiet, debounceReasonMax)
+	})
+	t.Run("adapts to changing event rate", func(t *testing.T) {
+		a := newDebounce()
+		a.observeInitContext(5 * time.Millisecond)
+		last := events(a, time.Now(), 10, time.Minute)
+		assertQuiet(t, a, minQuiet, debounceReasonMin)
+		events(a, last.Add(20*time.Millisecond), 30, 20*time.Millisecond)
+		q := a.quietPeriod()
+		assert.Equal(t, a.lastStatus().Reason, debounceReasonEventRate)
+		assert.Equal(t, q < 100*time.Millisecond, true)
+	})
+}
+
+func TestDebounceAdaptiveOncePerWindow(t *testing.T) {
+	// connections is called once per quiet period decision.
+	decisions := uatomic.NewInt32(0)
+	opts := DebounceOptions{
+		debounceMax: time.Second,
+		adaptive: newAdaptiveDebounce(50*time.Millisecond, 10*time.Millisecond, time.Second,
+			func() int { return 0 }, func() int { decisions.Inc(); return 1 }),
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	updateCh := make(chan *model.PushRequest)
+	pushes := uatomic.NewInt32(0)
+	go debounce(updateCh, stopCh, opts, func(*model.PushRequest) { pushes.Inc() }, uatomic.NewInt64(0))
+
+	for i := 0; i < 5; i++ {
+		updateCh <- &model.PushRequest{Forced: true}
+		time.Sleep(5 * time.Millisecond)
+	}
+	retry.UntilOrFail(t, func() bool { return pushes.Load() == 1 }, retry.Timeout(time.Second))
+	// The timer fires for every event of the window, but the quiet period is only chosen once.
+	assert.Equal(t, decisions.Load(), int32(1))
+}
@@ -85,6 +85,19 @@ type Connection struct {
 
 	s   *DiscoveryServer
 	ids []string
+
+	// pushPriority is the PushPriority of the connection in the push queue. It is recomputed on proxy updates, while
+	// the push queue reads it, so it is accessed atomically.
+	pushPriority int32
+
+	// flow tracks the responses the proxy has not ACKed yet, and holds back pushes while it is lagging.
+	flow *flowControl
+
+	// history holds the recently pushed versions of the proxy configuration, if enabled.
+	history *configHistory
+
+	// redirectMode is the value of the xds.RedirectHeader the client set, if any.
+	redirectMode string
 }
 
 func (conn *Connection) XdsConnection() *xds.Connection {
@@ -105,9 +118,13 @@ type Event struct {
 }
 
 func newConnection(peerAddr string, stream DiscoveryStream) *Connection {
-	return &Connection{
+	con := &Connection{
 		Connection: xds.NewConnection(peerAddr, stream),
+		flow:       newFlowControl(),
+		history:    newConnectionConfigHistory(),
 	}
+	con.setPushPriority(PushPriorityNormal)
+	return con
 }
 
 func (conn *Connection) Initialize(node *core.Node) error {
@@ -152,6 +169,12 @@ func (s *DiscoveryServer) processRequest(req *discovery.DiscoveryRequest, con *C
 			&model.PushRequest{Push: con.proxy.LastPushContext, Forced: true})
 	}
 
+	if pending := con.flow.received(req.TypeUrl, req.ResponseNonce, time.Now()); pending != nil {
+		// The proxy caught up, queue the pushes that were held back while it was lagging. Like any other push,
+		// they are throttled by the push queue.
+		s.pushQueue.Enqueue(con, pending)
+	}
+
 	shouldRespond, delta := xds.ShouldRespond(con.proxy, con.ID(), req)
 	if !shouldRespond {
 		return nil
@@ -231,6 +254,7 @@ func (s *DiscoveryServer) Stream(stream DiscoveryStream) error {
 	con := newConnection(peerAddr, stream)
 	con.ids = ids
 	con.s = s
+	con.redirectMode = redirectMode(ctx)
 	return xds.Stream(con)
 }
 
@@ -261,6 +285,11 @@ func (s *DiscoveryServer) initConnection(node *core.Node, con *Connection, ident
 	if err := s.authorize(con, identities); err != nil {
 		return err
 	}
+	// If connections are sharded, redirect connections owned by another replica before they are registered.
+	if err := s.shardRedirect(con); err != nil {
+		return err
+	}
+	con.setPushPriority(s.pushPriority(proxy))
 
 	// Register the connection. this allows pushes to be triggered for the proxy. Note: the timing of
 	// this and initializeProxy important. While registering for pushes *after* initialization is complete seems like
@@ -475,19 +504,32 @@ func (s *DiscoveryServer) DeltaAggregatedResources(stream discovery.AggregatedDi
 }
 
 // Compute and send the new configuration for a connection.
-func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
+func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) (err error) {
 	pushRequest := pushEv.pushRequest
 
 	if !model.OnlyHasConfigsOfKind(pushRequest.ConfigsUpdated, kind.Endpoints) {
 		// Update Proxy with current information.
 		s.computeProxyState(con.proxy, pushRequest)
 	}
+	if pushRequest.IsProxyUpdate() {
+		s.updatePushPriority(con)
+	}
 
 	pushRequest, needsPush := s.ProxyNeedsPush(con.proxy, pushRequest)
 	if !needsPush {
 		log.Debugf("Skipping push to %v, no updates required", con.ID())
 		return nil
 	}
+	if con.flow.coalesce(pushRequest) {
+		log.Debugf("Coalescing push to %v, waiting for the proxy to catch up", con.ID())
+		return nil
+	}
+	if features.EnablePushTracing {
+		finish := s.pushTracer.startProxyPush(con, pushRequest)
+		defer func() {
+			finish(err)
+		}()
+	}
 
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
@@ -0,0 +1,297 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
+)
+
+const (
+	// CertificateSourceCA is the source of the workload certificates issued by the Istio CA to a proxy.
+	CertificateSourceCA = "CA"
+	// CertificateSourceSDS is the source of the credentials served by istiod with SDS.
+	CertificateSourceSDS = "SDS"
+
+	CertificateIssueExpired       = "Expired"
+	CertificateIssueNearExpiry    = "NearExpiry"
+	CertificateIssueRootMismatch  = "RootMismatch"
+	CertificateIssueStuckRotation = "StuckRotation"
+
+	// DefaultCertificateExpiryWindow is the default window of certificates reported as near expiry. Workload
+	// certificates are renewed well before, with the default TTL of 24h.
+	DefaultCertificateExpiryWindow = 6 * time.Hour
+
+	// stuckRotationRatio is the fraction of its lifetime after which a workload certificate should have been renewed.
+	// Agents renew certificates after half of their lifetime by default, with some jitter.
+	stuckRotationRatio = 0.75
+)
+
+// CertificateInventory is the certificates of the proxies connected to an istiod instance.
+type CertificateInventory struct {
+	// MeshRoots are the fingerprints of the roots in the mesh trust bundle.
+	MeshRoots []string            `json:"meshRoots"`
+	Proxies   []ProxyCertificates `json:"proxies"`
+}
+
+// ProxyCertificates is the certificates served to a proxy, and the issues found with them.
+type ProxyCertificates struct {
+	Proxy string `json:"proxy"`
+	// Pod is the namespace/name of the pod of the proxy, matching the caller pod of the CA issuances.
+	Pod         string `json:"pod,omitempty"`
+	Type        string `json:"type"`
+	Identity    string `json:"identity,omitempty"`
+	TrustDomain string `json:"trustDomain,omitempty"`
+	// Roots are the fingerprints of the trust bundle acknowledged by the proxy, if it is served with PCDS.
+	Roots []string `json:"roots,omitempty"`
+	// TrustBundlePending is set if the proxy did not acknowledge the trust bundle served with PCDS.
+	TrustBundlePending bool                `json:"trustBundlePending,omitempty"`
+	Certificates       []ServedCertificate `json:"certificates"`
+	Issues             []CertificateIssue  `json:"issues,omitempty"`
+}
+
+// ServedCertificate is a certificate served to a proxy.
+type ServedCertificate struct {
+	Source string `json:"source"`
+	// Name is the SDS resource name of the certificate.
+	Name         string    `json:"name,omitempty"`
+	Serial       string    `json:"serial"`
+	Identities   []string  `json:"identities,omitempty"`
+	TrustDomains []string  `json:"trustDomains,omitempty"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	// Root is the fingerprint of the root certificate of the Istio CA the certificate chains to, if known.
+	Root string `json:"root,omitempty"`
+}
+
+type CertificateIssue struct {
+	Kind   string `json:"kind"`
+	Serial string `json:"serial,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// CertificateInventory returns the certificates of the proxies connected to this istiod. The SDS credentials and trust
+// bundle are generated as they are served to each proxy, and the workload certificates are looked up in the recent
+// issuances of the Istio CA. Certificates expiring within the window are reported as near expiry.
+func (s *DiscoveryServer) CertificateInventory(now time.Time, window time.Duration) CertificateInventory {
+	inv := CertificateInventory{MeshRoots: []string{}, Proxies: []ProxyCertificates{}}
+	if s.Env != nil && s.Env.TrustBundle != nil {
+		inv.MeshRoots = pemFingerprints(strings.Join(s.Env.TrustBundle.GetTrustBundle(), "\n"))
+	}
+	var issued map[string][]ServedCertificate
+	if s.ListIssuedCertificates != nil {
+		issued = IssuedCertificatesByPod(s.ListIssuedCertificates(audit.Filter{}))
+	}
+	for _, con := range s.SortedClients() {
+		proxy := con.proxy
+		pc := ProxyCertificates{
+			Proxy:        con.ID(),
+			Type:         string(proxy.Type),
+			Certificates: []ServedCertificate{},
+		}
+		pc.Pod = proxyPod(proxy)
+		if proxy.VerifiedIdentity != nil {
+			pc.Identity = proxy.VerifiedIdentity.String()
+			pc.TrustDomain = proxy.VerifiedIdentity.TrustDomain
+		}
+		dump := s.getConfigDumpByResourceType(con, nil, []string{v3.SecretType, v3.ProxyConfigType})
+		for _, r := range dump[v3.SecretType] {
+			secret := &tls.Secret{}
+			if err := r.Resource.UnmarshalTo(secret); err != nil {
+				continue
+			}
+			pc.Certificates = append(pc.Certificates, servedSecretCertificates(secret)...)
+		}
+		if w, f := proxy.DeepCloneWatchedResources()[v3.ProxyConfigType]; f {
+			pc.TrustBundlePending = w.NonceSent != "" && w.NonceAcked != w.NonceSent
+			for _, r := range dump[v3.ProxyConfigType] {
+				pcfg := &meshconfig.ProxyConfig{}
+				if err := r.Resource.UnmarshalTo(pcfg); err == nil && !pc.TrustBundlePending {
+					pc.Roots = pemFingerprints(pcfg.GetCaCertificatesPem()...)
+				}
+			}
+		}
+		pc.Certificates = append(pc.Certificates, issued[pc.Pod]...)
+		EvaluateCertificates(&pc, inv.MeshRoots, now, window)
+		inv.Proxies = append(inv.Proxies, pc)
+	}
+	return inv
+}
+
+// proxyPod returns the namespace/name of the pod of the proxy, matching the caller pod of the CA issuances, if known.
+func proxyPod(proxy *model.Proxy) string {
+	ns := proxy.ConfigNamespace
+	if ns == "" {
+		return ""
+	}
+	return ns + "/" + strings.TrimSuffix(proxy.ID, "."+ns)
+}
+
+// IssuedCertificatesByPod returns the latest certificate issued to each identity of each caller pod, from the CA
+// issuances sorted from the most recent.
+func IssuedCertificatesByPod(records []audit.Record) map[string][]ServedCertificate {
+	res := map[string][]ServedCertificate{}
+	seen := sets.New[string]()
+	for _, r := range records {
+		if r.CallerPod == "" || len(r.SANs) == 0 {
+			continue
+		}
+		key := r.CallerPod + " " + strings.Join(r.SANs, ",")
+		if seen.InsertContains(key) {
+			continue
+		}
+		res[r.CallerPod] = append(res[r.CallerPod], ServedCertificate{
+			Source:       CertificateSourceCA,
+			Serial:       r.Serial,
+			Identities:   r.SANs,
+			TrustDomains: trustDomains(r.SANs),
+			NotBefore:    r.NotBefore,
+			NotAfter:     r.NotAfter,
+			Root:         r.Root,
+		})
+	}
+	return res
+}
+
+// EvaluateCertificates sets the issues of the certificates of the proxy: certificates that expired or expire within the
+// window, workload certificates that should have been renewed, and workload certificates or trust bundles that do not
+// match the mesh trust bundle.
+func EvaluateCertificates(pc *ProxyCertificates, meshRoots []string, now time.Time, window time.Duration) {
+	pc.Issues = nil
+	if pc.TrustBundlePending {
+		pc.Issues = append(pc.Issues, CertificateIssue{
+			Kind:   CertificateIssueRootMismatch,
+			Detail: "the proxy has not acknowledged the current trust bundle",
+		})
+	}
+	for _, c := range pc.Certificates {
+		switch {
+		case !now.Before(c.NotAfter):
+			pc.Issues = append(pc.Issues, CertificateIssue{
+				Kind: CertificateIssueExpired, Serial: c.Serial, Detail: fmt.Sprintf("expired at %s", c.NotAfter.Format(time.RFC3339)),
+			})
+		case c.NotAfter.Sub(now) < window:
+			pc.Issues = append(pc.Issues, CertificateIssue{
+				Kind: CertificateIssueNearExpiry, Serial: c.Serial, Detail: fmt.Sprintf("expires at %s", c.NotAfter.Format(time.RFC3339)),
+			})
+		}
+		if c.Source != CertificateSourceCA {
+			continue
+		}
+		if lifetime := c.NotAfter.Sub(c.NotBefore); lifetime > 0 && now.Before(c.NotAfter) &&
+			float64(now.Sub(c.NotBefore)) > stuckRotationRatio*float64(lifetime) {
+			pc.Issues = append(pc.Issues, CertificateIssue{
+				Kind: CertificateIssueStuckRotation, Serial: c.Serial,
+				Detail: fmt.Sprintf("not renewed after %.0f%% of its lifetime", stuckRotationRatio*100),
+			})
+		}
+		if c.Root == "" {
+			continue
+		}
+		if len(meshRoots) > 0 && !slices.Contains(meshRoots, c.Root) {
+			pc.Issues = append(pc.Issues, CertificateIssue{
+				Kind: CertificateIssueRootMismatch, Serial: c.Serial, Detail: fmt.Sprintf("root %s is not in the mesh trust bundle", short(c.Root)),
+			})
+		} else if len(pc.Roots) > 0 && !slices.Contains(pc.Roots, c.Root) {
+			pc.Issues = append(pc.Issues, CertificateIssue{
+				Kind: CertificateIssueRootMismatch, Serial: c.Serial,
+				Detail: fmt.Sprintf("root %s is not in the trust bundle of the proxy", short(c.Root)),
+			})
+		}
+	}
+}
+
+// servedSecretCertificates returns the certificates of an SDS secret: the leaf of a TLS certificate, or the trusted CA
+// certificates of a validation context.
+func servedSecretCertificates(secret *tls.Secret) []ServedCertificate {
+	var data []byte
+	switch {
+	case secret.GetTlsCertificate() != nil:
+		data = inlineBytes(secret.GetTlsCertificate().GetCertificateChain())
+	case secret.GetValidationContext() != nil:
+		data = inlineBytes(secret.GetValidationContext().GetTrustedCa())
+	}
+	certs, _, err := util.ParsePemEncodedCertificateChain(data)
+	if err != nil || len(certs) == 0 {
+		return nil
+	}
+	if secret.GetTlsCertificate() != nil {
+		certs = certs[:1]
+	}
+	res := make([]ServedCertificate, 0, len(certs))
+	for _, c := range certs {
+		ids, _ := util.ExtractIDs(c.Extensions)
+		res = append(res, ServedCertificate{
+			Source:       CertificateSourceSDS,
+			Name:         secret.GetName(),
+			Serial:       c.SerialNumber.Text(16),
+			Identities:   ids,
+			TrustDomains: trustDomains(ids),
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+		})
+	}
+	return res
+}
+
+func inlineBytes(ds *core.DataSource) []byte {
+	if b := ds.GetInlineBytes(); b != nil {
+		return b
+	}
+	return []byte(ds.GetInlineString())
+}
+
+// pemFingerprints returns the fingerprints of the PEM encoded certificates, skipping the ones that do not parse.
+func pemFingerprints(pems ...string) []string {
+	res := []string{}
+	for _, p := range pems {
+		for _, c := range util.PemCertBytestoString([]byte(p)) {
+			if cert, err := util.ParsePemEncodedCertificate([]byte(c)); err == nil {
+				res = append(res, util.CertFingerprint(cert))
+			}
+		}
+	}
+	return res
+}
+
+func trustDomains(ids []string) []string {
+	res := sets.New[string]()
+	for _, id := range ids {
+		if sid, err := spiffe.ParseIdentity(id); err == nil {
+			res.Insert(sid.TrustDomain)
+		}
+	}
+	return sets.SortedList(res)
+}
+
+func short(fingerprint string) string {
+	if len(fingerprint) > 16 {
+		return fingerprint[:16]
+	}
+	return fingerprint
+}
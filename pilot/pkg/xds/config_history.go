@@ -0,0 +1,372 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/hash"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/xds"
+)
+
+// historyTypes are the types recorded in the config history of a proxy.
+var historyTypes = sets.New(v3.ListenerType, v3.RouteType, v3.ClusterType, v3.SecretType)
+
+// configHistory keeps the last pushed versions of the configuration of a proxy. Each version maps the name of every
+// resource the proxy has to the hash of its content. The content itself is stored once per hash and shared by all
+// versions, so versions that only differ in a few resources are cheap. Secrets are only tracked by name and the
+// version of the push that last sent them: nothing derived from their content is kept.
+// All methods are thread safe.
+type configHistory struct {
+	mu   sync.Mutex
+	size int
+	// versions are the recorded versions, oldest first.
+	versions []*configVersion
+	// next is the number of the next version.
+	next int
+	// contents are the serialized resources referenced by the recorded versions, by hash.
+	contents map[uint64][]byte
+}
+
+type configVersion struct {
+	ConfigVersion
+	// resources maps type to resource name to content hash. The per type maps are shared between versions and
+	// must not be modified.
+	resources map[string]map[string]uint64
+}
+
+// ConfigVersion describes a version of the configuration of a proxy.
+type ConfigVersion struct {
+	// Version numbers the versions recorded for a connection, starting at 1.
+	Version int `json:"version"`
+	// PushVersion is the version of the push context the version was generated from.
+	PushVersion string    `json:"pushVersion"`
+	Time        time.Time `json:"time"`
+	// TypeURL is the type of the push that produced this version.
+	TypeURL string `json:"typeUrl"`
+	// Resources is the number of resources of each type in this version.
+	Resources map[string]int `json:"resources"`
+}
+
+// newConnectionConfigHistory returns the config history for a new connection, or nil if it is disabled.
+func newConnectionConfigHistory() *configHistory {
+	if features.ConfigHistorySize <= 0 {
+		return nil
+	}
+	return newConfigHistory(features.ConfigHistorySize)
+}
+
+func newConfigHistory(size int) *configHistory {
+	return &configHistory{size: size, next: 1, contents: map[uint64][]byte{}}
+}
+
+// record adds the version produced by pushing res. If full is set, res replaces all resources of the type, otherwise
+// res is merged into them. Resources listed in removed are dropped, as are resources not in watched, if it is set.
+// Pushes that do not change anything are not recorded.
+func (h *configHistory) record(typeURL, pushVersion string, res model.Resources, full bool, removed []string, watched sets.String) {
+	if !historyTypes.Contains(typeURL) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var prev map[string]map[string]uint64
+	if len(h.versions) > 0 {
+		prev = h.versions[len(h.versions)-1].resources
+	}
+	cur := map[string]uint64{}
+	if !full {
+		maps.Copy(cur, prev[typeURL])
+	}
+	for _, name := range removed {
+		delete(cur, name)
+	}
+	hasher := hash.New()
+	for _, r := range res {
+		if r == nil || r.Resource == nil {
+			continue
+		}
+		hasher.Reset()
+		if typeURL == v3.SecretType {
+			// Secrets hold private keys, nothing derived from their content is kept. They are tracked by the push
+			// that sent them instead.
+			hasher.WriteString(r.Name)
+			hasher.WriteString(pushVersion)
+			cur[r.Name] = hasher.Sum64()
+			continue
+		}
+		hasher.Write(r.Resource.Value)
+		sum := hasher.Sum64()
+		cur[r.Name] = sum
+		h.contents[sum] = r.Resource.Value
+	}
+	if watched != nil {
+		for name := range cur {
+			if !watched.Contains(name) {
+				delete(cur, name)
+			}
+		}
+	}
+	if old, f := prev[typeURL]; f && maps.Equal(cur, old) {
+		h.collectLocked()
+		return
+	}
+
+	resources := maps.Clone(prev)
+	if resources == nil {
+		resources = map[string]map[string]uint64{}
+	}
+	resources[typeURL] = cur
+	counts := make(map[string]int, len(resources))
+	for t, names := range resources {
+		counts[v3.GetShortType(t)] = len(names)
+	}
+	h.versions = append(h.versions, &configVersion{
+		ConfigVersion: ConfigVersion{
+			Version:     h.next,
+			PushVersion: pushVersion,
+			Time:        time.Now(),
+			TypeURL:     typeURL,
+			Resources:   counts,
+		},
+		resources: resources,
+	})
+	h.next++
+	if len(h.versions) > h.size {
+		h.versions = slices.Clone(h.versions[len(h.versions)-h.size:])
+	}
+	h.collectLocked()
+}
+
+// recordHistory records a SotW push in the config history of the connection, if enabled.
+func (conn *Connection) recordHistory(typeURL, pushVersion string, res model.Resources, incremental bool) {
+	if conn.history == nil {
+		return
+	}
+	// Responses for wildcard types carry all resources. For other types, resources that are not pushed are kept
+	// until the proxy stops watching them.
+	if xds.IsWildcardTypeURL(typeURL) {
+		conn.history.record(typeURL, pushVersion, res, !incremental, nil, nil)
+		return
+	}
+	watched := sets.New[string]()
+	if wr := conn.proxy.GetWatchedResource(typeURL); wr != nil {
+		watched = wr.ResourceNames
+	}
+	conn.history.record(typeURL, pushVersion, res, false, nil, watched)
+}
+
+// collectLocked drops contents no longer referenced by any recorded version.
+func (h *configHistory) collectLocked() {
+	live := sets.New[uint64]()
+	for _, v := range h.versions {
+		for _, names := range v.resources {
+			for _, sum := range names {
+				live.Insert(sum)
+			}
+		}
+	}
+	for sum := range h.contents {
+		if !live.Contains(sum) {
+			delete(h.contents, sum)
+		}
+	}
+}
+
+// list returns the recorded versions, oldest first.
+func (h *configHistory) list() []ConfigVersion {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Map(h.versions, func(v *configVersion) ConfigVersion {
+		return v.ConfigVersion
+	})
+}
+
+// ConfigHistoryDiff is the difference between two versions of the configuration of a proxy.
+type ConfigHistoryDiff struct {
+	ProxyID   string           `json:"proxyId"`
+	From      ConfigVersion    `json:"from"`
+	To        ConfigVersion    `json:"to"`
+	Listeners []ResourceChange `json:"listeners,omitempty"`
+	Routes    []ResourceChange `json:"routes,omitempty"`
+	Clusters  []ResourceChange `json:"clusters,omitempty"`
+	Secrets   []ResourceChange `json:"secrets,omitempty"`
+}
+
+// ResourceChange describes how a single resource changed between two versions.
+type ResourceChange struct {
+	Name string `json:"name"`
+	// Change is one of added, removed or changed.
+	Change string `json:"change"`
+	// Diff is a unified diff of the resource in JSON form. It is not set for secrets.
+	Diff string `json:"diff,omitempty"`
+}
+
+// diff returns the difference between the versions from and to. If to is 0, the latest version is used. If from is 0,
+// the version before to is used.
+func (h *configHistory) diff(from, to int) (*ConfigHistoryDiff, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.versions) == 0 {
+		return nil, fmt.Errorf("no versions recorded")
+	}
+	oldest := h.versions[0].Version
+	if to == 0 {
+		to = h.versions[len(h.versions)-1].Version
+	}
+	if from == 0 {
+		from = max(to-1, oldest)
+	}
+	get := func(v int) (*configVersion, error) {
+		if v < oldest || v >= h.next {
+			return nil, fmt.Errorf("version %d not found, recorded versions are %d to %d", v, oldest, h.next-1)
+		}
+		return h.versions[v-oldest], nil
+	}
+	fv, err := get(from)
+	if err != nil {
+		return nil, err
+	}
+	tv, err := get(to)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigHistoryDiff{
+		From:      fv.ConfigVersion,
+		To:        tv.ConfigVersion,
+		Listeners: h.diffTypeLocked(v3.ListenerType, fv, tv),
+		Routes:    h.diffTypeLocked(v3.RouteType, fv, tv),
+		Clusters:  h.diffTypeLocked(v3.ClusterType, fv, tv),
+		Secrets:   h.diffTypeLocked(v3.SecretType, fv, tv),
+	}, nil
+}
+
+func (h *configHistory) diffTypeLocked(typeURL string, from, to *configVersion) []ResourceChange {
+	before, after := from.resources[typeURL], to.resources[typeURL]
+	var changes []ResourceChange
+	for name, b := range before {
+		a, f := after[name]
+		switch {
+		case !f:
+			changes = append(changes, ResourceChange{Name: name, Change: "removed", Diff: h.textDiffLocked(typeURL, name, b, 0)})
+		case a != b:
+			changes = append(changes, ResourceChange{Name: name, Change: "changed", Diff: h.textDiffLocked(typeURL, name, b, a)})
+		}
+	}
+	for name, a := range after {
+		if _, f := before[name]; !f {
+			changes = append(changes, ResourceChange{Name: name, Change: "added", Diff: h.textDiffLocked(typeURL, name, 0, a)})
+		}
+	}
+	slices.SortBy(changes, func(c ResourceChange) string {
+		return c.Name
+	})
+	return changes
+}
+
+// textDiffLocked returns a unified diff of the JSON form of the resource with content hashes from and to. A zero hash
+// stands for a missing resource.
+func (h *configHistory) textDiffLocked(typeURL, name string, from, to uint64) string {
+	if typeURL == v3.SecretType {
+		return ""
+	}
+	toJSON := func(sum uint64) string {
+		if sum == 0 {
+			return ""
+		}
+		b, f := h.contents[sum]
+		if !f {
+			return ""
+		}
+		msg, err := (&anypb.Any{TypeUrl: typeURL, Value: b}).UnmarshalNew()
+		if err != nil {
+			return ""
+		}
+		js, err := protomarshal.ToJSONWithIndent(msg, "  ")
+		if err != nil {
+			return ""
+		}
+		return js + "\n"
+	}
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(toJSON(from)),
+		B:        difflib.SplitLines(toJSON(to)),
+		FromFile: name,
+		ToFile:   name,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return text
+}
+
+// ConfigHistory lists the recorded config versions of a proxy, or, if from or to are set, returns the difference
+// between two versions. Instances the proxy is not connected to return an empty response.
+func (s *DiscoveryServer) ConfigHistory(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if con == nil && proxyID != "" {
+		// The proxy is connected to another instance. Reply with an empty body, which callers asking all instances
+		// skip, rather than an error.
+		return
+	}
+	if con == nil {
+		s.errorHandler(w, proxyID, con)
+		return
+	}
+	if con.history == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("config history is disabled, set PILOT_CONFIG_HISTORY_SIZE to enable it\n"))
+		return
+	}
+	query := req.URL.Query()
+	if !query.Has("from") && !query.Has("to") {
+		writeJSON(w, con.history.list(), req)
+		return
+	}
+	var versions [2]int
+	for i, p := range []string{"from", "to"} {
+		if v := query.Get(p); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprintf(w, "invalid %s version %q\n", p, v)
+				return
+			}
+			versions[i] = n
+		}
+	}
+	diff, err := con.history.diff(versions[0], versions[1])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "%v\n", err)
+		return
+	}
+	diff.ProxyID = con.proxy.ID
+	writeJSON(w, diff, req)
+}
@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestConfigHistory(t *testing.T) {
+	clusters := func(timeouts map[string]int64) model.Resources {
+		res := model.Resources{}
+		for name, timeout := range timeouts {
+			res = append(res, &discovery.Resource{
+				Name:     name,
+				Resource: protoconv.MessageToAny(&cluster.Cluster{Name: name, ConnectTimeout: durationpb.New(time.Duration(timeout) * time.Second)}),
+			})
+		}
+		return res
+	}
+	secret := func(name, key string) model.Resources {
+		return model.Resources{{
+			Name: name,
+			Resource: protoconv.MessageToAny(&tls.Secret{
+				Name: name,
+				Type: &tls.Secret_GenericSecret{GenericSecret: &tls.GenericSecret{
+					Secret: &core.DataSource{Specifier: &core.DataSource_InlineString{InlineString: key}},
+				}},
+			}),
+		}}
+	}
+	changes := func(rc []ResourceChange) []string {
+		return slices.Map(rc, func(c ResourceChange) string { return c.Change + " " + c.Name })
+	}
+
+	h := newConfigHistory(3)
+	h.record(v3.ClusterType, "1", clusters(map[string]int64{"a": 1, "b": 1}), true, nil, nil)
+	// Pushing the same resources again is not a new version
+	h.record(v3.ClusterType, "2", clusters(map[string]int64{"a": 1, "b": 1}), true, nil, nil)
+	h.record(v3.ClusterType, "3", clusters(map[string]int64{"a": 2, "c": 1}), true, nil, nil)
+	h.record(v3.SecretType, "3", secret("default", "key1"), false, nil, sets.New("default"))
+	assert.Equal(t, slices.Map(h.list(), func(v ConfigVersion) int { return v.Version }), []int{1, 2, 3})
+	assert.Equal(t, h.list()[2].Resources, map[string]int{"CDS": 2, "SDS": 1})
+
+	diff, err := h.diff(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, changes(diff.Clusters), []string{"changed a", "removed b", "added c"})
+	assert.Equal(t, strings.Contains(diff.Clusters[0].Diff, `-  "connectTimeout": "1s"`), true)
+	assert.Equal(t, strings.Contains(diff.Clusters[0].Diff, `+  "connectTimeout": "2s"`), true)
+
+	// Secrets are only tracked by the push that sent them
+	h.record(v3.SecretType, "4", secret("default", "key2"), false, nil, sets.New("default"))
+	diff, err = h.diff(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, diff.From.Version, 3)
+	assert.Equal(t, diff.To.Version, 4)
+	assert.Equal(t, diff.Secrets, []ResourceChange{{Name: "default", Change: "changed"}})
+	assert.Equal(t, len(diff.Clusters), 0)
+	h.record(v3.SecretType, "4", secret("default", "key2"), false, nil, sets.New("default"))
+	assert.Equal(t, h.list()[len(h.list())-1].Version, 4)
+
+	// Old versions are dropped, along with the contents only they referenced
+	_, err = h.diff(1, 4)
+	assert.Error(t, err)
+	assert.Equal(t, len(h.contents), 2)
+
+	t.Run("non wildcard types", func(t *testing.T) {
+		h := newConfigHistory(3)
+		h.record(v3.ClusterType, "1", clusters(map[string]int64{"a": 1, "b": 1}), false, nil, sets.New("a", "b"))
+		h.record(v3.ClusterType, "2", clusters(map[string]int64{"a": 2}), false, nil, sets.New("a"))
+		diff, err := h.diff(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, changes(diff.Clusters), []string{"changed a", "removed b"})
+	})
+}
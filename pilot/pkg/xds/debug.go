@@ -25,6 +25,7 @@ import (
 	"net/netip"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -55,6 +56,7 @@ import (
 	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/workloadapi"
+	"istio.io/istio/security/pkg/server/ca/audit"
 )
 
 // CallerNamespaceKey is used to store caller namespace in request context
@@ -140,6 +142,10 @@ type SyncStatus struct {
 	ExtensionConfigSent  string                    `json:"extensionconfig_sent,omitempty"`
 	ExtensionConfigAcked string                    `json:"extensionconfig_acked,omitempty"`
 	Resources            map[string]ResourceStatus `json:"resources,omitempty"`
+	// Slow is set if the proxy is lagging behind on any type.
+	Slow bool `json:"slow,omitempty"`
+	// CoalescedPushes is the number of pushes held back until the proxy catches up.
+	CoalescedPushes int `json:"coalescedPushes,omitempty"`
 }
 
 type ResourceStatus struct {
@@ -147,6 +153,7 @@ type ResourceStatus struct {
 	Acked     string    `json:"acked,omitempty"`
 	SentTime  time.Time `json:"sentTime,omitempty"`
 	LastError string    `json:"lastError,omitempty"`
+	FlowControlStatus
 }
 
 // SyncedVersions shows what resourceVersion of a given resource has been acked by Envoy.
@@ -216,13 +223,22 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/authorizationz", "Internal authorization policies", s.authorizationz)
 	s.addDebugHandler(mux, internalMux, "/debug/telemetryz", "Debug Telemetry configuration", s.telemetryz)
 	s.addDebugHandler(mux, internalMux, "/debug/config_dump", "ConfigDump in the form of the Envoy admin config dump API for passed in proxyID", s.ConfigDump)
+	s.addDebugHandler(mux, internalMux, "/debug/config_history",
+		"Recently pushed config versions for passed in proxyID, or the diff between the from and to versions", s.ConfigHistory)
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/push_trace", "Recent config events, the pushes they caused and the proxies pushed", s.pushTraceHandler)
+	// Previews are expensive, and take a body, so they are only served on the authenticated HTTP mux, to the system namespace.
+	s.addSystemDebugHandler(mux, "/debug/config_impact", "Preview the proxies and resources changed by the configs POSTed in the body",
+		s.ConfigImpactPreview)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
 
 	s.addDebugHandler(mux, internalMux, "/debug/inject", "Active inject template", s.injectTemplateHandler(webhook))
 	s.addDebugHandler(mux, internalMux, "/debug/mesh", "Active mesh config", s.meshHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/clusterz", "List remote clusters where istiod reads endpoints", s.clusterz)
+	s.addDebugHandler(mux, internalMux, "/debug/certz", "Recent certificates issued by the Istio CA", s.certz)
+	s.addDebugHandler(mux, internalMux, "/debug/rootrotation", "State of the staged root rotation of the Istio CA", s.rootRotation)
+	s.addDebugHandler(mux, internalMux, "/debug/certinventoryz", "Certificates of the connected proxies, and their issues", s.certInventory)
 	s.addDebugHandler(mux, internalMux, "/debug/networkz", "List cross-network gateways", s.networkz)
 	s.addDebugHandler(mux, internalMux, "/debug/mcsz", "List information about Kubernetes MCS services", s.mcsz)
 
@@ -241,6 +257,50 @@ func (s *DiscoveryServer) addDebugHandler(mux *http.ServeMux, internalMux *http.
 	mux.HandleFunc(path, s.allowAuthenticatedOrLocalhost(http.HandlerFunc(handler)))
 }
 
+// addSystemDebugHandler adds a debug handler restricted to localhost and the identities of the system namespace,
+// regardless of ENABLE_DEBUG_ENDPOINT_AUTH. It is not added to the internal mux.
+func (s *DiscoveryServer) addSystemDebugHandler(mux *http.ServeMux, path string, help string, handler func(http.ResponseWriter, *http.Request)) {
+	s.debugHandlers[path] = help
+	mux.HandleFunc(path, s.allowSystemNamespaceOrLocalhost(http.HandlerFunc(handler)))
+}
+
+// authenticateDebugRequest authenticates the request with the same method as XDS, and returns the caller identities,
+// or nil if it is not authenticated.
+func (s *DiscoveryServer) authenticateDebugRequest(req *http.Request) []string {
+	authFailMsgs := make([]string, 0)
+	authRequest := security.AuthContext{Request: req}
+	for _, authn := range s.Authenticators {
+		u, err := authn.Authenticate(authRequest)
+		// If one authenticator passes, return
+		if u != nil && u.Identities != nil && err == nil {
+			return u.Identities
+		}
+		authFailMsgs = append(authFailMsgs, fmt.Sprintf("Authenticator %s: %v", authn.AuthenticatorType(), err))
+	}
+	istiolog.Errorf("Failed to authenticate %s %v", req.URL, authFailMsgs)
+	return nil
+}
+
+func (s *DiscoveryServer) allowSystemNamespaceOrLocalhost(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if isRequestFromLocalhost(req) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		ids := s.authenticateDebugRequest(req)
+		if ids == nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if s.extractNamespace(ids) != s.systemNamespace() {
+			istiolog.Warnf("Unauthorized debug request from %v to %s", ids, req.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, req)
+	}
+}
+
 func (s *DiscoveryServer) allowAuthenticatedOrLocalhost(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		// Localhost gets unrestricted access (istiod talking to itself on 127.0.0.1:8080)
@@ -249,21 +309,8 @@ func (s *DiscoveryServer) allowAuthenticatedOrLocalhost(next http.Handler) http.
 			next.ServeHTTP(w, req)
 			return
 		}
-		// Authenticate request with the same method as XDS
-		authFailMsgs := make([]string, 0)
-		var ids []string
-		authRequest := security.AuthContext{Request: req}
-		for _, authn := range s.Authenticators {
-			u, err := authn.Authenticate(authRequest)
-			// If one authenticator passes, return
-			if u != nil && u.Identities != nil && err == nil {
-				ids = u.Identities
-				break
-			}
-			authFailMsgs = append(authFailMsgs, fmt.Sprintf("Authenticator %s: %v", authn.AuthenticatorType(), err))
-		}
+		ids := s.authenticateDebugRequest(req)
 		if ids == nil {
-			istiolog.Errorf("Failed to authenticate %s %v", req.URL, authFailMsgs)
 			// Not including detailed info in the response, XDS doesn't either (returns a generic "authentication failure).
 			w.WriteHeader(http.StatusUnauthorized)
 			return
@@ -317,11 +364,7 @@ func (s *DiscoveryServer) AuthorizeDebugRequest(identities []string, req *http.R
 		return false
 	}
 
-	// get system namespace (istio-system by default, or mesh root namespace)
-	systemNamespace := constants.IstioSystemNamespace
-	if s.Env != nil && s.Env.Mesh() != nil && s.Env.Mesh().GetRootNamespace() != "" {
-		systemNamespace = s.Env.Mesh().GetRootNamespace()
-	}
+	systemNamespace := s.systemNamespace()
 
 	// allow all if identity is from system namespace or an allowed namespace
 	if namespace == systemNamespace || features.DebugEndpointAuthAllowedNamespaces.Contains(namespace) {
@@ -343,14 +386,18 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 		node := con.proxy
 		if node != nil && (namespace == "" || node.GetNamespace() == namespace) {
 			wrs := node.DeepCloneWatchedResources()
+			flow, coalesced := con.flow.status(time.Now())
 			res := make(map[string]ResourceStatus, len(wrs))
+			slow := false
 			for _, wr := range wrs {
 				res[wr.TypeUrl] = ResourceStatus{
-					Sent:      wr.NonceSent,
-					Acked:     wr.NonceAcked,
-					SentTime:  wr.LastSendTime,
-					LastError: wr.LastError,
+					Sent:              wr.NonceSent,
+					Acked:             wr.NonceAcked,
+					SentTime:          wr.LastSendTime,
+					LastError:         wr.LastError,
+					FlowControlStatus: flow[wr.TypeUrl],
 				}
+				slow = slow || flow[wr.TypeUrl].Slow
 			}
 			syncz = append(syncz, SyncStatus{
 				ProxyID:              node.ID,
@@ -368,6 +415,8 @@ func (s *DiscoveryServer) Syncz(w http.ResponseWriter, req *http.Request) {
 				ExtensionConfigSent:  node.NonceSent(v3.ExtensionConfigurationType),
 				ExtensionConfigAcked: node.NonceAcked(v3.ExtensionConfigurationType),
 				Resources:            res,
+				Slow:                 slow,
+				CoalescedPushes:      coalesced,
 			})
 		}
 	}
@@ -888,10 +937,11 @@ func (s *DiscoveryServer) meshHandler(w http.ResponseWriter, req *http.Request)
 }
 
 // pushStatusHandler dumps the last PushContext
+// When adaptive debouncing is enabled, its most recent decision is included as "adaptiveDebounce".
 func (s *DiscoveryServer) pushStatusHandler(w http.ResponseWriter, req *http.Request) {
 	model.LastPushMutex.Lock()
 	defer model.LastPushMutex.Unlock()
-	if model.LastPushStatus == nil {
+	if model.LastPushStatus == nil && s.DebounceOptions.adaptive == nil {
 		return
 	}
 	out, err := model.LastPushStatus.StatusJSON()
@@ -899,6 +949,18 @@ func (s *DiscoveryServer) pushStatusHandler(w http.ResponseWriter, req *http.Req
 		handleHTTPError(w, err)
 		return
 	}
+	if s.DebounceOptions.adaptive != nil {
+		status := map[string]any{}
+		if err := json.Unmarshal(out, &status); err != nil {
+			handleHTTPError(w, err)
+			return
+		}
+		status["adaptiveDebounce"] = s.DebounceOptions.adaptive.lastStatus()
+		if out, err = json.MarshalIndent(status, "", "    "); err != nil {
+			handleHTTPError(w, err)
+			return
+		}
+	}
 	w.Header().Add("Content-Type", "application/json")
 
 	_, _ = w.Write(out)
@@ -1124,6 +1186,73 @@ func (s *DiscoveryServer) clusterz(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, s.ListRemoteClusters(), req)
 }
 
+// certz lists the certificates recently issued by the Istio CA, most recent first. The results can be narrowed down
+// with the identity, serial, since (RFC 3339) and limit query parameters.
+func (s *DiscoveryServer) certz(w http.ResponseWriter, req *http.Request) {
+	if s.ListIssuedCertificates == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("the Istio CA is not running in this istiod\n"))
+		return
+	}
+	q := req.URL.Query()
+	filter := audit.Filter{
+		Identity: q.Get("identity"),
+		Serial:   q.Get("serial"),
+	}
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, "invalid since %q: %v\n", since, err)
+			return
+		}
+		filter.Since = t
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, "invalid limit %q\n", limit)
+			return
+		}
+		filter.Limit = n
+	}
+	writeJSON(w, s.ListIssuedCertificates(filter), req)
+}
+
+// rootRotation shows the state of the staged root rotation of the Istio CA, and of the trust bundle of the proxies
+// connected to this istiod.
+func (s *DiscoveryServer) rootRotation(w http.ResponseWriter, req *http.Request) {
+	if s.RootRotationStatus == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("staged root rotation is not enabled in this istiod\n"))
+		return
+	}
+	status, err := s.RootRotationStatus()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "failed to get the root rotation status: %v\n", err)
+		return
+	}
+	writeJSON(w, status, req)
+}
+
+// certInventory lists the certificates of the proxies connected to this istiod, and the issues found with them. The
+// within query parameter sets the window of certificates reported as near expiry.
+func (s *DiscoveryServer) certInventory(w http.ResponseWriter, req *http.Request) {
+	window := DefaultCertificateExpiryWindow
+	if within := req.URL.Query().Get("within"); within != "" {
+		d, err := time.ParseDuration(within)
+		if err != nil || d < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, "invalid within %q\n", within)
+			return
+		}
+		window = d
+	}
+	writeJSON(w, s.CertificateInventory(time.Now(), window), req)
+}
+
 // handlePushRequest handles a ?push=true query param and triggers a push.
 // A boolean response is returned to indicate if the caller should continue
 func (s *DiscoveryServer) handlePushRequest(w http.ResponseWriter, req *http.Request) bool {
@@ -1146,24 +1275,38 @@ func (s *DiscoveryServer) getDebugConnection(req *http.Request) (string, *Connec
 	if proxyID := req.URL.Query().Get("proxyID"); proxyID != "" {
 		con := s.getProxyConnection(proxyID)
 		// Verify namespace if caller is not from system namespace (when auth is enabled)
-		if features.EnableDebugEndpointAuth {
-			callerNamespace, _ := req.Context().Value(CallerNamespaceKey{}).(string)
-			if con != nil && callerNamespace != "" {
-				systemNamespace := constants.IstioSystemNamespace
-				if s.Env != nil && s.Env.Mesh() != nil && s.Env.Mesh().GetRootNamespace() != "" {
-					systemNamespace = s.Env.Mesh().GetRootNamespace()
-				}
-				// Non-system namespaces can only access proxies in their own namespace
-				if callerNamespace != systemNamespace && con.proxy.ConfigNamespace != callerNamespace {
-					return proxyID, nil // Return nil connection to deny access
-				}
-			}
+		if ns := s.debugCallerNamespace(req); con != nil && ns != "" && con.proxy.ConfigNamespace != ns {
+			return proxyID, nil // Return nil connection to deny access
 		}
 		return proxyID, con
 	}
 	return "", nil
 }
 
+// systemNamespace returns the system namespace: the mesh root namespace, or istio-system by default.
+func (s *DiscoveryServer) systemNamespace() string {
+	if s.Env != nil && s.Env.Mesh() != nil && s.Env.Mesh().GetRootNamespace() != "" {
+		return s.Env.Mesh().GetRootNamespace()
+	}
+	return constants.IstioSystemNamespace
+}
+
+// debugCallerNamespace returns the namespace a debug request is restricted to, or "" if the caller may access
+// proxies in all namespaces. Non-system namespaces can only access proxies in their own namespace.
+func (s *DiscoveryServer) debugCallerNamespace(req *http.Request) string {
+	if !features.EnableDebugEndpointAuth {
+		return ""
+	}
+	callerNamespace, _ := req.Context().Value(CallerNamespaceKey{}).(string)
+	if callerNamespace == "" {
+		return ""
+	}
+	if callerNamespace == s.systemNamespace() {
+		return ""
+	}
+	return callerNamespace
+}
+
 func (s *DiscoveryServer) errorHandler(w http.ResponseWriter, proxyID string, con *Connection) {
 	if proxyID == "" {
 		w.WriteHeader(http.StatusBadRequest)
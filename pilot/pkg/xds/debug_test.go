@@ -22,19 +22,24 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
+	networking "istio.io/api/networking/v1alpha3"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/xds"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/spiffe"
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/server/ca/audit"
 )
 
 func TestSyncz(t *testing.T) {
@@ -86,6 +91,34 @@ func TestSyncz(t *testing.T) {
 		node, _ := model.ParseServiceNodeWithMetadata(ads.ID, &model.NodeMetadata{})
 		verifySyncStatus(t, s.Discovery, node.ID, true, true)
 	})
+	t.Run("slow proxies", func(t *testing.T) {
+		test.SetForTest(t, &features.EnablePushFlowControl, true)
+		test.SetForTest(t, &features.PushFlowControlMaxUnacked, 1)
+		s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+		ads := s.ConnectADS().WithType(v3.ClusterType)
+		ads.RequestResponseAck(t, nil)
+		slow := func() xds.SyncStatus {
+			for _, ss := range getSyncStatus(t, s.Discovery) {
+				return ss
+			}
+			return xds.SyncStatus{}
+		}
+		assert.EventuallyEqual(t, func() bool { return slow().Slow }, false)
+
+		// The proxy does not ACK this push, so it is lagging and the next push is held back
+		s.Discovery.ConfigUpdate(&model.PushRequest{Forced: true})
+		unacked := ads.ExpectResponse(t)
+		assert.EventuallyEqual(t, func() bool { return slow().Slow }, true)
+		s.Discovery.ConfigUpdate(&model.PushRequest{Forced: true})
+		assert.EventuallyEqual(t, func() int { return slow().CoalescedPushes }, 1)
+		ads.ExpectNoResponse(t)
+		assert.Equal(t, slow().Resources[v3.ClusterType].Unacked, 1)
+
+		// Once the proxy catches up, the held back push is sent
+		ads.Request(t, &discovery.DiscoveryRequest{ResponseNonce: unacked.Nonce, VersionInfo: unacked.VersionInfo})
+		ads.ExpectResponse(t)
+		assert.EventuallyEqual(t, func() int { return slow().CoalescedPushes }, 0)
+	})
 }
 
 func getSyncStatus(t *testing.T, server *xds.DiscoveryServer) []xds.SyncStatus {
@@ -569,3 +602,277 @@ func TestDebugGenPassesNamespaceContext(t *testing.T) {
 	}
 	assert.Equal(t, "production", capturedNS, "caller namespace should be passed to handler")
 }
+
+func TestConfigImpactPreview(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{ConfigString: `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: example
+  namespace: default
+spec:
+  hosts: [example.com]
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: DNS
+`})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.RouteType, ResourceNames: []string{"80"}})
+
+	preview := func(t *testing.T, method, body string) (int, xds.ConfigImpact) {
+		req := httptest.NewRequest(method, "/debug/config_impact", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		s.Discovery.ConfigImpactPreview(rr, req)
+		var impact xds.ConfigImpact
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &impact))
+		}
+		return rr.Code, impact
+	}
+
+	t.Run("routes and clusters", func(t *testing.T) {
+		code, impact := preview(t, http.MethodPost, `
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: example
+spec:
+  hosts: [example.com]
+  http:
+  - timeout: 5s
+    route:
+    - destination:
+        host: example.com
+---
+apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: example
+spec:
+  host: example.com
+  trafficPolicy:
+    connectionPool:
+      tcp:
+        maxConnections: 10
+`)
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, impact.Configs, []string{"VirtualService/default/example", "DestinationRule/default/example"})
+		assert.Equal(t, len(impact.Proxies), 1)
+		assert.Equal(t, impact.Proxies[0].Routes, &xds.ResourceImpact{Changed: []string{"80"}})
+		assert.Equal(t, impact.Proxies[0].Clusters, &xds.ResourceImpact{Changed: []string{"outbound|80||example.com"}})
+		// The proposed configs must not be applied
+		assert.Equal(t, len(s.Env().List(gvk.VirtualService, "")), 0)
+	})
+	t.Run("other namespace", func(t *testing.T) {
+		code, impact := preview(t, http.MethodPost, `
+apiVersion: networking.istio.io/v1
+kind: Sidecar
+metadata:
+  name: default
+  namespace: other
+spec:
+  egress:
+  - hosts: ["./*"]
+`)
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, len(impact.Proxies), 0)
+		assert.Equal(t, impact.Unaffected, 1)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		code, _ := preview(t, http.MethodPost, `
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: example
+spec:
+  http:
+  - route: []
+`)
+		assert.Equal(t, code, http.StatusBadRequest)
+		code, _ = preview(t, http.MethodGet, "")
+		assert.Equal(t, code, http.StatusMethodNotAllowed)
+	})
+	t.Run("authentication", func(t *testing.T) {
+		mux := http.NewServeMux()
+		internalMux := s.Discovery.InitDebug(mux, false, nil)
+		serve := func(mux *http.ServeMux, remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodPost, "/debug/config_impact", strings.NewReader(""))
+			req.RemoteAddr = remoteAddr
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			return rr.Code
+		}
+		assert.Equal(t, serve(mux, "10.0.0.1:1234"), http.StatusUnauthorized)
+		assert.Equal(t, serve(mux, "127.0.0.1:1234"), http.StatusBadRequest)
+		// The internal mux is not authenticated, the preview is not served on it.
+		assert.Equal(t, serve(internalMux, "127.0.0.1:1234"), http.StatusNotFound)
+	})
+}
+
+func TestConfigHistoryEndpoint(t *testing.T) {
+	test.SetForTest(t, &features.ConfigHistorySize, 5)
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+
+	get := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.Discovery.ConfigHistory(rr, httptest.NewRequest(http.MethodGet, "/debug/config_history?"+query, nil))
+		return rr
+	}
+	node, _ := model.ParseServiceNodeWithMetadata(ads.ID, &model.NodeMetadata{})
+	proxyID := "proxyID=" + node.ID
+
+	if _, err := s.Store().Create(config.Config{
+		Meta: config.Meta{GroupVersionKind: gvk.ServiceEntry, Name: "example", Namespace: "default"},
+		Spec: &networking.ServiceEntry{
+			Hosts:      []string{"example.com"},
+			Ports:      []*networking.ServicePort{{Number: 80, Name: "http", Protocol: "HTTP"}},
+			Resolution: networking.ServiceEntry_DNS,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ads.ExpectResponse(t)
+
+	var versions []xds.ConfigVersion
+	assert.EventuallyEqual(t, func() int {
+		versions = nil
+		_ = json.Unmarshal(get(t, proxyID).Body.Bytes(), &versions)
+		return len(versions)
+	}, 2)
+
+	rr := get(t, proxyID+"&from=1&to=2")
+	assert.Equal(t, rr.Code, http.StatusOK)
+	diff := xds.ConfigHistoryDiff{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &diff))
+	assert.Equal(t, diff.Clusters, []xds.ResourceChange{{
+		Name:   "outbound|80||example.com",
+		Change: "added",
+		Diff:   diff.Clusters[0].Diff,
+	}})
+	assert.Equal(t, strings.Contains(diff.Clusters[0].Diff, `+  "name": "outbound|80||example.com"`), true)
+
+	assert.Equal(t, get(t, proxyID+"&from=9").Code, http.StatusNotFound)
+	assert.Equal(t, get(t, proxyID+"&from=x").Code, http.StatusBadRequest)
+	assert.Equal(t, get(t, "").Code, http.StatusBadRequest)
+	// Proxies connected to other instances get an empty response
+	rr = get(t, "proxyID=other.default")
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.Equal(t, rr.Body.Len(), 0)
+}
+
+func TestCertz(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	mux := s.Discovery.InitDebug(http.NewServeMux(), false, func() map[string]string { return nil })
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/certz?"+query, nil))
+		return rr
+	}
+	assert.Equal(t, get("").Code, http.StatusBadRequest)
+
+	issuances := audit.NewLog(10, time.Second)
+	issuances.Seed([]audit.Record{
+		{Serial: "1", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}},
+		{Serial: "2", SANs: []string{"spiffe://cluster.local/ns/b/sa/b"}},
+		{Serial: "3", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}},
+	})
+	s.Discovery.ListIssuedCertificates = issuances.Query
+
+	serials := func(query string) []string {
+		rr := get(query)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var records []audit.Record
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &records))
+		out := []string{}
+		for _, r := range records {
+			out = append(out, r.Serial)
+		}
+		return out
+	}
+	assert.Equal(t, serials(""), []string{"3", "2", "1"})
+	assert.Equal(t, serials("identity=spiffe://cluster.local/ns/a/sa/a"), []string{"3", "1"})
+	assert.Equal(t, serials("serial=2"), []string{"2"})
+	assert.Equal(t, serials("limit=1"), []string{"3"})
+	assert.Equal(t, get("limit=x").Code, http.StatusBadRequest)
+	assert.Equal(t, get("since=yesterday").Code, http.StatusBadRequest)
+}
+
+func TestCertInventory(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+	mux := s.Discovery.InitDebug(http.NewServeMux(), false, func() map[string]string { return nil })
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/certinventoryz?"+query, nil))
+		return rr
+	}
+
+	now := time.Now()
+	issuances := audit.NewLog(10, time.Second)
+	issuances.Seed([]audit.Record{
+		{
+			Serial: "1", SANs: []string{"spiffe://cluster.local/ns/default/sa/default"}, CallerPod: "default/test",
+			NotBefore: now.Add(-20 * time.Hour), NotAfter: now.Add(4 * time.Hour),
+		},
+		{
+			Serial: "2", SANs: []string{"spiffe://cluster.local/ns/default/sa/default"}, CallerPod: "default/test",
+			NotBefore: now.Add(-time.Hour), NotAfter: now.Add(23 * time.Hour),
+		},
+	})
+	s.Discovery.ListIssuedCertificates = issuances.Query
+
+	inventory := func(query string) xds.CertificateInventory {
+		rr := get(query)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		inv := xds.CertificateInventory{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &inv))
+		return inv
+	}
+	inv := inventory("")
+	assert.Equal(t, len(inv.Proxies), 1)
+	// Only the latest certificate of the identity is served.
+	assert.Equal(t, len(inv.Proxies[0].Certificates), 1)
+	assert.Equal(t, inv.Proxies[0].Certificates[0].Serial, "2")
+	assert.Equal(t, inv.Proxies[0].Certificates[0].TrustDomains, []string{"cluster.local"})
+	assert.Equal(t, len(inv.Proxies[0].Issues), 0)
+
+	inv = inventory("within=24h")
+	assert.Equal(t, inv.Proxies[0].Issues, []xds.CertificateIssue{{
+		Kind: xds.CertificateIssueNearExpiry, Serial: "2", Detail: inv.Proxies[0].Issues[0].Detail,
+	}})
+	assert.Equal(t, get("within=x").Code, http.StatusBadRequest)
+}
+
+func TestEvaluateCertificates(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pc := &xds.ProxyCertificates{
+		Roots: []string{"old", "new"},
+		Certificates: []xds.ServedCertificate{
+			{Source: xds.CertificateSourceCA, Serial: "stuck", NotBefore: now.Add(-20 * time.Hour), NotAfter: now.Add(4 * time.Hour), Root: "old"},
+			{Source: xds.CertificateSourceCA, Serial: "unknown-root", NotBefore: now, NotAfter: now.Add(24 * time.Hour), Root: "other"},
+			{Source: xds.CertificateSourceSDS, Serial: "expired", NotBefore: now.Add(-48 * time.Hour), NotAfter: now.Add(-time.Hour)},
+		},
+	}
+	xds.EvaluateCertificates(pc, []string{"old", "new"}, now, time.Hour)
+	kinds := []string{}
+	for _, i := range pc.Issues {
+		kinds = append(kinds, i.Serial+":"+i.Kind)
+	}
+	assert.Equal(t, kinds, []string{"stuck:StuckRotation", "unknown-root:RootMismatch", "expired:Expired"})
+
+	// A root in the mesh trust bundle, but not yet in the one of the proxy, is a mismatch as well.
+	pc.Roots = []string{"old"}
+	pc.Certificates = []xds.ServedCertificate{
+		{Source: xds.CertificateSourceCA, Serial: "new", NotBefore: now, NotAfter: now.Add(24 * time.Hour), Root: "new"},
+	}
+	xds.EvaluateCertificates(pc, []string{"old", "new"}, now, time.Hour)
+	assert.Equal(t, len(pc.Issues), 1)
+	assert.Equal(t, pc.Issues[0].Kind, xds.CertificateIssueRootMismatch)
+}
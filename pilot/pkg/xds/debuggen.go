@@ -32,9 +32,10 @@ import (
 )
 
 var activeNamespaceDebuggers = map[string]struct{}{
-	"config_dump": {},
-	"ndsz":        {},
-	"edsz":        {},
+	"config_dump":    {},
+	"config_history": {},
+	"ndsz":           {},
+	"edsz":           {},
 }
 
 // DebugGen is a Generator for istio debug info
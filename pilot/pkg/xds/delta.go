@@ -86,6 +86,7 @@ func (s *DiscoveryServer) StreamDeltas(stream DeltaDiscoveryStream) error {
 	// InitContext returns immediately if the context was already initialized.
 	s.globalPushContext().InitContext(s.Env, nil, nil)
 	con := newDeltaConnection(peerAddr, stream)
+	con.redirectMode = redirectMode(ctx)
 
 	// Do not call: defer close(con.pushChannel). The push channel will be garbage collected
 	// when the connection is no longer used. Closing the channel can cause subtle race conditions
@@ -149,19 +150,32 @@ func (s *DiscoveryServer) StreamDeltas(stream DeltaDiscoveryStream) error {
 }
 
 // Compute and send the new configuration for a connection.
-func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) error {
+func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) (err error) {
 	pushRequest := pushEv.pushRequest
 
 	if !model.OnlyHasConfigsOfKind(pushRequest.ConfigsUpdated, kind.Endpoints) {
 		// Update Proxy with current information.
 		s.computeProxyState(con.proxy, pushRequest)
 	}
+	if pushRequest.IsProxyUpdate() {
+		s.updatePushPriority(con)
+	}
 
 	pushRequest, needsPush := s.ProxyNeedsPush(con.proxy, pushRequest)
 	if !needsPush {
 		deltaLog.Debugf("Skipping push to %v, no updates required", con.ID())
 		return nil
 	}
+	if con.flow.coalesce(pushRequest) {
+		deltaLog.Debugf("Coalescing push to %v, waiting for the proxy to catch up", con.ID())
+		return nil
+	}
+	if features.EnablePushTracing {
+		finish := s.pushTracer.startProxyPush(con, pushRequest)
+		defer func() {
+			finish(err)
+		}()
+	}
 
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
@@ -238,6 +252,10 @@ func (conn *Connection) sendDelta(res *discovery.DeltaDiscoveryResponse, newReso
 	err := sendResonse()
 	if err == nil {
 		if !strings.HasPrefix(res.TypeUrl, v3.DebugType) {
+			conn.flow.sent(res.TypeUrl, res.Nonce, time.Now())
+			if conn.history != nil {
+				conn.history.record(res.TypeUrl, res.SystemVersionInfo, res.Resources, false, res.RemovedResources, nil)
+			}
 			conn.proxy.UpdateWatchedResource(res.TypeUrl, func(wr *model.WatchedResource) *model.WatchedResource {
 				if wr == nil {
 					wr = &model.WatchedResource{TypeUrl: res.TypeUrl}
@@ -279,6 +297,12 @@ func (s *DiscoveryServer) processDeltaRequest(req *discovery.DeltaDiscoveryReque
 			&model.PushRequest{Push: con.proxy.LastPushContext, Forced: true})
 	}
 
+	if pending := con.flow.received(req.TypeUrl, req.ResponseNonce, time.Now()); pending != nil {
+		// The proxy caught up, queue the pushes that were held back while it was lagging. Like any other push,
+		// they are throttled by the push queue.
+		s.pushQueue.Enqueue(con, pending)
+	}
+
 	shouldRespond := shouldRespondDelta(con, req)
 	if !shouldRespond {
 		return nil
@@ -623,11 +647,15 @@ func shouldSetWatchedResources(w *model.WatchedResource) bool {
 }
 
 func newDeltaConnection(peerAddr string, stream DeltaDiscoveryStream) *Connection {
-	return &Connection{
+	con := &Connection{
 		Connection:   xds.NewConnection(peerAddr, nil),
 		deltaStream:  stream,
 		deltaReqChan: make(chan *discovery.DeltaDiscoveryRequest, 1),
+		flow:         newFlowControl(),
+		history:      newConnectionConfigHistory(),
 	}
+	con.setPushPriority(PushPriorityNormal)
+	return con
 }
 
 // To satisfy methods that need DiscoveryRequest. Not suitable for real usage
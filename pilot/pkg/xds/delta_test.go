@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/features"
@@ -555,3 +556,43 @@ func TestDeltaUnsub(t *testing.T) {
 	})
 	runAssert(resp.Nonce)
 }
+
+func TestDeltaLDS(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	addTestClientEndpoints(s.MemRegistry)
+	s.EnsureSynced(t)
+
+	id := "sidecar~127.0.0.1~test.default~default.svc.cluster.local"
+	ads := s.ConnectDeltaADS().WithType(v3.ListenerType).WithID(id)
+	state := map[string]*listener.Listener{}
+	apply := func(resp *discovery.DeltaDiscoveryResponse) {
+		for _, r := range resp.RemovedResources {
+			delete(state, r)
+		}
+		for _, r := range resp.Resources {
+			state[r.Name] = xdstest.UnmarshalAny[listener.Listener](t, r.Resource)
+		}
+	}
+	apply(ads.RequestResponseAck(nil))
+
+	// Only the listeners of the port of the new service are sent
+	s.MemRegistry.AddHTTPService(edsIncSvc, edsIncVip, 8080)
+	resp := ads.ExpectResponse()
+	assert.Equal(t, slices.Sort(slices.Map(resp.Resources, (*discovery.Resource).GetName)),
+		[]string{"0.0.0.0_8080", "virtualInbound", "virtualOutbound"})
+	apply(resp)
+
+	s.MemRegistry.RemoveService(edsIncSvc)
+	resp = ads.ExpectResponse()
+	assert.Equal(t, resp.RemovedResources, []string{"0.0.0.0_8080"})
+	apply(resp)
+
+	// The listeners known from delta updates are the same as the ones of a SotW connection
+	sotw := s.ConnectADS().WithType(v3.ListenerType).WithID(id).RequestResponseAck(t, nil)
+	want := map[string]*listener.Listener{}
+	for _, r := range sotw.Resources {
+		l := xdstest.UnmarshalAny[listener.Listener](t, r)
+		want[l.Name] = l
+	}
+	assert.Equal(t, state, want)
+}
@@ -24,6 +24,7 @@ import (
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
@@ -39,6 +40,9 @@ import (
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/tracing"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
 )
 
 var periodicRefreshMetrics = 10 * time.Second
@@ -59,6 +63,18 @@ type DebounceOptions struct {
 
 	// enableEDSDebounce indicates whether EDS pushes should be debounced.
 	enableEDSDebounce bool
+
+	// adaptive, if set, tunes the quiet period from the observed push cost and event rate, and is used instead
+	// of DebounceAfter.
+	adaptive *adaptiveDebounce
+}
+
+// quietPeriod returns the time to wait after the last event before pushing.
+func (o DebounceOptions) quietPeriod() time.Duration {
+	if o.adaptive != nil {
+		return o.adaptive.quietPeriod()
+	}
+	return o.DebounceAfter
 }
 
 // DiscoveryServer is Pilot's gRPC implementation for Envoy's xds APIs
@@ -104,6 +120,10 @@ type DiscoveryServer struct {
 	// debugHandlers is the list of all the supported debug handlers.
 	debugHandlers map[string]string
 
+	// impactPreviews bounds the number of config impact previews computed concurrently, each of which builds a full
+	// shadow push context.
+	impactPreviews chan struct{}
+
 	// adsClients reflect active gRPC channels, for both ADS and EDS.
 	adsClients      map[string]*Connection
 	adsClientsMutex sync.RWMutex
@@ -127,6 +147,21 @@ type DiscoveryServer struct {
 	// ListRemoteClusters collects debug information about other clusters this istiod reads from.
 	ListRemoteClusters func() []cluster.DebugInfo
 
+	// ListIssuedCertificates queries the recent certificate issuances of the Istio CA. It is nil if the CA is not
+	// running in this istiod.
+	ListIssuedCertificates func(filter audit.Filter) []audit.Record
+
+	// RootRotationStatus returns the state of the staged root rotation of the Istio CA. It is nil if staged root
+	// rotation is not enabled in this istiod.
+	RootRotationStatus func() (*ca.RootRotationStatus, error)
+
+	// NamespaceLabels returns the labels of a namespace. It is used to assign push priorities, and may be nil.
+	NamespaceLabels func(namespace string) map[string]string
+
+	// ShardMembership lists the Istiod replicas new connections are sharded across. If nil, connections are not
+	// sharded.
+	ShardMembership ShardMembership
+
 	// ClusterAliases are alias names for cluster. When a proxy connects with a cluster ID
 	// and if it has a different alias we should use that a cluster ID for proxy.
 	ClusterAliases map[cluster.ID]cluster.ID
@@ -141,6 +176,12 @@ type DiscoveryServer struct {
 
 	// registrations is the list of collection registrations for agentgateway, used to initialize the Collections map.
 	registrations []CollectionRegistration
+
+	// pendingCacheSnapshot is the XDS cache snapshot loaded at startup, to be restored once caches are synced.
+	pendingCacheSnapshot *model.XdsCacheSnapshot
+
+	// pushTracer records config events and the pushes they cause, when push tracing is enabled.
+	pushTracer *pushTracer
 }
 
 // NewDiscoveryServer creates DiscoveryServer that sources data from Pilot's internal mesh data structures
@@ -156,8 +197,10 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		pushChannel:         make(chan *model.PushRequest, 10),
 		pushQueue:           NewPushQueue(),
 		debugHandlers:       map[string]string{},
+		impactPreviews:      make(chan struct{}, 1),
 		adsClients:          map[string]*Connection{},
 		krtDebugger:         debugger,
+		pushTracer:          newPushTracer(),
 		DebounceOptions: DebounceOptions{
 			DebounceAfter:     features.DebounceAfter,
 			debounceMax:       features.DebounceMax,
@@ -172,7 +215,13 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		out.ClusterAliases[cluster.ID(alias)] = cluster.ID(clusterAliases[alias])
 	}
 
+	if features.EnableAdaptiveDebounce {
+		out.DebounceOptions.adaptive = newAdaptiveDebounce(features.DebounceAfter, features.DebounceAfterMin,
+			features.DebounceAfterMax, out.pushQueue.Pending, out.adsClientCount)
+	}
+
 	out.initJwksResolver()
+	out.initXdsCacheSnapshot()
 
 	return out
 }
@@ -221,6 +270,7 @@ var processStartTime = time.Now()
 // CachesSynced is called when caches have been synced so that server can accept connections.
 func (s *DiscoveryServer) CachesSynced() {
 	log.Infof("All caches have been synced up in %v, marking server ready", time.Since(s.DiscoveryStartTime))
+	s.restoreXdsCache()
 	s.serverReady.Store(true)
 }
 
@@ -241,6 +291,9 @@ func (s *DiscoveryServer) Start(stopCh <-chan struct{}) {
 	go s.periodicRefreshMetrics(stopCh)
 	go s.sendPushes(stopCh)
 	go s.Cache.Run(stopCh)
+	if features.XDSCacheSnapshotPath != "" {
+		go s.persistXdsCache(features.XDSCacheSnapshotPath, features.XDSCacheSnapshotInterval, stopCh)
+	}
 
 	if features.EnableAgentgateway {
 		for _, reg := range s.registrations {
@@ -293,16 +346,30 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 		// Push the previous push Envoy metrics.
 		envoyfilter.RecordMetrics()
 	}
+	ctx := context.Background()
+	if features.EnablePushTracing {
+		var span trace.Span
+		ctx, span = s.pushTracer.startPush(req)
+		defer span.End()
+	}
 	// PushContext is reset after a config change. Previous status is
 	// saved.
 	t0 := time.Now()
 	versionLocal := s.NextVersion()
+	_, initSpan := tracing.Start(ctx, "init_push_context")
 	push := s.initPushContext(req, oldPushContext, versionLocal)
+	initSpan.End()
 	initContextTime := time.Since(t0)
 	log.Debugf("InitContext %v for push took %s", versionLocal, initContextTime)
 	pushContextInitTime.Record(initContextTime.Seconds())
+	if s.DebounceOptions.adaptive != nil {
+		s.DebounceOptions.adaptive.observeInitContext(initContextTime)
+	}
 
 	req.Push = push
+	if features.EnablePushTracing {
+		s.pushTracer.recordPush(req, initContextTime)
+	}
 	s.AdsPushAll(req)
 }
 
@@ -334,6 +401,9 @@ func (s *DiscoveryServer) ConfigUpdate(req *model.PushRequest) {
 	}
 	inboundConfigUpdates.Increment()
 	s.InboundUpdates.Inc()
+	if features.EnablePushTracing {
+		s.pushTracer.traceConfigUpdate(req)
+	}
 	if pushLog.DebugEnabled() && !model.OnlyHasConfigsOfKind(req.ConfigsUpdated, kind.Endpoints) {
 		configs := slices.Sort(slices.Map(req.ConfigsUpdated.UnsortedList(), model.ConfigKey.String))
 		reasons := maps.Keys(req.Reason)
@@ -356,6 +426,8 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 	var timeChan <-chan time.Time
 	var startDebounce time.Time
 	var lastConfigUpdateTime time.Time
+	// quietPeriod is chosen once per debounce window, when its first event arrives.
+	var quietPeriod time.Duration
 
 	pushCounter := 0
 	debouncedEvents := 0
@@ -377,7 +449,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 		eventDelay := time.Since(startDebounce)
 		quietTime := time.Since(lastConfigUpdateTime)
 		// it has been too long or quiet enough
-		if eventDelay >= opts.debounceMax || quietTime >= opts.DebounceAfter {
+		if eventDelay >= opts.debounceMax || quietTime >= quietPeriod {
 			if req != nil {
 				pushCounter++
 				if req.ConfigsUpdated == nil {
@@ -395,7 +467,7 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 				debouncedEvents = 0
 			}
 		} else {
-			timeChan = time.After(opts.DebounceAfter - quietTime)
+			timeChan = time.After(quietPeriod - quietTime)
 		}
 	}
 
@@ -419,8 +491,12 @@ func debounce(ch chan *model.PushRequest, stopCh <-chan struct{}, opts DebounceO
 			}
 
 			lastConfigUpdateTime = time.Now()
+			if opts.adaptive != nil {
+				opts.adaptive.observeEvent(lastConfigUpdateTime)
+			}
 			if debouncedEvents == 0 {
-				timeChan = time.After(opts.DebounceAfter)
+				quietPeriod = opts.quietPeriod()
+				timeChan = time.After(quietPeriod)
 				startDebounce = lastConfigUpdateTime
 			}
 			debouncedEvents++
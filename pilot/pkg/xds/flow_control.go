@@ -0,0 +1,166 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// maxTrackedUnacked bounds the number of un-ACKed responses remembered per type, for proxies that stop responding.
+const maxTrackedUnacked = 100
+
+// FlowControlMetadataKey is the node metadata key under which the flow control state of a lagging proxy is reported
+// in the sync status sent to istioctl.
+const FlowControlMetadataKey = "PUSH_FLOW_CONTROL"
+
+// flowControl tracks the responses sent on a connection that the proxy has not yet ACKed or NACKed, and how long the
+// proxy takes to respond to them. When the proxy has too many un-ACKed responses of a single type, it is lagging:
+// instead of sending more pushes it cannot keep up with, they are merged into a single pending push, which is sent
+// once the proxy catches up.
+// All methods are thread safe.
+type flowControl struct {
+	mu    sync.Mutex
+	types map[string]*typeFlowControl
+	// pending is the push coalesced while the proxy was lagging, if any.
+	pending *model.PushRequest
+	// coalesced is the number of pushes merged into pending.
+	coalesced int
+}
+
+type typeFlowControl struct {
+	// unacked are the responses sent and not yet ACKed or NACKed, oldest first.
+	unacked []sentResponse
+	// ackLatency is the moving average of the time between sending a response and receiving its ACK or NACK.
+	ackLatency time.Duration
+}
+
+type sentResponse struct {
+	nonce string
+	sent  time.Time
+}
+
+func newFlowControl() *flowControl {
+	return &flowControl{types: map[string]*typeFlowControl{}}
+}
+
+// sent records that a response with the given nonce was sent.
+func (f *flowControl) sent(typeURL, nonce string, now time.Time) {
+	if !features.EnablePushFlowControl {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.types[typeURL]
+	if t == nil {
+		t = &typeFlowControl{}
+		f.types[typeURL] = t
+	}
+	if len(t.unacked) >= maxTrackedUnacked {
+		t.unacked = t.unacked[1:]
+	}
+	t.unacked = append(t.unacked, sentResponse{nonce: nonce, sent: now})
+}
+
+// received records a request from the proxy. A request carrying the nonce of a sent response ACKs or NACKs it, along
+// with all responses of the same type sent before it. If this lets a lagging proxy catch up, the coalesced push is
+// returned and must be queued by the caller.
+func (f *flowControl) received(typeURL, nonce string, now time.Time) *model.PushRequest {
+	if nonce == "" || !features.EnablePushFlowControl {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.types[typeURL]
+	if t == nil {
+		return nil
+	}
+	for i, r := range t.unacked {
+		if r.nonce != nonce {
+			continue
+		}
+		latency := now.Sub(r.sent)
+		t.ackLatency = movingAverage(t.ackLatency, latency)
+		t.unacked = t.unacked[i+1:]
+		recordAckLatency(typeURL, latency)
+		break
+	}
+	if f.pending == nil || f.laggingLocked() {
+		return nil
+	}
+	pending := f.pending
+	f.pending = nil
+	f.coalesced = 0
+	return pending
+}
+
+// coalesce merges req into the pending push if the proxy is lagging, and reports whether it did. A coalesced push
+// must not be sent.
+func (f *flowControl) coalesce(req *model.PushRequest) bool {
+	if !features.EnablePushFlowControl {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.laggingLocked() {
+		return false
+	}
+	// Push requests are shared by all connections, so they must not be modified.
+	f.pending = f.pending.CopyMerge(req)
+	f.coalesced++
+	pushCoalesced.Increment()
+	return true
+}
+
+func (f *flowControl) laggingLocked() bool {
+	for _, t := range f.types {
+		if len(t.unacked) >= features.PushFlowControlMaxUnacked {
+			return true
+		}
+	}
+	return false
+}
+
+// FlowControlStatus is the flow control state of a connection, for a single type.
+type FlowControlStatus struct {
+	// Unacked is the number of responses sent and not yet ACKed or NACKed.
+	Unacked int `json:"unacked,omitempty"`
+	// AckLatency is the moving average of the time the proxy takes to ACK or NACK a response.
+	AckLatency float64 `json:"ackLatencySeconds,omitempty"`
+	// Slow is set if the proxy is lagging behind for this type.
+	Slow bool `json:"slow,omitempty"`
+}
+
+// status returns the state for each type, and the number of pushes currently coalesced.
+func (f *flowControl) status(now time.Time) (map[string]FlowControlStatus, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res := make(map[string]FlowControlStatus, len(f.types))
+	for typeURL, t := range f.types {
+		slow := len(t.unacked) >= features.PushFlowControlMaxUnacked || t.ackLatency >= features.PushFlowControlSlowAck
+		if len(t.unacked) > 0 && now.Sub(t.unacked[0].sent) >= features.PushFlowControlSlowAck {
+			slow = true
+		}
+		res[typeURL] = FlowControlStatus{
+			Unacked:    len(t.unacked),
+			AckLatency: t.ackLatency.Seconds(),
+			Slow:       slow,
+		}
+	}
+	return res, f.coalesced
+}
@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestFlowControl(t *testing.T) {
+	test.SetForTest(t, &features.EnablePushFlowControl, true)
+	test.SetForTest(t, &features.PushFlowControlMaxUnacked, 2)
+	test.SetForTest(t, &features.PushFlowControlSlowAck, time.Second)
+	now := time.Now()
+	f := newFlowControl()
+
+	f.sent(v3.ClusterType, "a", now)
+	assert.Equal(t, f.coalesce(&model.PushRequest{}), false)
+	f.sent(v3.ClusterType, "b", now)
+	f.sent(v3.ListenerType, "c", now)
+
+	// Two un-ACKed clusters, pushes are coalesced
+	svc := func(name string) *model.PushRequest {
+		return &model.PushRequest{ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.ServiceEntry, Name: name})}
+	}
+	first := svc("a")
+	assert.Equal(t, f.coalesce(first), true)
+	assert.Equal(t, f.coalesce(svc("b")), true)
+	assert.Equal(t, len(first.ConfigsUpdated), 1)
+	status, coalesced := f.status(now)
+	assert.Equal(t, coalesced, 2)
+	assert.Equal(t, status[v3.ClusterType], FlowControlStatus{Unacked: 2, Slow: true})
+	assert.Equal(t, status[v3.ListenerType], FlowControlStatus{Unacked: 1})
+
+	// Unknown nonces are ignored
+	assert.Equal(t, f.received(v3.ClusterType, "c", now) == nil, true)
+
+	// Responding to the newest nonce also covers the older ones, and releases the coalesced push
+	pending := f.received(v3.ClusterType, "b", now.Add(2*time.Second))
+	assert.Equal(t, len(pending.ConfigsUpdated), 2)
+	status, coalesced = f.status(now.Add(2 * time.Second))
+	assert.Equal(t, coalesced, 0)
+	assert.Equal(t, status[v3.ClusterType], FlowControlStatus{AckLatency: 2, Slow: true})
+	// The listener response has now been waiting for longer than the slow threshold
+	assert.Equal(t, status[v3.ListenerType], FlowControlStatus{Unacked: 1, Slow: true})
+	assert.Equal(t, f.coalesce(svc("c")), false)
+
+	t.Run("disabled", func(t *testing.T) {
+		test.SetForTest(t, &features.EnablePushFlowControl, false)
+		f := newFlowControl()
+		f.sent(v3.ClusterType, "a", now)
+		// Nothing is tracked, so no ACK latency is recorded either
+		assert.Equal(t, f.received(v3.ClusterType, "a", now) == nil, true)
+		status, _ := f.status(now)
+		assert.Equal(t, len(status), 0)
+	})
+}
@@ -0,0 +1,310 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
+)
+
+// maxImpactRequestBytes bounds the size of the configs accepted by /debug/config_impact.
+const maxImpactRequestBytes = 1024 * 1024
+
+// impactTypes are the resource types compared by a config impact preview.
+var impactTypes = []string{v3.ListenerType, v3.ClusterType, v3.RouteType}
+
+// ConfigImpact describes how a set of proposed configs would change the configuration of the connected proxies.
+type ConfigImpact struct {
+	// Configs are the proposed configs.
+	Configs []string `json:"configs"`
+	// Proxies are the proxies whose configuration would change.
+	Proxies []ProxyImpact `json:"proxies"`
+	// Unaffected is the number of connected proxies whose configuration would not change.
+	Unaffected int `json:"unaffected"`
+}
+
+// ProxyImpact describes how the configuration of a single proxy would change.
+type ProxyImpact struct {
+	ID        string          `json:"id"`
+	Listeners *ResourceImpact `json:"listeners,omitempty"`
+	Clusters  *ResourceImpact `json:"clusters,omitempty"`
+	Routes    *ResourceImpact `json:"routes,omitempty"`
+}
+
+// ResourceImpact lists the names of the resources of a single type that would be added, removed or changed.
+type ResourceImpact struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+func (r *ResourceImpact) empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// ConfigImpactPreview previews the impact of the configs in the request body, without applying them.
+// Configs without a namespace are placed in the namespace query parameter, or "default". A single preview is computed
+// at a time, concurrent requests are rejected.
+func (s *DiscoveryServer) ConfigImpactPreview(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = w.Write([]byte("configs must be sent in the body of a POST request\n"))
+		return
+	}
+	select {
+	case s.impactPreviews <- struct{}{}:
+		defer func() { <-s.impactPreviews }()
+	default:
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("another config impact preview is in progress\n"))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxImpactRequestBytes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "failed to read request: %v\n", err)
+		return
+	}
+	configs, _, err := crd.ParseInputs(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "invalid configs: %v\n", err)
+		return
+	}
+	if len(configs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("no Istio configs found in request\n"))
+		return
+	}
+	namespace := req.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	for i := range configs {
+		if configs[i].Namespace == "" {
+			configs[i].Namespace = namespace
+		}
+	}
+	impact, err := s.configImpact(configs, s.debugCallerNamespace(req))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, "%v\n", err)
+		return
+	}
+	writeJSON(w, impact, req)
+}
+
+// configImpact computes the impact of the proposed configs on the connected proxies. It builds a shadow push context
+// with the configs overlaid on the live config store, and compares the listeners, clusters and routes generated for
+// each affected proxy against those generated from the current push context. If namespace is set, only proxies in
+// that namespace are considered.
+func (s *DiscoveryServer) configImpact(configs []config.Config, namespace string) (*ConfigImpact, error) {
+	store, err := model.NewOverlayConfigStore(s.Env.ConfigStore, configs)
+	if err != nil {
+		return nil, err
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	shadowEnv := model.NewShadowEnvironment(s.Env, store, stop)
+	push := s.globalPushContext()
+	shadow := model.NewPushContext()
+	shadow.InitContext(shadowEnv, nil, nil)
+	shadowEnv.SetPushContext(shadow)
+
+	updated := sets.New[model.ConfigKey]()
+	impact := &ConfigImpact{Proxies: []ProxyImpact{}}
+	for _, cfg := range configs {
+		k, ok := gvk.ToKind(cfg.GroupVersionKind)
+		if !ok {
+			return nil, fmt.Errorf("unknown type %v", cfg.GroupVersionKind)
+		}
+		key := model.ConfigKey{Kind: k, Name: cfg.Name, Namespace: cfg.Namespace}
+		updated.Insert(key)
+		impact.Configs = append(impact.Configs, key.String())
+	}
+
+	// The XDS cache is keyed by the configs it depends on, not by their content, so the shadow resources must never be
+	// read from or written to it.
+	cg := core.NewConfigGenerator(model.DisabledCache{})
+	generators := map[string]model.XdsResourceGenerator{
+		v3.ListenerType: &LdsGenerator{ConfigGenerator: cg},
+		v3.ClusterType:  &CdsGenerator{ConfigGenerator: cg},
+		v3.RouteType:    &RdsGenerator{ConfigGenerator: cg},
+	}
+	for _, con := range s.SortedClients() {
+		proxy := con.proxy
+		if namespace != "" && proxy.ConfigNamespace != namespace {
+			continue
+		}
+		if proxy.GetWatchedResource(v3.ListenerType) == nil && proxy.GetWatchedResource(v3.ClusterType) == nil {
+			// Not an Envoy based proxy, the generators compared here do not apply.
+			continue
+		}
+		// Mirror a real push: the proxy state is computed for the new push context before checking whether the
+		// proxy depends on the updated configs, so that configs which are new to the proxy are taken into account.
+		req := &model.PushRequest{
+			Push:           shadow,
+			ConfigsUpdated: updated,
+			Start:          time.Now(),
+			Reason:         model.NewReasonStats(model.DebugTrigger),
+		}
+		shadowProxy := cloneProxy(proxy)
+		s.computeProxyState(shadowProxy, req)
+		if _, needsPush := s.ProxyNeedsPush(shadowProxy, req); !needsPush {
+			impact.Unaffected++
+			continue
+		}
+		before := s.generateForImpact(cloneProxy(proxy), push, generators)
+		after := s.generateForImpact(shadowProxy, shadow, generators)
+		pi := ProxyImpact{ID: proxy.ID}
+		changed := false
+		for _, typ := range impactTypes {
+			ri := diffResources(before[typ], after[typ])
+			if ri.empty() {
+				continue
+			}
+			changed = true
+			switch typ {
+			case v3.ListenerType:
+				pi.Listeners = ri
+			case v3.ClusterType:
+				pi.Clusters = ri
+			case v3.RouteType:
+				pi.Routes = ri
+			}
+		}
+		if !changed {
+			impact.Unaffected++
+			continue
+		}
+		impact.Proxies = append(impact.Proxies, pi)
+	}
+	return impact, nil
+}
+
+// generateForImpact generates the resources of each impact type for proxy, as of the given push context. The proxy
+// state is recomputed for the push context, so proxy must not be shared with a connection.
+func (s *DiscoveryServer) generateForImpact(proxy *model.Proxy, push *model.PushContext,
+	generators map[string]model.XdsResourceGenerator,
+) map[string]map[string]proto.Message {
+	req := &model.PushRequest{
+		Forced: true,
+		Push:   push,
+		Start:  time.Now(),
+		Reason: model.NewReasonStats(model.DebugTrigger),
+	}
+	s.computeProxyState(proxy, req)
+
+	out := map[string]map[string]proto.Message{}
+	var listeners []*listener.Listener
+	for _, typ := range impactTypes {
+		w := proxy.GetWatchedResource(typ)
+		if typ == v3.RouteType {
+			// Routes are requested by name. Include the routes referenced by the generated listeners, so that
+			// routes that would only be requested once the new listeners are applied are compared as well.
+			names := sets.New[string]()
+			if w != nil {
+				names = w.ResourceNames.Copy()
+			}
+			for _, l := range listeners {
+				names.InsertAll(routeNames(l)...)
+			}
+			w = &model.WatchedResource{TypeUrl: typ, ResourceNames: names}
+		}
+		if w == nil {
+			continue
+		}
+		res, _, err := generators[typ].Generate(proxy, w, req)
+		if err != nil {
+			log.Warnf("config impact: failed to generate %s for %s: %v", v3.GetShortType(typ), proxy.ID, err)
+			continue
+		}
+		out[typ] = map[string]proto.Message{}
+		for _, r := range res {
+			msg, err := r.Resource.UnmarshalNew()
+			if err != nil {
+				log.Warnf("config impact: failed to unmarshal %s %s: %v", v3.GetShortType(typ), r.Name, err)
+				continue
+			}
+			out[typ][r.Name] = msg
+			if l, ok := msg.(*listener.Listener); ok {
+				listeners = append(listeners, l)
+			}
+		}
+	}
+	return out
+}
+
+// routeNames returns the names of the RDS routes referenced by the listener.
+func routeNames(l *listener.Listener) []string {
+	var names []string
+	chains := l.GetFilterChains()
+	if l.GetDefaultFilterChain() != nil {
+		chains = append(slices.Clone(chains), l.GetDefaultFilterChain())
+	}
+	for _, fc := range chains {
+		for _, f := range fc.GetFilters() {
+			if f.GetName() != wellknown.HTTPConnectionManager || f.GetTypedConfig() == nil {
+				continue
+			}
+			h := &hcm.HttpConnectionManager{}
+			if err := f.GetTypedConfig().UnmarshalTo(h); err != nil {
+				continue
+			}
+			if name := h.GetRds().GetRouteConfigName(); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func diffResources(before, after map[string]proto.Message) *ResourceImpact {
+	ri := &ResourceImpact{}
+	for name, b := range before {
+		a, f := after[name]
+		if !f {
+			ri.Removed = append(ri.Removed, name)
+		} else if !proto.Equal(a, b) {
+			ri.Changed = append(ri.Changed, name)
+		}
+	}
+	for name := range after {
+		if _, f := before[name]; !f {
+			ri.Added = append(ri.Added, name)
+		}
+	}
+	slices.Sort(ri.Added)
+	slices.Sort(ri.Removed)
+	slices.Sort(ri.Changed)
+	return ri
+}
@@ -0,0 +1,38 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestConfigImpactPreviewBusy(t *testing.T) {
+	s := &DiscoveryServer{impactPreviews: make(chan struct{}, 1)}
+	s.impactPreviews <- struct{}{}
+	rr := httptest.NewRecorder()
+	s.ConfigImpactPreview(rr, httptest.NewRequest(http.MethodPost, "/debug/config_impact", strings.NewReader("")))
+	assert.Equal(t, rr.Code, http.StatusTooManyRequests)
+
+	<-s.impactPreviews
+	rr = httptest.NewRecorder()
+	s.ConfigImpactPreview(rr, httptest.NewRequest(http.MethodPost, "/debug/config_impact", strings.NewReader("")))
+	assert.Equal(t, rr.Code, http.StatusBadRequest)
+	assert.Equal(t, len(s.impactPreviews), 0)
+}
@@ -15,6 +15,7 @@
 package xds
 
 import (
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -28,7 +29,7 @@ type LdsGenerator struct {
 	ConfigGenerator core.ConfigGenerator
 }
 
-var _ model.XdsResourceGenerator = &LdsGenerator{}
+var _ model.XdsDeltaResourceGenerator = &LdsGenerator{}
 
 // Map of all configs that do not impact LDS
 var skippedLdsConfigs = map[model.NodeType]sets.Set[kind.Kind]{
@@ -88,6 +89,28 @@ func (l LdsGenerator) Generate(proxy *model.Proxy, _ *model.WatchedResource, req
 		return nil, model.DefaultXdsLogDetails, nil
 	}
 	listeners := l.ConfigGenerator.BuildListeners(proxy, req.Push)
+	return listenerResources(listeners), model.DefaultXdsLogDetails, nil
+}
+
+// GenerateDeltas for LDS only sends the listeners of the ports impacted by ServiceEntry, VirtualService and
+// DestinationRule changes, and falls back to all listeners for other changes.
+func (l LdsGenerator) GenerateDeltas(proxy *model.Proxy, req *model.PushRequest,
+	w *model.WatchedResource,
+) (model.Resources, model.DeletedResources, model.XdsLogDetails, bool, error) {
+	if !ldsNeedsPush(proxy, req) {
+		return nil, nil, model.DefaultXdsLogDetails, false, nil
+	}
+	req = filterConfigsUpdated(req, func(key model.ConfigKey) bool {
+		return skippedLdsConfigs[proxy.Type].Contains(key.Kind)
+	})
+	listeners, removed, usedDelta := l.ConfigGenerator.BuildDeltaListeners(proxy, req, w)
+	if usedDelta && len(listeners) == 0 && len(removed) == 0 {
+		return nil, nil, model.DefaultXdsLogDetails, true, nil
+	}
+	return listenerResources(listeners), removed, model.DefaultXdsLogDetails, usedDelta, nil
+}
+
+func listenerResources(listeners []*listener.Listener) model.Resources {
 	resources := model.Resources{}
 	for _, c := range listeners {
 		resources = append(resources, &discovery.Resource{
@@ -95,5 +118,21 @@ func (l LdsGenerator) Generate(proxy *model.Proxy, _ *model.WatchedResource, req
 			Resource: protoconv.MessageToAny(c),
 		})
 	}
-	return resources, model.DefaultXdsLogDetails, nil
+	return resources
+}
+
+// filterConfigsUpdated returns a copy of req without the updated configs matching skip, if any.
+func filterConfigsUpdated(req *model.PushRequest, skip func(key model.ConfigKey) bool) *model.PushRequest {
+	relevant := make(sets.Set[model.ConfigKey], len(req.ConfigsUpdated))
+	for key := range req.ConfigsUpdated {
+		if !skip(key) {
+			relevant.Insert(key)
+		}
+	}
+	if len(relevant) == len(req.ConfigsUpdated) {
+		return req
+	}
+	filtered := *req
+	filtered.ConfigsUpdated = relevant
+	return &filtered
 }
@@ -26,8 +26,10 @@ import (
 )
 
 var (
-	typeTag    = monitoring.CreateLabel("type")
-	versionTag = monitoring.CreateLabel("version")
+	typeTag     = monitoring.CreateLabel("type")
+	versionTag  = monitoring.CreateLabel("version")
+	priorityTag = monitoring.CreateLabel("priority")
+	reasonTag   = monitoring.CreateLabel("reason")
 
 	monServices = monitoring.NewGauge(
 		"pilot_services",
@@ -60,6 +62,16 @@ var (
 		[]float64{.01, .1, 1, 3, 5, 10, 20, 30},
 	)
 
+	debounceQuietTime = monitoring.NewGauge(
+		"pilot_debounce_quiet_time",
+		"Quiet period in seconds most recently chosen by adaptive debouncing.",
+	)
+
+	debounceDecisions = monitoring.NewSum(
+		"pilot_debounce_decisions",
+		"Total number of debounce windows whose quiet period was chosen by adaptive debouncing, labeled by the input that determined it.",
+	)
+
 	pushContextInitTime = monitoring.NewDistribution(
 		"pilot_pushcontext_init_seconds",
 		"Total time in seconds Pilot takes to init pushContext.",
@@ -78,6 +90,33 @@ var (
 		[]float64{.1, .5, 1, 3, 5, 10, 20, 30},
 	)
 
+	pushQueueDepth = monitoring.NewGauge(
+		"pilot_push_queue_depth",
+		"Number of proxies waiting in the push queue, labeled by push priority.",
+	)
+
+	pushQueueWaitTime = monitoring.NewDistribution(
+		"pilot_push_queue_wait_time",
+		"Time in seconds a proxy waits in the push queue before being dequeued, labeled by push priority.",
+		[]float64{.01, .1, .5, 1, 3, 5, 10, 20, 30},
+	)
+
+	ackLatency = monitoring.NewDistribution(
+		"pilot_xds_ack_latency",
+		"Time in seconds between sending an xds response and receiving its ACK or NACK, labeled by type.",
+		[]float64{.01, .1, .5, 1, 3, 5, 10, 20, 30},
+	)
+
+	pushCoalesced = monitoring.NewSum(
+		"pilot_xds_push_coalesced",
+		"Total number of pushes to lagging proxies that were coalesced by push flow control.",
+	)
+
+	shardRedirects = monitoring.NewSum(
+		"pilot_xds_shard_redirects",
+		"Total number of new xDS connections redirected to the Istiod replica owning their shard.",
+	)
+
 	pushTriggers = monitoring.NewSum(
 		"pilot_push_triggers",
 		"Total number of times a push was triggered, labeled by reason for the push.",
@@ -99,6 +138,14 @@ var (
 		"Total number of failures to fetch SDS key and certificate.",
 	)
 
+	xdsCacheSnapshotEntries = monitoring.NewSum(
+		"pilot_xds_cache_snapshot_entries",
+		"Total number of xds cache entries loaded from the on-disk snapshot, labeled by whether they were restored or discarded.",
+	)
+
+	xdsCacheSnapshotRestored  = xdsCacheSnapshotEntries.With(typeTag.Value("restored"))
+	xdsCacheSnapshotDiscarded = xdsCacheSnapshotEntries.With(typeTag.Value("discarded"))
+
 	inboundConfigUpdates  = inboundUpdates.With(typeTag.Value("config"))
 	inboundEDSUpdates     = inboundUpdates.With(typeTag.Value("eds"))
 	inboundServiceUpdates = inboundUpdates.With(typeTag.Value("svc"))
@@ -149,6 +196,36 @@ func recordPushTriggers(reasons model.ReasonStats) {
 	}
 }
 
+// pushQueueDepthMetric and pushQueueWaitMetric are precomputed per priority tier, as they are recorded on every push.
+var (
+	pushQueueDepthMetric [numPushPriorities]monitoring.Metric
+	pushQueueWaitMetric  [numPushPriorities]monitoring.Metric
+)
+
+func init() {
+	for p := PushPriority(0); p < numPushPriorities; p++ {
+		pushQueueDepthMetric[p] = pushQueueDepth.With(priorityTag.Value(p.String()))
+		pushQueueWaitMetric[p] = pushQueueWaitTime.With(priorityTag.Value(p.String()))
+	}
+}
+
+func recordPushQueueDepth(p PushPriority, depth int) {
+	pushQueueDepthMetric[p].RecordInt(int64(depth))
+}
+
+func recordPushQueueWait(p PushPriority, wait time.Duration) {
+	pushQueueWaitMetric[p].Record(wait.Seconds())
+}
+
+func recordAdaptiveDebounce(quiet time.Duration, reason string) {
+	debounceQuietTime.Record(quiet.Seconds())
+	debounceDecisions.With(reasonTag.Value(reason)).Increment()
+}
+
+func recordAckLatency(typeURL string, latency time.Duration) {
+	ackLatency.With(typeTag.Value(v3.GetMetricType(typeURL))).Record(latency.Seconds())
+}
+
 func isUnexpectedError(err error) bool {
 	s, ok := status.FromError(err)
 	// Unavailable or canceled code will be sent when a connection is closing down. This is very normal,
@@ -15,6 +15,8 @@
 package xds
 
 import (
+	"time"
+
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	mesh "istio.io/api/mesh/v1alpha1"
@@ -22,7 +24,11 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	tb "istio.io/istio/pilot/pkg/trustbundle"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/server/ca/audit"
 )
 
 // PcdsGenerator generates proxy configuration for proxies to consume
@@ -67,3 +73,46 @@ func (e *PcdsGenerator) Generate(proxy *model.Proxy, w *model.WatchedResource, r
 	}
 	return model.Resources{&discovery.Resource{Resource: protoconv.MessageToAny(pc)}}, model.DefaultXdsLogDetails, nil
 }
+
+// TrustBundleSync returns whether the connected proxies acknowledged a trust bundle pushed after since. Proxies not
+// subscribed to PCDS, such as ztunnel and proxyless gRPC, receive the trust bundle with their certificates, so they are
+// synced once this istiod issued them a certificate after since, and awaiting one otherwise.
+func (s *DiscoveryServer) TrustBundleSync(since time.Time) ca.ProxySync {
+	res := ca.ProxySync{}
+	issued := sets.New(s.IssuedSince(since)...)
+	for _, con := range s.SortedClients() {
+		wr, f := con.proxy.DeepCloneWatchedResources()[v3.ProxyConfigType]
+		switch {
+		case !f:
+			pod := proxyPod(con.proxy)
+			switch {
+			case pod == "":
+				// Without a pod, the issuances to the proxy can not be found.
+				res.AwaitingCertificate = append(res.AwaitingCertificate, con.ID())
+			case issued.Contains(pod):
+				res.Synced++
+			default:
+				res.AwaitingCertificate = append(res.AwaitingCertificate, pod)
+			}
+		case wr.NonceSent != "" && wr.NonceAcked == wr.NonceSent && wr.LastSendTime.After(since):
+			res.Synced++
+		default:
+			res.Pending = append(res.Pending, con.ID())
+		}
+	}
+	return res
+}
+
+// IssuedSince returns the pods the Istio CA of this istiod issued certificates to after since.
+func (s *DiscoveryServer) IssuedSince(since time.Time) []string {
+	if s.ListIssuedCertificates == nil {
+		return nil
+	}
+	pods := sets.New[string]()
+	for _, r := range s.ListIssuedCertificates(audit.Filter{Since: since}) {
+		if r.CallerPod != "" {
+			pods.Insert(r.CallerPod)
+		}
+	}
+	return sets.SortedList(pods)
+}
@@ -0,0 +1,125 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync/atomic"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// PushPriorityKey is the pod annotation, or namespace label, used to set the push priority of a proxy.
+// The pod annotation takes precedence over the namespace label.
+const PushPriorityKey = "istio.io/push-priority"
+
+// PushPriorityAnnotation is the definition of the PushPriorityKey pod annotation, registered along with the
+// istio.io/api annotations.
+var PushPriorityAnnotation = annotation.Instance{
+	Name: PushPriorityKey,
+	Description: "The priority of the proxy of the pod in the xDS push queue: high, normal or low. Proxies with a " +
+		"higher priority are pushed first. Overrides the istio.io/push-priority label of the namespace, and is only " +
+		"read when the proxy connects.",
+	FeatureStatus: annotation.Alpha,
+	Resources:     []annotation.ResourceTypes{annotation.Pod},
+}
+
+// PushPriority is the priority tier of a connection in the PushQueue. Tiers are drained in order,
+// so lower values are pushed first.
+type PushPriority int
+
+const (
+	// PushPriorityHigh is used for gateways and other critical workloads.
+	PushPriorityHigh PushPriority = iota
+	// PushPriorityNormal is the default priority.
+	PushPriorityNormal
+	// PushPriorityLow is used for workloads that can tolerate delayed configuration, such as batch jobs.
+	PushPriorityLow
+
+	numPushPriorities
+)
+
+func (p PushPriority) String() string {
+	switch p {
+	case PushPriorityHigh:
+		return "high"
+	case PushPriorityNormal:
+		return "normal"
+	case PushPriorityLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
+// parsePushPriority parses the value of PushPriorityKey.
+func parsePushPriority(s string) (PushPriority, bool) {
+	switch s {
+	case "high":
+		return PushPriorityHigh, true
+	case "normal":
+		return PushPriorityNormal, true
+	case "low":
+		return PushPriorityLow, true
+	default:
+		return PushPriorityNormal, false
+	}
+}
+
+// PushPriority returns the priority tier of the connection in the push queue.
+func (conn *Connection) PushPriority() PushPriority {
+	return PushPriority(atomic.LoadInt32(&conn.pushPriority))
+}
+
+func (conn *Connection) setPushPriority(p PushPriority) {
+	atomic.StoreInt32(&conn.pushPriority, int32(p))
+}
+
+// updatePushPriority recomputes the push priority of a connection, as its proxy labels and namespace may have changed.
+// The pod annotation is read from the proxy metadata, which is only sent when connecting, so changes to it apply
+// when the proxy reconnects. Changes to the namespace label apply on the next proxy update, or reconnection.
+func (s *DiscoveryServer) updatePushPriority(con *Connection) {
+	if p := s.pushPriority(con.proxy); p != con.PushPriority() {
+		log.Debugf("%s: push priority changed to %v", con.ID(), p)
+		con.setPushPriority(p)
+	}
+}
+
+// pushPriority computes the push priority of a proxy. An explicit pod annotation takes precedence,
+// followed by the namespace label. Otherwise, gateways and waypoints are pushed with high priority.
+func (s *DiscoveryServer) pushPriority(proxy *model.Proxy) PushPriority {
+	if !features.EnablePushPriority {
+		return PushPriorityNormal
+	}
+	if v, f := proxy.Metadata.Annotations[PushPriorityKey]; f {
+		if p, ok := parsePushPriority(v); ok {
+			return p
+		}
+		log.Warnf("%s: invalid %s annotation %q", proxy.ID, PushPriorityKey, v)
+	}
+	if s.NamespaceLabels != nil {
+		if v, f := s.NamespaceLabels(proxy.ConfigNamespace)[PushPriorityKey]; f {
+			if p, ok := parsePushPriority(v); ok {
+				return p
+			}
+			log.Warnf("%s: invalid %s namespace label %q", proxy.ID, PushPriorityKey, v)
+		}
+	}
+	if proxy.Type == model.Router || proxy.IsWaypointProxy() {
+		return PushPriorityHigh
+	}
+	return PushPriorityNormal
+}
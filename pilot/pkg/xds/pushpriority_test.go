@@ -0,0 +1,81 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestPushPriority(t *testing.T) {
+	test.SetForTest(t, &features.EnablePushPriority, true)
+	s := &DiscoveryServer{
+		NamespaceLabels: func(ns string) map[string]string {
+			if ns == "batch" {
+				return map[string]string{PushPriorityKey: "low"}
+			}
+			return nil
+		},
+	}
+	proxy := func(typ model.NodeType, ns string, annotations map[string]string) *model.Proxy {
+		return &model.Proxy{
+			Type:            typ,
+			ConfigNamespace: ns,
+			Metadata:        &model.NodeMetadata{Annotations: annotations},
+		}
+	}
+	cases := []struct {
+		name  string
+		proxy *model.Proxy
+		want  PushPriority
+	}{
+		{"sidecar", proxy(model.SidecarProxy, "default", nil), PushPriorityNormal},
+		{"gateway", proxy(model.Router, "default", nil), PushPriorityHigh},
+		{"namespace label", proxy(model.SidecarProxy, "batch", nil), PushPriorityLow},
+		{"annotation", proxy(model.SidecarProxy, "batch", map[string]string{PushPriorityKey: "high"}), PushPriorityHigh},
+		{"invalid annotation", proxy(model.Router, "default", map[string]string{PushPriorityKey: "urgent"}), PushPriorityHigh},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, s.pushPriority(tt.proxy), tt.want)
+		})
+	}
+
+	test.SetForTest(t, &features.EnablePushPriority, false)
+	assert.Equal(t, s.pushPriority(proxy(model.Router, "default", nil)), PushPriorityNormal)
+}
+
+func TestUpdatePushPriority(t *testing.T) {
+	test.SetForTest(t, &features.EnablePushPriority, true)
+	labels := map[string]string{}
+	s := &DiscoveryServer{
+		NamespaceLabels: func(string) map[string]string {
+			return labels
+		},
+	}
+	con := newConnection("", nil)
+	con.proxy = &model.Proxy{Type: model.SidecarProxy, ConfigNamespace: "batch", Metadata: &model.NodeMetadata{}}
+	s.updatePushPriority(con)
+	assert.Equal(t, con.PushPriority(), PushPriorityNormal)
+
+	// The namespace label applies on the next proxy update.
+	labels[PushPriorityKey] = "low"
+	s.updatePushPriority(con)
+	assert.Equal(t, con.PushPriority(), PushPriorityLow)
+}
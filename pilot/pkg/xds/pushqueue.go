@@ -16,7 +16,9 @@ package xds
 
 import (
 	"sync"
+	"time"
 
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 )
 
@@ -27,8 +29,18 @@ type PushQueue struct {
 	// the PushRequest will be merged.
 	pending map[*Connection]*model.PushRequest
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// queues maintains ordering of the queue, with one queue per priority tier.
+	queues [numPushPriorities][]*Connection
+
+	// enqueued stores the time each pending connection was added to the queue.
+	enqueued map[*Connection]time.Time
+
+	// skipped counts, per priority tier, how many times a pending connection in the tier was passed
+	// over in favor of a higher priority one.
+	skipped [numPushPriorities]int
+
+	// starvationLimit is the number of times a tier can be passed over before it is served.
+	starvationLimit int
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
@@ -40,9 +52,11 @@ type PushQueue struct {
 
 func NewPushQueue() *PushQueue {
 	return &PushQueue{
-		pending:    make(map[*Connection]*model.PushRequest),
-		processing: make(map[*Connection]*model.PushRequest),
-		cond:       sync.NewCond(&sync.Mutex{}),
+		pending:         make(map[*Connection]*model.PushRequest),
+		enqueued:        make(map[*Connection]time.Time),
+		processing:      make(map[*Connection]*model.PushRequest),
+		starvationLimit: features.PushPriorityStarvationLimit,
+		cond:            sync.NewCond(&sync.Mutex{}),
 	}
 }
 
@@ -68,31 +82,81 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 	}
 
 	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.push(con)
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
 
+// push adds the connection to the queue for its priority tier. Must be called with the lock held.
+func (p *PushQueue) push(con *Connection) {
+	priority := con.PushPriority()
+	p.queues[priority] = append(p.queues[priority], con)
+	p.enqueued[con] = time.Now()
+	recordPushQueueDepth(priority, len(p.queues[priority]))
+}
+
+// nextTier returns the priority tier to dequeue from next, or false if all tiers are empty.
+// Tiers are drained in priority order, except that a tier which has been passed over
+// starvationLimit times is served first, so that low priority proxies are not starved.
+// Must be called with the lock held.
+func (p *PushQueue) nextTier() (PushPriority, bool) {
+	if p.starvationLimit > 0 {
+		for t := range p.queues {
+			if len(p.queues[t]) > 0 && p.skipped[t] >= p.starvationLimit {
+				return PushPriority(t), true
+			}
+		}
+	}
+	for t := range p.queues {
+		if len(p.queues[t]) > 0 {
+			return PushPriority(t), true
+		}
+	}
+	return 0, false
+}
+
+// len returns the number of queued connections across all tiers. Must be called with the lock held.
+func (p *PushQueue) len() int {
+	n := 0
+	for _, q := range p.queues {
+		n += len(q)
+	}
+	return n
+}
+
 // Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
 func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shutdown bool) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	for p.len() == 0 && !p.shuttingDown {
 		p.cond.Wait()
 	}
 
-	if len(p.queue) == 0 {
+	tier, ok := p.nextTier()
+	if !ok {
 		// We must be shutting down.
 		return nil, nil, true
 	}
 
-	con = p.queue[0]
+	queue := p.queues[tier]
+	con = queue[0]
 	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
 	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
+	queue[0] = nil
+	p.queues[tier] = queue[1:]
+	recordPushQueueDepth(tier, len(p.queues[tier]))
+
+	p.skipped[tier] = 0
+	for t := tier + 1; t < numPushPriorities; t++ {
+		if len(p.queues[t]) > 0 {
+			p.skipped[t]++
+		}
+	}
+
+	recordPushQueueWait(tier, time.Since(p.enqueued[con]))
+	delete(p.enqueued, con)
 
 	request = p.pending[con]
 	delete(p.pending, con)
@@ -113,7 +177,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 	// This means we need to add it back to the queue.
 	if request != nil {
 		p.pending[con] = request
-		p.queue = append(p.queue, con)
+		p.push(con)
 		p.cond.Signal()
 	}
 }
@@ -122,7 +186,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return p.len()
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
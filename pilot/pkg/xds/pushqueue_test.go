@@ -368,3 +368,71 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func TestProxyQueuePriority(t *testing.T) {
+	newConn := func(id string, priority PushPriority) *Connection {
+		conn := newConnection("", nil)
+		conn.SetID(id)
+		conn.setPushPriority(priority)
+		return conn
+	}
+
+	t.Run("higher tiers first", func(t *testing.T) {
+		p := NewPushQueue()
+		defer p.ShutDown()
+		low := newConn("low", PushPriorityLow)
+		normal := newConn("normal", PushPriorityNormal)
+		high := newConn("high", PushPriorityHigh)
+
+		p.Enqueue(low, &model.PushRequest{})
+		p.Enqueue(normal, &model.PushRequest{})
+		p.Enqueue(high, &model.PushRequest{})
+		if got := p.Pending(); got != 3 {
+			t.Fatalf("expected 3 pending, got %d", got)
+		}
+
+		ExpectDequeue(t, p, high)
+		ExpectDequeue(t, p, normal)
+		ExpectDequeue(t, p, low)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("markdone requeues in tier", func(t *testing.T) {
+		p := NewPushQueue()
+		defer p.ShutDown()
+		normal := newConn("normal", PushPriorityNormal)
+		high := newConn("high", PushPriorityHigh)
+
+		p.Enqueue(high, &model.PushRequest{})
+		ExpectDequeue(t, p, high)
+		p.Enqueue(normal, &model.PushRequest{})
+		p.Enqueue(high, &model.PushRequest{})
+		p.MarkDone(high)
+
+		ExpectDequeue(t, p, high)
+		ExpectDequeue(t, p, normal)
+	})
+
+	t.Run("starvation protection", func(t *testing.T) {
+		p := NewPushQueue()
+		defer p.ShutDown()
+		p.starvationLimit = 2
+		low := newConn("low", PushPriorityLow)
+		high := make([]*Connection, 0, 4)
+		for i := 0; i < 4; i++ {
+			high = append(high, newConn("high-"+strconv.Itoa(i), PushPriorityHigh))
+		}
+
+		p.Enqueue(low, &model.PushRequest{})
+		for _, c := range high {
+			p.Enqueue(c, &model.PushRequest{})
+		}
+
+		ExpectDequeue(t, p, high[0])
+		ExpectDequeue(t, p, high[1])
+		// low has been passed over twice, so it is served before the remaining high priority connections
+		ExpectDequeue(t, p, low)
+		ExpectDequeue(t, p, high[2])
+		ExpectDequeue(t, p, high[3])
+	})
+}
@@ -0,0 +1,225 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/tracing"
+)
+
+const (
+	// maxTracedEvents is the number of recent config events kept for /debug/push_trace.
+	maxTracedEvents = 1000
+	// maxTracedPushes is the number of recent pushes kept for /debug/push_trace.
+	maxTracedPushes = 100
+	// maxTracedProxies is the number of proxies recorded for a single push. Further proxies are only counted.
+	maxTracedProxies = 1000
+	// maxTracedConfigs is the number of config keys recorded for a single event.
+	maxTracedConfigs = 20
+)
+
+// PushTraceEvent is a config event that triggered a push.
+type PushTraceEvent struct {
+	TraceID string    `json:"traceId"`
+	Time    time.Time `json:"time"`
+	// Configs are the configs updated by the event. At most maxTracedConfigs are recorded.
+	Configs []string `json:"configs,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// PushTraceProxy is a single proxy push that was part of a traced push.
+type PushTraceProxy struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"durationSeconds"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// PushTrace links a push to the config events that caused it, and the proxies it was sent to.
+type PushTrace struct {
+	TraceID            string    `json:"traceId"`
+	Version            string    `json:"version"`
+	Start              time.Time `json:"start"`
+	InitContextSeconds float64   `json:"initContextSeconds"`
+	// Events are the config events merged into this push. Events that are no longer retained are reported with
+	// their trace ID only.
+	Events []PushTraceEvent `json:"events"`
+	// Proxies are the proxies this push was sent to. At most maxTracedProxies are recorded.
+	Proxies []PushTraceProxy `json:"proxies"`
+	// ProxyCount is the total number of proxies this push was sent to.
+	ProxyCount int `json:"proxyCount"`
+}
+
+// pushTracer records recent config events and the pushes they caused. All operations are thread safe.
+type pushTracer struct {
+	mu         sync.Mutex
+	events     map[trace.TraceID]*PushTraceEvent
+	eventOrder []trace.TraceID
+	pushes     map[trace.TraceID]*PushTrace
+	pushOrder  []trace.TraceID
+}
+
+func newPushTracer() *pushTracer {
+	return &pushTracer{
+		events: map[trace.TraceID]*PushTraceEvent{},
+		pushes: map[trace.TraceID]*PushTrace{},
+	}
+}
+
+// traceConfigUpdate records a config event, and sets it as the cause of the request.
+func (t *pushTracer) traceConfigUpdate(req *model.PushRequest) {
+	configs := slices.Map(req.ConfigsUpdated.UnsortedList(), model.ConfigKey.String)
+	if len(configs) > maxTracedConfigs {
+		configs = slices.Sort(configs)[:maxTracedConfigs]
+	}
+	reasons := slices.Map(maps.Keys(req.Reason), func(r model.TriggerReason) string {
+		return string(r)
+	})
+	_, span := tracing.Start(context.Background(), "config_update")
+	span.SetAttributes(
+		attribute.StringSlice("configs", configs),
+		attribute.StringSlice("reasons", reasons),
+		attribute.Int("config_count", len(req.ConfigsUpdated)),
+	)
+	span.End()
+	sc := tracing.SpanContextOrNew(span)
+	req.Causes = append(req.Causes, sc)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events[sc.TraceID()] = &PushTraceEvent{
+		TraceID: sc.TraceID().String(),
+		Time:    time.Now(),
+		Configs: configs,
+		Reasons: reasons,
+	}
+	t.eventOrder = append(t.eventOrder, sc.TraceID())
+	if len(t.eventOrder) > maxTracedEvents {
+		delete(t.events, t.eventOrder[0])
+		t.eventOrder = t.eventOrder[1:]
+	}
+}
+
+// startPush starts the span for a push, linked to all the config events that caused it.
+func (t *pushTracer) startPush(req *model.PushRequest) (context.Context, trace.Span) {
+	ctx, span := tracing.StartLinked(context.Background(), "push", req.Causes)
+	span.SetAttributes(attribute.Int("cause_count", len(req.Causes)))
+	req.Span = tracing.SpanContextOrNew(span)
+	return ctx, span
+}
+
+// recordPush records a push once its push context is initialized.
+func (t *pushTracer) recordPush(req *model.PushRequest, initContextTime time.Duration) {
+	pt := &PushTrace{
+		TraceID:            req.Span.TraceID().String(),
+		Version:            req.Push.PushVersion,
+		Start:              time.Now(),
+		InitContextSeconds: initContextTime.Seconds(),
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range req.Causes {
+		if ev, f := t.events[c.TraceID()]; f {
+			pt.Events = append(pt.Events, *ev)
+		} else {
+			pt.Events = append(pt.Events, PushTraceEvent{TraceID: c.TraceID().String()})
+		}
+	}
+	t.pushes[req.Span.TraceID()] = pt
+	t.pushOrder = append(t.pushOrder, req.Span.TraceID())
+	if len(t.pushOrder) > maxTracedPushes {
+		delete(t.pushes, t.pushOrder[0])
+		t.pushOrder = t.pushOrder[1:]
+	}
+}
+
+// startProxyPush starts the span for a push to a single proxy, as a child of the push span. The returned
+// function must be called once the push to the proxy completes.
+func (t *pushTracer) startProxyPush(con *Connection, req *model.PushRequest) func(err error) {
+	if !req.Span.IsValid() {
+		return func(error) {}
+	}
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), req.Span)
+	_, span := tracing.Start(ctx, "proxy_push")
+	span.SetAttributes(attribute.String("proxy", con.ID()))
+	t0 := time.Now()
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+		t.recordProxyPush(req.Span.TraceID(), con.ID(), t0, err)
+	}
+}
+
+func (t *pushTracer) recordProxyPush(id trace.TraceID, proxy string, t0 time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pt, f := t.pushes[id]
+	if !f {
+		return
+	}
+	pt.ProxyCount++
+	if len(pt.Proxies) >= maxTracedProxies {
+		return
+	}
+	p := PushTraceProxy{ID: proxy, Time: t0, Duration: time.Since(t0).Seconds()}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	pt.Proxies = append(pt.Proxies, p)
+}
+
+// list returns the recorded pushes, newest first. If traceID is set, only the pushes with that trace ID, or caused by
+// an event with that trace ID, are returned.
+func (t *pushTracer) list(traceID string) []PushTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]PushTrace, 0, len(t.pushOrder))
+	for i := len(t.pushOrder) - 1; i >= 0; i-- {
+		pt := t.pushes[t.pushOrder[i]]
+		if traceID != "" && pt.TraceID != traceID && !slices.ContainsFunc(pt.Events, func(e PushTraceEvent) bool {
+			return e.TraceID == traceID
+		}) {
+			continue
+		}
+		cp := *pt
+		cp.Events = slices.Clone(pt.Events)
+		cp.Proxies = slices.Clone(pt.Proxies)
+		out = append(out, cp)
+	}
+	return out
+}
+
+// pushTraceHandler shows the recent config events, the pushes they caused, and the proxies each push was sent to.
+func (s *DiscoveryServer) pushTraceHandler(w http.ResponseWriter, req *http.Request) {
+	if !features.EnablePushTracing {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("push tracing is not enabled, set PILOT_ENABLE_PUSH_TRACING=true\n"))
+		return
+	}
+	writeJSON(w, s.pushTracer.list(req.URL.Query().Get("trace")), req)
+}
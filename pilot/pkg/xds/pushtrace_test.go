@@ -0,0 +1,74 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestPushTracer(t *testing.T) {
+	tracer := newPushTracer()
+
+	vs := &model.PushRequest{
+		ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "default"}),
+		Reason:         model.NewReasonStats(model.ConfigUpdate),
+	}
+	dr := &model.PushRequest{
+		ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}),
+		Reason:         model.NewReasonStats(model.ConfigUpdate),
+	}
+	tracer.traceConfigUpdate(vs)
+	tracer.traceConfigUpdate(dr)
+	assert.Equal(t, len(vs.Causes), 1)
+	vsTrace := vs.Causes[0].TraceID().String()
+
+	// Debounce merges both events into a single push
+	req := vs.Merge(dr)
+	_, span := tracer.startPush(req)
+	req.Push = &model.PushContext{PushVersion: "v1"}
+	tracer.recordPush(req, time.Second)
+	span.End()
+
+	proxy := func(id string) *Connection {
+		con := newConnection("", nil)
+		con.SetID(id)
+		return con
+	}
+	tracer.startProxyPush(proxy("a"), req.CopyMerge(&model.PushRequest{}))(nil)
+	tracer.startProxyPush(proxy("b"), req)(errors.New("failed"))
+
+	pushes := tracer.list("")
+	assert.Equal(t, len(pushes), 1)
+	got := pushes[0]
+	assert.Equal(t, got.TraceID, req.Span.TraceID().String())
+	assert.Equal(t, got.Version, "v1")
+	assert.Equal(t, got.ProxyCount, 2)
+	assert.Equal(t, len(got.Events), 2)
+	assert.Equal(t, got.Events[0].Configs, []string{"VirtualService/default/vs"})
+	assert.Equal(t, got.Events[1].Configs, []string{"DestinationRule/default/dr"})
+	assert.Equal(t, got.Proxies[0].ID, "a")
+	assert.Equal(t, got.Proxies[1].Error, "failed")
+
+	assert.Equal(t, len(tracer.list(vsTrace)), 1)
+	assert.Equal(t, len(tracer.list(got.TraceID)), 1)
+	assert.Equal(t, len(tracer.list("unknown")), 0)
+}
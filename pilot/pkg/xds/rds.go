@@ -25,7 +25,7 @@ type RdsGenerator struct {
 	ConfigGenerator core.ConfigGenerator
 }
 
-var _ model.XdsResourceGenerator = &RdsGenerator{}
+var _ model.XdsDeltaResourceGenerator = &RdsGenerator{}
 
 // Map of all configs that do not impact RDS
 var skippedRdsConfigs = sets.New(
@@ -68,3 +68,18 @@ func (c RdsGenerator) Generate(proxy *model.Proxy, w *model.WatchedResource, req
 	resources, logDetails := c.ConfigGenerator.BuildHTTPRoutes(proxy, req, w.ResourceNames.UnsortedList())
 	return resources, logDetails, nil
 }
+
+// GenerateDeltas for RDS only sends the watched routes of the ports impacted by ServiceEntry, VirtualService and
+// DestinationRule changes, and falls back to all watched routes for other changes.
+func (c RdsGenerator) GenerateDeltas(proxy *model.Proxy, req *model.PushRequest,
+	w *model.WatchedResource,
+) (model.Resources, model.DeletedResources, model.XdsLogDetails, bool, error) {
+	if !rdsNeedsPush(req, proxy) {
+		return nil, nil, model.DefaultXdsLogDetails, false, nil
+	}
+	req = filterConfigsUpdated(req, func(key model.ConfigKey) bool {
+		return skippedRdsConfigs.Contains(key.Kind) || (key.Kind == kind.Gateway && proxy.Type != model.Router && !proxy.IsAmbientEastWestGateway())
+	})
+	resources, removed, logDetails, usedDelta := c.ConfigGenerator.BuildDeltaHTTPRoutes(proxy, req, w)
+	return resources, removed, logDetails, usedDelta, nil
+}
@@ -0,0 +1,89 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/util/hash"
+	"istio.io/istio/pkg/xds"
+)
+
+// ShardMembership lists the Istiod replicas new xDS connections are sharded across.
+type ShardMembership interface {
+	// Members returns the addresses of the live replicas, including this one.
+	Members() []string
+	// Self returns the address of this replica, or an empty string if it is not known yet.
+	Self() string
+}
+
+// redirectMode returns the value of the redirect header of the stream, if it has one.
+func redirectMode(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if v := md.Get(xds.RedirectHeader); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// shardKey returns the key a proxy is sharded by. Proxies with the same Sidecar scope get largely the same
+// configuration, so serving them from the same replica makes the most of its caches.
+func shardKey(push *model.PushContext, proxy *model.Proxy) string {
+	return proxy.ConfigNamespace + "/" + push.SidecarScopeName(proxy)
+}
+
+// shardOwner returns the member owning key, or an empty string if there are no members. Ownership is assigned with
+// rendezvous hashing, so when a member joins or leaves only the keys it owns move.
+func shardOwner(key string, members []string) string {
+	var owner string
+	var best uint64
+	h := hash.New()
+	for _, m := range members {
+		h.Reset()
+		h.WriteString(key)
+		h.WriteString("/")
+		h.WriteString(m)
+		if score := h.Sum64(); owner == "" || score > best {
+			owner, best = m, score
+		}
+	}
+	return owner
+}
+
+// shardRedirect returns an error redirecting the connection to the replica that owns its shard, or nil if it should
+// be served by this replica. Only connections from clients that follow redirects are redirected, and never twice.
+func (s *DiscoveryServer) shardRedirect(con *Connection) error {
+	if s.ShardMembership == nil || con.redirectMode != xds.RedirectSupported {
+		return nil
+	}
+	self := s.ShardMembership.Self()
+	if self == "" {
+		return nil
+	}
+	key := shardKey(con.proxy.LastPushContext, con.proxy)
+	owner := shardOwner(key, s.ShardMembership.Members())
+	if owner == "" || owner == self {
+		return nil
+	}
+	log.Debugf("ADS: redirecting %s with shard %s to %s", con.proxy.ID, key, owner)
+	shardRedirects.Increment()
+	return xds.RedirectError(owner)
+}
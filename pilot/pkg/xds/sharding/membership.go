@@ -0,0 +1,214 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sharding tracks the Istiod replicas xDS connections are sharded across.
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient"
+	istiolog "istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/ptr"
+)
+
+const (
+	// LeaseLabel marks the Leases held by Istiod replicas for xDS sharding. Its value is the revision of the replica.
+	LeaseLabel = "istio.io/xds-shard"
+	// AddressAnnotation holds the address the replica holding a Lease accepts redirected xDS connections on.
+	AddressAnnotation = "istio.io/xds-address"
+
+	leasePrefix = "istiod-xds-shard-"
+)
+
+var log = istiolog.RegisterScope("sharding", "xDS connection sharding")
+
+// Membership holds a Lease for this Istiod replica, renewing it while the replica runs, and watches the Leases of the
+// other replicas of the same revision. Replicas whose Lease expired are not members.
+type Membership struct {
+	client    kube.Client
+	leases    kclient.Client[*coordinationv1.Lease]
+	namespace string
+	podName   string
+	port      string
+	duration  time.Duration
+	labels    map[string]string
+
+	mu      sync.RWMutex
+	address string
+
+	now func() time.Time
+}
+
+// NewMembership returns the membership of the replica running in pod podName of namespace. If address is empty, the
+// pod IP and port are advertised.
+func NewMembership(client kube.Client, namespace, podName, revision, address, port string, duration time.Duration) *Membership {
+	selector := klabels.SelectorFromSet(map[string]string{LeaseLabel: revision})
+	return &Membership{
+		client: client,
+		leases: kclient.NewFiltered[*coordinationv1.Lease](client, kclient.Filter{
+			Namespace:     namespace,
+			LabelSelector: selector.String(),
+		}),
+		namespace: namespace,
+		podName:   podName,
+		port:      port,
+		duration:  duration,
+		labels:    map[string]string{LeaseLabel: revision},
+		address:   address,
+		now:       time.Now,
+	}
+}
+
+// Self returns the address of this replica, or an empty string until it is known.
+func (m *Membership) Self() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.address
+}
+
+// Members returns the sorted addresses of the live replicas, including this one.
+func (m *Membership) Members() []string {
+	now := m.now()
+	self := m.Self()
+	members := []string{}
+	hasSelf := false
+	for _, l := range m.leases.List(m.namespace, klabels.Everything()) {
+		addr := l.Annotations[AddressAnnotation]
+		if addr == "" || m.expired(l, now) {
+			continue
+		}
+		hasSelf = hasSelf || addr == self
+		members = append(members, addr)
+	}
+	// Our own Lease may not be observed yet, we are a member regardless.
+	if self != "" && !hasSelf {
+		members = append(members, self)
+	}
+	sort.Strings(members)
+	return members
+}
+
+func (m *Membership) expired(l *coordinationv1.Lease, now time.Time) bool {
+	if l.Spec.RenewTime == nil {
+		return true
+	}
+	duration := m.duration
+	if l.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return l.Spec.RenewTime.Add(duration).Before(now)
+}
+
+// Run resolves the address of this replica, then holds its Lease until stop is closed. The Lease is deleted on
+// shutdown, so other replicas stop redirecting connections to this one right away.
+func (m *Membership) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	if !kube.WaitForCacheSync("xds sharding", stop, m.leases.HasSynced) {
+		return
+	}
+	renew := time.NewTicker(m.duration / 3)
+	defer renew.Stop()
+	for {
+		if err := m.renew(ctx); err != nil {
+			log.Warnf("failed to renew xDS sharding lease: %v", err)
+		}
+		select {
+		case <-stop:
+			if err := m.leases.Delete(m.leaseName(), m.namespace); err != nil && !kerrors.IsNotFound(err) {
+				log.Warnf("failed to delete xDS sharding lease: %v", err)
+			}
+			return
+		case <-renew.C:
+		}
+	}
+}
+
+func (m *Membership) leaseName() string {
+	return leasePrefix + m.podName
+}
+
+// renew creates or renews the Lease of this replica, resolving its address first if needed.
+func (m *Membership) renew(ctx context.Context) error {
+	var owner []metav1.OwnerReference
+	if m.Self() == "" {
+		pod, err := m.client.Kube().CoreV1().Pods(m.namespace).Get(ctx, m.podName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get pod %s/%s: %v", m.namespace, m.podName, err)
+		}
+		if pod.Status.PodIP == "" {
+			return fmt.Errorf("pod %s/%s has no IP yet", m.namespace, m.podName)
+		}
+		m.mu.Lock()
+		m.address = net.JoinHostPort(pod.Status.PodIP, m.port)
+		m.mu.Unlock()
+		// Tie the Lease to the pod, so it is garbage collected if we are not shut down cleanly.
+		owner = []metav1.OwnerReference{{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       pod.Name,
+			UID:        pod.UID,
+		}}
+		log.Infof("advertising xDS sharding address %s", m.Self())
+	}
+	now := metav1.NewMicroTime(m.now())
+	cur := m.leases.Get(m.leaseName(), m.namespace)
+	if cur == nil {
+		_, err := m.leases.Create(&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            m.leaseName(),
+				Namespace:       m.namespace,
+				Labels:          m.labels,
+				Annotations:     map[string]string{AddressAnnotation: m.Self()},
+				OwnerReferences: owner,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.Of(m.podName),
+				LeaseDurationSeconds: ptr.Of(int32(m.duration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		})
+		if kerrors.IsAlreadyExists(err) {
+			// Not observed by the informer yet, the next renewal updates it.
+			return nil
+		}
+		return err
+	}
+	l := cur.DeepCopy()
+	if l.Annotations == nil {
+		l.Annotations = map[string]string{}
+	}
+	l.Annotations[AddressAnnotation] = m.Self()
+	l.Spec.HolderIdentity = ptr.Of(m.podName)
+	l.Spec.LeaseDurationSeconds = ptr.Of(int32(m.duration.Seconds()))
+	l.Spec.RenewTime = &now
+	_, err := m.leases.Update(l)
+	return err
+}
@@ -0,0 +1,88 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sharding
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestMembership(t *testing.T) {
+	stop := test.NewStop(t)
+	now := time.Now()
+	client := kube.NewFakeClient(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "istiod-a", Namespace: "istio-system", UID: "pod-uid"},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+	})
+	m := NewMembership(client, "istio-system", "istiod-a", "default", "", "15012", 15*time.Second)
+	clock := atomic.NewTime(now)
+	m.now = clock.Load
+	leases := kclient.New[*coordinationv1.Lease](client)
+	lease := func(name, address, revision string, renewed time.Time) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        leasePrefix + name,
+				Namespace:   "istio-system",
+				Labels:      map[string]string{LeaseLabel: revision},
+				Annotations: map[string]string{AddressAnnotation: address},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				LeaseDurationSeconds: ptr.Of(int32(15)),
+				RenewTime:            ptr.Of(metav1.NewMicroTime(renewed)),
+			},
+		}
+	}
+	for _, l := range []*coordinationv1.Lease{
+		lease("istiod-b", "10.0.0.2:15012", "default", now),
+		// Expired
+		lease("istiod-c", "10.0.0.3:15012", "default", now.Add(-time.Minute)),
+		// Another revision
+		lease("istiod-canary", "10.0.0.4:15012", "canary", now),
+	} {
+		if _, err := leases.Create(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client.RunAndWait(stop)
+
+	assert.Equal(t, m.Self(), "")
+	go m.Run(stop)
+
+	// The address is resolved from the pod, and advertised in our own Lease
+	assert.EventuallyEqual(t, m.Self, "10.0.0.1:15012")
+	assert.EventuallyEqual(t, func() string {
+		l := leases.Get(leasePrefix+"istiod-a", "istio-system")
+		if l == nil || len(l.OwnerReferences) == 0 {
+			return ""
+		}
+		return string(l.OwnerReferences[0].UID) + " " + l.Annotations[AddressAnnotation]
+	}, "pod-uid 10.0.0.1:15012")
+	assert.Equal(t, m.Members(), []string{"10.0.0.1:15012", "10.0.0.2:15012"})
+
+	// Replicas whose Lease is not renewed drop out
+	clock.Store(now.Add(time.Minute))
+	assert.Equal(t, m.Members(), []string{"10.0.0.1:15012"})
+}
@@ -0,0 +1,53 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestShardOwner(t *testing.T) {
+	assert.Equal(t, shardOwner("ns/default-sidecar", nil), "")
+
+	members := []string{"10.0.0.1:15012", "10.0.0.2:15012", "10.0.0.3:15012"}
+	keys := make([]string, 0, 300)
+	for i := 0; i < 300; i++ {
+		keys = append(keys, fmt.Sprintf("ns-%d/default-sidecar", i))
+	}
+	owners := map[string]string{}
+	counts := map[string]int{}
+	for _, k := range keys {
+		owners[k] = shardOwner(k, members)
+		counts[owners[k]]++
+		// Ownership does not depend on the order of the members
+		assert.Equal(t, shardOwner(k, []string{members[2], members[0], members[1]}), owners[k])
+	}
+	for _, m := range members {
+		if counts[m] < 50 {
+			t.Fatalf("expected keys to be spread across members, got %v", counts)
+		}
+	}
+
+	// When a member leaves, only the keys it owned move
+	for _, k := range keys {
+		owner := shardOwner(k, members[:2])
+		if owners[k] != members[2] {
+			assert.Equal(t, owner, owners[k])
+		}
+	}
+}
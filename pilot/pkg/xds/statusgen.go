@@ -16,6 +16,7 @@ package xds
 
 import (
 	"fmt"
+	"time"
 
 	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -23,6 +24,7 @@ import (
 	status "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
 	"google.golang.org/grpc/codes"
 	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"istio.io/istio/pilot/pkg/features"
@@ -169,14 +171,18 @@ func (sg *StatusGen) debugSyncz(callerNamespace string) model.Resources {
 			slices.SortBy(xdsConfigs, func(a *status.ClientConfig_GenericXdsConfig) string {
 				return a.TypeUrl
 			})
+			meta := model.NodeMetadata{
+				ClusterID:    con.proxy.Metadata.ClusterID,
+				Namespace:    con.proxy.Metadata.Namespace,
+				IstioVersion: con.proxy.Metadata.IstioVersion,
+			}.ToStruct()
+			if flow := debugFlowControlStatus(con); flow != nil && meta != nil {
+				meta.Fields[FlowControlMetadataKey] = structpb.NewStructValue(flow)
+			}
 			clientConfig := &status.ClientConfig{
 				Node: &core.Node{
-					Id: con.proxy.ID,
-					Metadata: model.NodeMetadata{
-						ClusterID:    con.proxy.Metadata.ClusterID,
-						Namespace:    con.proxy.Metadata.Namespace,
-						IstioVersion: con.proxy.Metadata.IstioVersion,
-					}.ToStruct(),
+					Id:       con.proxy.ID,
+					Metadata: meta,
 				},
 				GenericXdsConfigs: xdsConfigs,
 			}
@@ -191,6 +197,27 @@ func (sg *StatusGen) debugSyncz(callerNamespace string) model.Resources {
 	return res
 }
 
+// debugFlowControlStatus summarizes the flow control state of a slow proxy, or returns nil if the proxy keeps up.
+func debugFlowControlStatus(con *Connection) *structpb.Struct {
+	flow, coalesced := con.flow.status(time.Now())
+	slow := false
+	unacked := 0
+	var ackLatency float64
+	for _, f := range flow {
+		slow = slow || f.Slow
+		unacked += f.Unacked
+		ackLatency = max(ackLatency, f.AckLatency)
+	}
+	if !slow {
+		return nil
+	}
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"unacked":           structpb.NewNumberValue(float64(unacked)),
+		"ackLatencySeconds": structpb.NewNumberValue(ackLatency),
+		"coalescedPushes":   structpb.NewNumberValue(float64(coalesced)),
+	}}
+}
+
 func debugSyncStatus(wr model.WatchedResource) status.ConfigStatus {
 	if wr.LastError != "" {
 		return status.ConfigStatus_ERROR
@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/file"
+)
+
+// loadXdsCacheSnapshot reads a previously written cache snapshot. A missing file is not an error.
+func loadXdsCacheSnapshot(path string) (*model.XdsCacheSnapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	snap := &model.XdsCacheSnapshot{}
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, fmt.Errorf("invalid xds cache snapshot %s: %v", path, err)
+	}
+	return snap, nil
+}
+
+func writeXdsCacheSnapshot(path string, snap *model.XdsCacheSnapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return file.AtomicWrite(path, b, os.FileMode(0o600))
+}
+
+// restoreXdsCache loads the pending cache snapshot, if any, into the cache. It must only be called once caches
+// are synced: entries are validated against the current push context, and only those whose dependent
+// configs are unchanged since the snapshot was taken are restored.
+func (s *DiscoveryServer) restoreXdsCache() {
+	snap := s.pendingCacheSnapshot
+	s.pendingCacheSnapshot = nil
+	if snap == nil {
+		return
+	}
+	cache, ok := s.Cache.(model.PersistentXdsCache)
+	if !ok {
+		return
+	}
+	t0 := time.Now()
+	// InitContext returns immediately if the context was already initialized.
+	push := s.globalPushContext()
+	push.InitContext(s.Env, nil, nil)
+	valid := snap.ValidEntries(model.MeshFingerprint(s.Env), model.ConfigFingerprints(s.Env, push))
+	restored := cache.Restore(valid, &model.PushRequest{Start: time.Now(), Push: push})
+	xdsCacheSnapshotRestored.RecordInt(int64(restored))
+	xdsCacheSnapshotDiscarded.RecordInt(int64(len(snap.Entries) - restored))
+	log.Infof("restored %d/%d xds cache entries from snapshot %s in %v",
+		restored, len(snap.Entries), snap.PushVersion, time.Since(t0))
+}
+
+// snapshotXdsCache builds a snapshot of the current cache. It returns nil if there are config updates that have not
+// yet been committed to the push context, as the cache may then hold entries that are stale relative to the
+// config store.
+func (s *DiscoveryServer) snapshotXdsCache() *model.XdsCacheSnapshot {
+	cache, ok := s.Cache.(model.PersistentXdsCache)
+	if !ok {
+		return nil
+	}
+	committed := s.CommittedUpdates.Load()
+	if s.InboundUpdates.Load() != committed {
+		return nil
+	}
+	push := s.globalPushContext()
+	fingerprints := model.ConfigFingerprints(s.Env, push)
+	snap := model.NewXdsCacheSnapshot(cache, push.PushVersion, model.MeshFingerprint(s.Env), fingerprints)
+	// Check again, an update may have come in while we were computing fingerprints.
+	if s.InboundUpdates.Load() != committed || s.CommittedUpdates.Load() != committed {
+		return nil
+	}
+	return snap
+}
+
+// persistXdsCache periodically writes the cache snapshot to path, and once more on shutdown.
+func (s *DiscoveryServer) persistXdsCache(path string, interval time.Duration, stopCh <-chan struct{}) {
+	write := func() {
+		snap := s.snapshotXdsCache()
+		if snap == nil {
+			log.Debugf("skipping xds cache snapshot, updates are in flight")
+			return
+		}
+		if err := writeXdsCacheSnapshot(path, snap); err != nil {
+			log.Warnf("failed to write xds cache snapshot: %v", err)
+			return
+		}
+		log.Debugf("wrote %d xds cache entries to %s", len(snap.Entries), path)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if s.IsServerReady() {
+				write()
+			}
+		case <-stopCh:
+			if s.IsServerReady() {
+				write()
+			}
+			return
+		}
+	}
+}
+
+// initXdsCacheSnapshot loads the cache snapshot to be restored once caches are synced.
+func (s *DiscoveryServer) initXdsCacheSnapshot() {
+	if features.XDSCacheSnapshotPath == "" {
+		return
+	}
+	snap, err := loadXdsCacheSnapshot(features.XDSCacheSnapshotPath)
+	if err != nil {
+		log.Warnf("failed to load xds cache snapshot: %v", err)
+		return
+	}
+	s.pendingCacheSnapshot = snap
+}
@@ -0,0 +1,47 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"path/filepath"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestXdsCacheSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "xds-cache.json")
+
+	snap, err := loadXdsCacheSnapshot(path)
+	assert.NoError(t, err)
+	if snap != nil {
+		t.Fatalf("expected no snapshot, got %v", snap)
+	}
+
+	want := &model.XdsCacheSnapshot{
+		Version:         "1.0",
+		PushVersion:     "v1",
+		MeshFingerprint: 10,
+		Fingerprints:    map[model.ConfigHash]uint64{1: 2},
+		Entries: []model.XdsCacheSnapshotEntry{
+			{Type: model.CDSType, Key: 3, Dependents: []model.ConfigHash{1}, Resource: []byte("resource")},
+		},
+	}
+	assert.NoError(t, writeXdsCacheSnapshot(path, want))
+	got, err := loadXdsCacheSnapshot(path)
+	assert.NoError(t, err)
+	assert.Equal(t, got, want)
+}
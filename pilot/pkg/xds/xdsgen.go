@@ -174,6 +174,10 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 		}
 		return err
 	}
+	if !strings.HasPrefix(w.TypeUrl, v3.DebugType) {
+		con.flow.sent(w.TypeUrl, resp.Nonce, time.Now())
+	}
+	con.recordHistory(w.TypeUrl, req.Push.PushVersion, res, logdata.Incremental)
 
 	switch {
 	case model.OnlyHasConfigsOfKind(req.ConfigsUpdated, kind.Endpoints):
@@ -0,0 +1,295 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: auditapi/audit.proto
+
+package auditapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type IssuanceRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The hex encoded serial number of the certificate.
+	Serial string `protobuf:"bytes,1,opt,name=serial,proto3" json:"serial,omitempty"`
+	// The subject alternative names of the certificate.
+	Sans      []string               `protobuf:"bytes,2,rep,name=sans,proto3" json:"sans,omitempty"`
+	NotBefore *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	IssuedAt  *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=issued_at,json=issuedAt,proto3" json:"issued_at,omitempty"`
+	// The type of the authenticator that authenticated the caller.
+	Authenticator string `protobuf:"bytes,6,opt,name=authenticator,proto3" json:"authenticator,omitempty"`
+	// The identities of the caller, as authenticated.
+	CallerIdentities []string `protobuf:"bytes,7,rep,name=caller_identities,json=callerIdentities,proto3" json:"caller_identities,omitempty"`
+	// The namespace/name of the calling pod, if known.
+	CallerPod string `protobuf:"bytes,8,opt,name=caller_pod,json=callerPod,proto3" json:"caller_pod,omitempty"`
+	// The address of the caller.
+	ClientAddress string `protobuf:"bytes,9,opt,name=client_address,json=clientAddress,proto3" json:"client_address,omitempty"`
+	// Set if the certificate was requested by a trusted node agent on behalf of the SAN identity.
+	Impersonated bool `protobuf:"varint,10,opt,name=impersonated,proto3" json:"impersonated,omitempty"`
+	// The name of the signer selected by the caller, if any.
+	CertSigner string `protobuf:"bytes,11,opt,name=cert_signer,json=certSigner,proto3" json:"cert_signer,omitempty"`
+	// The hex encoded SHA-256 fingerprint of the root certificate the certificate chains to, if known.
+	Root          string `protobuf:"bytes,12,opt,name=root,proto3" json:"root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssuanceRecord) Reset() {
+	*x = IssuanceRecord{}
+	mi := &file_auditapi_audit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssuanceRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssuanceRecord) ProtoMessage() {}
+
+func (x *IssuanceRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_auditapi_audit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssuanceRecord.ProtoReflect.Descriptor instead.
+func (*IssuanceRecord) Descriptor() ([]byte, []int) {
+	return file_auditapi_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *IssuanceRecord) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *IssuanceRecord) GetSans() []string {
+	if x != nil {
+		return x.Sans
+	}
+	return nil
+}
+
+func (x *IssuanceRecord) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *IssuanceRecord) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *IssuanceRecord) GetIssuedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssuedAt
+	}
+	return nil
+}
+
+func (x *IssuanceRecord) GetAuthenticator() string {
+	if x != nil {
+		return x.Authenticator
+	}
+	return ""
+}
+
+func (x *IssuanceRecord) GetCallerIdentities() []string {
+	if x != nil {
+		return x.CallerIdentities
+	}
+	return nil
+}
+
+func (x *IssuanceRecord) GetCallerPod() string {
+	if x != nil {
+		return x.CallerPod
+	}
+	return ""
+}
+
+func (x *IssuanceRecord) GetClientAddress() string {
+	if x != nil {
+		return x.ClientAddress
+	}
+	return ""
+}
+
+func (x *IssuanceRecord) GetImpersonated() bool {
+	if x != nil {
+		return x.Impersonated
+	}
+	return false
+}
+
+func (x *IssuanceRecord) GetCertSigner() string {
+	if x != nil {
+		return x.CertSigner
+	}
+	return ""
+}
+
+func (x *IssuanceRecord) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+type RecordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordResponse) Reset() {
+	*x = RecordResponse{}
+	mi := &file_auditapi_audit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordResponse) ProtoMessage() {}
+
+func (x *RecordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_auditapi_audit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordResponse.ProtoReflect.Descriptor instead.
+func (*RecordResponse) Descriptor() ([]byte, []int) {
+	return file_auditapi_audit_proto_rawDescGZIP(), []int{1}
+}
+
+var File_auditapi_audit_proto protoreflect.FileDescriptor
+
+const file_auditapi_audit_proto_rawDesc = "" +
+	"\n" +
+	"\x14auditapi/audit.proto\x12\x17istio.security.audit.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdb\x03\n" +
+	"\x0eIssuanceRecord\x12\x16\n" +
+	"\x06serial\x18\x01 \x01(\tR\x06serial\x12\x12\n" +
+	"\x04sans\x18\x02 \x03(\tR\x04sans\x129\n" +
+	"\n" +
+	"not_before\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tnotBefore\x127\n" +
+	"\tnot_after\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bnotAfter\x127\n" +
+	"\tissued_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\x12$\n" +
+	"\rauthenticator\x18\x06 \x01(\tR\rauthenticator\x12+\n" +
+	"\x11caller_identities\x18\a \x03(\tR\x10callerIdentities\x12\x1d\n" +
+	"\n" +
+	"caller_pod\x18\b \x01(\tR\tcallerPod\x12%\n" +
+	"\x0eclient_address\x18\t \x01(\tR\rclientAddress\x12\"\n" +
+	"\fimpersonated\x18\n" +
+	" \x01(\bR\fimpersonated\x12\x1f\n" +
+	"\vcert_signer\x18\v \x01(\tR\n" +
+	"certSigner\x12\x12\n" +
+	"\x04root\x18\f \x01(\tR\x04root\"\x10\n" +
+	"\x0eRecordResponse2u\n" +
+	"\x17CertificateIssuanceSink\x12Z\n" +
+	"\x06Record\x12'.istio.security.audit.v1.IssuanceRecord\x1a'.istio.security.audit.v1.RecordResponseB\x1dZ\x1bistio.io/istio/pkg/auditapib\x06proto3"
+
+var (
+	file_auditapi_audit_proto_rawDescOnce sync.Once
+	file_auditapi_audit_proto_rawDescData []byte
+)
+
+func file_auditapi_audit_proto_rawDescGZIP() []byte {
+	file_auditapi_audit_proto_rawDescOnce.Do(func() {
+		file_auditapi_audit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_auditapi_audit_proto_rawDesc), len(file_auditapi_audit_proto_rawDesc)))
+	})
+	return file_auditapi_audit_proto_rawDescData
+}
+
+var file_auditapi_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_auditapi_audit_proto_goTypes = []any{
+	(*IssuanceRecord)(nil),        // 0: istio.security.audit.v1.IssuanceRecord
+	(*RecordResponse)(nil),        // 1: istio.security.audit.v1.RecordResponse
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_auditapi_audit_proto_depIdxs = []int32{
+	2, // 0: istio.security.audit.v1.IssuanceRecord.not_before:type_name -> google.protobuf.Timestamp
+	2, // 1: istio.security.audit.v1.IssuanceRecord.not_after:type_name -> google.protobuf.Timestamp
+	2, // 2: istio.security.audit.v1.IssuanceRecord.issued_at:type_name -> google.protobuf.Timestamp
+	0, // 3: istio.security.audit.v1.CertificateIssuanceSink.Record:input_type -> istio.security.audit.v1.IssuanceRecord
+	1, // 4: istio.security.audit.v1.CertificateIssuanceSink.Record:output_type -> istio.security.audit.v1.RecordResponse
+	4, // [4:5] is the sub-list for method output_type
+	3, // [3:4] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_auditapi_audit_proto_init() }
+func file_auditapi_audit_proto_init() {
+	if File_auditapi_audit_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_auditapi_audit_proto_rawDesc), len(file_auditapi_audit_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_auditapi_audit_proto_goTypes,
+		DependencyIndexes: file_auditapi_audit_proto_depIdxs,
+		MessageInfos:      file_auditapi_audit_proto_msgTypes,
+	}.Build()
+	File_auditapi_audit_proto = out.File
+	file_auditapi_audit_proto_goTypes = nil
+	file_auditapi_audit_proto_depIdxs = nil
+}
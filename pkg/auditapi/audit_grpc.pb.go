@@ -0,0 +1,144 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: auditapi/audit.proto
+
+package auditapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CertificateIssuanceSink_Record_FullMethodName = "/istio.security.audit.v1.CertificateIssuanceSink/Record"
+)
+
+// CertificateIssuanceSinkClient is the client API for CertificateIssuanceSink service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CertificateIssuanceSink durably stores the audit records of the certificates issued by the Istio CA.
+type CertificateIssuanceSinkClient interface {
+	// Record stores an issuance. Istiod retries the call until it succeeds, so the sink should deduplicate records by
+	// serial.
+	Record(ctx context.Context, in *IssuanceRecord, opts ...grpc.CallOption) (*RecordResponse, error)
+}
+
+type certificateIssuanceSinkClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCertificateIssuanceSinkClient(cc grpc.ClientConnInterface) CertificateIssuanceSinkClient {
+	return &certificateIssuanceSinkClient{cc}
+}
+
+func (c *certificateIssuanceSinkClient) Record(ctx context.Context, in *IssuanceRecord, opts ...grpc.CallOption) (*RecordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordResponse)
+	err := c.cc.Invoke(ctx, CertificateIssuanceSink_Record_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CertificateIssuanceSinkServer is the server API for CertificateIssuanceSink service.
+// All implementations must embed UnimplementedCertificateIssuanceSinkServer
+// for forward compatibility.
+//
+// CertificateIssuanceSink durably stores the audit records of the certificates issued by the Istio CA.
+type CertificateIssuanceSinkServer interface {
+	// Record stores an issuance. Istiod retries the call until it succeeds, so the sink should deduplicate records by
+	// serial.
+	Record(context.Context, *IssuanceRecord) (*RecordResponse, error)
+	mustEmbedUnimplementedCertificateIssuanceSinkServer()
+}
+
+// UnimplementedCertificateIssuanceSinkServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCertificateIssuanceSinkServer struct{}
+
+func (UnimplementedCertificateIssuanceSinkServer) Record(context.Context, *IssuanceRecord) (*RecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Record not implemented")
+}
+func (UnimplementedCertificateIssuanceSinkServer) mustEmbedUnimplementedCertificateIssuanceSinkServer() {
+}
+func (UnimplementedCertificateIssuanceSinkServer) testEmbeddedByValue() {}
+
+// UnsafeCertificateIssuanceSinkServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CertificateIssuanceSinkServer will
+// result in compilation errors.
+type UnsafeCertificateIssuanceSinkServer interface {
+	mustEmbedUnimplementedCertificateIssuanceSinkServer()
+}
+
+func RegisterCertificateIssuanceSinkServer(s grpc.ServiceRegistrar, srv CertificateIssuanceSinkServer) {
+	// If the following call pancis, it indicates UnimplementedCertificateIssuanceSinkServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CertificateIssuanceSink_ServiceDesc, srv)
+}
+
+func _CertificateIssuanceSink_Record_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssuanceRecord)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CertificateIssuanceSinkServer).Record(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CertificateIssuanceSink_Record_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CertificateIssuanceSinkServer).Record(ctx, req.(*IssuanceRecord))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CertificateIssuanceSink_ServiceDesc is the grpc.ServiceDesc for CertificateIssuanceSink service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CertificateIssuanceSink_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "istio.security.audit.v1.CertificateIssuanceSink",
+	HandlerType: (*CertificateIssuanceSinkServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Record",
+			Handler:    _CertificateIssuanceSink_Record_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "auditapi/audit.proto",
+}
@@ -45,6 +45,7 @@ func All() []analysis.Analyzer {
 		// Please keep this list sorted alphabetically by pkg.name for convenience
 		&annotations.K8sAnalyzer{},
 		&authn.BlockedCIDRsAnalyzer{},
+		&authn.MTLSParametersAnalyzer{},
 		&authz.AuthorizationPoliciesAnalyzer{},
 		&conditions.ConditionAnalyzer{},
 		&deployment.ServiceAssociationAnalyzer{},
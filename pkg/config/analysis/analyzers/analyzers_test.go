@@ -104,6 +104,7 @@ var testGrid = []testCase{
 			{msg.AlphaAnnotation, "Pod anno-not-set-by-default"},
 			{msg.AlphaAnnotation, "Pod anno-not-set-by-default"},
 			{msg.AlphaAnnotation, "Pod anno-not-set-by-default"},
+			{msg.AlphaAnnotation, "Pod push-priority"},
 		},
 		skipAll: true,
 	},
@@ -557,6 +558,19 @@ var testGrid = []testCase{
 		analyzer: &authn.BlockedCIDRsAnalyzer{},
 		expected: []message{},
 	},
+	{
+		name: "mtlsParameters",
+		inputFiles: []string{
+			"testdata/mtls-parameters.yaml",
+		},
+		meshConfigFile: "testdata/mtls-parameters-meshconfig.yaml",
+		analyzer:       &authn.MTLSParametersAnalyzer{},
+		expected: []message{
+			{msg.MTLSParametersMismatch, "PeerAuthentication legacy/default"},
+			{msg.MTLSParametersMismatch, "DestinationRule default/reviews"},
+			{msg.MTLSParametersMismatch, "DestinationRule default/productpage"},
+		},
+	},
 	{
 		name: "authorizationpolicies",
 		inputFiles: []string{
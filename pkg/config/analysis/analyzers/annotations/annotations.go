@@ -19,6 +19,8 @@ import (
 
 	"istio.io/api/annotation"
 	"istio.io/api/label"
+	"istio.io/istio/cni/pkg/enrollment"
+	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/maturity"
@@ -26,6 +28,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/security"
 	"istio.io/istio/pkg/kube/inject"
 	"istio.io/istio/pkg/slices"
 )
@@ -33,7 +36,12 @@ import (
 // K8sAnalyzer checks for misplaced and invalid Istio annotations in K8s resources
 type K8sAnalyzer struct{}
 
-var istioAnnotations = annotation.AllResourceAnnotations()
+// The mesh mTLS, ambient enrollment status and push priority annotations are defined in this repository rather than
+// in istio.io/api.
+var istioAnnotations = append(append(annotation.AllResourceAnnotations(), security.MTLSAnnotations()...),
+	&enrollment.Status,
+	&xds.PushPriorityAnnotation,
+)
 
 // Metadata implements analyzer.Analyzer
 func (*K8sAnalyzer) Metadata() analysis.Metadata {
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authn
+
+import (
+	"strings"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/security"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// MTLSParametersAnalyzer checks that the ECDH curves and signature algorithms of mesh mTLS, set with annotations on
+// DestinationRules and PeerAuthentications or in the mesh config, leave clients and servers a value in common.
+type MTLSParametersAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSParametersAnalyzer{}
+
+const (
+	meshConfigSource = "the mesh config"
+	defaultsSource   = "the defaults"
+)
+
+// mtlsParameters are the mTLS parameters of clients or of a server, with the most specific resource setting them.
+type mtlsParameters struct {
+	params security.MTLSParameters
+	source string
+	// r is the resource setting the parameters, if any.
+	r *resource.Instance
+}
+
+// override returns the parameters overridden by the annotations of r, a resource of the given kind.
+func (p mtlsParameters) override(kind string, r *resource.Instance) mtlsParameters {
+	params := security.MTLSParametersFromAnnotations(r.Metadata.Annotations)
+	if params.IsEmpty() {
+		return p
+	}
+	return mtlsParameters{
+		params: p.params.Override(params),
+		source: kind + " " + r.Metadata.FullName.String(),
+		r:      r,
+	}
+}
+
+func (a *MTLSParametersAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "authn.MTLSParametersAnalyzer",
+		Description: "Checks that the mTLS ECDH curves and signature algorithms of clients and servers match",
+		Inputs: []config.GroupVersionKind{
+			gvk.DestinationRule,
+			gvk.PeerAuthentication,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+func (a *MTLSParametersAnalyzer) Analyze(c analysis.Context) {
+	mesh := mtlsParameters{source: defaultsSource}
+	rootNamespace := resource.Namespace(constants.IstioSystemNamespace)
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		mc := r.Message.(*meshconfig.MeshConfig)
+		curves := mc.GetMeshMTLS().GetEcdhCurves()
+		algorithms := security.SplitList(mc.GetDefaultConfig().GetProxyMetadata()[security.MTLSSignatureAlgorithmsMetadata])
+		if len(curves) > 0 || len(algorithms) > 0 {
+			mesh = mtlsParameters{params: security.NewMTLSParameters(curves, algorithms), source: meshConfigSource}
+		}
+		if mc.GetRootNamespace() != "" {
+			rootNamespace = resource.Namespace(mc.GetRootNamespace())
+		}
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+
+	// The PeerAuthentications without selector, and with a selector, of each namespace.
+	namespacePolicies := map[resource.Namespace]*resource.Instance{}
+	workloadPolicies := map[resource.Namespace][]*resource.Instance{}
+	c.ForEach(gvk.PeerAuthentication, func(r *resource.Instance) bool {
+		pa := r.Message.(*v1beta1.PeerAuthentication)
+		ns := r.Metadata.FullName.Namespace
+		if len(pa.GetSelector().GetMatchLabels()) == 0 {
+			if cur, f := namespacePolicies[ns]; !f || r.Metadata.CreateTime.Before(cur.Metadata.CreateTime) {
+				namespacePolicies[ns] = r
+			}
+		} else if ns != rootNamespace {
+			workloadPolicies[ns] = append(workloadPolicies[ns], r)
+		}
+		return true
+	})
+	meshServer := mesh
+	if r, f := namespacePolicies[rootNamespace]; f {
+		meshServer = meshServer.override(gvk.PeerAuthentication.Kind, r)
+	}
+	servers := func(ns resource.Namespace) []mtlsParameters {
+		server := meshServer
+		if r, f := namespacePolicies[ns]; f && ns != rootNamespace {
+			server = server.override(gvk.PeerAuthentication.Kind, r)
+		}
+		res := []mtlsParameters{server}
+		for _, r := range workloadPolicies[ns] {
+			res = append(res, server.override(gvk.PeerAuthentication.Kind, r))
+		}
+		return res
+	}
+
+	// Clients without DestinationRule use the mesh-wide parameters.
+	reported := sets.New[*resource.Instance]()
+	nss := sets.New(maps.Keys(namespacePolicies)...).InsertAll(maps.Keys(workloadPolicies)...)
+	for _, ns := range sets.SortedList(nss) {
+		for _, server := range servers(ns) {
+			if server.r != nil && !reported.InsertContains(server.r) {
+				reportMismatch(c, gvk.PeerAuthentication, server.r, mesh, server)
+			}
+		}
+	}
+
+	c.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		dr := r.Message.(*v1alpha3.DestinationRule)
+		// Auto mTLS uses ISTIO_MUTUAL when the TLS settings are unset.
+		if tls := dr.GetTrafficPolicy().GetTls(); tls != nil && tls.GetMode() != v1alpha3.ClientTLSSettings_ISTIO_MUTUAL {
+			return true
+		}
+		client := mesh.override(gvk.DestinationRule.Kind, r)
+		if client.r == nil {
+			return true
+		}
+		ns := util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost()).Namespace
+		for _, server := range servers(ns) {
+			if reportMismatch(c, gvk.DestinationRule, r, client, server) {
+				break
+			}
+		}
+		return true
+	})
+}
+
+// reportMismatch reports on r the first parameter the client and the server have nothing in common, and returns true
+// if there is one.
+func reportMismatch(c analysis.Context, kind config.GroupVersionKind, r *resource.Instance, client, server mtlsParameters) bool {
+	shared := security.SharedMTLSParameters(client.params, server.params)
+	clientParams, serverParams := client.params.Effective(), server.params.Effective()
+	var parameter string
+	var clientValues, serverValues []string
+	switch {
+	case len(shared.ECDHCurves) == 0:
+		parameter, clientValues, serverValues = "ECDH curves", clientParams.ECDHCurves, serverParams.ECDHCurves
+	case len(shared.SignatureAlgorithms) == 0:
+		parameter, clientValues, serverValues = "signature algorithms", clientParams.SignatureAlgorithms, serverParams.SignatureAlgorithms
+	default:
+		return false
+	}
+	c.Report(kind, msg.NewMTLSParametersMismatch(r, parameter, client.source, strings.Join(clientValues, ","),
+		server.source, strings.Join(serverValues, ",")))
+	return true
+}
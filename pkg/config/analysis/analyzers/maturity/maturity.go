@@ -20,12 +20,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"istio.io/api/annotation"
+	"istio.io/istio/cni/pkg/enrollment"
+	"istio.io/istio/pilot/pkg/controllers/autowaypoint"
+	"istio.io/istio/pilot/pkg/xds"
 	"istio.io/istio/pkg/config"
 	"istio.io/istio/pkg/config/analysis"
 	"istio.io/istio/pkg/config/analysis/analyzers/util"
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/resource"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/security"
 )
 
 // AlphaAnalyzer checks for alpha Istio annotations in K8s resources
@@ -35,7 +39,12 @@ type AlphaAnalyzer struct{}
 // in too much noise for users, with annotations that are set by default.  Once the noise dies down, this should be
 // added to the CombinedAnalyzers() function.
 
-var istioAnnotations = annotation.AllResourceAnnotations()
+// The mesh mTLS, ambient enrollment status and push priority annotations are defined in this repository rather than
+// in istio.io/api.
+var istioAnnotations = append(append(annotation.AllResourceAnnotations(), security.MTLSAnnotations()...),
+	&enrollment.Status,
+	&xds.PushPriorityAnnotation,
+)
 
 // Metadata implements analyzer.Analyzer
 func (*AlphaAnalyzer) Metadata() analysis.Metadata {
@@ -65,6 +74,9 @@ var AlwaysIgnoredAnnotations = map[string]bool{
 	// this annotation is set by controller, don't alert on it.
 	annotation.GatewayControllerVersion.Name: true,
 
+	// this annotation is set on Services by the waypoint auto-provisioner in istiod, don't alert on it.
+	autowaypoint.ProvisionedWaypointAnnotation: true,
+
 	// this annotation is added automatically.
 	annotation.IoIstioRev.Name: true,
 }
@@ -279,6 +279,10 @@ var (
 	// ConflictingServiceEntryProtocol defines a diag.MessageType for message "ConflictingServiceEntryProtocol".
 	// Description: Multiple ServiceEntries define the same host and port with conflicting protocols.
 	ConflictingServiceEntryProtocol = diag.NewMessageType(diag.Warning, "IST0177", "Multiple ServiceEntries (%s) define the same host %q and port %d with conflicting protocols (%s).")
+
+	// MTLSParametersMismatch defines a diag.MessageType for message "MTLSParametersMismatch".
+	// Description: The ECDH curves or signature algorithms of mesh mTLS set for clients and for a server have nothing in common, so handshakes between them fail.
+	MTLSParametersMismatch = diag.NewMessageType(diag.Warning, "IST0178", "The mTLS %s of the clients, set by %s (%s), and of the server, set by %s (%s), have nothing in common; mTLS handshakes will fail.")
 )
 
 // All returns a list of all known message types.
@@ -352,6 +356,7 @@ func All() []*diag.MessageType {
 		JwksUriFetchUnrestricted,
 		GatewayAPICRDVersionBelowMinimum,
 		ConflictingServiceEntryProtocol,
+		MTLSParametersMismatch,
 	}
 }
 
@@ -1026,3 +1031,16 @@ func NewConflictingServiceEntryProtocol(r *resource.Instance, serviceEntryNames
 		protocols,
 	)
 }
+
+// NewMTLSParametersMismatch returns a new diag.Message based on MTLSParametersMismatch.
+func NewMTLSParametersMismatch(r *resource.Instance, parameter string, clientSource string, clientValues string, serverSource string, serverValues string) diag.Message {
+	return diag.NewMessage(
+		MTLSParametersMismatch,
+		r,
+		parameter,
+		clientSource,
+		clientValues,
+		serverSource,
+		serverValues,
+	)
+}
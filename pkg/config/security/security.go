@@ -25,6 +25,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 
+	"istio.io/api/annotation"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/util/sets"
@@ -350,6 +351,178 @@ var ValidECDHCurves = sets.New(
 	"X25519MLKEM768",
 )
 
+// ValidSignatureAlgorithms contains a list of all signature algorithms supported in mesh mTLS.
+// Source: https://github.com/google/boringssl/blob/58f3bc83230d2958bb9710bc910972c4f5d382dc/ssl/ssl_privkey.cc#L700-L722
+var ValidSignatureAlgorithms = sets.New(
+	"rsa_pkcs1_md5_sha1",
+	"rsa_pkcs1_sha1",
+	"rsa_pkcs1_sha256",
+	"rsa_pkcs1_sha384",
+	"rsa_pkcs1_sha512",
+	"ecdsa_sha1",
+	"ecdsa_secp256r1_sha256",
+	"ecdsa_secp384r1_sha384",
+	"ecdsa_secp521r1_sha512",
+	"rsa_pss_rsae_sha256",
+	"rsa_pss_rsae_sha384",
+	"rsa_pss_rsae_sha512",
+	"ed25519",
+)
+
+// DefaultECDHCurves are the ECDH curves used by Envoy when none are configured.
+var DefaultECDHCurves = []string{"X25519", "P-256"}
+
+// DefaultSignatureAlgorithms are the signature algorithms used by Envoy when none are configured.
+var DefaultSignatureAlgorithms = []string{
+	"ecdsa_secp256r1_sha256",
+	"rsa_pss_rsae_sha256",
+	"rsa_pkcs1_sha256",
+	"ecdsa_secp384r1_sha384",
+	"rsa_pss_rsae_sha384",
+	"rsa_pkcs1_sha384",
+	"rsa_pss_rsae_sha512",
+	"rsa_pkcs1_sha512",
+	"rsa_pkcs1_sha1",
+}
+
+const (
+	// MTLSECDHCurvesAnnotation is a comma separated list of the ECDH curves offered in mesh mTLS, in order of
+	// preference. It is read from DestinationRules for the client side, from PeerAuthentications for the server side,
+	// and from pods and waypoints for HBONE.
+	MTLSECDHCurvesAnnotation = "security.istio.io/mtls-ecdh-curves"
+	// MTLSSignatureAlgorithmsAnnotation is a comma separated list of the signature algorithms accepted in mesh mTLS.
+	// It is read from the same resources as MTLSECDHCurvesAnnotation.
+	MTLSSignatureAlgorithmsAnnotation = "security.istio.io/mtls-signature-algorithms"
+
+	// MTLSSignatureAlgorithmsMetadata is the proxy metadata setting the signature algorithms accepted in the mesh mTLS
+	// of a proxy, when not set with MTLSSignatureAlgorithmsAnnotation. It is set mesh-wide with the proxyMetadata of
+	// the defaultConfig of the MeshConfig, and for a workload with the proxy.istio.io/config annotation.
+	MTLSSignatureAlgorithmsMetadata = "ISTIO_META_MTLS_SIGNATURE_ALGORITHMS"
+)
+
+var (
+	// MTLSECDHCurves is the definition of MTLSECDHCurvesAnnotation, registered along with the istio.io/api
+	// annotations.
+	MTLSECDHCurves = annotation.Instance{
+		Name: MTLSECDHCurvesAnnotation,
+		Description: "A comma separated list of the ECDH curves offered in mesh mTLS, in order of preference. " +
+			"Overrides meshMTLS.ecdhCurves on a DestinationRule for its clients, on a PeerAuthentication for its " +
+			"servers, and on a workload or waypoint for HBONE.",
+		FeatureStatus: annotation.Alpha,
+		Resources:     []annotation.ResourceTypes{annotation.Any},
+	}
+
+	// MTLSSignatureAlgorithms is the definition of MTLSSignatureAlgorithmsAnnotation, registered along with the
+	// istio.io/api annotations.
+	MTLSSignatureAlgorithms = annotation.Instance{
+		Name: MTLSSignatureAlgorithmsAnnotation,
+		Description: "A comma separated list of the signature algorithms accepted in mesh mTLS. Overrides the " +
+			"ISTIO_META_MTLS_SIGNATURE_ALGORITHMS proxy metadata on a DestinationRule for its clients, on a " +
+			"PeerAuthentication for its servers, and on a workload or waypoint for HBONE.",
+		FeatureStatus: annotation.Alpha,
+		Resources:     []annotation.ResourceTypes{annotation.Any},
+	}
+)
+
+// MTLSAnnotations returns the definitions of the mesh mTLS annotations.
+func MTLSAnnotations() []*annotation.Instance {
+	return []*annotation.Instance{&MTLSECDHCurves, &MTLSSignatureAlgorithms}
+}
+
+// MTLSParameters are the ECDH curves and signature algorithms of mesh mTLS. An empty list keeps the Envoy defaults.
+type MTLSParameters struct {
+	ECDHCurves          []string
+	SignatureAlgorithms []string
+}
+
+// MTLSParametersFromAnnotations returns the mTLS parameters set with annotations. Unsupported values are ignored,
+// as they would lead Envoy to NACKing.
+func MTLSParametersFromAnnotations(annotations map[string]string) MTLSParameters {
+	return NewMTLSParameters(SplitList(annotations[MTLSECDHCurvesAnnotation]), SplitList(annotations[MTLSSignatureAlgorithmsAnnotation]))
+}
+
+// NewMTLSParameters returns the mTLS parameters of the supported, deduplicated, curves and signature algorithms.
+func NewMTLSParameters(curves, algorithms []string) MTLSParameters {
+	return MTLSParameters{
+		ECDHCurves:          filterValues(curves, ValidECDHCurves, "ECDH curve"),
+		SignatureAlgorithms: filterValues(algorithms, ValidSignatureAlgorithms, "signature algorithm"),
+	}
+}
+
+// IsEmpty returns true if no parameter is set.
+func (p MTLSParameters) IsEmpty() bool {
+	return len(p.ECDHCurves) == 0 && len(p.SignatureAlgorithms) == 0
+}
+
+// Override returns the parameters, with the ones set in o taking precedence.
+func (p MTLSParameters) Override(o MTLSParameters) MTLSParameters {
+	if len(o.ECDHCurves) > 0 {
+		p.ECDHCurves = o.ECDHCurves
+	}
+	if len(o.SignatureAlgorithms) > 0 {
+		p.SignatureAlgorithms = o.SignatureAlgorithms
+	}
+	return p
+}
+
+// Effective returns the parameters used by Envoy, with the defaults in place of the unset ones.
+func (p MTLSParameters) Effective() MTLSParameters {
+	return MTLSParameters{
+		ECDHCurves:          DefaultECDHCurves,
+		SignatureAlgorithms: DefaultSignatureAlgorithms,
+	}.Override(p)
+}
+
+// SharedMTLSParameters returns the ECDH curves and signature algorithms supported by both a client and a server.
+// A handshake fails if either list is empty.
+func SharedMTLSParameters(client, server MTLSParameters) MTLSParameters {
+	client, server = client.Effective(), server.Effective()
+	return MTLSParameters{
+		ECDHCurves:          intersect(client.ECDHCurves, server.ECDHCurves),
+		SignatureAlgorithms: intersect(client.SignatureAlgorithms, server.SignatureAlgorithms),
+	}
+}
+
+// SplitList splits a comma separated list, trimming spaces and skipping empty values.
+func SplitList(s string) []string {
+	var res []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func filterValues(values []string, valid sets.String, kind string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(values))
+	seen := sets.New[string]()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !valid.Contains(v) {
+			log.Debugf("ignoring unsupported %s: %q", kind, v)
+		} else if !seen.InsertContains(v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// intersect returns the values of a also in b, in the order of a.
+func intersect(a, b []string) []string {
+	res := []string{}
+	bs := sets.New(b...)
+	for _, v := range a {
+		if bs.Contains(v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 func IsValidCipherSuite(cs string) bool {
 	if cs == "" || cs == "ALL" {
 		return true
@@ -369,6 +542,13 @@ func IsValidECDHCurve(cs string) bool {
 	return ValidECDHCurves.Contains(cs)
 }
 
+func IsValidSignatureAlgorithm(alg string) bool {
+	if alg == "" {
+		return true
+	}
+	return ValidSignatureAlgorithms.Contains(alg)
+}
+
 // FilterCipherSuites filters out invalid cipher suites which would lead Envoy to NACKing.
 func FilterCipherSuites(suites []string) []string {
 	if len(suites) == 0 {
@@ -579,3 +579,48 @@ func TestContainsPathTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestMTLSParametersFromAnnotations(t *testing.T) {
+	got := security.MTLSParametersFromAnnotations(map[string]string{
+		security.MTLSECDHCurvesAnnotation:          "X25519MLKEM768, X25519,invalid,X25519",
+		security.MTLSSignatureAlgorithmsAnnotation: "ecdsa_secp256r1_sha256,",
+	})
+	assert.Equal(t, got, security.MTLSParameters{
+		ECDHCurves:          []string{"X25519MLKEM768", "X25519"},
+		SignatureAlgorithms: []string{"ecdsa_secp256r1_sha256"},
+	})
+	assert.Equal(t, security.MTLSParametersFromAnnotations(nil).IsEmpty(), true)
+}
+
+func TestSharedMTLSParameters(t *testing.T) {
+	testCases := []struct {
+		name   string
+		client security.MTLSParameters
+		server security.MTLSParameters
+		want   security.MTLSParameters
+	}{
+		{
+			name:   "defaults",
+			client: security.MTLSParameters{},
+			server: security.MTLSParameters{},
+			want:   security.MTLSParameters{ECDHCurves: security.DefaultECDHCurves, SignatureAlgorithms: security.DefaultSignatureAlgorithms},
+		},
+		{
+			name:   "hybrid client with default server",
+			client: security.MTLSParameters{ECDHCurves: []string{"X25519MLKEM768", "X25519"}},
+			server: security.MTLSParameters{},
+			want:   security.MTLSParameters{ECDHCurves: []string{"X25519"}, SignatureAlgorithms: security.DefaultSignatureAlgorithms},
+		},
+		{
+			name:   "mismatch",
+			client: security.MTLSParameters{ECDHCurves: []string{"X25519MLKEM768"}},
+			server: security.MTLSParameters{ECDHCurves: []string{"P-256"}, SignatureAlgorithms: []string{"ed25519"}},
+			want:   security.MTLSParameters{ECDHCurves: []string{}, SignatureAlgorithms: []string{}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, security.SharedMTLSParameters(tc.client, tc.server), tc.want)
+		})
+	}
+}
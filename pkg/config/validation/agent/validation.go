@@ -418,6 +418,12 @@ func ValidateMeshConfigProxyConfig(config *meshconfig.ProxyConfig) Validation {
 			"https://istio.io/latest/docs/ops/configuration/traffic-management/dns-proxy/#dns-auto-allocation-v2 for information about replacement functionality"))
 	}
 
+	for _, alg := range security.SplitList(config.GetProxyMetadata()[security.MTLSSignatureAlgorithmsMetadata]) {
+		if !security.IsValidSignatureAlgorithm(alg) {
+			errs = multierror.Append(errs, fmt.Errorf("mesh TLS does not support signature algorithm %q", alg))
+		}
+	}
+
 	if config.EnvoyMetricsService != nil && config.EnvoyMetricsService.Address != "" {
 		if err := ValidateProxyAddress(config.EnvoyMetricsService.Address); err != nil {
 			errs = multierror.Append(errs, multierror.Prefix(err, fmt.Sprintf("invalid envoy metrics service address %q:", config.EnvoyMetricsService.Address)))
@@ -769,8 +775,10 @@ func validateTrustDomainConfig(config *meshconfig.MeshConfig) (errs error) {
 
 func ValidateMeshTLSConfig(mesh *meshconfig.MeshConfig) (errs error) {
 	if meshMTLS := mesh.MeshMTLS; meshMTLS != nil {
-		if meshMTLS.EcdhCurves != nil {
-			errs = multierror.Append(errs, errors.New("mesh TLS does not support ECDH curves configuration"))
+		for _, c := range meshMTLS.EcdhCurves {
+			if c == "" || !security.IsValidECDHCurve(c) {
+				errs = multierror.Append(errs, fmt.Errorf("mesh TLS does not support ECDH curve %q", c))
+			}
 		}
 	}
 	return errs
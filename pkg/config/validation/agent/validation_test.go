@@ -663,6 +663,22 @@ func TestValidateMeshConfigProxyConfig(t *testing.T) {
 			isValid: true, // allowed
 			isWarn:  true, // issue a warning though
 		},
+		{
+			name: "mTLS signature algorithms",
+			in: modify(valid,
+				func(c *meshconfig.ProxyConfig) {
+					c.ProxyMetadata = map[string]string{"ISTIO_META_MTLS_SIGNATURE_ALGORITHMS": "ecdsa_secp256r1_sha256,rsa_pss_rsae_sha256"}
+				}),
+			isValid: true,
+		},
+		{
+			name: "invalid mTLS signature algorithm",
+			in: modify(valid,
+				func(c *meshconfig.ProxyConfig) {
+					c.ProxyMetadata = map[string]string{"ISTIO_META_MTLS_SIGNATURE_ALGORITHMS": "ecdsa_secp256r1_sha256,rsa_md5"}
+				}),
+			isValid: false,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -1077,7 +1093,7 @@ func TestValidateMeshConfig(t *testing.T) {
 			},
 		},
 		MeshMTLS: &meshconfig.MeshConfig_TLSConfig{
-			EcdhCurves: []string{"P-256"},
+			EcdhCurves: []string{"X25519MLKEM768", "invalid"},
 		},
 		TlsDefaults: &meshconfig.MeshConfig_TLSConfig{
 			EcdhCurves: []string{"P-256", "P-256", "invalid"},
@@ -1102,7 +1118,7 @@ func TestValidateMeshConfig(t *testing.T) {
 			"trustDomainAliases[0]",
 			"trustDomainAliases[1]",
 			"trustDomainAliases[2]",
-			"mesh TLS does not support ECDH curves configuration",
+			"mesh TLS does not support ECDH curve \"invalid\"",
 		}
 		switch err := err.(type) {
 		case *multierror.Error:
@@ -48,6 +48,7 @@ import (
 	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/istio/pkg/wasm"
 	"istio.io/istio/security/pkg/nodeagent/cache"
+	"istio.io/istio/security/pkg/nodeagent/workloadapi"
 )
 
 const (
@@ -137,6 +138,9 @@ type Agent struct {
 	sdsServer   SDSService
 	secretCache *cache.SecretManagerClient
 
+	// Serves the SPIFFE Workload API, if enabled.
+	workloadAPIServer *workloadapi.Server
+
 	// Used when proxying envoy xds via istio-agent is enabled.
 	xdsProxy    *XdsProxy
 	fileWatcher filewatcher.FileWatcher
@@ -238,6 +242,9 @@ type AgentOptions struct {
 	// Note that the path is not configurable by design - only the socket file name.
 	WorkloadIdentitySocketFile string
 
+	// SPIFFEWorkloadAPISocketPath, if set, is the path of the UDS on which the SPIFFE Workload API is served.
+	SPIFFEWorkloadAPISocketPath string
+
 	EnvoySkipDeprecatedLogs bool
 }
 
@@ -473,27 +480,42 @@ func (a *Agent) initSdsServer() error {
 		return fmt.Errorf("failed to start workload secret manager %v", err)
 	}
 
+	var secretHandler func(resourceName string)
 	if a.cfg.DisableEnvoy {
 		// For proxyless we don't need an SDS server, but still need the keys and
 		// we need them refreshed periodically.
 		//
 		// This is based on the code from newSDSService, but customized to have explicit rotation.
+		st := a.secretCache
+		secretHandler = func(resourceName string) {
+			// The secret handler is called when a secret should be renewed, after invalidating the cache.
+			// The handler does not call GenerateSecret - it is a side-effect of the SDS generate() method, which
+			// is called by sdsServer.OnSecretUpdate, which triggers a push and eventually calls sdsservice.Generate
+			// TODO: extract the logic to detect expiration time, and use a simpler code to rotate to files.
+			_, _ = a.getWorkloadCerts(st)
+		}
 		go func() {
-			st := a.secretCache
-			st.RegisterSecretHandler(func(resourceName string) {
-				// The secret handler is called when a secret should be renewed, after invalidating the cache.
-				// The handler does not call GenerateSecret - it is a side-effect of the SDS generate() method, which
-				// is called by sdsServer.OnSecretUpdate, which triggers a push and eventually calls sdsservice.Generate
-				// TODO: extract the logic to detect expiration time, and use a simpler code to rotate to files.
-				_, _ = a.getWorkloadCerts(st)
-			})
 			_, _ = a.getWorkloadCerts(st)
 		}()
 	} else {
 		pkpConf := a.proxyConfig.GetPrivateKeyProvider()
 		a.sdsServer = a.cfg.SDSFactory(a.secOpts, a.secretCache, pkpConf)
-		a.secretCache.RegisterSecretHandler(a.sdsServer.OnSecretUpdate)
+		secretHandler = a.sdsServer.OnSecretUpdate
+	}
+
+	if a.cfg.SPIFFEWorkloadAPISocketPath != "" {
+		a.workloadAPIServer, err = workloadapi.NewServer(a.cfg.SPIFFEWorkloadAPISocketPath, a.secretCache)
+		if err != nil {
+			return fmt.Errorf("failed to start SPIFFE Workload API server: %v", err)
+		}
+		// Rotated secrets are pushed to both the proxy and the Workload API streams.
+		next := secretHandler
+		secretHandler = func(resourceName string) {
+			next(resourceName)
+			a.workloadAPIServer.OnSecretUpdate(resourceName)
+		}
 	}
+	a.secretCache.RegisterSecretHandler(secretHandler)
 
 	return nil
 }
@@ -646,6 +668,9 @@ func (a *Agent) Close() {
 	if a.sdsServer != nil {
 		a.sdsServer.Stop()
 	}
+	if a.workloadAPIServer != nil {
+		a.workloadAPIServer.Stop()
+	}
 	if a.secretCache != nil {
 		a.secretCache.Close()
 	}
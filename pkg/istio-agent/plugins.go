@@ -16,11 +16,15 @@ package istioagent
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
+	"istio.io/istio/pkg/env"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/nodeagent/caclient/providers/acme"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
+	"istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 )
 
 // WARNING WARNING WARNING
@@ -65,8 +69,83 @@ func createCitadel(opts *security.Options, a RootCertProvider) (security.Client,
 	return citadel.NewCitadelClient(opts, tlsOpts)
 }
 
+var (
+	acmeHostsEnv = env.Register("ACME_HOSTS", "",
+		"Comma separated DNS names and IP addresses the workload certificates are ordered for, with the ACME CA provider.").Get()
+	acmeEmailEnv          = env.Register("ACME_EMAIL", "", "Contact email of the ACME account.").Get()
+	acmeAccountKeyFileEnv = env.Register("ACME_ACCOUNT_KEY_FILE", "",
+		"Path to the PEM encoded private key of the ACME account. If unset, a new account is registered on each start.").Get()
+	acmeEABKeyIDEnv   = env.Register("ACME_EAB_KEY_ID", "", "Key ID of the ACME external account binding.").Get()
+	acmeEABHMACKeyEnv = env.Register("ACME_EAB_HMAC_KEY_FILE", "",
+		"Path to the raw HMAC key of the ACME external account binding.").Get()
+	acmeHTTP01AddressEnv = env.Register("ACME_HTTP01_ADDRESS", "",
+		"Address to serve the ACME http-01 challenge responses on, such as :80. If unset, the authorizations must already be valid.").Get()
+	acmeTrustBundleFileEnv = env.Register("ACME_TRUST_BUNDLE_FILE", "",
+		"Path to the root certificates of the ACME CA. If unset, the last certificate of the issued chain is used as the root.").Get()
+
+	vaultPKIPathEnv   = env.Register("VAULT_PKI_PATH", "pki", "Mount path of the Vault PKI secrets engine.").Get()
+	vaultPKIRoleEnv   = env.Register("VAULT_PKI_ROLE", "", "Vault PKI role the workload certificates are signed with.").Get()
+	vaultAuthPathEnv  = env.Register("VAULT_AUTH_PATH", "kubernetes", "Mount path of the Vault auth method the agent logs in with.").Get()
+	vaultAuthRoleEnv  = env.Register("VAULT_AUTH_ROLE", "", "Vault role the agent logs in as, with its platform credential.").Get()
+	vaultTokenFileEnv = env.Register("VAULT_TOKEN_FILE", "",
+		"Path to a Vault token to use instead of logging in.").Get()
+)
+
+func createACME(opts *security.Options, a RootCertProvider) (security.Client, error) {
+	rootCert, err := a.FindRootCAForCA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find root CA cert for CA: %v", err)
+	}
+	acmeOpts := acme.Options{
+		DirectoryURL:    opts.CAEndpoint,
+		RootCert:        rootCert,
+		Email:           acmeEmailEnv,
+		AccountKeyFile:  acmeAccountKeyFileEnv,
+		EABKeyID:        acmeEABKeyIDEnv,
+		HTTP01Address:   acmeHTTP01AddressEnv,
+		TrustBundleFile: acmeTrustBundleFileEnv,
+	}
+	for _, h := range strings.Split(acmeHostsEnv, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			acmeOpts.Hosts = append(acmeOpts.Hosts, h)
+		}
+	}
+	if acmeEABHMACKeyEnv != "" {
+		if acmeOpts.EABHMACKey, err = os.ReadFile(acmeEABHMACKeyEnv); err != nil {
+			return nil, fmt.Errorf("failed to read the ACME external account binding key: %v", err)
+		}
+	}
+	log.Infof("Using ACME CA %s for %v", opts.CAEndpoint, acmeOpts.Hosts)
+	return acme.NewClient(acmeOpts)
+}
+
+func createVault(opts *security.Options, a RootCertProvider) (security.Client, error) {
+	rootCert, err := a.FindRootCAForCA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find root CA cert for CA: %v", err)
+	}
+	log.Infof("Using Vault CA %s with PKI role %s/%s", opts.CAEndpoint, vaultPKIPathEnv, vaultPKIRoleEnv)
+	return vault.NewClient(vault.Options{
+		Address:   opts.CAEndpoint,
+		RootCert:  rootCert,
+		PKIPath:   vaultPKIPathEnv,
+		Role:      vaultPKIRoleEnv,
+		TokenFile: vaultTokenFileEnv,
+		AuthPath:  vaultAuthPathEnv,
+		AuthRole:  vaultAuthRoleEnv,
+		Credential: func() (string, error) {
+			if opts.CredFetcher == nil {
+				return "", fmt.Errorf("no credential fetcher is configured")
+			}
+			return opts.CredFetcher.GetPlatformCredential()
+		},
+	})
+}
+
 func init() {
 	providers["Citadel"] = createCitadel
+	providers[security.ACMEProvider] = createACME
+	providers[security.VaultProvider] = createVault
 }
 
 func createCAClient(opts *security.Options, a RootCertProvider) (security.Client, error) {
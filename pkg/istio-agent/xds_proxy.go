@@ -109,6 +109,26 @@ type XdsProxy struct {
 	ecdsLastNonce         atomic.String
 	downstreamGrpcOptions []grpc.ServerOption
 	istiodSAN             string
+
+	// redirect is the redirect received on the last upstream connection, if any.
+	redirect redirectState
+}
+
+const (
+	redirectInitialBackoff = 10 * time.Second
+	redirectMaxBackoff     = 5 * time.Minute
+)
+
+// redirectState tracks the address Istiod redirected the last upstream connection to. It is used for the next
+// upstream connection only, so a replica that went away is not retried. If a redirected connection fails before
+// receiving anything, for instance because the replica is not reachable from the network of the proxy, redirects are
+// declined for an exponentially growing period, and the agent stays connected to the configured address.
+type redirectState struct {
+	mu      sync.Mutex
+	address string
+	// backoff is the period redirects were last declined for, reset once a redirected connection succeeds.
+	backoff      time.Duration
+	declineUntil time.Time
 }
 
 var proxyLog = log.RegisterScope("xdsproxy", "XDS Proxy in Istio Agent")
@@ -261,6 +281,8 @@ type ProxyConnection struct {
 	upstream           DiscoveryClient
 	downstreamDeltas   DeltaDiscoveryStream
 	upstreamDeltas     DeltaDiscoveryClient
+	// redirected is set if the upstream connection follows a redirect.
+	redirected bool
 }
 
 // sendRequest is a small wrapper around sending to con.requestsChan. This ensures that we do not
@@ -329,31 +351,97 @@ func (p *XdsProxy) handleStream(downstream adsStream) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	upstreamConn, err := p.buildUpstreamConn(ctx)
+	address, redirect := p.upstreamAddress()
+	con.redirected = address != p.istiodAddress
+	upstreamConn, err := p.buildUpstreamConn(ctx, address)
 	if err != nil {
-		proxyLog.Errorf("failed to connect to upstream %s: %v", p.istiodAddress, err)
+		proxyLog.Errorf("failed to connect to upstream %s: %v", address, err)
 		metrics.IstiodConnectionFailures.Increment()
+		p.redirectFailed(con)
 		return err
 	}
 	defer upstreamConn.Close()
 
 	xds := discovery.NewAggregatedDiscoveryServiceClient(upstreamConn)
-	ctx = metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID)
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID, xdspkg.RedirectHeader, redirect)
 	for k, v := range p.xdsHeaders {
 		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
 	}
 	// We must propagate upstream termination to Envoy. This ensures that we resume the full XDS sequence on new connection
-	return p.handleUpstream(ctx, con, xds)
+	return p.handleUpstream(ctx, con, xds, address)
+}
+
+// upstreamAddress returns the address to connect to upstream, and the value of the redirect header to send. The
+// redirect received on the previous connection is followed once; later connections go to the configured address.
+// While redirects are declined, the connection is marked as already redirected, so that Istiod accepts it.
+func (p *XdsProxy) upstreamAddress() (string, string) {
+	r := &p.redirect
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if addr := r.address; addr != "" {
+		r.address = ""
+		return addr, xdspkg.RedirectFollowed
+	}
+	if time.Now().Before(r.declineUntil) {
+		return p.istiodAddress, xdspkg.RedirectFollowed
+	}
+	return p.istiodAddress, xdspkg.RedirectSupported
+}
+
+// recordRedirect stores the address an upstream error redirects to, so the next connection is made to it.
+func (p *XdsProxy) recordRedirect(con *ProxyConnection, err error) {
+	if addr, ok := xdspkg.RedirectAddress(err); ok {
+		proxyLog.WithLabels("id", con.conID).Infof("upstream redirected connection to %s", addr)
+		p.redirect.mu.Lock()
+		p.redirect.address = addr
+		p.redirect.mu.Unlock()
+	}
+}
+
+// redirectFailed declines redirects for a while if con followed a redirect and failed before receiving anything.
+func (p *XdsProxy) redirectFailed(con *ProxyConnection) {
+	if !con.redirected {
+		return
+	}
+	r := &p.redirect
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.backoff = min(max(2*r.backoff, redirectInitialBackoff), redirectMaxBackoff)
+	r.declineUntil = time.Now().Add(r.backoff)
+	proxyLog.WithLabels("id", con.conID).Warnf("redirected upstream connection failed, connecting to %s for %v",
+		p.istiodAddress, r.backoff)
+}
+
+// redirectSucceeded resets the backoff once a redirected connection receives a response.
+func (p *XdsProxy) redirectSucceeded(con *ProxyConnection) {
+	if !con.redirected {
+		return
+	}
+	p.redirect.mu.Lock()
+	defer p.redirect.mu.Unlock()
+	p.redirect.backoff = 0
+}
+
+// upstreamRecvError handles an error receiving from upstream before or after the first response.
+func (p *XdsProxy) upstreamRecvError(con *ProxyConnection, received bool, err error) {
+	if _, ok := xdspkg.RedirectAddress(err); ok {
+		p.recordRedirect(con, err)
+	} else if !received {
+		p.redirectFailed(con)
+	}
+	upstreamErr(con, err)
 }
 
-func (p *XdsProxy) buildUpstreamConn(ctx context.Context) (*grpc.ClientConn, error) {
+func (p *XdsProxy) buildUpstreamConn(ctx context.Context, address string) (*grpc.ClientConn, error) {
 	p.optsMutex.RLock()
 	opts := p.dialOptions
 	p.optsMutex.RUnlock()
-	return grpc.DialContext(ctx, p.istiodAddress, opts...)
+	return grpc.DialContext(ctx, address, opts...)
 }
 
-func (p *XdsProxy) handleUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient) error {
+func (p *XdsProxy) handleUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient,
+	address string,
+) error {
 	log := proxyLog.WithLabels("id", con.conID)
 	upstream, err := xds.StreamAggregatedResources(ctx,
 		grpc.MaxCallRecvMsgSize(defaultClientMaxReceiveMessageSize))
@@ -362,22 +450,28 @@ func (p *XdsProxy) handleUpstream(ctx context.Context, con *ProxyConnection, xds
 		log.Debugf("failed to create upstream grpc client: %v", err)
 		// Increase metric when xds connection error, for example: forgot to restart ingressgateway or sidecar after changing root CA.
 		metrics.IstiodConnectionErrors.Increment()
+		p.redirectFailed(con)
 		return err
 	}
-	log.Infof("connected to upstream XDS server: %s", p.istiodAddress)
-	defer log.Debugf("disconnected from XDS server: %s", p.istiodAddress)
+	log.Infof("connected to upstream XDS server: %s", address)
+	defer log.Debugf("disconnected from XDS server: %s", address)
 
 	con.upstream = upstream
 
 	// Handle upstream xds recv
 	go func() {
+		received := false
 		for {
 			// from istiod
 			resp, err := con.upstream.Recv()
 			if err != nil {
-				upstreamErr(con, err)
+				p.upstreamRecvError(con, received, err)
 				return
 			}
+			if !received {
+				received = true
+				p.redirectSucceeded(con)
+			}
 			select {
 			case con.responsesChan <- resp:
 			case <-con.stopChan:
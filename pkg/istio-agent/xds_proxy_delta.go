@@ -33,6 +33,7 @@ import (
 	"istio.io/istio/pkg/model"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/wasm"
+	xdspkg "istio.io/istio/pkg/xds"
 )
 
 // sendDeltaRequest is a small wrapper around sending to con.requestsChan. This ensures that we do not
@@ -64,24 +65,29 @@ func (p *XdsProxy) DeltaAggregatedResources(downstream DeltaDiscoveryStream) err
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	upstreamConn, err := p.buildUpstreamConn(ctx)
+	address, redirect := p.upstreamAddress()
+	con.redirected = address != p.istiodAddress
+	upstreamConn, err := p.buildUpstreamConn(ctx, address)
 	if err != nil {
-		proxyLog.Errorf("failed to connect to upstream %s: %v", p.istiodAddress, err)
+		proxyLog.Errorf("failed to connect to upstream %s: %v", address, err)
 		metrics.IstiodConnectionFailures.Increment()
+		p.redirectFailed(con)
 		return err
 	}
 	defer upstreamConn.Close()
 
 	xds := discovery.NewAggregatedDiscoveryServiceClient(upstreamConn)
-	ctx = metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID)
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "ClusterID", p.clusterID, xdspkg.RedirectHeader, redirect)
 	for k, v := range p.xdsHeaders {
 		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
 	}
 	// We must propagate upstream termination to Envoy. This ensures that we resume the full XDS sequence on new connection
-	return p.handleDeltaUpstream(ctx, con, xds)
+	return p.handleDeltaUpstream(ctx, con, xds, address)
 }
 
-func (p *XdsProxy) handleDeltaUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient) error {
+func (p *XdsProxy) handleDeltaUpstream(ctx context.Context, con *ProxyConnection, xds discovery.AggregatedDiscoveryServiceClient,
+	address string,
+) error {
 	log := proxyLog.WithLabels("id", con.conID)
 	deltaUpstream, err := xds.DeltaAggregatedResources(ctx,
 		grpc.MaxCallRecvMsgSize(defaultClientMaxReceiveMessageSize))
@@ -90,21 +96,27 @@ func (p *XdsProxy) handleDeltaUpstream(ctx context.Context, con *ProxyConnection
 		log.Debugf("failed to create delta upstream grpc client: %v", err)
 		// Increase metric when xds connection error, for example: forgot to restart ingressgateway or sidecar after changing root CA.
 		metrics.IstiodConnectionErrors.Increment()
+		p.redirectFailed(con)
 		return err
 	}
-	log.Infof("connected to delta upstream XDS server: %s", p.istiodAddress)
-	defer log.Debugf("disconnected from delta XDS server: %s", p.istiodAddress)
+	log.Infof("connected to delta upstream XDS server: %s", address)
+	defer log.Debugf("disconnected from delta XDS server: %s", address)
 
 	con.upstreamDeltas = deltaUpstream
 
 	// handle responses from istiod
 	go func() {
+		received := false
 		for {
 			resp, err := con.upstreamDeltas.Recv()
 			if err != nil {
-				upstreamErr(con, err)
+				p.upstreamRecvError(con, received, err)
 				return
 			}
+			if !received {
+				received = true
+				p.redirectSucceeded(con)
+			}
 			select {
 			case con.deltaResponsesChan <- resp:
 			case <-con.stopChan:
@@ -55,6 +55,7 @@ import (
 	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/test/util/retry"
 	wasmcache "istio.io/istio/pkg/wasm"
+	xdspkg "istio.io/istio/pkg/xds"
 )
 
 // Validates basic xds proxy flow by proxying one CDS requests end to end.
@@ -417,6 +418,110 @@ func TestXdsProxyReconnects(t *testing.T) {
 	})
 }
 
+type fakeShardMembership struct {
+	self    string
+	members []string
+}
+
+func (f fakeShardMembership) Members() []string { return f.members }
+func (f fakeShardMembership) Self() string      { return f.self }
+
+func TestXdsProxyFollowsRedirect(t *testing.T) {
+	meta := model.NodeMetadata{
+		Namespace:   "default",
+		InstanceIPs: []string{"1.1.1.1"},
+	}
+	listen := func() (net.Listener, *xds.FakeDiscoveryServer) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+		grpcServer := grpc.NewServer()
+		t.Cleanup(grpcServer.Stop)
+		f.Discovery.Register(grpcServer)
+		go grpcServer.Serve(listener)
+		return listener, f
+	}
+	first, firstServer := listen()
+	owner, ownerServer := listen()
+	// Each replica believes the other one owns every shard. The redirected connection must still be accepted.
+	firstServer.Discovery.ShardMembership = fakeShardMembership{self: first.Addr().String(), members: []string{owner.Addr().String()}}
+	ownerServer.Discovery.ShardMembership = fakeShardMembership{self: owner.Addr().String(), members: []string{first.Addr().String()}}
+
+	proxy := setupXdsProxy(t)
+	proxy.istiodAddress = first.Addr().String()
+	proxy.dialOptions = []grpc.DialOption{grpc.WithBlock(), grpc.WithTransportCredentials(insecure.NewCredentials())}
+
+	conn := setupDownstreamConnection(t, proxy)
+	downstream := stream(t, conn)
+	err := downstream.Send(&discovery.DiscoveryRequest{
+		TypeUrl: v3.ClusterType,
+		Node:    &core.Node{Id: "sidecar~1.1.1.1~debug~cluster.local", Metadata: meta.ToStruct()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := downstream.Recv(); err == nil {
+		t.Fatal("expected the connection to be redirected")
+	}
+
+	// Envoy reconnects, and the agent follows the redirect
+	downstream = stream(t, conn)
+	sendDownstreamWithNode(t, downstream, meta)
+	if got := len(ownerServer.Discovery.AllClients()); got != 1 {
+		t.Fatalf("expected the owner to serve the proxy, got %d connections", got)
+	}
+	if got := len(firstServer.Discovery.AllClients()); got != 0 {
+		t.Fatalf("expected the first replica to not serve the proxy, got %d connections", got)
+	}
+	// The redirect is only followed once
+	if addr, _ := proxy.upstreamAddress(); addr != proxy.istiodAddress {
+		t.Fatalf("expected the redirect to be consumed, got %q", addr)
+	}
+}
+
+func TestXdsProxyRedirectBackoff(t *testing.T) {
+	proxy := &XdsProxy{istiodAddress: "istiod:15012"}
+	redirected := func() *ProxyConnection {
+		t.Helper()
+		proxy.recordRedirect(&ProxyConnection{}, xdspkg.RedirectError("10.0.0.1:15012"))
+		addr, header := proxy.upstreamAddress()
+		if addr != "10.0.0.1:15012" || header != xdspkg.RedirectFollowed {
+			t.Fatalf("expected the redirect to be followed, got %s %s", addr, header)
+		}
+		return &ProxyConnection{redirected: true}
+	}
+
+	// A failed redirected connection declines redirects, and the agent connects to Istiod without being redirected
+	proxy.redirectFailed(redirected())
+	if addr, header := proxy.upstreamAddress(); addr != proxy.istiodAddress || header != xdspkg.RedirectFollowed {
+		t.Fatalf("expected redirects to be declined, got %s %s", addr, header)
+	}
+	if proxy.redirect.backoff != redirectInitialBackoff {
+		t.Fatalf("expected backoff %v, got %v", redirectInitialBackoff, proxy.redirect.backoff)
+	}
+
+	// Repeated failures back off exponentially, up to the maximum
+	for i := 0; i < 10; i++ {
+		proxy.redirect.declineUntil = time.Time{}
+		proxy.redirectFailed(redirected())
+	}
+	if proxy.redirect.backoff != redirectMaxBackoff {
+		t.Fatalf("expected backoff %v, got %v", redirectMaxBackoff, proxy.redirect.backoff)
+	}
+
+	// A successful redirected connection resets the backoff
+	proxy.redirect.declineUntil = time.Time{}
+	proxy.redirectSucceeded(redirected())
+	if proxy.redirect.backoff != 0 {
+		t.Fatalf("expected the backoff to be reset, got %v", proxy.redirect.backoff)
+	}
+	if _, header := proxy.upstreamAddress(); header != xdspkg.RedirectSupported {
+		t.Fatalf("expected redirects to be supported, got %s", header)
+	}
+}
+
 type fakeAckCache struct{}
 
 func (f *fakeAckCache) Get(string, wasmcache.GetOptions) (string, error) {
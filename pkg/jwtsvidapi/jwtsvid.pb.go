@@ -0,0 +1,319 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: jwtsvidapi/jwtsvid.proto
+
+package jwtsvidapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type JWTSVIDRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The audiences of the JWT-SVID. At least one is required.
+	Audiences []string `protobuf:"bytes,1,rep,name=audiences,proto3" json:"audiences,omitempty"`
+	// The requested validity of the JWT-SVID, in seconds. The default and maximum is set by CA_JWT_SVID_TTL.
+	ValidityDuration int64 `protobuf:"varint,2,opt,name=validity_duration,json=validityDuration,proto3" json:"validity_duration,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *JWTSVIDRequest) Reset() {
+	*x = JWTSVIDRequest{}
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWTSVIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWTSVIDRequest) ProtoMessage() {}
+
+func (x *JWTSVIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWTSVIDRequest.ProtoReflect.Descriptor instead.
+func (*JWTSVIDRequest) Descriptor() ([]byte, []int) {
+	return file_jwtsvidapi_jwtsvid_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *JWTSVIDRequest) GetAudiences() []string {
+	if x != nil {
+		return x.Audiences
+	}
+	return nil
+}
+
+func (x *JWTSVIDRequest) GetValidityDuration() int64 {
+	if x != nil {
+		return x.ValidityDuration
+	}
+	return 0
+}
+
+type JWTSVIDResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The SPIFFE ID in the sub claim of the JWT-SVID.
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// The signed JWT-SVID.
+	Token string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	// The expiration time of the JWT-SVID, in seconds since the Unix epoch.
+	ExpiresAt     int64 `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JWTSVIDResponse) Reset() {
+	*x = JWTSVIDResponse{}
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWTSVIDResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWTSVIDResponse) ProtoMessage() {}
+
+func (x *JWTSVIDResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWTSVIDResponse.ProtoReflect.Descriptor instead.
+func (*JWTSVIDResponse) Descriptor() ([]byte, []int) {
+	return file_jwtsvidapi_jwtsvid_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JWTSVIDResponse) GetSpiffeId() string {
+	if x != nil {
+		return x.SpiffeId
+	}
+	return ""
+}
+
+func (x *JWTSVIDResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *JWTSVIDResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type JWTBundleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JWTBundleRequest) Reset() {
+	*x = JWTBundleRequest{}
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWTBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWTBundleRequest) ProtoMessage() {}
+
+func (x *JWTBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWTBundleRequest.ProtoReflect.Descriptor instead.
+func (*JWTBundleRequest) Descriptor() ([]byte, []int) {
+	return file_jwtsvidapi_jwtsvid_proto_rawDescGZIP(), []int{2}
+}
+
+type JWTBundleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The trust domain of the JWT-SVIDs.
+	TrustDomain string `protobuf:"bytes,1,opt,name=trust_domain,json=trustDomain,proto3" json:"trust_domain,omitempty"`
+	// The keys verifying the JWT-SVIDs, as a JSON Web Key Set.
+	Jwks          []byte `protobuf:"bytes,2,opt,name=jwks,proto3" json:"jwks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JWTBundleResponse) Reset() {
+	*x = JWTBundleResponse{}
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JWTBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JWTBundleResponse) ProtoMessage() {}
+
+func (x *JWTBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_jwtsvidapi_jwtsvid_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JWTBundleResponse.ProtoReflect.Descriptor instead.
+func (*JWTBundleResponse) Descriptor() ([]byte, []int) {
+	return file_jwtsvidapi_jwtsvid_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *JWTBundleResponse) GetTrustDomain() string {
+	if x != nil {
+		return x.TrustDomain
+	}
+	return ""
+}
+
+func (x *JWTBundleResponse) GetJwks() []byte {
+	if x != nil {
+		return x.Jwks
+	}
+	return nil
+}
+
+var File_jwtsvidapi_jwtsvid_proto protoreflect.FileDescriptor
+
+const file_jwtsvidapi_jwtsvid_proto_rawDesc = "" +
+	"\n" +
+	"\x18jwtsvidapi/jwtsvid.proto\x12\x19istio.security.jwtsvid.v1\"[\n" +
+	"\x0eJWTSVIDRequest\x12\x1c\n" +
+	"\taudiences\x18\x01 \x03(\tR\taudiences\x12+\n" +
+	"\x11validity_duration\x18\x02 \x01(\x03R\x10validityDuration\"c\n" +
+	"\x0fJWTSVIDResponse\x12\x1b\n" +
+	"\tspiffe_id\x18\x01 \x01(\tR\bspiffeId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"\x12\n" +
+	"\x10JWTBundleRequest\"J\n" +
+	"\x11JWTBundleResponse\x12!\n" +
+	"\ftrust_domain\x18\x01 \x01(\tR\vtrustDomain\x12\x12\n" +
+	"\x04jwks\x18\x02 \x01(\fR\x04jwks2\xe5\x01\n" +
+	"\x0eJWTSVIDService\x12f\n" +
+	"\rCreateJWTSVID\x12).istio.security.jwtsvid.v1.JWTSVIDRequest\x1a*.istio.security.jwtsvid.v1.JWTSVIDResponse\x12k\n" +
+	"\x0eFetchJWTBundle\x12+.istio.security.jwtsvid.v1.JWTBundleRequest\x1a,.istio.security.jwtsvid.v1.JWTBundleResponseB\x1fZ\x1distio.io/istio/pkg/jwtsvidapib\x06proto3"
+
+var (
+	file_jwtsvidapi_jwtsvid_proto_rawDescOnce sync.Once
+	file_jwtsvidapi_jwtsvid_proto_rawDescData []byte
+)
+
+func file_jwtsvidapi_jwtsvid_proto_rawDescGZIP() []byte {
+	file_jwtsvidapi_jwtsvid_proto_rawDescOnce.Do(func() {
+		file_jwtsvidapi_jwtsvid_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_jwtsvidapi_jwtsvid_proto_rawDesc), len(file_jwtsvidapi_jwtsvid_proto_rawDesc)))
+	})
+	return file_jwtsvidapi_jwtsvid_proto_rawDescData
+}
+
+var file_jwtsvidapi_jwtsvid_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_jwtsvidapi_jwtsvid_proto_goTypes = []any{
+	(*JWTSVIDRequest)(nil),    // 0: istio.security.jwtsvid.v1.JWTSVIDRequest
+	(*JWTSVIDResponse)(nil),   // 1: istio.security.jwtsvid.v1.JWTSVIDResponse
+	(*JWTBundleRequest)(nil),  // 2: istio.security.jwtsvid.v1.JWTBundleRequest
+	(*JWTBundleResponse)(nil), // 3: istio.security.jwtsvid.v1.JWTBundleResponse
+}
+var file_jwtsvidapi_jwtsvid_proto_depIdxs = []int32{
+	0, // 0: istio.security.jwtsvid.v1.JWTSVIDService.CreateJWTSVID:input_type -> istio.security.jwtsvid.v1.JWTSVIDRequest
+	2, // 1: istio.security.jwtsvid.v1.JWTSVIDService.FetchJWTBundle:input_type -> istio.security.jwtsvid.v1.JWTBundleRequest
+	1, // 2: istio.security.jwtsvid.v1.JWTSVIDService.CreateJWTSVID:output_type -> istio.security.jwtsvid.v1.JWTSVIDResponse
+	3, // 3: istio.security.jwtsvid.v1.JWTSVIDService.FetchJWTBundle:output_type -> istio.security.jwtsvid.v1.JWTBundleResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_jwtsvidapi_jwtsvid_proto_init() }
+func file_jwtsvidapi_jwtsvid_proto_init() {
+	if File_jwtsvidapi_jwtsvid_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_jwtsvidapi_jwtsvid_proto_rawDesc), len(file_jwtsvidapi_jwtsvid_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_jwtsvidapi_jwtsvid_proto_goTypes,
+		DependencyIndexes: file_jwtsvidapi_jwtsvid_proto_depIdxs,
+		MessageInfos:      file_jwtsvidapi_jwtsvid_proto_msgTypes,
+	}.Build()
+	File_jwtsvidapi_jwtsvid_proto = out.File
+	file_jwtsvidapi_jwtsvid_proto_goTypes = nil
+	file_jwtsvidapi_jwtsvid_proto_depIdxs = nil
+}
@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: jwtsvidapi/jwtsvid.proto
+
+package jwtsvidapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	JWTSVIDService_CreateJWTSVID_FullMethodName  = "/istio.security.jwtsvid.v1.JWTSVIDService/CreateJWTSVID"
+	JWTSVIDService_FetchJWTBundle_FullMethodName = "/istio.security.jwtsvid.v1.JWTSVIDService/FetchJWTBundle"
+)
+
+// JWTSVIDServiceClient is the client API for JWTSVIDService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// JWTSVIDService issues JWT-SVIDs, so that workloads can authenticate to services accepting bearer tokens.
+// Callers are authenticated as for IstioCertificateService.
+type JWTSVIDServiceClient interface {
+	// CreateJWTSVID returns a JWT-SVID for the identity of the caller.
+	CreateJWTSVID(ctx context.Context, in *JWTSVIDRequest, opts ...grpc.CallOption) (*JWTSVIDResponse, error)
+	// FetchJWTBundle returns the keys verifying the JWT-SVIDs.
+	FetchJWTBundle(ctx context.Context, in *JWTBundleRequest, opts ...grpc.CallOption) (*JWTBundleResponse, error)
+}
+
+type jWTSVIDServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJWTSVIDServiceClient(cc grpc.ClientConnInterface) JWTSVIDServiceClient {
+	return &jWTSVIDServiceClient{cc}
+}
+
+func (c *jWTSVIDServiceClient) CreateJWTSVID(ctx context.Context, in *JWTSVIDRequest, opts ...grpc.CallOption) (*JWTSVIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JWTSVIDResponse)
+	err := c.cc.Invoke(ctx, JWTSVIDService_CreateJWTSVID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jWTSVIDServiceClient) FetchJWTBundle(ctx context.Context, in *JWTBundleRequest, opts ...grpc.CallOption) (*JWTBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JWTBundleResponse)
+	err := c.cc.Invoke(ctx, JWTSVIDService_FetchJWTBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// JWTSVIDServiceServer is the server API for JWTSVIDService service.
+// All implementations must embed UnimplementedJWTSVIDServiceServer
+// for forward compatibility.
+//
+// JWTSVIDService issues JWT-SVIDs, so that workloads can authenticate to services accepting bearer tokens.
+// Callers are authenticated as for IstioCertificateService.
+type JWTSVIDServiceServer interface {
+	// CreateJWTSVID returns a JWT-SVID for the identity of the caller.
+	CreateJWTSVID(context.Context, *JWTSVIDRequest) (*JWTSVIDResponse, error)
+	// FetchJWTBundle returns the keys verifying the JWT-SVIDs.
+	FetchJWTBundle(context.Context, *JWTBundleRequest) (*JWTBundleResponse, error)
+	mustEmbedUnimplementedJWTSVIDServiceServer()
+}
+
+// UnimplementedJWTSVIDServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJWTSVIDServiceServer struct{}
+
+func (UnimplementedJWTSVIDServiceServer) CreateJWTSVID(context.Context, *JWTSVIDRequest) (*JWTSVIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateJWTSVID not implemented")
+}
+func (UnimplementedJWTSVIDServiceServer) FetchJWTBundle(context.Context, *JWTBundleRequest) (*JWTBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchJWTBundle not implemented")
+}
+func (UnimplementedJWTSVIDServiceServer) mustEmbedUnimplementedJWTSVIDServiceServer() {}
+func (UnimplementedJWTSVIDServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeJWTSVIDServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JWTSVIDServiceServer will
+// result in compilation errors.
+type UnsafeJWTSVIDServiceServer interface {
+	mustEmbedUnimplementedJWTSVIDServiceServer()
+}
+
+func RegisterJWTSVIDServiceServer(s grpc.ServiceRegistrar, srv JWTSVIDServiceServer) {
+	// If the following call pancis, it indicates UnimplementedJWTSVIDServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JWTSVIDService_ServiceDesc, srv)
+}
+
+func _JWTSVIDService_CreateJWTSVID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JWTSVIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JWTSVIDServiceServer).CreateJWTSVID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JWTSVIDService_CreateJWTSVID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JWTSVIDServiceServer).CreateJWTSVID(ctx, req.(*JWTSVIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JWTSVIDService_FetchJWTBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JWTBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JWTSVIDServiceServer).FetchJWTBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JWTSVIDService_FetchJWTBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JWTSVIDServiceServer).FetchJWTBundle(ctx, req.(*JWTBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// JWTSVIDService_ServiceDesc is the grpc.ServiceDesc for JWTSVIDService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JWTSVIDService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "istio.security.jwtsvid.v1.JWTSVIDService",
+	HandlerType: (*JWTSVIDServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateJWTSVID",
+			Handler:    _JWTSVIDService_CreateJWTSVID_Handler,
+		},
+		{
+			MethodName: "FetchJWTBundle",
+			Handler:    _JWTSVIDService_FetchJWTBundle_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "jwtsvidapi/jwtsvid.proto",
+}
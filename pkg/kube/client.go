@@ -182,6 +182,9 @@ type CLIClient interface {
 	// AllDiscoveryDo makes a http request to each Istio discovery instance.
 	AllDiscoveryDo(ctx context.Context, namespace, path string) (map[string][]byte, error)
 
+	// AllDiscoveryDoWithBody makes a http request with the given method and body to each Istio discovery instance.
+	AllDiscoveryDoWithBody(ctx context.Context, namespace, method, path string, body []byte) (map[string][]byte, error)
+
 	// GetIstioVersions gets the version for each Istio control plane component.
 	GetIstioVersions(ctx context.Context, namespace string) (*version.MeshInfo, error)
 
@@ -1020,6 +1023,10 @@ func (c *client) PodLogsFollow(ctx context.Context, podName, podNamespace, conta
 }
 
 func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path string) (map[string][]byte, error) {
+	return c.AllDiscoveryDoWithBody(ctx, istiodNamespace, http.MethodGet, path, nil)
+}
+
+func (c *client) AllDiscoveryDoWithBody(ctx context.Context, istiodNamespace, method, path string, body []byte) (map[string][]byte, error) {
 	istiods, err := c.GetIstioPods(ctx, istiodNamespace, metav1.ListOptions{
 		LabelSelector: "app=istiod",
 		FieldSelector: RunningStatus,
@@ -1034,7 +1041,7 @@ func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path strin
 	result := map[string][]byte{}
 	for _, istiod := range istiods {
 		monitoringPort := FindIstiodMonitoringPort(&istiod)
-		res, err := c.portForwardRequest(ctx, istiod.Name, istiod.Namespace, http.MethodGet, path, monitoringPort)
+		res, err := c.portForwardRequest(ctx, istiod.Name, istiod.Namespace, method, path, monitoringPort, body)
 		if err != nil {
 			return nil, err
 		}
@@ -1050,10 +1057,10 @@ func (c *client) AllDiscoveryDo(ctx context.Context, istiodNamespace, path strin
 }
 
 func (c *client) EnvoyDoWithPort(ctx context.Context, podName, podNamespace, method, path string, port int) ([]byte, error) {
-	return c.portForwardRequest(ctx, podName, podNamespace, method, path, port)
+	return c.portForwardRequest(ctx, podName, podNamespace, method, path, port, nil)
 }
 
-func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace, method, path string, port int) ([]byte, error) {
+func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace, method, path string, port int, body []byte) ([]byte, error) {
 	formatError := func(err error) error {
 		return fmt.Errorf("failure running port forward process: %v", err)
 	}
@@ -1066,7 +1073,11 @@ func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace,
 		return nil, formatError(err)
 	}
 	defer fw.Close()
-	req, err := http.NewRequest(method, fmt.Sprintf("http://%s/%s", fw.Address(), path), nil)
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s/%s", fw.Address(), path), reqBody)
 	if err != nil {
 		return nil, formatError(err)
 	}
@@ -1075,10 +1086,13 @@ func (c *client) portForwardRequest(ctx context.Context, podName, podNamespace,
 		return nil, formatError(err)
 	}
 	defer closeQuietly(resp.Body)
+	out, err := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, msg)
+		}
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-	out, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, formatError(err)
 	}
@@ -1131,7 +1145,7 @@ func (c *client) GetIstioVersions(ctx context.Context, namespace string) (*versi
 		}
 
 		monitoringPort := FindIstiodMonitoringPort(&pod)
-		result, err := c.portForwardRequest(ctx, pod.Name, pod.Namespace, http.MethodGet, "/version", monitoringPort)
+		result, err := c.portForwardRequest(ctx, pod.Name, pod.Namespace, http.MethodGet, "/version", monitoringPort, nil)
 		if err != nil {
 			errs = multierror.Append(errs,
 				fmt.Errorf("error port-forwarding into %s.%s: %v", pod.Namespace, pod.Name, err),
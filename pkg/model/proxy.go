@@ -254,6 +254,10 @@ type NodeMetadata struct {
 	// RequestedNetworkView specifies the networks that the proxy wants to see
 	RequestedNetworkView StringList `json:"REQUESTED_NETWORK_VIEW,omitempty"`
 
+	// MTLSSignatureAlgorithms are the signature algorithms accepted in the mesh mTLS of the proxy, unless overridden
+	// with the security.istio.io/mtls-signature-algorithms annotation.
+	MTLSSignatureAlgorithms StringList `json:"MTLS_SIGNATURE_ALGORITHMS,omitempty"`
+
 	// PodPorts defines the ports on a pod. This is used to lookup named ports.
 	PodPorts PodPortList `json:"POD_PORTS,omitempty"`
 
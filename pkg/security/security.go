@@ -16,6 +16,7 @@ package security
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -123,6 +124,12 @@ const (
 	// GkeWorkloadCertificateProvider uses the GKE workload certificates
 	GkeWorkloadCertificateProvider = "GkeWorkloadCertificate"
 
+	// ACMEProvider uses an ACME (RFC 8555) CA to sign workload certificates
+	ACMEProvider = "ACME"
+
+	// VaultProvider uses the PKI secrets engine of HashiCorp Vault to sign workload certificates
+	VaultProvider = "Vault"
+
 	// FileRootSystemCACert is a unique resource name signaling that the system CA certificate should be used
 	FileRootSystemCACert = "file-root:system"
 
@@ -285,6 +292,48 @@ type Client interface {
 	GetRootCertBundle() ([]string, error)
 }
 
+// CSRHostsClient is implemented by the clients of CAs which can not certify the workload SPIFFE identity, such as
+// ACME CAs. The CSR is then generated for the returned hosts instead of the identity.
+type CSRHostsClient interface {
+	CSRHosts(identity string) []string
+}
+
+// JWTSVIDClient is implemented by the clients of CAs which issue JWT-SVIDs.
+type JWTSVIDClient interface {
+	// JWTSVIDSign returns a JWT-SVID for the audiences. If validTTLInSec is 0, the CA default is used.
+	JWTSVIDSign(audiences []string, validTTLInSec int64) (*JWTSVID, error)
+	// GetJWTBundle returns the keys verifying the JWT-SVIDs issued by the CA.
+	GetJWTBundle() (*JWTBundle, error)
+}
+
+// JWTSVIDManager is implemented by the secret managers which can issue JWT-SVIDs.
+type JWTSVIDManager interface {
+	// GenerateJWTSVID returns a JWT-SVID for the audiences, or ErrJWTSVIDNotSupported if the CA does not issue them.
+	GenerateJWTSVID(audiences []string) (*JWTSVID, error)
+	// GenerateJWTBundle returns the keys verifying the JWT-SVIDs, or ErrJWTSVIDNotSupported.
+	GenerateJWTBundle() (*JWTBundle, error)
+}
+
+// ErrJWTSVIDNotSupported is returned when the CA does not issue JWT-SVIDs.
+var ErrJWTSVIDNotSupported = errors.New("the CA does not issue JWT-SVIDs")
+
+// JWTSVID is a JWT-SVID issued to the workload.
+type JWTSVID struct {
+	SpiffeID string
+	Token    string
+
+	CreatedTime time.Time
+
+	ExpireTime time.Time
+}
+
+// JWTBundle holds the keys verifying the JWT-SVIDs of a trust domain.
+type JWTBundle struct {
+	TrustDomain string
+	// JWKS is the JSON Web Key Set of the keys.
+	JWKS []byte
+}
+
 // SecretManager defines secrets management interface which is used by SDS.
 type SecretManager interface {
 	// GenerateSecret generates new secret for the given resource.
@@ -368,6 +417,8 @@ func (ac *AuthContext) Header(header string) []string {
 type Caller struct {
 	AuthSource AuthSource
 	Identities []string
+	// Authenticator is the type of the authenticator that authenticated the caller.
+	Authenticator string
 
 	KubernetesInfo KubernetesInfo
 }
@@ -405,6 +456,9 @@ func (am *authenticationManager) authenticate(ctx context.Context) *Caller {
 		u, err := authn.Authenticate(req)
 		if u != nil && len(u.Identities) > 0 && err == nil {
 			securityLog.Debugf("Authentication successful through auth source %v", u.AuthSource)
+			if u.Authenticator == "" {
+				u.Authenticator = authn.AuthenticatorType()
+			}
 			return u
 		}
 		am.authFailMsgs = append(am.authFailMsgs, fmt.Sprintf("Authenticator %s: %v", authn.AuthenticatorType(), err))
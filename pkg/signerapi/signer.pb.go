@@ -0,0 +1,284 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: signerapi/signer.proto
+
+package signerapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The hash function used to compute a digest.
+type Hash int32
+
+const (
+	Hash_HASH_UNSPECIFIED Hash = 0
+	Hash_SHA256           Hash = 1
+	Hash_SHA384           Hash = 2
+	Hash_SHA512           Hash = 3
+)
+
+// Enum value maps for Hash.
+var (
+	Hash_name = map[int32]string{
+		0: "HASH_UNSPECIFIED",
+		1: "SHA256",
+		2: "SHA384",
+		3: "SHA512",
+	}
+	Hash_value = map[string]int32{
+		"HASH_UNSPECIFIED": 0,
+		"SHA256":           1,
+		"SHA384":           2,
+		"SHA512":           3,
+	}
+)
+
+func (x Hash) Enum() *Hash {
+	p := new(Hash)
+	*p = x
+	return p
+}
+
+func (x Hash) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Hash) Descriptor() protoreflect.EnumDescriptor {
+	return file_signerapi_signer_proto_enumTypes[0].Descriptor()
+}
+
+func (Hash) Type() protoreflect.EnumType {
+	return &file_signerapi_signer_proto_enumTypes[0]
+}
+
+func (x Hash) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Hash.Descriptor instead.
+func (Hash) EnumDescriptor() ([]byte, []int) {
+	return file_signerapi_signer_proto_rawDescGZIP(), []int{0}
+}
+
+type SignRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The name of the key, as configured with --caSignerKey.
+	KeyName string `protobuf:"bytes,1,opt,name=key_name,json=keyName,proto3" json:"key_name,omitempty"`
+	// The subject key identifier of the CA certificate, to select the version of a rotated key.
+	KeyId []byte `protobuf:"bytes,2,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	// The hash function used to compute the digest.
+	Hash Hash `protobuf:"varint,3,opt,name=hash,proto3,enum=istio.security.signer.v1.Hash" json:"hash,omitempty"`
+	// The digest to sign.
+	Digest        []byte `protobuf:"bytes,4,opt,name=digest,proto3" json:"digest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignRequest) Reset() {
+	*x = SignRequest{}
+	mi := &file_signerapi_signer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRequest) ProtoMessage() {}
+
+func (x *SignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_signerapi_signer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRequest.ProtoReflect.Descriptor instead.
+func (*SignRequest) Descriptor() ([]byte, []int) {
+	return file_signerapi_signer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SignRequest) GetKeyName() string {
+	if x != nil {
+		return x.KeyName
+	}
+	return ""
+}
+
+func (x *SignRequest) GetKeyId() []byte {
+	if x != nil {
+		return x.KeyId
+	}
+	return nil
+}
+
+func (x *SignRequest) GetHash() Hash {
+	if x != nil {
+		return x.Hash
+	}
+	return Hash_HASH_UNSPECIFIED
+}
+
+func (x *SignRequest) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+type SignResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The signature of the digest: PKCS#1 v1.5 for RSA keys, or ASN.1 DER for ECDSA keys.
+	Signature     []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignResponse) Reset() {
+	*x = SignResponse{}
+	mi := &file_signerapi_signer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignResponse) ProtoMessage() {}
+
+func (x *SignResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_signerapi_signer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignResponse.ProtoReflect.Descriptor instead.
+func (*SignResponse) Descriptor() ([]byte, []int) {
+	return file_signerapi_signer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SignResponse) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+var File_signerapi_signer_proto protoreflect.FileDescriptor
+
+const file_signerapi_signer_proto_rawDesc = "" +
+	"\n" +
+	"\x16signerapi/signer.proto\x12\x18istio.security.signer.v1\"\x8b\x01\n" +
+	"\vSignRequest\x12\x19\n" +
+	"\bkey_name\x18\x01 \x01(\tR\akeyName\x12\x15\n" +
+	"\x06key_id\x18\x02 \x01(\fR\x05keyId\x122\n" +
+	"\x04hash\x18\x03 \x01(\x0e2\x1e.istio.security.signer.v1.HashR\x04hash\x12\x16\n" +
+	"\x06digest\x18\x04 \x01(\fR\x06digest\",\n" +
+	"\fSignResponse\x12\x1c\n" +
+	"\tsignature\x18\x01 \x01(\fR\tsignature*@\n" +
+	"\x04Hash\x12\x14\n" +
+	"\x10HASH_UNSPECIFIED\x10\x00\x12\n" +
+	"\n" +
+	"\x06SHA256\x10\x01\x12\n" +
+	"\n" +
+	"\x06SHA384\x10\x02\x12\n" +
+	"\n" +
+	"\x06SHA512\x10\x032e\n" +
+	"\fRemoteSigner\x12U\n" +
+	"\x04Sign\x12%.istio.security.signer.v1.SignRequest\x1a&.istio.security.signer.v1.SignResponseB\x1eZ\x1cistio.io/istio/pkg/signerapib\x06proto3"
+
+var (
+	file_signerapi_signer_proto_rawDescOnce sync.Once
+	file_signerapi_signer_proto_rawDescData []byte
+)
+
+func file_signerapi_signer_proto_rawDescGZIP() []byte {
+	file_signerapi_signer_proto_rawDescOnce.Do(func() {
+		file_signerapi_signer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_signerapi_signer_proto_rawDesc), len(file_signerapi_signer_proto_rawDesc)))
+	})
+	return file_signerapi_signer_proto_rawDescData
+}
+
+var file_signerapi_signer_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_signerapi_signer_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_signerapi_signer_proto_goTypes = []any{
+	(Hash)(0),            // 0: istio.security.signer.v1.Hash
+	(*SignRequest)(nil),  // 1: istio.security.signer.v1.SignRequest
+	(*SignResponse)(nil), // 2: istio.security.signer.v1.SignResponse
+}
+var file_signerapi_signer_proto_depIdxs = []int32{
+	0, // 0: istio.security.signer.v1.SignRequest.hash:type_name -> istio.security.signer.v1.Hash
+	1, // 1: istio.security.signer.v1.RemoteSigner.Sign:input_type -> istio.security.signer.v1.SignRequest
+	2, // 2: istio.security.signer.v1.RemoteSigner.Sign:output_type -> istio.security.signer.v1.SignResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_signerapi_signer_proto_init() }
+func file_signerapi_signer_proto_init() {
+	if File_signerapi_signer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_signerapi_signer_proto_rawDesc), len(file_signerapi_signer_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_signerapi_signer_proto_goTypes,
+		DependencyIndexes: file_signerapi_signer_proto_depIdxs,
+		EnumInfos:         file_signerapi_signer_proto_enumTypes,
+		MessageInfos:      file_signerapi_signer_proto_msgTypes,
+	}.Build()
+	File_signerapi_signer_proto = out.File
+	file_signerapi_signer_proto_goTypes = nil
+	file_signerapi_signer_proto_depIdxs = nil
+}
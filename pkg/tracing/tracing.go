@@ -16,6 +16,7 @@ package tracing
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"os"
 
@@ -126,3 +127,29 @@ func InitializeFullBinary(rootSpan string) (context.Context, func(), error) {
 func Start(ctx context.Context, span string) (context.Context, traceapi.Span) {
 	return tracer().Start(ctx, span)
 }
+
+// StartLinked starts a new root span, linked to the given span contexts. This is used when a single operation is
+// caused by multiple independent operations, each with their own trace.
+func StartLinked(ctx context.Context, span string, links []traceapi.SpanContext) (context.Context, traceapi.Span) {
+	opts := []traceapi.SpanStartOption{traceapi.WithNewRoot()}
+	for _, l := range links {
+		if l.IsValid() {
+			opts = append(opts, traceapi.WithLinks(traceapi.Link{SpanContext: l}))
+		}
+	}
+	return tracer().Start(ctx, span, opts...)
+}
+
+// SpanContextOrNew returns the span context of the span. If it is not valid, which is the case when no tracer
+// provider is initialized, a new span context with random IDs is returned instead. This allows operations to be
+// correlated by trace ID even when traces are not exported.
+func SpanContextOrNew(span traceapi.Span) traceapi.SpanContext {
+	if sc := span.SpanContext(); sc.IsValid() {
+		return sc
+	}
+	var tid traceapi.TraceID
+	var sid traceapi.SpanID
+	_, _ = rand.Read(tid[:])
+	_, _ = rand.Read(sid[:])
+	return traceapi.NewSpanContext(traceapi.SpanContextConfig{TraceID: tid, SpanID: sid})
+}
@@ -0,0 +1,69 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// RedirectHeader is the gRPC metadata key clients that follow redirects set on their streams.
+	// Servers only redirect streams that set it to RedirectSupported. Streams that set it to RedirectFollowed were
+	// made by following a redirect, and are always accepted to avoid redirect loops.
+	RedirectHeader = "istio-xds-redirect"
+	// RedirectSupported marks a stream of a client that follows redirects.
+	RedirectSupported = "supported"
+	// RedirectFollowed marks a stream made by following a redirect.
+	RedirectFollowed = "followed"
+
+	redirectReason     = "XDS_REDIRECT"
+	redirectDomain     = "istio.io"
+	redirectAddressKey = "address"
+)
+
+// RedirectError returns an error that closes a stream and asks the client to reconnect to address, much like an
+// HTTP/2 GOAWAY with an alternate server.
+func RedirectError(address string) error {
+	st := status.New(codes.Unavailable, "connection redirected to "+address)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   redirectReason,
+		Domain:   redirectDomain,
+		Metadata: map[string]string{redirectAddressKey: address},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// RedirectAddress returns the address an error returned by RedirectError redirects to.
+func RedirectAddress(err error) (string, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Unavailable {
+		return "", false
+	}
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Reason != redirectReason || info.Domain != redirectDomain {
+			continue
+		}
+		if addr := info.Metadata[redirectAddressKey]; addr != "" {
+			return addr, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,39 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestRedirectAddress(t *testing.T) {
+	addr, ok := RedirectAddress(RedirectError("10.0.0.2:15012"))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, addr, "10.0.0.2:15012")
+
+	for _, err := range []error{
+		errors.New("connection redirected to 10.0.0.2:15012"),
+		status.Error(codes.Unavailable, "connection redirected to 10.0.0.2:15012"),
+	} {
+		_, ok := RedirectAddress(err)
+		assert.Equal(t, ok, false)
+	}
+}
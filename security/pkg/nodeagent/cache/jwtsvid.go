@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/slices"
+)
+
+// jwtBundleRefreshInterval is how long the JWT bundle is cached before it is fetched again from the CA.
+const jwtBundleRefreshInterval = 5 * time.Minute
+
+var _ security.JWTSVIDManager = &SecretManagerClient{}
+
+// GenerateJWTSVID returns a JWT-SVID for the audiences. JWT-SVIDs are cached, and renewed from the CA once half of
+// their lifetime has passed.
+func (sc *SecretManagerClient) GenerateJWTSVID(audiences []string) (*security.JWTSVID, error) {
+	client, ok := sc.caClient.(security.JWTSVIDClient)
+	if !ok {
+		return nil, security.ErrJWTSVIDNotSupported
+	}
+	key := strings.Join(slices.Sort(slices.Clone(audiences)), "\n")
+
+	sc.jwtMutex.Lock()
+	defer sc.jwtMutex.Unlock()
+	now := time.Now()
+	for k, svid := range sc.jwtSVIDs {
+		if !now.Before(svid.ExpireTime) {
+			delete(sc.jwtSVIDs, k)
+		}
+	}
+	if svid := sc.jwtSVIDs[key]; svid != nil && now.Before(svid.CreatedTime.Add(svid.ExpireTime.Sub(svid.CreatedTime)/2)) {
+		return svid, nil
+	}
+	svid, err := client.JWTSVIDSign(audiences, 0)
+	if err != nil {
+		return nil, err
+	}
+	cacheLog.Debugf("JWT-SVID generated for %s, audiences %v, expires at %v", svid.SpiffeID, audiences, svid.ExpireTime)
+	sc.jwtSVIDs[key] = svid
+	return svid, nil
+}
+
+// GenerateJWTBundle returns the keys verifying the JWT-SVIDs. The bundle is cached, and fetched again from the CA
+// every jwtBundleRefreshInterval; if that fails, the cached bundle is returned.
+func (sc *SecretManagerClient) GenerateJWTBundle() (*security.JWTBundle, error) {
+	client, ok := sc.caClient.(security.JWTSVIDClient)
+	if !ok {
+		return nil, security.ErrJWTSVIDNotSupported
+	}
+
+	sc.jwtMutex.Lock()
+	defer sc.jwtMutex.Unlock()
+	if sc.jwtBundle != nil && time.Since(sc.jwtBundleTime) < jwtBundleRefreshInterval {
+		return sc.jwtBundle, nil
+	}
+	bundle, err := client.GetJWTBundle()
+	if err != nil {
+		if sc.jwtBundle != nil {
+			cacheLog.Warnf("failed to refresh JWT bundle, using the cached one: %v", err)
+			return sc.jwtBundle, nil
+		}
+		return nil, err
+	}
+	sc.jwtBundle = bundle
+	sc.jwtBundleTime = time.Now()
+	return bundle, nil
+}
@@ -119,6 +119,12 @@ type SecretManagerClient struct {
 	stop  chan struct{}
 
 	caRootPath string
+
+	// jwtMutex protects the cached JWT-SVIDs, by audiences, and JWT bundle.
+	jwtMutex      sync.Mutex
+	jwtSVIDs      map[string]*security.JWTSVID
+	jwtBundle     *security.JWTBundle
+	jwtBundleTime time.Time
 }
 
 type secretCache struct {
@@ -186,6 +192,7 @@ func NewSecretManagerClient(caClient security.Client, options *security.Options)
 		fileCerts:   make(map[FileCert]struct{}),
 		stop:        make(chan struct{}),
 		caRootPath:  options.CARootPath,
+		jwtSVIDs:    map[string]*security.JWTSVID{},
 	}
 
 	go ret.queue.Run(ret.stop)
@@ -799,6 +806,10 @@ func (sc *SecretManagerClient) generateNewSecret(resourceName string) (*security
 		ECSigAlg:   pkiutil.SupportedECSignatureAlgorithms(sc.configOptions.ECCSigAlg),
 		ECCCurve:   pkiutil.SupportedEllipticCurves(sc.configOptions.ECCCurve),
 	}
+	if c, ok := sc.caClient.(security.CSRHostsClient); ok {
+		options.Host = strings.Join(c.CSRHosts(options.Host), ",")
+		cacheLog.Debugf("%s CA client overrides the CSR hosts: %s", logPrefix, options.Host)
+	}
 
 	// Generate the cert/key, send CSR to CA.
 	csrPEM, keyPEM, err := pkiutil.GenCSR(options)
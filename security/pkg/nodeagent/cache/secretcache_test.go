@@ -91,6 +91,55 @@ func TestWorkloadAgentGenerateSecret(t *testing.T) {
 	mt.Assert(certExpirySeconds.Name(), map[string]string{"resource_name": "default"}, monitortest.LessThan(certDefaultTTL))
 }
 
+// csrHostsCAClient is a CA client requesting its own hosts in the CSRs, like an ACME CA client.
+type csrHostsCAClient struct {
+	*mock.CAClient
+	hosts []string
+
+	mu       sync.Mutex
+	identity string
+	csrHosts []string
+}
+
+func (c *csrHostsCAClient) CSRHosts(identity string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.identity = identity
+	return c.hosts
+}
+
+func (c *csrHostsCAClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]string, error) {
+	csr, err := pkiutil.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.csrHosts = csr.DNSNames
+	c.mu.Unlock()
+	return c.CAClient.CSRSign(csrPEM, certValidTTLInSec)
+}
+
+func TestWorkloadAgentGenerateSecretCSRHosts(t *testing.T) {
+	fakeCACli, err := mock.NewMockCAClient(time.Hour, false)
+	if err != nil {
+		t.Fatalf("Error creating Mock CA client: %v", err)
+	}
+	caClient := &csrHostsCAClient{CAClient: fakeCACli, hosts: []string{"foo.example.com", "bar.example.com"}}
+	sc := createCache(t, caClient, func(resourceName string) {}, security.Options{
+		WorkloadRSAKeySize: 2048,
+		TrustDomain:        "cluster.local",
+		WorkloadNamespace:  "default",
+		ServiceAccount:     "sa",
+	})
+	if _, err := sc.GenerateSecret(security.WorkloadKeyCertResourceName); err != nil {
+		t.Fatalf("Failed to get secrets: %v", err)
+	}
+	caClient.mu.Lock()
+	defer caClient.mu.Unlock()
+	assert.Equal(t, caClient.identity, "spiffe://cluster.local/ns/default/sa/sa")
+	assert.Equal(t, caClient.csrHosts, caClient.hosts)
+}
+
 func createCache(t *testing.T, caClient security.Client, notifyCb func(resourceName string), options security.Options) *SecretManagerClient {
 	t.Helper()
 	sc, err := NewSecretManagerClient(caClient, &options)
@@ -1554,3 +1603,89 @@ func TestResolveSymlink(t *testing.T) {
 		})
 	}
 }
+
+type jwtSVIDCAClient struct {
+	*mock.CAClient
+	// lifetime is the lifetime of the JWT-SVIDs; they are issued as if created lifetime*age ago.
+	lifetime time.Duration
+	age      float64
+
+	mu        sync.Mutex
+	svids     int
+	bundles   int
+	bundleErr error
+}
+
+func (c *jwtSVIDCAClient) JWTSVIDSign(audiences []string, _ int64) (*security.JWTSVID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.svids++
+	created := time.Now().Add(-time.Duration(float64(c.lifetime) * c.age))
+	return &security.JWTSVID{
+		SpiffeID:    "spiffe://cluster.local/ns/default/sa/sa",
+		Token:       fmt.Sprintf("token-%d-%v", c.svids, audiences),
+		CreatedTime: created,
+		ExpireTime:  created.Add(c.lifetime),
+	}, nil
+}
+
+func (c *jwtSVIDCAClient) GetJWTBundle() (*security.JWTBundle, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bundles++
+	if c.bundleErr != nil {
+		return nil, c.bundleErr
+	}
+	return &security.JWTBundle{TrustDomain: "cluster.local", JWKS: []byte(`{"keys":[]}`)}, nil
+}
+
+func TestGenerateJWTSVID(t *testing.T) {
+	fakeCACli, err := mock.NewMockCAClient(time.Hour, false)
+	if err != nil {
+		t.Fatalf("Error creating Mock CA client: %v", err)
+	}
+
+	t.Run("not supported", func(t *testing.T) {
+		sc := createCache(t, fakeCACli, func(resourceName string) {}, security.Options{})
+		_, err := sc.GenerateJWTSVID([]string{"kafka"})
+		assert.Equal(t, err, security.ErrJWTSVIDNotSupported)
+		_, err = sc.GenerateJWTBundle()
+		assert.Equal(t, err, security.ErrJWTSVIDNotSupported)
+	})
+	t.Run("cached by audiences", func(t *testing.T) {
+		caClient := &jwtSVIDCAClient{CAClient: fakeCACli, lifetime: time.Hour}
+		sc := createCache(t, caClient, func(resourceName string) {}, security.Options{})
+		a, err := sc.GenerateJWTSVID([]string{"kafka", "db"})
+		assert.NoError(t, err)
+		b, err := sc.GenerateJWTSVID([]string{"db", "kafka"})
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+		c, err := sc.GenerateJWTSVID([]string{"kafka"})
+		assert.NoError(t, err)
+		assert.Equal(t, c.Token != a.Token, true)
+		assert.Equal(t, caClient.svids, 2)
+	})
+	t.Run("renewed after half of the lifetime", func(t *testing.T) {
+		caClient := &jwtSVIDCAClient{CAClient: fakeCACli, lifetime: time.Hour, age: 0.6}
+		sc := createCache(t, caClient, func(resourceName string) {}, security.Options{})
+		a, err := sc.GenerateJWTSVID([]string{"kafka"})
+		assert.NoError(t, err)
+		b, err := sc.GenerateJWTSVID([]string{"kafka"})
+		assert.NoError(t, err)
+		assert.Equal(t, b.Token != a.Token, true)
+		assert.Equal(t, caClient.svids, 2)
+	})
+	t.Run("bundle cached", func(t *testing.T) {
+		caClient := &jwtSVIDCAClient{CAClient: fakeCACli}
+		sc := createCache(t, caClient, func(resourceName string) {}, security.Options{})
+		_, err := sc.GenerateJWTBundle()
+		assert.NoError(t, err)
+		// A failed refresh returns the cached bundle.
+		sc.jwtBundleTime = time.Now().Add(-2 * jwtBundleRefreshInterval)
+		caClient.bundleErr = fmt.Errorf("unavailable")
+		bundle, err := sc.GenerateJWTBundle()
+		assert.NoError(t, err)
+		assert.Equal(t, bundle.TrustDomain, "cluster.local")
+		assert.Equal(t, caClient.bundles, 2)
+	})
+}
@@ -0,0 +1,328 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package acme implements a CA client ordering the workload certificates from an ACME (RFC 8555) CA.
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	http01ChallengePrefix = "/.well-known/acme-challenge/"
+	defaultTimeout        = 2 * time.Minute
+)
+
+var acmeClientLog = log.RegisterScope("acmeclient", "ACME CA client debugging")
+
+// Options configures the ACME client.
+type Options struct {
+	// DirectoryURL is the URL of the ACME directory of the CA.
+	DirectoryURL string
+	// RootCert is the path to the certificates trusted to connect to the CA. The system roots are used if empty.
+	RootCert string
+	// Hosts are the DNS names and IP addresses the workload certificates are ordered for. ACME CAs do not certify
+	// SPIFFE identities, so the certificates do not carry one.
+	Hosts []string
+	// Email is the contact of the ACME account.
+	Email string
+	// AccountKeyFile is the path to the PEM encoded private key of the ACME account. If empty, a key is generated, and
+	// a new account is registered each time the agent starts.
+	AccountKeyFile string
+	// EABKeyID and EABHMACKey are the external account binding credentials, for CAs requiring one.
+	EABKeyID   string
+	EABHMACKey []byte
+	// HTTP01Address is the address the http-01 challenge responses are served on. If empty, the authorizations of the
+	// orders must already be valid, for instance with a pre-authorized account.
+	HTTP01Address string
+	// TrustBundleFile is the path to the root certificates of the CA. ACME CAs do not return their root, so if empty,
+	// the last certificate of the chain is used as the root.
+	TrustBundleFile string
+	// Timeout bounds the time to order a certificate. Defaults to 2 minutes.
+	Timeout time.Duration
+}
+
+// Client orders the workload certificates from an ACME CA.
+type Client struct {
+	opts   Options
+	client *acme.Client
+	ids    []acme.AuthzID
+
+	mu         sync.Mutex
+	registered bool
+
+	// challenges holds the http-01 key authorizations being validated, by token.
+	challenges sync.Map
+	server     *http.Server
+}
+
+var (
+	_ security.Client         = &Client{}
+	_ security.CSRHostsClient = &Client{}
+)
+
+// NewClient creates a CA client for an ACME CA.
+func NewClient(opts Options) (*Client, error) {
+	if opts.DirectoryURL == "" {
+		return nil, errors.New("the ACME directory URL is required")
+	}
+	if len(opts.Hosts) == 0 {
+		return nil, errors.New("at least one host is required to order certificates from an ACME CA")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	key, err := accountKey(opts.AccountKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := newHTTPClient(opts.RootCert)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		opts: opts,
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: opts.DirectoryURL,
+			HTTPClient:   httpClient,
+			UserAgent:    "istio-agent",
+		},
+	}
+	for _, h := range opts.Hosts {
+		if net.ParseIP(h) != nil {
+			c.ids = append(c.ids, acme.IPIDs(h)...)
+		} else {
+			c.ids = append(c.ids, acme.DomainIDs(h)...)
+		}
+	}
+
+	if opts.HTTP01Address != "" {
+		l, err := net.Listen("tcp", opts.HTTP01Address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for http-01 challenges on %s: %v", opts.HTTP01Address, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc(http01ChallengePrefix, c.serveHTTP01)
+		c.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := c.server.Serve(l); err != nil && err != http.ErrServerClosed {
+				acmeClientLog.Errorf("http-01 challenge server stopped: %v", err)
+			}
+		}()
+	}
+	return c, nil
+}
+
+func accountKey(file string) (crypto.Signer, error) {
+	if file == "" {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the ACME account key: %v", err)
+	}
+	key, err := pkiutil.ParsePemEncodedKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the ACME account key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported ACME account key type %T", key)
+	}
+	return signer, nil
+}
+
+func newHTTPClient(rootCert string) (*http.Client, error) {
+	if rootCert == "" {
+		return http.DefaultClient, nil
+	}
+	b, err := os.ReadFile(rootCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA root certificate %s: %v", rootCert, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no certificate found in %s", rootCert)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
+
+func (c *Client) serveHTTP01(w http.ResponseWriter, r *http.Request) {
+	keyAuth, ok := c.challenges.Load(strings.TrimPrefix(r.URL.Path, http01ChallengePrefix))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(keyAuth.(string)))
+}
+
+// CSRHosts returns the hosts the certificates are ordered for, which the CSR must request exactly.
+func (c *Client) CSRHosts(string) []string {
+	return c.opts.Hosts
+}
+
+// CSRSign orders a certificate for the CSR. The validity of the certificate is chosen by the CA, so the requested
+// TTL is ignored.
+func (c *Client) CSRSign(csrPEM []byte, _ int64) ([]string, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, errors.New("invalid PEM encoded CSR")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	if err := c.register(ctx); err != nil {
+		return nil, err
+	}
+	order, err := c.client.AuthorizeOrder(ctx, c.ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the order: %v", err)
+	}
+	for _, u := range order.AuthzURLs {
+		if err := c.authorize(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	orderURI := order.URI
+	if order, err = c.client.WaitOrder(ctx, orderURI); err != nil {
+		return nil, fmt.Errorf("order %s failed: %v", orderURI, err)
+	}
+	der, _, err := c.client.CreateOrderCert(ctx, order.FinalizeURL, block.Bytes, true)
+	if err != nil {
+		// CreateOrderCert waits for a processing order at the location of the finalize response, which CAs such as
+		// Pebble do not return. Wait for it at the order URL instead.
+		order, waitErr := c.client.WaitOrder(ctx, orderURI)
+		if waitErr != nil || order.Status != acme.StatusValid {
+			return nil, fmt.Errorf("failed to finalize the order: %v", err)
+		}
+		if der, err = c.client.FetchCert(ctx, order.CertURL, true); err != nil {
+			return nil, fmt.Errorf("failed to fetch the certificate %s: %v", order.CertURL, err)
+		}
+	}
+	acmeClientLog.Debugf("issued certificate for order %s", orderURI)
+
+	chain := make([]string, 0, len(der))
+	for _, cert := range der {
+		chain = append(chain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})))
+	}
+	return chain, nil
+}
+
+// register registers the ACME account, once. An account already registered with the key is used as is.
+func (c *Client) register(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registered {
+		return nil
+	}
+	acct := &acme.Account{}
+	if c.opts.Email != "" {
+		acct.Contact = []string{"mailto:" + c.opts.Email}
+	}
+	if c.opts.EABKeyID != "" {
+		acct.ExternalAccountBinding = &acme.ExternalAccountBinding{KID: c.opts.EABKeyID, Key: c.opts.EABHMACKey}
+	}
+	if _, err := c.client.Register(ctx, acct, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register the ACME account: %v", err)
+	}
+	c.registered = true
+	return nil
+}
+
+// authorize completes the authorization at url with an http-01 challenge, if it is not valid yet.
+func (c *Client) authorize(ctx context.Context, url string) error {
+	z, err := c.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get the authorization %s: %v", url, err)
+	}
+	switch z.Status {
+	case acme.StatusValid:
+		return nil
+	case acme.StatusPending:
+	default:
+		return fmt.Errorf("authorization for %s is %s", z.Identifier.Value, z.Status)
+	}
+	if c.server == nil {
+		return fmt.Errorf("authorization for %s is pending, and no http-01 challenge address is configured", z.Identifier.Value)
+	}
+
+	var chal *acme.Challenge
+	for _, ch := range z.Challenges {
+		if ch.Type == "http-01" {
+			chal = ch
+			break
+		}
+	}
+	if chal == nil {
+		return fmt.Errorf("no http-01 challenge offered for %s", z.Identifier.Value)
+	}
+	keyAuth, err := c.client.HTTP01ChallengeResponse(chal.Token)
+	if err != nil {
+		return err
+	}
+	c.challenges.Store(chal.Token, keyAuth)
+	defer c.challenges.Delete(chal.Token)
+
+	if _, err := c.client.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("failed to accept the http-01 challenge for %s: %v", z.Identifier.Value, err)
+	}
+	if _, err := c.client.WaitAuthorization(ctx, z.URI); err != nil {
+		return fmt.Errorf("authorization for %s failed: %v", z.Identifier.Value, err)
+	}
+	return nil
+}
+
+// GetRootCertBundle returns the configured trust bundle. ACME does not publish the roots of the CA.
+func (c *Client) GetRootCertBundle() ([]string, error) {
+	if c.opts.TrustBundleFile == "" {
+		return []string{}, nil
+	}
+	b, err := os.ReadFile(c.opts.TrustBundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the ACME trust bundle: %v", err)
+	}
+	return pkiutil.PemCertBytestoString(b), nil
+}
+
+func (c *Client) Close() {
+	if c.server != nil {
+		_ = c.server.Close()
+	}
+}
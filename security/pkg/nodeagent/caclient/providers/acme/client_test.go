@@ -0,0 +1,344 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+// fakeCA is a minimal ACME server. It does not verify the JWS signatures, and validates the http-01 challenges with
+// solve.
+type fakeCA struct {
+	t      *testing.T
+	srv    *httptest.Server
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+	// preAuthorized makes the authorizations valid without challenge.
+	preAuthorized bool
+	solve         func(token string) string
+
+	mu    sync.Mutex
+	hosts []string
+	valid map[int]bool
+	chain []byte
+}
+
+func newFakeCA(t *testing.T) *fakeCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake ACME root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	f := &fakeCA{t: t, caKey: key, caCert: cert, valid: map[int]bool{}}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeCA) url(path string) string {
+	return f.srv.URL + path
+}
+
+func (f *fakeCA) reply(w http.ResponseWriter, status int, location string, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	if location != "" {
+		w.Header().Set("Location", f.url(location))
+	}
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeCA) authz(i int) map[string]any {
+	status := "pending"
+	if f.preAuthorized || f.valid[i] {
+		status = "valid"
+	}
+	return map[string]any{
+		"status":     status,
+		"identifier": map[string]string{"type": "dns", "value": f.hosts[i]},
+		"challenges": []map[string]string{{
+			"type":   "http-01",
+			"url":    f.url(fmt.Sprintf("/chal/%d", i)),
+			"token":  fmt.Sprintf("token-%d", i),
+			"status": status,
+		}},
+	}
+}
+
+func (f *fakeCA) order(status string) map[string]any {
+	authzs := []string{}
+	ids := []map[string]string{}
+	for i, h := range f.hosts {
+		authzs = append(authzs, f.url(fmt.Sprintf("/authz/%d", i)))
+		ids = append(ids, map[string]string{"type": "dns", "value": h})
+	}
+	o := map[string]any{
+		"status":         status,
+		"identifiers":    ids,
+		"authorizations": authzs,
+		"finalize":       f.url("/finalize"),
+	}
+	if status == "valid" {
+		o["certificate"] = f.url("/cert")
+	}
+	return o
+}
+
+func (f *fakeCA) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", time.Now().UnixNano()))
+	if r.URL.Path == "/dir" {
+		f.reply(w, http.StatusOK, "", map[string]string{
+			"newNonce":   f.url("/nonce"),
+			"newAccount": f.url("/account"),
+			"newOrder":   f.url("/order"),
+		})
+		return
+	}
+	if r.URL.Path == "/nonce" {
+		return
+	}
+
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var i int
+	switch {
+	case r.URL.Path == "/account":
+		f.reply(w, http.StatusCreated, "/account/1", map[string]any{"status": "valid"})
+	case r.URL.Path == "/order":
+		var req struct {
+			Identifiers []struct {
+				Value string `json:"value"`
+			} `json:"identifiers"`
+		}
+		_ = json.Unmarshal(payload, &req)
+		f.hosts = nil
+		for _, id := range req.Identifiers {
+			f.hosts = append(f.hosts, id.Value)
+		}
+		f.valid = map[int]bool{}
+		f.chain = nil
+		f.reply(w, http.StatusCreated, "/order/1", f.order("pending"))
+	case r.URL.Path == "/order/1":
+		status := "ready"
+		for i := range f.hosts {
+			if !f.preAuthorized && !f.valid[i] {
+				status = "pending"
+			}
+		}
+		if f.chain != nil {
+			status = "valid"
+		}
+		f.reply(w, http.StatusOK, "/order/1", f.order(status))
+	case scan(r.URL.Path, "/authz/%d", &i):
+		f.reply(w, http.StatusOK, "", f.authz(i))
+	case scan(r.URL.Path, "/chal/%d", &i):
+		token := fmt.Sprintf("token-%d", i)
+		if f.solve == nil || !strings.HasPrefix(f.solve(token), token+".") {
+			f.reply(w, http.StatusForbidden, "", map[string]string{"type": "urn:ietf:params:acme:error:unauthorized"})
+			return
+		}
+		f.valid[i] = true
+		f.reply(w, http.StatusOK, "", f.authz(i)["challenges"].([]map[string]string)[0])
+	case r.URL.Path == "/finalize":
+		var req struct {
+			CSR string `json:"csr"`
+		}
+		_ = json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil || !slices.Equal(csr.DNSNames, f.hosts) {
+			f.reply(w, http.StatusBadRequest, "", map[string]string{"type": "urn:ietf:params:acme:error:badCSR"})
+			return
+		}
+		f.issue(csr)
+		// Like Pebble, the order is returned as processing, without location.
+		f.reply(w, http.StatusOK, "", f.order("processing"))
+	case r.URL.Path == "/cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(f.chain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func scan(path, format string, i *int) bool {
+	_, err := fmt.Sscanf(path, format, i)
+	return err == nil
+}
+
+// issue signs the certificate for the CSR, returned with the root by /cert.
+func (f *fakeCA) issue(csr *x509.CertificateRequest) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     csr.DNSNames,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, f.caCert, csr.PublicKey, f.caKey)
+	assert.NoError(f.t, err)
+	f.chain = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw})...)
+}
+
+func genCSR(t *testing.T, c *Client) []byte {
+	t.Helper()
+	csr, _, err := pkiutil.GenCSR(pkiutil.CertOptions{
+		Host:       strings.Join(c.CSRHosts("spiffe://cluster.local/ns/default/sa/default"), ","),
+		RSAKeySize: 2048,
+	})
+	assert.NoError(t, err)
+	return csr
+}
+
+func TestCSRSign(t *testing.T) {
+	hosts := []string{"foo.example.com", "bar.example.com"}
+	cases := []struct {
+		name          string
+		preAuthorized bool
+		http01        bool
+		err           bool
+	}{
+		{name: "http-01", http01: true},
+		{name: "pre-authorized", preAuthorized: true},
+		{name: "no solver", err: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ca := newFakeCA(t)
+			ca.preAuthorized = tt.preAuthorized
+			opts := Options{DirectoryURL: ca.url("/dir"), Hosts: hosts, Email: "admin@example.com"}
+			if tt.http01 {
+				opts.HTTP01Address = "127.0.0.1:0"
+			}
+			c, err := NewClient(opts)
+			assert.NoError(t, err)
+			defer c.Close()
+			ca.solve = func(token string) string {
+				rec := httptest.NewRecorder()
+				c.serveHTTP01(rec, httptest.NewRequest(http.MethodGet, http01ChallengePrefix+token, nil))
+				return rec.Body.String()
+			}
+
+			chain, err := c.CSRSign(genCSR(t, c), 3600)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(chain), 2)
+			cert, err := pkiutil.ParsePemEncodedCertificate([]byte(chain[0]))
+			assert.NoError(t, err)
+			assert.Equal(t, cert.DNSNames, hosts)
+			assert.Equal(t, cert.CheckSignatureFrom(ca.caCert), nil)
+
+			// The account is registered once, the next certificates are ordered with it.
+			_, err = c.CSRSign(genCSR(t, c), 3600)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestGetRootCertBundle(t *testing.T) {
+	ca := newFakeCA(t)
+	c, err := NewClient(Options{DirectoryURL: ca.url("/dir"), Hosts: []string{"foo.example.com"}})
+	assert.NoError(t, err)
+	roots, err := c.GetRootCertBundle()
+	assert.NoError(t, err)
+	assert.Equal(t, len(roots), 0)
+
+	bundle := filepath.Join(t.TempDir(), "roots.pem")
+	assert.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.caCert.Raw}), 0o600))
+	c, err = NewClient(Options{DirectoryURL: ca.url("/dir"), Hosts: []string{"foo.example.com"}, TrustBundleFile: bundle})
+	assert.NoError(t, err)
+	roots, err = c.GetRootCertBundle()
+	assert.NoError(t, err)
+	assert.Equal(t, len(roots), 1)
+}
+
+func TestNewClient(t *testing.T) {
+	for _, opts := range []Options{
+		{Hosts: []string{"foo.example.com"}},
+		{DirectoryURL: "https://acme.example.com/dir"},
+		{DirectoryURL: "https://acme.example.com/dir", Hosts: []string{"foo.example.com"}, AccountKeyFile: "/missing"},
+		{DirectoryURL: "https://acme.example.com/dir", Hosts: []string{"foo.example.com"}, RootCert: "/missing"},
+	} {
+		_, err := NewClient(opts)
+		assert.Error(t, err)
+	}
+}
+
+// TestPebble orders a certificate from a Pebble server, started with PEBBLE_VA_ALWAYS_VALID=1. It is skipped unless
+// PEBBLE_DIRECTORY is set, PEBBLE_ROOT_CERT being the certificate Pebble serves its API with.
+func TestPebble(t *testing.T) {
+	dir := os.Getenv("PEBBLE_DIRECTORY")
+	if dir == "" {
+		t.Skip("PEBBLE_DIRECTORY is not set")
+	}
+	c, err := NewClient(Options{
+		DirectoryURL:  dir,
+		RootCert:      os.Getenv("PEBBLE_ROOT_CERT"),
+		Hosts:         []string{"istio.example.com"},
+		HTTP01Address: "127.0.0.1:5002",
+	})
+	assert.NoError(t, err)
+	defer c.Close()
+	chain, err := c.CSRSign(genCSR(t, c), 3600)
+	assert.NoError(t, err)
+	cert, err := pkiutil.ParsePemEncodedCertificate([]byte(chain[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, cert.DNSNames, []string{"istio.example.com"})
+}
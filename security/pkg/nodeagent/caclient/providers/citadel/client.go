@@ -18,14 +18,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	pb "istio.io/api/security/v1alpha1"
 	istiogrpc "istio.io/istio/pilot/pkg/grpc"
+	"istio.io/istio/pkg/jwtsvidapi"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/nodeagent/caclient"
@@ -37,6 +41,8 @@ const (
 
 var citadelClientLog = log.RegisterScope("citadelclient", "citadel client debugging")
 
+var _ security.JWTSVIDClient = &CitadelClient{}
+
 type CitadelClient struct {
 	// It means enable tls connection to Citadel if this is not nil.
 	tlsOpts  *TLSOptions
@@ -104,12 +110,7 @@ func (c *CitadelClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) (res []s
 		}
 	}()
 
-	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("ClusterID", c.opts.ClusterID))
-	for k, v := range c.opts.CAHeaders {
-		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
-	}
-
-	resp, err := c.client.CreateCertificate(ctx, req)
+	resp, err := c.client.CreateCertificate(c.outgoingContext(), req)
 	if err != nil {
 		return nil, fmt.Errorf("create certificate: %v", err)
 	}
@@ -121,6 +122,47 @@ func (c *CitadelClient) CSRSign(csrPEM []byte, certValidTTLInSec int64) (res []s
 	return resp.CertChain, nil
 }
 
+// JWTSVIDSign calls Citadel to issue a JWT-SVID.
+func (c *CitadelClient) JWTSVIDSign(audiences []string, validTTLInSec int64) (*security.JWTSVID, error) {
+	resp, err := jwtsvidapi.NewJWTSVIDServiceClient(c.conn).CreateJWTSVID(c.outgoingContext(), &jwtsvidapi.JWTSVIDRequest{
+		Audiences:        audiences,
+		ValidityDuration: validTTLInSec,
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, security.ErrJWTSVIDNotSupported
+		}
+		return nil, fmt.Errorf("create JWT-SVID: %v", err)
+	}
+	return &security.JWTSVID{
+		SpiffeID:    resp.SpiffeId,
+		Token:       resp.Token,
+		CreatedTime: time.Now(),
+		ExpireTime:  time.Unix(resp.ExpiresAt, 0),
+	}, nil
+}
+
+// GetJWTBundle calls Citadel to get the keys verifying the JWT-SVIDs.
+func (c *CitadelClient) GetJWTBundle() (*security.JWTBundle, error) {
+	resp, err := jwtsvidapi.NewJWTSVIDServiceClient(c.conn).FetchJWTBundle(c.outgoingContext(), &jwtsvidapi.JWTBundleRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, security.ErrJWTSVIDNotSupported
+		}
+		return nil, fmt.Errorf("fetch JWT bundle: %v", err)
+	}
+	return &security.JWTBundle{TrustDomain: resp.TrustDomain, JWKS: resp.Jwks}, nil
+}
+
+// outgoingContext returns the context of the requests to Citadel, with the cluster ID and CA headers.
+func (c *CitadelClient) outgoingContext() context.Context {
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("ClusterID", c.opts.ClusterID))
+	for k, v := range c.opts.CAHeaders {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	return ctx
+}
+
 func (c *CitadelClient) getTLSOptions() *istiogrpc.TLSOptions {
 	if c.tlsOpts != nil {
 		return &istiogrpc.TLSOptions{
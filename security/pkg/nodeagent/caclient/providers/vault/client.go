@@ -0,0 +1,283 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vault implements a CA client signing the workload certificates with the PKI secrets engine of HashiCorp
+// Vault.
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	defaultPKIPath  = "pki"
+	defaultAuthPath = "kubernetes"
+	defaultTimeout  = 30 * time.Second
+)
+
+var vaultClientLog = log.RegisterScope("vaultclient", "Vault CA client debugging")
+
+// Options configures the Vault client.
+type Options struct {
+	// Address is the address of Vault, such as https://vault.vault.svc:8200.
+	Address string
+	// RootCert is the path to the certificates trusted to connect to Vault. The system roots are used if empty.
+	RootCert string
+	// PKIPath is the mount path of the PKI secrets engine. Defaults to "pki".
+	PKIPath string
+	// Role is the PKI role the certificates are signed with. It must allow the SPIFFE URI SANs of the workloads, and
+	// not require a common name.
+	Role string
+	// TokenFile is the path to a Vault token. If set, it is used instead of logging in.
+	TokenFile string
+	// AuthPath is the mount path of the JWT based auth method the agent logs in with. Defaults to "kubernetes".
+	AuthPath string
+	// AuthRole is the role the agent logs in as.
+	AuthRole string
+	// Credential returns the JWT the agent logs in with.
+	Credential func() (string, error)
+	// Timeout bounds each request to Vault. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// Client signs the workload certificates with Vault.
+type Client struct {
+	opts   Options
+	client *http.Client
+
+	mu sync.Mutex
+	// token is the token from the last login, valid until expiry.
+	token  string
+	expiry time.Time
+}
+
+var _ security.Client = &Client{}
+
+// NewClient creates a CA client for the PKI secrets engine of Vault.
+func NewClient(opts Options) (*Client, error) {
+	if opts.Address == "" {
+		return nil, errors.New("the Vault address is required")
+	}
+	if opts.Role == "" {
+		return nil, errors.New("the Vault PKI role is required")
+	}
+	if opts.TokenFile == "" && (opts.AuthRole == "" || opts.Credential == nil) {
+		return nil, errors.New("either a Vault token file, or an auth role and credential are required")
+	}
+	if opts.PKIPath == "" {
+		opts.PKIPath = defaultPKIPath
+	}
+	if opts.AuthPath == "" {
+		opts.AuthPath = defaultAuthPath
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	opts.Address = strings.TrimSuffix(opts.Address, "/")
+	opts.PKIPath = strings.Trim(opts.PKIPath, "/")
+	opts.AuthPath = strings.Trim(opts.AuthPath, "/")
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.RootCert != "" {
+		b, err := os.ReadFile(opts.RootCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Vault root certificate %s: %v", opts.RootCert, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificate found in %s", opts.RootCert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &Client{
+		opts:   opts,
+		client: &http.Client{Transport: transport, Timeout: opts.Timeout},
+	}, nil
+}
+
+// responseError is a non 2xx response from Vault.
+type responseError struct {
+	status int
+	errors []string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("vault returned %d: %s", e.status, strings.Join(e.errors, "; "))
+}
+
+// do sends a request to Vault, and decodes its JSON response into out.
+func (c *Client) do(path, token string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.opts.Address+"/v1/"+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		e := &responseError{status: resp.StatusCode}
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(b, &errResp) == nil {
+			e.errors = errResp.Errors
+		}
+		return e
+	}
+	return json.Unmarshal(b, out)
+}
+
+// getToken returns the token to authenticate to Vault with, logging in if there is no valid token. If refresh is set,
+// the agent logs in again.
+func (c *Client) getToken(refresh bool) (string, error) {
+	if c.opts.TokenFile != "" {
+		// Read the file each time, as it may be rotated.
+		b, err := os.ReadFile(c.opts.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the Vault token: %v", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !refresh && c.token != "" && (c.expiry.IsZero() || time.Now().Before(c.expiry)) {
+		return c.token, nil
+	}
+	jwt, err := c.opts.Credential()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the credential to log in Vault: %v", err)
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int64  `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	if err := c.do("auth/"+c.opts.AuthPath+"/login", "", map[string]string{"role": c.opts.AuthRole, "jwt": jwt}, &resp); err != nil {
+		return "", fmt.Errorf("failed to log in Vault: %v", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("failed to log in Vault: no token returned")
+	}
+	c.token = resp.Auth.ClientToken
+	c.expiry = time.Time{}
+	if resp.Auth.LeaseDuration > 0 {
+		// Log in again before the token expires, rather than renewing it.
+		c.expiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second * 4 / 5)
+	}
+	vaultClientLog.Debugf("logged in Vault, token valid for %ds", resp.Auth.LeaseDuration)
+	return c.token, nil
+}
+
+// CSRSign signs the CSR with the PKI role. The SPIFFE URI SANs of the CSR are requested explicitly, as Vault only
+// copies the DNS and IP SANs of the CSR by default.
+func (c *Client) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]string, error) {
+	csr, err := pkiutil.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	req := map[string]any{
+		"csr":    string(csrPEM),
+		"format": "pem",
+	}
+	if certValidTTLInSec > 0 {
+		req["ttl"] = fmt.Sprintf("%ds", certValidTTLInSec)
+	}
+	if csr.Subject.CommonName != "" {
+		req["common_name"] = csr.Subject.CommonName
+	}
+	uris := make([]string, 0, len(csr.URIs))
+	for _, u := range csr.URIs {
+		uris = append(uris, u.String())
+	}
+	if len(uris) > 0 {
+		req["uri_sans"] = strings.Join(uris, ",")
+	}
+
+	var resp struct {
+		Data struct {
+			Certificate string   `json:"certificate"`
+			IssuingCA   string   `json:"issuing_ca"`
+			CAChain     []string `json:"ca_chain"`
+		} `json:"data"`
+	}
+	path := c.opts.PKIPath + "/sign/" + c.opts.Role
+	token, err := c.getToken(false)
+	if err != nil {
+		return nil, err
+	}
+	err = c.do(path, token, req, &resp)
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.status == http.StatusForbidden && c.opts.TokenFile == "" {
+		// The token may have been revoked before its lease expired.
+		vaultClientLog.Debugf("Vault denied the request, logging in again: %v", err)
+		if token, err = c.getToken(true); err != nil {
+			return nil, err
+		}
+		err = c.do(path, token, req, &resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the CSR: %v", err)
+	}
+	if resp.Data.Certificate == "" {
+		return nil, errors.New("failed to sign the CSR: no certificate returned")
+	}
+
+	chain := []string{resp.Data.Certificate}
+	if len(resp.Data.CAChain) > 0 {
+		chain = append(chain, resp.Data.CAChain...)
+	} else if resp.Data.IssuingCA != "" {
+		chain = append(chain, resp.Data.IssuingCA)
+	}
+	return chain, nil
+}
+
+// GetRootCertBundle returns no bundle: the root of the chain returned by Vault is used.
+func (c *Client) GetRootCertBundle() ([]string, error) {
+	return []string{}, nil
+}
+
+func (c *Client) Close() {
+	c.client.CloseIdleConnections()
+}
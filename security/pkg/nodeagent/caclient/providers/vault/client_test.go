@@ -0,0 +1,281 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	testIdentity = "spiffe://cluster.local/ns/default/sa/default"
+	testJWT      = "jwt"
+)
+
+// fakeVault serves the kubernetes auth login and the PKI sign endpoints.
+type fakeVault struct {
+	t      *testing.T
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	mu     sync.Mutex
+	tokens map[string]bool
+	logins int
+	ttl    string
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fake Vault root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	f := &fakeVault{t: t, caKey: key, caCert: cert, tokens: map[string]bool{}}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+// revoke revokes all the tokens issued by login.
+func (f *fakeVault) revoke() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = map[string]bool{}
+}
+
+// requests returns the number of logins, and the TTL of the last signing request.
+func (f *fakeVault) requests() (int, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins, f.ttl
+}
+
+func (f *fakeVault) reply(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.reply(w, http.StatusBadRequest, map[string][]string{"errors": {err.Error()}})
+		return
+	}
+	switch r.URL.Path {
+	case "/v1/auth/kubernetes/login":
+		if req["role"] != "istio" || req["jwt"] != testJWT {
+			f.reply(w, http.StatusForbidden, map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("token-%d", f.logins)
+		f.tokens[token] = true
+		f.reply(w, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 3600}})
+	case "/v1/pki/sign/workload":
+		if token := r.Header.Get("X-Vault-Token"); !f.tokens[token] && token != "static" {
+			f.reply(w, http.StatusForbidden, map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		csr, err := pkiutil.ParsePemEncodedCSR([]byte(req["csr"]))
+		if err != nil {
+			f.reply(w, http.StatusBadRequest, map[string][]string{"errors": {err.Error()}})
+			return
+		}
+		f.ttl = req["ttl"]
+		uri, _ := url.Parse(req["uri_sans"])
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+			URIs:         []*url.URL{uri},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, f.caCert, csr.PublicKey, f.caKey)
+		assert.NoError(f.t, err)
+		ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw}))
+		f.reply(w, http.StatusOK, map[string]any{"data": map[string]any{
+			"certificate": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+			"issuing_ca":  ca,
+			"ca_chain":    []string{ca},
+		}})
+	default:
+		f.reply(w, http.StatusNotFound, map[string][]string{"errors": {}})
+	}
+}
+
+func genCSR(t *testing.T) []byte {
+	t.Helper()
+	csr, _, err := pkiutil.GenCSR(pkiutil.CertOptions{Host: testIdentity, RSAKeySize: 2048})
+	assert.NoError(t, err)
+	return csr
+}
+
+func checkChain(t *testing.T, chain []string, root *x509.Certificate) {
+	t.Helper()
+	assert.Equal(t, len(chain), 2)
+	cert, err := pkiutil.ParsePemEncodedCertificate([]byte(chain[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, cert.URIs[0].String(), testIdentity)
+	if root != nil {
+		assert.NoError(t, cert.CheckSignatureFrom(root))
+	}
+}
+
+func TestCSRSign(t *testing.T) {
+	t.Run("login", func(t *testing.T) {
+		v, addr := newFakeVault(t)
+		c, err := NewClient(Options{
+			Address:    addr,
+			Role:       "workload",
+			AuthRole:   "istio",
+			Credential: func() (string, error) { return testJWT, nil },
+		})
+		assert.NoError(t, err)
+		defer c.Close()
+
+		chain, err := c.CSRSign(genCSR(t), 3600)
+		assert.NoError(t, err)
+		checkChain(t, chain, v.caCert)
+		logins, ttl := v.requests()
+		assert.Equal(t, ttl, "3600s")
+		assert.Equal(t, logins, 1)
+
+		// The token is reused until it expires.
+		_, err = c.CSRSign(genCSR(t), 3600)
+		assert.NoError(t, err)
+		logins, _ = v.requests()
+		assert.Equal(t, logins, 1)
+
+		// A revoked token is replaced.
+		v.revoke()
+		_, err = c.CSRSign(genCSR(t), 3600)
+		assert.NoError(t, err)
+		logins, _ = v.requests()
+		assert.Equal(t, logins, 2)
+	})
+
+	t.Run("token file", func(t *testing.T) {
+		v, addr := newFakeVault(t)
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		assert.NoError(t, os.WriteFile(tokenFile, []byte("static\n"), 0o600))
+		c, err := NewClient(Options{Address: addr, Role: "workload", TokenFile: tokenFile})
+		assert.NoError(t, err)
+		chain, err := c.CSRSign(genCSR(t), 3600)
+		assert.NoError(t, err)
+		checkChain(t, chain, v.caCert)
+		logins, _ := v.requests()
+		assert.Equal(t, logins, 0)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		_, addr := newFakeVault(t)
+		c, err := NewClient(Options{
+			Address:    addr,
+			Role:       "workload",
+			AuthRole:   "other",
+			Credential: func() (string, error) { return testJWT, nil },
+		})
+		assert.NoError(t, err)
+		_, err = c.CSRSign(genCSR(t), 3600)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	for _, opts := range []Options{
+		{Role: "workload", TokenFile: "token"},
+		{Address: "https://vault:8200", TokenFile: "token"},
+		{Address: "https://vault:8200", Role: "workload"},
+		{Address: "https://vault:8200", Role: "workload", TokenFile: "token", RootCert: "/missing"},
+	} {
+		_, err := NewClient(opts)
+		assert.Error(t, err)
+	}
+}
+
+// vaultAPI calls the Vault API of a dev server with the root token.
+func vaultAPI(t *testing.T, addr, token, method, path string, body any) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req, err := http.NewRequest(method, addr+"/v1/"+path, bytes.NewReader(b))
+	assert.NoError(t, err)
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	if resp.StatusCode > 299 {
+		t.Fatalf("%s %s: %s", method, path, resp.Status)
+	}
+}
+
+// TestDevServer signs a certificate with a Vault dev server, such as one started with
+// `vault server -dev -dev-root-token-id=root`. It is skipped unless VAULT_ADDR and VAULT_TOKEN are set.
+func TestDevServer(t *testing.T) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		t.Skip("VAULT_ADDR or VAULT_TOKEN is not set")
+	}
+	mount := fmt.Sprintf("istio-pki-%d", time.Now().UnixNano())
+	vaultAPI(t, addr, token, http.MethodPost, "sys/mounts/"+mount, map[string]any{"type": "pki", "config": map[string]string{"max_lease_ttl": "24h"}})
+	t.Cleanup(func() { vaultAPI(t, addr, token, http.MethodDelete, "sys/mounts/"+mount, nil) })
+	vaultAPI(t, addr, token, http.MethodPost, mount+"/root/generate/internal", map[string]any{"common_name": "Istio Vault root", "ttl": "24h"})
+	vaultAPI(t, addr, token, http.MethodPost, mount+"/roles/workload", map[string]any{
+		"allowed_uri_sans": "spiffe://cluster.local/*",
+		"allow_any_name":   true,
+		"require_cn":       false,
+		"max_ttl":          "2h",
+	})
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte(token), 0o600))
+	c, err := NewClient(Options{Address: addr, PKIPath: mount, Role: "workload", TokenFile: tokenFile})
+	assert.NoError(t, err)
+	defer c.Close()
+	chain, err := c.CSRSign(genCSR(t), 3600)
+	assert.NoError(t, err)
+	checkChain(t, chain, nil)
+}
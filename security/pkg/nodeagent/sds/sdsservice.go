@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -30,6 +31,7 @@ import (
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	sds "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
+	"github.com/fsnotify/fsnotify"
 	"github.com/google/uuid"
 	uberatomic "go.uber.org/atomic"
 	"google.golang.org/grpc"
@@ -50,6 +52,9 @@ import (
 
 var sdsServiceLog = log.RegisterScope("sds", "SDS service debugging")
 
+// caCRLFilePath is the CA CRL file attached to the root certificates. It is a variable to be overridden in tests.
+var caCRLFilePath = security.CACRLFilePath
+
 type sdsservice struct {
 	st security.SecretManager
 
@@ -83,6 +88,10 @@ func newSDSService(st security.SecretManager, options *security.Options, pkpConf
 
 	ret.rootCaPath = options.CARootPath
 
+	if features.EnableCACRL {
+		ret.watchCRL()
+	}
+
 	if options.FileMountedCerts || options.ServeOnlyFiles {
 		return ret
 	}
@@ -172,6 +181,65 @@ func (s *sdsservice) push(secretName string) {
 	}
 }
 
+// watchCRL pushes the root certificates again when the CA CRL file appears or disappears, so that Envoy starts or
+// stops loading it. Envoy itself reloads the content of a file referenced by a secret when it changes, such as when
+// the CA publishes a new CRL.
+func (s *sdsservice) watchCRL() {
+	dir := filepath.Dir(caCRLFilePath)
+	if _, err := os.Stat(dir); err != nil {
+		sdsServiceLog.Debugf("not watching for CRL changes, %s does not exist", dir)
+		return
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		sdsServiceLog.Errorf("failed to watch for CRL changes: %v", err)
+		return
+	}
+	if err := watcher.Add(dir); err != nil {
+		sdsServiceLog.Errorf("failed to watch for CRL changes in %s: %v", dir, err)
+		_ = watcher.Close()
+		return
+	}
+	provided := isCrlFileProvided()
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if isCrlFileProvided() == provided {
+					continue
+				}
+				provided = !provided
+				sdsServiceLog.Infof("CA CRL file %s changed (present: %v), pushing root certificates", caCRLFilePath, provided)
+				s.pushRootCerts()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				sdsServiceLog.Errorf("CRL watch error: %v", err)
+			}
+		}
+	}()
+}
+
+// pushRootCerts pushes the root certificates requested by any client.
+func (s *sdsservice) pushRootCerts() {
+	s.Lock()
+	names := sets.New[string]()
+	for _, client := range s.clients {
+		names.InsertAll(client.w.requestedRootCerts()...)
+	}
+	s.Unlock()
+	for name := range names {
+		s.push(name)
+	}
+}
+
 func (c *Context) XdsConnection() *xds.Connection {
 	return &c.BaseConnection
 }
@@ -239,6 +307,22 @@ func (w *Watch) requested(secretName string) bool {
 	return false
 }
 
+func (w *Watch) requestedRootCerts() []string {
+	w.Lock()
+	defer w.Unlock()
+	if w.watch == nil {
+		return nil
+	}
+	var out []string
+	for name := range w.watch.ResourceNames {
+		if cfg, ok := security.SdsCertificateConfigFromResourceName(name); name == security.RootCertReqResourceName ||
+			name == security.FileRootSystemCACert || (ok && cfg.IsRootCertificate()) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
 func (c *Context) Process(req *discovery.DiscoveryRequest) error {
 	shouldRespond, delta := xds.ShouldRespond(c.Watcher(), c.XdsConnection().ID(), req)
 	if !shouldRespond {
@@ -316,7 +400,7 @@ func toEnvoySecret(s *security.SecretItem, caRootPath string, pkpConf *mesh.Priv
 			if isCrlFileProvided() {
 				secretValidationContext.ValidationContext.Crl = &core.DataSource{
 					Specifier: &core.DataSource_Filename{
-						Filename: security.CACRLFilePath,
+						Filename: caCRLFilePath,
 					},
 				}
 			}
@@ -399,13 +483,13 @@ func toEnvoySecret(s *security.SecretItem, caRootPath string, pkpConf *mesh.Priv
 
 // isCrlFileProvided checks if the Plugged-in CA CRL file is present
 func isCrlFileProvided() bool {
-	_, err := os.Stat(security.CACRLFilePath)
+	_, err := os.Stat(caCRLFilePath)
 	if err == nil {
 		return true
 	}
 
 	if os.IsNotExist(err) {
-		sdsServiceLog.Debugf("CRL is not configured, %s does not exist", security.CACRLFilePath)
+		sdsServiceLog.Debugf("CRL is not configured, %s does not exist", caCRLFilePath)
 		return false
 	}
 
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -35,6 +36,7 @@ import (
 	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/log"
 	ca2 "istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test"
 )
 
 var (
@@ -272,6 +274,34 @@ func TestSDS(t *testing.T) {
 		// No need to push a new root if just the cert changes
 		root.ExpectNoResponse(t)
 	})
+	t.Run("push root on CRL change", func(t *testing.T) {
+		crlDir := t.TempDir()
+		test.SetForTest(t, &caCRLFilePath, filepath.Join(crlDir, "ca-crl.pem"))
+		s := setupSDS(t)
+		cert := s.Connect()
+		root := s.Connect()
+		s.Verify(cert.RequestResponseAck(t, &discovery.DiscoveryRequest{ResourceNames: []string{testResourceName}}), expectCert)
+		resp := s.Verify(root.RequestResponseAck(t, &discovery.DiscoveryRequest{ResourceNames: []string{rootResourceName}}), expectRoot)
+		if crl := xdstest.ExtractTLSSecrets(t, resp.Resources)[rootResourceName].GetValidationContext().GetCrl(); crl != nil {
+			t.Fatalf("unexpected CRL %v", crl)
+		}
+
+		// The CRL appearing is pushed to the root certificate, but not to the workload certificate.
+		if err := os.WriteFile(caCRLFilePath, []byte("crl"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		resp = s.Verify(root.ExpectResponse(t), expectRoot)
+		if crl := xdstest.ExtractTLSSecrets(t, resp.Resources)[rootResourceName].GetValidationContext().GetCrl(); crl.GetFilename() != caCRLFilePath {
+			t.Fatalf("expected CRL file %s, got %v", caCRLFilePath, crl)
+		}
+		cert.ExpectNoResponse(t)
+
+		// Envoy reloads a changed CRL itself.
+		if err := os.WriteFile(caCRLFilePath, []byte("new crl"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		root.ExpectNoResponse(t)
+	})
 	t.Run("reconnect", func(t *testing.T) {
 		s := setupSDS(t)
 		c := s.Connect()
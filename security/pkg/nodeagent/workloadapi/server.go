@@ -0,0 +1,279 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workloadapi serves the SPIFFE Workload API, so that applications doing their own mTLS can use the Istio
+// workload identity with standard SPIFFE libraries.
+package workloadapi
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/uds"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// securityHeader must be set to "true" by Workload API clients, to prevent requests forged by other servers.
+const securityHeader = "workload.spiffe.io"
+
+var workloadAPILog = log.RegisterScope("workloadapi", "SPIFFE Workload API")
+
+// Server serves the SPIFFE Workload API on a UDS, with the workload certificate and trust bundle of a secret
+// manager. Streams are updated each time the secret manager rotates them.
+type Server struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+
+	secrets  security.SecretManager
+	listener net.Listener
+	server   *grpc.Server
+
+	mu sync.Mutex
+	// watchers are notified of secret updates.
+	watchers map[chan struct{}]struct{}
+}
+
+// NewServer creates and starts a Workload API server listening on the UDS at path.
+func NewServer(path string, secrets security.SecretManager) (*Server, error) {
+	l, err := uds.NewListener(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		secrets:  secrets,
+		listener: l,
+		server:   grpc.NewServer(),
+		watchers: map[chan struct{}]struct{}{},
+	}
+	workload.RegisterSpiffeWorkloadAPIServer(s.server, s)
+	go func() {
+		workloadAPILog.Infof("Starting SPIFFE Workload API server, will listen on %q", path)
+		if err := s.server.Serve(l); err != nil {
+			workloadAPILog.Errorf("SPIFFE Workload API server failed: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// OnSecretUpdate sends the updated secrets to all streams.
+func (s *Server) OnSecretUpdate(resourceName string) {
+	if resourceName != security.WorkloadKeyCertResourceName && resourceName != security.RootCertReqResourceName {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for w := range s.watchers {
+		select {
+		case w <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stop closes all streams and the listener.
+func (s *Server) Stop() {
+	if s == nil {
+		return
+	}
+	s.server.Stop()
+	_ = s.listener.Close()
+}
+
+func (s *Server) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	return s.watch(stream.Context(), func() error {
+		svid, _, err := s.fetchX509SVID()
+		if err != nil {
+			return err
+		}
+		return stream.Send(&workload.X509SVIDResponse{Svids: []*workload.X509SVID{svid}})
+	})
+}
+
+func (s *Server) FetchX509Bundles(_ *workload.X509BundlesRequest, stream workload.SpiffeWorkloadAPI_FetchX509BundlesServer) error {
+	return s.watch(stream.Context(), func() error {
+		svid, trustDomain, err := s.fetchX509SVID()
+		if err != nil {
+			return err
+		}
+		return stream.Send(&workload.X509BundlesResponse{
+			Bundles: map[string][]byte{trustDomain: svid.Bundle},
+		})
+	})
+}
+
+// FetchJWTSVID returns a JWT-SVID for the audiences, if the secret manager issues them.
+func (s *Server) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest) (*workload.JWTSVIDResponse, error) {
+	if err := checkSecurityHeader(ctx); err != nil {
+		return nil, err
+	}
+	if len(req.Audience) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one audience is required")
+	}
+	jwtManager, ok := s.secrets.(security.JWTSVIDManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, security.ErrJWTSVIDNotSupported.Error())
+	}
+	svid, err := jwtManager.GenerateJWTSVID(req.Audience)
+	if err != nil {
+		return nil, jwtStatus(err)
+	}
+	if req.SpiffeId != "" && req.SpiffeId != svid.SpiffeID {
+		return nil, status.Errorf(codes.PermissionDenied, "no JWT-SVID for %s", req.SpiffeId)
+	}
+	return &workload.JWTSVIDResponse{
+		Svids: []*workload.JWTSVID{{SpiffeId: svid.SpiffeID, Svid: svid.Token}},
+	}, nil
+}
+
+// FetchJWTBundles returns the keys verifying the JWT-SVIDs, if the secret manager issues them.
+func (s *Server) FetchJWTBundles(_ *workload.JWTBundlesRequest, stream workload.SpiffeWorkloadAPI_FetchJWTBundlesServer) error {
+	jwtManager, ok := s.secrets.(security.JWTSVIDManager)
+	if !ok {
+		return status.Error(codes.Unimplemented, security.ErrJWTSVIDNotSupported.Error())
+	}
+	return s.watch(stream.Context(), func() error {
+		bundle, err := jwtManager.GenerateJWTBundle()
+		if err != nil {
+			return jwtStatus(err)
+		}
+		return stream.Send(&workload.JWTBundlesResponse{
+			Bundles: map[string][]byte{spiffe.URIPrefix + bundle.TrustDomain: bundle.JWKS},
+		})
+	})
+}
+
+// watch calls send with the current secrets, and again after each update, until the stream is closed.
+func (s *Server) watch(ctx context.Context, send func() error) error {
+	if err := checkSecurityHeader(ctx); err != nil {
+		return err
+	}
+	updates := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers[updates] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, updates)
+		s.mu.Unlock()
+	}()
+	for {
+		if err := send(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-updates:
+		}
+	}
+}
+
+// fetchX509SVID returns the workload certificate, key and trust bundle of the secret manager, DER encoded, and the
+// SPIFFE ID of the trust domain of the workload.
+func (s *Server) fetchX509SVID() (*workload.X509SVID, string, error) {
+	cert, err := s.secrets.GenerateSecret(security.WorkloadKeyCertResourceName)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Unavailable, "workload certificate is not available: %v", err)
+	}
+	root, err := s.secrets.GenerateSecret(security.RootCertReqResourceName)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Unavailable, "trust bundle is not available: %v", err)
+	}
+	chain, err := pemToDER(cert.CertificateChain)
+	if err != nil || len(chain) == 0 {
+		return nil, "", status.Errorf(codes.Internal, "invalid workload certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "invalid workload certificate: %v", err)
+	}
+	var id *url.URL
+	for _, uri := range leaf.URIs {
+		if uri.Scheme == spiffe.Scheme {
+			id = uri
+			break
+		}
+	}
+	if id == nil {
+		return nil, "", status.Error(codes.PermissionDenied, "the workload certificate has no SPIFFE ID")
+	}
+	key, err := util.ParsePemEncodedKey(cert.PrivateKey)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "invalid workload key: %v", err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "invalid workload key: %v", err)
+	}
+	bundle, err := pemToDER(root.RootCert)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "invalid trust bundle: %v", err)
+	}
+	return &workload.X509SVID{
+		SpiffeId:    id.String(),
+		X509Svid:    concat(chain),
+		X509SvidKey: pkcs8,
+		Bundle:      concat(bundle),
+	}, spiffe.URIPrefix + id.Host, nil
+}
+
+// jwtStatus returns the status of a failure to get a JWT-SVID or JWT bundle.
+func jwtStatus(err error) error {
+	if errors.Is(err, security.ErrJWTSVIDNotSupported) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	return status.Errorf(codes.Unavailable, "JWT-SVIDs are not available: %v", err)
+}
+
+func checkSecurityHeader(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(securityHeader); len(v) != 1 || v[0] != "true" {
+		return status.Errorf(codes.InvalidArgument, "the %s header must be set to true", securityHeader)
+	}
+	return nil
+}
+
+// pemToDER returns the DER bytes of the certificates in the PEM data.
+func pemToDER(data []byte) ([][]byte, error) {
+	var out [][]byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+		out = append(out, block.Bytes)
+	}
+	return out, nil
+}
+
+func concat(certs [][]byte) []byte {
+	var out []byte
+	for _, c := range certs {
+		out = append(out, c...)
+	}
+	return out
+}
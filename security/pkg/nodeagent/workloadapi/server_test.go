@@ -0,0 +1,173 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadapi
+
+import (
+	"context"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const testID = "spiffe://cluster.local/ns/default/sa/app"
+
+func genSecret(t *testing.T) *security.SecretItem {
+	t.Helper()
+	cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         testID,
+		NotBefore:    time.Now(),
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	assert.NoError(t, err)
+	return &security.SecretItem{CertificateChain: cert, PrivateKey: key, RootCert: cert}
+}
+
+func setup(t *testing.T) (*security.DirectSecretManager, *Server, workload.SpiffeWorkloadAPIClient) {
+	secrets := security.NewDirectSecretManager()
+	s, client := setupWithSecrets(t, secrets)
+	return secrets, s, client
+}
+
+func setupWithSecrets(t *testing.T, secrets security.SecretManager) (*Server, workload.SpiffeWorkloadAPIClient) {
+	path := filepath.Join(t.TempDir(), "workload.sock")
+	s, err := NewServer(path, secrets)
+	assert.NoError(t, err)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.NewClient("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return s, workload.NewSpiffeWorkloadAPIClient(conn)
+}
+
+func withHeader(t *testing.T) context.Context {
+	return metadata.AppendToOutgoingContext(t.Context(), securityHeader, "true")
+}
+
+func TestFetchX509SVID(t *testing.T) {
+	secrets, s, client := setup(t)
+	secret := genSecret(t)
+	secrets.Set(security.WorkloadKeyCertResourceName, secret)
+	secrets.Set(security.RootCertReqResourceName, secret)
+
+	stream, err := client.FetchX509SVID(withHeader(t), &workload.X509SVIDRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, len(resp.Svids), 1)
+	svid := resp.Svids[0]
+	assert.Equal(t, svid.SpiffeId, testID)
+	leaf, err := x509.ParseCertificate(svid.X509Svid)
+	assert.NoError(t, err)
+	_, err = x509.ParsePKCS8PrivateKey(svid.X509SvidKey)
+	assert.NoError(t, err)
+	roots, err := x509.ParseCertificates(svid.Bundle)
+	assert.NoError(t, err)
+	assert.Equal(t, len(roots), 1)
+
+	// A rotated certificate is sent on the open stream.
+	rotated := genSecret(t)
+	secrets.Set(security.WorkloadKeyCertResourceName, rotated)
+	s.OnSecretUpdate(security.WorkloadKeyCertResourceName)
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	next, err := x509.ParseCertificate(resp.Svids[0].X509Svid)
+	assert.NoError(t, err)
+	assert.Equal(t, next.SerialNumber.Cmp(leaf.SerialNumber) != 0, true)
+}
+
+func TestFetchX509Bundles(t *testing.T) {
+	secrets, _, client := setup(t)
+	secret := genSecret(t)
+	secrets.Set(security.WorkloadKeyCertResourceName, secret)
+	secrets.Set(security.RootCertReqResourceName, secret)
+
+	stream, err := client.FetchX509Bundles(withHeader(t), &workload.X509BundlesRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	bundle, f := resp.Bundles["spiffe://cluster.local"]
+	assert.Equal(t, f, true)
+	_, err = x509.ParseCertificates(bundle)
+	assert.NoError(t, err)
+}
+
+func TestFetchErrors(t *testing.T) {
+	secrets, _, client := setup(t)
+
+	t.Run("missing security header", func(t *testing.T) {
+		stream, err := client.FetchX509SVID(t.Context(), &workload.X509SVIDRequest{})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	})
+	t.Run("certificate not available", func(t *testing.T) {
+		stream, err := client.FetchX509SVID(withHeader(t), &workload.X509SVIDRequest{})
+		assert.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, status.Code(err), codes.Unavailable)
+	})
+	t.Run("jwt not supported", func(t *testing.T) {
+		secrets.Set(security.WorkloadKeyCertResourceName, genSecret(t))
+		_, err := client.FetchJWTSVID(withHeader(t), &workload.JWTSVIDRequest{Audience: []string{"foo"}})
+		assert.Equal(t, status.Code(err), codes.Unimplemented)
+	})
+}
+
+type jwtSecretManager struct {
+	*security.DirectSecretManager
+}
+
+func (jwtSecretManager) GenerateJWTSVID(audiences []string) (*security.JWTSVID, error) {
+	return &security.JWTSVID{SpiffeID: testID, Token: "token"}, nil
+}
+
+func (jwtSecretManager) GenerateJWTBundle() (*security.JWTBundle, error) {
+	return &security.JWTBundle{TrustDomain: "cluster.local", JWKS: []byte(`{"keys":[]}`)}, nil
+}
+
+func TestFetchJWTSVID(t *testing.T) {
+	_, client := setupWithSecrets(t, jwtSecretManager{security.NewDirectSecretManager()})
+
+	resp, err := client.FetchJWTSVID(withHeader(t), &workload.JWTSVIDRequest{Audience: []string{"kafka"}})
+	assert.NoError(t, err)
+	assert.Equal(t, len(resp.Svids), 1)
+	assert.Equal(t, resp.Svids[0].SpiffeId, testID)
+	assert.Equal(t, resp.Svids[0].Svid, "token")
+
+	_, err = client.FetchJWTSVID(withHeader(t), &workload.JWTSVIDRequest{})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	_, err = client.FetchJWTSVID(withHeader(t), &workload.JWTSVIDRequest{Audience: []string{"kafka"}, SpiffeId: "spiffe://cluster.local/ns/a/sa/b"})
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	stream, err := client.FetchJWTBundles(withHeader(t), &workload.JWTBundlesRequest{})
+	assert.NoError(t, err)
+	bundles, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, string(bundles.Bundles["spiffe://cluster.local"]), `{"keys":[]}`)
+}
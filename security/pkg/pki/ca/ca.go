@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/elliptic"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"os"
@@ -95,6 +96,9 @@ type CertOpts struct {
 
 	// Cert Signer info
 	CertSigner string
+
+	// Extensions are added to the certificate, such as by the CSR policy. Not supported by the RA.
+	Extensions []pkix.Extension
 }
 
 const (
@@ -119,20 +123,25 @@ type IstioCAOptions struct {
 
 	// OnRootCertUpdate is the cb which can only be called by self-signed root cert rotator
 	OnRootCertUpdate func() error
+
+	// RevocationConfig enables certificate revocation, if set.
+	RevocationConfig *RevocationConfig
 }
 
 type RootCertUpdateFunc func() error
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// If crlSign is set, generated root certificates are allowed to sign CRLs, as required by certificate revocation.
 func NewSelfSignedIstioCAOptions(ctx context.Context,
 	rootCertGracePeriodPercentile int, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 	maxCertTTL time.Duration, org string, useCacertsSecretName, dualUse bool, namespace string, client corev1.CoreV1Interface,
-	rootCertFile string, enableJitter bool, caRSAKeySize int,
+	rootCertFile string, enableJitter bool, caRSAKeySize int, crlSign bool,
 ) (caOpts *IstioCAOptions, err error) {
 	caOpts = &IstioCAOptions{
 		CAType:         selfSignedCA,
 		DefaultCertTTL: defaultCertTTL,
 		MaxCertTTL:     maxCertTTL,
+		CARSAKeySize:   caRSAKeySize,
 		RotatorConfig: &SelfSignedCARootCertRotatorConfig{
 			CheckInterval:      rootCertCheckInverval,
 			caCertTTL:          caCertTTL,
@@ -144,6 +153,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 			org:                org,
 			rootCertFile:       rootCertFile,
 			enableJitter:       enableJitter,
+			crlSign:            crlSign,
 			client:             client,
 		},
 	}
@@ -180,6 +190,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 				IsSelfSigned: true,
 				RSAKeySize:   caRSAKeySize,
 				IsDualUse:    dualUse,
+				CRLSign:      crlSign,
 			}
 			pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
 			if ckErr != nil {
@@ -223,7 +234,7 @@ func loadSelfSignedCaSecret(client corev1.CoreV1Interface, namespace string, caC
 	caSecret, err := client.Secrets(namespace).Get(context.TODO(), caCertName, metav1.GetOptions{})
 	if err == nil {
 		pkiCaLog.Infof("Load signing key and cert from existing secret %s/%s", caSecret.Namespace, caSecret.Name)
-		rootCerts, err := util.AppendRootCerts(caSecret.Data[CACertFile], rootCertFile)
+		rootCerts, err := rootCertsFromSecret(caSecret, rootCertFile)
 		if err != nil {
 			return fmt.Errorf("failed to append root certificates (%v)", err)
 		}
@@ -242,9 +253,9 @@ func loadSelfSignedCaSecret(client corev1.CoreV1Interface, namespace string, caC
 }
 
 // NewSelfSignedDebugIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate produced by in-memory CA,
-// which runs without K8s, and no local ca key file presented.
+// which runs without K8s, and no local ca key file presented. If crlSign is set, the root certificate is allowed to sign CRLs.
 func NewSelfSignedDebugIstioCAOptions(rootCertFile string, caCertTTL, defaultCertTTL, maxCertTTL time.Duration,
-	org string, caRSAKeySize int,
+	org string, caRSAKeySize int, crlSign bool,
 ) (caOpts *IstioCAOptions, err error) {
 	caOpts = &IstioCAOptions{
 		CAType:         selfSignedCA,
@@ -260,6 +271,7 @@ func NewSelfSignedDebugIstioCAOptions(rootCertFile string, caCertTTL, defaultCer
 		IsSelfSigned: true,
 		RSAKeySize:   caRSAKeySize,
 		IsDualUse:    true, // hardcoded to true for K8S as well
+		CRLSign:      crlSign,
 	}
 	pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
 	if ckErr != nil {
@@ -305,26 +317,62 @@ func NewPluggedCertIstioCAOptions(fileBundle SigningCAFileBundle,
 		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 	}
 
-	// Validate that the passed in signing cert can be used as CA.
-	// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
-	// validate workload certificates (i.e., where the leaf certificate is not a CA).
-	b, err := os.ReadFile(fileBundle.SigningCertFile)
-	if err != nil {
+	if err := checkSigningCertIsCA(fileBundle.SigningCertFile); err != nil {
+		return nil, err
+	}
+
+	return caOpts, nil
+}
+
+// NewPluggedCertWithSignerIstioCAOptions returns a new IstioCAOptions instance using given certificate, whose private
+// key is held by the signer returned by signerFor. The signing key file is not used.
+func NewPluggedCertWithSignerIstioCAOptions(fileBundle SigningCAFileBundle, signerFor util.SignerProvider,
+	defaultCertTTL, maxCertTTL time.Duration, caRSAKeySize int,
+) (caOpts *IstioCAOptions, err error) {
+	caOpts = &IstioCAOptions{
+		CAType:         pluggedCertCA,
+		DefaultCertTTL: defaultCertTTL,
+		MaxCertTTL:     maxCertTTL,
+		CARSAKeySize:   caRSAKeySize,
+	}
+
+	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleWithSignerFromFile(
+		fileBundle.SigningCertFile,
+		signerFor,
+		fileBundle.CertChainFiles,
+		fileBundle.RootCertFile,
+		fileBundle.CRLFile,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+
+	if err := checkSigningCertIsCA(fileBundle.SigningCertFile); err != nil {
 		return nil, err
 	}
+
+	return caOpts, nil
+}
+
+// checkSigningCertIsCA validates that the passed in signing cert can be used as CA.
+// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
+// validate workload certificates (i.e., where the leaf certificate is not a CA).
+func checkSigningCertIsCA(signingCertFile string) error {
+	b, err := os.ReadFile(signingCertFile)
+	if err != nil {
+		return err
+	}
 	block, _ := pem.Decode(b)
 	if block == nil {
-		return nil, fmt.Errorf("invalid PEM encoded certificate")
+		return fmt.Errorf("invalid PEM encoded certificate")
 	}
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse X.509 certificate")
+		return fmt.Errorf("failed to parse X.509 certificate")
 	}
 	if !cert.IsCA {
-		return nil, fmt.Errorf("certificate is not authorized to sign other certificates")
+		return fmt.Errorf("certificate is not authorized to sign other certificates")
 	}
-
-	return caOpts, nil
+	return nil
 }
 
 // BuildSecret returns a secret struct, contents of which are filled with parameters passed in.
@@ -363,6 +411,9 @@ type IstioCA struct {
 	// rootCertRotator periodically rotates self-signed root cert for CA. It is nil
 	// if CA is not self-signed CA.
 	rootCertRotator *SelfSignedCARootCertRotator
+
+	// revoker keeps the revocation list and signs CRLs. It is nil if revocation is not enabled.
+	revoker *certRevoker
 }
 
 // NewIstioCA returns a new IstioCA instance.
@@ -377,6 +428,14 @@ func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 		ca.rootCertRotator = NewSelfSignedCARootCertRotator(opts.RotatorConfig, ca, opts.OnRootCertUpdate)
 	}
 
+	if opts.RevocationConfig != nil {
+		signingCert, _, _, _ := opts.KeyCertBundle.GetAll()
+		if err := checkCRLSigner(signingCert); err != nil {
+			return nil, fmt.Errorf("cannot enable certificate revocation: %v", err)
+		}
+		ca.revoker = newCertRevoker(opts.RevocationConfig, ca)
+	}
+
 	// if CA cert becomes invalid before workload cert it's going to cause workload cert to be invalid too,
 	// however citatel won't rotate if that happens, this function will prevent that using cert chain TTL as
 	// the workload TTL
@@ -393,6 +452,12 @@ func (ca *IstioCA) Run(stopChan chan struct{}) {
 	if ca.rootCertRotator != nil {
 		// Start root cert rotator in a separate goroutine.
 		go ca.rootCertRotator.Run(stopChan)
+		if ca.rootCertRotator.rotation != nil {
+			go ca.rootCertRotator.runStagedRotation(stopChan)
+		}
+	}
+	if ca.revoker != nil {
+		go ca.revoker.run(stopChan)
 	}
 }
 
@@ -400,14 +465,14 @@ func (ca *IstioCA) Run(stopChan chan struct{}) {
 func (ca *IstioCA) Sign(csrPEM []byte, certOpts CertOpts) (
 	[]byte, error,
 ) {
-	return ca.sign(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA)
+	return ca.sign(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA, certOpts.Extensions)
 }
 
 // SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
 func (ca *IstioCA) SignWithCertChain(csrPEM []byte, certOpts CertOpts) (
 	[]string, error,
 ) {
-	cert, err := ca.signWithCertChain(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA)
+	cert, err := ca.signWithCertChain(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA, certOpts.Extensions)
 	if err != nil {
 		return nil, err
 	}
@@ -445,7 +510,7 @@ func (ca *IstioCA) GenKeyCert(hostnames []string, certTTL time.Duration, checkLi
 		return nil, nil, err
 	}
 
-	certPEM, err := ca.signWithCertChain(csrPEM, hostnames, certTTL, checkLifetime, false)
+	certPEM, err := ca.signWithCertChain(csrPEM, hostnames, certTTL, checkLifetime, false, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -475,7 +540,9 @@ func (ca *IstioCA) minTTL(defaultCertTTL time.Duration) (time.Duration, error) {
 	return defaultCertTTL, nil
 }
 
-func (ca *IstioCA) sign(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, checkLifetime, forCA bool) ([]byte, error) {
+func (ca *IstioCA) sign(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, checkLifetime, forCA bool,
+	extensions []pkix.Extension,
+) ([]byte, error) {
 	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
 	if signingCert == nil {
 		return nil, caerror.NewError(caerror.CANotReady, fmt.Errorf("Istio CA is not ready")) // nolint
@@ -501,10 +568,13 @@ func (ca *IstioCA) sign(csrPEM []byte, subjectIDs []string, requestedLifetime ti
 			"requested TTL %s is greater than the max allowed TTL %s", requestedLifetime, ca.maxCertTTL))
 	}
 
-	certBytes, err := util.GenCertFromCSR(csr, signingCert, csr.PublicKey, *signingKey, subjectIDs, lifetime, forCA)
+	certBytes, err := util.GenCertFromCSRWithExtensions(csr, signingCert, csr.PublicKey, *signingKey, subjectIDs, lifetime, forCA, extensions)
 	if err != nil {
 		return nil, caerror.NewError(caerror.CertGenError, err)
 	}
+	if ca.revoker != nil && !forCA {
+		ca.revoker.recordIssued(certBytes, subjectIDs)
+	}
 
 	block := &pem.Block{
 		Type:  "CERTIFICATE",
@@ -516,9 +586,9 @@ func (ca *IstioCA) sign(csrPEM []byte, subjectIDs []string, requestedLifetime ti
 }
 
 func (ca *IstioCA) signWithCertChain(csrPEM []byte, subjectIDs []string, requestedLifetime time.Duration, lifetimeCheck,
-	forCA bool,
+	forCA bool, extensions []pkix.Extension,
 ) ([]byte, error) {
-	cert, err := ca.sign(csrPEM, subjectIDs, requestedLifetime, lifetimeCheck, forCA)
+	cert, err := ca.sign(csrPEM, subjectIDs, requestedLifetime, lifetimeCheck, forCA, extensions)
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,7 @@ package ca
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"os"
@@ -101,7 +102,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, false, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -166,7 +167,7 @@ func TestCreateSelfSignedIstioCAWithoutSecretAndUseCacertsEnabled(t *testing.T)
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, true, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -242,7 +243,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL, maxCertTTL,
 		org, false, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -292,7 +293,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Errorf("Got unexpected error: %v", err)
 	}
@@ -307,7 +308,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	ctx1 := t.Context()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -360,7 +361,7 @@ func TestConcurrentCreateSelfSignedIstioCA(t *testing.T) {
 			defer cancel0()
 			caOpts, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 				caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-				caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+				caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 			if err != nil {
 				t.Errorf("NewSelfSignedIstioCAOptions got unexpected error: %v", err)
 			}
@@ -453,6 +454,60 @@ func TestCreatePluggedCertCA(t *testing.T) {
 	}
 }
 
+// externalSigner hides the private key type, as the signers of keys held outside of Istiod.
+type externalSigner struct {
+	crypto.Signer
+}
+
+func TestCreatePluggedCertCAWithSigner(t *testing.T) {
+	fileBundle := SigningCAFileBundle{
+		RootCertFile:    "../testdata/multilevelpki/root-cert.pem",
+		CertChainFiles:  []string{"../testdata/multilevelpki/int2-cert-chain.pem"},
+		SigningCertFile: "../testdata/multilevelpki/int2-cert.pem",
+	}
+	signerFor := func(keyFile string) util.SignerProvider {
+		return func(*x509.Certificate) (crypto.Signer, error) {
+			_, key, err := util.LoadSignerCredsFromFiles(fileBundle.SigningCertFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return externalSigner{key.(crypto.Signer)}, nil
+		}
+	}
+
+	_, err := NewPluggedCertWithSignerIstioCAOptions(fileBundle, signerFor("../testdata/multilevelpki/int-key.pem"),
+		time.Hour, time.Hour, rsaKeySize)
+	if err == nil {
+		t.Fatal("Expected an error for a signer not holding the key of the signing cert")
+	}
+
+	caopts, err := NewPluggedCertWithSignerIstioCAOptions(fileBundle, signerFor("../testdata/multilevelpki/int2-key.pem"),
+		time.Hour, time.Hour, rsaKeySize)
+	if err != nil {
+		t.Fatalf("Failed to create a plugged-cert CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating plugged-cert CA: %v", err)
+	}
+	if _, key, _, _ := ca.GetCAKeyCertBundle().GetAllPem(); len(key) != 0 {
+		t.Errorf("Expected no signing key pem, got %q", key)
+	}
+
+	csr, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://cluster.local/ns/foo/sa/bar", RSAKeySize: rsaKeySize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csr, CertOpts{SubjectIDs: []string{"spiffe://cluster.local/ns/foo/sa/bar"}, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to sign with the signer: %v", err)
+	}
+	_, _, certChainBytes, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem()
+	if err := util.VerifyCertificate(nil, append(certPEM, certChainBytes...), rootCertBytes, nil); err != nil {
+		t.Errorf("Failed to verify the signed certificate: %v", err)
+	}
+}
+
 func TestCreatePluggedCertCAWithCrl(t *testing.T) {
 	rootCertFile := "../testdata/crl/root-cert.pem"
 	certChainFile := []string{"../testdata/crl/cert-chain.pem"}
@@ -849,7 +904,7 @@ func TestSignWithCertChain(t *testing.T) {
 		TTL:        time.Hour,
 		ForCA:      false,
 	}
-	certPEM, signErr := ca.signWithCertChain(csrPEM, caCertOpts.SubjectIDs, caCertOpts.TTL, true, caCertOpts.ForCA)
+	certPEM, signErr := ca.signWithCertChain(csrPEM, caCertOpts.SubjectIDs, caCertOpts.TTL, true, caCertOpts.ForCA, nil)
 
 	if signErr != nil {
 		t.Error(err)
@@ -0,0 +1,504 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// CARevocationsSecret stores the list of certificates revoked by the Istio CA.
+	CARevocationsSecret = "istio-ca-revocations"
+	// RevocationsFile is the key of the JSON encoded list of Revocation in CARevocationsSecret.
+	RevocationsFile = "revocations.json"
+
+	// maxConflictRetries is the number of times an update of the revocations secret is retried on conflicts with
+	// other istiod replicas.
+	maxConflictRetries = 5
+)
+
+var revocationLog = log.RegisterScope("carevocation", "Istio CA certificate revocation log")
+
+// revocationReasons maps the reasons accepted in a Revocation to the CRL reason codes of RFC 5280.
+var revocationReasons = map[string]int{
+	"":                     0,
+	"unspecified":          0,
+	"keyCompromise":        1,
+	"cACompromise":         2,
+	"affiliationChanged":   3,
+	"superseded":           4,
+	"cessationOfOperation": 5,
+}
+
+// Revocation revokes either the certificate with the given serial, or all the certificates issued for the given
+// identity before RevokedAt. Identity revocations are resolved into serial revocations by the istiod replicas that
+// issued the certificates, since a CRL can only list serials. Replicas know the certificates they issued since they
+// started and, if RevocationConfig.LookupIssued is set, those in their persistent issuance log. Other certificates,
+// such as those issued by replicas that are gone along with their log, must be revoked by serial.
+type Revocation struct {
+	// Serial is the hex encoded serial number of the certificate.
+	Serial   string `json:"serial,omitempty"`
+	Identity string `json:"identity,omitempty"`
+	// RevokedAt is the time of the revocation. It is set by the CA if omitted.
+	RevokedAt time.Time `json:"revokedAt,omitempty"`
+	// Reason is one of the RFC 5280 reasons, such as keyCompromise.
+	Reason string `json:"reason,omitempty"`
+}
+
+// RevocationConfig configures certificate revocation for the Istio CA.
+type RevocationConfig struct {
+	Client corev1.CoreV1Interface
+	// Namespace of the CARevocationsSecret.
+	Namespace string
+	// CheckInterval is how often the revocations secret is reloaded.
+	CheckInterval time.Duration
+	// CRLValidity is the time between the ThisUpdate and NextUpdate of the generated CRLs.
+	CRLValidity time.Duration
+	// OnCRLUpdate is called with the PEM encoded CRL each time a new one is signed.
+	OnCRLUpdate func(crl []byte)
+	// LookupIssued returns the certificates issued to identity at or after since, from a persistent issuance log. If
+	// set, identity revocations also resolve the certificates issued before this istiod started.
+	LookupIssued func(identity string, since time.Time) ([]IssuedCertificate, error)
+}
+
+// IssuedCertificate is a certificate issued by the CA, as recorded in an issuance log.
+type IssuedCertificate struct {
+	// Serial is the hex encoded serial number of the certificate.
+	Serial   string
+	IssuedAt time.Time
+	NotAfter time.Time
+}
+
+// issuedCert is a certificate issued by this CA, kept to resolve identity revocations.
+type issuedCert struct {
+	identities []string
+	issuedAt   time.Time
+	notAfter   time.Time
+}
+
+// certRevoker keeps the revocation list of an IstioCA and signs CRLs for it.
+type certRevoker struct {
+	config *RevocationConfig
+	ca     *IstioCA
+	// trigger requests an immediate refresh.
+	trigger chan struct{}
+
+	// started is when the revoker started recording the issued certificates.
+	started time.Time
+
+	mu     sync.Mutex
+	issued map[string]issuedCert
+	// incomplete is the set of identity revocations already warned to not be fully resolvable.
+	incomplete sets.String
+	// lookedUp is the set of identity revocations already resolved with LookupIssued.
+	lookedUp sets.String
+	// revoked is the set of serials in the last signed CRL.
+	revoked    sets.String
+	crl        []byte
+	crlSigner  []byte
+	crlIssued  time.Time
+	crlBaseCRL []byte
+}
+
+func newCertRevoker(config *RevocationConfig, ca *IstioCA) *certRevoker {
+	return &certRevoker{
+		config:     config,
+		ca:         ca,
+		trigger:    make(chan struct{}, 1),
+		started:    time.Now(),
+		issued:     map[string]issuedCert{},
+		incomplete: sets.New[string](),
+		lookedUp:   sets.New[string](),
+	}
+}
+
+// NormalizeSerial returns the lower case hex encoding, without leading zeros, of a serial number given in hex,
+// optionally with a 0x prefix or colon separators.
+func NormalizeSerial(serial string) (string, error) {
+	s := strings.TrimPrefix(strings.ToLower(strings.ReplaceAll(serial, ":", "")), "0x")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok || n.Sign() < 0 {
+		return "", fmt.Errorf("invalid serial %q", serial)
+	}
+	return n.Text(16), nil
+}
+
+func (r Revocation) validate() error {
+	if r.Serial == "" && r.Identity == "" {
+		return fmt.Errorf("a revocation must have a serial or an identity")
+	}
+	if r.Serial != "" {
+		if _, err := NormalizeSerial(r.Serial); err != nil {
+			return err
+		}
+	}
+	if _, ok := revocationReasons[r.Reason]; !ok {
+		return fmt.Errorf("unknown revocation reason %q", r.Reason)
+	}
+	return nil
+}
+
+// Revoke adds revocations to the revocations secret, and publishes a new CRL.
+func (ca *IstioCA) Revoke(ctx context.Context, revocations ...Revocation) error {
+	if ca.revoker == nil {
+		return fmt.Errorf("certificate revocation is not enabled")
+	}
+	if err := AddRevocations(ctx, ca.revoker.config.Client, ca.revoker.config.Namespace, revocations...); err != nil {
+		return err
+	}
+	ca.revoker.refreshNow()
+	return nil
+}
+
+// AddRevocations adds revocations to the CARevocationsSecret of namespace. The istiod replicas publish a new CRL
+// with them on their next check.
+func AddRevocations(ctx context.Context, client corev1.CoreV1Interface, namespace string, revocations ...Revocation) error {
+	now := time.Now()
+	for i := range revocations {
+		if err := revocations[i].validate(); err != nil {
+			return err
+		}
+		if revocations[i].Serial != "" {
+			revocations[i].Serial, _ = NormalizeSerial(revocations[i].Serial)
+		}
+		if revocations[i].RevokedAt.IsZero() {
+			revocations[i].RevokedAt = now
+		}
+	}
+	return updateRevocations(ctx, client, namespace, func(list []Revocation) ([]Revocation, bool) {
+		return append(list, revocations...), true
+	})
+}
+
+// recordIssued remembers a certificate issued by the CA, until it expires.
+func (r *certRevoker) recordIssued(certDER []byte, identities []string) {
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		revocationLog.Errorf("failed to parse issued certificate for %v: %v", identities, err)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.issued[cert.SerialNumber.Text(16)] = issuedCert{identities: identities, issuedAt: time.Now(), notAfter: cert.NotAfter}
+}
+
+// RefreshCRL requests a new CRL to be signed, such as after the plugged-in CA CRL changed. It returns false if
+// revocation is not enabled.
+func (ca *IstioCA) RefreshCRL() bool {
+	if ca.revoker == nil {
+		return false
+	}
+	ca.revoker.refreshNow()
+	return true
+}
+
+func (r *certRevoker) refreshNow() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run refreshes the CRL every CheckInterval, and when triggered, until stopCh is closed.
+func (r *certRevoker) run(stopCh chan struct{}) {
+	r.refresh()
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.refresh()
+		case <-r.trigger:
+			r.refresh()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// refresh resolves and prunes the revocations in the secret, and signs a new CRL if the revoked serials or the
+// signing certificate changed, or the current CRL is halfway through its validity.
+func (r *certRevoker) refresh() {
+	var list []Revocation
+	err := updateRevocations(context.Background(), r.config.Client, r.config.Namespace, func(current []Revocation) ([]Revocation, bool) {
+		list = r.resolve(current, time.Now())
+		return list, !slices.Equal(list, current)
+	})
+	if err != nil {
+		revocationLog.Errorf("failed to update secret %s/%s: %v", r.config.Namespace, CARevocationsSecret, err)
+		return
+	}
+	if err := r.maybeSignCRL(list, time.Now()); err != nil {
+		revocationLog.Errorf("failed to sign CRL: %v", err)
+	}
+}
+
+// resolve returns the revocations with a RevokedAt set, the serials of the certificates this CA issued to revoked
+// identities added, and the revocations of certificates that have expired by now removed.
+func (r *certRevoker) resolve(list []Revocation, now time.Time) []Revocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for serial, c := range r.issued {
+		if now.After(c.notAfter) {
+			delete(r.issued, serial)
+		}
+	}
+
+	out := make([]Revocation, 0, len(list))
+	serials := sets.New[string]()
+	for _, rev := range list {
+		if rev.RevokedAt.IsZero() {
+			rev.RevokedAt = now
+		}
+		// Certificates issued before the revocation are all expired by RevokedAt + maxCertTTL.
+		if now.After(rev.RevokedAt.Add(r.ca.maxCertTTL)) {
+			continue
+		}
+		if rev.Serial != "" {
+			serials.Insert(rev.Serial)
+		}
+		out = append(out, rev)
+	}
+	for _, rev := range out {
+		if rev.Serial != "" || rev.Identity == "" {
+			continue
+		}
+		revoke := func(serial string) {
+			if serials.Contains(serial) {
+				return
+			}
+			revocationLog.Infof("revoking certificate %s of revoked identity %s", serial, rev.Identity)
+			out = append(out, Revocation{Serial: serial, Identity: rev.Identity, RevokedAt: rev.RevokedAt, Reason: rev.Reason})
+			serials.Insert(serial)
+		}
+		for serial, c := range r.issued {
+			if slices.Contains(c.identities, rev.Identity) && c.issuedAt.Before(rev.RevokedAt) {
+				revoke(serial)
+			}
+		}
+		// The identity may hold certificates issued before this replica started, which are only known from the
+		// persistent issuance log, if any.
+		key := rev.Identity + "/" + rev.RevokedAt.String()
+		if !rev.RevokedAt.Add(-r.ca.maxCertTTL).Before(r.started) || r.lookedUp.Contains(key) {
+			continue
+		}
+		if r.config.LookupIssued != nil {
+			issued, err := r.config.LookupIssued(rev.Identity, rev.RevokedAt.Add(-r.ca.maxCertTTL))
+			if err == nil {
+				for _, c := range issued {
+					if c.IssuedAt.Before(rev.RevokedAt) && now.Before(c.NotAfter) {
+						revoke(c.Serial)
+					}
+				}
+				r.lookedUp.Insert(key)
+				continue
+			}
+			revocationLog.Errorf("failed to look up the certificates issued to revoked identity %s: %v", rev.Identity, err)
+		}
+		if !r.incomplete.InsertContains(key) {
+			revocationLog.Warnf("revocation of identity %s may not revoke the certificates issued to it before %v, "+
+				"when this istiod started, or by other istiod replicas that are gone; revoke those by serial",
+				rev.Identity, r.started.Format(time.RFC3339))
+		}
+	}
+	return out
+}
+
+// checkCRLSigner returns an error if the CA signing certificate cannot sign CRLs.
+func checkCRLSigner(signingCert *x509.Certificate) error {
+	if signingCert == nil {
+		return fmt.Errorf("istio CA is not ready")
+	}
+	if signingCert.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return fmt.Errorf("the CA signing certificate %q does not have the cRLSign key usage required to sign CRLs; "+
+			"reissue it with that key usage, or disable PILOT_ENABLE_CA_REVOCATION", signingCert.Subject)
+	}
+	return nil
+}
+
+// updateRevocations applies fn to the revocations in the secret, and writes them back if fn reports a change. The
+// secret is created if needed, and the update retried if another replica changed it concurrently.
+func updateRevocations(ctx context.Context, client corev1.CoreV1Interface, namespace string,
+	fn func([]Revocation) ([]Revocation, bool),
+) error {
+	secrets := client.Secrets(namespace)
+	for attempt := 0; ; attempt++ {
+		secret, err := secrets.Get(ctx, CARevocationsSecret, metav1.GetOptions{})
+		if apierror.IsNotFound(err) {
+			secret = nil
+		} else if err != nil {
+			return err
+		}
+		var current []Revocation
+		if secret != nil && len(secret.Data[RevocationsFile]) > 0 {
+			if err := json.Unmarshal(secret.Data[RevocationsFile], &current); err != nil {
+				return fmt.Errorf("invalid %s: %v", RevocationsFile, err)
+			}
+		}
+		valid := current[:0:0]
+		for _, rev := range current {
+			if err := rev.validate(); err != nil {
+				revocationLog.Warnf("ignoring invalid revocation %+v: %v", rev, err)
+				continue
+			}
+			if rev.Serial != "" {
+				rev.Serial, _ = NormalizeSerial(rev.Serial)
+			}
+			valid = append(valid, rev)
+		}
+		updated, changed := fn(valid)
+		if !changed {
+			return nil
+		}
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		if secret == nil {
+			_, err = secrets.Create(ctx, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: CARevocationsSecret, Namespace: namespace},
+				Data:       map[string][]byte{RevocationsFile: data},
+			}, metav1.CreateOptions{})
+		} else {
+			secret = secret.DeepCopy()
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[RevocationsFile] = data
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if (apierror.IsConflict(err) || apierror.IsAlreadyExists(err)) && attempt < maxConflictRetries {
+			continue
+		}
+		return err
+	}
+}
+
+// maybeSignCRL signs a CRL of the revoked serials, if it differs from the current one or the current one is due.
+func (r *certRevoker) maybeSignCRL(list []Revocation, now time.Time) error {
+	signingCert, signingKey, _, _ := r.ca.keyCertBundle.GetAll()
+	if err := checkCRLSigner(signingCert); err != nil {
+		return err
+	}
+	baseCRL := r.ca.keyCertBundle.GetCRLPem()
+	revoked := sets.New[string]()
+	for _, rev := range list {
+		if rev.Serial != "" {
+			revoked.Insert(rev.Serial)
+		}
+	}
+
+	r.mu.Lock()
+	if r.crl != nil && r.revoked.Equals(revoked) && bytes.Equal(r.crlSigner, signingCert.Raw) &&
+		bytes.Equal(r.crlBaseCRL, baseCRL) && now.Before(r.crlIssued.Add(r.config.CRLValidity/2)) {
+		r.mu.Unlock()
+		return nil
+	}
+	r.mu.Unlock()
+
+	entries := make([]x509.RevocationListEntry, 0, len(list))
+	seen := sets.New[string]()
+	for _, rev := range list {
+		if rev.Serial == "" || seen.InsertContains(rev.Serial) {
+			continue
+		}
+		serial, _ := new(big.Int).SetString(rev.Serial, 16)
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: rev.RevokedAt,
+			ReasonCode:     revocationReasons[rev.Reason],
+		})
+	}
+	// A plugged-in CA may come with a CRL from its operator. The entries of the CRL of the signing certificate are
+	// kept in the generated CRL, which replaces it, and the CRLs of the other certificates in the chain are kept as is.
+	var others [][]byte
+	for rest := baseCRL; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse plugged-in CA CRL: %v", err)
+		}
+		if crl.CheckSignatureFrom(signingCert) != nil {
+			others = append(others, pem.EncodeToMemory(block))
+			continue
+		}
+		for _, e := range crl.RevokedCertificateEntries {
+			if !seen.InsertContains(e.SerialNumber.Text(16)) {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	signer, ok := (*signingKey).(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("the signing key of type %T cannot sign CRLs", *signingKey)
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		// The number must increase with every CRL, including across replicas and restarts.
+		Number:                    big.NewInt(now.UnixNano()),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(r.config.CRLValidity),
+		RevokedCertificateEntries: entries,
+	}, signingCert, signer)
+	if err != nil {
+		return err
+	}
+	crl := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	for _, o := range others {
+		crl = append(crl, o...)
+	}
+	revocationLog.Infof("signed CRL with %d revoked certificates", len(entries))
+	r.mu.Lock()
+	r.crl, r.revoked, r.crlSigner, r.crlIssued, r.crlBaseCRL = crl, revoked, signingCert.Raw, now, baseCRL
+	r.mu.Unlock()
+	if r.config.OnCRLUpdate != nil {
+		r.config.OnCRLUpdate(crl)
+	}
+	return nil
+}
+
+// GetCRLPem returns the last CRL signed by the CA, or nil if revocation is not enabled.
+func (ca *IstioCA) GetCRLPem() []byte {
+	if ca.revoker == nil {
+		return nil
+	}
+	ca.revoker.mu.Lock()
+	defer ca.revoker.mu.Unlock()
+	return ca.revoker.crl
+}
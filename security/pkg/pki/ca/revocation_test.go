@@ -0,0 +1,183 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	revokedIdentity = "spiffe://cluster.local/ns/a/sa/a"
+	otherIdentity   = "spiffe://cluster.local/ns/b/sa/b"
+)
+
+func TestNormalizeSerial(t *testing.T) {
+	for _, in := range []string{"1f2e", "0x1F2E", "00:1f:2e", "001f2e"} {
+		got, err := NormalizeSerial(in)
+		assert.NoError(t, err)
+		assert.Equal(t, got, "1f2e")
+	}
+	_, err := NormalizeSerial("not-hex")
+	assert.Error(t, err)
+}
+
+func TestRevocation(t *testing.T) {
+	client := fake.NewClientset()
+	var crls [][]byte
+	caOpts, err := NewSelfSignedDebugIstioCAOptions("", time.Hour*24, time.Hour, time.Hour*2, "cluster.local", 2048, true)
+	assert.NoError(t, err)
+	caOpts.RevocationConfig = &RevocationConfig{
+		Client:        client.CoreV1(),
+		Namespace:     "istio-system",
+		CheckInterval: time.Minute,
+		CRLValidity:   time.Hour,
+		OnCRLUpdate:   func(crl []byte) { crls = append(crls, crl) },
+	}
+	ca, err := NewIstioCA(caOpts)
+	assert.NoError(t, err)
+
+	sign := func(identity string) string {
+		t.Helper()
+		csr, _, err := util.GenCSR(util.CertOptions{Host: identity, RSAKeySize: 2048})
+		assert.NoError(t, err)
+		certPEM, err := ca.Sign(csr, CertOpts{SubjectIDs: []string{identity}, TTL: time.Hour})
+		assert.NoError(t, err)
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		assert.NoError(t, err)
+		return cert.SerialNumber.Text(16)
+	}
+	revoked := func() map[string]int {
+		t.Helper()
+		block, _ := pem.Decode(crls[len(crls)-1])
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		assert.NoError(t, err)
+		signingCert, _, _, _ := ca.keyCertBundle.GetAll()
+		assert.NoError(t, crl.CheckSignatureFrom(signingCert))
+		out := map[string]int{}
+		for _, e := range crl.RevokedCertificateEntries {
+			out[e.SerialNumber.Text(16)] = e.ReasonCode
+		}
+		return out
+	}
+	secret := func() []Revocation {
+		t.Helper()
+		s, err := client.CoreV1().Secrets("istio-system").Get(context.Background(), CARevocationsSecret, metav1.GetOptions{})
+		assert.NoError(t, err)
+		var out []Revocation
+		assert.NoError(t, json.Unmarshal(s.Data[RevocationsFile], &out))
+		return out
+	}
+
+	// An empty CRL is published even if nothing was revoked yet.
+	ca.revoker.refresh()
+	assert.Equal(t, len(crls), 1)
+	assert.Equal(t, revoked(), map[string]int{})
+
+	a1, a2, b := sign(revokedIdentity), sign(revokedIdentity), sign(otherIdentity)
+	assert.NoError(t, ca.Revoke(context.Background(), Revocation{Identity: revokedIdentity, Reason: "keyCompromise"}))
+	ca.revoker.refresh()
+	assert.Equal(t, revoked(), map[string]int{a1: 1, a2: 1})
+	// The serials of the identity are persisted, for the replicas that did not issue them.
+	assert.Equal(t, len(secret()), 3)
+
+	// Certificates issued after the revocation of their identity are valid.
+	sign(revokedIdentity)
+	ca.revoker.refresh()
+	assert.Equal(t, len(crls), 2)
+
+	assert.NoError(t, ca.Revoke(context.Background(), Revocation{Serial: "0x" + strings.ToUpper(b)}))
+	ca.revoker.refresh()
+	assert.Equal(t, revoked(), map[string]int{a1: 1, a2: 1, b: 0})
+
+	// Revocations are dropped once all the certificates they revoke have expired.
+	assert.Equal(t, len(ca.revoker.resolve(secret(), time.Now().Add(3*time.Hour))), 0)
+
+	assert.Error(t, ca.Revoke(context.Background(), Revocation{Serial: b, Reason: "bored"}))
+	assert.Error(t, ca.Revoke(context.Background(), Revocation{}))
+}
+
+func TestRevocationDisabled(t *testing.T) {
+	caOpts, err := NewSelfSignedDebugIstioCAOptions("", time.Hour*24, time.Hour, time.Hour*2, "cluster.local", 2048, false)
+	assert.NoError(t, err)
+	ca, err := NewIstioCA(caOpts)
+	assert.NoError(t, err)
+	assert.Error(t, ca.Revoke(context.Background(), Revocation{Serial: "1"}))
+	assert.Equal(t, ca.RefreshCRL(), false)
+	assert.Equal(t, len(ca.GetCRLPem()), 0)
+}
+
+func TestRevocationRequiresCRLSign(t *testing.T) {
+	caOpts, err := NewSelfSignedDebugIstioCAOptions("", time.Hour*24, time.Hour, time.Hour*2, "cluster.local", 2048, false)
+	assert.NoError(t, err)
+	caOpts.RevocationConfig = &RevocationConfig{
+		Client:        fake.NewClientset().CoreV1(),
+		Namespace:     "istio-system",
+		CheckInterval: time.Minute,
+		CRLValidity:   time.Hour,
+	}
+	_, err = NewIstioCA(caOpts)
+	assert.Error(t, err)
+}
+
+func TestRevocationLookupIssued(t *testing.T) {
+	client := fake.NewClientset()
+	caOpts, err := NewSelfSignedDebugIstioCAOptions("", time.Hour*24, time.Hour, time.Hour*2, "cluster.local", 2048, true)
+	assert.NoError(t, err)
+	now := time.Now()
+	lookups := 0
+	caOpts.RevocationConfig = &RevocationConfig{
+		Client:        client.CoreV1(),
+		Namespace:     "istio-system",
+		CheckInterval: time.Minute,
+		CRLValidity:   time.Hour,
+		// The issuance log holds certificates issued before istiod started.
+		LookupIssued: func(identity string, since time.Time) ([]IssuedCertificate, error) {
+			lookups++
+			assert.Equal(t, identity, revokedIdentity)
+			return []IssuedCertificate{
+				{Serial: "a1", IssuedAt: now.Add(-30 * time.Minute), NotAfter: now.Add(30 * time.Minute)},
+				{Serial: "a2", IssuedAt: now.Add(-90 * time.Minute), NotAfter: now.Add(-30 * time.Minute)},
+				{Serial: "a3", IssuedAt: now.Add(time.Hour), NotAfter: now.Add(2 * time.Hour)},
+			}, nil
+		},
+	}
+	ca, err := NewIstioCA(caOpts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ca.Revoke(context.Background(), Revocation{Identity: revokedIdentity, RevokedAt: now}))
+	ca.revoker.refresh()
+	// Only the unexpired certificate issued before the revocation is revoked.
+	s, err := client.CoreV1().Secrets("istio-system").Get(context.Background(), CARevocationsSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	var list []Revocation
+	assert.NoError(t, json.Unmarshal(s.Data[RevocationsFile], &list))
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[1].Serial, "a1")
+	// The log is only looked up once per identity revocation.
+	ca.revoker.refresh()
+	assert.Equal(t, lookups, 1)
+}
@@ -0,0 +1,501 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// A staged root rotation replaces the self-signed root with a root of a new key, without breaking mTLS between
+// workloads holding certificates signed by either of them:
+//  1. Distributing: the new root is added to the trust bundle, while the old root keeps signing.
+//  2. Signing: once all proxies have the new trust bundle, the new root signs, while the old root is still trusted.
+//  3. Idle: once the certificates signed by the old root have expired, the old root is retired.
+//
+// The phase and the roots are kept in the CA secret, so that all Istiod replicas converge on them. Transitions are
+// requested by setting RootRotationRequestAnnotation on the CA secret to the next phase, and are only done once the
+// gates of the phase are met, as reported by every live Istiod replica, unless RootRotationForceAnnotation is set to
+// "true". Each replica shares the trust bundle state of its proxies through a ReplicaSyncStore.
+
+// RootRotationPhase is a phase of a staged root rotation.
+type RootRotationPhase string
+
+const (
+	// RootRotationIdle is the phase without rotation in progress.
+	RootRotationIdle RootRotationPhase = "Idle"
+	// RootRotationDistributing is the phase where the new root is trusted but does not sign yet.
+	RootRotationDistributing RootRotationPhase = "Distributing"
+	// RootRotationSigning is the phase where the new root signs and the old root is still trusted.
+	RootRotationSigning RootRotationPhase = "Signing"
+)
+
+const (
+	// RootRotationRequestAnnotation is set on the CA secret to request a transition to the phase it holds.
+	RootRotationRequestAnnotation = "ca.istio.io/root-rotation-request"
+	// RootRotationForceAnnotation is set to "true" on the CA secret to skip the gates of the requested transition.
+	RootRotationForceAnnotation = "ca.istio.io/root-rotation-force"
+
+	// StagedCACertFile is the new root certificate, in the Distributing phase.
+	StagedCACertFile = "staged-ca-cert.pem"
+	// StagedCAPrivateKeyFile is the private key of StagedCACertFile.
+	StagedCAPrivateKeyFile = "staged-ca-key.pem"
+	// RetiringCACertFile is the old root certificate, in the Signing phase.
+	RetiringCACertFile = "retiring-ca-cert.pem"
+
+	rootRotationPhaseKey = "rotation-phase"
+	rootRotationSinceKey = "rotation-since"
+)
+
+// ProxySync is the trust bundle state of the proxies connected to an Istiod, since a phase transition.
+type ProxySync struct {
+	// Synced is the number of proxies which acknowledged a trust bundle pushed since the transition, or, for those not
+	// served the trust bundle with PCDS, were issued a certificate since the transition.
+	Synced int `json:"synced"`
+	// Pending are the proxies served the trust bundle with PCDS which did not acknowledge it yet.
+	Pending []string `json:"pending,omitempty"`
+	// AwaitingCertificate are the pods of the proxies not served the trust bundle with PCDS, such as ztunnel and
+	// proxyless gRPC, which were not issued a certificate by this Istiod since the transition. They receive the trust
+	// bundle along with their certificates, which another replica may have issued.
+	AwaitingCertificate []string `json:"awaitingCertificate,omitempty"`
+}
+
+// ReplicaSync is the trust bundle state an Istiod replica reports to the others.
+type ReplicaSync struct {
+	Replica string `json:"replica"`
+	// Since is the time of the transition the state is observed since.
+	Since   time.Time `json:"since"`
+	Synced  int       `json:"synced"`
+	Pending int       `json:"pending"`
+	// AwaitingCertificate are the pods of the proxies awaiting a certificate on this replica.
+	AwaitingCertificate []string `json:"awaitingCertificate,omitempty"`
+	// Issued are the pods awaiting a certificate on other replicas which this replica issued one to since the transition.
+	Issued []string `json:"issued,omitempty"`
+}
+
+// ReplicaSyncStore shares the trust bundle state observed by each Istiod replica.
+type ReplicaSyncStore interface {
+	// Publish records the state observed by this replica.
+	Publish(sync ReplicaSync) error
+	// List returns the state last published by each other live replica.
+	List() ([]ReplicaSync, error)
+}
+
+// StagedRootRotationOptions configures the staged rotation of the self-signed root.
+type StagedRootRotationOptions struct {
+	// Replica is the name of this Istiod replica.
+	Replica string
+	// CheckInterval is the interval at which requested transitions are checked, and the state of the replica published.
+	CheckInterval time.Duration
+	// ProxySync reports the trust bundle state of the proxies connected to this replica since the given time.
+	ProxySync func(since time.Time) ProxySync
+	// IssuedSince returns the pods this replica issued certificates to since the given time.
+	IssuedSince func(since time.Time) []string
+	// Replicas shares the state with the other replicas. If nil, only the proxies of this replica gate the transitions.
+	Replicas ReplicaSyncStore
+}
+
+// RootInfo describes a root certificate.
+type RootInfo struct {
+	Subject string `json:"subject"`
+	// Fingerprint is the hex encoded SHA-256 of the certificate.
+	Fingerprint string    `json:"fingerprint"`
+	NotAfter    time.Time `json:"notAfter"`
+}
+
+// RootRotationStatus is the state of the staged root rotation, as observed by an Istiod.
+type RootRotationStatus struct {
+	// Secret is the name of the CA secret.
+	Secret string            `json:"secret"`
+	Phase  RootRotationPhase `json:"phase"`
+	// Since is the time of the transition to Phase.
+	Since time.Time `json:"since,omitempty"`
+	// Requested is the phase requested with RootRotationRequestAnnotation, if any.
+	Requested    RootRotationPhase `json:"requested,omitempty"`
+	SigningRoot  *RootInfo         `json:"signingRoot,omitempty"`
+	StagedRoot   *RootInfo         `json:"stagedRoot,omitempty"`
+	RetiringRoot *RootInfo         `json:"retiringRoot,omitempty"`
+	// Proxies is the state of the proxies connected to this Istiod.
+	Proxies ProxySync `json:"proxies"`
+	// Replicas is the state reported by each live Istiod replica during a rotation, this one first.
+	Replicas []ReplicaSync `json:"replicas,omitempty"`
+	// Blocked is why the rotation can not advance to the next phase yet, if it can not.
+	Blocked string `json:"blocked,omitempty"`
+}
+
+// rootRotation holds the staged rotation state of the rotator.
+type rootRotation struct {
+	StagedRootRotationOptions
+
+	mu sync.Mutex
+	// secret is the CA secret last loaded.
+	secret *v1.Secret
+}
+
+// NextRootRotationPhase returns the phase advancing a rotation in the given phase.
+func NextRootRotationPhase(phase RootRotationPhase) RootRotationPhase {
+	switch phase {
+	case RootRotationIdle:
+		return RootRotationDistributing
+	case RootRotationDistributing:
+		return RootRotationSigning
+	default:
+		return RootRotationIdle
+	}
+}
+
+// EnableStagedRootRotation enables staged rotations of the self-signed root. It must be called before Run.
+func (ca *IstioCA) EnableStagedRootRotation(opts StagedRootRotationOptions) error {
+	if ca.rootCertRotator == nil {
+		return fmt.Errorf("staged root rotation requires a self-signed CA with root cert rotation")
+	}
+	ca.rootCertRotator.rotation = &rootRotation{StagedRootRotationOptions: opts}
+	return nil
+}
+
+// RootRotationStatus returns the state of the staged root rotation.
+func (ca *IstioCA) RootRotationStatus() (*RootRotationStatus, error) {
+	if ca.rootCertRotator == nil || ca.rootCertRotator.rotation == nil {
+		return nil, fmt.Errorf("staged root rotation is not enabled")
+	}
+	return ca.rootCertRotator.rootRotationStatus()
+}
+
+// runStagedRotation applies the requested transitions and converges the KeyCertBundle on the CA secret.
+func (rotator *SelfSignedCARootCertRotator) runStagedRotation(stopCh chan struct{}) {
+	rotator.checkStagedRotation()
+	ticker := time.NewTicker(rotator.rotation.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rotator.checkStagedRotation()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (rotator *SelfSignedCARootCertRotator) checkStagedRotation() {
+	caSecret, err := rotator.config.client.Secrets(rotator.config.caStorageNamespace).Get(context.TODO(),
+		rotator.config.secretName, metav1.GetOptions{})
+	if err != nil {
+		rootCertRotatorLog.Errorf("failed to load CA secret %s/%s for the staged root rotation: %v",
+			rotator.config.caStorageNamespace, rotator.config.secretName, err)
+		return
+	}
+	rotator.publishSync(caSecret)
+	if requested := RootRotationPhase(caSecret.Annotations[RootRotationRequestAnnotation]); requested != "" {
+		updated, err := rotator.transition(caSecret, requested, caSecret.Annotations[RootRotationForceAnnotation] == "true")
+		if err != nil {
+			rootCertRotatorLog.Warnf("root rotation to phase %s not done: %v", requested, err)
+		}
+		if updated != nil {
+			caSecret = updated
+		}
+	}
+	rotator.rotation.mu.Lock()
+	rotator.rotation.secret = caSecret
+	rotator.rotation.mu.Unlock()
+	rotator.convergeOnSecret(caSecret)
+}
+
+// transition moves the rotation to the requested phase, if its gates are met or force is set. It returns the updated
+// CA secret, or nil if it was not updated; an invalid request is removed from the secret, and reported as an error.
+func (rotator *SelfSignedCARootCertRotator) transition(caSecret *v1.Secret, to RootRotationPhase, force bool) (*v1.Secret, error) {
+	from, since := rootRotationPhaseOf(caSecret)
+	updated := caSecret.DeepCopy()
+	delete(updated.Annotations, RootRotationRequestAnnotation)
+	delete(updated.Annotations, RootRotationForceAnnotation)
+
+	var transitionErr error
+	switch {
+	case from == to:
+	case from == RootRotationIdle && to == RootRotationDistributing:
+		cert, key, err := rotator.genStagedRoot(caSecret.Data[CACertFile])
+		if err != nil {
+			return nil, err
+		}
+		updated.Data[StagedCACertFile] = cert
+		updated.Data[StagedCAPrivateKeyFile] = key
+	case from == RootRotationDistributing && to == RootRotationIdle:
+		delete(updated.Data, StagedCACertFile)
+		delete(updated.Data, StagedCAPrivateKeyFile)
+	case from == RootRotationDistributing && to == RootRotationSigning, from == RootRotationSigning && to == RootRotationIdle:
+		if blocked := rotator.blocked(from, since); blocked != "" && !force {
+			// The request is kept, and done once the gates are met.
+			return nil, fmt.Errorf("%s", blocked)
+		}
+		if to == RootRotationSigning {
+			updated.Data[RetiringCACertFile] = caSecret.Data[CACertFile]
+			updated.Data[CACertFile] = caSecret.Data[StagedCACertFile]
+			updated.Data[CAPrivateKeyFile] = caSecret.Data[StagedCAPrivateKeyFile]
+			if len(caSecret.Data[RootCertFile]) > 0 {
+				updated.Data[RootCertFile] = caSecret.Data[StagedCACertFile]
+			}
+			delete(updated.Data, StagedCACertFile)
+			delete(updated.Data, StagedCAPrivateKeyFile)
+		} else {
+			delete(updated.Data, RetiringCACertFile)
+		}
+	default:
+		// The request is dropped, so that it is not retried.
+		transitionErr = fmt.Errorf("invalid transition from phase %s to %s", from, to)
+		to = from
+	}
+	if to != from {
+		updated.Data[rootRotationPhaseKey] = []byte(to)
+		updated.Data[rootRotationSinceKey] = []byte(time.Now().UTC().Format(time.RFC3339))
+		if to == RootRotationIdle {
+			delete(updated.Data, rootRotationPhaseKey)
+		}
+	}
+	// The update fails if another Istiod changed the secret, in which case the request is evaluated again.
+	res, err := rotator.config.client.Secrets(updated.Namespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update CA secret: %v", err)
+	}
+	if transitionErr != nil {
+		return res, transitionErr
+	}
+	if to != from {
+		rootCertRotatorLog.Infof("Root rotation moved from phase %s to %s", from, to)
+	}
+	return res, nil
+}
+
+// genStagedRoot generates a root certificate of a new key, with the options of the current one.
+func (rotator *SelfSignedCARootCertRotator) genStagedRoot(currentCert []byte) ([]byte, []byte, error) {
+	oldCertOptions, err := util.GetCertOptionsFromExistingCert(currentCert)
+	if err != nil {
+		rootCertRotatorLog.Warnf("Failed to generate cert options from existing root certificate (%v), "+
+			"new root certificate may not match old root certificate", err)
+	}
+	options := util.MergeCertOptions(util.CertOptions{
+		TTL:          rotator.config.caCertTTL,
+		Org:          rotator.config.org,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   rotator.ca.caRSAKeySize,
+		IsDualUse:    rotator.config.dualUse,
+		CRLSign:      rotator.config.crlSign,
+	}, oldCertOptions)
+	cert, key, err := util.GenCertKeyFromOptions(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate the new root certificate: %v", err)
+	}
+	return cert, key, nil
+}
+
+// localSync returns the state of the proxies of this replica since the given time, as shared with the others. The
+// pods awaiting a certificate on the given other replicas are resolved against the issuances of this replica.
+func (rotator *SelfSignedCARootCertRotator) localSync(since time.Time, others []ReplicaSync) ReplicaSync {
+	local := ProxySync{}
+	if rotator.rotation.ProxySync != nil {
+		local = rotator.rotation.ProxySync(since)
+	}
+	res := ReplicaSync{
+		Replica:             rotator.rotation.Replica,
+		Since:               since,
+		Synced:              local.Synced,
+		Pending:             len(local.Pending),
+		AwaitingCertificate: local.AwaitingCertificate,
+	}
+	awaiting := sets.New[string]()
+	for _, o := range others {
+		awaiting.InsertAll(o.AwaitingCertificate...)
+	}
+	if rotator.rotation.IssuedSince != nil && awaiting.Len() > 0 {
+		for _, pod := range rotator.rotation.IssuedSince(since) {
+			if awaiting.Contains(pod) {
+				res.Issued = append(res.Issued, pod)
+			}
+		}
+		sort.Strings(res.Issued)
+	}
+	return res
+}
+
+// publishSync shares the state of the proxies of this replica, during a rotation.
+func (rotator *SelfSignedCARootCertRotator) publishSync(caSecret *v1.Secret) {
+	phase, since := rootRotationPhaseOf(caSecret)
+	if phase == RootRotationIdle || rotator.rotation.Replicas == nil {
+		return
+	}
+	others, err := rotator.rotation.Replicas.List()
+	if err != nil {
+		rootCertRotatorLog.Warnf("failed to list the trust bundle state of the other replicas: %v", err)
+	}
+	if err := rotator.rotation.Replicas.Publish(rotator.localSync(since, others)); err != nil {
+		rootCertRotatorLog.Warnf("failed to publish the trust bundle state of this replica: %v", err)
+	}
+}
+
+// replicaSyncs returns the state of the proxies of all live replicas, this one first, since the given time.
+func (rotator *SelfSignedCARootCertRotator) replicaSyncs(since time.Time) ([]ReplicaSync, error) {
+	var others []ReplicaSync
+	if rotator.rotation.Replicas != nil {
+		var err error
+		if others, err = rotator.rotation.Replicas.List(); err != nil {
+			return nil, err
+		}
+	}
+	return append([]ReplicaSync{rotator.localSync(since, others)}, others...), nil
+}
+
+// blocked returns why a rotation in phase since the given time can not advance yet, or an empty string.
+func (rotator *SelfSignedCARootCertRotator) blocked(phase RootRotationPhase, since time.Time) string {
+	if phase == RootRotationIdle {
+		return ""
+	}
+	if rotator.rotation.ProxySync == nil {
+		return "the trust bundle state of the proxies is not observed"
+	}
+	replicas, err := rotator.replicaSyncs(since)
+	if err != nil {
+		return fmt.Sprintf("the trust bundle state of the other replicas is unknown: %v", err)
+	}
+	pending := 0
+	awaiting, issued := sets.New[string](), sets.New[string]()
+	for _, r := range replicas {
+		if !r.Since.Equal(since) {
+			return fmt.Sprintf("replica %s has not reported the trust bundle state since %s", r.Replica, since.Format(time.RFC3339))
+		}
+		pending += r.Pending
+		awaiting.InsertAll(r.AwaitingCertificate...)
+		issued.InsertAll(r.Issued...)
+	}
+	if pending > 0 {
+		return fmt.Sprintf("%d proxies have not acknowledged the trust bundle pushed since %s", pending,
+			since.Format(time.RFC3339))
+	}
+	if remaining := awaiting.Difference(issued); remaining.Len() > 0 {
+		return fmt.Sprintf("%d proxies not served the trust bundle with PCDS have not been issued a certificate since %s",
+			remaining.Len(), since.Format(time.RFC3339))
+	}
+	if phase == RootRotationSigning {
+		// Workload certificates are renewed before they expire, so that none signed by the retiring root is valid after
+		// the default TTL. Longer ones must be renewed before forcing the retirement.
+		if retireAt := since.Add(rotator.ca.defaultCertTTL); time.Now().Before(retireAt) {
+			return fmt.Sprintf("certificates signed by the retiring root may be valid until %s", retireAt.Format(time.RFC3339))
+		}
+	}
+	return ""
+}
+
+// convergeOnSecret updates the KeyCertBundle if its signing certificate or roots differ from the CA secret.
+func (rotator *SelfSignedCARootCertRotator) convergeOnSecret(caSecret *v1.Secret) {
+	rootCerts, err := rootCertsFromSecret(caSecret, rotator.config.rootCertFile)
+	if err != nil {
+		rootCertRotatorLog.Errorf("failed to append root certificates: %v", err)
+		return
+	}
+	certInMem, _, _, rootInMem := rotator.ca.GetCAKeyCertBundle().GetAllPem()
+	if bytes.Equal(certInMem, caSecret.Data[CACertFile]) && bytes.Equal(rootInMem, rootCerts) {
+		return
+	}
+	if err := rotator.ca.GetCAKeyCertBundle().VerifyAndSetAll(caSecret.Data[CACertFile], caSecret.Data[CAPrivateKeyFile],
+		nil, rootCerts, nil); err != nil {
+		rootCertRotatorLog.Errorf("failed to reload the roots of the CA secret into KeyCertBundle (%v)", err)
+		return
+	}
+	rootCertRotatorLog.Info("Reloaded the roots of the CA secret into KeyCertBundle.")
+	if rotator.onRootCertUpdate != nil {
+		_ = rotator.onRootCertUpdate()
+	}
+}
+
+func (rotator *SelfSignedCARootCertRotator) rootRotationStatus() (*RootRotationStatus, error) {
+	rotator.rotation.mu.Lock()
+	caSecret := rotator.rotation.secret
+	rotator.rotation.mu.Unlock()
+	if caSecret == nil {
+		var err error
+		caSecret, err = rotator.config.client.Secrets(rotator.config.caStorageNamespace).Get(context.TODO(),
+			rotator.config.secretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	phase, since := rootRotationPhaseOf(caSecret)
+	status := &RootRotationStatus{
+		Secret:       caSecret.Name,
+		Phase:        phase,
+		Since:        since,
+		Requested:    RootRotationPhase(caSecret.Annotations[RootRotationRequestAnnotation]),
+		SigningRoot:  rootInfo(caSecret.Data[CACertFile]),
+		StagedRoot:   rootInfo(caSecret.Data[StagedCACertFile]),
+		RetiringRoot: rootInfo(caSecret.Data[RetiringCACertFile]),
+		Blocked:      rotator.blocked(phase, since),
+	}
+	if rotator.rotation.ProxySync != nil {
+		status.Proxies = rotator.rotation.ProxySync(since)
+	}
+	if phase != RootRotationIdle {
+		if replicas, err := rotator.replicaSyncs(since); err == nil {
+			status.Replicas = replicas
+		}
+	}
+	return status, nil
+}
+
+// rootRotationPhaseOf returns the rotation phase of the CA secret, and the time of the transition to it.
+func rootRotationPhaseOf(caSecret *v1.Secret) (RootRotationPhase, time.Time) {
+	since, _ := time.Parse(time.RFC3339, string(caSecret.Data[rootRotationSinceKey]))
+	if phase := RootRotationPhase(caSecret.Data[rootRotationPhaseKey]); phase != "" {
+		return phase, since
+	}
+	return RootRotationIdle, since
+}
+
+// rootCertsFromSecret returns the roots of the CA secret: its CA certificate, the roots of rootCertFile, and the
+// staged or retiring root of a rotation in progress.
+func rootCertsFromSecret(caSecret *v1.Secret, rootCertFile string) ([]byte, error) {
+	rootCerts, err := util.AppendRootCerts(caSecret.Data[CACertFile], rootCertFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{StagedCACertFile, RetiringCACertFile} {
+		if cert := caSecret.Data[key]; len(cert) > 0 {
+			rootCerts = util.AppendCertByte(rootCerts, cert)
+		}
+	}
+	return rootCerts, nil
+}
+
+func rootInfo(certPEM []byte) *RootInfo {
+	if len(certPEM) == 0 {
+		return nil
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		return nil
+	}
+	return &RootInfo{
+		Subject:     cert.Subject.String(),
+		Fingerprint: util.CertFingerprint(cert),
+		NotAfter:    cert.NotAfter,
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func requestRootRotation(t *testing.T, rotator *SelfSignedCARootCertRotator, phase RootRotationPhase, force bool) {
+	t.Helper()
+	secrets := rotator.config.client.Secrets(rotator.config.caStorageNamespace)
+	caSecret, err := secrets.Get(context.TODO(), rotator.config.secretName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	caSecret.Annotations = map[string]string{RootRotationRequestAnnotation: string(phase)}
+	if force {
+		caSecret.Annotations[RootRotationForceAnnotation] = "true"
+	}
+	_, err = secrets.Update(context.TODO(), caSecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	rotator.checkStagedRotation()
+}
+
+func rootCount(t *testing.T, rotator *SelfSignedCARootCertRotator) int {
+	t.Helper()
+	certs, _, err := util.ParsePemEncodedCertificateChain(rotator.ca.GetCAKeyCertBundle().GetRootCertPem())
+	assert.NoError(t, err)
+	return len(certs)
+}
+
+func TestStagedRootRotation(t *testing.T) {
+	rotator := getRootCertRotator(getDefaultSelfSignedIstioCAOptions(fake.NewClientset()))
+	sync := ProxySync{Pending: []string{"sidecar"}}
+	assert.NoError(t, rotator.ca.EnableStagedRootRotation(StagedRootRotationOptions{
+		CheckInterval: time.Second,
+		ProxySync:     func(time.Time) ProxySync { return sync },
+	}))
+	notified := 0
+	rotator.onRootCertUpdate = func() error {
+		notified++
+		return nil
+	}
+	oldCert, _, _, _ := rotator.ca.GetCAKeyCertBundle().GetAllPem()
+
+	// Distributing: the new root is trusted, the old root signs.
+	requestRootRotation(t, rotator, RootRotationDistributing, false)
+	status, err := rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+	assert.Equal(t, status.Requested, RootRotationPhase(""))
+	assert.Equal(t, status.StagedRoot != nil, true)
+	assert.Equal(t, rootCount(t, rotator), 2)
+	cert, _, _, _ := rotator.ca.GetCAKeyCertBundle().GetAllPem()
+	assert.Equal(t, cert, oldCert)
+	assert.Equal(t, notified, 1)
+
+	// Signing is blocked until the proxies acknowledged the new trust bundle.
+	requestRootRotation(t, rotator, RootRotationSigning, false)
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+	assert.Equal(t, status.Requested, RootRotationSigning)
+	assert.Equal(t, status.Blocked != "", true)
+
+	sync = ProxySync{Synced: 1}
+	rotator.checkStagedRotation()
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationSigning)
+	assert.Equal(t, status.RetiringRoot.Fingerprint, rootInfo(oldCert).Fingerprint)
+	assert.Equal(t, rootCount(t, rotator), 2)
+	cert, _, _, _ = rotator.ca.GetCAKeyCertBundle().GetAllPem()
+	assert.Equal(t, status.SigningRoot.Fingerprint, rootInfo(cert).Fingerprint)
+	assert.Equal(t, cert != nil && string(cert) != string(oldCert), true)
+
+	// Retiring is blocked until the certificates signed by the old root expired, unless forced.
+	requestRootRotation(t, rotator, RootRotationIdle, false)
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationSigning)
+	requestRootRotation(t, rotator, RootRotationIdle, true)
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationIdle)
+	assert.Equal(t, status.RetiringRoot == nil, true)
+	assert.Equal(t, rootCount(t, rotator), 1)
+	assert.Equal(t, notified, 3)
+}
+
+func TestStagedRootRotationAbort(t *testing.T) {
+	rotator := getRootCertRotator(getDefaultSelfSignedIstioCAOptions(fake.NewClientset()))
+	assert.NoError(t, rotator.ca.EnableStagedRootRotation(StagedRootRotationOptions{CheckInterval: time.Second}))
+
+	requestRootRotation(t, rotator, RootRotationDistributing, false)
+	assert.Equal(t, rootCount(t, rotator), 2)
+	// Invalid requests are dropped.
+	requestRootRotation(t, rotator, "Unknown", false)
+	status, err := rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+	assert.Equal(t, status.Requested, RootRotationPhase(""))
+
+	requestRootRotation(t, rotator, RootRotationIdle, false)
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationIdle)
+	assert.Equal(t, status.StagedRoot == nil, true)
+	assert.Equal(t, rootCount(t, rotator), 1)
+}
+
+func TestStagedRootRotationGatedOnAllReplicas(t *testing.T) {
+	client := fake.NewClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istiod-a", Namespace: "istio-system"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "istiod-b", Namespace: "istio-system"}},
+	)
+	rotator := getRootCertRotator(getDefaultSelfSignedIstioCAOptions(client))
+	// Replica a has no proxies; b has a pending sidecar, and a ztunnel which a issued a certificate to.
+	assert.NoError(t, rotator.ca.EnableStagedRootRotation(StagedRootRotationOptions{
+		Replica:       "istiod-a",
+		CheckInterval: time.Second,
+		ProxySync:     func(time.Time) ProxySync { return ProxySync{} },
+		IssuedSince:   func(time.Time) []string { return []string{"istio-system/ztunnel", "default/app"} },
+		Replicas:      NewLeaseReplicaSyncStore(client, "istio-system", "istiod-a", time.Minute),
+	}))
+	replicaB := NewLeaseReplicaSyncStore(client, "istio-system", "istiod-b", time.Minute)
+
+	requestRootRotation(t, rotator, RootRotationDistributing, false)
+	status, err := rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+
+	// b reported before the transition.
+	assert.NoError(t, replicaB.Publish(ReplicaSync{}))
+	requestRootRotation(t, rotator, RootRotationSigning, false)
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+	assert.Equal(t, len(status.Replicas), 2)
+
+	assert.NoError(t, replicaB.Publish(ReplicaSync{Since: status.Since, Pending: 1, AwaitingCertificate: []string{"istio-system/ztunnel"}}))
+	rotator.checkStagedRotation()
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationDistributing)
+	assert.Equal(t, status.Blocked, fmt.Sprintf("1 proxies have not acknowledged the trust bundle pushed since %s",
+		status.Since.Format(time.RFC3339)))
+
+	// The ztunnel awaiting a certificate on b was issued one by a.
+	assert.NoError(t, replicaB.Publish(ReplicaSync{Since: status.Since, AwaitingCertificate: []string{"istio-system/ztunnel"}}))
+	rotator.checkStagedRotation()
+	status, err = rotator.ca.RootRotationStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, status.Phase, RootRotationSigning)
+}
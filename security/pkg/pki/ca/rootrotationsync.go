@@ -0,0 +1,150 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/pkg/ptr"
+)
+
+const (
+	// RootRotationSyncLabel marks the Leases the Istiod replicas report their trust bundle state with.
+	RootRotationSyncLabel = "ca.istio.io/root-rotation-sync"
+	// RootRotationSyncAnnotation holds the JSON encoded ReplicaSync of the replica holding a Lease.
+	RootRotationSyncAnnotation = "ca.istio.io/root-rotation-sync"
+
+	rootRotationSyncLeasePrefix = "istiod-root-rotation-"
+)
+
+// LeaseReplicaSyncStore shares the trust bundle state of the Istiod replicas with a Lease per replica. A replica whose
+// Lease was not renewed within its duration is not live, and its state is ignored.
+type LeaseReplicaSyncStore struct {
+	client    kubernetes.Interface
+	namespace string
+	podName   string
+	duration  time.Duration
+
+	owner []metav1.OwnerReference
+	now   func() time.Time
+}
+
+var _ ReplicaSyncStore = &LeaseReplicaSyncStore{}
+
+// NewLeaseReplicaSyncStore returns a store for the replica running in pod podName of namespace. Each publication
+// renews the Lease for duration, which should be a few times the interval of the publications.
+func NewLeaseReplicaSyncStore(client kubernetes.Interface, namespace, podName string, duration time.Duration) *LeaseReplicaSyncStore {
+	return &LeaseReplicaSyncStore{
+		client:    client,
+		namespace: namespace,
+		podName:   podName,
+		duration:  duration,
+		now:       time.Now,
+	}
+}
+
+func (s *LeaseReplicaSyncStore) leaseName() string {
+	return rootRotationSyncLeasePrefix + s.podName
+}
+
+// Publish creates or renews the Lease of this replica with its state.
+func (s *LeaseReplicaSyncStore) Publish(sync ReplicaSync) error {
+	sync.Replica = s.podName
+	b, err := json.Marshal(sync)
+	if err != nil {
+		return err
+	}
+	ctx := context.TODO()
+	if s.owner == nil {
+		// Tie the Lease to the pod, so it is garbage collected along with the replica.
+		pod, err := s.client.CoreV1().Pods(s.namespace).Get(ctx, s.podName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get pod %s/%s: %v", s.namespace, s.podName, err)
+		}
+		s.owner = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: pod.Name, UID: pod.UID}}
+	}
+	now := metav1.NewMicroTime(s.now())
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	cur, err := leases.Get(ctx, s.leaseName(), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            s.leaseName(),
+				Namespace:       s.namespace,
+				Labels:          map[string]string{RootRotationSyncLabel: "true"},
+				Annotations:     map[string]string{RootRotationSyncAnnotation: string(b)},
+				OwnerReferences: s.owner,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.Of(s.podName),
+				LeaseDurationSeconds: ptr.Of(int32(s.duration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	l := cur.DeepCopy()
+	if l.Annotations == nil {
+		l.Annotations = map[string]string{}
+	}
+	l.Annotations[RootRotationSyncAnnotation] = string(b)
+	l.Spec.LeaseDurationSeconds = ptr.Of(int32(s.duration.Seconds()))
+	l.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, l, metav1.UpdateOptions{})
+	return err
+}
+
+// List returns the state of the other replicas whose Lease is live.
+func (s *LeaseReplicaSyncStore) List() ([]ReplicaSync, error) {
+	leases, err := s.client.CoordinationV1().Leases(s.namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: klabels.SelectorFromSet(map[string]string{RootRotationSyncLabel: "true"}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	var res []ReplicaSync
+	for _, l := range leases.Items {
+		if l.Name == s.leaseName() || l.Spec.RenewTime == nil {
+			continue
+		}
+		duration := s.duration
+		if l.Spec.LeaseDurationSeconds != nil {
+			duration = time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second
+		}
+		if l.Spec.RenewTime.Add(duration).Before(now) {
+			continue
+		}
+		sync := ReplicaSync{}
+		if err := json.Unmarshal([]byte(l.Annotations[RootRotationSyncAnnotation]), &sync); err != nil {
+			return nil, fmt.Errorf("failed to decode the trust bundle state of lease %s: %v", l.Name, err)
+		}
+		res = append(res, sync)
+	}
+	return res, nil
+}
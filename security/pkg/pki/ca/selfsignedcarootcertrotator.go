@@ -44,6 +44,8 @@ type SelfSignedCARootCertRotatorConfig struct {
 	retryMax           time.Duration
 	dualUse            bool
 	enableJitter       bool
+	// crlSign allows the rotated root certificate to sign CRLs.
+	crlSign bool
 }
 
 // SelfSignedCARootCertRotator automatically checks self-signed signing root
@@ -54,6 +56,8 @@ type SelfSignedCARootCertRotator struct {
 	backOffTime        time.Duration
 	ca                 *IstioCA
 	onRootCertUpdate   func() error
+	// rotation is the staged root rotation, if enabled.
+	rotation *rootRotation
 }
 
 // NewSelfSignedCARootCertRotator returns a new root cert rotator instance that
@@ -150,7 +154,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 		if !bytes.Equal(caCertInMem, caSecret.Data[CACertFile]) {
 			rootCertRotatorLog.Warnf("CA cert in KeyCertBundle does not match CA cert in "+
 				"%s. Start to reload root cert into KeyCertBundle", rotator.config.secretName)
-			rootCerts, err := util.AppendRootCerts(caSecret.Data[CACertFile], rotator.config.rootCertFile)
+			rootCerts, err := rootCertsFromSecret(caSecret, rotator.config.rootCertFile)
 			if err != nil {
 				rootCertRotatorLog.Errorf("failed to append root certificates from file: %s", err.Error())
 				return
@@ -174,6 +178,12 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 		return
 	}
 
+	if phase, _ := rootRotationPhaseOf(caSecret); phase != RootRotationIdle {
+		rootCertRotatorLog.Warnf("Root cert is about to expire, but a staged root rotation is in phase %s, "+
+			"skipping root cert rotation.", phase)
+		return
+	}
+
 	rootCertRotatorLog.Infof("Refresh root certificate, root cert is about to expire: %s", err.Error())
 
 	oldCertOptions, err := util.GetCertOptionsFromExistingCert(caSecret.Data[CACertFile])
@@ -189,6 +199,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 		IsSelfSigned:  true,
 		RSAKeySize:    rotator.ca.caRSAKeySize,
 		IsDualUse:     rotator.config.dualUse,
+		CRLSign:       rotator.config.crlSign,
 	}
 	// options should be consistent with the one used in NewSelfSignedIstioCAOptions().
 	// This is to make sure when rotate the root cert, we don't make unnecessary changes
@@ -92,6 +92,9 @@ func (r *KubernetesRA) Sign(csrPEM []byte, certOpts ca.CertOpts) ([]byte, error)
 	if err != nil {
 		return nil, err
 	}
+	if len(certOpts.Extensions) > 0 {
+		return nil, raerror.NewError(raerror.CSRError, fmt.Errorf("extensions can not be added to certificates signed by Kubernetes"))
+	}
 	certSigner := certOpts.CertSigner
 
 	return r.kubernetesSign(csrPEM, r.raOpts.CaCertFile, certSigner, certOpts.TTL)
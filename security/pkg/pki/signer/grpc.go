@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"istio.io/istio/pkg/signerapi"
+)
+
+// hashes maps the hash functions supported by the remote signer to their protocol value.
+var hashes = map[crypto.Hash]signerapi.Hash{
+	crypto.SHA256: signerapi.Hash_SHA256,
+	crypto.SHA384: signerapi.Hash_SHA384,
+	crypto.SHA512: signerapi.Hash_SHA512,
+}
+
+type grpcProvider struct {
+	conn    *grpc.ClientConn
+	client  signerapi.RemoteSignerClient
+	keyName string
+	timeout time.Duration
+}
+
+var _ Provider = &grpcProvider{}
+
+// NewGRPC returns a provider signing with the key named keyName of the remote signer at address, which implements
+// the istio.security.signer.v1.RemoteSigner service. Each signature must be returned within timeout.
+func NewGRPC(address, keyName string, creds credentials.TransportCredentials, timeout time.Duration) (Provider, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcProvider{conn: conn, client: signerapi.NewRemoteSignerClient(conn), keyName: keyName, timeout: timeout}, nil
+}
+
+func (p *grpcProvider) Signer(cert *x509.Certificate) (crypto.Signer, error) {
+	s := &grpcSigner{
+		provider: p,
+		keyID:    cert.SubjectKeyId,
+		pub:      cert.PublicKey,
+	}
+	if err := Check(s, cert.PublicKey); err != nil {
+		return nil, fmt.Errorf("key %q of the remote signer does not match the certificate %v: %v",
+			p.keyName, cert.Subject, err)
+	}
+	return s, nil
+}
+
+func (p *grpcProvider) Close() error {
+	return p.conn.Close()
+}
+
+type grpcSigner struct {
+	provider *grpcProvider
+	keyID    []byte
+	pub      crypto.PublicKey
+}
+
+func (s *grpcSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *grpcSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, fmt.Errorf("RSA-PSS signatures are not supported")
+	}
+	hash, ok := hashes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash function %v", opts.HashFunc())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.provider.timeout)
+	defer cancel()
+	resp, err := s.provider.client.Sign(ctx, &signerapi.SignRequest{
+		KeyName: s.provider.keyName,
+		KeyId:   s.keyID,
+		Hash:    hash,
+		Digest:  digest,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.GetSignature()) == 0 {
+		return nil, fmt.Errorf("the remote signer returned no signature")
+	}
+	return resp.GetSignature(), nil
+}
@@ -0,0 +1,271 @@
+//go:build cgo
+
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// digestInfoPrefixes are the DER encoded DigestInfo prefixes of PKCS#1 v1.5 signatures, which CKM_RSA_PKCS expects
+// in front of the digest.
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// pkcs11Supported is true as this build can load PKCS#11 modules.
+const pkcs11Supported = true
+
+type pkcs11Provider struct {
+	ctx   *pkcs11.Ctx
+	token string
+	pin   string
+	label string
+
+	// mu serializes the operations on the session, which PKCS#11 does not allow concurrently.
+	mu      sync.Mutex
+	session pkcs11.SessionHandle
+}
+
+var _ Provider = &pkcs11Provider{}
+
+// NewPKCS11 returns a provider signing with the private keys of the token labeled token, loaded with the PKCS#11
+// module library at module path. If label is set, only the private keys with that label are used.
+func NewPKCS11(module, token, pin, label string) (Provider, error) {
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load the PKCS#11 module %s", module)
+	}
+	initErr := ctx.Initialize()
+	if initErr != nil && !isError(initErr, pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize the PKCS#11 module %s: %v", module, initErr)
+	}
+	p := &pkcs11Provider{ctx: ctx, token: token, pin: pin, label: label}
+	if err := p.openSession(); err != nil {
+		// The module is shared by the process, it is only finalized if it was initialized here.
+		if initErr == nil {
+			_ = ctx.Finalize()
+		}
+		ctx.Destroy()
+		return nil, err
+	}
+	return p, nil
+}
+
+// openSession opens and logs in a session on the token. It is called with mu held, or before the provider is used.
+func (p *pkcs11Provider) openSession() error {
+	slots, err := p.ctx.GetSlotList(true)
+	if err != nil {
+		return fmt.Errorf("failed to list the PKCS#11 slots: %v", err)
+	}
+	for _, slot := range slots {
+		info, err := p.ctx.GetTokenInfo(slot)
+		if err != nil || strings.TrimSpace(info.Label) != p.token {
+			continue
+		}
+		session, err := p.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return fmt.Errorf("failed to open a session on the PKCS#11 token %q: %v", p.token, err)
+		}
+		if err := p.ctx.Login(session, pkcs11.CKU_USER, p.pin); err != nil && !isError(err, pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			_ = p.ctx.CloseSession(session)
+			return fmt.Errorf("failed to log in the PKCS#11 token %q: %v", p.token, err)
+		}
+		p.session = session
+		return nil
+	}
+	return fmt.Errorf("PKCS#11 token %q not found", p.token)
+}
+
+// findKeys returns the private keys of the given type, with the given id if it is set.
+func (p *pkcs11Provider) findKeys(keyType uint, id []byte) ([]pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, keyType),
+	}
+	if p.label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, p.label))
+	}
+	if id != nil {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+	}
+	if err := p.ctx.FindObjectsInit(p.session, template); err != nil {
+		return nil, err
+	}
+	defer func() { _ = p.ctx.FindObjectsFinal(p.session) }()
+	var out []pkcs11.ObjectHandle
+	for {
+		objs, _, err := p.ctx.FindObjects(p.session, 16)
+		if err != nil {
+			return nil, err
+		}
+		if len(objs) == 0 {
+			return out, nil
+		}
+		out = append(out, objs...)
+	}
+}
+
+func (p *pkcs11Provider) Signer(cert *x509.Certificate) (crypto.Signer, error) {
+	var keyType uint
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		keyType = pkcs11.CKK_RSA
+	case *ecdsa.PublicKey:
+		keyType = pkcs11.CKK_EC
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+	}
+
+	p.mu.Lock()
+	keys, err := p.findKeys(keyType, nil)
+	var candidates []*pkcs11Signer
+	for _, key := range keys {
+		attrs, err := p.ctx.GetAttributeValue(p.session, key, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_ID, nil)})
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, &pkcs11Signer{provider: p, keyType: keyType, id: attrs[0].Value, key: key, pub: cert.PublicKey})
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the private keys of the PKCS#11 token %q: %v", p.token, err)
+	}
+
+	// The public key objects are optional, so the key is matched by checking it signs for the certificate.
+	for _, s := range candidates {
+		if Check(s, cert.PublicKey) == nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no private key of the PKCS#11 token %q matches the certificate %v", p.token, cert.Subject)
+}
+
+func (p *pkcs11Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_ = p.ctx.Logout(p.session)
+	_ = p.ctx.CloseSession(p.session)
+	err := p.ctx.Finalize()
+	p.ctx.Destroy()
+	return err
+}
+
+type pkcs11Signer struct {
+	provider *pkcs11Provider
+	keyType  uint
+	// id is the CKA_ID of the key, to find it again if the session is lost.
+	id  []byte
+	key pkcs11.ObjectHandle
+	pub crypto.PublicKey
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism uint
+	data := digest
+	switch s.keyType {
+	case pkcs11.CKK_RSA:
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			return nil, fmt.Errorf("RSA-PSS signatures are not supported")
+		}
+		prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash function %v", opts.HashFunc())
+		}
+		mechanism = pkcs11.CKM_RSA_PKCS
+		data = append(append([]byte{}, prefix...), digest...)
+	case pkcs11.CKK_EC:
+		mechanism = pkcs11.CKM_ECDSA
+	}
+
+	p := s.provider
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sig, err := s.sign(mechanism, data)
+	if err != nil && sessionLost(err) {
+		// The token may have been reset or reinserted: log in again, and look up the key, whose handle may have
+		// changed, by its id.
+		signerLog.Warnf("PKCS#11 session lost, reopening it: %v", err)
+		_ = p.ctx.CloseSession(p.session)
+		if err := p.openSession(); err != nil {
+			return nil, err
+		}
+		keys, findErr := p.findKeys(s.keyType, s.id)
+		if findErr != nil || len(keys) == 0 {
+			return nil, fmt.Errorf("failed to find the private key in the PKCS#11 token %q: %v", p.token, findErr)
+		}
+		s.key = keys[0]
+		sig, err = s.sign(mechanism, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.keyType == pkcs11.CKK_EC {
+		// PKCS#11 returns the raw r || s values, while x509 expects an ASN.1 ECDSA-Sig-Value.
+		half := len(sig) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(sig[:half]),
+			S: new(big.Int).SetBytes(sig[half:]),
+		})
+	}
+	return sig, nil
+}
+
+// sign is called with the provider mutex held.
+func (s *pkcs11Signer) sign(mechanism uint, data []byte) ([]byte, error) {
+	p := s.provider
+	if err := p.ctx.SignInit(p.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, s.key); err != nil {
+		return nil, err
+	}
+	return p.ctx.Sign(p.session, data)
+}
+
+func isError(err error, code uint) bool {
+	var e pkcs11.Error
+	return errors.As(err, &e) && uint(e) == code
+}
+
+func sessionLost(err error) bool {
+	for _, code := range []uint{
+		pkcs11.CKR_SESSION_HANDLE_INVALID, pkcs11.CKR_SESSION_CLOSED, pkcs11.CKR_USER_NOT_LOGGED_IN,
+		pkcs11.CKR_KEY_HANDLE_INVALID, pkcs11.CKR_OBJECT_HANDLE_INVALID, pkcs11.CKR_DEVICE_REMOVED,
+		pkcs11.CKR_TOKEN_NOT_PRESENT,
+	} {
+		if isError(err, code) {
+			return true
+		}
+	}
+	return false
+}
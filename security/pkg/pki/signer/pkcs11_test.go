@@ -0,0 +1,170 @@
+//go:build cgo
+
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const (
+	testToken = "istio-ca"
+	testPin   = "1234"
+)
+
+// softHSMModule returns the SoftHSM module, from the SOFTHSM2_MODULE environment variable or its usual install
+// locations. The tests are skipped if it is not installed.
+func softHSMModule(t *testing.T) string {
+	candidates := []string{
+		os.Getenv("SOFTHSM2_MODULE"),
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+		"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+	}
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	t.Skip("SoftHSM is not installed")
+	return ""
+}
+
+// initToken initializes a SoftHSM token in a temporary directory, and generates an ECDSA and an RSA key pair in it.
+func initToken(t *testing.T, module string) (ecPub *ecdsa.PublicKey, rsaPub *rsa.PublicKey) {
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "tokens"), 0o700))
+	assert.NoError(t, os.WriteFile(conf, []byte("directories.tokendir = "+filepath.Join(dir, "tokens")+"\n"), 0o600))
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(module)
+	assert.NoError(t, ctx.Initialize())
+	defer func() {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+	}()
+	slots, err := ctx.GetSlotList(false)
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.InitToken(slots[0], "so-pin", testToken))
+	// SoftHSM moves the initialized token to a new slot.
+	slots, err = ctx.GetSlotList(true)
+	assert.NoError(t, err)
+	session, err := ctx.OpenSession(slots[0], pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.Login(session, pkcs11.CKU_SO, "so-pin"))
+	assert.NoError(t, ctx.InitPIN(session, testPin))
+	assert.NoError(t, ctx.Logout(session))
+	assert.NoError(t, ctx.Login(session, pkcs11.CKU_USER, testPin))
+
+	keyAttrs := func(id string) ([]*pkcs11.Attribute, []*pkcs11.Attribute) {
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(id)),
+		}, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "istio-ca-key"),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte(id)),
+		}
+	}
+
+	p256, _ := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+	pubAttrs, privAttrs := keyAttrs("ec")
+	pubAttrs = append(pubAttrs, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, p256))
+	pub, _, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		pubAttrs, privAttrs)
+	assert.NoError(t, err)
+	attrs, err := ctx.GetAttributeValue(session, pub, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	assert.NoError(t, err)
+	var point []byte
+	_, err = asn1.Unmarshal(attrs[0].Value, &point)
+	assert.NoError(t, err)
+	x, y := elliptic.Unmarshal(elliptic.P256(), point) // nolint: staticcheck
+	ecPub = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+	pubAttrs, privAttrs = keyAttrs("rsa")
+	pubAttrs = append(pubAttrs,
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}))
+	pub, _, err = ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		pubAttrs, privAttrs)
+	assert.NoError(t, err)
+	attrs, err = ctx.GetAttributeValue(session, pub, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	assert.NoError(t, err)
+	rsaPub = &rsa.PublicKey{
+		N: new(big.Int).SetBytes(attrs[0].Value),
+		E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+	}
+	return ecPub, rsaPub
+}
+
+func TestPKCS11(t *testing.T) {
+	module := softHSMModule(t)
+	ecPub, rsaPub := initToken(t, module)
+
+	p, err := NewPKCS11(module, testToken, testPin, "istio-ca-key")
+	assert.NoError(t, err)
+	defer p.Close()
+
+	for name, pub := range map[string]crypto.PublicKey{"ec": ecPub, "rsa": rsaPub} {
+		t.Run(name, func(t *testing.T) {
+			// The provider only looks at the public key, the CA certificate is then self-signed by the token.
+			s, err := p.Signer(&x509.Certificate{PublicKey: pub})
+			assert.NoError(t, err)
+			cert := caCert(t, s)
+			s, err = p.Signer(cert)
+			assert.NoError(t, err)
+			assert.NoError(t, signCert(t, cert, s))
+		})
+	}
+
+	t.Run("mismatched key", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		_, err = p.Signer(caCert(t, key))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		_, err := NewPKCS11(module, "missing", testPin, "")
+		assert.Error(t, err)
+	})
+}
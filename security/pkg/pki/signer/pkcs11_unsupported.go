@@ -0,0 +1,27 @@
+//go:build !cgo
+
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import "fmt"
+
+// pkcs11Supported is false without cgo, which is required to load PKCS#11 modules.
+const pkcs11Supported = false
+
+// NewPKCS11 is not supported without cgo, which is required to load PKCS#11 modules.
+func NewPKCS11(_, _, _, _ string) (Provider, error) {
+	return nil, fmt.Errorf("PKCS#11 signers are not supported: Istiod was built without cgo (CGO_ENABLED=0)")
+}
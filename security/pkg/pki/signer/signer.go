@@ -0,0 +1,240 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer provides signers for the Istio CA whose private key never leaves an external system: a PKCS#11
+// token, such as an HSM, or a remote signing service reached over gRPC, such as a KMS proxy.
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/monitoring"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// TypePKCS11 signs with a private key held in a PKCS#11 token.
+	TypePKCS11 = "pkcs11"
+	// TypeGRPC signs with a private key held by a remote signer implementing the RemoteSigner service of pkg/signerapi.
+	TypeGRPC = "grpc"
+
+	grpcTimeout = 5 * time.Second
+)
+
+var (
+	signerLog = log.RegisterScope("casigner", "Istio CA external signer")
+
+	signerHealthy = monitoring.NewGauge(
+		"citadel_server_external_signer_healthy",
+		"Whether the external signer holding the CA private key is able to sign (1) or not (0).",
+	)
+)
+
+// Provider holds the private keys of CA certificates.
+type Provider interface {
+	// Signer returns a signer for the private key of cert, or an error if the provider does not hold that key.
+	// The signer stays bound to that key, so a new signer is requested when the CA certificate is rotated.
+	Signer(cert *x509.Certificate) (crypto.Signer, error)
+	// Close releases the connection to the external system.
+	Close() error
+}
+
+// Options configures the external signer of the Istio CA.
+type Options struct {
+	// Type of the external signer, one of TypePKCS11 or TypeGRPC. If empty, the CA private key is loaded from the
+	// cacerts secret.
+	Type string
+	// KeyLabel is the label of the private key object in the PKCS#11 token, or the name of the key in the remote
+	// signer. In a PKCS#11 token, any private key matching the CA certificate is used if it is empty.
+	KeyLabel string
+
+	PKCS11Module  string
+	PKCS11Token   string
+	PKCS11PinFile string
+
+	GRPCAddress      string
+	GRPCRootCertFile string
+	GRPCInsecure     bool
+
+	// HealthCheckInterval is how often the signer is checked to still hold the CA private key.
+	HealthCheckInterval time.Duration
+}
+
+// DefaultOptions returns the default external signer options, with no external signer.
+func DefaultOptions() *Options {
+	return &Options{
+		HealthCheckInterval: 30 * time.Second,
+	}
+}
+
+// AttachCobraFlags attaches a set of Cobra flags to the given Cobra command.
+func (o *Options) AttachCobraFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.Type, "caSigner", o.Type,
+		"If set, the Istio CA signs with a private key held outside of Istiod, and the cacerts secret only holds "+
+			"the certificates. One of pkcs11|grpc. pkcs11 is not available in the official Istio release builds, "+
+			"which are built without cgo")
+	cmd.PersistentFlags().StringVar(&o.KeyLabel, "caSignerKey", o.KeyLabel,
+		"The label of the CA private key in the PKCS#11 token, or the name of the key in the remote signer")
+	cmd.PersistentFlags().StringVar(&o.PKCS11Module, "caSignerPKCS11Module", o.PKCS11Module,
+		"The path of the PKCS#11 module library")
+	cmd.PersistentFlags().StringVar(&o.PKCS11Token, "caSignerPKCS11Token", o.PKCS11Token,
+		"The label of the PKCS#11 token holding the CA private key")
+	cmd.PersistentFlags().StringVar(&o.PKCS11PinFile, "caSignerPKCS11PinFile", o.PKCS11PinFile,
+		"The path of a file containing the user PIN of the PKCS#11 token")
+	cmd.PersistentFlags().StringVar(&o.GRPCAddress, "caSignerGRPCAddress", o.GRPCAddress,
+		"The address of the remote signer")
+	cmd.PersistentFlags().StringVar(&o.GRPCRootCertFile, "caSignerGRPCRootCertFile", o.GRPCRootCertFile,
+		"The root certificates used to verify the remote signer. If empty, the system roots are used")
+	cmd.PersistentFlags().BoolVar(&o.GRPCInsecure, "caSignerGRPCInsecure", o.GRPCInsecure,
+		"If enabled, the connection to the remote signer is not encrypted")
+	cmd.PersistentFlags().DurationVar(&o.HealthCheckInterval, "caSignerHealthCheckInterval", o.HealthCheckInterval,
+		"How often the external signer is checked to still hold the CA private key")
+}
+
+// Validate checks that the external signer options are complete, and that the signer type is supported by this
+// build of Istiod.
+func (o *Options) Validate() error {
+	switch o.Type {
+	case "":
+		return nil
+	case TypePKCS11:
+		if !pkcs11Supported {
+			return fmt.Errorf("the %s CA signer requires an Istiod built with cgo (CGO_ENABLED=1), as PKCS#11 modules "+
+				"are native libraries; the official Istio release builds are not", TypePKCS11)
+		}
+		if o.PKCS11Module == "" || o.PKCS11Token == "" {
+			return fmt.Errorf("the PKCS#11 module and token must be set")
+		}
+	case TypeGRPC:
+		if o.GRPCAddress == "" || o.KeyLabel == "" {
+			return fmt.Errorf("the remote signer address and key name must be set")
+		}
+	default:
+		return fmt.Errorf("unknown CA signer type %q", o.Type)
+	}
+	return nil
+}
+
+// New returns the external signer configured by opts, or nil if none is configured.
+func New(opts *Options) (Provider, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	switch opts.Type {
+	case TypePKCS11:
+		pin := ""
+		if opts.PKCS11PinFile != "" {
+			b, err := os.ReadFile(opts.PKCS11PinFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the PKCS#11 PIN: %v", err)
+			}
+			pin = strings.TrimSpace(string(b))
+		}
+		return NewPKCS11(opts.PKCS11Module, opts.PKCS11Token, pin, opts.KeyLabel)
+	case TypeGRPC:
+		var creds credentials.TransportCredentials
+		switch {
+		case opts.GRPCInsecure:
+			creds = insecure.NewCredentials()
+		case opts.GRPCRootCertFile != "":
+			c, err := credentials.NewClientTLSFromFile(opts.GRPCRootCertFile, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the remote signer root certificates: %v", err)
+			}
+			creds = c
+		default:
+			creds = credentials.NewClientTLSFromCert(nil, "")
+		}
+		return NewGRPC(opts.GRPCAddress, opts.KeyLabel, creds, grpcTimeout)
+	default:
+		return nil, nil
+	}
+}
+
+// Check verifies that s holds the private key of pub, by signing a probe and verifying the signature.
+func Check(s crypto.Signer, pub crypto.PublicKey) error {
+	digest := sha256.Sum256([]byte("istio-ca-signer-check " + time.Now().String()))
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign: %v", err)
+	}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("the signature does not match the public key: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return fmt.Errorf("the signature does not match the public key")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// Monitor checks every interval that the signer of the key cert bundle still holds the private key of its
+// certificate, until stop is closed. The result is stored in healthy, to be reported by the readiness of Istiod, and
+// in the citadel_server_external_signer_healthy metric.
+func Monitor(stop <-chan struct{}, interval time.Duration, bundle *util.KeyCertBundle, healthy *atomic.Bool) {
+	check := func() {
+		cert, key, _, _ := bundle.GetAll()
+		if cert == nil || key == nil {
+			return
+		}
+		s, ok := (*key).(crypto.Signer)
+		if !ok {
+			return
+		}
+		err := Check(s, cert.PublicKey)
+		switch {
+		case err != nil:
+			signerLog.Errorf("the CA external signer is unhealthy, Istiod is not ready: %v", err)
+		case !healthy.Load():
+			signerLog.Infof("the CA external signer is healthy again")
+		}
+		healthy.Store(err == nil)
+		if err == nil {
+			signerHealthy.Record(1)
+		} else {
+			signerHealthy.Record(0)
+		}
+	}
+	check()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
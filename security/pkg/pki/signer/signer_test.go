@@ -0,0 +1,196 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"istio.io/istio/pkg/signerapi"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// caCert returns a CA certificate for the key.
+func caCert(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"cluster.local"}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+// signCert signs a workload certificate with the CA, to check the signer can be used by the Istio CA.
+func signCert(t *testing.T, ca *x509.Certificate, s crypto.Signer) error {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), s)
+	if err != nil {
+		return err
+	}
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert.CheckSignatureFrom(ca)
+}
+
+// fakeRemoteSigner implements the RemoteSigner service with the given keys, by name.
+type fakeRemoteSigner struct {
+	signerapi.UnimplementedRemoteSignerServer
+	keys map[string]crypto.Signer
+}
+
+func (f *fakeRemoteSigner) Sign(_ context.Context, req *signerapi.SignRequest) (*signerapi.SignResponse, error) {
+	key, ok := f.keys[req.KeyName]
+	if !ok {
+		return nil, fmt.Errorf("unknown key")
+	}
+	if req.Hash != signerapi.Hash_SHA256 {
+		return nil, fmt.Errorf("unexpected hash %v", req.Hash)
+	}
+	sig, err := key.Sign(rand.Reader, req.Digest, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &signerapi.SignResponse{Signature: sig}, nil
+}
+
+// startRemoteSigner serves the RemoteSigner service with the given keys, and returns its address.
+func startRemoteSigner(t *testing.T, keys map[string]crypto.Signer) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	srv := grpc.NewServer()
+	signerapi.RegisterRemoteSignerServer(srv, &fakeRemoteSigner{keys: keys})
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(srv.Stop)
+	return l.Addr().String()
+}
+
+func TestGRPC(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	addr := startRemoteSigner(t, map[string]crypto.Signer{"ec": ecKey, "rsa": rsaKey})
+
+	for name, key := range map[string]crypto.Signer{"ec": ecKey, "rsa": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewGRPC(addr, name, insecure.NewCredentials(), time.Second)
+			assert.NoError(t, err)
+			defer p.Close()
+			cert := caCert(t, key)
+			s, err := p.Signer(cert)
+			assert.NoError(t, err)
+			assert.NoError(t, Check(s, cert.PublicKey))
+			assert.NoError(t, signCert(t, cert, s))
+		})
+	}
+
+	t.Run("mismatched key", func(t *testing.T) {
+		p, err := NewGRPC(addr, "ec", insecure.NewCredentials(), time.Second)
+		assert.NoError(t, err)
+		defer p.Close()
+		_, err = p.Signer(caCert(t, rsaKey))
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		p, err := NewGRPC(addr, "missing", insecure.NewCredentials(), time.Second)
+		assert.NoError(t, err)
+		defer p.Close()
+		_, err = p.Signer(caCert(t, ecKey))
+		assert.Error(t, err)
+	})
+}
+
+func TestNew(t *testing.T) {
+	p, err := New(DefaultOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, p, nil)
+
+	for _, opts := range []*Options{
+		{Type: "kms"},
+		{Type: TypePKCS11, PKCS11Token: "istio"},
+		{Type: TypeGRPC, GRPCAddress: "localhost:1234"},
+	} {
+		_, err := New(opts)
+		assert.Error(t, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultOptions().Validate())
+	assert.NoError(t, (&Options{Type: TypeGRPC, GRPCAddress: "localhost:1234", KeyLabel: "istio"}).Validate())
+	assert.Error(t, (&Options{Type: "kms"}).Validate())
+
+	// PKCS#11 modules can only be loaded by builds with cgo.
+	err := (&Options{Type: TypePKCS11, PKCS11Module: "/usr/lib/softhsm/libsofthsm2.so", PKCS11Token: "istio"}).Validate()
+	assert.Equal(t, err == nil, pkcs11Supported)
+}
+
+func TestMonitor(t *testing.T) {
+	genCA := func() ([]byte, []byte) {
+		t.Helper()
+		cert, key, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Org: "cluster.local", TTL: time.Hour, IsCA: true, IsSelfSigned: true, ECSigAlg: util.EcdsaSigAlg,
+		})
+		assert.NoError(t, err)
+		return cert, key
+	}
+	cert, key := genCA()
+	_, otherKey := genCA()
+
+	// Monitor checks once before returning on the closed stop channel.
+	stop := make(chan struct{})
+	close(stop)
+	healthy := &atomic.Bool{}
+	Monitor(stop, time.Hour, util.NewKeyCertBundleFromPem(cert, key, nil, cert, nil), healthy)
+	assert.Equal(t, healthy.Load(), true)
+	Monitor(stop, time.Hour, util.NewKeyCertBundleFromPem(cert, otherKey, nil, cert, nil), healthy)
+	assert.Equal(t, healthy.Load(), false)
+}
@@ -20,7 +20,9 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"reflect"
@@ -176,6 +178,16 @@ func GetEllipticCurve(privKey *crypto.PrivateKey) (elliptic.Curve, error) {
 			return key.Curve, nil
 		}
 		return elliptic.P256(), nil
+	case crypto.Signer:
+		// The private key is held by an external signer.
+		pub, ok := key.Public().(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not ECDSA based")
+		}
+		if pub.Curve == elliptic.P384() {
+			return pub.Curve, nil
+		}
+		return elliptic.P256(), nil
 	default:
 		return nil, fmt.Errorf("private key is not ECDSA based")
 	}
@@ -200,3 +212,9 @@ func PemCertBytestoString(caCerts []byte) []string {
 	}
 	return certs
 }
+
+// CertFingerprint returns the hex encoded SHA-256 digest of the DER encoding of the certificate.
+func CertFingerprint(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(digest[:])
+}
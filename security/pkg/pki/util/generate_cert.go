@@ -86,6 +86,9 @@ type CertOptions struct {
 	// Whether this certificate is used as signing cert for CA.
 	IsCA bool
 
+	// Whether the private key of a CA certificate is allowed to sign CRLs.
+	CRLSign bool
+
 	// Whether this certificate is self-signed.
 	IsSelfSigned bool
 
@@ -244,11 +247,19 @@ func MergeCertOptions(defaultOpts, deltaOpts CertOptions) CertOptions {
 // GenCertFromCSR generates a X.509 certificate with the given CSR.
 func GenCertFromCSR(csr *x509.CertificateRequest, signingCert *x509.Certificate, publicKey any,
 	signingKey crypto.PrivateKey, subjectIDs []string, ttl time.Duration, isCA bool,
+) (cert []byte, err error) {
+	return GenCertFromCSRWithExtensions(csr, signingCert, publicKey, signingKey, subjectIDs, ttl, isCA, nil)
+}
+
+// GenCertFromCSRWithExtensions is similar to GenCertFromCSR, and adds the given extensions to the certificate.
+func GenCertFromCSRWithExtensions(csr *x509.CertificateRequest, signingCert *x509.Certificate, publicKey any,
+	signingKey crypto.PrivateKey, subjectIDs []string, ttl time.Duration, isCA bool, extensions []pkix.Extension,
 ) (cert []byte, err error) {
 	tmpl, err := genCertTemplateFromCSR(csr, subjectIDs, ttl, isCA, signingCert)
 	if err != nil {
 		return nil, err
 	}
+	tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, extensions...)
 	return x509.CreateCertificate(rand.Reader, tmpl, signingCert, publicKey, signingKey)
 }
 
@@ -361,8 +372,11 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
+		// If the cert is a CA cert, the private key is allowed to sign other certificates, and CRLs if requested.
 		keyUsage = x509.KeyUsageCertSign
+		if options.CRLSign {
+			keyUsage |= x509.KeyUsageCRLSign
+		}
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -76,37 +76,15 @@ func NewVerifiedKeyCertBundleFromFile(
 ) (
 	*KeyCertBundle, error,
 ) {
-	certBytes, err := os.ReadFile(certFile)
-	if err != nil {
-		return nil, err
-	}
 	privKeyBytes, err := os.ReadFile(privKeyFile)
 	if err != nil {
 		return nil, err
 	}
-	var certChainBytes []byte
-	if len(certChainFiles) > 0 {
-		for _, f := range certChainFiles {
-			var b []byte
-
-			if b, err = os.ReadFile(f); err != nil {
-				return nil, err
-			}
-
-			certChainBytes = append(certChainBytes, b...)
-		}
-	}
-	rootCertBytes, err := os.ReadFile(rootCertFile)
+	certBytes, certChainBytes, rootCertBytes, crlBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile, crlFile)
 	if err != nil {
 		return nil, err
 	}
 
-	// Read CRL file if provided
-	crlBytes, crlErr := gerCRLBytesFromFile(crlFile)
-	if crlErr != nil {
-		return nil, crlErr
-	}
-
 	return NewVerifiedKeyCertBundleFromPem(certBytes, privKeyBytes, certChainBytes, rootCertBytes, crlBytes)
 }
 
@@ -251,44 +229,119 @@ func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleFromFile(
 	certChainFiles []string,
 	rootCertFile, crlFile string,
 ) error {
-	certBytes, err := os.ReadFile(certFile)
+	privKeyBytes, err := os.ReadFile(privKeyFile)
 	if err != nil {
 		return err
 	}
-	privKeyBytes, err := os.ReadFile(privKeyFile)
+	certBytes, certChainBytes, rootCertBytes, crlBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile, crlFile)
 	if err != nil {
 		return err
 	}
-	certChainBytes := []byte{}
-	if len(certChainFiles) != 0 {
-		for _, f := range certChainFiles {
-			var b []byte
-			if b, err = os.ReadFile(f); err != nil {
-				return err
-			}
 
-			certChainBytes = append(certChainBytes, b...)
-		}
-	}
-	rootCertBytes, err := os.ReadFile(rootCertFile)
+	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes, crlBytes)
 	if err != nil {
 		return err
 	}
 
-	// Read CRL file if provided
-	crlBytes, crlErr := gerCRLBytesFromFile(crlFile)
-	if crlErr != nil {
-		return crlErr
+	return nil
+}
+
+// SignerProvider returns the signer holding the private key of cert, for CAs whose private key is held outside of
+// Istiod.
+type SignerProvider func(cert *x509.Certificate) (crypto.Signer, error)
+
+// NewVerifiedKeyCertBundleWithSignerFromFile returns a new KeyCertBundle whose private key is held by the signer
+// returned by signerFor, or error if the provided certs failed the verification.
+func NewVerifiedKeyCertBundleWithSignerFromFile(
+	certFile string,
+	signerFor SignerProvider,
+	certChainFiles []string,
+	rootCertFile, crlFile string,
+) (
+	*KeyCertBundle, error,
+) {
+	bundle := &KeyCertBundle{}
+	if err := bundle.UpdateVerifiedKeyCertBundleWithSignerFromFile(certFile, signerFor, certChainFiles, rootCertFile,
+		crlFile); err != nil {
+		return nil, err
 	}
+	return bundle, nil
+}
 
-	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes, crlBytes)
+// UpdateVerifiedKeyCertBundleWithSignerFromFile verifies and updates KeyCertBundle with new certs, whose private key
+// is held by the signer returned by signerFor.
+func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleWithSignerFromFile(
+	certFile string,
+	signerFor SignerProvider,
+	certChainFiles []string,
+	rootCertFile, crlFile string,
+) error {
+	certBytes, certChainBytes, rootCertBytes, crlBytes, err := readCertFiles(certFile, certChainFiles, rootCertFile, crlFile)
 	if err != nil {
 		return err
 	}
+	return b.VerifyAndSetAllWithSigner(certBytes, signerFor, certChainBytes, rootCertBytes, crlBytes)
+}
 
+// VerifyAndSetAllWithSigner verifies the certs, and sets all certs in KeyCertBundle together with the signer holding
+// the private key of the cert, which is returned by GetAll in place of the private key. The private key PEM is empty.
+func (b *KeyCertBundle) VerifyAndSetAllWithSigner(certBytes []byte, signerFor SignerProvider, certChainBytes,
+	rootCertBytes, crlBytes []byte,
+) error {
+	if err := verifyCertChainAndCRL(certBytes, certChainBytes, rootCertBytes, crlBytes); err != nil {
+		return err
+	}
+	cert, err := ParsePemEncodedCertificate(certBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse cert PEM: %v", err)
+	}
+	signer, err := signerFor(cert)
+	if err != nil {
+		return fmt.Errorf("failed to get the signer of the cert: %v", err)
+	}
+	if pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(cert.PublicKey) {
+		return fmt.Errorf("the cert does not match the key of the signer")
+	}
+	var privKey crypto.PrivateKey = signer
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.certBytes = copyBytes(certBytes)
+	b.cert = cert
+	b.privKeyBytes = []byte{}
+	b.privKey = &privKey
+	b.certChainBytes = copyBytes(certChainBytes)
+	b.rootCertBytes = copyBytes(rootCertBytes)
+	if len(crlBytes) != 0 {
+		b.crlBytes = copyBytes(crlBytes)
+	}
 	return nil
 }
 
+// readCertFiles reads the cert, cert chain, root cert and optional CRL files of a KeyCertBundle.
+func readCertFiles(certFile string, certChainFiles []string, rootCertFile, crlFile string) (
+	certBytes, certChainBytes, rootCertBytes, crlBytes []byte, err error,
+) {
+	if certBytes, err = os.ReadFile(certFile); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	for _, f := range certChainFiles {
+		var b []byte
+		if b, err = os.ReadFile(f); err != nil {
+			return nil, nil, nil, nil, err
+		}
+		certChainBytes = append(certChainBytes, b...)
+	}
+	if rootCertBytes, err = os.ReadFile(rootCertFile); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	// Read CRL file if provided
+	if crlBytes, err = gerCRLBytesFromFile(crlFile); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return certBytes, certChainBytes, rootCertBytes, crlBytes, nil
+}
+
 // gerCRLBytesFromFile reads the CRL file and returns the content if it exists.
 // Providing CRL file is optional, if not provided, it returns nil without an error.
 func gerCRLBytesFromFile(crlFile string) ([]byte, error) {
@@ -328,6 +381,25 @@ func TimeBeforeCertExpires(certBytes []byte, now time.Time) (time.Duration, erro
 
 // Verify that the cert chain, root cert and key/cert match.
 func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes, crl []byte) error {
+	if err := verifyCertChainAndCRL(certBytes, certChainBytes, rootCertBytes, crl); err != nil {
+		return err
+	}
+
+	// Verify that the key can be correctly parsed.
+	if _, err := ParsePemEncodedKey(privKeyBytes); err != nil {
+		return fmt.Errorf("failed to parse private key PEM: %v", err)
+	}
+
+	// Verify the cert and key match.
+	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
+		return fmt.Errorf("the cert does not match the key: %v", err)
+	}
+	return nil
+}
+
+// verifyCertChainAndCRL verifies that the cert can be verified from the root cert through the cert chain, and that
+// the CRL, if any, covers the whole chain.
+func verifyCertChainAndCRL(certBytes, certChainBytes, rootCertBytes, crl []byte) error {
 	// Verify the cert can be verified from the root cert through the cert chain.
 	rcp := x509.NewCertPool()
 	rcp.AppendCertsFromPEM(rootCertBytes)
@@ -351,16 +423,6 @@ func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes, crl []byte)
 				"pool with error: %v", err)
 	}
 
-	// Verify that the key can be correctly parsed.
-	if _, err = ParsePemEncodedKey(privKeyBytes); err != nil {
-		return fmt.Errorf("failed to parse private key PEM: %v", err)
-	}
-
-	// Verify the cert and key match.
-	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
-		return fmt.Errorf("the cert does not match the key: %v", err)
-	}
-
 	// verify only if the CRL is provided
 	if len(crl) != 0 {
 		// envoy expects that if a CRL is provided for any certificate authority in a trust chain,
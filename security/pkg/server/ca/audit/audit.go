@@ -0,0 +1,226 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records the certificates issued by the Istio CA. Each issuance is written to append-only sinks,
+// and the most recent ones are kept in memory to be queried.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/backoff"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/monitoring"
+)
+
+var auditLog = log.RegisterScope("caaudit", "CA certificate issuance audit log")
+
+var (
+	sinkTag = monitoring.CreateLabel("sink")
+
+	sinkErrors = monitoring.NewSum(
+		"citadel_server_issuance_audit_errors_total",
+		"The number of failed attempts to write a certificate issuance to an audit sink.",
+	)
+
+	failed = monitoring.NewSum(
+		"citadel_server_issuance_audit_failed_total",
+		"The number of certificate issuances refused because they could not be written to the audit sinks.",
+	)
+)
+
+// retryOption is the backoff between the attempts to write a record to a failing sink.
+var retryOption = backoff.Option{InitialInterval: 50 * time.Millisecond, MaxInterval: time.Second}
+
+// Record is the audit record of an issued certificate.
+type Record struct {
+	// Serial is the hex encoded serial number of the certificate.
+	Serial    string    `json:"serial"`
+	SANs      []string  `json:"sans"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	// Authenticator is the type of the authenticator that authenticated the caller.
+	Authenticator    string   `json:"authenticator"`
+	CallerIdentities []string `json:"callerIdentities,omitempty"`
+	// CallerPod is the namespace/name of the calling pod, if known.
+	CallerPod     string `json:"callerPod,omitempty"`
+	ClientAddress string `json:"clientAddress,omitempty"`
+	// Impersonated is set if the certificate was requested by a trusted node agent on behalf of the SAN identity.
+	Impersonated bool   `json:"impersonated,omitempty"`
+	CertSigner   string `json:"certSigner,omitempty"`
+	// Root is the fingerprint of the root certificate the certificate chains to, if it was part of the response.
+	Root string `json:"root,omitempty"`
+}
+
+// Filter selects issuance records. Empty fields match all records.
+type Filter struct {
+	// Identity matches records with this SAN.
+	Identity string
+	Serial   string
+	// Since matches records issued at or after this time.
+	Since time.Time
+	// Limit is the maximum number of records returned, if positive.
+	Limit int
+}
+
+// Matches returns true if the record is selected by the filter.
+func (f Filter) Matches(r Record) bool {
+	if f.Identity != "" && !slices.Contains(r.SANs, f.Identity) {
+		return false
+	}
+	if f.Serial != "" && f.Serial != r.Serial {
+		return false
+	}
+	return f.Since.IsZero() || !r.IssuedAt.Before(f.Since)
+}
+
+// Sink durably stores issuance records. Write may be called concurrently, and again with the same record after a
+// failure.
+type Sink interface {
+	// Name identifies the sink in logs and metrics.
+	Name() string
+	Write(r Record) error
+	Close() error
+}
+
+// Log records certificate issuances to its sinks, and keeps the most recent ones for queries.
+type Log struct {
+	sinks []Sink
+	// timeout bounds the time spent retrying the sinks for a record.
+	timeout time.Duration
+
+	// closeMu is held for reading while records are written, so that Close waits for them.
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu sync.RWMutex
+	// history is a ring buffer of the most recent records; next is the position of the next record.
+	history []Record
+	next    int
+	full    bool
+}
+
+// NewLog creates a Log keeping the last historySize records in memory. Failed writes to the sinks are retried for up
+// to timeout.
+func NewLog(historySize int, timeout time.Duration, sinks ...Sink) *Log {
+	if historySize < 1 {
+		historySize = 1
+	}
+	return &Log{
+		sinks:   sinks,
+		timeout: timeout,
+		history: make([]Record, historySize),
+	}
+}
+
+// Record writes an issuance to all sinks, retrying failed writes until they succeed, ctx is done or the timeout of
+// the log expires, and then adds it to the history. An error is returned if a sink could not store the record, in
+// which case the certificate must not be handed out.
+func (l *Log) Record(ctx context.Context, r Record) error {
+	l.closeMu.RLock()
+	defer l.closeMu.RUnlock()
+	if l.closed {
+		failed.Increment()
+		return errors.New("the audit log is closed")
+	}
+	if len(l.sinks) > 0 {
+		ctx, cancel := context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+		for _, s := range l.sinks {
+			if err := l.write(ctx, s, r); err != nil {
+				failed.Increment()
+				return err
+			}
+		}
+	}
+	l.remember(r)
+	return nil
+}
+
+func (l *Log) write(ctx context.Context, s Sink, r Record) error {
+	err := backoff.NewExponentialBackOff(retryOption).RetryWithContext(ctx, func() error {
+		err := s.Write(r)
+		if err != nil {
+			sinkErrors.With(sinkTag.Value(s.Name())).Increment()
+			auditLog.Warnf("failed to write issuance of serial %s for %v to %s sink, retrying: %v", r.Serial, r.SANs, s.Name(), err)
+		}
+		return err
+	})
+	if err != nil {
+		auditLog.Errorf("failed to write issuance of serial %s for %v to %s sink: %v", r.Serial, r.SANs, s.Name(), err)
+		return fmt.Errorf("failed to write issuance to %s audit sink: %v", s.Name(), err)
+	}
+	return nil
+}
+
+// Seed adds records issued previously, such as those read back from a file sink, to the history without writing
+// them to the sinks.
+func (l *Log) Seed(records []Record) {
+	for _, r := range records {
+		l.remember(r)
+	}
+}
+
+func (l *Log) remember(r Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.history[l.next] = r
+	l.next = (l.next + 1) % len(l.history)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Query returns the records in the history matching the filter, most recent first.
+func (l *Log) Query(f Filter) []Record {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	n := l.next
+	if l.full {
+		n = len(l.history)
+	}
+	out := []Record{}
+	for i := 1; i <= n; i++ {
+		r := l.history[(l.next-i+len(l.history))%len(l.history)]
+		if !f.Matches(r) {
+			continue
+		}
+		out = append(out, r)
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// Close waits for the records being written, and closes the sinks. Records can no longer be added once closed.
+func (l *Log) Close() error {
+	l.closeMu.Lock()
+	defer l.closeMu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	var errs []error
+	for _, s := range l.sinks {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
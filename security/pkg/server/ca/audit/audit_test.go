@@ -0,0 +1,243 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"istio.io/istio/pkg/auditapi"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+type fakeSink struct {
+	records []Record
+	// failures is the number of writes failing before the sink recovers, or all of them if negative.
+	failures int
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Write(r Record) error {
+	if s.failures != 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *fakeSink) Close() error { return nil }
+
+func serials(records []Record) []string {
+	out := []string{}
+	for _, r := range records {
+		out = append(out, r.Serial)
+	}
+	return out
+}
+
+func TestLog(t *testing.T) {
+	sink := &fakeSink{}
+	flaky := &fakeSink{failures: 2}
+	l := NewLog(3, time.Minute, flaky, sink)
+	l.Seed([]Record{{Serial: "0"}})
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, san := range []string{"a", "b", "a"} {
+		assert.NoError(t, l.Record(context.Background(),
+			Record{Serial: string(rune('1' + i)), SANs: []string{san}, IssuedAt: base.Add(time.Duration(i) * time.Minute)}))
+	}
+
+	// Seeded records are not written again, and failed writes are retried.
+	assert.NoError(t, l.Close())
+	assert.Equal(t, serials(flaky.records), []string{"1", "2", "3"})
+	assert.Equal(t, serials(sink.records), []string{"1", "2", "3"})
+	// Only the last 3 records are kept.
+	assert.Equal(t, serials(l.Query(Filter{})), []string{"3", "2", "1"})
+	assert.Equal(t, serials(l.Query(Filter{Identity: "a"})), []string{"3", "1"})
+	assert.Equal(t, serials(l.Query(Filter{Serial: "2"})), []string{"2"})
+	assert.Equal(t, serials(l.Query(Filter{Since: base.Add(time.Minute)})), []string{"3", "2"})
+	assert.Equal(t, serials(l.Query(Filter{Limit: 2})), []string{"3", "2"})
+	assert.Equal(t, serials(NewLog(3, time.Minute).Query(Filter{})), []string{})
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	records, err := ReadFile(path, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, len(records), 0)
+
+	s, err := NewFileSink(path, 0)
+	assert.NoError(t, err)
+	for _, serial := range []string{"1", "2", "3"} {
+		assert.NoError(t, s.Write(Record{Serial: serial, SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}}))
+	}
+	assert.NoError(t, s.Close())
+
+	// A line partially written during a crash is skipped, and records written after it are still read.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"serial":"4","sa` + "\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	s, err = NewFileSink(path, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Write(Record{Serial: "5"}))
+	assert.NoError(t, s.Close())
+
+	records, err = ReadFile(path, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, serials(records), []string{"1", "2", "3", "5"})
+	assert.Equal(t, records[0].SANs, []string{"spiffe://cluster.local/ns/a/sa/a"})
+	records, err = ReadFile(path, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, serials(records), []string{"3", "5"})
+}
+
+func TestLogFailingSink(t *testing.T) {
+	sink := &fakeSink{}
+	l := NewLog(3, 200*time.Millisecond, sink, &fakeSink{failures: -1})
+	// A record that cannot be stored by every sink is refused, and not added to the history.
+	assert.Error(t, l.Record(context.Background(), Record{Serial: "1"}))
+	assert.Equal(t, serials(l.Query(Filter{})), []string{})
+
+	// Records are refused once the log is closed.
+	assert.NoError(t, l.Close())
+	assert.Error(t, l.Record(context.Background(), Record{Serial: "2"}))
+	assert.Equal(t, serials(l.Query(Filter{})), []string{})
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	line, err := json.Marshal(Record{Serial: "1"})
+	assert.NoError(t, err)
+	// Two records fit in the file.
+	s, err := NewFileSink(path, int64(2*(len(line)+1)))
+	assert.NoError(t, err)
+	for _, serial := range []string{"1", "2", "3", "4", "5"} {
+		assert.NoError(t, s.Write(Record{Serial: serial}))
+	}
+	assert.NoError(t, s.Close())
+
+	// A reopened sink continues after the existing rotated files.
+	s, err = NewFileSink(path, int64(2*(len(line)+1)))
+	assert.NoError(t, err)
+	for _, serial := range []string{"6", "7"} {
+		assert.NoError(t, s.Write(Record{Serial: serial}))
+	}
+	assert.NoError(t, s.Close())
+
+	for file, want := range map[string][]string{
+		path + ".1": {"1", "2"},
+		path + ".2": {"3", "4"},
+		path + ".3": {"5", "6"},
+		path:        {"7"},
+	} {
+		records, err := ReadFile(file, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, serials(records), want)
+	}
+}
+
+func TestReadFilesSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	line, err := json.Marshal(Record{Serial: "1", IssuedAt: base})
+	assert.NoError(t, err)
+	// Two records fit in the file.
+	s, err := NewFileSink(path, int64(2*(len(line)+1)))
+	assert.NoError(t, err)
+	for i := 1; i <= 7; i++ {
+		assert.NoError(t, s.Write(Record{Serial: strconv.Itoa(i), IssuedAt: base.Add(time.Duration(i) * time.Minute)}))
+	}
+	assert.NoError(t, s.Close())
+
+	records, err := ReadFilesSince(path, base.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, serials(records), []string{"2", "3", "4", "5", "6", "7"})
+	records, err = ReadFilesSince(path, base.Add(6*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, serials(records), []string{"6", "7"})
+	records, err = ReadFilesSince(filepath.Join(t.TempDir(), "missing.jsonl"), base)
+	assert.NoError(t, err)
+	assert.Equal(t, len(records), 0)
+}
+
+func TestReadFileTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	s, err := NewFileSink(path, 0)
+	assert.NoError(t, err)
+	// Enough records to span several chunks.
+	sans := []string{strings.Repeat("a", 1000)}
+	for i := 0; i < 300; i++ {
+		assert.NoError(t, s.Write(Record{Serial: strconv.Itoa(i), SANs: sans}))
+	}
+	assert.NoError(t, s.Close())
+
+	for _, n := range []int{1, 64, 65, 299, 300, 400} {
+		records, err := ReadFile(path, n)
+		assert.NoError(t, err)
+		want := []string{}
+		for i := max(0, 300-n); i < 300; i++ {
+			want = append(want, strconv.Itoa(i))
+		}
+		assert.Equal(t, serials(records), want)
+	}
+}
+
+type fakeIssuanceSink struct {
+	auditapi.UnimplementedCertificateIssuanceSinkServer
+	received chan *auditapi.IssuanceRecord
+}
+
+func (f *fakeIssuanceSink) Record(_ context.Context, r *auditapi.IssuanceRecord) (*auditapi.RecordResponse, error) {
+	f.received <- r
+	return &auditapi.RecordResponse{}, nil
+}
+
+func TestGRPCSink(t *testing.T) {
+	sink := &fakeIssuanceSink{received: make(chan *auditapi.IssuanceRecord, 1)}
+	server := grpc.NewServer()
+	auditapi.RegisterCertificateIssuanceSinkServer(server, sink)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	s, err := NewGRPCSink(lis.Addr().String(), insecure.NewCredentials(), 5*time.Second)
+	assert.NoError(t, err)
+	defer s.Close()
+	issued := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, s.Write(Record{
+		Serial: "1f", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, Authenticator: "ClientCertAuthenticator", IssuedAt: issued,
+	}))
+
+	req := <-sink.received
+	assert.Equal(t, req.Serial, "1f")
+	assert.Equal(t, req.Authenticator, "ClientCertAuthenticator")
+	assert.Equal(t, req.Sans, []string{"spiffe://cluster.local/ns/a/sa/a"})
+	assert.Equal(t, req.IssuedAt.AsTime(), issued)
+}
@@ -0,0 +1,252 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSink appends issuance records to a file, one JSON document per line. Once the file would grow past its
+// maximum size, it is renamed with the next free numbered suffix, such as ".1" then ".2", and a new file is started.
+// Rotated files are never overwritten or removed; the highest number is the most recent.
+type FileSink struct {
+	path    string
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	// next is the suffix of the next rotated file.
+	next int
+}
+
+var _ Sink = &FileSink{}
+
+// NewFileSink opens path for appending, creating it if needed. The file is rotated once it would exceed maxSize
+// bytes; it is never rotated if maxSize is not positive.
+func NewFileSink(path string, maxSize int64) (*FileSink, error) {
+	s := &FileSink{path: path, maxSize: maxSize}
+	next, err := nextRotatedSuffix(path)
+	if err != nil {
+		return nil, err
+	}
+	s.next = next
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// nextRotatedSuffix returns the suffix following the highest one of the rotated files of path.
+func nextRotatedSuffix(path string) (int, error) {
+	suffixes, err := rotatedSuffixes(path)
+	if err != nil || len(suffixes) == 0 {
+		return 1, err
+	}
+	return suffixes[len(suffixes)-1] + 1, nil
+}
+
+// rotatedSuffixes returns the suffixes of the rotated files of path, oldest first.
+func rotatedSuffixes(path string) ([]int, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var suffixes []int
+	for _, m := range matches {
+		if n, err := strconv.Atoi(strings.TrimPrefix(m, path+".")); err == nil && n > 0 {
+			suffixes = append(suffixes, n)
+		}
+	}
+	slices.Sort(suffixes)
+	return suffixes, nil
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.size = f, stat.Size()
+	return nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Write appends the record and syncs the file, so that it is not lost if istiod crashes.
+func (s *FileSink) Write(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		if n > 0 && n < len(line) {
+			// End the partial line, so that it is skipped when read and a retry starts on its own line.
+			m, _ := s.file.Write([]byte{'\n'})
+			s.size += int64(m)
+		}
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	if err := s.renameToRotated(); err != nil {
+		// Keep appending to the current file, so that the write can be retried.
+		return errors.Join(err, s.open())
+	}
+	return s.open()
+}
+
+// renameToRotated renames the file to the next free rotated file. A rotated file is never replaced, even one created
+// since the sink was opened.
+func (s *FileSink) renameToRotated() error {
+	for {
+		rotated := s.path + "." + strconv.Itoa(s.next)
+		if _, err := os.Lstat(rotated); err == nil {
+			s.next++
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := os.Rename(s.path, rotated); err != nil {
+			return err
+		}
+		s.next++
+		return nil
+	}
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// readChunkSize is the size of the chunks ReadFile reads from the end of the file.
+const readChunkSize = 64 * 1024
+
+// ReadFile returns the last n records of a file written by a FileSink, oldest first, or all of them if n is not
+// positive. Only the end of the file holding the last n lines is read. A missing file has no records, and malformed
+// lines, such as one partially written during a crash, are skipped. Rotated files are not read.
+func ReadFile(path string, n int) ([]Record, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if n <= 0 {
+		return readRecords(path, f, n)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := stat.Size()
+	var data []byte
+	// A complete line follows the first newline, so n+1 newlines are needed for the last n lines.
+	for offset > 0 && bytes.Count(data, []byte{'\n'}) <= n {
+		chunk := make([]byte, min(offset, readChunkSize))
+		offset -= int64(len(chunk))
+		if _, err := f.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+	}
+	if offset > 0 {
+		data = data[bytes.IndexByte(data, '\n')+1:]
+	}
+	return readRecords(path, bytes.NewReader(data), n)
+}
+
+// ReadFilesSince returns the records issued at or after since, oldest first, of a file written by a FileSink and of
+// its rotated files. The rotated files are read from the most recent one, until one starts before since.
+func ReadFilesSince(path string, since time.Time) ([]Record, error) {
+	suffixes, err := rotatedSuffixes(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	for i := len(suffixes) - 1; i >= 0; i-- {
+		files = append(files, path+"."+strconv.Itoa(suffixes[i]))
+	}
+	var out []Record
+	for _, file := range files {
+		records, err := ReadFile(file, 0)
+		if err != nil {
+			return nil, err
+		}
+		f := Filter{Since: since}
+		matching := slices.DeleteFunc(slices.Clone(records), func(r Record) bool { return !f.Matches(r) })
+		out = append(matching, out...)
+		if len(records) > 0 && records[0].IssuedAt.Before(since) {
+			break
+		}
+	}
+	return out, nil
+}
+
+func readRecords(path string, reader io.Reader, n int) ([]Record, error) {
+	var out []Record
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			auditLog.Warnf("skipping malformed issuance record in %s: %v", path, err)
+			continue
+		}
+		out = append(out, r)
+	}
+	if n > 0 && len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out, scanner.Err()
+}
@@ -0,0 +1,77 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"istio.io/istio/pkg/auditapi"
+)
+
+// GRPCSink sends issuance records to a remote istio.security.audit.v1.CertificateIssuanceSink service.
+type GRPCSink struct {
+	conn    *grpc.ClientConn
+	client  auditapi.CertificateIssuanceSinkClient
+	timeout time.Duration
+}
+
+var _ Sink = &GRPCSink{}
+
+// NewGRPCSink creates a sink sending records to address. Each record must be acknowledged within timeout.
+func NewGRPCSink(address string, creds credentials.TransportCredentials, timeout time.Duration) (*GRPCSink, error) {
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCSink{conn: conn, client: auditapi.NewCertificateIssuanceSinkClient(conn), timeout: timeout}, nil
+}
+
+func (s *GRPCSink) Name() string {
+	return "grpc"
+}
+
+func (s *GRPCSink) Write(r Record) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	_, err := s.client.Record(ctx, RecordToProto(r))
+	return err
+}
+
+func (s *GRPCSink) Close() error {
+	return s.conn.Close()
+}
+
+// RecordToProto converts a record to the message sent to a CertificateIssuanceSink.
+func RecordToProto(r Record) *auditapi.IssuanceRecord {
+	return &auditapi.IssuanceRecord{
+		Serial:           r.Serial,
+		Sans:             r.SANs,
+		NotBefore:        timestamppb.New(r.NotBefore),
+		NotAfter:         timestamppb.New(r.NotAfter),
+		IssuedAt:         timestamppb.New(r.IssuedAt),
+		Authenticator:    r.Authenticator,
+		CallerIdentities: r.CallerIdentities,
+		CallerPod:        r.CallerPod,
+		ClientAddress:    r.ClientAddress,
+		Impersonated:     r.Impersonated,
+		CertSigner:       r.CertSigner,
+		Root:             r.Root,
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/cryptosigner"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pkg/jwtsvidapi"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/security/pkg/server/ca/policy"
+)
+
+// JWKSPath is the path of the HTTP endpoint publishing the keys verifying the JWT-SVIDs.
+const JWKSPath = "/jwt-svid/jwks.json"
+
+// jwtSVIDKeyUse is the use of the JWT-SVID keys in the JWKS, as per the SPIFFE trust domain bundle format.
+const jwtSVIDKeyUse = "jwt-svid"
+
+// CreateJWTSVID issues a JWT-SVID for the SPIFFE identity of the caller, authenticated as for CreateCertificate.
+// The JWT-SVID is signed with the active key of JWTSVIDKeys, which all Istiod replicas share. Requests for denied
+// identities, or with a TTL above the maximum of the CSR policy, are denied as for CreateCertificate.
+func (s *Server) CreateJWTSVID(ctx context.Context, request *jwtsvidapi.JWTSVIDRequest) (*jwtsvidapi.JWTSVIDResponse, error) {
+	caller, err := security.Authenticate(ctx, s.Authenticators)
+	if caller == nil || err != nil {
+		s.monitoring.AuthnError.Increment()
+		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
+	}
+	if len(request.Audiences) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one audience is required")
+	}
+	id := spiffeIdentity(caller.Identities)
+	if id == "" {
+		return nil, status.Error(codes.PermissionDenied, "the caller has no SPIFFE identity")
+	}
+	ttl := s.jwtSVIDTTL
+	if d := time.Duration(request.ValidityDuration) * time.Second; d > 0 && d < ttl {
+		ttl = d
+	}
+	if s.Policy != nil {
+		if err := s.Policy.EvaluateJWT(policy.Request{Identities: []string{id}, TTL: ttl}); err != nil {
+			serverCaLog.Warnf("JWT-SVID denied for %s: %v", id, err)
+			return nil, s.policyError(err)
+		}
+	}
+
+	key, signer, err := s.jwtSigningKey()
+	if err != nil {
+		serverCaLog.Errorf("failed to issue JWT-SVID for %s: %v", id, err)
+		return nil, status.Error(codes.Internal, "failed to sign JWT-SVID")
+	}
+	key.Key = cryptosigner.Opaque(signer)
+	jwtSigner, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		serverCaLog.Errorf("failed to issue JWT-SVID for %s: %v", id, err)
+		return nil, status.Error(codes.Internal, "failed to sign JWT-SVID")
+	}
+	now := time.Now()
+	expiry := now.Add(ttl)
+	token, err := jwt.Signed(jwtSigner).Claims(jwt.Claims{
+		Subject:  id,
+		Audience: jwt.Audience(request.Audiences),
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(expiry),
+	}).Serialize()
+	if err != nil {
+		serverCaLog.Errorf("failed to issue JWT-SVID for %s: %v", id, err)
+		return nil, status.Error(codes.Internal, "failed to sign JWT-SVID")
+	}
+	jwtSVIDCounts.Increment()
+	serverCaLog.Debugf("JWT-SVID issued for %s, audiences %v", id, request.Audiences)
+	return &jwtsvidapi.JWTSVIDResponse{
+		SpiffeId:  id,
+		Token:     token,
+		ExpiresAt: expiry.Unix(),
+	}, nil
+}
+
+// FetchJWTBundle returns the keys verifying the JWT-SVIDs, for the trust domain of the caller.
+func (s *Server) FetchJWTBundle(ctx context.Context, _ *jwtsvidapi.JWTBundleRequest) (*jwtsvidapi.JWTBundleResponse, error) {
+	caller, err := security.Authenticate(ctx, s.Authenticators)
+	if caller == nil || err != nil {
+		s.monitoring.AuthnError.Increment()
+		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
+	}
+	id := spiffeIdentity(caller.Identities)
+	if id == "" {
+		return nil, status.Error(codes.PermissionDenied, "the caller has no SPIFFE identity")
+	}
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "invalid SPIFFE identity %s", id)
+	}
+	jwks, err := s.JWKS()
+	if err != nil {
+		serverCaLog.Errorf("failed to build JWKS: %v", err)
+		return nil, status.Error(codes.Internal, "failed to build JWKS")
+	}
+	return &jwtsvidapi.JWTBundleResponse{TrustDomain: u.Host, Jwks: jwks}, nil
+}
+
+// JWKS returns the JSON Web Key Set verifying the JWT-SVIDs. It holds the active key, the next one, and the previous
+// ones until the JWT-SVIDs they signed expire.
+func (s *Server) JWKS() ([]byte, error) {
+	if s.JWTSVIDKeys == nil || !s.JWTSVIDKeys.HasSynced() {
+		return nil, fmt.Errorf("the JWT-SVID signing keys are not loaded")
+	}
+	return json.Marshal(jose.JSONWebKeySet{Keys: s.JWTSVIDKeys.publicKeys()})
+}
+
+// ServeJWKS serves the JSON Web Key Set verifying the JWT-SVIDs, at JWKSPath.
+func (s *Server) ServeJWKS(w http.ResponseWriter, _ *http.Request) {
+	jwks, err := s.JWKS()
+	if err != nil {
+		serverCaLog.Errorf("failed to build JWKS: %v", err)
+		http.Error(w, "failed to build JWKS", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jwks)
+}
+
+// jwtSigningKey returns the public JWK of the active JWT-SVID signing key, and its signer.
+func (s *Server) jwtSigningKey() (jose.JSONWebKey, crypto.Signer, error) {
+	if s.JWTSVIDKeys == nil {
+		return jose.JSONWebKey{}, nil, fmt.Errorf("no JWT-SVID signing keys are configured")
+	}
+	return s.JWTSVIDKeys.signingKey()
+}
+
+// spiffeIdentity returns the first SPIFFE identity, or an empty string if there is none.
+func spiffeIdentity(identities []string) string {
+	for _, id := range identities {
+		if strings.HasPrefix(id, spiffe.URIPrefix) {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/pkg/jwtsvidapi"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/server/ca/policy"
+)
+
+const jwtSVIDID = "spiffe://cluster.local/ns/default/sa/app"
+
+func newJWTSVIDServer(t *testing.T, identities ...string) *Server {
+	t.Helper()
+	keys := NewJWTSVIDKeys(JWTSVIDKeyConfig{RotationPeriod: 24 * time.Hour, TTL: 5 * time.Minute})
+	assert.NoError(t, keys.Refresh(context.Background()))
+	return &Server{
+		Authenticators: []security.Authenticator{&mockAuthenticator{identities: identities}},
+		monitoring:     newMonitoringMetrics(),
+		jwtSVIDTTL:     5 * time.Minute,
+		JWTSVIDKeys:    keys,
+	}
+}
+
+func jwtSVIDContext() context.Context {
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	return peer.NewContext(context.Background(), p)
+}
+
+func TestCreateJWTSVID(t *testing.T) {
+	server := newJWTSVIDServer(t, "test-identity", jwtSVIDID)
+	resp, err := server.CreateJWTSVID(jwtSVIDContext(), &jwtsvidapi.JWTSVIDRequest{
+		Audiences:        []string{"kafka", "db"},
+		ValidityDuration: 60,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, resp.SpiffeId, jwtSVIDID)
+
+	// The JWT-SVID is verified by the published keys.
+	rec := httptest.NewRecorder()
+	server.ServeJWKS(rec, httptest.NewRequest("GET", JWKSPath, nil))
+	assert.Equal(t, rec.Code, 200)
+	jwks := jose.JSONWebKeySet{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	assert.Equal(t, len(jwks.Keys), 1)
+	assert.Equal(t, jwks.Keys[0].Use, "jwt-svid")
+
+	token, err := jwt.ParseSigned(resp.Token, []jose.SignatureAlgorithm{jose.ES256})
+	assert.NoError(t, err)
+	assert.Equal(t, token.Headers[0].KeyID, jwks.Keys[0].KeyID)
+	claims := jwt.Claims{}
+	assert.NoError(t, token.Claims(jwks.Keys[0].Key, &claims))
+	assert.NoError(t, claims.Validate(jwt.Expected{Subject: jwtSVIDID, AnyAudience: jwt.Audience{"db"}, Time: time.Now()}))
+	// The requested validity is shorter than the maximum.
+	assert.Equal(t, claims.Expiry.Time().Sub(claims.IssuedAt.Time()), time.Minute)
+	assert.Equal(t, resp.ExpiresAt, claims.Expiry.Time().Unix())
+}
+
+func TestCreateJWTSVIDErrors(t *testing.T) {
+	cases := []struct {
+		name       string
+		server     *Server
+		audiences  []string
+		expectCode codes.Code
+	}{
+		{
+			name:       "unauthenticated",
+			server:     &Server{Authenticators: []security.Authenticator{&mockAuthenticator{errMsg: "denied"}}, monitoring: newMonitoringMetrics()},
+			audiences:  []string{"kafka"},
+			expectCode: codes.Unauthenticated,
+		},
+		{
+			name:       "no audience",
+			server:     newJWTSVIDServer(t, jwtSVIDID),
+			expectCode: codes.InvalidArgument,
+		},
+		{
+			name:       "no spiffe identity",
+			server:     newJWTSVIDServer(t, "test-identity"),
+			audiences:  []string{"kafka"},
+			expectCode: codes.PermissionDenied,
+		},
+		{
+			name: "no signing key",
+			server: &Server{
+				Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{jwtSVIDID}}},
+				monitoring:     newMonitoringMetrics(),
+			},
+			audiences:  []string{"kafka"},
+			expectCode: codes.Internal,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.server.CreateJWTSVID(jwtSVIDContext(), &jwtsvidapi.JWTSVIDRequest{Audiences: c.audiences})
+			assert.Equal(t, status.Code(err), c.expectCode)
+		})
+	}
+}
+
+func TestCreateJWTSVIDPolicy(t *testing.T) {
+	engine := policy.NewEngine(time.Hour)
+	assert.NoError(t, engine.Update(&policy.Policy{
+		MaxTTL:           metav1.Duration{Duration: 2 * time.Minute},
+		DeniedIdentities: []string{"spiffe://cluster.local/ns/denied/*"},
+	}))
+	cases := []struct {
+		name     string
+		identity string
+		validity int64
+		rule     string
+	}{
+		{name: "allowed", identity: jwtSVIDID, validity: 60},
+		// The default TTL of JWT-SVIDs is above the maximum.
+		{name: "ttl too long", identity: jwtSVIDID, rule: policy.RuleTTL},
+		{name: "denied identity", identity: "spiffe://cluster.local/ns/denied/sa/app", validity: 60, rule: policy.RuleDeniedIdentity},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := newJWTSVIDServer(t, c.identity)
+			server.Policy = engine
+			_, err := server.CreateJWTSVID(jwtSVIDContext(), &jwtsvidapi.JWTSVIDRequest{
+				Audiences:        []string{"kafka"},
+				ValidityDuration: c.validity,
+			})
+			if c.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			s := status.Convert(err)
+			assert.Equal(t, s.Code(), codes.PermissionDenied)
+			assert.Equal(t, len(s.Details()), 1)
+			assert.Equal(t, s.Details()[0].(*errdetails.ErrorInfo).Reason, c.rule)
+		})
+	}
+}
+
+func TestFetchJWTBundle(t *testing.T) {
+	server := newJWTSVIDServer(t, jwtSVIDID)
+	resp, err := server.FetchJWTBundle(jwtSVIDContext(), &jwtsvidapi.JWTBundleRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, resp.TrustDomain, "cluster.local")
+	jwks, err := server.JWKS()
+	assert.NoError(t, err)
+	assert.Equal(t, resp.Jwks, jwks)
+}
+
+func TestJWTSVIDKeyRotation(t *testing.T) {
+	client := fake.NewClientset().CoreV1()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newKeys := func() *JWTSVIDKeys {
+		k := NewJWTSVIDKeys(JWTSVIDKeyConfig{
+			Client:         client,
+			Namespace:      "istio-system",
+			RotationPeriod: 24 * time.Hour,
+			PublishLead:    10 * time.Minute,
+			TTL:            5 * time.Minute,
+		})
+		k.now = func() time.Time { return now }
+		return k
+	}
+	keyIDs := func(k *JWTSVIDKeys) []string {
+		out := []string{}
+		for _, key := range k.publicKeys() {
+			out = append(out, key.KeyID)
+		}
+		return out
+	}
+	signingKeyID := func(k *JWTSVIDKeys) string {
+		key, _, err := k.signingKey()
+		assert.NoError(t, err)
+		return key.KeyID
+	}
+	refresh := func(replicas ...*JWTSVIDKeys) {
+		for _, k := range replicas {
+			assert.NoError(t, k.Refresh(context.Background()))
+		}
+	}
+
+	// The replicas share the key created by the first one.
+	a, b := newKeys(), newKeys()
+	refresh(a, b)
+	first := signingKeyID(a)
+	assert.Equal(t, keyIDs(a), []string{first})
+	assert.Equal(t, keyIDs(b), []string{first})
+
+	// The next key is published ahead of its activation.
+	now = now.Add(24*time.Hour - 10*time.Minute)
+	refresh(a, b)
+	assert.Equal(t, len(keyIDs(b)), 2)
+	assert.Equal(t, keyIDs(a), keyIDs(b))
+	assert.Equal(t, signingKeyID(b), first)
+	next := keyIDs(b)[1]
+
+	// The previous key is published until the JWT-SVIDs it signed expire.
+	now = now.Add(10 * time.Minute)
+	refresh(a, b)
+	assert.Equal(t, signingKeyID(a), next)
+	assert.Equal(t, keyIDs(a), []string{first, next})
+	now = now.Add(6 * time.Minute)
+	refresh(a, b)
+	assert.Equal(t, keyIDs(a), []string{next})
+	assert.Equal(t, keyIDs(b), []string{next})
+}
@@ -0,0 +1,318 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	v1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// JWTSVIDKeysSecret stores the keys signing the JWT-SVIDs, so that all istiod replicas sign with and publish the
+	// same keys.
+	JWTSVIDKeysSecret = "istio-jwt-svid-keys"
+	// JWTSVIDKeysFile is the key of the JSON encoded list of keys in JWTSVIDKeysSecret.
+	JWTSVIDKeysFile = "keys.json"
+
+	// maxJWTSVIDKeyConflictRetries is the number of times an update of the keys secret is retried on conflicts with
+	// other istiod replicas.
+	maxJWTSVIDKeyConflictRetries = 5
+
+	// defaultJWTSVIDKeyPublishLead covers the time the Istio agents cache the keys.
+	defaultJWTSVIDKeyPublishLead = 10 * time.Minute
+)
+
+// JWTSVIDKeyConfig configures the keys signing the JWT-SVIDs. They are dedicated to JWT-SVIDs, rather than the CA
+// signing key, so that they can be rotated independently.
+type JWTSVIDKeyConfig struct {
+	// Client stores the keys in JWTSVIDKeysSecret. If nil, the keys are only kept in memory.
+	Client corev1.CoreV1Interface
+	// Namespace of the JWTSVIDKeysSecret.
+	Namespace string
+	// RotationPeriod is how long a key signs JWT-SVIDs before it is replaced.
+	RotationPeriod time.Duration
+	// CheckInterval is how often the keys are reloaded.
+	CheckInterval time.Duration
+	// PublishLead is how long a new key is published before it signs, so that every replica publishes it, and the
+	// clients caching the keys fetch it, by then. It defaults to CheckInterval plus defaultJWTSVIDKeyPublishLead.
+	PublishLead time.Duration
+	// TTL is the maximum validity of the JWT-SVIDs. A replaced key is published for this long, until the JWT-SVIDs
+	// it signed expire.
+	TTL time.Duration
+}
+
+// storedJWTSVIDKey is a key as stored in JWTSVIDKeysSecret.
+type storedJWTSVIDKey struct {
+	// Key is the PEM encoded PKCS#8 private key.
+	Key string `json:"key"`
+	// ActiveAt is when the key starts signing.
+	ActiveAt time.Time `json:"activeAt"`
+}
+
+// jwtSVIDKey is a key signing JWT-SVIDs, with its public JWK.
+type jwtSVIDKey struct {
+	public   jose.JSONWebKey
+	signer   crypto.Signer
+	activeAt time.Time
+}
+
+// JWTSVIDKeys holds the keys signing the JWT-SVIDs, and rotates them. The keys are ordered by activation: the last
+// active one signs, the next one is published ahead of its activation, and the previous ones are published until the
+// JWT-SVIDs they signed expire.
+type JWTSVIDKeys struct {
+	config JWTSVIDKeyConfig
+	now    func() time.Time
+
+	mu     sync.RWMutex
+	keys   []jwtSVIDKey
+	stored []storedJWTSVIDKey
+	synced bool
+}
+
+// NewJWTSVIDKeys creates the keys signing the JWT-SVIDs. They are loaded, or created, by Run.
+func NewJWTSVIDKeys(config JWTSVIDKeyConfig) *JWTSVIDKeys {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = time.Minute
+	}
+	if config.PublishLead <= 0 {
+		config.PublishLead = config.CheckInterval + defaultJWTSVIDKeyPublishLead
+	}
+	if config.RotationPeriod < 2*config.PublishLead {
+		serverCaLog.Warnf("the JWT-SVID key rotation period %v is too short, using %v", config.RotationPeriod, 2*config.PublishLead)
+		config.RotationPeriod = 2 * config.PublishLead
+	}
+	return &JWTSVIDKeys{config: config, now: time.Now}
+}
+
+// Run loads and rotates the keys every CheckInterval, until stop is closed.
+func (k *JWTSVIDKeys) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(k.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := k.Refresh(context.Background()); err != nil {
+			serverCaLog.Errorf("failed to refresh the JWT-SVID signing keys: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// HasSynced returns true once the keys have been loaded.
+func (k *JWTSVIDKeys) HasSynced() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.synced
+}
+
+// Refresh loads the keys, adds the next key once the current one is due for rotation, and removes the keys no longer
+// needed to verify unexpired JWT-SVIDs.
+func (k *JWTSVIDKeys) Refresh(ctx context.Context) error {
+	now := k.now()
+	rotate := func(stored []storedJWTSVIDKey) ([]storedJWTSVIDKey, bool, error) {
+		changed := false
+		if len(stored) == 0 {
+			// No JWT-SVID was signed yet, so the first key does not need to be published ahead.
+			key, err := newStoredJWTSVIDKey(now)
+			if err != nil {
+				return nil, false, err
+			}
+			return []storedJWTSVIDKey{key}, true, nil
+		}
+		if last := stored[len(stored)-1]; !now.Before(last.ActiveAt.Add(k.config.RotationPeriod - k.config.PublishLead)) {
+			key, err := newStoredJWTSVIDKey(maxTime(now, last.ActiveAt).Add(k.config.PublishLead))
+			if err != nil {
+				return nil, false, err
+			}
+			stored = append(slices.Clone(stored), key)
+			changed = true
+		}
+		// A key is no longer needed once the JWT-SVIDs it signed expired, TTL after the next key became active.
+		for len(stored) > 1 && stored[1].ActiveAt.Add(k.config.TTL).Before(now) {
+			stored = stored[1:]
+			changed = true
+		}
+		return stored, changed, nil
+	}
+	var stored []storedJWTSVIDKey
+	var err error
+	if k.config.Client == nil {
+		k.mu.RLock()
+		current := k.stored
+		k.mu.RUnlock()
+		stored, _, err = rotate(current)
+	} else {
+		stored, err = k.update(ctx, rotate)
+	}
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWTSVIDKeys(stored)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.stored, k.keys, k.synced = stored, keys, true
+	return nil
+}
+
+// update applies fn to the keys in the secret, and writes them back if fn reports a change. The secret is created if
+// needed, and the update retried if another replica changed it concurrently. The resulting keys are returned.
+func (k *JWTSVIDKeys) update(ctx context.Context,
+	fn func([]storedJWTSVIDKey) ([]storedJWTSVIDKey, bool, error),
+) ([]storedJWTSVIDKey, error) {
+	secrets := k.config.Client.Secrets(k.config.Namespace)
+	for attempt := 0; ; attempt++ {
+		secret, err := secrets.Get(ctx, JWTSVIDKeysSecret, metav1.GetOptions{})
+		if apierror.IsNotFound(err) {
+			secret = nil
+		} else if err != nil {
+			return nil, err
+		}
+		var current []storedJWTSVIDKey
+		if secret != nil && len(secret.Data[JWTSVIDKeysFile]) > 0 {
+			if err := json.Unmarshal(secret.Data[JWTSVIDKeysFile], &current); err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", JWTSVIDKeysFile, err)
+			}
+		}
+		updated, changed, err := fn(current)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return updated, nil
+		}
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return nil, err
+		}
+		if secret == nil {
+			_, err = secrets.Create(ctx, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: JWTSVIDKeysSecret, Namespace: k.config.Namespace},
+				Data:       map[string][]byte{JWTSVIDKeysFile: data},
+			}, metav1.CreateOptions{})
+		} else {
+			secret = secret.DeepCopy()
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[JWTSVIDKeysFile] = data
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if (apierror.IsConflict(err) || apierror.IsAlreadyExists(err)) && attempt < maxJWTSVIDKeyConflictRetries {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+}
+
+// signingKey returns the public JWK of the active key, and its signer.
+func (k *JWTSVIDKeys) signingKey() (jose.JSONWebKey, crypto.Signer, error) {
+	now := k.now()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if !k.keys[i].activeAt.After(now) {
+			return k.keys[i].public, k.keys[i].signer, nil
+		}
+	}
+	return jose.JSONWebKey{}, nil, fmt.Errorf("no JWT-SVID signing key is active")
+}
+
+// publicKeys returns the public JWKs of all the keys, including the next and the previous ones.
+func (k *JWTSVIDKeys) publicKeys() []jose.JSONWebKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	keys := make([]jose.JSONWebKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key.public)
+	}
+	return keys
+}
+
+func newStoredJWTSVIDKey(activeAt time.Time) (storedJWTSVIDKey, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return storedJWTSVIDKey{}, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return storedJWTSVIDKey{}, err
+	}
+	return storedJWTSVIDKey{
+		Key:      string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		ActiveAt: activeAt,
+	}, nil
+}
+
+func parseJWTSVIDKeys(stored []storedJWTSVIDKey) ([]jwtSVIDKey, error) {
+	keys := make([]jwtSVIDKey, 0, len(stored))
+	for _, s := range stored {
+		block, _ := pem.Decode([]byte(s.Key))
+		if block == nil {
+			return nil, fmt.Errorf("invalid JWT-SVID signing key activated at %v", s.ActiveAt)
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT-SVID signing key activated at %v: %v", s.ActiveAt, err)
+		}
+		signer, ok := priv.(*ecdsa.PrivateKey)
+		if !ok || signer.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("the JWT-SVID signing key activated at %v is not a P-256 ECDSA key", s.ActiveAt)
+		}
+		public := jose.JSONWebKey{
+			Key:       signer.Public(),
+			Algorithm: string(jose.ES256),
+			Use:       jwtSVIDKeyUse,
+		}
+		thumbprint, err := public.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		public.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+		keys = append(keys, jwtSVIDKey{public: public, signer: signer, activeAt: s.ActiveAt})
+	}
+	return keys, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -20,10 +20,12 @@ import (
 
 const (
 	errorlabel = "error"
+	rulelabel  = "rule"
 )
 
 var (
 	errorTag = monitoring.CreateLabel(errorlabel)
+	ruleTag  = monitoring.CreateLabel(rulelabel)
 
 	csrCounts = monitoring.NewSum(
 		"citadel_server_csr_count",
@@ -55,6 +57,16 @@ var (
 		"The number of certificates issuances that have succeeded.",
 	)
 
+	policyViolationCounts = monitoring.NewSum(
+		"citadel_server_csr_policy_violation_count",
+		"The number of CSRs denied by the CSR policy.",
+	)
+
+	jwtSVIDCounts = monitoring.NewSum(
+		"citadel_server_jwt_svid_count",
+		"The number of JWT-SVIDs issued by Citadel server.",
+	)
+
 	rootCertExpiryTimestamp = monitoring.NewGauge(
 		"citadel_server_root_cert_expiry_timestamp",
 		"The unix timestamp, in seconds, when the root cert will expire.",
@@ -83,6 +95,7 @@ type monitoringMetrics struct {
 	CSRError          monitoring.Metric
 	IDExtractionError monitoring.Metric
 	certSignErrors    monitoring.Metric
+	policyViolations  monitoring.Metric
 }
 
 // newMonitoringMetrics creates a new monitoringMetrics.
@@ -94,9 +107,14 @@ func newMonitoringMetrics() monitoringMetrics {
 		CSRError:          csrParsingErrorCounts,
 		IDExtractionError: idExtractionErrorCounts,
 		certSignErrors:    certSignErrorCounts,
+		policyViolations:  policyViolationCounts,
 	}
 }
 
 func (m *monitoringMetrics) GetCertSignError(err string) monitoring.Metric {
 	return m.certSignErrors.With(errorTag.Value(err))
 }
+
+func (m *monitoringMetrics) GetPolicyViolation(rule string) monitoring.Metric {
+	return m.policyViolations.With(ruleTag.Value(rule))
+}
@@ -0,0 +1,375 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy restricts the certificates issued by the Istio CA, beyond the check that the requested identities
+// are the ones of the caller.
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/spiffe"
+)
+
+const (
+	// ConfigMapName is the ConfigMap holding the policy, in the Istiod namespace.
+	ConfigMapName = "istio-ca-policy"
+	// ConfigMapKey is the key of the YAML encoded Policy in ConfigMapName.
+	ConfigMapKey = "policy.yaml"
+	// ErrorDomain is the domain of the ErrorInfo detail of the gRPC errors reporting a Violation.
+	ErrorDomain = "ca.istio.io"
+)
+
+// The rules reported in a Violation.
+const (
+	RuleTTL            = "ttl"
+	RuleKeyAlgorithm   = "key-algorithm"
+	RuleKeySize        = "key-size"
+	RuleDeniedIdentity = "denied-identity"
+)
+
+var policyLog = log.RegisterScope("capolicy", "Istio CA CSR policy")
+
+// Policy restricts the certificates issued by the Istio CA. Identity patterns are either exact, a prefix ending with
+// "*", a suffix starting with "*", or "*" to match any identity.
+type Policy struct {
+	// MaxTTL is the maximum TTL of workload certificates. Unlimited if zero.
+	MaxTTL metav1.Duration `json:"maxTTL,omitempty"`
+	// NamespaceMaxTTL overrides MaxTTL for the identities of namespaces.
+	NamespaceMaxTTL map[string]metav1.Duration `json:"namespaceMaxTTL,omitempty"`
+	// KeyAlgorithms are the allowed key algorithms: RSA, ECDSA or ED25519. All are allowed if empty.
+	KeyAlgorithms []string `json:"keyAlgorithms,omitempty"`
+	// MinRSAKeySize is the minimum size in bits of RSA keys.
+	MinRSAKeySize int `json:"minRSAKeySize,omitempty"`
+	// ECDSACurves are the allowed ECDSA curves, such as P-256. All are allowed if empty.
+	ECDSACurves []string `json:"ecdsaCurves,omitempty"`
+	// DeniedIdentities are the patterns of the identities which are never issued certificates.
+	DeniedIdentities []string `json:"deniedIdentities,omitempty"`
+	// Additions are added to the certificates of matching identities.
+	Additions []Addition `json:"additions,omitempty"`
+}
+
+// Addition adds SANs and extensions to the certificates of identities. With an external CA (EXTERNAL_CA), the DNS
+// names are only in the certificates if they are in the CSR, and certificates with extensions are not issued.
+type Addition struct {
+	// Identities are the patterns of the identities the addition applies to.
+	Identities []string `json:"identities"`
+	// DNSNames are added to the SANs of the certificates.
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// Extensions are added to the certificates.
+	Extensions []Extension `json:"extensions,omitempty"`
+}
+
+// Extension is an X.509 extension.
+type Extension struct {
+	// OID is the dotted object identifier of the extension, such as 1.3.6.1.4.1.99999.1. The OIDs of the
+	// certificate extensions set by the CA, under 2.5.29, are rejected.
+	OID      string `json:"oid"`
+	Critical bool   `json:"critical,omitempty"`
+	// Value is the base64 encoded DER value of the extension.
+	Value string `json:"value"`
+}
+
+// Request is a certificate request evaluated by the policy.
+type Request struct {
+	// Identities are the SANs the certificate is issued for.
+	Identities []string
+	// TTL is the requested TTL, or zero for the default TTL of the CA.
+	TTL time.Duration
+	// PublicKey is the public key of the CSR.
+	PublicKey any
+}
+
+// Result holds what the policy adds to an allowed certificate.
+type Result struct {
+	DNSNames   []string
+	Extensions []pkix.Extension
+}
+
+// Violation is returned when a request is denied by the policy.
+type Violation struct {
+	// Rule is the rule which denied the request, such as RuleTTL.
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("CSR policy violation (%s): %s", v.Rule, v.Message)
+}
+
+// compiled is a validated Policy, with the extensions decoded.
+type compiled struct {
+	*Policy
+	extensions [][]pkix.Extension
+}
+
+// Engine evaluates requests against the current policy. All requests are allowed until a policy is set.
+type Engine struct {
+	// defaultTTL is the TTL of the certificates requested without one.
+	defaultTTL time.Duration
+	policy     atomic.Pointer[compiled]
+}
+
+// NewEngine returns an Engine without policy, for a CA issuing certificates with defaultTTL if none is requested.
+func NewEngine(defaultTTL time.Duration) *Engine {
+	return &Engine{defaultTTL: defaultTTL}
+}
+
+// Parse parses and validates a YAML encoded Policy.
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, err
+	}
+	if _, err := compile(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update sets the policy; nil allows all requests.
+func (e *Engine) Update(p *Policy) error {
+	if p == nil {
+		e.policy.Store(nil)
+		return nil
+	}
+	c, err := compile(p)
+	if err != nil {
+		return err
+	}
+	e.policy.Store(c)
+	return nil
+}
+
+// UpdateFromConfigMap sets the policy from ConfigMapName. If the ConfigMap is deleted, all requests are allowed; if
+// it is invalid, the previous policy is kept.
+func (e *Engine) UpdateFromConfigMap(cm *v1.ConfigMap) {
+	if cm == nil {
+		policyLog.Infof("ConfigMap %s removed, all CSRs are allowed", ConfigMapName)
+		_ = e.Update(nil)
+		return
+	}
+	p, err := Parse([]byte(cm.Data[ConfigMapKey]))
+	if err == nil {
+		err = e.Update(p)
+	}
+	if err != nil {
+		policyLog.Errorf("invalid CSR policy in ConfigMap %s/%s, keeping the previous one: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+	policyLog.Infof("CSR policy updated from ConfigMap %s/%s", cm.Namespace, cm.Name)
+}
+
+// Evaluate returns what the policy adds to the certificate of an allowed request, or a *Violation.
+func (e *Engine) Evaluate(req Request) (*Result, error) {
+	p := e.policy.Load()
+	if p == nil {
+		return &Result{}, nil
+	}
+	if err := p.checkIdentities(req); err != nil {
+		return nil, err
+	}
+	if err := p.checkTTL(req, e.defaultTTL); err != nil {
+		return nil, err
+	}
+	if err := p.checkKey(req.PublicKey); err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	for i, a := range p.Additions {
+		if !matchesAny(a.Identities, req.Identities) {
+			continue
+		}
+		res.DNSNames = append(res.DNSNames, a.DNSNames...)
+		res.Extensions = append(res.Extensions, p.extensions[i]...)
+	}
+	return res, nil
+}
+
+// EvaluateJWT returns a *Violation if a JWT-SVID request is denied by the policy. Only the denied identities and the
+// maximum TTLs apply to JWT-SVIDs, which have no key and to which nothing is added.
+func (e *Engine) EvaluateJWT(req Request) error {
+	p := e.policy.Load()
+	if p == nil {
+		return nil
+	}
+	if err := p.checkIdentities(req); err != nil {
+		return err
+	}
+	return p.checkTTL(req, e.defaultTTL)
+}
+
+// checkIdentities denies the identities matching a denied identity pattern.
+func (p *compiled) checkIdentities(req Request) error {
+	for _, id := range req.Identities {
+		for _, pattern := range p.DeniedIdentities {
+			if matches(pattern, id) {
+				return &Violation{Rule: RuleDeniedIdentity, Message: fmt.Sprintf("identity %s is denied", id)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkTTL denies TTLs above the maximum of the namespace of the identities, or else the global one.
+func (p *compiled) checkTTL(req Request, defaultTTL time.Duration) error {
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	maxTTL := p.MaxTTL.Duration
+	overridden := false
+	for _, id := range req.Identities {
+		identity, err := spiffe.ParseIdentity(id)
+		if err != nil {
+			continue
+		}
+		// With identities in several namespaces, the lowest maximum applies.
+		if nsMax, f := p.NamespaceMaxTTL[identity.Namespace]; f && (!overridden || nsMax.Duration < maxTTL) {
+			maxTTL = nsMax.Duration
+			overridden = true
+		}
+	}
+	if maxTTL > 0 && ttl > maxTTL {
+		return &Violation{Rule: RuleTTL, Message: fmt.Sprintf("TTL %v is greater than the maximum %v", ttl, maxTTL)}
+	}
+	return nil
+}
+
+// checkKey denies the key algorithms, RSA key sizes and ECDSA curves not allowed.
+func (p *compiled) checkKey(pub any) error {
+	var alg string
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		alg = "RSA"
+		if p.MinRSAKeySize > 0 && k.N.BitLen() < p.MinRSAKeySize {
+			return &Violation{Rule: RuleKeySize, Message: fmt.Sprintf("RSA key size %d is less than %d", k.N.BitLen(), p.MinRSAKeySize)}
+		}
+	case *ecdsa.PublicKey:
+		alg = "ECDSA"
+		curve := k.Curve.Params().Name
+		if len(p.ECDSACurves) > 0 && !containsFold(p.ECDSACurves, curve) {
+			return &Violation{Rule: RuleKeySize, Message: fmt.Sprintf("ECDSA curve %s is not allowed", curve)}
+		}
+	case ed25519.PublicKey:
+		alg = "ED25519"
+	default:
+		return &Violation{Rule: RuleKeyAlgorithm, Message: fmt.Sprintf("unsupported key type %T", pub)}
+	}
+	if len(p.KeyAlgorithms) > 0 && !containsFold(p.KeyAlgorithms, alg) {
+		return &Violation{Rule: RuleKeyAlgorithm, Message: fmt.Sprintf("key algorithm %s is not allowed", alg)}
+	}
+	return nil
+}
+
+func compile(p *Policy) (*compiled, error) {
+	for _, alg := range p.KeyAlgorithms {
+		if !containsFold([]string{"RSA", "ECDSA", "ED25519"}, alg) {
+			return nil, fmt.Errorf("unknown key algorithm %q", alg)
+		}
+	}
+	c := &compiled{Policy: p}
+	for _, a := range p.Additions {
+		if len(a.Identities) == 0 {
+			return nil, fmt.Errorf("additions require at least one identity")
+		}
+		var exts []pkix.Extension
+		for _, e := range a.Extensions {
+			ext, err := e.decode()
+			if err != nil {
+				return nil, err
+			}
+			exts = append(exts, ext)
+		}
+		c.extensions = append(c.extensions, exts)
+	}
+	return c, nil
+}
+
+// idCE is the arc of the X.509 certificate extensions, 2.5.29.
+var idCE = asn1.ObjectIdentifier{2, 5, 29}
+
+func (e Extension) decode() (pkix.Extension, error) {
+	var oid asn1.ObjectIdentifier
+	for _, part := range strings.Split(e.OID, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return pkix.Extension{}, fmt.Errorf("invalid extension OID %q", e.OID)
+		}
+		oid = append(oid, n)
+	}
+	if len(oid) < 2 {
+		return pkix.Extension{}, fmt.Errorf("invalid extension OID %q", e.OID)
+	}
+	// The certificate extensions (id-ce), such as the SANs, basic constraints and key usages, are set by the CA.
+	// Adding them would duplicate or override those, and could make a workload certificate a CA certificate.
+	if len(oid) >= len(idCE) && oid[:len(idCE)].Equal(idCE) {
+		return pkix.Extension{}, fmt.Errorf("extension OID %q is reserved for the extensions set by the CA (%s.*)", e.OID, idCE)
+	}
+	value, err := base64.StdEncoding.DecodeString(e.Value)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("invalid value of extension %s: %v", e.OID, err)
+	}
+	return pkix.Extension{Id: oid, Critical: e.Critical, Value: value}, nil
+}
+
+// matches returns whether the identity matches the pattern: exact, prefix*, *suffix or *.
+func matches(pattern, id string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(id, strings.TrimSuffix(pattern, "*"))
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(id, strings.TrimPrefix(pattern, "*"))
+	default:
+		return pattern == id
+	}
+}
+
+func matchesAny(patterns, ids []string) bool {
+	for _, id := range ids {
+		for _, pattern := range patterns {
+			if matches(pattern, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
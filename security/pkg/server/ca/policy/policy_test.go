@@ -0,0 +1,195 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const (
+	appID      = "spiffe://cluster.local/ns/default/sa/app"
+	batchID    = "spiffe://cluster.local/ns/batch/sa/job"
+	policyYAML = `
+maxTTL: 24h
+namespaceMaxTTL:
+  batch: 1h
+keyAlgorithms: [RSA, ECDSA]
+minRSAKeySize: 2048
+ecdsaCurves: [P-256]
+deniedIdentities:
+- spiffe://cluster.local/ns/kube-system/*
+additions:
+- identities: ["*/sa/app"]
+  dnsNames: [app.example.com]
+  extensions:
+  - oid: 1.3.6.1.4.1.99999.1
+    value: BQA=
+`
+)
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(policyYAML))
+	assert.NoError(t, err)
+	e := NewEngine(time.Hour)
+	assert.NoError(t, e.Update(p))
+
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rsa1024, _ := rsa.GenerateKey(rand.Reader, 1024)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	cases := []struct {
+		name string
+		req  Request
+		rule string
+	}{
+		{name: "allowed", req: Request{Identities: []string{appID}, TTL: 12 * time.Hour, PublicKey: &p256.PublicKey}},
+		{name: "default ttl", req: Request{Identities: []string{batchID}, PublicKey: &p256.PublicKey}},
+		{name: "global max ttl", req: Request{Identities: []string{appID}, TTL: 48 * time.Hour, PublicKey: &p256.PublicKey}, rule: RuleTTL},
+		{name: "namespace max ttl", req: Request{Identities: []string{batchID}, TTL: 2 * time.Hour, PublicKey: &p256.PublicKey}, rule: RuleTTL},
+		{
+			name: "denied identity",
+			req:  Request{Identities: []string{"spiffe://cluster.local/ns/kube-system/sa/default"}, PublicKey: &p256.PublicKey},
+			rule: RuleDeniedIdentity,
+		},
+		{name: "ecdsa curve", req: Request{Identities: []string{appID}, PublicKey: &p384.PublicKey}, rule: RuleKeySize},
+		{name: "rsa key size", req: Request{Identities: []string{appID}, PublicKey: &rsa1024.PublicKey}, rule: RuleKeySize},
+		{name: "key algorithm", req: Request{Identities: []string{appID}, PublicKey: edPub}, rule: RuleKeyAlgorithm},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := e.Evaluate(c.req)
+			if c.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var v *Violation
+			assert.Equal(t, errors.As(err, &v), true)
+			assert.Equal(t, v.Rule, c.rule)
+		})
+	}
+}
+
+func TestEvaluateJWT(t *testing.T) {
+	p, err := Parse([]byte(policyYAML))
+	assert.NoError(t, err)
+	e := NewEngine(time.Hour)
+	assert.NoError(t, e.Update(p))
+
+	cases := []struct {
+		name string
+		req  Request
+		rule string
+	}{
+		// The key rules do not apply to JWT-SVIDs, which have none.
+		{name: "allowed", req: Request{Identities: []string{appID}, TTL: 5 * time.Minute}},
+		{name: "namespace max ttl", req: Request{Identities: []string{batchID}, TTL: 2 * time.Hour}, rule: RuleTTL},
+		{name: "denied identity", req: Request{Identities: []string{"spiffe://cluster.local/ns/kube-system/sa/default"}}, rule: RuleDeniedIdentity},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := e.EvaluateJWT(c.req)
+			if c.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var v *Violation
+			assert.Equal(t, errors.As(err, &v), true)
+			assert.Equal(t, v.Rule, c.rule)
+		})
+	}
+	assert.NoError(t, NewEngine(time.Hour).EvaluateJWT(Request{Identities: []string{appID}}))
+}
+
+func TestEvaluateAdditions(t *testing.T) {
+	p, err := Parse([]byte(policyYAML))
+	assert.NoError(t, err)
+	e := NewEngine(time.Hour)
+	assert.NoError(t, e.Update(p))
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	res, err := e.Evaluate(Request{Identities: []string{appID}, PublicKey: &key.PublicKey})
+	assert.NoError(t, err)
+	assert.Equal(t, res.DNSNames, []string{"app.example.com"})
+	assert.Equal(t, len(res.Extensions), 1)
+	assert.Equal(t, res.Extensions[0].Id.String(), "1.3.6.1.4.1.99999.1")
+	assert.Equal(t, res.Extensions[0].Value, []byte{5, 0})
+
+	res, err = e.Evaluate(Request{Identities: []string{batchID}, PublicKey: &key.PublicKey})
+	assert.NoError(t, err)
+	assert.Equal(t, len(res.DNSNames), 0)
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":     "maxTTLs: 1h",
+		"unknown algorithm": "keyAlgorithms: [DSA]",
+		"invalid oid":       "additions: [{identities: ['*'], extensions: [{oid: 'a.b', value: ''}]}]",
+		"san oid":           "additions: [{identities: ['*'], extensions: [{oid: 2.5.29.17, value: ''}]}]",
+		"basic constraints": "additions: [{identities: ['*'], extensions: [{oid: 2.5.29.19, value: ''}]}]",
+		"certificate arc":   "additions: [{identities: ['*'], extensions: [{oid: 2.5.29, value: ''}]}]",
+		"no identities":     "additions: [{dnsNames: [foo]}]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpdateReservedExtension(t *testing.T) {
+	e := NewEngine(time.Hour)
+	err := e.Update(&Policy{Additions: []Addition{{
+		Identities: []string{"*"},
+		Extensions: []Extension{{OID: "2.5.29.17", Value: "MAA="}},
+	}}})
+	assert.Error(t, err)
+	// Extensions under other arcs, even sharing a prefix, are allowed.
+	assert.NoError(t, e.Update(&Policy{Additions: []Addition{{
+		Identities: []string{"*"},
+		Extensions: []Extension{{OID: "2.5.290.1", Value: "MAA="}},
+	}}}))
+}
+
+func TestUpdateFromConfigMap(t *testing.T) {
+	e := NewEngine(time.Hour)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	req := Request{Identities: []string{appID}, TTL: 2 * time.Hour, PublicKey: &key.PublicKey}
+
+	e.UpdateFromConfigMap(&v1.ConfigMap{Data: map[string]string{ConfigMapKey: "maxTTL: 1h"}})
+	_, err := e.Evaluate(req)
+	assert.Error(t, err)
+
+	// An invalid policy keeps the previous one.
+	e.UpdateFromConfigMap(&v1.ConfigMap{Data: map[string]string{ConfigMapKey: "maxTTL: [1h]"}})
+	_, err = e.Evaluate(req)
+	assert.Error(t, err)
+
+	// Without the ConfigMap, all requests are allowed.
+	e.UpdateFromConfigMap(nil)
+	_, err = e.Evaluate(req)
+	assert.NoError(t, err)
+}
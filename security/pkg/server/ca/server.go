@@ -16,21 +16,30 @@ package ca
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"k8s.io/apimachinery/pkg/types"
 
 	pb "istio.io/api/security/v1alpha1"
 	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pkg/jwtsvidapi"
 	"istio.io/istio/pkg/kube/multicluster"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/pki/ca"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
+	"istio.io/istio/security/pkg/server/ca/policy"
 )
 
 var serverCaLog = log.RegisterScope("serverca", "Citadel server log")
@@ -49,12 +58,20 @@ type CertificateAuthority interface {
 // specified port.
 type Server struct {
 	pb.UnimplementedIstioCertificateServiceServer
+	jwtsvidapi.UnimplementedJWTSVIDServiceServer
 	monitoring     monitoringMetrics
 	Authenticators []security.Authenticator
 	ca             CertificateAuthority
 	serverCertTTL  time.Duration
+	jwtSVIDTTL     time.Duration
 
 	nodeAuthorizer *MulticlusterNodeAuthorizor
+	issuanceLog    *audit.Log
+
+	// Policy restricts the certificates issued, if set.
+	Policy *policy.Engine
+	// JWTSVIDKeys signs the JWT-SVIDs, if set.
+	JWTSVIDKeys *JWTSVIDKeys
 }
 
 type SaNode struct {
@@ -116,6 +133,12 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 		ForCA:      false,
 		CertSigner: certSigner,
 	}
+	if s.Policy != nil {
+		if err := s.evaluatePolicy(request, &certOpts); err != nil {
+			serverCaLog.Warnf("CSR denied for sans %v: %v", sans, err)
+			return nil, err
+		}
+	}
 	var signErr error
 	var cert []byte
 	var respCertChain []string
@@ -154,12 +177,123 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 		response.CertChain = append(response.CertChain, string(rootCertBytes))
 	}
 
+	// The certificate is only handed out once its issuance is recorded.
+	if err := s.recordIssuance(ctx, caller, response.CertChain, len(rootCertBytes) != 0, impersonatedIdentity != "", certSigner); err != nil {
+		serverCaLog.Errorf("failed to record issuance for sans %v: %v", sans, err)
+		return nil, status.Error(codes.Unavailable, "failed to record certificate issuance")
+	}
 	serverCaLog.Debugf("Responding with cert chain, %q", response.CertChain)
 	s.monitoring.Success.Increment()
 	serverCaLog.Debugf("CSR successfully signed, sans %v.", sans)
 	return response, nil
 }
 
+// evaluatePolicy denies the request if it violates the CSR policy, or else adds the DNS names and extensions of the
+// policy to certOpts. Violations are returned with an ErrorInfo detail, whose reason is the violated rule.
+func (s *Server) evaluatePolicy(request *pb.IstioCertificateRequest, certOpts *ca.CertOpts) error {
+	csr, err := util.ParsePemEncodedCSR([]byte(request.Csr))
+	if err != nil {
+		s.monitoring.CSRError.Increment()
+		return status.Errorf(codes.InvalidArgument, "CSR parsing error (%v)", err)
+	}
+	res, err := s.Policy.Evaluate(policy.Request{
+		Identities: certOpts.SubjectIDs,
+		TTL:        certOpts.TTL,
+		PublicKey:  csr.PublicKey,
+	})
+	if err != nil {
+		return s.policyError(err)
+	}
+	if len(res.DNSNames) > 0 {
+		certOpts.SubjectIDs = append(append([]string{}, certOpts.SubjectIDs...), res.DNSNames...)
+	}
+	certOpts.Extensions = res.Extensions
+	return nil
+}
+
+// policyError returns the status of a request denied by the CSR policy. Violations are returned with an ErrorInfo
+// detail, whose reason is the violated rule.
+func (s *Server) policyError(err error) error {
+	v, ok := err.(*policy.Violation)
+	if !ok {
+		return status.Errorf(codes.Internal, "CSR policy error (%v)", err)
+	}
+	s.monitoring.GetPolicyViolation(v.Rule).Increment()
+	st, detailErr := status.New(codes.PermissionDenied, v.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: v.Rule,
+		Domain: policy.ErrorDomain,
+	})
+	if detailErr != nil {
+		return status.Error(codes.PermissionDenied, v.Error())
+	}
+	return st.Err()
+}
+
+// recordIssuance writes the issued leaf certificate, the first of the chain, to the issuance audit log. The SANs are
+// those of the certificate, which include the DNS names added by the CSR policy. If hasRoots is set, the last element
+// of the chain holds the root certificates.
+func (s *Server) recordIssuance(ctx context.Context, caller *security.Caller, chain []string,
+	hasRoots bool, impersonated bool, certSigner string,
+) error {
+	if s.issuanceLog == nil {
+		return nil
+	}
+	leaf, err := util.ParsePemEncodedCertificate([]byte(chain[0]))
+	if err != nil {
+		return fmt.Errorf("failed to parse issued certificate: %v", err)
+	}
+	sans, err := util.ExtractIDs(leaf.Extensions)
+	if err != nil {
+		return fmt.Errorf("failed to read the SANs of issued certificate: %v", err)
+	}
+	r := audit.Record{
+		Serial:           leaf.SerialNumber.Text(16),
+		SANs:             sans,
+		NotBefore:        leaf.NotBefore,
+		NotAfter:         leaf.NotAfter,
+		IssuedAt:         time.Now(),
+		Authenticator:    caller.Authenticator,
+		CallerIdentities: caller.Identities,
+		ClientAddress:    security.GetConnectionAddress(ctx),
+		Impersonated:     impersonated,
+		CertSigner:       certSigner,
+	}
+	if caller.KubernetesInfo.PodName != "" {
+		r.CallerPod = caller.KubernetesInfo.PodNamespace + "/" + caller.KubernetesInfo.PodName
+	}
+	if hasRoots && len(chain) > 1 {
+		r.Root = rootFingerprint(leaf, chain[1:len(chain)-1], chain[len(chain)-1])
+	}
+	return s.issuanceLog.Record(ctx, r)
+}
+
+// rootFingerprint returns the fingerprint of the root the leaf certificate chains to through the intermediates, or an
+// empty string if it does not chain to any of the roots.
+func rootFingerprint(leaf *x509.Certificate, intermediatesPEM []string, rootsPEM string) string {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(rootsPEM)) {
+		return ""
+	}
+	intermediates := x509.NewCertPool()
+	for _, pem := range intermediatesPEM {
+		intermediates.AppendCertsFromPEM([]byte(pem))
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil || len(chains) == 0 {
+		return ""
+	}
+	return util.CertFingerprint(chains[0][len(chains[0])-1])
+}
+
+// IssuanceLog returns the audit log of the certificates issued by the server.
+func (s *Server) IssuanceLog() *audit.Log {
+	return s.issuanceLog
+}
+
 // RecordCertsExpiry updates the certificate-expiration related metrics given a new keycertbundle
 func RecordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 	// Expiry of the first root cert in trust bundle
@@ -187,6 +321,9 @@ func RecordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 // Register registers a GRPC server on the specified port.
 func (s *Server) Register(grpcServer *grpc.Server) {
 	pb.RegisterIstioCertificateServiceServer(grpcServer, s)
+	if features.EnableCAJWTSVID {
+		jwtsvidapi.RegisterJWTSVIDServiceServer(grpcServer, s)
+	}
 }
 
 // New creates a new instance of `IstioCAServiceServer`
@@ -204,9 +341,15 @@ func New(
 	server := &Server{
 		Authenticators: authenticators,
 		serverCertTTL:  ttl,
+		jwtSVIDTTL:     features.CAJWTSVIDTTL,
 		ca:             ca,
 		monitoring:     newMonitoringMetrics(),
 	}
+	issuanceLog, err := newIssuanceLog()
+	if err != nil {
+		return nil, err
+	}
+	server.issuanceLog = issuanceLog
 
 	if len(features.CATrustedNodeAccounts) > 0 {
 		// TODO: do we need some way to delayed readiness until this is synced? Probably
@@ -215,3 +358,39 @@ func New(
 	}
 	return server, nil
 }
+
+// auditWriteTimeout bounds the time a certificate request waits for its issuance to be recorded.
+const auditWriteTimeout = 10 * time.Second
+
+// newIssuanceLog creates the issuance audit log with the sinks configured by CA_AUDIT_LOG_FILE and
+// CA_AUDIT_LOG_GRPC_ADDRESS. The history is seeded from the file, so it survives restarts.
+func newIssuanceLog() (*audit.Log, error) {
+	var sinks []audit.Sink
+	var history []audit.Record
+	if path := features.CAAuditLogFile; path != "" {
+		var err error
+		history, err = audit.ReadFile(path, features.CAAuditLogHistorySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA audit log %s: %v", path, err)
+		}
+		fs, err := audit.NewFileSink(path, int64(features.CAAuditLogFileMaxSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CA audit log %s: %v", path, err)
+		}
+		sinks = append(sinks, fs)
+	}
+	if addr := features.CAAuditLogGRPCAddress; addr != "" {
+		creds := insecure.NewCredentials()
+		if !features.CAAuditLogGRPCInsecure {
+			creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		}
+		gs, err := audit.NewGRPCSink(addr, creds, 5*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CA audit sink for %s: %v", addr, err)
+		}
+		sinks = append(sinks, gs)
+	}
+	l := audit.NewLog(features.CAAuditLogHistorySize, auditWriteTimeout, sinks...)
+	l.Seed(history)
+	return l, nil
+}
@@ -21,16 +21,20 @@ import (
 	"crypto/x509/pkix"
 	"fmt"
 	"net"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -46,7 +50,9 @@ import (
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
 	caerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/istio/security/pkg/server/ca/audit"
 	"istio.io/istio/security/pkg/server/ca/authenticate"
+	"istio.io/istio/security/pkg/server/ca/policy"
 )
 
 const (
@@ -315,7 +321,163 @@ func TestCreateCertificate(t *testing.T) {
 	}
 }
 
+func TestCreateCertificatePolicy(t *testing.T) {
+	const id = "spiffe://cluster.local/ns/default/sa/app"
+	csr, _, err := util.GenCSR(util.CertOptions{Host: id, ECSigAlg: util.EcdsaSigAlg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := policy.NewEngine(time.Hour)
+	if err := engine.Update(&policy.Policy{
+		MaxTTL:           metav1.Duration{Duration: 2 * time.Hour},
+		DeniedIdentities: []string{"spiffe://cluster.local/ns/denied/*"},
+		Additions:        []policy.Addition{{Identities: []string{id}, DNSNames: []string{"app.example.com"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	ctx := peer.NewContext(context.Background(), p)
+
+	cases := []struct {
+		name       string
+		identity   string
+		ttl        time.Duration
+		csr        string
+		code       codes.Code
+		rule       string
+		receivedID []string
+	}{
+		{
+			name:       "allowed with additions",
+			identity:   id,
+			ttl:        time.Hour,
+			csr:        string(csr),
+			code:       codes.OK,
+			receivedID: []string{id, "app.example.com"},
+		},
+		{name: "ttl too long", identity: id, ttl: 3 * time.Hour, csr: string(csr), code: codes.PermissionDenied, rule: policy.RuleTTL},
+		{
+			name:     "denied identity",
+			identity: "spiffe://cluster.local/ns/denied/sa/app",
+			csr:      string(csr),
+			code:     codes.PermissionDenied,
+			rule:     policy.RuleDeniedIdentity,
+		},
+		{name: "invalid CSR", identity: id, csr: "dumb CSR", code: codes.InvalidArgument},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mt := monitortest.New(t)
+			fakeCA := &mockca.FakeCA{
+				SignedCert:    []byte(testCert),
+				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
+			}
+			server := &Server{
+				ca:             fakeCA,
+				Authenticators: []security.Authenticator{&mockAuthenticator{identities: []string{c.identity}}},
+				monitoring:     newMonitoringMetrics(),
+				Policy:         engine,
+			}
+			_, err := server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: c.csr, ValidityDuration: int64(c.ttl.Seconds())})
+			s := status.Convert(err)
+			if s.Code() != c.code {
+				t.Fatalf("expected code %v, got %v: %s", c.code, s.Code(), s.Message())
+			}
+			if c.rule != "" {
+				if len(s.Details()) != 1 || s.Details()[0].(*errdetails.ErrorInfo).Reason != c.rule {
+					t.Fatalf("expected violation of rule %s, got details %v", c.rule, s.Details())
+				}
+				mt.Assert(policyViolationCounts.Name(), map[string]string{rulelabel: c.rule}, monitortest.Exactly(1))
+			}
+			if c.receivedID != nil && !slices.Equal(fakeCA.ReceivedIDs, c.receivedID) {
+				t.Fatalf("expected SANs %v, got %v", c.receivedID, fakeCA.ReceivedIDs)
+			}
+		})
+	}
+}
+
+func TestCreateCertificateIssuanceLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	test.SetForTest(t, &features.CAAuditLogFile, path)
+	// The certificate has a DNS SAN on top of the caller identity, such as one added by the CSR policy.
+	signedCert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/default/sa/default,reviews.default.svc",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := New(&mockca.FakeCA{
+		SignedCert:    signedCert,
+		KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
+	}, time.Hour, []security.Authenticator{&mockAuthenticator{
+		identities:     []string{"spiffe://cluster.local/ns/default/sa/default"},
+		kubernetesInfo: security.KubernetesInfo{PodName: "app", PodNamespace: "default"},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.IssuanceLog().Close() })
+
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	if _, err := server.CreateCertificate(peer.NewContext(context.Background(), p), &pb.IstioCertificateRequest{Csr: "dumb CSR"}); err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := util.ParsePemEncodedCertificate(signedCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := server.IssuanceLog().Query(audit.Filter{})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 issuance record, got %v", records)
+	}
+	got := records[0]
+	if got.Serial != leaf.SerialNumber.Text(16) || !got.NotAfter.Equal(leaf.NotAfter) || !got.NotBefore.Equal(leaf.NotBefore) {
+		t.Errorf("record does not match the issued certificate: %+v", got)
+	}
+	if got.Authenticator != "mockAuthenticator" || got.CallerPod != "default/app" || got.ClientAddress != "192.168.1.1" {
+		t.Errorf("record does not match the caller: %+v", got)
+	}
+	if !slices.Equal(got.SANs, []string{"spiffe://cluster.local/ns/default/sa/default", "reviews.default.svc"}) {
+		t.Errorf("unexpected SANs %v", got.SANs)
+	}
+
+	// The record is persisted before the certificate is returned, and restored on restart.
+	if err := server.IssuanceLog().Close(); err != nil {
+		t.Fatal(err)
+	}
+	persisted, err := audit.ReadFile(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persisted) != 1 || persisted[0].Serial != got.Serial {
+		t.Errorf("expected the record to be persisted, got %v", persisted)
+	}
+	restarted, err := newIssuanceLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if records := restarted.Query(audit.Filter{Serial: got.Serial}); len(records) != 1 {
+		t.Errorf("expected the history to be restored, got %v", records)
+	}
+}
+
 func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
+	// The issued certificate is parsed to record its issuance.
+	signedCert, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/ns-a/sa/sa-a",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafCert := string(signedCert)
 	allowZtunnel := sets.Set[types.NamespacedName]{
 		{Name: "ztunnel", Namespace: "istio-system"}: {},
 	}
@@ -388,10 +550,10 @@ func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 				kubernetesInfo: ztunnelCaller,
 			}},
 			ca: &mockca.FakeCA{
-				SignedCert:    []byte(testCert),
+				SignedCert:    []byte(leafCert),
 				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
 			},
-			certChain:           []string{testCert, testCertChain, testRootCert},
+			certChain:           []string{leafCert, testCertChain, testRootCert},
 			trustedNodeAccounts: sets.Set[types.NamespacedName]{},
 			code:                codes.Unauthenticated,
 		},
@@ -402,10 +564,10 @@ func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 				kubernetesInfo: ztunnelCaller,
 			}},
 			ca: &mockca.FakeCA{
-				SignedCert:    []byte(testCert),
+				SignedCert:    []byte(leafCert),
 				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
 			},
-			certChain:           []string{testCert, testCertChain, testRootCert},
+			certChain:           []string{leafCert, testCertChain, testRootCert},
 			pods:                []pod{ztunnelPod, podOtherNode},
 			impersonatePod:      podOtherNode,
 			callerClusterID:     cluster.ID("fake"),
@@ -419,10 +581,10 @@ func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 				kubernetesInfo: ztunnelCaller,
 			}},
 			ca: &mockca.FakeCA{
-				SignedCert:    []byte(testCert),
+				SignedCert:    []byte(leafCert),
 				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
 			},
-			certChain:           []string{testCert, testCertChain, testRootCert},
+			certChain:           []string{leafCert, testCertChain, testRootCert},
 			pods:                []pod{ztunnelPod, podSameNode},
 			impersonatePod:      podSameNode,
 			callerClusterID:     cluster.ID("fake"),
@@ -436,10 +598,10 @@ func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 				kubernetesInfo: ztunnelCaller,
 			}},
 			ca: &mockca.FakeCA{
-				SignedCert:    []byte(testCert),
+				SignedCert:    []byte(leafCert),
 				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
 			},
-			certChain:           []string{testCert, testCertChain, testRootCert},
+			certChain:           []string{leafCert, testCertChain, testRootCert},
 			pods:                []pod{ztunnelPod},
 			impersonatePod:      podSameNodeRemote,
 			callerClusterID:     cluster.ID("fake"),
@@ -455,10 +617,10 @@ func TestCreateCertificateE2EWithImpersonateIdentity(t *testing.T) {
 				kubernetesInfo: ztunnelCallerRemote,
 			}},
 			ca: &mockca.FakeCA{
-				SignedCert:    []byte(testCert),
+				SignedCert:    []byte(leafCert),
 				KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, []byte(testCertChain), []byte(testRootCert), nil),
 			},
-			certChain:           []string{testCert, testCertChain, testRootCert},
+			certChain:           []string{leafCert, testCertChain, testRootCert},
 			pods:                []pod{ztunnelPod, podSameNode},
 			impersonatePod:      podSameNodeRemote,
 			callerClusterID:     cluster.ID("fake-remote"),
@@ -650,3 +812,44 @@ func TestRecordCertsExipryMetrics(t *testing.T) {
 		mt.Assert(certChainExpirySeconds.Name(), nil, monitortest.AlmostEquals(certTTL.Seconds(), eps))
 	})
 }
+
+func TestRootFingerprint(t *testing.T) {
+	genRoot := func() ([]byte, *x509.Certificate, []byte) {
+		certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+			Org: "root", TTL: time.Hour, IsCA: true, IsSelfSigned: true, ECSigAlg: util.EcdsaSigAlg,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certPEM, cert, keyPEM
+	}
+	rootPEM, root, rootKeyPEM := genRoot()
+	otherPEM, _, _ := genRoot()
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host: "spiffe://cluster.local/ns/default/sa/default", TTL: time.Hour, SignerCert: root, SignerPriv: rootKey,
+		ECSigAlg: util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := util.ParsePemEncodedCertificate(leafPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The root is found in a bundle of several roots.
+	if got := rootFingerprint(leaf, nil, string(otherPEM)+string(rootPEM)); got != util.CertFingerprint(root) {
+		t.Errorf("expected the fingerprint of the signing root, got %q", got)
+	}
+	if got := rootFingerprint(leaf, nil, string(otherPEM)); got != "" {
+		t.Errorf("expected no fingerprint for an unrelated root, got %q", got)
+	}
+}
@@ -31,6 +31,16 @@ type Rule struct {
 	chain  string
 	table  string
 	params []string
+	// reason records why the rule was added. It is never rendered into the rule itself.
+	reason string
+}
+
+// ExplainedRule is a single rule as it would be applied, along with the reason it exists.
+type ExplainedRule struct {
+	Table  string `json:"table"`
+	Chain  string `json:"chain"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // Rules represents iptables for V4 and V6
@@ -41,8 +51,9 @@ type Rules struct {
 
 // IptablesRuleBuilder is an implementation for IptablesRuleBuilder interface
 type IptablesRuleBuilder struct {
-	rules Rules
-	cfg   *config.Config
+	rules  Rules
+	cfg    *config.Config
+	reason string
 }
 
 // NewIptablesRuleBuilder creates a new IptablesRuleBuilder
@@ -59,6 +70,13 @@ func NewIptablesRuleBuilder(cfg *config.Config) *IptablesRuleBuilder {
 	}
 }
 
+// WithReason sets the reason attached to every rule added after this call, until it is called again.
+// Reasons do not affect the generated rules; they are only surfaced by ExplainV4 and ExplainV6.
+func (rb *IptablesRuleBuilder) WithReason(reason string) *IptablesRuleBuilder {
+	rb.reason = reason
+	return rb
+}
+
 func (rb *IptablesRuleBuilder) InsertRule(chain string, table string, position int, params ...string) *IptablesRuleBuilder {
 	rb.InsertRuleV4(chain, table, position, params...)
 	rb.InsertRuleV6(chain, table, position, params...)
@@ -71,6 +89,7 @@ func (rb *IptablesRuleBuilder) insertInternal(ipt *[]Rule, chain string, table s
 		chain:  chain,
 		table:  table,
 		params: append([]string{"-I", chain, fmt.Sprint(position)}, rules...),
+		reason: rb.reason,
 	})
 	idx := indexOf("-j", params)
 	if idx < 0 && !strings.HasPrefix(chain, "ISTIO_") {
@@ -109,6 +128,7 @@ func (rb *IptablesRuleBuilder) appendInternal(ipt *[]Rule, chain string, table s
 		chain:  chain,
 		table:  table,
 		params: append([]string{"-A", chain}, rules...),
+		reason: rb.reason,
 	})
 	return rb
 }
@@ -310,6 +330,30 @@ func (rb *IptablesRuleBuilder) BuildV6Restore() string {
 	return rb.buildRestore(rb.rules.rulesv6)
 }
 
+func (rb *IptablesRuleBuilder) explainRules(rules []Rule) []ExplainedRule {
+	output := make([]ExplainedRule, 0, len(rules))
+	for _, r := range rules {
+		output = append(output, ExplainedRule{
+			Table:  r.table,
+			Chain:  r.chain,
+			Rule:   strings.Join(r.params, " "),
+			Reason: r.reason,
+		})
+	}
+	return output
+}
+
+// ExplainV4 returns the IPv4 rules in the order they would be applied, each annotated with the reason
+// set via WithReason when it was added. Nothing is applied.
+func (rb *IptablesRuleBuilder) ExplainV4() []ExplainedRule {
+	return rb.explainRules(rb.rules.rulesv4)
+}
+
+// ExplainV6 is the IPv6 counterpart of ExplainV4.
+func (rb *IptablesRuleBuilder) ExplainV6() []ExplainedRule {
+	return rb.explainRules(rb.rules.rulesv6)
+}
+
 // GetStateFromSave function takes a string in iptables-restore format and returns a map of the tables, chains, and rules.
 // Note that if this function is used to parse iptables-save output, the rules may have changed since they were first applied
 // as rules do not necessarily undergo a round-trip through the kernel in the same form.
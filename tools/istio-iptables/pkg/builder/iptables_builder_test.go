@@ -442,3 +442,28 @@ func TestStateFromRestoreFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	iptables := NewIptablesRuleBuilder(&config.Config{EnableIPv6: true})
+	iptables.AppendRule("OUTPUT", "nat", "-j", "ISTIO_OUTPUT")
+	iptables.WithReason("redirect to the proxy")
+	iptables.AppendRuleV4("ISTIO_OUTPUT", "nat", "-d", "10.0.0.0/8", "-j", "ISTIO_REDIRECT")
+	iptables.InsertRule("PREROUTING", "nat", 1, "-i", "virt0", "-j", "RETURN")
+
+	want := []ExplainedRule{
+		{Table: "nat", Chain: "OUTPUT", Rule: "-A OUTPUT -j ISTIO_OUTPUT"},
+		{Table: "nat", Chain: "ISTIO_OUTPUT", Rule: "-A ISTIO_OUTPUT -d 10.0.0.0/8 -j ISTIO_REDIRECT", Reason: "redirect to the proxy"},
+		{Table: "nat", Chain: "PREROUTING", Rule: "-I PREROUTING 1 -i virt0 -j RETURN", Reason: "redirect to the proxy"},
+	}
+	if got := iptables.ExplainV4(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplainV4() = %+v, want %+v", got, want)
+	}
+	wantV6 := []ExplainedRule{want[0], want[2]}
+	if got := iptables.ExplainV6(); !reflect.DeepEqual(got, wantV6) {
+		t.Errorf("ExplainV6() = %+v, want %+v", got, wantV6)
+	}
+	// Reasons must never leak into the rules themselves.
+	if got := iptables.BuildV4Restore(); strings.Contains(got, "proxy") {
+		t.Errorf("reason rendered into rules: %s", got)
+	}
+}
@@ -92,6 +92,8 @@ func (cfg *IptablesConfigurator) handleInboundPortsInclude() {
 	var table string
 	if cfg.cfg.InboundPortsInclude != "" {
 		if cfg.cfg.InboundInterceptionMode == "TPROXY" {
+			cfg.ruleBuilder.WithReason("TPROXY inbound capture: mark inbound packets so they are routed to the loopback interface, " +
+				"then deliver them to the inbound capture port " + cfg.cfg.InboundCapturePort)
 			// When using TPROXY, create a new chain for routing all inbound traffic to
 			// Envoy. Any packet entering this chain gets marked with the ${INBOUND_TPROXY_MARK} mark,
 			// so that they get routed to the loopback interface in order to get redirected to Envoy.
@@ -114,18 +116,21 @@ func (cfg *IptablesConfigurator) handleInboundPortsInclude() {
 		} else {
 			table = "nat"
 		}
+		cfg.ruleBuilder.WithReason("Send inbound TCP traffic through " + constants.ISTIOINBOUND + " to decide whether it is captured")
 		cfg.ruleBuilder.AppendRule("PREROUTING", table, "-p", "tcp",
 			"-j", constants.ISTIOINBOUND)
 
 		if cfg.cfg.InboundPortsInclude == "*" {
 			// Apply any user-specified port exclusions.
 			if cfg.cfg.InboundPortsExclude != "" {
+				cfg.ruleBuilder.WithReason("Inbound port is excluded from capture (--" + constants.LocalExcludePorts + ")")
 				for _, port := range config.Split(cfg.cfg.InboundPortsExclude) {
 					cfg.ruleBuilder.AppendRule(constants.ISTIOINBOUND, table, "-p", "tcp",
 						"--dport", port, "-j", "RETURN")
 				}
 			}
 			// Redirect remaining inbound traffic to Envoy.
+			cfg.ruleBuilder.WithReason("All inbound ports are captured (--" + constants.InboundPorts + "=*), redirect to the proxy")
 			if cfg.cfg.InboundInterceptionMode == "TPROXY" {
 				// If an inbound packet belongs to an established socket, route it to the
 				// loopback interface.
@@ -140,6 +145,7 @@ func (cfg *IptablesConfigurator) handleInboundPortsInclude() {
 			}
 		} else {
 			// User has specified a non-empty list of ports to be redirected to Envoy.
+			cfg.ruleBuilder.WithReason("Inbound port is explicitly captured (--" + constants.InboundPorts + "), redirect to the proxy")
 			for _, port := range config.Split(cfg.cfg.InboundPortsInclude) {
 				if cfg.cfg.InboundInterceptionMode == "TPROXY" {
 					cfg.ruleBuilder.AppendRule(constants.ISTIOINBOUND, "mangle", "-p", "tcp",
@@ -163,6 +169,8 @@ func (cfg *IptablesConfigurator) handleOutboundIncludeRules(
 	// Apply outbound IP inclusions.
 	if rangeInclude.IsWildcard {
 		// Wildcard specified. Redirect all remaining outbound traffic to Envoy.
+		cfg.ruleBuilder.WithReason("All outbound destinations are captured (--" + constants.ServiceCidr + "=*), " +
+			"redirect remaining outbound traffic, and traffic from rerouted virtual interfaces, to the proxy")
 		appendRule(constants.ISTIOOUTPUT, "nat", "-j", constants.ISTIOREDIRECT)
 		for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
 			insert(
@@ -170,6 +178,7 @@ func (cfg *IptablesConfigurator) handleOutboundIncludeRules(
 		}
 	} else if len(rangeInclude.CIDRs) > 0 {
 		// User has specified a non-empty list of cidrs to be redirected to Envoy.
+		cfg.ruleBuilder.WithReason("Outbound destination is explicitly captured (--" + constants.ServiceCidr + "), redirect to the proxy")
 		for _, cidr := range rangeInclude.CIDRs {
 			for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
 				insert("PREROUTING", "nat", 1, "-i", internalInterface,
@@ -182,12 +191,14 @@ func (cfg *IptablesConfigurator) handleOutboundIncludeRules(
 }
 
 func (cfg *IptablesConfigurator) shortCircuitKubeInternalInterface() {
+	cfg.ruleBuilder.WithReason("Traffic from a rerouted virtual interface (--" + constants.RerouteVirtualInterfaces + ") is not captured as inbound")
 	for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
 		cfg.ruleBuilder.InsertRule("PREROUTING", "nat", 1, "-i", internalInterface, "-j", "RETURN")
 	}
 }
 
 func (cfg *IptablesConfigurator) shortCircuitExcludeInterfaces() {
+	cfg.ruleBuilder.WithReason("Interface is excluded from capture (--" + constants.ExcludeInterfaces + ")")
 	for _, excludeInterface := range config.Split(cfg.cfg.ExcludeInterfaces) {
 		cfg.ruleBuilder.AppendRule(
 			"PREROUTING", "nat", "-i", excludeInterface, "-j", "RETURN")
@@ -216,6 +227,24 @@ func (cfg *IptablesConfigurator) Run() error {
 		}
 	}()
 
+	cfg.logConfig()
+	if err := cfg.buildRules(); err != nil {
+		return err
+	}
+	return cfg.executeCommands(&cfg.iptV, &cfg.ipt6V)
+}
+
+// Explain builds the rules Run would apply, without applying them, and returns them along with the reason each
+// rule exists.
+func (cfg *IptablesConfigurator) Explain() (v4 []builder.ExplainedRule, v6 []builder.ExplainedRule, err error) {
+	cfg.ruleBuilder = builder.NewIptablesRuleBuilder(cfg.cfg)
+	if err := cfg.buildRules(); err != nil {
+		return nil, nil, err
+	}
+	return cfg.ruleBuilder.ExplainV4(), cfg.ruleBuilder.ExplainV6(), nil
+}
+
+func (cfg *IptablesConfigurator) buildRules() error {
 	// Since OUTBOUND_IP_RANGES_EXCLUDE could carry ipv4 and ipv6 ranges
 	// need to split them in different arrays one for ipv4 and one for ipv6
 	// in order to not to fail
@@ -240,8 +269,6 @@ func (cfg *IptablesConfigurator) Run() error {
 		redirectDNS = false
 	}
 
-	cfg.logConfig()
-
 	cfg.shortCircuitExcludeInterfaces()
 
 	// Do not capture internal interface.
@@ -250,24 +277,28 @@ func (cfg *IptablesConfigurator) Run() error {
 	// Create a rule for invalid drop in PREROUTING chain in mangle table, so the iptables will drop the out of window packets instead of reset connection .
 	dropInvalid := cfg.cfg.DropInvalid
 	if dropInvalid {
+		cfg.ruleBuilder.WithReason("Drop packets conntrack considers INVALID (--" + constants.DropInvalid + ") instead of resetting the connection")
 		cfg.ruleBuilder.AppendRule("PREROUTING", "mangle", "-m", "conntrack", "--ctstate",
 			"INVALID", "-j", constants.ISTIODROP)
 		cfg.ruleBuilder.AppendRule(constants.ISTIODROP, "mangle", "-j", "DROP")
 	}
 
 	// Create a new chain for to hit tunnel port directly. Envoy will be listening on port acting as VPN tunnel.
+	cfg.ruleBuilder.WithReason("Inbound traffic to the tunnel port " + cfg.cfg.InboundTunnelPort + " reaches the proxy directly")
 	cfg.ruleBuilder.AppendRule(constants.ISTIOINBOUND, "nat", "-p", "tcp", "--dport",
 		cfg.cfg.InboundTunnelPort, "-j", "RETURN")
 
 	// Create a new chain for redirecting outbound traffic to the common Envoy port.
 	// In both chains, '-j RETURN' bypasses Envoy and '-j ISTIOREDIRECT'
 	// redirects to Envoy.
+	cfg.ruleBuilder.WithReason("Redirect captured outbound traffic to the proxy outbound port " + cfg.cfg.ProxyPort)
 	cfg.ruleBuilder.AppendRule(
 		constants.ISTIOREDIRECT, "nat", "-p", "tcp", "-j", "REDIRECT", "--to-ports", cfg.cfg.ProxyPort)
 
 	// Use this chain also for redirecting inbound traffic to the common Envoy port
 	// when not using TPROXY.
 
+	cfg.ruleBuilder.WithReason("Redirect captured inbound traffic to the proxy inbound port " + cfg.cfg.InboundCapturePort)
 	cfg.ruleBuilder.AppendRule(constants.ISTIOINREDIRECT, "nat", "-p", "tcp", "-j", "REDIRECT",
 		"--to-ports", cfg.cfg.InboundCapturePort)
 
@@ -277,10 +308,12 @@ func (cfg *IptablesConfigurator) Run() error {
 	// iptablesOrFail wrapper (like ufw). Current default is similar with 0.1
 	// Jump to the ISTIOOUTPUT chain from OUTPUT chain for all traffic
 	// NOTE: udp traffic will be optionally shunted (or no-op'd) within the ISTIOOUTPUT chain, we don't need a conditional jump here.
+	cfg.ruleBuilder.WithReason("Send outbound traffic through " + constants.ISTIOOUTPUT + " to decide whether it is captured")
 	cfg.ruleBuilder.AppendRule("OUTPUT", "nat", "-j", constants.ISTIOOUTPUT)
 
 	// Apply port based exclusions. Must be applied before connections back to self are redirected.
 	if cfg.cfg.OutboundPortsExclude != "" {
+		cfg.ruleBuilder.WithReason("Outbound port is excluded from capture (--" + constants.LocalOutboundPortsExclude + ")")
 		for _, port := range config.Split(cfg.cfg.OutboundPortsExclude) {
 			cfg.ruleBuilder.AppendRule(constants.ISTIOOUTPUT, "nat", "-p", "tcp", "--dport", port, "-j", "RETURN")
 			cfg.ruleBuilder.AppendRule(constants.ISTIOOUTPUT, "nat", "-p", "udp", "--dport", port, "-j", "RETURN")
@@ -288,6 +321,7 @@ func (cfg *IptablesConfigurator) Run() error {
 	}
 
 	// 127.0.0.6/::6 is bind connect from inbound passthrough cluster
+	cfg.ruleBuilder.WithReason("Traffic the proxy sends from 127.0.0.6/::6 is inbound passthrough to the app, do not capture it again")
 	cfg.ruleBuilder.AppendVersionedRule("127.0.0.6/32", "::6/128", constants.ISTIOOUTPUT, "nat",
 		"-o", "lo", "-s", constants.IPVersionSpecific, "-j", "RETURN")
 
@@ -295,6 +329,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		// Redirect app calls back to itself via Envoy when using the service VIP
 		// e.g. appN => Envoy (client) => Envoy (server) => appN.
 		// nolint: lll
+		cfg.ruleBuilder.WithReason("The proxy calling its own workload over loopback through a service VIP goes back through the proxy inbound")
 		if redirectDNS {
 			// When DNS is enabled, we skip this for port 53. This ensures we do not have:
 			// app => istio-agent => Envoy inbound => dns server
@@ -319,6 +354,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		// e.g. appN => appN by lo
 		// If loopback explicitly set via OutboundIPRangesInclude, then don't return.
 		if !ipv4RangesInclude.HasLoopBackIP && !ipv6RangesInclude.HasLoopBackIP {
+			cfg.ruleBuilder.WithReason("Loopback traffic from anything but the proxy is not captured")
 			if redirectDNS {
 				// Users may have a DNS server that is on localhost. In these cases, applications may
 				// send TCP traffic to the DNS server that we actually *do* want to intercept. To
@@ -338,6 +374,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		// Envoy for non-loopback traffic.
 		// Note that this rule is, unlike the others, protocol-independent - we want to unconditionally skip
 		// all UDP/TCP packets from Envoy, regardless of dest.
+		cfg.ruleBuilder.WithReason("Traffic sent by the proxy itself is never captured, to avoid loops")
 		cfg.ruleBuilder.AppendRule(constants.ISTIOOUTPUT, "nat",
 			"-m", "owner", "--uid-owner", uid, "-j", "RETURN")
 	}
@@ -345,6 +382,7 @@ func (cfg *IptablesConfigurator) Run() error {
 	for _, gid := range config.Split(cfg.cfg.ProxyGID) {
 		// Redirect app calls back to itself via Envoy when using the service VIP
 		// e.g. appN => Envoy (client) => Envoy (server) => appN.
+		cfg.ruleBuilder.WithReason("The proxy calling its own workload over loopback through a service VIP goes back through the proxy inbound")
 		cfg.ruleBuilder.AppendVersionedRule(cfg.cfg.HostIPv4LoopbackCidr, "::1/128", constants.ISTIOOUTPUT, "nat",
 			"-o", "lo",
 			"!", "-d", constants.IPVersionSpecific,
@@ -356,6 +394,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		// e.g. appN => appN by lo
 		// If loopback explicitly set via OutboundIPRangesInclude, then don't return.
 		if !ipv4RangesInclude.HasLoopBackIP && !ipv6RangesInclude.HasLoopBackIP {
+			cfg.ruleBuilder.WithReason("Loopback traffic from anything but the proxy is not captured")
 			if redirectDNS {
 				// Users may have a DNS server that is on localhost. In these cases, applications may
 				// send TCP traffic to the DNS server that we actually *do* want to intercept. To
@@ -376,6 +415,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		// Envoy for non-loopback traffic.
 		// Note that this rule is, unlike the others, protocol-independent - we want to unconditionally skip
 		// all UDP/TCP packets from Envoy, regardless of dest.
+		cfg.ruleBuilder.WithReason("Traffic sent by the proxy itself is never captured, to avoid loops")
 		cfg.ruleBuilder.AppendRule(constants.ISTIOOUTPUT, "nat", "-m", "owner", "--gid-owner", gid, "-j", "RETURN")
 	}
 
@@ -393,9 +433,11 @@ func (cfg *IptablesConfigurator) Run() error {
 	// Skip redirection for Envoy-aware applications and
 	// container-to-container traffic both of which explicitly use
 	// localhost.
+	cfg.ruleBuilder.WithReason("Traffic to localhost is not captured")
 	cfg.ruleBuilder.AppendVersionedRule(cfg.cfg.HostIPv4LoopbackCidr, "::1/128", constants.ISTIOOUTPUT, "nat",
 		"-d", constants.IPVersionSpecific, "-j", "RETURN")
 	// Apply outbound IPv4 exclusions. Must be applied before inclusions.
+	cfg.ruleBuilder.WithReason("Outbound destination is excluded from capture (--" + constants.ServiceExcludeCidr + ")")
 	for _, cidr := range ipv4RangesExclude.CIDRs {
 		cfg.ruleBuilder.AppendRuleV4(constants.ISTIOOUTPUT, "nat", "-d", cidr.String(), "-j", "RETURN")
 	}
@@ -409,6 +451,8 @@ func (cfg *IptablesConfigurator) Run() error {
 	cfg.handleOutboundIncludeRules(ipv6RangesInclude, cfg.ruleBuilder.AppendRuleV6, cfg.ruleBuilder.InsertRuleV6)
 
 	if cfg.cfg.InboundInterceptionMode == "TPROXY" {
+		cfg.ruleBuilder.WithReason("TPROXY inbound capture: carry the proxy packet mark across the connection so replies " +
+			"are routed back through the proxy, and do not capture proxy-to-app traffic again")
 		// save packet mark set by envoy.filters.listener.original_src as connection mark
 		cfg.ruleBuilder.AppendRule("PREROUTING", "mangle",
 			"-p", "tcp", "-m", "mark", "--mark", cfg.cfg.InboundTProxyMark, "-j", "CONNMARK", "--save-mark")
@@ -444,7 +488,7 @@ func (cfg *IptablesConfigurator) Run() error {
 		cfg.ruleBuilder.InsertRule(constants.ISTIOINBOUND, "mangle", 3,
 			"-p", "tcp", "-i", "lo", "-m", "mark", "!", "--mark", constants.OutboundMark, "-j", "RETURN")
 	}
-	return cfg.executeCommands(&cfg.iptV, &cfg.ipt6V)
+	return nil
 }
 
 // SetupDNSRedir is a helper function to tackle with DNS UDP specific operations.
@@ -454,6 +498,7 @@ func SetupDNSRedir(iptables *builder.IptablesRuleBuilder, proxyUID, proxyGID str
 ) {
 	// Uniquely for DNS (at this time) we need a jump in "raw:OUTPUT", so this jump is conditional on that setting.
 	// And, unlike nat/OUTPUT, we have no shared rules, so no need to do a 2-level jump at this time
+	iptables.WithReason("DNS capture is enabled, redirect DNS queries to the istio-agent DNS proxy on port " + constants.IstioAgentDNSListenerPort)
 	iptables.AppendRule("OUTPUT", "raw", "-j", constants.ISTIOOUTPUTDNS)
 
 	// Conditionally insert jumps for V6 and V4 - we may have DNS capture enabled for V4 servers but not V6, or vice versa.
@@ -538,6 +583,7 @@ func SetupDNSRedir(iptables *builder.IptablesRuleBuilder, proxyUID, proxyGID str
 func addDNSConntrackZones(
 	iptables *builder.IptablesRuleBuilder, proxyUID, proxyGID string, dnsServersV4 []string, dnsServersV6 []string, captureAllDNS bool,
 ) {
+	iptables.WithReason("Keep proxy-to-upstream and app-to-proxy DNS packets in separate conntrack zones, to avoid UDP conntrack races")
 	for _, uid := range config.Split(proxyUID) {
 		// Packets with dst port 53 from istio to zone 1. These are Istio calls to upstream resolvers
 		iptables.AppendRule(constants.ISTIOOUTPUTDNS, "raw", "-p", "udp", "--dport", "53", "-m", "owner", "--uid-owner", uid, "-j", "CT", "--zone", "1")
@@ -598,6 +644,7 @@ func addDNSConntrackZones(
 
 func (cfg *IptablesConfigurator) handleOutboundPortsInclude() {
 	if cfg.cfg.OutboundPortsInclude != "" {
+		cfg.ruleBuilder.WithReason("Outbound port is explicitly captured (--" + constants.OutboundPorts + "), redirect to the proxy")
 		for _, port := range config.Split(cfg.cfg.OutboundPortsInclude) {
 			cfg.ruleBuilder.AppendRule(
 				constants.ISTIOOUTPUT, "nat", "-p", "tcp", "--dport", port, "-j", constants.ISTIOREDIRECT)
@@ -606,6 +653,8 @@ func (cfg *IptablesConfigurator) handleOutboundPortsInclude() {
 }
 
 func (cfg *IptablesConfigurator) handleCaptureByOwnerGroup(filter config.InterceptFilter) {
+	cfg.ruleBuilder.WithReason("Outbound traffic from this group is not captured (" +
+		constants.OwnerGroupsInclude.Name + "/" + constants.OwnerGroupsExclude.Name + ")")
 	if filter.Except {
 		for _, group := range filter.Values {
 			cfg.ruleBuilder.AppendRule(constants.ISTIOOUTPUT, "nat",
@@ -290,3 +290,37 @@ func TestIptables(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	for _, tt := range getCommonTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := constructTestConfig()
+			tt.config(cfg)
+
+			ext := &dep.DependenciesStub{}
+			iptConfigurator, _ := NewIptablesConfigurator(cfg, ext)
+			v4, v6, err := iptConfigurator.Explain()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ext.ExecutedAll) != 0 {
+				t.Fatalf("explain must not apply anything, but ran %v", ext.ExecutedAll)
+			}
+
+			applied := &dep.DependenciesStub{}
+			iptConfigurator, _ = NewIptablesConfigurator(cfg, applied)
+			if err := iptConfigurator.Run(); err != nil {
+				t.Fatal(err)
+			}
+			appliedRules := strings.Join(applied.ExecutedAll, "\n")
+			for _, r := range append(v4, v6...) {
+				if r.Reason == "" {
+					t.Errorf("rule %q in %s has no reason", r.Rule, r.Table)
+				}
+				if !strings.Contains(appliedRules, r.Rule) {
+					t.Errorf("explained rule %q was not applied by Run", r.Rule)
+				}
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
+	"istio.io/istio/pkg/env"
 	"istio.io/istio/pkg/flag"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/tools/common/config"
@@ -205,6 +208,61 @@ Otherwise, this pod will need to be manually removed so that it is scheduled on
 	return cmd
 }
 
+// ConfigFromContainer returns the configuration istio-iptables runs with, given the arguments and environment of the
+// container running it, such as the istio-init container of an injected pod. Like the command, the environment is
+// applied first and the arguments override it. Settings read from the environment the container runs in, such as the
+// pod IP and DNS servers, are not filled in.
+func ConfigFromContainer(args []string, environ map[string]string) (*config.Config, error) {
+	cfg := config.DefaultConfig()
+	cmd := &cobra.Command{}
+	bindCmdlineFlags(cfg, cmd)
+	fs := cmd.Flags()
+	// Logging flags are registered by the command's caller, and do not matter here.
+	fs.ParseErrorsAllowlist.UnknownFlags = true
+
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if v, ok := environ[strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))]; ok && err == nil {
+			err = fs.Set(f.Name, v)
+		}
+	})
+	for name, envName := range map[string]string{
+		constants.RedirectDNS: "ISTIO_META_DNS_CAPTURE",
+		constants.DropInvalid: InvalidDropByIptables,
+		constants.DualStack:   "ISTIO_DUAL_STACK",
+	} {
+		if v, ok := environ[envName]; ok && err == nil {
+			err = fs.Set(name, v)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 0 && args[0] == "istio-iptables" {
+		args = args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	envOrDefault := func(v env.GenericVar[string]) string {
+		if value, ok := environ[v.Name]; ok {
+			return value
+		}
+		return v.DefaultValue
+	}
+	cfg.OwnerGroupsInclude = envOrDefault(constants.OwnerGroupsInclude)
+	cfg.OwnerGroupsExclude = envOrDefault(constants.OwnerGroupsExclude)
+	cfg.HostIPv4LoopbackCidr = envOrDefault(constants.HostIPv4LoopbackCidr)
+	if cfg.ProxyUID == "" {
+		cfg.ProxyUID = constants.DefaultProxyUID
+	}
+	if cfg.ProxyGID == "" {
+		cfg.ProxyGID = cfg.ProxyUID
+	}
+	return cfg, nil
+}
+
 type IptablesError struct {
 	Error    error
 	ExitCode int
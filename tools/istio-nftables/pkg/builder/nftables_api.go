@@ -22,13 +22,16 @@ import (
 )
 
 // NftablesAPI defines the interface for interacting with nftables.
-// It supports creating a transaction, running it, listing elements, and optionally dumping the config (mainly for testing).
+// It supports creating a transaction, running it, listing rules and elements, and optionally dumping the config (mainly for testing).
 type NftablesAPI interface {
 	NewTransaction() *knftables.Transaction
 	Run(ctx context.Context, tx *knftables.Transaction) error
 	Dump(tx *knftables.Transaction) string
 	// ListElements returns a list of the elements in a set or map. (objectType should be "set" or "map".)
 	ListElements(ctx context.Context, objectType, name string) ([]*knftables.Element, error)
+	// ListRules returns the rules in a chain of the table this was created for. Only the rule handles and
+	// comments are filled in, not the rule text.
+	ListRules(ctx context.Context, chain string) ([]*knftables.Rule, error)
 }
 
 // NftImpl is the real implementation of NftablesAPI using the actual knftables backend.
@@ -66,6 +69,11 @@ func (r *NftImpl) ListElements(ctx context.Context, objectType, name string) ([]
 	return r.nft.ListElements(ctx, objectType, name)
 }
 
+// ListRules returns the rules in a chain using the real knftables interface.
+func (r *NftImpl) ListRules(ctx context.Context, chain string) ([]*knftables.Rule, error) {
+	return r.nft.ListRules(ctx, chain)
+}
+
 // MockNftables is a mock implementation of NftablesAPI for use in unit tests.
 // It uses knftables.Fake to simulate nftables behavior without making changes to the system.
 type MockNftables struct {
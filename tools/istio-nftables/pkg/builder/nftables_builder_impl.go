@@ -15,8 +15,12 @@
 package builder
 
 import (
+	"fmt"
+
 	"sigs.k8s.io/knftables"
 
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/tools/common/config"
 	"istio.io/istio/tools/istio-nftables/pkg/constants"
 )
@@ -29,6 +33,17 @@ var IstioTableNames = []string{
 type NftablesRuleBuilder struct {
 	Rules map[string][]knftables.Rule
 	cfg   *config.Config
+	// reasons holds, per table, why each entry of Rules was added. It is index-aligned with Rules.
+	reasons map[string][]string
+	reason  string
+}
+
+// ExplainedRule is a single rule as it would be applied, along with the reason it exists.
+type ExplainedRule struct {
+	Table  string `json:"table"`
+	Chain  string `json:"chain"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // NewNftablesRuleBuilder creates a new rule builder with an empty rule list for each Istio table.
@@ -41,9 +56,50 @@ func NewNftablesRuleBuilder(cfg *config.Config) *NftablesRuleBuilder {
 		rules[table] = []knftables.Rule{}
 	}
 	return &NftablesRuleBuilder{
-		Rules: rules,
-		cfg:   cfg,
+		Rules:   rules,
+		cfg:     cfg,
+		reasons: make(map[string][]string),
+	}
+}
+
+// WithReason sets the reason attached to every rule added after this call, until it is called again.
+// Reasons do not affect the generated rules; they are only surfaced by Explain.
+func (rb *NftablesRuleBuilder) WithReason(reason string) *NftablesRuleBuilder {
+	rb.reason = reason
+	return rb
+}
+
+func (rb *NftablesRuleBuilder) addRule(rule knftables.Rule) {
+	rb.Rules[rule.Table] = append(rb.Rules[rule.Table], rule)
+	rb.reasons[rule.Table] = append(rb.reasons[rule.Table], rb.reason)
+}
+
+// Explain returns every rule in the builder, grouped by table in name order and otherwise in the order
+// they were added, each rendered as the nft command that would be applied and annotated with the reason
+// set via WithReason. Nothing is applied.
+func (rb *NftablesRuleBuilder) Explain() []ExplainedRule {
+	var output []ExplainedRule
+	for _, table := range slices.Sort(maps.Keys(rb.Rules)) {
+		for i, rule := range rb.Rules[table] {
+			var reason string
+			if i < len(rb.reasons[table]) {
+				reason = rb.reasons[table][i]
+			}
+			var cmd string
+			if rule.Index != nil {
+				cmd = fmt.Sprintf("insert rule %s %s %s index %d %s", rule.Family, rule.Table, rule.Chain, *rule.Index, rule.Rule)
+			} else {
+				cmd = fmt.Sprintf("add rule %s %s %s %s", rule.Family, rule.Table, rule.Chain, rule.Rule)
+			}
+			output = append(output, ExplainedRule{
+				Table:  table,
+				Chain:  rule.Chain,
+				Rule:   cmd,
+				Reason: reason,
+			})
+		}
 	}
+	return output
 }
 
 // InsertRule adds a rule at a specific position in the given chain and table.
@@ -55,7 +111,7 @@ func (rb *NftablesRuleBuilder) InsertRule(chain string, table string, position i
 		Rule:   knftables.Concat(params),
 		Index:  knftables.PtrTo(position),
 	}
-	rb.Rules[table] = append(rb.Rules[table], rule)
+	rb.addRule(rule)
 	return rb
 }
 
@@ -76,7 +132,7 @@ func (rb *NftablesRuleBuilder) AppendRule(chain string, table string, params ...
 		Family: knftables.InetFamily,
 		Rule:   knftables.Concat(params),
 	}
-	rb.Rules[table] = append(rb.Rules[table], rule)
+	rb.addRule(rule)
 	return rb
 }
 
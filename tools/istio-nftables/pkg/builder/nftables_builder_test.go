@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/knftables"
 
 	testutil "istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/tools/common/config"
 )
 
@@ -149,3 +150,26 @@ func TestBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	nft := NewNftablesRuleBuilder(&config.Config{})
+	nft.AppendRule(testChain, testTable, "counter", "jump", "istio-output")
+	nft.WithReason("redirect to the proxy")
+	nft.InsertRule(testChain, testTable, 0, "iifname", "virt0", "counter", "return")
+	// IPv6 is disabled, so this rule is not added, and must not shift the reasons of the others.
+	nft.AppendV6RuleIfSupported(testChain, testTable, "ip6 daddr", "::1/128", "counter", "return")
+	nft.AppendRule("istio-output", testTable, "ip daddr", "10.0.0.0/8", "counter", "jump", "istio-redirect")
+
+	want := []ExplainedRule{
+		{Table: testTable, Chain: testChain, Rule: "add rule inet inet-table test-chain counter jump istio-output"},
+		{Table: testTable, Chain: testChain, Rule: "insert rule inet inet-table test-chain index 0 iifname virt0 counter return", Reason: "redirect to the proxy"},
+		{
+			Table: testTable, Chain: "istio-output",
+			Rule: "add rule inet inet-table istio-output ip daddr 10.0.0.0/8 counter jump istio-redirect", Reason: "redirect to the proxy",
+		},
+	}
+	assert.Equal(t, nft.Explain(), want)
+	for _, rule := range nft.Rules[testTable] {
+		assert.Equal(t, rule.Comment, nil)
+	}
+}
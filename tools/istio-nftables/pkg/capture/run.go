@@ -25,6 +25,7 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/tools/common/config"
+	iptablesconstants "istio.io/istio/tools/istio-iptables/pkg/constants"
 	"istio.io/istio/tools/istio-nftables/pkg/builder"
 	"istio.io/istio/tools/istio-nftables/pkg/constants"
 )
@@ -102,6 +103,8 @@ func (cfg *NftablesConfigurator) handleInboundTProxyMode() {
 	// In the IstioInboundChain chain, 'jump IstioDivertChain' reroutes to the loopback
 	// interface.
 	// Mark all inbound packets.
+	cfg.ruleBuilder.WithReason("TPROXY inbound capture: mark inbound packets so they are routed to the loopback interface, " +
+		"then deliver them to the inbound capture port " + cfg.cfg.InboundCapturePort)
 	cfg.ruleBuilder.AppendRule(constants.IstioDivertChain, constants.IstioProxyMangleTable,
 		constants.Counter, "meta mark set", cfg.cfg.InboundTProxyMark)
 	cfg.ruleBuilder.AppendRule(constants.IstioDivertChain, constants.IstioProxyMangleTable, constants.Counter, "accept")
@@ -125,6 +128,7 @@ func (cfg *NftablesConfigurator) handleInboundTProxyMode() {
 		"accept")
 
 	// Add jump rule in prerouting chain
+	cfg.ruleBuilder.WithReason("Send inbound TCP traffic through " + constants.IstioInboundChain + " to decide whether it is captured")
 	cfg.ruleBuilder.AppendRule(constants.PreroutingChain, constants.IstioProxyMangleTable,
 		"meta l4proto tcp", constants.Counter,
 		"jump", constants.IstioInboundChain)
@@ -132,6 +136,7 @@ func (cfg *NftablesConfigurator) handleInboundTProxyMode() {
 	// Handle port exclusions if wildcard is specified
 	if cfg.cfg.InboundPortsInclude == "*" {
 		if cfg.cfg.InboundPortsExclude != "" {
+			cfg.ruleBuilder.WithReason("Inbound port is excluded from capture (--" + iptablesconstants.LocalExcludePorts + ")")
 			for _, port := range config.Split(cfg.cfg.InboundPortsExclude) {
 				cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyMangleTable,
 					"meta l4proto tcp",
@@ -139,6 +144,7 @@ func (cfg *NftablesConfigurator) handleInboundTProxyMode() {
 			}
 		}
 		// If an inbound packet belongs to an established socket, route it to the loopback interface.
+		cfg.ruleBuilder.WithReason("All inbound ports are captured (--" + iptablesconstants.InboundPorts + "=*), redirect to the proxy")
 		cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyMangleTable,
 			"meta l4proto tcp",
 			"ct state", "related,established", constants.Counter,
@@ -149,6 +155,7 @@ func (cfg *NftablesConfigurator) handleInboundTProxyMode() {
 			"jump", constants.IstioTproxyChain)
 	} else {
 		// User has specified a non-empty list of ports to be redirected to Envoy.
+		cfg.ruleBuilder.WithReason("Inbound port is explicitly captured (--" + iptablesconstants.InboundPorts + "), redirect to the proxy")
 		for _, port := range config.Split(cfg.cfg.InboundPortsInclude) {
 			cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyMangleTable,
 				"meta l4proto tcp",
@@ -175,6 +182,7 @@ func (cfg *NftablesConfigurator) handleInboundPortsInclude() {
 	}
 
 	// Handle NAT table redirection
+	cfg.ruleBuilder.WithReason("Send inbound TCP traffic through " + constants.IstioInboundChain + " to decide whether it is captured")
 	cfg.ruleBuilder.AppendRule(constants.PreroutingChain, constants.IstioProxyNatTable,
 		"meta l4proto tcp", constants.Counter,
 		"jump", constants.IstioInboundChain)
@@ -182,6 +190,7 @@ func (cfg *NftablesConfigurator) handleInboundPortsInclude() {
 	if cfg.cfg.InboundPortsInclude == "*" {
 		// Apply any user-specified port exclusions.
 		if cfg.cfg.InboundPortsExclude != "" {
+			cfg.ruleBuilder.WithReason("Inbound port is excluded from capture (--" + iptablesconstants.LocalExcludePorts + ")")
 			for _, port := range config.Split(cfg.cfg.InboundPortsExclude) {
 				cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyNatTable,
 					"meta l4proto tcp",
@@ -189,11 +198,13 @@ func (cfg *NftablesConfigurator) handleInboundPortsInclude() {
 			}
 		}
 		// Redirect remaining inbound traffic to Envoy.
+		cfg.ruleBuilder.WithReason("All inbound ports are captured (--" + iptablesconstants.InboundPorts + "=*), redirect to the proxy")
 		cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyNatTable,
 			"meta l4proto tcp", constants.Counter,
 			"jump", constants.IstioInRedirectChain)
 	} else {
 		// User has specified a non-empty list of ports to be redirected to Envoy.
+		cfg.ruleBuilder.WithReason("Inbound port is explicitly captured (--" + iptablesconstants.InboundPorts + "), redirect to the proxy")
 		for _, port := range config.Split(cfg.cfg.InboundPortsInclude) {
 			cfg.ruleBuilder.AppendRule(
 				constants.IstioInboundChain, constants.IstioProxyNatTable,
@@ -208,6 +219,8 @@ func (cfg *NftablesConfigurator) handleInboundPortsInclude() {
 func (cfg *NftablesConfigurator) handleOutboundIncludeRules(ipv4NwRange config.NetworkRange, ipv6NwRange config.NetworkRange) {
 	// Apply outbound IP inclusions.
 	if ipv4NwRange.IsWildcard || ipv6NwRange.IsWildcard {
+		cfg.ruleBuilder.WithReason("All outbound destinations are captured (--" + iptablesconstants.ServiceCidr + "=*), " +
+			"redirect remaining outbound traffic, and traffic from rerouted virtual interfaces, to the proxy")
 		cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable, constants.Counter, "jump", constants.IstioRedirectChain)
 		// Wildcard specified. Redirect all remaining outbound traffic to Envoy.
 		for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
@@ -216,6 +229,7 @@ func (cfg *NftablesConfigurator) handleOutboundIncludeRules(ipv4NwRange config.N
 		}
 	} else if len(ipv4NwRange.CIDRs) > 0 || len(ipv6NwRange.CIDRs) > 0 {
 		// User has specified a non-empty list of cidrs to be redirected to Envoy.
+		cfg.ruleBuilder.WithReason("Outbound destination is explicitly captured (--" + iptablesconstants.ServiceCidr + "), redirect to the proxy")
 		for _, cidr := range ipv4NwRange.CIDRs {
 			for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
 				cfg.ruleBuilder.InsertRule(constants.PreroutingChain, constants.IstioProxyNatTable, 0, "iifname", internalInterface,
@@ -238,6 +252,7 @@ func (cfg *NftablesConfigurator) handleOutboundIncludeRules(ipv4NwRange config.N
 
 // shortCircuitKubeInternalInterface adds a rule to skip traffic redirection for configured interfaces.
 func (cfg *NftablesConfigurator) shortCircuitKubeInternalInterface() {
+	cfg.ruleBuilder.WithReason("Traffic from a rerouted virtual interface (--" + iptablesconstants.RerouteVirtualInterfaces + ") is not captured as inbound")
 	for _, internalInterface := range config.Split(cfg.cfg.RerouteVirtualInterfaces) {
 		cfg.ruleBuilder.InsertRule(constants.PreroutingChain, constants.IstioProxyNatTable, 0, "iifname", internalInterface, constants.Counter, "return")
 	}
@@ -247,6 +262,7 @@ func (cfg *NftablesConfigurator) shortCircuitKubeInternalInterface() {
 // for the interfaces listed in ExcludeInterfaces. This is useful when you want to avoid capturing
 // traffic from specific network interfaces.
 func (cfg *NftablesConfigurator) shortCircuitExcludeInterfaces() {
+	cfg.ruleBuilder.WithReason("Interface is excluded from capture (--" + iptablesconstants.ExcludeInterfaces + ")")
 	for _, excludeInterface := range config.Split(cfg.cfg.ExcludeInterfaces) {
 		cfg.ruleBuilder.AppendRule(
 			constants.PreroutingChain, constants.IstioProxyNatTable, "iifname", excludeInterface, constants.Counter, "return")
@@ -266,20 +282,38 @@ func (cfg *NftablesConfigurator) shortCircuitExcludeInterfaces() {
 // It handles exclusion and inclusion logic for inbound and outbound traffic, DNS redirection,
 // owner-based filtering, TPROXY mark handling, and finally applies all the rules.
 func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
+	cfg.logConfig()
+	if err := cfg.buildRules(); err != nil {
+		return nil, err
+	}
+	return cfg.executeCommands()
+}
+
+// Explain builds the rules Run would apply, without applying them, and returns them along with the reason each
+// rule exists.
+func (cfg *NftablesConfigurator) Explain() ([]builder.ExplainedRule, error) {
+	cfg.ruleBuilder = builder.NewNftablesRuleBuilder(cfg.cfg)
+	if err := cfg.buildRules(); err != nil {
+		return nil, err
+	}
+	return cfg.ruleBuilder.Explain(), nil
+}
+
+func (cfg *NftablesConfigurator) buildRules() error {
 	// Since OUTBOUND_IP_RANGES_EXCLUDE could carry ipv4 and ipv6 ranges
 	// need to split them in different arrays one for ipv4 and one for ipv6
 	// in order to not to fail
 	ipv4RangesExclude, ipv6RangesExclude, err := config.SeparateV4V6(cfg.cfg.OutboundIPRangesExclude)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if ipv4RangesExclude.IsWildcard {
-		return nil, fmt.Errorf("invalid value for OUTBOUND_IP_RANGES_EXCLUDE")
+		return fmt.Errorf("invalid value for OUTBOUND_IP_RANGES_EXCLUDE")
 	}
 
 	ipv4RangesInclude, ipv6RangesInclude, err := config.SeparateV4V6(cfg.cfg.OutboundIPRangesInclude)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	redirectDNS := cfg.cfg.RedirectDNS
@@ -289,8 +323,6 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		redirectDNS = false
 	}
 
-	cfg.logConfig()
-
 	// Add rules to skip specific interfaces from redirection.
 	cfg.shortCircuitExcludeInterfaces()
 	cfg.shortCircuitKubeInternalInterface()
@@ -298,6 +330,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	// Create a rule for invalid drop in PREROUTING chain in mangle table, so the nftables will drop the out of window packets instead of reset connection .
 	dropInvalid := cfg.cfg.DropInvalid
 	if dropInvalid {
+		cfg.ruleBuilder.WithReason("Drop packets conntrack considers invalid (--" + iptablesconstants.DropInvalid + ") instead of resetting the connection")
 		cfg.ruleBuilder.AppendRule(constants.PreroutingChain, constants.IstioProxyMangleTable,
 			"meta l4proto tcp",
 			"ct state", "invalid", constants.Counter,
@@ -307,6 +340,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 
 	// Create a rule to directly route traffic to the tunnel port. Envoy will listen on port 15008, serving
 	// as an HBONE tunnel for Ambient traffic.
+	cfg.ruleBuilder.WithReason("Inbound traffic to the tunnel port " + cfg.cfg.InboundTunnelPort + " reaches the proxy directly")
 	cfg.ruleBuilder.AppendRule(constants.IstioInboundChain, constants.IstioProxyNatTable,
 		"meta l4proto tcp",
 		"tcp dport", cfg.cfg.InboundTunnelPort, constants.Counter,
@@ -315,6 +349,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	// Create a new chain for redirecting outbound traffic to the common Envoy port.
 	// In both chains, 'counter RETURN' bypasses Envoy and 'counter jump IstioRedirectChain'
 	// redirects to Envoy.
+	cfg.ruleBuilder.WithReason("Redirect captured outbound traffic to the proxy outbound port " + cfg.cfg.ProxyPort)
 	cfg.ruleBuilder.AppendRule(
 		constants.IstioRedirectChain, constants.IstioProxyNatTable,
 		"meta l4proto tcp", constants.Counter,
@@ -323,6 +358,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	// Use this chain also for redirecting inbound traffic to the common Envoy port
 	// when not using TPROXY.
 
+	cfg.ruleBuilder.WithReason("Redirect captured inbound traffic to the proxy inbound port " + cfg.cfg.InboundCapturePort)
 	cfg.ruleBuilder.AppendRule(constants.IstioInRedirectChain, constants.IstioProxyNatTable,
 		"meta l4proto tcp", constants.Counter,
 		"redirect to", ":"+cfg.cfg.InboundCapturePort)
@@ -331,10 +367,12 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	cfg.handleInboundPortsInclude()
 
 	// Send all output traffic to the output chain
+	cfg.ruleBuilder.WithReason("Send outbound traffic through " + constants.IstioOutputChain + " to decide whether it is captured")
 	cfg.ruleBuilder.AppendRule(constants.OutputChain, constants.IstioProxyNatTable, constants.Counter, "jump", constants.IstioOutputChain)
 
 	// Apply port based exclusions. Must be applied before connections back to self are redirected.
 	if cfg.cfg.OutboundPortsExclude != "" {
+		cfg.ruleBuilder.WithReason("Outbound port is excluded from capture (--" + iptablesconstants.LocalOutboundPortsExclude + ")")
 		for _, port := range config.Split(cfg.cfg.OutboundPortsExclude) {
 			cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable, "tcp dport", port, constants.Counter, "return")
 			cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable, "udp dport", port, constants.Counter, "return")
@@ -342,6 +380,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	}
 
 	// 127.0.0.6 and ::6 is bind connect from inbound passthrough cluster
+	cfg.ruleBuilder.WithReason("Traffic the proxy sends from 127.0.0.6/::6 is inbound passthrough to the app, do not capture it again")
 	cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable, "oifname", "lo", "ip saddr", "127.0.0.6/32", constants.Counter, "return")
 	cfg.ruleBuilder.AppendV6RuleIfSupported(constants.IstioOutputChain, constants.IstioProxyNatTable, "oifname", "lo", "ip6 saddr", "::6/128",
 		constants.Counter, "return")
@@ -350,6 +389,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		// Redirect app calls back to itself via Envoy when using the service VIP
 		// e.g. appN => Envoy (client) => Envoy (server) => appN.
 		// nolint: lll
+		cfg.ruleBuilder.WithReason("The proxy calling its own workload over loopback through a service VIP goes back through the proxy inbound")
 		if redirectDNS {
 			// When DNS is enabled, we skip this for port 53. This ensures we do not have:
 			// app => istio-agent => Envoy inbound => dns server
@@ -397,6 +437,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		// e.g. appN => appN by lo
 		// If loopback explicitly set via OutboundIPRangesInclude, then don't return.
 		if !ipv4RangesInclude.HasLoopBackIP && !ipv6RangesInclude.HasLoopBackIP {
+			cfg.ruleBuilder.WithReason("Loopback traffic from anything but the proxy is not captured")
 			if redirectDNS {
 				// Users may have a DNS server that is on localhost. In these cases, applications may
 				// send TCP traffic to the DNS server that we actually *do* want to intercept. To
@@ -422,6 +463,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		// Envoy for non-loopback traffic.
 		// Note that this rule is, unlike the others, protocol-independent - we want to unconditionally skip
 		// all UDP/TCP packets from Envoy, regardless of dest.
+		cfg.ruleBuilder.WithReason("Traffic sent by the proxy itself is never captured, to avoid loops")
 		cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
 			"skuid", uid,
 			constants.Counter, "return")
@@ -430,6 +472,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	for _, gid := range config.Split(cfg.cfg.ProxyGID) {
 		// Redirect app calls back to itself via Envoy when using the service VIP
 		// e.g. appN => Envoy (client) => Envoy (server) => appN.
+		cfg.ruleBuilder.WithReason("The proxy calling its own workload over loopback through a service VIP goes back through the proxy inbound")
 		cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
 			"oifname", "lo",
 			"meta l4proto tcp",
@@ -452,6 +495,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		// e.g. appN => appN by lo
 		// If loopback explicitly set via OutboundIPRangesInclude, then don't return.
 		if !ipv4RangesInclude.HasLoopBackIP && !ipv6RangesInclude.HasLoopBackIP {
+			cfg.ruleBuilder.WithReason("Loopback traffic from anything but the proxy is not captured")
 			if redirectDNS {
 				// Users may have a DNS server that is on localhost. In these cases, applications may
 				// send TCP traffic to the DNS server that we actually *do* want to intercept. To
@@ -478,6 +522,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		// Envoy for non-loopback traffic.
 		// Note that this rule is, unlike the others, protocol-independent - we want to unconditionally skip
 		// all UDP/TCP packets from Envoy, regardless of dest.
+		cfg.ruleBuilder.WithReason("Traffic sent by the proxy itself is never captured, to avoid loops")
 		cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
 			"skgid", gid,
 			constants.Counter,
@@ -498,6 +543,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	// Skip redirection for Envoy-aware applications and
 	// container-to-container traffic both of which explicitly use
 	// localhost.
+	cfg.ruleBuilder.WithReason("Traffic to localhost is not captured")
 	cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
 		"ip daddr", cfg.cfg.HostIPv4LoopbackCidr, constants.Counter, "return")
 
@@ -505,6 +551,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 		"ip6 daddr", "::1/128", constants.Counter, "return")
 
 	// Apply outbound IPv4 exclusions. Must be applied before inclusions.
+	cfg.ruleBuilder.WithReason("Outbound destination is excluded from capture (--" + iptablesconstants.ServiceExcludeCidr + ")")
 	for _, cidr := range ipv4RangesExclude.CIDRs {
 		cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
 			"ip daddr", cidr.String(),
@@ -523,6 +570,8 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 	cfg.handleOutboundIncludeRules(ipv4RangesInclude, ipv6RangesInclude)
 
 	if cfg.cfg.InboundInterceptionMode == "TPROXY" {
+		cfg.ruleBuilder.WithReason("TPROXY inbound capture: carry the proxy packet mark across the connection so replies " +
+			"are routed back through the proxy, and do not capture proxy-to-app traffic again")
 		// save packet mark set by envoy.filters.listener.original_src as connection mark
 		cfg.ruleBuilder.AppendRule(constants.PreroutingChain, constants.IstioProxyMangleTable,
 			"meta l4proto tcp",
@@ -601,7 +650,7 @@ func (cfg *NftablesConfigurator) Run() (*knftables.Transaction, error) {
 			"return")
 	}
 
-	return cfg.executeCommands()
+	return nil
 }
 
 // SetupDNSRedir is a helper function for supporting DNS redirection use-cases.
@@ -610,6 +659,7 @@ func (cfg *NftablesConfigurator) SetupDNSRedir(nft *builder.NftablesRuleBuilder,
 ) {
 	// Uniquely for DNS (at this time) we need a jump in "raw:OUTPUT", so this jump is conditional on that setting.
 	// And, unlike nat/OUTPUT, we have no shared rules, so no need to do a 2-level jump at this time
+	nft.WithReason("DNS capture is enabled, redirect DNS queries to the istio-agent DNS proxy on port " + constants.IstioAgentDNSListenerPort)
 	nft.AppendRule(constants.OutputChain, constants.IstioProxyRawTable, constants.Counter, "jump", constants.IstioOutputDNSChain)
 
 	// Conditionally insert jumps for V6 and V4 - we may have DNS capture enabled for V4 servers but not V6, or vice versa.
@@ -703,6 +753,7 @@ func (cfg *NftablesConfigurator) SetupDNSRedir(nft *builder.NftablesRuleBuilder,
 func (cfg *NftablesConfigurator) addDNSConntrackZones(
 	nft *builder.NftablesRuleBuilder, proxyUID, proxyGID string, dnsServersV4 []string, dnsServersV6 []string, captureAllDNS bool,
 ) {
+	nft.WithReason("Keep proxy-to-upstream and app-to-proxy DNS packets in separate conntrack zones, to avoid UDP conntrack races")
 	for _, uid := range config.Split(proxyUID) {
 		// Packets with dst port 53 from istio to zone 1. These are Istio calls to upstream resolvers
 		nft.AppendRule(constants.IstioOutputDNSChain, constants.IstioProxyRawTable,
@@ -788,6 +839,7 @@ func (cfg *NftablesConfigurator) addDNSConntrackZones(
 // This makes sure traffic to these ports gets redirected properly in the IstioProxyNatTable table.
 func (cfg *NftablesConfigurator) handleOutboundPortsInclude() {
 	if cfg.cfg.OutboundPortsInclude != "" {
+		cfg.ruleBuilder.WithReason("Outbound port is explicitly captured (--" + iptablesconstants.OutboundPorts + "), redirect to the proxy")
 		for _, port := range config.Split(cfg.cfg.OutboundPortsInclude) {
 			// For each port, add a rule to redirect TCP traffic on that port from the OUTPUT chain in the NAT table to the redirect chain
 			cfg.ruleBuilder.AppendRule(
@@ -798,6 +850,8 @@ func (cfg *NftablesConfigurator) handleOutboundPortsInclude() {
 
 // handleCaptureByOwnerGroup adds rules based on the socket owner group ID (skgid).
 func (cfg *NftablesConfigurator) handleCaptureByOwnerGroup(filter config.InterceptFilter) {
+	cfg.ruleBuilder.WithReason("Outbound traffic from this group is not captured (" +
+		constants.OwnerGroupsInclude.Name + "/" + constants.OwnerGroupsExclude.Name + ")")
 	if filter.Except {
 		for _, group := range filter.Values {
 			cfg.ruleBuilder.AppendRule(constants.IstioOutputChain, constants.IstioProxyNatTable,
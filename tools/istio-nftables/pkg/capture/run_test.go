@@ -304,3 +304,31 @@ func TestNftables(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	for _, tt := range getCommonTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := constructTestConfig()
+			tt.config(cfg)
+
+			nftProvider := func(_ knftables.Family, table string) (builder.NftablesAPI, error) {
+				t.Fatalf("explain must not apply anything, but opened table %s", table)
+				return nil, nil
+			}
+
+			nftConfigurator, _ := NewNftablesConfigurator(cfg, nftProvider)
+			rules, err := nftConfigurator.Explain()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) == 0 {
+				t.Fatal("expected rules to be explained")
+			}
+			for _, r := range rules {
+				if r.Reason == "" {
+					t.Errorf("rule %q has no reason", r.Rule)
+				}
+			}
+		})
+	}
+}